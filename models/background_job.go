@@ -0,0 +1,139 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/lindb/common/pkg/ltoml"
+	"github.com/lindb/common/pkg/timeutil"
+)
+
+// JobType represents the type of background maintenance job.
+type JobType string
+
+const (
+	JobTypeFlush      JobType = "flush"
+	JobTypeCompaction JobType = "compaction"
+)
+
+// JobState represents the state of background job.
+type JobState string
+
+const (
+	JobPending  JobState = "pending"
+	JobRunning  JobState = "running"
+	JobFinished JobState = "finished"
+	JobFailed   JobState = "failed"
+)
+
+// JobStatus represents the status of a background job(e.g. flush/compaction) of a shard.
+type JobStatus struct {
+	ID             string   `json:"id"`
+	Type           JobType  `json:"type"`
+	Node           string   `json:"node"`
+	Database       string   `json:"database"`
+	ShardID        int32    `json:"shardId"`
+	State          JobState `json:"state"`
+	Progress       float64  `json:"progress"` // percent in [0, 100]
+	TotalBytes     int64    `json:"totalBytes"`
+	ProcessedBytes int64    `json:"processedBytes"`
+	StartedAt      int64    `json:"startedAt"`        // in milliseconds, 0 if not started
+	FinishedAt     int64    `json:"finishedAt"`       // in milliseconds, 0 if not finished
+	Errors         []string `json:"errors,omitempty"` // errors of job, e.g. failed compaction of a family
+}
+
+// UpdateProgress updates the processed bytes and the progress percent, progress is 0 if total bytes is unknown.
+func (s *JobStatus) UpdateProgress(processedBytes int64) {
+	s.ProcessedBytes = processedBytes
+	s.Progress = 0
+	if s.TotalBytes > 0 {
+		s.Progress = float64(processedBytes) / float64(s.TotalBytes) * 100
+		if s.Progress > 100 {
+			s.Progress = 100
+		}
+	}
+}
+
+// Elapsed returns the elapsed time of job, until now if it's not finished.
+func (s *JobStatus) Elapsed(now int64) time.Duration {
+	if s.StartedAt <= 0 {
+		return 0
+	}
+	end := now
+	if s.FinishedAt > 0 {
+		end = s.FinishedAt
+	}
+	if end < s.StartedAt {
+		return 0
+	}
+	return time.Duration(end-s.StartedAt) * time.Millisecond
+}
+
+// JobStatusList represents the status list of background jobs.
+type JobStatusList []*JobStatus
+
+// Running returns the running jobs, sorted by started time.
+func (l JobStatusList) Running() JobStatusList {
+	var rs JobStatusList
+	for _, s := range l {
+		if s.State == JobRunning {
+			rs = append(rs, s)
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].StartedAt < rs[j].StartedAt
+	})
+	return rs
+}
+
+// ToTable returns job status list as table if it has value, else return empty string.
+func (l JobStatusList) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	now := timeutil.Now()
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{
+		"Type", "Node", "Database", "Shard", "State", "Progress", "Bytes", "Started At", "Elapsed", "Errors",
+	})
+	for _, s := range l {
+		startedAt := ""
+		if s.StartedAt > 0 {
+			startedAt = timeutil.FormatTimestamp(s.StartedAt, timeutil.DataTimeFormat2)
+		}
+		writer.AppendRow(table.Row{
+			s.Type,
+			s.Node,
+			s.Database,
+			s.ShardID,
+			s.State,
+			fmt.Sprintf("%.2f%%", s.Progress),
+			fmt.Sprintf("%s / %s", ltoml.Size(s.ProcessedBytes), ltoml.Size(s.TotalBytes)),
+			startedAt,
+			s.Elapsed(now).Round(time.Millisecond).String(),
+			strings.Join(s.Errors, "\n"),
+		})
+	}
+	return len(l), writer.Render()
+}
@@ -0,0 +1,74 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/encoding"
+)
+
+func TestJobStatus_Progress(t *testing.T) {
+	s := &JobStatus{TotalBytes: 200}
+	s.UpdateProgress(50)
+	assert.Equal(t, int64(50), s.ProcessedBytes)
+	assert.Equal(t, float64(25), s.Progress)
+	s.UpdateProgress(300)
+	assert.Equal(t, float64(100), s.Progress)
+	s = &JobStatus{}
+	s.UpdateProgress(300)
+	assert.Zero(t, s.Progress)
+}
+
+func TestJobStatus_Elapsed(t *testing.T) {
+	assert.Zero(t, (&JobStatus{}).Elapsed(1000))
+	assert.Equal(t, time.Second, (&JobStatus{StartedAt: 1000}).Elapsed(2000))
+	assert.Equal(t, 500*time.Millisecond, (&JobStatus{StartedAt: 1000, FinishedAt: 1500}).Elapsed(2000))
+	assert.Zero(t, (&JobStatus{StartedAt: 3000}).Elapsed(2000))
+}
+
+func TestJobStatusList(t *testing.T) {
+	rows, rs := JobStatusList{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, rs)
+
+	s1 := &JobStatus{ID: "1", Type: JobTypeCompaction, Node: "node-1", Database: "db", ShardID: 1, State: JobRunning,
+		TotalBytes: 1024 * 1024, StartedAt: 2000}
+	s1.UpdateProgress(512 * 1024)
+	s2 := &JobStatus{ID: "2", Type: JobTypeFlush, Node: "node-1", Database: "db", ShardID: 2, State: JobRunning, StartedAt: 1000}
+	s3 := &JobStatus{ID: "3", Type: JobTypeCompaction, Database: "db", ShardID: 3, State: JobFailed,
+		StartedAt: 1000, FinishedAt: 3000, Errors: []string{"compact family failure"}}
+	s4 := &JobStatus{ID: "4", Type: JobTypeFlush, Database: "db", ShardID: 3, State: JobPending}
+	list := JobStatusList{s1, s2, s3, s4}
+	assert.Equal(t, JobStatusList{s2, s1}, list.Running())
+
+	rows, rs = list.ToTable()
+	assert.Equal(t, 4, rows)
+	assert.Contains(t, rs, "50.00%")
+	assert.Contains(t, rs, "compact family failure")
+	assert.Contains(t, rs, "2s")
+
+	data := encoding.JSONMarshal(list)
+	var decoded JobStatusList
+	assert.NoError(t, encoding.JSONUnmarshal(data, &decoded))
+	assert.Equal(t, list, decoded)
+	assert.Contains(t, string(data), `"type":"compaction"`)
+}
@@ -0,0 +1,92 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// CheckStatus represents the status of diagnostic check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// colored returns the colored upper-case status for displaying in terminal.
+func (s CheckStatus) colored() string {
+	switch s {
+	case CheckPass:
+		return text.FgGreen.Sprint("PASS")
+	case CheckWarn:
+		return text.FgYellow.Sprint("WARN")
+	default:
+		return text.FgRed.Sprint("FAIL")
+	}
+}
+
+// CheckResult represents the result of a diagnostic check.
+type CheckResult struct {
+	Name    string      `json:"name"`
+	Status  CheckStatus `json:"status"`
+	Message string      `json:"message"`
+	Cost    int64       `json:"cost"` // in nanoseconds
+}
+
+// CheckResultList represents the results of all diagnostic checks.
+type CheckResultList []*CheckResult
+
+// Status returns the worst status of all checks.
+func (l CheckResultList) Status() CheckStatus {
+	status := CheckPass
+	for _, r := range l {
+		switch r.Status {
+		case CheckPass:
+		case CheckWarn:
+			if status == CheckPass {
+				status = CheckWarn
+			}
+		default:
+			return CheckFail
+		}
+	}
+	return status
+}
+
+// ToTable returns check results as table if it has value, else return empty string.
+func (l CheckResultList) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{"Check", "Status", "Cost", "Message"})
+	for _, r := range l {
+		writer.AppendRow(table.Row{
+			r.Name,
+			r.Status.colored(),
+			time.Duration(r.Cost).String(),
+			r.Message,
+		})
+	}
+	return len(l), writer.Render()
+}
@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatus_colored(t *testing.T) {
+	assert.Contains(t, CheckPass.colored(), "PASS")
+	assert.Contains(t, CheckWarn.colored(), "WARN")
+	assert.Contains(t, CheckFail.colored(), "FAIL")
+}
+
+func TestCheckResultList_Status(t *testing.T) {
+	assert.Equal(t, CheckPass, CheckResultList{}.Status())
+	assert.Equal(t, CheckPass, CheckResultList{{Status: CheckPass}}.Status())
+	assert.Equal(t, CheckWarn, CheckResultList{{Status: CheckWarn}, {Status: CheckPass}, {Status: CheckWarn}}.Status())
+	assert.Equal(t, CheckFail, CheckResultList{{Status: CheckWarn}, {Status: CheckFail}, {Status: CheckPass}}.Status())
+}
+
+func TestCheckResultList_ToTable(t *testing.T) {
+	rows, rs := CheckResultList{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, rs)
+
+	rows, rs = CheckResultList{
+		{Name: "ulimit", Status: CheckPass, Message: "open files: 65535"},
+		{Name: "clock drift", Status: CheckWarn, Message: "drift: 2s"},
+	}.ToTable()
+	assert.Equal(t, 2, rows)
+	assert.Contains(t, rs, "open files: 65535")
+}
@@ -0,0 +1,177 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/lindb/common/pkg/encoding"
+	"github.com/lindb/common/pkg/timeutil"
+)
+
+// EventType represents the type of activity event.
+type EventType string
+
+const (
+	EventCreate EventType = "create"
+	EventUpdate EventType = "update"
+	EventDelete EventType = "delete"
+	EventAction EventType = "action" // operation on resource, e.g. restart, trigger compaction
+)
+
+// DefaultEventFeedCapacity is the default number of recent events kept by event feed.
+const DefaultEventFeedCapacity = 1024
+
+// Event represents an activity/changelog event of console activity stream.
+type Event struct {
+	ID        uint64    `json:"id"` // monotonic sequence assigned by event feed
+	Type      EventType `json:"type"`
+	Actor     string    `json:"actor"`    // who triggers the event, e.g. user or node
+	Resource  string    `json:"resource"` // what is changed, e.g. database/db1
+	Message   string    `json:"message"`
+	Timestamp int64     `json:"timestamp"` // in milliseconds
+}
+
+// SSEFields returns the id/event/data fields of server-sent event, data is the event in json(single line),
+// so the client can resume the stream by Last-Event-ID.
+func (e *Event) SSEFields() (id, event, data string) {
+	return strconv.FormatUint(e.ID, 10), string(e.Type), string(encoding.JSONMarshal(e))
+}
+
+// Events represents the activity events.
+type Events []*Event
+
+// ToTable returns events as table if it has value, else return empty string.
+func (l Events) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{"Time", "Type", "Actor", "Resource", "Message"})
+	for _, e := range l {
+		writer.AppendRow(table.Row{
+			timeutil.FormatTimestamp(e.Timestamp, timeutil.DataTimeFormat2),
+			e.Type,
+			e.Actor,
+			e.Resource,
+			e.Message,
+		})
+	}
+	return len(l), writer.Render()
+}
+
+// EventFeed keeps the recent events in a ring buffer, the oldest event is overwritten if full.
+type EventFeed struct {
+	events []*Event
+	head   int // index of the oldest event
+	size   int
+	seq    uint64
+	notify chan struct{}
+
+	lock sync.RWMutex
+}
+
+// NewEventFeed creates an event feed with capacity(DefaultEventFeedCapacity if <= 0).
+func NewEventFeed(capacity int) *EventFeed {
+	if capacity <= 0 {
+		capacity = DefaultEventFeedCapacity
+	}
+	return &EventFeed{
+		events: make([]*Event, capacity),
+		notify: make(chan struct{}),
+	}
+}
+
+// Append appends the event, assigns the id and the timestamp(now if not set), then notifies the waiters.
+func (f *EventFeed) Append(event *Event) *Event {
+	if event.Timestamp <= 0 {
+		event.Timestamp = timeutil.Now()
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.seq++
+	event.ID = f.seq
+	if f.size < len(f.events) {
+		f.events[(f.head+f.size)%len(f.events)] = event
+		f.size++
+	} else {
+		f.events[f.head] = event
+		f.head = (f.head + 1) % len(f.events)
+	}
+	close(f.notify)
+	f.notify = make(chan struct{})
+	return event
+}
+
+// Len returns the number of events kept.
+func (f *EventFeed) Len() int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.size
+}
+
+// Recent returns at most n recent events(all if n <= 0), oldest first.
+func (f *EventFeed) Recent(n int) Events {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if n <= 0 || n > f.size {
+		n = f.size
+	}
+	return f.copyEvents(f.size-n, f.size)
+}
+
+// Since returns the events after the id(e.g. Last-Event-ID of SSE), oldest first,
+// the events overwritten are missed.
+func (f *EventFeed) Since(id uint64) Events {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.size == 0 || id >= f.seq {
+		return nil
+	}
+	// ids are continuous in buffer
+	oldest := f.events[f.head].ID
+	start := 0
+	if id >= oldest {
+		start = int(id - oldest + 1)
+	}
+	return f.copyEvents(start, f.size)
+}
+
+// Notify returns the channel which is closed when a new event is appended.
+func (f *EventFeed) Notify() <-chan struct{} {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.notify
+}
+
+// copyEvents returns the events in [from, to) of buffer order, must hold the lock.
+func (f *EventFeed) copyEvents(from, to int) Events {
+	rs := make(Events, 0, to-from)
+	for i := from; i < to; i++ {
+		rs = append(rs, f.events[(f.head+i)%len(f.events)])
+	}
+	return rs
+}
@@ -0,0 +1,124 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/encoding"
+)
+
+func TestEvent_SSEFields(t *testing.T) {
+	e := &Event{ID: 10, Type: EventCreate, Actor: "admin", Resource: "database/db1", Message: "create database\nwith 3 shards",
+		Timestamp: 1000}
+	id, event, data := e.SSEFields()
+	assert.Equal(t, "10", id)
+	assert.Equal(t, "create", event)
+	assert.NotContains(t, data, "\n")
+	decoded := &Event{}
+	assert.NoError(t, encoding.JSONUnmarshal([]byte(data), decoded))
+	assert.Equal(t, e, decoded)
+}
+
+func TestEvents_ToTable(t *testing.T) {
+	rows, rs := Events{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, rs)
+
+	events := Events{
+		{ID: 1, Type: EventCreate, Actor: "admin", Resource: "database/db1", Message: "create database", Timestamp: 1000},
+		{ID: 2, Type: EventAction, Actor: "node-1", Resource: "shard/db1/1", Message: "compaction", Timestamp: 2000},
+	}
+	rows, rs = events.ToTable()
+	assert.Equal(t, 2, rows)
+	assert.Contains(t, rs, "database/db1")
+	assert.Contains(t, rs, "compaction")
+
+	data := encoding.JSONMarshal(events)
+	var decoded Events
+	assert.NoError(t, encoding.JSONUnmarshal(data, &decoded))
+	assert.Equal(t, events, decoded)
+}
+
+func TestEventFeed(t *testing.T) {
+	f := NewEventFeed(3)
+	assert.Zero(t, f.Len())
+	assert.Empty(t, f.Recent(0))
+	assert.Empty(t, f.Since(0))
+
+	notify := f.Notify()
+	e := f.Append(&Event{Type: EventCreate, Message: "1"})
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Positive(t, e.Timestamp)
+	select {
+	case <-notify:
+	default:
+		t.Fatal("not notified")
+	}
+	assert.NotEqual(t, notify, f.Notify())
+
+	for i := 2; i <= 5; i++ {
+		f.Append(&Event{Type: EventUpdate, Message: fmt.Sprint(i), Timestamp: int64(i)})
+	}
+	messages := func(events Events) string {
+		var rs []string
+		for _, e := range events {
+			rs = append(rs, e.Message)
+		}
+		return strings.Join(rs, ",")
+	}
+	assert.Equal(t, 3, f.Len())
+	assert.Equal(t, "3,4,5", messages(f.Recent(0)))
+	assert.Equal(t, "4,5", messages(f.Recent(2)))
+	assert.Equal(t, "3,4,5", messages(f.Recent(10)))
+	assert.Equal(t, "3,4,5", messages(f.Since(0)))
+	assert.Equal(t, "3,4,5", messages(f.Since(2)))
+	assert.Equal(t, "5", messages(f.Since(4)))
+	assert.Empty(t, f.Since(5))
+	assert.Empty(t, f.Since(6))
+
+	f = NewEventFeed(0)
+	assert.Len(t, f.events, DefaultEventFeedCapacity)
+}
+
+func TestEventFeed_Concurrent(t *testing.T) {
+	f := NewEventFeed(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				f.Append(&Event{Type: EventAction})
+				_ = f.Since(uint64(j))
+			}
+		}()
+	}
+	wg.Wait()
+	events := f.Recent(0)
+	assert.Len(t, events, 100)
+	for i := 1; i < len(events); i++ {
+		assert.Equal(t, events[i-1].ID+1, events[i].ID)
+	}
+	assert.Equal(t, uint64(400), events[99].ID)
+}
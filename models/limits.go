@@ -0,0 +1,209 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/lindb/common/pkg/timeutil"
+)
+
+const (
+	// LimitMaxSeries is the limit of total series.
+	LimitMaxSeries = "maxSeries"
+	// LimitMaxDatabases is the limit of databases.
+	LimitMaxDatabases = "maxDatabases"
+	// LimitMaxQPS is the limit of requests per second.
+	LimitMaxQPS = "maxQPS"
+)
+
+// for testing
+var limitNowFunc = timeutil.Now
+
+// Limits represents the soft limits of license, 0 means unlimited.
+type Limits struct {
+	MaxSeries    int64 `json:"maxSeries" toml:"max-series"`
+	MaxDatabases int64 `json:"maxDatabases" toml:"max-databases"`
+	MaxQPS       int64 `json:"maxQPS" toml:"max-qps"`
+}
+
+// Max returns the max value of limit by name, 0 means unlimited or unknown limit.
+func (l *Limits) Max(name string) int64 {
+	switch name {
+	case LimitMaxSeries:
+		return l.MaxSeries
+	case LimitMaxDatabases:
+		return l.MaxDatabases
+	case LimitMaxQPS:
+		return l.MaxQPS
+	default:
+		return 0
+	}
+}
+
+// LimitExceededError represents the error of limit exceeded, which is returned by LimitChecker.
+type LimitExceededError struct {
+	Name  string `json:"name"`
+	Max   int64  `json:"max"`
+	Used  int64  `json:"used"`
+	Delta int64  `json:"delta"` // the amount requested
+}
+
+// Error returns the message of limit exceeded.
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s, max: %d, used: %d, requested: %d", e.Name, e.Max, e.Used, e.Delta)
+}
+
+// IsLimitExceeded returns the limit exceeded error if err(or wrapped error) is, else returns false.
+func IsLimitExceeded(err error) (*LimitExceededError, bool) {
+	var e *LimitExceededError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// LimitUsage represents the usage of a limit.
+type LimitUsage struct {
+	Name string `json:"name"`
+	Max  int64  `json:"max"` // 0 means unlimited
+	Used int64  `json:"used"`
+}
+
+// Exceeded returns if the usage reaches the limit.
+func (u *LimitUsage) Exceeded() bool {
+	return u.Max > 0 && u.Used >= u.Max
+}
+
+// LimitUsages represents the usages of all limits.
+type LimitUsages []LimitUsage
+
+// ToTable returns limit usages as table if it has value, else return empty string.
+func (l LimitUsages) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{"Limit", "Max", "Used", "Usage", "Status"})
+	for i := range l {
+		u := &l[i]
+		maxValue, usage, status := "unlimited", "-", text.FgGreen.Sprint("OK")
+		if u.Max > 0 {
+			maxValue = strconv.FormatInt(u.Max, 10)
+			usage = formatPercent(float64(u.Used) / float64(u.Max))
+		}
+		if u.Exceeded() {
+			status = text.FgRed.Sprint("EXCEEDED")
+		}
+		writer.AppendRow(table.Row{u.Name, maxValue, u.Used, usage, status})
+	}
+	return len(l), writer.Render()
+}
+
+// LimitChecker checks the limits, which is consulted by middleware(e.g. qps) and ingestion paths(e.g. new series).
+type LimitChecker interface {
+	// Check returns LimitExceededError if the usage of limit exceeds the max after adding delta,
+	// the delta is counted if the limit is tracked by checker(e.g. qps) and not exceeded.
+	Check(name string, delta int64) error
+	// Usages returns the usages of all limits.
+	Usages() LimitUsages
+}
+
+// UsageFunc returns the current usage of limit, e.g. the number of series/databases from metadata.
+type UsageFunc func(name string) int64
+
+// limitChecker implements LimitChecker, qps is tracked by checker in a fixed one second window,
+// the usages of other limits are provided by usage func.
+type limitChecker struct {
+	limits Limits
+	usage  UsageFunc
+
+	mutex     sync.Mutex
+	qpsSecond int64
+	qpsCount  int64
+}
+
+// NewLimitChecker creates a limit checker, usage provides the usages of series/databases(0 if nil).
+func NewLimitChecker(limits Limits, usage UsageFunc) LimitChecker {
+	return &limitChecker{limits: limits, usage: usage}
+}
+
+// Check returns LimitExceededError if the usage of limit exceeds the max after adding delta.
+func (c *limitChecker) Check(name string, delta int64) error {
+	maxValue := c.limits.Max(name)
+	if name == LimitMaxQPS {
+		return c.checkQPS(maxValue, delta)
+	}
+	if maxValue <= 0 {
+		return nil
+	}
+	if used := c.used(name); used+delta > maxValue {
+		return &LimitExceededError{Name: name, Max: maxValue, Used: used, Delta: delta}
+	}
+	return nil
+}
+
+// checkQPS counts the requests in current second, the requests exceeding the limit aren't counted.
+func (c *limitChecker) checkQPS(maxValue, delta int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.rollQPS()
+	if maxValue > 0 && c.qpsCount+delta > maxValue {
+		return &LimitExceededError{Name: LimitMaxQPS, Max: maxValue, Used: c.qpsCount, Delta: delta}
+	}
+	c.qpsCount += delta
+	return nil
+}
+
+// rollQPS resets the counter if entering next second.
+func (c *limitChecker) rollQPS() {
+	second := limitNowFunc() / 1000
+	if second != c.qpsSecond {
+		c.qpsSecond = second
+		c.qpsCount = 0
+	}
+}
+
+// used returns the usage of limit from usage func.
+func (c *limitChecker) used(name string) int64 {
+	if c.usage == nil {
+		return 0
+	}
+	return c.usage(name)
+}
+
+// Usages returns the usages of series/databases/qps.
+func (c *limitChecker) Usages() LimitUsages {
+	c.mutex.Lock()
+	c.rollQPS()
+	qps := c.qpsCount
+	c.mutex.Unlock()
+
+	return LimitUsages{
+		{Name: LimitMaxSeries, Max: c.limits.MaxSeries, Used: c.used(LimitMaxSeries)},
+		{Name: LimitMaxDatabases, Max: c.limits.MaxDatabases, Used: c.used(LimitMaxDatabases)},
+		{Name: LimitMaxQPS, Max: c.limits.MaxQPS, Used: qps},
+	}
+}
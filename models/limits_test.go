@@ -0,0 +1,94 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/timeutil"
+)
+
+func TestLimitChecker_Check(t *testing.T) {
+	usages := map[string]int64{LimitMaxSeries: 90, LimitMaxDatabases: 3}
+	checker := NewLimitChecker(Limits{MaxSeries: 100, MaxDatabases: 3}, func(name string) int64 {
+		return usages[name]
+	})
+	assert.NoError(t, checker.Check(LimitMaxSeries, 10))
+	err := checker.Check(LimitMaxSeries, 11)
+	assert.EqualError(t, err, "limit exceeded: maxSeries, max: 100, used: 90, requested: 11")
+	e, ok := IsLimitExceeded(fmt.Errorf("write failure: %w", err))
+	assert.True(t, ok)
+	assert.Equal(t, &LimitExceededError{Name: LimitMaxSeries, Max: 100, Used: 90, Delta: 11}, e)
+	assert.Error(t, checker.Check(LimitMaxDatabases, 1))
+	// unlimited
+	assert.NoError(t, checker.Check(LimitMaxQPS, 1000))
+	assert.NoError(t, checker.Check("unknown", 1000))
+	_, ok = IsLimitExceeded(fmt.Errorf("err"))
+	assert.False(t, ok)
+
+	checker = NewLimitChecker(Limits{MaxSeries: 100}, nil)
+	assert.NoError(t, checker.Check(LimitMaxSeries, 100))
+	assert.Error(t, checker.Check(LimitMaxSeries, 101))
+}
+
+func TestLimitChecker_QPS(t *testing.T) {
+	defer func() {
+		limitNowFunc = timeutil.Now
+	}()
+	now := int64(10_000)
+	limitNowFunc = func() int64 { return now }
+	checker := NewLimitChecker(Limits{MaxQPS: 3}, nil)
+	assert.NoError(t, checker.Check(LimitMaxQPS, 1))
+	assert.NoError(t, checker.Check(LimitMaxQPS, 2))
+	err := checker.Check(LimitMaxQPS, 1)
+	e, ok := IsLimitExceeded(err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), e.Used)
+	assert.Equal(t, int64(3), checker.Usages()[2].Used)
+
+	// next second
+	now += 1000
+	assert.Equal(t, int64(0), checker.Usages()[2].Used)
+	assert.NoError(t, checker.Check(LimitMaxQPS, 3))
+}
+
+func TestLimitUsages_ToTable(t *testing.T) {
+	rows, tableStr := LimitUsages{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, tableStr)
+
+	checker := NewLimitChecker(Limits{MaxSeries: 100, MaxDatabases: 2}, func(name string) int64 {
+		if name == LimitMaxSeries {
+			return 50
+		}
+		return 2
+	})
+	usages := checker.Usages()
+	assert.False(t, usages[0].Exceeded())
+	assert.True(t, usages[1].Exceeded())
+	assert.False(t, usages[2].Exceeded())
+	rows, tableStr = usages.ToTable()
+	assert.Equal(t, 3, rows)
+	assert.Contains(t, tableStr, "maxSeries")
+	assert.Contains(t, tableStr, "50.000%")
+	assert.Contains(t, tableStr, "EXCEEDED")
+	assert.Contains(t, tableStr, "unlimited")
+}
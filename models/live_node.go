@@ -0,0 +1,85 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/lindb/common/pkg/timeutil"
+)
+
+// LiveNode represents the ephemeral registration of node, which exists while the node keeps heartbeat.
+type LiveNode struct {
+	ID         string `json:"id"`
+	Role       string `json:"role"`
+	HostIP     string `json:"hostIp"`
+	HostName   string `json:"hostName"`
+	GRPCPort   uint16 `json:"grpcPort"`
+	HTTPPort   uint16 `json:"httpPort"`
+	Version    string `json:"version"`
+	OnlineTime int64  `json:"onlineTime"`
+}
+
+// Address returns the grpc address of node.
+func (n *LiveNode) Address() string {
+	return net.JoinHostPort(n.HostIP, strconv.Itoa(int(n.GRPCPort)))
+}
+
+// Uptime returns the duration since node online.
+func (n *LiveNode) Uptime(now int64) time.Duration {
+	if n.OnlineTime <= 0 || now < n.OnlineTime {
+		return 0
+	}
+	return time.Duration(now-n.OnlineTime) * time.Millisecond
+}
+
+// LiveNodes represents the live node list.
+type LiveNodes []LiveNode
+
+// ToTable returns live node list as table if it has value, else return empty string.
+func (l LiveNodes) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	now := timeutil.Now()
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{"ID", "Role", "Host IP", "Host Name", "GRPC", "HTTP", "Version", "Online Time", "Uptime"})
+	for i := range l {
+		n := &l[i]
+		onlineTime := ""
+		if n.OnlineTime > 0 {
+			onlineTime = timeutil.FormatTimestamp(n.OnlineTime, timeutil.DataTimeFormat2)
+		}
+		writer.AppendRow(table.Row{
+			n.ID,
+			n.Role,
+			n.HostIP,
+			n.HostName,
+			n.GRPCPort,
+			n.HTTPPort,
+			n.Version,
+			onlineTime,
+			n.Uptime(now).Round(time.Second).String(),
+		})
+	}
+	return len(l), writer.Render()
+}
@@ -0,0 +1,59 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/encoding"
+)
+
+func TestLiveNode(t *testing.T) {
+	n := &LiveNode{HostIP: "1.1.1.1", GRPCPort: 2891, OnlineTime: 1000}
+	assert.Equal(t, "1.1.1.1:2891", n.Address())
+	n.HostIP = "::1"
+	assert.Equal(t, "[::1]:2891", n.Address())
+	assert.Equal(t, time.Second, n.Uptime(2000))
+	assert.Zero(t, n.Uptime(500))
+	assert.Zero(t, (&LiveNode{}).Uptime(2000))
+}
+
+func TestLiveNodes(t *testing.T) {
+	rows, rs := LiveNodes{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, rs)
+
+	nodes := LiveNodes{
+		{ID: "1", Role: "broker", HostIP: "1.1.1.1", HostName: "host-1", GRPCPort: 2891, HTTPPort: 9000,
+			Version: "v2.0.0", OnlineTime: 1000},
+		{ID: "2", Role: "storage", HostIP: "1.1.1.2", HostName: "host-2", GRPCPort: 2892, HTTPPort: 9001},
+	}
+	rows, rs = nodes.ToTable()
+	assert.Equal(t, 2, rows)
+	assert.Contains(t, rs, "host-1")
+	assert.Contains(t, rs, "storage")
+
+	data := encoding.JSONMarshal(nodes)
+	var decoded LiveNodes
+	assert.NoError(t, encoding.JSONUnmarshal(data, &decoded))
+	assert.Equal(t, nodes, decoded)
+	assert.Contains(t, string(data), `"grpcPort":2891`)
+}
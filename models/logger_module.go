@@ -0,0 +1,68 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// LoggerModule represents a logger module with effective level and output targets.
+type LoggerModule struct {
+	Module string   `json:"module"`
+	Roles  []string `json:"roles,omitempty"`
+	Level  string   `json:"level"`
+	Sinks  []string `json:"sinks,omitempty"`
+}
+
+// LoggerLevel represents the logging level of logger module, the global level if module is empty.
+type LoggerLevel struct {
+	Module     string `json:"module,omitempty"`
+	Level      string `json:"level"`
+	Overridden bool   `json:"overridden,omitempty"` // level is overridden for module
+}
+
+// LoggerModules represents all registered logger modules.
+type LoggerModules []*LoggerModule
+
+// ToTable returns logger modules as table if it has value, else return empty string.
+func (l LoggerModules) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{"Module", "Roles", "Level", "Sinks"})
+	for _, m := range l {
+		writer.AppendRow(table.Row{
+			m.Module,
+			joinOrDash(m.Roles),
+			m.Level,
+			joinOrDash(m.Sinks),
+		})
+	}
+	return len(l), writer.Render()
+}
+
+// joinOrDash joins values by comma, returns "-" if empty.
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
@@ -18,6 +18,8 @@
 package models
 
 import (
+	"io"
+
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
@@ -71,6 +73,82 @@ func (m *Metadata) toTableForMapValues(header table.Row, cols []string, writer t
 	return len(values), writer.Render()
 }
 
+// MetadataTableWriter writes metadata values as table into writer incrementally, for large result set.
+// Values are appended one by one(e.g. while decoding the response by json.Decoder), so the whole list
+// isn't materialized in memory.
+type MetadataTableWriter struct {
+	writer *TableStreamWriter
+	cols   []string
+}
+
+// NewMetadataTableWriter creates a metadata table writer for the type of metadata,
+// the values of unknown type are ignored and nothing is written.
+func NewMetadataTableWriter(w io.Writer, metadataType string) *MetadataTableWriter {
+	var (
+		header []string
+		cols   []string
+	)
+	switch metadataType {
+	case "namespace":
+		header = []string{"Namespace"}
+	case "metric":
+		header = []string{"Metric"}
+	case "tagKey":
+		header = []string{"Tag Key"}
+	case "tagValue":
+		header = []string{"Tag Value"}
+	case "field":
+		header = []string{"Name", "Type"}
+		cols = []string{"name", "type"}
+	default:
+		return &MetadataTableWriter{}
+	}
+	return &MetadataTableWriter{
+		writer: NewTableStreamWriter(w, 0, header...),
+		cols:   cols,
+	}
+}
+
+// AppendValue appends a metadata value as a row,
+// value is string for namespace/metric/tagKey/tagValue, Field or map[string]interface{} for field.
+func (mw *MetadataTableWriter) AppendValue(value any) error {
+	if mw.writer == nil {
+		return nil
+	}
+	if mw.cols == nil {
+		return mw.writer.AppendRow(value)
+	}
+	switch v := value.(type) {
+	case Field:
+		return mw.writer.AppendRow(v.Name, v.Type)
+	case *Field:
+		return mw.writer.AppendRow(v.Name, v.Type)
+	default:
+		mapValue, _ := value.(map[string]interface{})
+		row := make([]any, len(mw.cols))
+		for idx, col := range mw.cols {
+			row[idx] = mapValue[col]
+		}
+		return mw.writer.AppendRow(row...)
+	}
+}
+
+// Rows returns the number of appended values.
+func (mw *MetadataTableWriter) Rows() int {
+	if mw.writer == nil {
+		return 0
+	}
+	return mw.writer.Rows()
+}
+
+// Close renders the buffered rows, then flushes the writer.
+func (mw *MetadataTableWriter) Close() error {
+	if mw.writer == nil {
+		return nil
+	}
+	return mw.writer.Close()
+}
+
 // Field represents field metadata
 type Field struct {
 	Name string `json:"name"`
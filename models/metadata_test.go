@@ -18,6 +18,9 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -47,3 +50,62 @@ func TestMetadata_ToTable(t *testing.T) {
 	assert.Equal(t, rows, 1)
 	assert.NotEmpty(t, rs)
 }
+
+func TestMetadataTableWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := NewMetadataTableWriter(buf, "unknown")
+	assert.NoError(t, writer.AppendValue("name"))
+	assert.NoError(t, writer.Close())
+	assert.Zero(t, writer.Rows())
+	assert.Empty(t, buf.String())
+
+	for _, typ := range []string{"namespace", "metric", "tagKey", "tagValue"} {
+		buf.Reset()
+		writer = NewMetadataTableWriter(buf, typ)
+		assert.NoError(t, writer.AppendValue("name"))
+		assert.NoError(t, writer.Close())
+		assert.Equal(t, 1, writer.Rows())
+		_, tableStr := (&Metadata{Type: typ, Values: []interface{}{"name"}}).ToTable()
+		assert.Equal(t, tableStr+"\n", buf.String())
+	}
+	m := &Metadata{
+		Type: "field",
+		Values: []interface{}{
+			map[string]interface{}{"name": "n", "type": "sum"},
+			map[string]interface{}{"name": "m", "type": "last"},
+			map[string]interface{}{"name": "h", "type": "histogram"},
+		},
+	}
+	_, tableStr := m.ToTable()
+	buf.Reset()
+	writer = NewMetadataTableWriter(buf, "field")
+	assert.NoError(t, writer.AppendValue(m.Values.([]interface{})[0]))
+	assert.NoError(t, writer.AppendValue(Field{Name: "m", Type: "last"}))
+	assert.NoError(t, writer.AppendValue(&Field{Name: "h", Type: "histogram"}))
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, 3, writer.Rows())
+	assert.Equal(t, tableStr+"\n", buf.String())
+}
+
+func TestMetadataTableWriter_JSONStream(t *testing.T) {
+	// values are decoded and appended one by one
+	decoder := json.NewDecoder(strings.NewReader(`["a","b","c"]`))
+	_, err := decoder.Token()
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	writer := NewMetadataTableWriter(buf, "metric")
+	for decoder.More() {
+		var value string
+		assert.NoError(t, decoder.Decode(&value))
+		assert.NoError(t, writer.AppendValue(value))
+	}
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, 3, writer.Rows())
+	_, tableStr := (&Metadata{Type: "metric", Values: []interface{}{"a", "b", "c"}}).ToTable()
+	assert.Equal(t, tableStr+"\n", buf.String())
+
+	writer = NewMetadataTableWriter(&errWriter{}, "metric")
+	assert.NoError(t, writer.AppendValue("name"))
+	assert.Error(t, writer.Close())
+	assert.Equal(t, 1, writer.Rows())
+}
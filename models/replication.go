@@ -0,0 +1,125 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// ReplicaState represents the consume/ack progress of a replica(follower) for a replication channel.
+type ReplicaState struct {
+	Replica string `json:"replica"` // replica node
+	Consume int64  `json:"consume"` // sequence consumed(sent) by replicator
+	Ack     int64  `json:"ack"`     // sequence acknowledged by replica
+}
+
+// ReplicationState represents the wal replication state of a channel(database/shard/family) on leader.
+type ReplicationState struct {
+	Database   string          `json:"database"`
+	ShardID    int32           `json:"shardId"`
+	FamilyTime string          `json:"familyTime"`
+	Leader     string          `json:"leader"`
+	Append     int64           `json:"append"` // sequence appended into wal by leader
+	Replicas   []*ReplicaState `json:"replicas"`
+}
+
+// ConsumeLag returns the number of wal entries which are not consumed by the replica.
+func (s *ReplicationState) ConsumeLag(replica *ReplicaState) int64 {
+	return nonNegative(s.Append - replica.Consume)
+}
+
+// AckLag returns the number of wal entries which are not acknowledged by the replica.
+func (s *ReplicationState) AckLag(replica *ReplicaState) int64 {
+	return nonNegative(s.Append - replica.Ack)
+}
+
+// MaxAckLag returns the max ack lag of all replicas.
+func (s *ReplicationState) MaxAckLag() int64 {
+	var lag int64
+	for _, replica := range s.Replicas {
+		if l := s.AckLag(replica); l > lag {
+			lag = l
+		}
+	}
+	return lag
+}
+
+// ReplicationStateList represents the replication state list of all channels.
+type ReplicationStateList []*ReplicationState
+
+// MaxAckLag returns the max ack lag of all channels.
+func (l ReplicationStateList) MaxAckLag() int64 {
+	var lag int64
+	for _, s := range l {
+		if channelLag := s.MaxAckLag(); channelLag > lag {
+			lag = channelLag
+		}
+	}
+	return lag
+}
+
+// Lagging returns the channels whose max ack lag > threshold, sorted by lag desc.
+func (l ReplicationStateList) Lagging(threshold int64) ReplicationStateList {
+	var rs ReplicationStateList
+	for _, s := range l {
+		if s.MaxAckLag() > threshold {
+			rs = append(rs, s)
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].MaxAckLag() > rs[j].MaxAckLag()
+	})
+	return rs
+}
+
+// ToTable returns replication state list as table if it has value, else return empty string.
+func (l ReplicationStateList) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{
+		"Database", "Shard", "Family", "Leader", "Replica",
+		"Append", "Consume", "Ack", "Consume Lag", "Ack Lag",
+	})
+	for _, s := range l {
+		if len(s.Replicas) == 0 {
+			writer.AppendRow(table.Row{s.Database, s.ShardID, s.FamilyTime, s.Leader, "", s.Append, "", "", "", ""})
+			rows++
+			continue
+		}
+		for _, replica := range s.Replicas {
+			writer.AppendRow(table.Row{
+				s.Database, s.ShardID, s.FamilyTime, s.Leader, replica.Replica,
+				s.Append, replica.Consume, replica.Ack, s.ConsumeLag(replica), s.AckLag(replica),
+			})
+			rows++
+		}
+	}
+	return rows, writer.Render()
+}
+
+// nonNegative returns 0 if value < 0(e.g. replica is ahead after leader changed).
+func nonNegative(value int64) int64 {
+	if value < 0 {
+		return 0
+	}
+	return value
+}
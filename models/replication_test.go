@@ -0,0 +1,58 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicationState_Lag(t *testing.T) {
+	s := &ReplicationState{Append: 100, Replicas: []*ReplicaState{
+		{Replica: "node-2", Consume: 90, Ack: 80},
+		{Replica: "node-3", Consume: 110, Ack: 105},
+	}}
+	assert.Equal(t, int64(10), s.ConsumeLag(s.Replicas[0]))
+	assert.Equal(t, int64(20), s.AckLag(s.Replicas[0]))
+	assert.Zero(t, s.ConsumeLag(s.Replicas[1]))
+	assert.Zero(t, s.AckLag(s.Replicas[1]))
+	assert.Equal(t, int64(20), s.MaxAckLag())
+	assert.Zero(t, (&ReplicationState{Append: 10}).MaxAckLag())
+}
+
+func TestReplicationStateList(t *testing.T) {
+	rows, rs := ReplicationStateList{}.ToTable()
+	assert.Zero(t, rows)
+	assert.Empty(t, rs)
+
+	s1 := &ReplicationState{Database: "db", ShardID: 1, FamilyTime: "20231010", Leader: "node-1", Append: 100,
+		Replicas: []*ReplicaState{{Replica: "node-2", Consume: 90, Ack: 80}, {Replica: "node-3", Consume: 100, Ack: 100}}}
+	s2 := &ReplicationState{Database: "db", ShardID: 2, Leader: "node-2", Append: 100,
+		Replicas: []*ReplicaState{{Replica: "node-1", Consume: 60, Ack: 50}}}
+	s3 := &ReplicationState{Database: "db", ShardID: 3, Leader: "node-3", Append: 10}
+	list := ReplicationStateList{s1, s2, s3}
+	assert.Equal(t, int64(50), list.MaxAckLag())
+	assert.Equal(t, ReplicationStateList{s2, s1}, list.Lagging(10))
+	assert.Empty(t, list.Lagging(50))
+
+	rows, rs = list.ToTable()
+	assert.Equal(t, 4, rows)
+	assert.Contains(t, rs, "node-3")
+	assert.Contains(t, rs, "20231010")
+}
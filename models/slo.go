@@ -0,0 +1,81 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// SLOStatus represents the service level objective status of a http route in a rolling window.
+type SLOStatus struct {
+	Route  string `json:"route"`
+	Window int64  `json:"window"` // rolling window in nanoseconds
+
+	Total  uint64 `json:"total"`
+	Failed uint64 `json:"failed"` // requests with 5xx status code
+	Slow   uint64 `json:"slow"`   // requests slower than latency threshold
+
+	AvailabilityObjective float64 `json:"availabilityObjective"`
+	Availability          float64 `json:"availability"`
+	AvailabilityBurnRate  float64 `json:"availabilityBurnRate"`
+	ErrorBudgetRemaining  float64 `json:"errorBudgetRemaining"` // 0 if budget is exhausted
+
+	LatencyThreshold int64   `json:"latencyThreshold"` // in nanoseconds
+	LatencyObjective float64 `json:"latencyObjective"`
+	LatencyRatio     float64 `json:"latencyRatio"` // ratio of requests faster than threshold
+	LatencyBurnRate  float64 `json:"latencyBurnRate"`
+}
+
+// SLOStatusList represents the slo status list of all tracked routes.
+type SLOStatusList []*SLOStatus
+
+// ToTable returns slo status list as table if it has value, else return empty string.
+func (l SLOStatusList) ToTable() (rows int, tableStr string) {
+	if len(l) == 0 {
+		return 0, ""
+	}
+	writer := NewTableFormatter()
+	writer.AppendHeader(table.Row{
+		"Route", "Window", "Total",
+		"Availability", "Objective", "Burn Rate", "Budget Remaining",
+		"Latency", "Objective", "Burn Rate",
+	})
+	for _, s := range l {
+		writer.AppendRow(table.Row{
+			s.Route,
+			time.Duration(s.Window).String(),
+			s.Total,
+			formatPercent(s.Availability),
+			formatPercent(s.AvailabilityObjective),
+			fmt.Sprintf("%.2f", s.AvailabilityBurnRate),
+			formatPercent(s.ErrorBudgetRemaining),
+			fmt.Sprintf("%s < %s", formatPercent(s.LatencyRatio), time.Duration(s.LatencyThreshold)),
+			formatPercent(s.LatencyObjective),
+			fmt.Sprintf("%.2f", s.LatencyBurnRate),
+		})
+	}
+	return len(l), writer.Render()
+}
+
+// formatPercent formats ratio as percent string.
+func formatPercent(ratio float64) string {
+	return fmt.Sprintf("%.3f%%", ratio*100)
+}
@@ -0,0 +1,186 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// defaultStreamSampleRows is the default number of rows buffered for computing column widths.
+const defaultStreamSampleRows = 1000
+
+// tableCell represents a rendered cell, number is right aligned like table.Writer.
+type tableCell struct {
+	value  string
+	number bool
+}
+
+// TableStreamWriter renders table rows incrementally into writer with bounded memory.
+// Column widths are computed from the header and the first sample rows,
+// the cell of following rows which is wider than its column will be truncated with "~".
+type TableStreamWriter struct {
+	w          *bufio.Writer
+	header     []tableCell
+	widths     []int
+	sampleRows int
+	pending    [][]tableCell
+	started    bool
+	rows       int
+	err        error
+}
+
+// NewTableStreamWriter creates a table stream writer, sampleRows is the max buffered rows before rendering.
+func NewTableStreamWriter(w io.Writer, sampleRows int, header ...string) *TableStreamWriter {
+	if sampleRows <= 0 {
+		sampleRows = defaultStreamSampleRows
+	}
+	cells := make([]tableCell, len(header))
+	widths := make([]int, len(header))
+	for idx, h := range header {
+		cells[idx] = tableCell{value: h}
+		widths[idx] = text.RuneWidthWithoutEscSequences(h)
+	}
+	return &TableStreamWriter{
+		w:          bufio.NewWriter(w),
+		header:     cells,
+		widths:     widths,
+		sampleRows: sampleRows,
+	}
+}
+
+// AppendRow appends a row, the row is buffered until sample rows are collected.
+func (tw *TableStreamWriter) AppendRow(values ...any) error {
+	if tw.err != nil {
+		return tw.err
+	}
+	row := make([]tableCell, len(tw.widths))
+	for idx := range row {
+		if idx < len(values) {
+			row[idx] = tableCell{value: fmt.Sprint(values[idx]), number: isNumber(values[idx])}
+		}
+	}
+	tw.rows++
+	if tw.started {
+		tw.writeRow(row)
+		return tw.err
+	}
+	for idx, cell := range row {
+		if width := text.RuneWidthWithoutEscSequences(cell.value); width > tw.widths[idx] {
+			tw.widths[idx] = width
+		}
+	}
+	tw.pending = append(tw.pending, row)
+	if len(tw.pending) >= tw.sampleRows {
+		tw.start()
+	}
+	return tw.err
+}
+
+// Rows returns the number of appended rows.
+func (tw *TableStreamWriter) Rows() int {
+	return tw.rows
+}
+
+// Close renders the buffered rows and the bottom border, then flushes the writer.
+// Nothing is written if no rows appended.
+func (tw *TableStreamWriter) Close() error {
+	if tw.err != nil {
+		return tw.err
+	}
+	if tw.rows == 0 {
+		return nil
+	}
+	if !tw.started {
+		tw.start()
+	}
+	tw.writeSeparator()
+	if tw.err == nil {
+		tw.err = tw.w.Flush()
+	}
+	return tw.err
+}
+
+// start renders the header and the buffered sample rows.
+func (tw *TableStreamWriter) start() {
+	tw.started = true
+	tw.writeSeparator()
+	tw.writeRow(tw.header)
+	tw.writeSeparator()
+	for _, row := range tw.pending {
+		tw.writeRow(row)
+	}
+	tw.pending = nil
+}
+
+// writeSeparator writes the separator line, e.g. "+-----+----+".
+func (tw *TableStreamWriter) writeSeparator() {
+	var sb strings.Builder
+	for _, width := range tw.widths {
+		sb.WriteString("+")
+		sb.WriteString(strings.Repeat("-", width+2))
+	}
+	sb.WriteString("+\n")
+	tw.write(sb.String())
+}
+
+// writeRow writes a row line, e.g. "| a   | b  |".
+func (tw *TableStreamWriter) writeRow(row []tableCell) {
+	var sb strings.Builder
+	for idx, width := range tw.widths {
+		cell := row[idx].value
+		if text.RuneWidthWithoutEscSequences(cell) > width {
+			cell = text.Trim(cell, width-1) + "~"
+			if width == 0 {
+				cell = ""
+			}
+		}
+		sb.WriteString("| ")
+		if row[idx].number {
+			sb.WriteString(text.AlignRight.Apply(cell, width))
+		} else {
+			sb.WriteString(text.Pad(cell, width, ' '))
+		}
+		sb.WriteString(" ")
+	}
+	sb.WriteString("|\n")
+	tw.write(sb.String())
+}
+
+func (tw *TableStreamWriter) write(s string) {
+	if tw.err != nil {
+		return
+	}
+	_, tw.err = tw.w.WriteString(s)
+}
+
+// isNumber checks if the value is a number.
+func isNumber(value any) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	default:
+		return false
+	}
+}
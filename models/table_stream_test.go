@@ -0,0 +1,91 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type errWriter struct{}
+
+func (w *errWriter) Write(_ []byte) (int, error) {
+	return 0, fmt.Errorf("err")
+}
+
+func TestTableStreamWriter_SameAsTableFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := NewTableStreamWriter(buf, 0, "Name", "Value")
+	formatter := NewTableFormatter()
+	formatter.AppendHeader(table.Row{"Name", "Value"})
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, writer.AppendRow(fmt.Sprintf("name-%d", i), i*1000))
+		formatter.AppendRow(table.Row{fmt.Sprintf("name-%d", i), i * 1000})
+	}
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, 10, writer.Rows())
+	assert.Equal(t, formatter.Render()+"\n", buf.String())
+}
+
+func TestTableStreamWriter_Streaming(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := NewTableStreamWriter(buf, 2, "Name", "")
+	assert.NoError(t, writer.AppendRow("a"))
+	assert.Empty(t, buf.String())
+	assert.NoError(t, writer.AppendRow("bb", 1))
+	// sample rows rendered, wider cell is truncated
+	assert.NoError(t, writer.AppendRow("long-name", "long-value", "ignored"))
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, 3, writer.Rows())
+	assert.Equal(t, ""+
+		"+------+---+\n"+
+		"| Name |   |\n"+
+		"+------+---+\n"+
+		"| a    |   |\n"+
+		"| bb   | 1 |\n"+
+		"| lon~ | ~ |\n"+
+		"+------+---+\n", buf.String())
+
+	// empty column
+	buf.Reset()
+	writer = NewTableStreamWriter(buf, 1, "")
+	assert.NoError(t, writer.AppendRow(""))
+	assert.NoError(t, writer.AppendRow("a"))
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "+--+\n|  |\n+--+\n|  |\n|  |\n+--+\n", buf.String())
+
+	// no rows
+	buf.Reset()
+	writer = NewTableStreamWriter(buf, 1, "Name")
+	assert.NoError(t, writer.Close())
+	assert.Empty(t, buf.String())
+}
+
+func TestTableStreamWriter_Error(t *testing.T) {
+	writer := NewTableStreamWriter(&errWriter{}, 1, "Name")
+	writer.w.Reset(&errWriter{})
+	// buffered writer fails on flush
+	assert.NoError(t, writer.AppendRow("a"))
+	assert.Error(t, writer.Close())
+	assert.Error(t, writer.AppendRow("b"))
+	assert.Error(t, writer.Close())
+}
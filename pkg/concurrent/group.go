@@ -0,0 +1,145 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError represents the panic recovered from the task of group.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error returns the panic value with stack.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it's an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Group runs tasks in goroutines with bounded concurrency, waits for all of them and aggregates their errors,
+// the panic of task is recovered and converted to PanicError with stack.
+// The zero value is a valid group without concurrency limit which collects all errors.
+type Group struct {
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	errs []error
+	lock sync.Mutex
+}
+
+// NewGroup creates a group with the max number of running tasks, no limit if limit <= 0.
+func NewGroup(limit int) *Group {
+	g := &Group{}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return g
+}
+
+// NewGroupWithContext creates a group with the max number of running tasks(no limit if limit <= 0),
+// the returned context is canceled when the first task fails or Wait returns.
+func NewGroupWithContext(ctx context.Context, limit int) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := NewGroup(limit)
+	g.cancel = cancel
+	return g, ctx
+}
+
+// Go runs the task in a new goroutine, blocks until the number of running tasks is under the limit.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.run(fn)
+}
+
+// TryGo runs the task in a new goroutine only if the number of running tasks is under the limit,
+// returns if the task is started.
+func (g *Group) TryGo(fn func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.run(fn)
+	return true
+}
+
+// Wait blocks until all tasks return, returns the joined errors of tasks in order of occurrence, nil if all succeed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return errors.Join(g.errs...)
+}
+
+// run runs the task in a new goroutine, the slot of limit is released after task returns.
+func (g *Group) run(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+		if err := safeCall(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// fail records the error, cancels the context for the first error.
+func (g *Group) fail(err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.errs = append(g.errs, err)
+	if len(g.errs) == 1 && g.cancel != nil {
+		g.cancel()
+	}
+}
+
+// safeCall calls the task, converts panic to PanicError.
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
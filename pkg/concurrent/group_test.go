@@ -0,0 +1,130 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Limit(t *testing.T) {
+	g := NewGroup(2)
+	var running, maxRunning atomic.Int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, int32(2), maxRunning.Load())
+
+	// zero value group without limit
+	var zero Group
+	var count atomic.Int32
+	for i := 0; i < 10; i++ {
+		zero.Go(func() error {
+			count.Add(1)
+			return nil
+		})
+	}
+	assert.NoError(t, zero.Wait())
+	assert.Equal(t, int32(10), count.Load())
+}
+
+func TestGroup_TryGo(t *testing.T) {
+	g := NewGroup(1)
+	release := make(chan struct{})
+	assert.True(t, g.TryGo(func() error {
+		<-release
+		return nil
+	}))
+	assert.False(t, g.TryGo(func() error { return nil }))
+	close(release)
+	assert.NoError(t, g.Wait())
+	assert.True(t, g.TryGo(func() error { return nil }))
+	assert.NoError(t, g.Wait())
+
+	assert.True(t, NewGroup(0).TryGo(func() error { return nil }))
+}
+
+func TestGroup_Errors(t *testing.T) {
+	err1 := fmt.Errorf("err1")
+	err2 := fmt.Errorf("err2")
+	g := NewGroup(1)
+	g.Go(func() error { return err1 })
+	g.Go(func() error { return nil })
+	g.Go(func() error { return err2 })
+	err := g.Wait()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, err1))
+	assert.True(t, errors.Is(err, err2))
+	assert.Equal(t, "err1\nerr2", err.Error())
+}
+
+func TestGroup_Panic(t *testing.T) {
+	g := NewGroup(2)
+	g.Go(func() error {
+		panic("task panic")
+	})
+	cause := fmt.Errorf("cause")
+	g.Go(func() error {
+		panic(cause)
+	})
+	err := g.Wait()
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Contains(t, err.Error(), "panic: task panic")
+	assert.Contains(t, err.Error(), "group_test.go")
+	assert.True(t, errors.Is(err, cause))
+	assert.Nil(t, (&PanicError{Value: "value"}).Unwrap())
+}
+
+func TestGroup_Context(t *testing.T) {
+	g, ctx := NewGroupWithContext(context.TODO(), 0)
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.Go(func() error {
+		return fmt.Errorf("err")
+	})
+	err := g.Wait()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// canceled after wait
+	g, ctx = NewGroupWithContext(context.TODO(), 2)
+	g.Go(func() error { return nil })
+	assert.NoError(t, g.Wait())
+	assert.Error(t, ctx.Err())
+}
@@ -0,0 +1,218 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSkipItem is returned by stage function to drop the item without failing the pipeline.
+var ErrSkipItem = errors.New("skip item")
+
+// Stage represents a step of pipeline, which is processed by concurrent workers,
+// the outputs are sent to next stage through a bounded channel for backpressure.
+type Stage struct {
+	name        string
+	concurrency int
+	buffer      int
+	fn          func(ctx context.Context, item any) (any, error)
+}
+
+// NewStage creates a stage with concurrent workers(at least 1) and the capacity of output channel,
+// fn returns ErrSkipItem for dropping the item, other error stops the pipeline.
+func NewStage[In, Out any](name string, concurrency, buffer int, fn func(ctx context.Context, in In) (Out, error)) Stage {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if buffer < 0 {
+		buffer = 0
+	}
+	return Stage{
+		name:        name,
+		concurrency: concurrency,
+		buffer:      buffer,
+		fn: func(ctx context.Context, item any) (any, error) {
+			in, ok := item.(In)
+			if !ok {
+				var expect In
+				return nil, fmt.Errorf("stage: %s expects input type: %T, but got: %T", name, expect, item)
+			}
+			return fn(ctx, in)
+		},
+	}
+}
+
+// StageStats represents the statistics of a stage.
+type StageStats struct {
+	Name    string        `json:"name"`
+	In      int64         `json:"in"`
+	Out     int64         `json:"out"`
+	Skipped int64         `json:"skipped"`
+	Failed  int64         `json:"failed"`
+	Cost    time.Duration `json:"cost"` // total processing time of all workers
+}
+
+// stageStats records the statistics of a stage.
+type stageStats struct {
+	in      atomic.Int64
+	out     atomic.Int64
+	skipped atomic.Int64
+	failed  atomic.Int64
+	cost    atomic.Int64
+}
+
+// Pipeline chains stages with bounded channels, items flow from source through all stages into sink,
+// the first error cancels all stages.
+type Pipeline struct {
+	stages []Stage
+	stats  []*stageStats
+}
+
+// NewPipeline creates a pipeline with stages in order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	p := &Pipeline{stages: stages}
+	for range stages {
+		p.stats = append(p.stats, &stageStats{})
+	}
+	return p
+}
+
+// Run runs the pipeline until source completes and all items are consumed by sink, or any error occurs.
+// Source emits items into first stage, emit blocks if the stage is busy and returns error if the pipeline is stopped.
+// Sink consumes the outputs of last stage in one goroutine.
+func (p *Pipeline) Run(parent context.Context,
+	source func(ctx context.Context, emit func(item any) error) error,
+	sink func(ctx context.Context, item any) error,
+) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		firstErr error
+		errOnce  sync.Once
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	input := make(chan any)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(input)
+		err := source(ctx, func(item any) error {
+			select {
+			case input <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			fail(err)
+		}
+	}()
+
+	var ch <-chan any = input
+	for idx := range p.stages {
+		ch = p.runStage(ctx, idx, ch, fail)
+	}
+	// sink
+	for item := range ch {
+		if ctx.Err() != nil {
+			continue // drain
+		}
+		if err := sink(ctx, item); err != nil {
+			fail(err)
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return parent.Err()
+}
+
+// runStage starts the workers of stage, returns the output channel which is closed after all workers exit.
+func (p *Pipeline) runStage(ctx context.Context, idx int, input <-chan any, fail func(err error)) <-chan any {
+	stage := p.stages[idx]
+	stats := p.stats[idx]
+	output := make(chan any, stage.buffer)
+	var wg sync.WaitGroup
+	wg.Add(stage.concurrency)
+	for i := 0; i < stage.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for item := range input {
+				if ctx.Err() != nil {
+					continue // drain input for upstream exiting
+				}
+				stats.in.Add(1)
+				start := time.Now()
+				out, err := stage.fn(ctx, item)
+				stats.cost.Add(int64(time.Since(start)))
+				switch {
+				case errors.Is(err, ErrSkipItem):
+					stats.skipped.Add(1)
+					continue
+				case err != nil:
+					stats.failed.Add(1)
+					fail(fmt.Errorf("stage: %s failure: %w", stage.name, err))
+					continue
+				}
+				select {
+				case output <- out:
+					stats.out.Add(1)
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(output)
+	}()
+	return output
+}
+
+// Stats returns the statistics of all stages.
+func (p *Pipeline) Stats() []StageStats {
+	rs := make([]StageStats, len(p.stages))
+	for idx, stage := range p.stages {
+		stats := p.stats[idx]
+		rs[idx] = StageStats{
+			Name:    stage.name,
+			In:      stats.in.Load(),
+			Out:     stats.out.Load(),
+			Skipped: stats.skipped.Load(),
+			Failed:  stats.failed.Load(),
+			Cost:    time.Duration(stats.cost.Load()),
+		}
+	}
+	return rs
+}
@@ -0,0 +1,141 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func emitInts(n int) func(ctx context.Context, emit func(item any) error) error {
+	return func(_ context.Context, emit func(item any) error) error {
+		for i := 0; i < n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func newTestPipeline(failAt int) *Pipeline {
+	return NewPipeline(
+		NewStage("double", 4, 2, func(_ context.Context, in int) (int, error) {
+			if in == failAt {
+				return 0, fmt.Errorf("fail at: %d", in)
+			}
+			if in%10 == 0 {
+				return 0, ErrSkipItem
+			}
+			return in * 2, nil
+		}),
+		NewStage("format", 2, 0, func(_ context.Context, in int) (string, error) {
+			return strconv.Itoa(in), nil
+		}),
+	)
+}
+
+func TestPipeline(t *testing.T) {
+	p := newTestPipeline(-1)
+	var rs []string
+	err := p.Run(context.TODO(), emitInts(100), func(_ context.Context, item any) error {
+		rs = append(rs, item.(string))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rs, 90)
+	sort.Slice(rs, func(i, j int) bool {
+		a, _ := strconv.Atoi(rs[i])
+		b, _ := strconv.Atoi(rs[j])
+		return a < b
+	})
+	assert.Equal(t, "2", rs[0])
+	assert.Equal(t, "198", rs[89])
+
+	stats := p.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "double", stats[0].Name)
+	assert.Equal(t, int64(100), stats[0].In)
+	assert.Equal(t, int64(90), stats[0].Out)
+	assert.Equal(t, int64(10), stats[0].Skipped)
+	assert.Equal(t, int64(90), stats[1].In)
+	assert.Equal(t, int64(90), stats[1].Out)
+	assert.Zero(t, stats[1].Failed)
+}
+
+func TestPipeline_Error(t *testing.T) {
+	sink := func(_ context.Context, _ any) error { return nil }
+	// stage failure
+	p := newTestPipeline(55)
+	err := p.Run(context.TODO(), emitInts(10000), sink)
+	assert.ErrorContains(t, err, "stage: double failure: fail at: 55")
+	assert.Equal(t, int64(1), p.Stats()[0].Failed)
+
+	// sink failure
+	err = newTestPipeline(-1).Run(context.TODO(), emitInts(10000), func(_ context.Context, _ any) error {
+		return fmt.Errorf("sink err")
+	})
+	assert.EqualError(t, err, "sink err")
+
+	// source failure
+	err = newTestPipeline(-1).Run(context.TODO(), func(_ context.Context, emit func(item any) error) error {
+		_ = emit(1)
+		return fmt.Errorf("source err")
+	}, sink)
+	assert.EqualError(t, err, "source err")
+
+	// type mismatch
+	err = newTestPipeline(-1).Run(context.TODO(), func(_ context.Context, emit func(item any) error) error {
+		return emit("1")
+	}, sink)
+	assert.ErrorContains(t, err, "expects input type: int, but got: string")
+
+	// parent canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	err = newTestPipeline(-1).Run(ctx, emitInts(10000), func(_ context.Context, _ any) error {
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	// parent canceled, source ignores emit error
+	ctx, cancel = context.WithCancel(context.TODO())
+	cancel()
+	err = newTestPipeline(-1).Run(ctx, func(_ context.Context, emit func(item any) error) error {
+		_ = emit(1)
+		return nil
+	}, sink)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewStage(t *testing.T) {
+	stage := NewStage("s", 0, -1, func(_ context.Context, in int) (int, error) { return in, nil })
+	assert.Equal(t, 1, stage.concurrency)
+	assert.Zero(t, stage.buffer)
+	// pipeline without stage
+	var rs []any
+	assert.NoError(t, NewPipeline().Run(context.TODO(), emitInts(3), func(_ context.Context, item any) error {
+		rs = append(rs, item)
+		return nil
+	}))
+	assert.Equal(t, []any{0, 1, 2}, rs)
+}
@@ -0,0 +1,206 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultShards is the default number of shards of sharded map.
+const DefaultShards = 64
+
+// StringHash returns the xxhash of string key.
+func StringHash[K ~string](key K) uint64 {
+	return xxhash.Sum64String(string(key))
+}
+
+// Uint64Hash returns the xxhash of uint64 key(e.g. series id).
+func Uint64Hash[K ~uint64](key K) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(key))
+	return xxhash.Sum64(buf[:])
+}
+
+// mapShard is a shard of sharded map guarded by its own lock.
+type mapShard[K comparable, V any] struct {
+	items map[K]V
+	lock  sync.RWMutex
+}
+
+// ShardedMap is a map split into shards by the hash of key, each shard has its own lock,
+// so the goroutines operating different keys(e.g. series keyed mutable state in write path) rarely contend.
+type ShardedMap[K comparable, V any] struct {
+	shards []*mapShard[K, V]
+	mask   uint64
+	hash   func(key K) uint64
+}
+
+// NewShardedMap creates a sharded map, the number of shards is rounded up to power of 2(DefaultShards if <= 0),
+// hash selects the shard of key, e.g. StringHash/Uint64Hash.
+func NewShardedMap[K comparable, V any](shards int, hash func(key K) uint64) *ShardedMap[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	n := 1 << bits.Len(uint(shards-1))
+	m := &ShardedMap[K, V]{
+		shards: make([]*mapShard[K, V], n),
+		mask:   uint64(n - 1),
+		hash:   hash,
+	}
+	for i := range m.shards {
+		m.shards[i] = &mapShard[K, V]{items: make(map[K]V)}
+	}
+	return m
+}
+
+// shard returns the shard of key.
+func (m *ShardedMap[K, V]) shard(key K) *mapShard[K, V] {
+	return m.shards[m.hash(key)&m.mask]
+}
+
+// Shards returns the number of shards.
+func (m *ShardedMap[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+// Get returns the value of key, false if not exist.
+func (m *ShardedMap[K, V]) Get(key K) (V, bool) {
+	s := m.shard(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	v, ok := s.items[key]
+	return v, ok
+}
+
+// Put sets the value of key.
+func (m *ShardedMap[K, V]) Put(key K, value V) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.items[key] = value
+}
+
+// GetOrCreate returns the value of key, creates it by newFn under the lock of shard if not exist,
+// returns true if created.
+func (m *ShardedMap[K, V]) GetOrCreate(key K, newFn func() V) (value V, created bool) {
+	if v, ok := m.Get(key); ok {
+		return v, false
+	}
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// double check
+	if v, ok := s.items[key]; ok {
+		return v, false
+	}
+	value = newFn()
+	s.items[key] = value
+	return value, true
+}
+
+// Update updates the value of key under the lock of shard, fn receives the current value(ok is false if not exist),
+// the returned value is stored if keep is true, else the key is deleted.
+func (m *ShardedMap[K, V]) Update(key K, fn func(value V, ok bool) (newValue V, keep bool)) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v, ok := s.items[key]
+	if newValue, keep := fn(v, ok); keep {
+		s.items[key] = newValue
+	} else if ok {
+		delete(s.items, key)
+	}
+}
+
+// Delete deletes the key, returns false if not exist.
+func (m *ShardedMap[K, V]) Delete(key K) bool {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, ok := s.items[key]
+	if ok {
+		delete(s.items, key)
+	}
+	return ok
+}
+
+// Len returns the number of keys, it's not a consistent snapshot if the map is being modified.
+func (m *ShardedMap[K, V]) Len() (n int) {
+	for _, s := range m.shards {
+		s.lock.RLock()
+		n += len(s.items)
+		s.lock.RUnlock()
+	}
+	return n
+}
+
+// ShardLens returns the number of keys in each shard, for monitoring the skew of shards.
+func (m *ShardedMap[K, V]) ShardLens() []int {
+	lens := make([]int, len(m.shards))
+	for i, s := range m.shards {
+		s.lock.RLock()
+		lens[i] = len(s.items)
+		s.lock.RUnlock()
+	}
+	return lens
+}
+
+// Range calls fn for each key/value sequentially until fn returns false. The items of each shard are snapshotted
+// before calling fn, so fn can modify the map without deadlock, but the modifications of other shards may be visible.
+func (m *ShardedMap[K, V]) Range(fn func(key K, value V) bool) {
+	type item struct {
+		key   K
+		value V
+	}
+	var items []item
+	for _, s := range m.shards {
+		items = items[:0]
+		s.lock.RLock()
+		for k, v := range s.items {
+			items = append(items, item{key: k, value: v})
+		}
+		s.lock.RUnlock()
+		for _, it := range items {
+			if !fn(it.key, it.value) {
+				return
+			}
+		}
+	}
+}
+
+// Snapshot returns a copy of all key/values, it's consistent per shard.
+func (m *ShardedMap[K, V]) Snapshot() map[K]V {
+	result := make(map[K]V)
+	for _, s := range m.shards {
+		s.lock.RLock()
+		for k, v := range s.items {
+			result[k] = v
+		}
+		s.lock.RUnlock()
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShardedMap(t *testing.T) {
+	cases := []struct {
+		shards int
+		expect int
+	}{
+		{shards: 0, expect: DefaultShards},
+		{shards: -1, expect: DefaultShards},
+		{shards: 1, expect: 1},
+		{shards: 3, expect: 4},
+		{shards: 16, expect: 16},
+		{shards: 17, expect: 32},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(fmt.Sprintf("shards_%d", tt.shards), func(t *testing.T) {
+			m := NewShardedMap[string, int](tt.shards, StringHash[string])
+			assert.Equal(t, tt.expect, m.Shards())
+			assert.Len(t, m.ShardLens(), tt.expect)
+		})
+	}
+}
+
+func TestShardedMap(t *testing.T) {
+	m := NewShardedMap[string, int](4, StringHash[string])
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+	m.Put("a", 1)
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	v, created := m.GetOrCreate("a", func() int { return 2 })
+	assert.False(t, created)
+	assert.Equal(t, 1, v)
+	v, created = m.GetOrCreate("b", func() int { return 2 })
+	assert.True(t, created)
+	assert.Equal(t, 2, v)
+
+	// update
+	m.Update("a", func(value int, ok bool) (int, bool) {
+		assert.True(t, ok)
+		return value + 10, true
+	})
+	v, _ = m.Get("a")
+	assert.Equal(t, 11, v)
+	m.Update("c", func(value int, ok bool) (int, bool) {
+		assert.False(t, ok)
+		return 3, true
+	})
+	m.Update("d", func(_ int, _ bool) (int, bool) {
+		return 0, false
+	})
+	assert.Equal(t, 3, m.Len())
+	m.Update("c", func(_ int, _ bool) (int, bool) {
+		return 0, false
+	})
+	_, ok = m.Get("c")
+	assert.False(t, ok)
+
+	assert.True(t, m.Delete("b"))
+	assert.False(t, m.Delete("b"))
+	assert.Equal(t, map[string]int{"a": 11}, m.Snapshot())
+	lens := m.ShardLens()
+	sum := 0
+	for _, l := range lens {
+		sum += l
+	}
+	assert.Equal(t, 1, sum)
+}
+
+func TestShardedMap_Range(t *testing.T) {
+	m := NewShardedMap[uint64, string](8, Uint64Hash[uint64])
+	for i := uint64(0); i < 100; i++ {
+		m.Put(i, fmt.Sprint(i))
+	}
+	visited := make(map[uint64]string)
+	m.Range(func(key uint64, value string) bool {
+		visited[key] = value
+		// modifying map in range doesn't deadlock
+		m.Delete(key)
+		return true
+	})
+	assert.Len(t, visited, 100)
+	assert.Zero(t, m.Len())
+
+	for i := uint64(0); i < 100; i++ {
+		m.Put(i, fmt.Sprint(i))
+	}
+	count := 0
+	m.Range(func(_ uint64, _ string) bool {
+		count++
+		return count < 10
+	})
+	assert.Equal(t, 10, count)
+
+	// keys are spread over shards
+	for _, l := range m.ShardLens() {
+		assert.Greater(t, l, 0)
+	}
+}
+
+func TestShardedMap_Concurrent(t *testing.T) {
+	m := NewShardedMap[string, *int64](16, StringHash[string])
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprintf("series-%d", j%100)
+				m.Update(key, func(value *int64, ok bool) (*int64, bool) {
+					if !ok {
+						value = new(int64)
+					}
+					*value++
+					return value, true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 100, m.Len())
+	m.Range(func(_ string, value *int64) bool {
+		assert.Equal(t, int64(80), *value)
+		return true
+	})
+}
+
+func BenchmarkShardedMap_Update(b *testing.B) {
+	m := NewShardedMap[string, int](DefaultShards, StringHash[string])
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("series-%d", i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Update(keys[i&1023], func(value int, _ bool) (int, bool) {
+				return value + 1, true
+			})
+			i++
+		}
+	})
+}
@@ -0,0 +1,155 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lindb/common/models"
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+// for testing
+var (
+	createTempFunc   = os.CreateTemp
+	getOpenFilesFunc = getOpenFilesLimit
+)
+
+// DefaultChecks returns the common checks for the data dirs of lind doctor command,
+// clock drift check is not included because it needs a reference clock.
+func DefaultChecks(dataDirs ...string) []Check {
+	checks := []Check{ULimitCheck(65535)}
+	for _, dir := range dataDirs {
+		checks = append(checks, DirPermissionCheck(dir), DiskSpeedCheck(dir, 64*1024*1024, 50*1024*1024))
+	}
+	return checks
+}
+
+// DirPermissionCheck checks if the data dir exists and is writable.
+func DirPermissionCheck(dir string) Check {
+	return Check{
+		Name: "dir permission: " + dir,
+		Run: func(_ context.Context) (models.CheckStatus, string) {
+			info, err := os.Stat(dir)
+			if err != nil {
+				return models.CheckFail, err.Error()
+			}
+			if !info.IsDir() {
+				return models.CheckFail, fmt.Sprintf("%s is not a directory", dir)
+			}
+			f, err := createTempFunc(dir, ".doctor-*")
+			if err != nil {
+				return models.CheckFail, fmt.Sprintf("not writable: %v", err)
+			}
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return models.CheckPass, fmt.Sprintf("mode: %s", info.Mode().Perm())
+		},
+	}
+}
+
+// DiskSpeedCheck probes the sequential write(with fsync) speed of the dir using a temp file with size bytes,
+// warns if the speed is less than minSpeed bytes per second.
+func DiskSpeedCheck(dir string, size int, minSpeed ltoml.Size) Check {
+	return Check{
+		Name: "disk speed: " + dir,
+		Run: func(ctx context.Context) (models.CheckStatus, string) {
+			f, err := createTempFunc(dir, ".doctor-disk-*")
+			if err != nil {
+				return models.CheckFail, err.Error()
+			}
+			defer func() {
+				_ = f.Close()
+				_ = os.Remove(filepath.Clean(f.Name()))
+			}()
+			buf := make([]byte, 64*1024)
+			start := time.Now()
+			for written := 0; written < size; written += len(buf) {
+				if ctx.Err() != nil {
+					return models.CheckFail, ctx.Err().Error()
+				}
+				n := len(buf)
+				if size-written < n {
+					n = size - written
+				}
+				if _, err := f.Write(buf[:n]); err != nil {
+					return models.CheckFail, err.Error()
+				}
+			}
+			if err := f.Sync(); err != nil {
+				return models.CheckFail, err.Error()
+			}
+			cost := time.Since(start)
+			speed := ltoml.Size(float64(size) / cost.Seconds())
+			msg := fmt.Sprintf("write speed: %s/s", speed)
+			if speed < minSpeed {
+				return models.CheckWarn, fmt.Sprintf("%s < %s/s", msg, minSpeed)
+			}
+			return models.CheckPass, msg
+		},
+	}
+}
+
+// ClockDriftCheck checks the drift between local clock and the reference clock(e.g. ntp server, peer node),
+// warns if the drift exceeds maxDrift.
+func ClockDriftCheck(reference func(ctx context.Context) (time.Time, error), maxDrift time.Duration) Check {
+	return Check{
+		Name: "clock drift",
+		Run: func(ctx context.Context) (models.CheckStatus, string) {
+			start := time.Now()
+			remote, err := reference(ctx)
+			if err != nil {
+				return models.CheckFail, err.Error()
+			}
+			// estimate local time when reference time is taken
+			end := time.Now()
+			local := start.Add(end.Sub(start) / 2)
+			drift := local.Sub(remote)
+			if drift < 0 {
+				drift = -drift
+			}
+			msg := fmt.Sprintf("drift: %s", drift)
+			if drift > maxDrift {
+				return models.CheckWarn, fmt.Sprintf("%s > %s", msg, maxDrift)
+			}
+			return models.CheckPass, msg
+		},
+	}
+}
+
+// ULimitCheck checks the max open files limit of the process, warns if less than minOpenFiles.
+func ULimitCheck(minOpenFiles uint64) Check {
+	return Check{
+		Name: "ulimit",
+		Run: func(_ context.Context) (models.CheckStatus, string) {
+			limit, err := getOpenFilesFunc()
+			if err != nil {
+				return models.CheckFail, err.Error()
+			}
+			msg := fmt.Sprintf("open files: %d", limit)
+			if limit < minOpenFiles {
+				return models.CheckWarn, fmt.Sprintf("%s < %d", msg, minOpenFiles)
+			}
+			return models.CheckPass, msg
+		},
+	}
+}
@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/models"
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+func TestDefaultChecks(t *testing.T) {
+	checks := DefaultChecks("/data1", "/data2")
+	assert.Len(t, checks, 5)
+}
+
+func TestDirPermissionCheck(t *testing.T) {
+	defer func() {
+		createTempFunc = os.CreateTemp
+	}()
+	dir := t.TempDir()
+	status, msg := DirPermissionCheck(dir).Run(context.TODO())
+	assert.Equal(t, models.CheckPass, status, msg)
+	entries, _ := os.ReadDir(dir)
+	assert.Empty(t, entries)
+
+	status, _ = DirPermissionCheck(filepath.Join(dir, "not-exist")).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+	status, _ = DirPermissionCheck(file).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+
+	createTempFunc = func(dir, pattern string) (*os.File, error) {
+		return nil, fmt.Errorf("permission denied")
+	}
+	status, msg = DirPermissionCheck(dir).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+	assert.Equal(t, "not writable: permission denied", msg)
+}
+
+func TestDiskSpeedCheck(t *testing.T) {
+	defer func() {
+		createTempFunc = os.CreateTemp
+	}()
+	dir := t.TempDir()
+	status, msg := DiskSpeedCheck(dir, 100*1024, 1).Run(context.TODO())
+	assert.Equal(t, models.CheckPass, status, msg)
+	status, _ = DiskSpeedCheck(dir, 1024, 1000*ltoml.Size(1024*1024*1024*1024)).Run(context.TODO())
+	assert.Equal(t, models.CheckWarn, status)
+	entries, _ := os.ReadDir(dir)
+	assert.Empty(t, entries)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	status, _ = DiskSpeedCheck(dir, 1024, 1).Run(ctx)
+	assert.Equal(t, models.CheckFail, status)
+
+	// write failure
+	createTempFunc = func(dir, pattern string) (*os.File, error) {
+		f, err := os.CreateTemp(dir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		_ = f.Close()
+		return f, nil
+	}
+	status, _ = DiskSpeedCheck(dir, 1024, 1).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+	// sync failure
+	status, _ = DiskSpeedCheck(dir, 0, 1).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+
+	createTempFunc = func(dir, pattern string) (*os.File, error) {
+		return nil, fmt.Errorf("err")
+	}
+	status, _ = DiskSpeedCheck(dir, 1024, 1).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+}
+
+func TestClockDriftCheck(t *testing.T) {
+	status, msg := ClockDriftCheck(func(_ context.Context) (time.Time, error) {
+		return time.Now(), nil
+	}, time.Second).Run(context.TODO())
+	assert.Equal(t, models.CheckPass, status, msg)
+	status, _ = ClockDriftCheck(func(_ context.Context) (time.Time, error) {
+		return time.Now().Add(time.Minute), nil
+	}, time.Second).Run(context.TODO())
+	assert.Equal(t, models.CheckWarn, status)
+	status, _ = ClockDriftCheck(func(_ context.Context) (time.Time, error) {
+		return time.Time{}, fmt.Errorf("err")
+	}, time.Second).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+}
+
+func TestULimitCheck(t *testing.T) {
+	defer func() {
+		getOpenFilesFunc = getOpenFilesLimit
+	}()
+	limit, err := getOpenFilesLimit()
+	assert.NoError(t, err)
+	status, _ := ULimitCheck(1).Run(context.TODO())
+	assert.Equal(t, models.CheckPass, status)
+	status, _ = ULimitCheck(limit + 1).Run(context.TODO())
+	assert.Equal(t, models.CheckWarn, status)
+
+	getOpenFilesFunc = func() (uint64, error) {
+		return 0, fmt.Errorf("err")
+	}
+	status, _ = ULimitCheck(1).Run(context.TODO())
+	assert.Equal(t, models.CheckFail, status)
+}
@@ -0,0 +1,99 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lindb/common/models"
+)
+
+// defaultCheckTimeout is the default timeout of each check.
+const defaultCheckTimeout = 30 * time.Second
+
+// CheckFunc runs a diagnostic check, returns the status with message.
+type CheckFunc func(ctx context.Context) (status models.CheckStatus, message string)
+
+// Check represents a registered diagnostic check.
+type Check struct {
+	Name    string
+	Run     CheckFunc
+	Timeout time.Duration // default 30s if <= 0
+}
+
+// Doctor runs registered diagnostic checks in order, backing the doctor command.
+type Doctor struct {
+	checks []Check
+}
+
+// New creates a doctor with checks.
+func New(checks ...Check) *Doctor {
+	return &Doctor{checks: checks}
+}
+
+// Register registers the diagnostic checks.
+func (d *Doctor) Register(checks ...Check) {
+	d.checks = append(d.checks, checks...)
+}
+
+// Run runs all checks one by one, a check is failed if it panics or times out.
+func (d *Doctor) Run(ctx context.Context) models.CheckResultList {
+	rs := make(models.CheckResultList, 0, len(d.checks))
+	for _, check := range d.checks {
+		rs = append(rs, runCheck(ctx, check))
+	}
+	return rs
+}
+
+// runCheck runs the check with timeout.
+func runCheck(ctx context.Context, check Check) *models.CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &models.CheckResult{Name: check.Name}
+	done := make(chan struct{})
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result.Status = models.CheckFail
+				result.Message = fmt.Sprintf("panic: %v", r)
+			}
+			close(done)
+		}()
+		result.Status, result.Message = check.Run(ctx)
+	}()
+	select {
+	case <-done:
+		result.Cost = time.Since(start).Nanoseconds()
+		return result
+	case <-ctx.Done():
+		return &models.CheckResult{
+			Name:    check.Name,
+			Status:  models.CheckFail,
+			Message: fmt.Sprintf("check not completed: %v", ctx.Err()),
+			Cost:    time.Since(start).Nanoseconds(),
+		}
+	}
+}
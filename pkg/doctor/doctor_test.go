@@ -0,0 +1,60 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package doctor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/models"
+)
+
+func TestDoctor_Run(t *testing.T) {
+	d := New(Check{
+		Name: "pass",
+		Run: func(_ context.Context) (models.CheckStatus, string) {
+			return models.CheckPass, "ok"
+		},
+	})
+	d.Register(Check{
+		Name: "panic",
+		Run: func(_ context.Context) (models.CheckStatus, string) {
+			panic("err")
+		},
+	}, Check{
+		Name:    "timeout",
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) (models.CheckStatus, string) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return models.CheckPass, "ok"
+		},
+	})
+	rs := d.Run(context.TODO())
+	assert.Len(t, rs, 3)
+	assert.Equal(t, models.CheckPass, rs[0].Status)
+	assert.Equal(t, "ok", rs[0].Message)
+	assert.Equal(t, models.CheckFail, rs[1].Status)
+	assert.Equal(t, "panic: err", rs[1].Message)
+	assert.Equal(t, models.CheckFail, rs[2].Status)
+	assert.Contains(t, rs[2].Message, "deadline exceeded")
+	assert.Equal(t, models.CheckFail, rs.Status())
+}
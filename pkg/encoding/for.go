@@ -0,0 +1,217 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	// FORBlockSize is the number of values in a frame-of-reference block.
+	FORBlockSize = 128
+	// forHeaderSize is the size of payload header(value count).
+	forHeaderSize = 4
+	// forBlockHeaderSize is the size of block header(base + bit width + padding),
+	// which keeps the packed words aligned to 4 bytes.
+	forBlockHeaderSize = 8
+)
+
+// FOREncodeUint32 encodes the values using frame-of-reference and bit-packing, appends into dst.
+// Values are split into blocks of 128 values, each block stores the min value as base,
+// and packs (value - base) with the bit width of max delta, so sorted sequences(e.g. series ids, offsets)
+// with small range are compressed well. A full block is 16 * width bytes after the header.
+//
+//	+-------+---------+---------+-----+
+//	| count | block 1 | block 2 | ... |
+//	+-------+---------+---------+-----+
+//	block: | base(4 bytes) | width(1 byte) | padding(3 bytes) | packed words(4 bytes * n) |
+func FOREncodeUint32(dst []byte, values []uint32) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(values)))
+	for start := 0; start < len(values); start += FORBlockSize {
+		end := start + FORBlockSize
+		if end > len(values) {
+			end = len(values)
+		}
+		dst = forEncodeBlock(dst, values[start:end])
+	}
+	return dst
+}
+
+// forEncodeBlock encodes a block of values.
+func forEncodeBlock(dst []byte, block []uint32) []byte {
+	base, max := block[0], block[0]
+	for _, v := range block[1:] {
+		if v < base {
+			base = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	width := bits.Len32(max - base)
+	dst = binary.LittleEndian.AppendUint32(dst, base)
+	dst = append(dst, byte(width), 0, 0, 0)
+	if width == 0 {
+		return dst
+	}
+	var (
+		acc   uint64
+		nbits int
+	)
+	for _, v := range block {
+		acc |= uint64(v-base) << nbits
+		nbits += width
+		if nbits >= 32 {
+			dst = binary.LittleEndian.AppendUint32(dst, uint32(acc))
+			acc >>= 32
+			nbits -= 32
+		}
+	}
+	if nbits > 0 {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(acc))
+	}
+	return dst
+}
+
+// forPackedSize returns the byte size of packed words for n values with bit width.
+func forPackedSize(n, width int) int {
+	return (n*width + 31) / 32 * 4
+}
+
+// FORReader reads the values encoded by FOREncodeUint32, supports random access.
+type FORReader struct {
+	data   []byte
+	count  int
+	blocks []int // offset of each block
+}
+
+// NewFORReader creates a reader for the encoded data, returns error if data is corrupted.
+func NewFORReader(data []byte) (*FORReader, error) {
+	if len(data) < forHeaderSize {
+		return nil, fmt.Errorf("corrupted frame-of-reference data, size: %d", len(data))
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	blocks := (count + FORBlockSize - 1) / FORBlockSize
+	if blocks > (len(data)-forHeaderSize)/forBlockHeaderSize {
+		return nil, fmt.Errorf("corrupted frame-of-reference data, count: %d exceeds size: %d", count, len(data))
+	}
+	r := &FORReader{
+		data:   data,
+		count:  count,
+		blocks: make([]int, 0, blocks),
+	}
+	pos := forHeaderSize
+	for remaining := count; remaining > 0; remaining -= FORBlockSize {
+		n := remaining
+		if n > FORBlockSize {
+			n = FORBlockSize
+		}
+		if len(data)-pos < forBlockHeaderSize {
+			return nil, fmt.Errorf("corrupted frame-of-reference data, block header is truncated at: %d", pos)
+		}
+		width := int(data[pos+4])
+		if width > 32 {
+			return nil, fmt.Errorf("corrupted frame-of-reference data, bit width: %d is invalid at: %d", width, pos)
+		}
+		size := forBlockHeaderSize + forPackedSize(n, width)
+		if len(data)-pos < size {
+			return nil, fmt.Errorf("corrupted frame-of-reference data, block is truncated at: %d", pos)
+		}
+		r.blocks = append(r.blocks, pos)
+		pos += size
+	}
+	return r, nil
+}
+
+// Len returns the number of values.
+func (r *FORReader) Len() int {
+	return r.count
+}
+
+// Get returns the value at index without decoding the whole block, panics if index out of range.
+func (r *FORReader) Get(idx int) uint32 {
+	if idx < 0 || idx >= r.count {
+		panic(fmt.Sprintf("frame-of-reference index: %d out of range: %d", idx, r.count))
+	}
+	pos := r.blocks[idx/FORBlockSize]
+	base := binary.LittleEndian.Uint32(r.data[pos:])
+	width := int(r.data[pos+4])
+	if width == 0 {
+		return base
+	}
+	words := r.data[pos+forBlockHeaderSize:]
+	bitPos := (idx % FORBlockSize) * width
+	wordPos := bitPos / 32 * 4
+	v := uint64(binary.LittleEndian.Uint32(words[wordPos:]))
+	if wordPos+4 < len(words) {
+		v |= uint64(binary.LittleEndian.Uint32(words[wordPos+4:])) << 32
+	}
+	return base + uint32(v>>(bitPos%32)&(1<<width-1))
+}
+
+// Decode appends all values into dst.
+func (r *FORReader) Decode(dst []uint32) []uint32 {
+	for idx, pos := range r.blocks {
+		n := r.count - idx*FORBlockSize
+		if n > FORBlockSize {
+			n = FORBlockSize
+		}
+		dst = forDecodeBlock(dst, r.data[pos:], n)
+	}
+	return dst
+}
+
+// forDecodeBlock decodes n values of the block.
+func forDecodeBlock(dst []uint32, block []byte, n int) []uint32 {
+	base := binary.LittleEndian.Uint32(block)
+	width := int(block[4])
+	if width == 0 {
+		for i := 0; i < n; i++ {
+			dst = append(dst, base)
+		}
+		return dst
+	}
+	var (
+		words = block[forBlockHeaderSize:]
+		mask  = uint64(1)<<width - 1
+		acc   uint64
+		nbits int
+	)
+	for i := 0; i < n; i++ {
+		if nbits < width {
+			acc |= uint64(binary.LittleEndian.Uint32(words)) << nbits
+			words = words[4:]
+			nbits += 32
+		}
+		dst = append(dst, base+uint32(acc&mask))
+		acc >>= width
+		nbits -= width
+	}
+	return dst
+}
+
+// FORDecodeUint32 decodes the data encoded by FOREncodeUint32, appends values into dst.
+func FORDecodeUint32(dst []uint32, data []byte) ([]uint32, error) {
+	r, err := NewFORReader(data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Decode(dst), nil
+}
@@ -0,0 +1,139 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFOR_Codec(t *testing.T) {
+	cases := [][]uint32{
+		nil,
+		{1},
+		{5, 5, 5},
+		{math.MaxUint32, 0, 1},
+	}
+	// sorted series ids
+	var ids []uint32
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, uint32(100000+i*3))
+	}
+	cases = append(cases, ids)
+	// random values with all bit widths
+	r := rand.New(rand.NewSource(1))
+	for width := 1; width <= 32; width++ {
+		var values []uint32
+		for i := 0; i < FORBlockSize+width; i++ {
+			values = append(values, 1000+uint32(r.Uint64()&(1<<width-1)))
+		}
+		cases = append(cases, values)
+	}
+	for _, values := range cases {
+		data := FOREncodeUint32(nil, values)
+		decoded, err := FORDecodeUint32(nil, data)
+		assert.NoError(t, err)
+		assert.Equal(t, len(values), len(decoded))
+		for i := range values {
+			assert.Equal(t, values[i], decoded[i])
+		}
+		reader, err := NewFORReader(data)
+		assert.NoError(t, err)
+		assert.Equal(t, len(values), reader.Len())
+		for i := range values {
+			assert.Equal(t, values[i], reader.Get(i))
+		}
+	}
+	// sorted ids are compressed: max delta 381 in block needs 9 bits
+	assert.Less(t, len(FOREncodeUint32(nil, ids)), len(ids)*4/3)
+}
+
+func TestFORReader_Corrupted(t *testing.T) {
+	_, err := NewFORReader([]byte{1})
+	assert.Error(t, err)
+	data := FOREncodeUint32(nil, []uint32{1, 2, 3, 1000})
+	// count exceeds size
+	_, err = FORDecodeUint32(nil, append([]byte{0xff, 0xff, 0, 0}, data[4:]...))
+	assert.Error(t, err)
+	// block header truncated
+	values := make([]uint32, FORBlockSize+1)
+	values[1] = 1
+	truncated := FOREncodeUint32(nil, values)
+	_, err = NewFORReader(truncated[:len(truncated)-1])
+	assert.Error(t, err)
+	// invalid width
+	invalid := append([]byte{}, data...)
+	invalid[8] = 33
+	_, err = NewFORReader(invalid)
+	assert.Error(t, err)
+	// packed words truncated
+	_, err = NewFORReader(data[:len(data)-1])
+	assert.Error(t, err)
+
+	reader, err := NewFORReader(data)
+	assert.NoError(t, err)
+	assert.Panics(t, func() {
+		reader.Get(4)
+	})
+	assert.Panics(t, func() {
+		reader.Get(-1)
+	})
+}
+
+func BenchmarkFOREncodeUint32(b *testing.B) {
+	values := make([]uint32, 10000)
+	for i := range values {
+		values[i] = uint32(i * 7)
+	}
+	var dst []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = FOREncodeUint32(dst[:0], values)
+	}
+}
+
+func BenchmarkFORDecodeUint32(b *testing.B) {
+	values := make([]uint32, 10000)
+	for i := range values {
+		values[i] = uint32(i * 7)
+	}
+	data := FOREncodeUint32(nil, values)
+	reader, _ := NewFORReader(data)
+	dst := make([]uint32, 0, len(values))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = reader.Decode(dst[:0])
+	}
+}
+
+func BenchmarkFORReader_Get(b *testing.B) {
+	values := make([]uint32, 10000)
+	for i := range values {
+		values[i] = uint32(i * 7)
+	}
+	reader, _ := NewFORReader(FOREncodeUint32(nil, values))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = reader.Get(i % len(values))
+	}
+}
@@ -0,0 +1,171 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashAlgorithm represents the checksum algorithm of tee hasher.
+type HashAlgorithm int
+
+const (
+	// HashCRC32C is crc32 with castagnoli polynomial(hardware accelerated on most platforms).
+	HashCRC32C HashAlgorithm = iota
+	// HashXXHash64 is 64-bit xxhash.
+	HashXXHash64
+)
+
+// String returns the name of hash algorithm.
+func (alg HashAlgorithm) String() string {
+	switch alg {
+	case HashCRC32C:
+		return "crc32c"
+	case HashXXHash64:
+		return "xxhash64"
+	default:
+		return fmt.Sprintf("HashAlgorithm(%d)", int(alg))
+	}
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// rollingHash computes the checksum incrementally.
+type rollingHash interface {
+	io.Writer
+	Reset()
+	Sum64() uint64
+}
+
+// crc32Hash wraps crc32 hash as rollingHash.
+type crc32Hash struct {
+	hash.Hash32
+}
+
+// Sum64 returns the crc32 checksum as uint64.
+func (h crc32Hash) Sum64() uint64 { return uint64(h.Sum32()) }
+
+// newRollingHash creates the rolling hash of algorithm, panic if algorithm is unknown.
+func newRollingHash(alg HashAlgorithm) rollingHash {
+	switch alg {
+	case HashCRC32C:
+		return crc32Hash{Hash32: crc32.New(crc32cTable)}
+	case HashXXHash64:
+		return xxhash.New()
+	default:
+		panic(fmt.Sprintf("unknown hash algorithm: %s", alg))
+	}
+}
+
+// Checksum returns the checksum of data, which is the same as the sum of tee hasher.
+func Checksum(alg HashAlgorithm, data []byte) uint64 {
+	switch alg {
+	case HashCRC32C:
+		return uint64(crc32.Checksum(data, crc32cTable))
+	case HashXXHash64:
+		return xxhash.Sum64(data)
+	default:
+		panic(fmt.Sprintf("unknown hash algorithm: %s", alg))
+	}
+}
+
+// TeeHashWriter computes the rolling checksum of data while writing it into underlying writer,
+// so that the buffers needn't be scanned twice for integrity checks. Only the bytes written successfully are hashed.
+// TeeHashWriter is not thread-safe.
+type TeeHashWriter struct {
+	w       io.Writer
+	h       rollingHash
+	written int64
+}
+
+// NewTeeHashWriter creates a tee hash writer over the writer with hash algorithm.
+func NewTeeHashWriter(w io.Writer, alg HashAlgorithm) *TeeHashWriter {
+	return &TeeHashWriter{w: w, h: newRollingHash(alg)}
+}
+
+// Write writes data into underlying writer, then hashes the written bytes.
+func (tw *TeeHashWriter) Write(p []byte) (n int, err error) {
+	n, err = tw.w.Write(p)
+	if n > 0 {
+		_, _ = tw.h.Write(p[:n])
+		tw.written += int64(n)
+	}
+	return n, err
+}
+
+// Sum64 returns the checksum of all written bytes.
+func (tw *TeeHashWriter) Sum64() uint64 { return tw.h.Sum64() }
+
+// Written returns the number of written bytes.
+func (tw *TeeHashWriter) Written() int64 { return tw.written }
+
+// Reset resets the checksum and switches to the writer for reusing.
+func (tw *TeeHashWriter) Reset(w io.Writer) {
+	tw.w = w
+	tw.h.Reset()
+	tw.written = 0
+}
+
+// TeeHashReader computes the rolling checksum of data while reading it from underlying reader.
+// TeeHashReader is not thread-safe.
+type TeeHashReader struct {
+	r    io.Reader
+	h    rollingHash
+	read int64
+}
+
+// NewTeeHashReader creates a tee hash reader over the reader with hash algorithm.
+func NewTeeHashReader(r io.Reader, alg HashAlgorithm) *TeeHashReader {
+	return &TeeHashReader{r: r, h: newRollingHash(alg)}
+}
+
+// Read reads data from underlying reader, then hashes the read bytes.
+func (tr *TeeHashReader) Read(p []byte) (n int, err error) {
+	n, err = tr.r.Read(p)
+	if n > 0 {
+		_, _ = tr.h.Write(p[:n])
+		tr.read += int64(n)
+	}
+	return n, err
+}
+
+// Sum64 returns the checksum of all read bytes.
+func (tr *TeeHashReader) Sum64() uint64 { return tr.h.Sum64() }
+
+// ReadBytes returns the number of read bytes.
+func (tr *TeeHashReader) ReadBytes() int64 { return tr.read }
+
+// Verify checks if the checksum of all read bytes equals the expected checksum.
+func (tr *TeeHashReader) Verify(expected uint64) error {
+	if actual := tr.h.Sum64(); actual != expected {
+		return fmt.Errorf("checksum mismatch after reading %d bytes, expected: %x, actual: %x", tr.read, expected, actual)
+	}
+	return nil
+}
+
+// Reset resets the checksum and switches to the reader for reusing.
+func (tr *TeeHashReader) Reset(r io.Reader) {
+	tr.r = r
+	tr.h.Reset()
+	tr.read = 0
+}
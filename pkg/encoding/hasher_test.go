@@ -0,0 +1,131 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAlgorithm_String(t *testing.T) {
+	assert.Equal(t, "crc32c", HashCRC32C.String())
+	assert.Equal(t, "xxhash64", HashXXHash64.String())
+	assert.Equal(t, "HashAlgorithm(10)", HashAlgorithm(10).String())
+	assert.Panics(t, func() {
+		NewTeeHashWriter(io.Discard, HashAlgorithm(10))
+	})
+	assert.Panics(t, func() {
+		Checksum(HashAlgorithm(10), nil)
+	})
+}
+
+func TestTeeHashWriter(t *testing.T) {
+	data := bytes.Repeat([]byte("lindb"), 1000)
+	for _, alg := range []HashAlgorithm{HashCRC32C, HashXXHash64} {
+		var buf bytes.Buffer
+		w := NewTeeHashWriter(&buf, alg)
+		for i := 0; i < len(data); i += 333 {
+			end := i + 333
+			if end > len(data) {
+				end = len(data)
+			}
+			n, err := w.Write(data[i:end])
+			assert.NoError(t, err)
+			assert.Equal(t, end-i, n)
+		}
+		assert.Equal(t, data, buf.Bytes())
+		assert.Equal(t, int64(len(data)), w.Written())
+		assert.Equal(t, Checksum(alg, data), w.Sum64())
+
+		w.Reset(io.Discard)
+		assert.Zero(t, w.Written())
+		assert.Equal(t, Checksum(alg, nil), w.Sum64())
+	}
+}
+
+type shortWriter struct {
+	limit int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return w.limit, fmt.Errorf("short write")
+	}
+	return len(p), nil
+}
+
+func TestTeeHashWriter_ShortWrite(t *testing.T) {
+	w := NewTeeHashWriter(&shortWriter{limit: 3}, HashXXHash64)
+	n, err := w.Write([]byte("12345"))
+	assert.Error(t, err)
+	assert.Equal(t, 3, n)
+	// only written bytes are hashed
+	assert.Equal(t, Checksum(HashXXHash64, []byte("123")), w.Sum64())
+	assert.Equal(t, int64(3), w.Written())
+}
+
+func TestTeeHashReader(t *testing.T) {
+	data := bytes.Repeat([]byte("lindb"), 1000)
+	for _, alg := range []HashAlgorithm{HashCRC32C, HashXXHash64} {
+		r := NewTeeHashReader(iotest.HalfReader(bytes.NewReader(data)), alg)
+		read, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, read)
+		assert.Equal(t, int64(len(data)), r.ReadBytes())
+		assert.Equal(t, Checksum(alg, data), r.Sum64())
+		assert.NoError(t, r.Verify(Checksum(alg, data)))
+		assert.Error(t, r.Verify(Checksum(alg, data)+1))
+
+		r.Reset(bytes.NewReader([]byte("abc")))
+		assert.Zero(t, r.ReadBytes())
+		_, err = io.Copy(io.Discard, r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Verify(Checksum(alg, []byte("abc"))))
+	}
+}
+
+func TestTeeHashReader_Error(t *testing.T) {
+	r := NewTeeHashReader(iotest.TimeoutReader(bytes.NewReader([]byte("abc"))), HashCRC32C)
+	buf := make([]byte, 2)
+	n, err := r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = r.Read(buf)
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), r.ReadBytes())
+	assert.NoError(t, r.Verify(Checksum(HashCRC32C, []byte("ab"))))
+}
+
+func Benchmark_TeeHashWriter(b *testing.B) {
+	data := bytes.Repeat([]byte("lindb"), 1024)
+	for _, alg := range []HashAlgorithm{HashCRC32C, HashXXHash64} {
+		b.Run(alg.String(), func(b *testing.B) {
+			w := NewTeeHashWriter(io.Discard, alg)
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = w.Write(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultTimestampBlockSize is the default number of timestamps in a delta-of-delta block.
+const DefaultTimestampBlockSize = 128
+
+// dodBuckets are the prefix codes of zigzag delta-of-delta, '0' means the delta is unchanged.
+var dodBuckets = [...]struct {
+	prefix     uint64
+	prefixBits int
+	valueBits  int
+}{
+	{prefix: 0b10, prefixBits: 2, valueBits: 7},
+	{prefix: 0b110, prefixBits: 3, valueBits: 9},
+	{prefix: 0b1110, prefixBits: 4, valueBits: 12},
+	{prefix: 0b11110, prefixBits: 5, valueBits: 32},
+	{prefix: 0b11111, prefixBits: 5, valueBits: 64},
+}
+
+// TimestampBlock is the index entry of an encoded timestamp block, used for random access,
+// e.g. skipping the blocks out of time range, then seeking the decoder to block offset.
+type TimestampBlock struct {
+	Offset int   // byte offset of block in stream
+	Size   int   // byte size of block
+	Count  int   // number of timestamps
+	First  int64 // first timestamp
+	Last   int64 // last timestamp
+}
+
+// TimestampEncoder compresses the timestamps by delta-of-delta(Gorilla) and writes them into the stream
+// by blocks, each block is byte-aligned and self-contained, so it can be decoded independently.
+//
+//	+---------+---------+-----+
+//	| block 1 | block 2 | ... |
+//	+---------+---------+-----+
+//	block: | count(uvarint) | first(varint) | last-first(varint) | payload size(uvarint) | payload(bits) |
+//	payload: delta-of-delta of the 2nd..nth timestamps with prefix codes:
+//	'0'(unchanged), '10'+7 bits, '110'+9 bits, '1110'+12 bits, '11110'+32 bits, '11111'+64 bits(zigzag)
+type TimestampEncoder struct {
+	w         io.Writer
+	blockSize int
+	onBlock   func(block TimestampBlock)
+
+	offset int
+	count  int
+	first  int64
+	prev   int64
+	delta  int64
+	bits   bitWriter
+	buf    []byte
+	err    error
+}
+
+// NewTimestampEncoder creates a timestamp encoder which writes the full blocks into w,
+// uses DefaultTimestampBlockSize if blockSize <= 0.
+func NewTimestampEncoder(w io.Writer, blockSize int) *TimestampEncoder {
+	if blockSize <= 0 {
+		blockSize = DefaultTimestampBlockSize
+	}
+	return &TimestampEncoder{w: w, blockSize: blockSize}
+}
+
+// OnBlock sets the hook which is invoked after writing each block, e.g. building the index of blocks.
+func (e *TimestampEncoder) OnBlock(fn func(block TimestampBlock)) {
+	e.onBlock = fn
+}
+
+// Write appends a timestamp, writes the block into stream if it's full.
+func (e *TimestampEncoder) Write(timestamp int64) error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.count == 0 {
+		e.first = timestamp
+		e.delta = 0
+	} else {
+		delta := timestamp - e.prev
+		e.bits.writeDoD(delta - e.delta)
+		e.delta = delta
+	}
+	e.prev = timestamp
+	e.count++
+	if e.count == e.blockSize {
+		return e.seal()
+	}
+	return nil
+}
+
+// Flush writes the pending timestamps as a block.
+func (e *TimestampEncoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.count == 0 {
+		return nil
+	}
+	return e.seal()
+}
+
+// Offset returns the byte size written into stream.
+func (e *TimestampEncoder) Offset() int {
+	return e.offset
+}
+
+// Reset resets the encoder to write a new stream into w.
+func (e *TimestampEncoder) Reset(w io.Writer) {
+	e.w = w
+	e.offset = 0
+	e.count = 0
+	e.bits.reset()
+	e.err = nil
+}
+
+// seal writes the pending timestamps as a block.
+func (e *TimestampEncoder) seal() error {
+	payload := e.bits.bytes()
+	e.buf = binary.AppendUvarint(e.buf[:0], uint64(e.count))
+	e.buf = binary.AppendVarint(e.buf, e.first)
+	e.buf = binary.AppendVarint(e.buf, e.prev-e.first)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(payload)))
+	e.buf = append(e.buf, payload...)
+	if _, err := e.w.Write(e.buf); err != nil {
+		e.err = fmt.Errorf("write timestamp block failure: %w", err)
+		return e.err
+	}
+	block := TimestampBlock{Offset: e.offset, Size: len(e.buf), Count: e.count, First: e.first, Last: e.prev}
+	e.offset += len(e.buf)
+	e.count = 0
+	e.bits.reset()
+	if e.onBlock != nil {
+		e.onBlock(block)
+	}
+	return nil
+}
+
+// sliceWriter appends the written data into slice.
+type sliceWriter struct {
+	buf []byte
+}
+
+// Write appends p into slice.
+func (w *sliceWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// EncodeTimestamps encodes the timestamps by blocks of DefaultTimestampBlockSize, appends into dst.
+func EncodeTimestamps(dst []byte, timestamps []int64) []byte {
+	w := &sliceWriter{buf: dst}
+	e := NewTimestampEncoder(w, DefaultTimestampBlockSize)
+	for _, ts := range timestamps {
+		_ = e.Write(ts)
+	}
+	_ = e.Flush()
+	return w.buf
+}
+
+// DecodeTimestamps decodes the data encoded by TimestampEncoder, appends timestamps into dst.
+func DecodeTimestamps(dst []int64, data []byte) ([]int64, error) {
+	d := NewTimestampDecoder(data)
+	for d.Next() {
+		dst = append(dst, d.Value())
+	}
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// ReadTimestampBlocks reads the index of blocks by block headers without decoding the payload.
+func ReadTimestampBlocks(data []byte) ([]TimestampBlock, error) {
+	var blocks []TimestampBlock
+	for pos := 0; pos < len(data); {
+		block, _, err := readTimestampBlockHeader(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		pos += block.Size
+	}
+	return blocks, nil
+}
+
+// SearchTimestampBlock returns the index of first block whose last timestamp >= timestamp,
+// len(blocks) if not found, the blocks should be sorted by time.
+func SearchTimestampBlock(blocks []TimestampBlock, timestamp int64) int {
+	return sort.Search(len(blocks), func(i int) bool {
+		return blocks[i].Last >= timestamp
+	})
+}
+
+// readTimestampBlockHeader reads the header of block at pos, returns the block and the offset of payload.
+func readTimestampBlockHeader(data []byte, pos int) (block TimestampBlock, payloadPos int, err error) {
+	corrupted := func(what string) error {
+		return fmt.Errorf("corrupted timestamp block at: %d, %s", pos, what)
+	}
+	p := pos
+	count, n := binary.Uvarint(data[p:])
+	if n <= 0 || count == 0 {
+		return block, 0, corrupted("invalid count")
+	}
+	p += n
+	first, n := binary.Varint(data[p:])
+	if n <= 0 {
+		return block, 0, corrupted("invalid first timestamp")
+	}
+	p += n
+	span, n := binary.Varint(data[p:])
+	if n <= 0 {
+		return block, 0, corrupted("invalid last timestamp")
+	}
+	p += n
+	size, n := binary.Uvarint(data[p:])
+	if n <= 0 || size > uint64(len(data)-p-n) {
+		return block, 0, corrupted("payload is truncated")
+	}
+	p += n
+	// each timestamp takes 1 bit at least
+	if count-1 > size*8 {
+		return block, 0, corrupted(fmt.Sprintf("count: %d exceeds payload size: %d", count, size))
+	}
+	return TimestampBlock{
+		Offset: pos,
+		Size:   p - pos + int(size),
+		Count:  int(count),
+		First:  first,
+		Last:   first + span,
+	}, p, nil
+}
+
+// TimestampDecoder decodes the timestamps encoded by TimestampEncoder.
+type TimestampDecoder struct {
+	data []byte
+	pos  int // offset of next block
+
+	block     TimestampBlock
+	bits      bitReader
+	remaining int
+	value     int64
+	delta     int64
+	err       error
+}
+
+// NewTimestampDecoder creates a timestamp decoder for the encoded data.
+func NewTimestampDecoder(data []byte) *TimestampDecoder {
+	return &TimestampDecoder{data: data}
+}
+
+// SeekBlock moves the decoder to the block at offset(see TimestampBlock.Offset).
+func (d *TimestampDecoder) SeekBlock(offset int) error {
+	if offset < 0 || offset > len(d.data) {
+		return fmt.Errorf("timestamp block offset: %d out of range: %d", offset, len(d.data))
+	}
+	d.pos = offset
+	d.remaining = 0
+	d.err = nil
+	return nil
+}
+
+// Next decodes the next timestamp, returns false if no more timestamps or failure.
+func (d *TimestampDecoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	if d.remaining == 0 {
+		if d.pos >= len(d.data) {
+			return false
+		}
+		block, payloadPos, err := readTimestampBlockHeader(d.data, d.pos)
+		if err != nil {
+			d.err = err
+			return false
+		}
+		d.block = block
+		d.bits = bitReader{data: d.data[payloadPos : block.Offset+block.Size]}
+		d.pos = block.Offset + block.Size
+		d.remaining = block.Count
+		d.value = block.First
+		d.delta = 0
+	} else {
+		dod, ok := d.bits.readDoD()
+		if !ok {
+			d.err = fmt.Errorf("corrupted timestamp block at: %d, payload is truncated", d.block.Offset)
+			return false
+		}
+		d.delta += dod
+		d.value += d.delta
+	}
+	d.remaining--
+	if d.remaining == 0 && d.value != d.block.Last {
+		d.err = fmt.Errorf("corrupted timestamp block at: %d, last timestamp: %d mismatch: %d",
+			d.block.Offset, d.value, d.block.Last)
+		return false
+	}
+	return true
+}
+
+// Value returns the current timestamp.
+func (d *TimestampDecoder) Value() int64 {
+	return d.value
+}
+
+// Err returns the failure of decoding.
+func (d *TimestampDecoder) Err() error {
+	return d.err
+}
+
+// bitWriter writes bits in MSB-first order.
+type bitWriter struct {
+	buf  []byte
+	free int // free bits of last byte
+}
+
+// writeBits writes the lower nbits of v.
+func (w *bitWriter) writeBits(v uint64, nbits int) {
+	for nbits > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+		take := min(nbits, w.free)
+		chunk := v >> (nbits - take) & (1<<take - 1)
+		w.buf[len(w.buf)-1] |= byte(chunk << (w.free - take))
+		w.free -= take
+		nbits -= take
+	}
+}
+
+// writeDoD writes the delta-of-delta with prefix code.
+func (w *bitWriter) writeDoD(dod int64) {
+	if dod == 0 {
+		w.writeBits(0, 1)
+		return
+	}
+	zz := uint64(dod<<1) ^ uint64(dod>>63)
+	for _, b := range dodBuckets {
+		if b.valueBits == 64 || zz < 1<<b.valueBits {
+			w.writeBits(b.prefix, b.prefixBits)
+			w.writeBits(zz, b.valueBits)
+			return
+		}
+	}
+}
+
+// bytes returns the written bits, the last byte is padded by zero.
+func (w *bitWriter) bytes() []byte {
+	return w.buf
+}
+
+// reset resets the writer for reusing buffer.
+func (w *bitWriter) reset() {
+	w.buf = w.buf[:0]
+	w.free = 0
+}
+
+// bitReader reads bits in MSB-first order.
+type bitReader struct {
+	data []byte
+	pos  int // bit position
+}
+
+// readBits reads nbits as the lower bits of value, returns false if no enough bits.
+func (r *bitReader) readBits(nbits int) (uint64, bool) {
+	if r.pos+nbits > len(r.data)*8 {
+		return 0, false
+	}
+	var v uint64
+	for nbits > 0 {
+		avail := 8 - r.pos%8
+		take := min(avail, nbits)
+		chunk := r.data[r.pos/8] >> (avail - take) & (1<<take - 1)
+		v = v<<take | uint64(chunk)
+		r.pos += take
+		nbits -= take
+	}
+	return v, true
+}
+
+// readDoD reads the delta-of-delta with prefix code.
+func (r *bitReader) readDoD() (int64, bool) {
+	ones := 0
+	for ones < len(dodBuckets) {
+		bit, ok := r.readBits(1)
+		if !ok {
+			return 0, false
+		}
+		if bit == 0 {
+			break
+		}
+		ones++
+	}
+	if ones == 0 {
+		return 0, true
+	}
+	zz, ok := r.readBits(dodBuckets[ones-1].valueBits)
+	if !ok {
+		return 0, false
+	}
+	return int64(zz>>1) ^ -int64(zz&1), true
+}
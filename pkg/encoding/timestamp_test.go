@@ -0,0 +1,288 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockWriter fails the writing.
+type mockWriter struct{}
+
+func (w *mockWriter) Write(_ []byte) (int, error) { return 0, io.ErrShortWrite }
+
+// regularTimestamps returns the timestamps with fixed interval and random jitter.
+func regularTimestamps(n int, interval, jitter int64) []int64 {
+	r := rand.New(rand.NewSource(1))
+	timestamps := make([]int64, n)
+	ts := int64(1_700_000_000_000)
+	for i := range timestamps {
+		timestamps[i] = ts
+		ts += interval
+		if jitter > 0 {
+			ts += r.Int63n(jitter*2+1) - jitter
+		}
+	}
+	return timestamps
+}
+
+func TestTimestamp_Codec(t *testing.T) {
+	cases := map[string][]int64{
+		"empty":     nil,
+		"single":    {1},
+		"same":      {10, 10, 10, 10},
+		"unsorted":  {100, 50, 200, -10, 0},
+		"extremes":  {math.MinInt64, math.MaxInt64, 0, math.MaxInt64, math.MinInt64},
+		"regular":   regularTimestamps(1000, 10_000, 0),
+		"jitter":    regularTimestamps(1000, 10_000, 50),
+		"jitter-1s": regularTimestamps(1000, 10_000, 1000),
+	}
+	// all buckets
+	var buckets []int64
+	ts := int64(0)
+	for _, delta := range []int64{1, 60, 200, 2000, 1 << 20, 1 << 40, 3, 3} {
+		ts += delta
+		buckets = append(buckets, ts)
+	}
+	cases["buckets"] = buckets
+	for name, timestamps := range cases {
+		timestamps := timestamps
+		t.Run(name, func(t *testing.T) {
+			data := EncodeTimestamps(nil, timestamps)
+			decoded, err := DecodeTimestamps(nil, data)
+			assert.NoError(t, err)
+			assert.Equal(t, len(timestamps), len(decoded))
+			for i := range timestamps {
+				assert.Equal(t, timestamps[i], decoded[i])
+			}
+		})
+	}
+	// fixed interval takes 1 bit per timestamp
+	regular := EncodeTimestamps(nil, cases["regular"])
+	assert.Less(t, len(regular), 1000/8+8*20)
+	assert.Less(t, len(EncodeTimestamps(nil, cases["jitter"])), 1000*2)
+}
+
+func TestTimestampEncoder_Blocks(t *testing.T) {
+	timestamps := regularTimestamps(1000, 1000, 10)
+	var (
+		buf    bytes.Buffer
+		blocks []TimestampBlock
+	)
+	e := NewTimestampEncoder(&buf, 100)
+	e.OnBlock(func(block TimestampBlock) {
+		blocks = append(blocks, block)
+	})
+	for i, ts := range timestamps[:950] {
+		assert.NoError(t, e.Write(ts))
+		// full blocks are written
+		assert.Equal(t, (i+1)/100, len(blocks))
+	}
+	assert.Equal(t, buf.Len(), e.Offset())
+	for _, ts := range timestamps[950:] {
+		assert.NoError(t, e.Write(ts))
+	}
+	assert.NoError(t, e.Flush())
+	assert.NoError(t, e.Flush())
+	data := buf.Bytes()
+	assert.Len(t, blocks, 10)
+	assert.Equal(t, len(data), e.Offset())
+
+	// rebuild index from block headers
+	index, err := ReadTimestampBlocks(data)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks, index)
+	for i, block := range blocks {
+		assert.Equal(t, 100, block.Count)
+		assert.Equal(t, timestamps[i*100], block.First)
+		assert.Equal(t, timestamps[i*100+99], block.Last)
+	}
+
+	// random access
+	d := NewTimestampDecoder(data)
+	for target, expect := range map[int64]int64{
+		timestamps[0] - 1: timestamps[0],
+		timestamps[555]:   timestamps[555],
+		timestamps[999]:   timestamps[999],
+	} {
+		idx := SearchTimestampBlock(index, target)
+		assert.NoError(t, d.SeekBlock(index[idx].Offset))
+		var found bool
+		for d.Next() {
+			if d.Value() >= target {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found)
+		assert.Equal(t, expect, d.Value())
+	}
+	assert.Equal(t, len(index), SearchTimestampBlock(index, timestamps[999]+1))
+	assert.Error(t, d.SeekBlock(-1))
+	assert.Error(t, d.SeekBlock(len(data)+1))
+	assert.NoError(t, d.SeekBlock(len(data)))
+	assert.False(t, d.Next())
+	assert.NoError(t, d.Err())
+
+	// reset
+	buf.Reset()
+	e.Reset(&buf)
+	assert.NoError(t, e.Write(1))
+	assert.NoError(t, e.Flush())
+	decoded, err := DecodeTimestamps(nil, buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, decoded)
+	assert.Equal(t, TimestampBlock{Size: buf.Len(), Count: 1, First: 1, Last: 1}, blocks[len(blocks)-1])
+
+	// default block size
+	assert.Equal(t, DefaultTimestampBlockSize, NewTimestampEncoder(&buf, 0).blockSize)
+}
+
+func TestTimestampEncoder_WriteFailure(t *testing.T) {
+	e := NewTimestampEncoder(&mockWriter{}, 2)
+	assert.NoError(t, e.Write(1))
+	assert.Error(t, e.Write(2))
+	assert.Error(t, e.Write(3))
+	assert.Error(t, e.Flush())
+}
+
+func TestTimestampDecoder_Corrupted(t *testing.T) {
+	data := EncodeTimestamps(nil, []int64{1000, 2000, 3005, 4000})
+	_, payloadPos, err := readTimestampBlockHeader(data, 0)
+	assert.NoError(t, err)
+	payload := data[payloadPos:]
+	header := func(count uint64, last int64, size int) []byte {
+		h := binary.AppendUvarint(nil, count)
+		h = binary.AppendVarint(h, 1000)
+		h = binary.AppendVarint(h, last-1000)
+		return binary.AppendUvarint(h, uint64(size))
+	}
+	cases := map[string][]byte{
+		"zero count":        {0},
+		"invalid count":     {0x80},
+		"invalid first":     {1, 0x80},
+		"invalid last":      {1, 2, 0x80},
+		"invalid size":      {1, 2, 0, 0x80},
+		"payload truncated": data[:len(data)-1],
+		"count exceeds":     {100, 2, 0, 1, 0},
+		"last mismatch":     append(header(4, 4001, len(payload)), payload...),
+		"dod truncated":     append(header(4, 4000, 2), payload[:2]...),
+	}
+	for name, corrupted := range cases {
+		corrupted := corrupted
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeTimestamps(nil, corrupted)
+			assert.Error(t, err)
+			d := NewTimestampDecoder(corrupted)
+			for d.Next() {
+			}
+			assert.False(t, d.Next())
+			assert.Error(t, d.Err())
+		})
+	}
+	_, err = ReadTimestampBlocks([]byte{0})
+	assert.Error(t, err)
+}
+
+func BenchmarkTimestampEncoder(b *testing.B) {
+	for _, jitter := range []int64{0, 10, 1000} {
+		timestamps := regularTimestamps(10_000, 10_000, jitter)
+		b.Run(fmt.Sprintf("jitter-%d", jitter), func(b *testing.B) {
+			w := &sliceWriter{}
+			e := NewTimestampEncoder(w, DefaultTimestampBlockSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.buf = w.buf[:0]
+				e.Reset(w)
+				for _, ts := range timestamps {
+					_ = e.Write(ts)
+				}
+				_ = e.Flush()
+			}
+			b.ReportMetric(float64(len(w.buf)*8)/float64(len(timestamps)), "bits/ts")
+		})
+	}
+}
+
+func BenchmarkTimestampDecoder(b *testing.B) {
+	for _, jitter := range []int64{0, 10, 1000} {
+		data := EncodeTimestamps(nil, regularTimestamps(10_000, 10_000, jitter))
+		b.Run(fmt.Sprintf("jitter-%d", jitter), func(b *testing.B) {
+			dst := make([]int64, 0, 10_000)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dst, _ = DecodeTimestamps(dst[:0], data)
+			}
+		})
+	}
+}
+
+func BenchmarkTimestampBlockSize(b *testing.B) {
+	timestamps := regularTimestamps(10_000, 10_000, 10)
+	for _, blockSize := range []int{32, 128, 512, 2048} {
+		b.Run(fmt.Sprintf("block-%d", blockSize), func(b *testing.B) {
+			w := &sliceWriter{}
+			e := NewTimestampEncoder(w, blockSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.buf = w.buf[:0]
+				e.Reset(w)
+				for _, ts := range timestamps {
+					_ = e.Write(ts)
+				}
+				_ = e.Flush()
+			}
+			b.ReportMetric(float64(len(w.buf)*8)/float64(len(timestamps)), "bits/ts")
+		})
+	}
+}
+
+func BenchmarkTimestampDecoder_Seek(b *testing.B) {
+	timestamps := regularTimestamps(100_000, 10_000, 10)
+	data := EncodeTimestamps(nil, timestamps)
+	index, _ := ReadTimestampBlocks(data)
+	d := NewTimestampDecoder(data)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		target := timestamps[i%len(timestamps)]
+		_ = d.SeekBlock(index[SearchTimestampBlock(index, target)].Offset)
+		for d.Next() && d.Value() < target {
+		}
+	}
+}
+
+func BenchmarkReadTimestampBlocks(b *testing.B) {
+	data := EncodeTimestamps(nil, regularTimestamps(100_000, 10_000, 10))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ReadTimestampBlocks(data)
+	}
+}
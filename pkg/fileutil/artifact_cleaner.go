@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ArtifactDir represents a directory of debug artifacts(e.g. pprof dumps, heap profiles, crash logs, cores)
+// with its retention policy.
+type ArtifactDir struct {
+	Path   string
+	Policy RetentionPolicy
+}
+
+// DefaultArtifactPolicy is the retention policy for debug artifacts if not set,
+// keeps 20 files(at most 7 days, 1GB in total).
+var DefaultArtifactPolicy = RetentionPolicy{
+	MaxAge:       7 * 24 * time.Hour,
+	MaxTotalSize: 1 << 30,
+	MaxFiles:     20,
+}
+
+// ArtifactCleaner sweeps the debug artifact directories periodically, which prevents surprise
+// disk exhaustion on long-lived nodes.
+type ArtifactCleaner struct {
+	dirs     []ArtifactDir
+	interval time.Duration
+	onSweep  func(dir string, result *RetentionResult, err error)
+}
+
+// NewArtifactCleaner creates an artifact cleaner sweeping the dirs every interval,
+// the dir with empty policy uses DefaultArtifactPolicy.
+// onSweep is invoked after sweeping each dir(e.g. logging removed files), nil means ignore.
+func NewArtifactCleaner(interval time.Duration, onSweep func(dir string, result *RetentionResult, err error),
+	dirs ...ArtifactDir,
+) *ArtifactCleaner {
+	cleanDirs := make([]ArtifactDir, len(dirs))
+	for i, dir := range dirs {
+		if isEmptyPolicy(dir.Policy) {
+			patterns := dir.Policy.Patterns
+			dir.Policy = DefaultArtifactPolicy
+			dir.Policy.Patterns = patterns
+		}
+		cleanDirs[i] = dir
+	}
+	if onSweep == nil {
+		onSweep = func(_ string, _ *RetentionResult, _ error) {}
+	}
+	return &ArtifactCleaner{
+		dirs:     cleanDirs,
+		interval: interval,
+		onSweep:  onSweep,
+	}
+}
+
+// Sweep applies the retention policies of all dirs once, returns the joined error.
+func (c *ArtifactCleaner) Sweep() error {
+	var errs []error
+	for _, dir := range c.dirs {
+		result, err := ApplyRetention(dir.Path, dir.Policy)
+		c.onSweep(dir.Path, result, err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sweeps immediately, then every interval until the ctx is done.
+func (c *ArtifactCleaner) Run(ctx context.Context) {
+	_ = c.Sweep()
+	if c.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = c.Sweep()
+		}
+	}
+}
+
+// isEmptyPolicy returns if the policy has no limit.
+func isEmptyPolicy(policy RetentionPolicy) bool {
+	return policy.MaxAge <= 0 && policy.MaxTotalSize <= 0 && policy.MaxFiles <= 0
+}
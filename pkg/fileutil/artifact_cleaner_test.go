@@ -0,0 +1,94 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewArtifactCleaner(t *testing.T) {
+	c := NewArtifactCleaner(time.Minute, nil,
+		ArtifactDir{Path: "pprof", Policy: RetentionPolicy{Patterns: []string{"*.pprof"}}},
+		ArtifactDir{Path: "crash", Policy: RetentionPolicy{MaxFiles: 3}},
+	)
+	assert.Equal(t, DefaultArtifactPolicy.MaxAge, c.dirs[0].Policy.MaxAge)
+	assert.Equal(t, []string{"*.pprof"}, c.dirs[0].Policy.Patterns)
+	assert.Equal(t, RetentionPolicy{MaxFiles: 3}, c.dirs[1].Policy)
+	assert.NoError(t, c.Sweep())
+}
+
+func TestArtifactCleaner_Sweep(t *testing.T) {
+	now := time.Now()
+	pprofDir, crashDir := t.TempDir(), t.TempDir()
+	createRetentionFiles(t, pprofDir, 5, now)
+	createRetentionFiles(t, crashDir, 5, now)
+	results := make(map[string]*RetentionResult)
+	c := NewArtifactCleaner(0, func(dir string, result *RetentionResult, err error) {
+		assert.NoError(t, err)
+		results[dir] = result
+	},
+		ArtifactDir{Path: pprofDir, Policy: RetentionPolicy{MaxFiles: 2}},
+		ArtifactDir{Path: crashDir, Policy: RetentionPolicy{MaxTotalSize: 400}},
+	)
+	c.Run(context.TODO())
+	assert.Len(t, results[pprofDir].Removed, 3)
+	assert.Len(t, results[crashDir].Removed, 1)
+
+	// bad pattern
+	c = NewArtifactCleaner(0, nil, ArtifactDir{Path: pprofDir, Policy: RetentionPolicy{Patterns: []string{"["}}})
+	assert.Error(t, c.Sweep())
+}
+
+func TestArtifactCleaner_Run(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		mutex  sync.Mutex
+		sweeps int
+	)
+	c := NewArtifactCleaner(time.Millisecond, func(_ string, _ *RetentionResult, _ error) {
+		mutex.Lock()
+		sweeps++
+		mutex.Unlock()
+	}, ArtifactDir{Path: dir, Policy: RetentionPolicy{MaxFiles: 1}})
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	// artifacts dumped after starting are swept
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, time.Now().Format("150405.000000000")), nil, 0o600))
+	}
+	assert.Eventually(t, func() bool {
+		files, _ := ListDir(dir)
+		return len(files) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	mutex.Lock()
+	assert.Greater(t, sweeps, 1)
+	mutex.Unlock()
+}
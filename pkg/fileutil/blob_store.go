@@ -0,0 +1,279 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// blobTempDir is the directory for writing blobs before moving them into place.
+const blobTempDir = ".tmp"
+
+// for testing
+var (
+	renameFunc = os.Rename
+	syncFunc   = func(f *os.File) error { return f.Sync() }
+)
+
+// BlobSweepResult represents the result of sweeping unreferenced blobs.
+type BlobSweepResult struct {
+	Scanned      int      // number of blobs
+	Removed      []string // digests of removed blobs
+	RemovedBytes int64    // total size of removed blobs
+}
+
+// BlobStore is a content-addressed store, each blob is stored once in the file named by SHA-256 of content,
+// fan-out into sub directories by digest prefix(e.g. dir/ab/cd/abcd...), so the identical segment/backup blobs
+// across snapshots are deduplicated. Unreferenced blobs are removed by mark-and-sweep(see Sweep).
+type BlobStore struct {
+	dir string
+}
+
+// NewBlobStore creates a blob store in dir, the temp files left by unfinished writing are removed.
+func NewBlobStore(dir string) (*BlobStore, error) {
+	tmp := filepath.Join(dir, blobTempDir)
+	if err := RemoveDir(tmp); err != nil {
+		return nil, err
+	}
+	if err := MkDirIfNotExist(tmp); err != nil {
+		return nil, err
+	}
+	return &BlobStore{dir: dir}, nil
+}
+
+// Dir returns the root directory of blob store.
+func (s *BlobStore) Dir() string {
+	return s.dir
+}
+
+// Path returns the file path of blob, returns error if digest is invalid.
+func (s *BlobStore) Path(digest string) (string, error) {
+	if !isBlobDigest(digest) {
+		return "", fmt.Errorf("invalid blob digest: %q", digest)
+	}
+	return filepath.Join(s.dir, digest[0:2], digest[2:4], digest), nil
+}
+
+// Put writes the blob read from r, returns the digest(hex of SHA-256) and size.
+// The blob is written into temp file then moved into place atomically, if the identical blob exists,
+// the temp file is discarded and the existing one is touched, so it's protected by the grace period of Sweep.
+func (s *BlobStore) Put(r io.Reader) (digest string, size int64, err error) {
+	tmp, err := os.CreateTemp(fixPath(filepath.Join(s.dir, blobTempDir)), "blob-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	h := sha256.New()
+	if size, err = io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		return "", 0, err
+	}
+	if err = syncFunc(tmp); err != nil {
+		return "", 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	digest = hex.EncodeToString(h.Sum(nil))
+	path, _ := s.Path(digest)
+	if Exist(path) {
+		now := nowFunc()
+		err = os.Chtimes(fixPath(path), now, now)
+		if err == nil {
+			// deduplicated, discard the temp file
+			_ = os.Remove(tmp.Name())
+			return digest, size, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", 0, err
+		}
+		// removed by sweeping concurrently, write it again
+	}
+	if err = MkDirIfNotExist(filepath.Dir(path)); err != nil {
+		return "", 0, err
+	}
+	if err = renameFunc(tmp.Name(), fixPath(path)); err != nil {
+		if !Exist(path) {
+			return "", 0, err
+		}
+		// written concurrently(rename doesn't replace existing file on some platforms)
+		err = nil
+		_ = os.Remove(tmp.Name())
+	}
+	return digest, size, nil
+}
+
+// PutBytes writes the blob data, returns the digest.
+func (s *BlobStore) PutBytes(data []byte) (string, error) {
+	digest, _, err := s.Put(bytes.NewReader(data))
+	return digest, err
+}
+
+// Has returns if the blob exists.
+func (s *BlobStore) Has(digest string) bool {
+	path, err := s.Path(digest)
+	return err == nil && Exist(path)
+}
+
+// Open opens the blob for reading.
+func (s *BlobStore) Open(digest string) (*os.File, error) {
+	path, err := s.Path(digest)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(fixPath(path))
+}
+
+// Verify returns error if the content of blob doesn't match the digest(e.g. corrupted on disk).
+func (s *BlobStore) Verify(digest string) error {
+	f, err := s.Open(digest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return fmt.Errorf("blob: %s is corrupted, actual digest: %s", digest, actual)
+	}
+	return nil
+}
+
+// Walk calls fn for each blob in the store, stops if fn returns error.
+func (s *BlobStore) Walk(fn func(digest string, info fs.FileInfo) error) error {
+	return s.walk(func(digest, _ string, info fs.FileInfo) error {
+		return fn(digest, info)
+	})
+}
+
+// Sweep removes the blobs which are not marked as live, the blobs modified within gracePeriod are kept,
+// avoiding removing the blobs just written(or deduplicated by Put during sweeping) but not referenced yet. It keeps removing others when some blob
+// cannot be removed, returns the joined error.
+func (s *BlobStore) Sweep(live func(digest string) bool, gracePeriod time.Duration) (*BlobSweepResult, error) {
+	result := &BlobSweepResult{}
+	now := nowFunc()
+	var errs []error
+	err := s.walk(func(digest, path string, info fs.FileInfo) error {
+		result.Scanned++
+		if live(digest) || now.Sub(info.ModTime()) < gracePeriod {
+			return nil
+		}
+		// re-check the modification time, the blob may be touched by concurrent Put(deduplicated) after walking
+		latest, err := os.Lstat(fixPath(path))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			return nil
+		}
+		if now.Sub(latest.ModTime()) < gracePeriod {
+			return nil
+		}
+		if err := removeFunc(fixPath(path)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			return nil
+		}
+		result.Removed = append(result.Removed, digest)
+		result.RemovedBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, errors.Join(errs...)
+}
+
+// walk calls fn for each blob file in fan-out directories, other files are ignored.
+func (s *BlobStore) walk(fn func(digest, path string, info fs.FileInfo) error) error {
+	level1, err := GetDirectoryList(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, d1 := range level1 {
+		if !isBlobFanout(d1) {
+			continue
+		}
+		level2, err0 := GetDirectoryList(filepath.Join(s.dir, d1))
+		if err0 != nil {
+			return err0
+		}
+		for _, d2 := range level2 {
+			if !isBlobFanout(d2) {
+				continue
+			}
+			dir := filepath.Join(s.dir, d1, d2)
+			entries, err0 := readDirFunc(fixPath(dir))
+			if err0 != nil {
+				return err0
+			}
+			for _, entry := range entries {
+				digest := entry.Name()
+				if !entry.Type().IsRegular() || !isBlobDigest(digest) || digest[0:2] != d1 || digest[2:4] != d2 {
+					continue
+				}
+				info, err0 := entry.Info()
+				if err0 != nil {
+					// removed concurrently
+					continue
+				}
+				if err0 = fn(digest, filepath.Join(dir, digest), info); err0 != nil {
+					return err0
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isBlobDigest returns if the digest is the lower hex of SHA-256.
+func isBlobDigest(digest string) bool {
+	return len(digest) == sha256.Size*2 && isLowerHex(digest)
+}
+
+// isBlobFanout returns if the directory name is the fan-out of digest prefix.
+func isBlobFanout(name string) bool {
+	return len(name) == 2 && isLowerHex(name)
+}
+
+// isLowerHex returns if s only contains lower hex characters.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
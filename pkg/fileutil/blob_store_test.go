@@ -0,0 +1,292 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errReader fails to read.
+type errReader struct{}
+
+func (r *errReader) Read(_ []byte) (int, error) { return 0, fmt.Errorf("read err") }
+
+func TestBlobStore(t *testing.T) {
+	dir := t.TempDir()
+	// temp file left by unfinished writing
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, blobTempDir), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, blobTempDir, "blob-1"), []byte("partial"), 0o600))
+	s, err := NewBlobStore(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, dir, s.Dir())
+	assert.False(t, Exist(filepath.Join(dir, blobTempDir, "blob-1")))
+
+	data := []byte("segment data")
+	sum := sha256.Sum256(data)
+	expect := hex.EncodeToString(sum[:])
+	digest, size, err := s.Put(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, expect, digest)
+	assert.Equal(t, int64(len(data)), size)
+	path, err := s.Path(digest)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, expect[0:2], expect[2:4], expect), path)
+	assert.True(t, s.Has(digest))
+	assert.NoError(t, s.Verify(digest))
+
+	// deduplicated
+	digest2, err := s.PutBytes(data)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, digest2)
+	tmpFiles, err := ListDir(filepath.Join(dir, blobTempDir))
+	assert.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+
+	f, err := s.Open(digest)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	assert.Equal(t, data, content)
+
+	// invalid digest
+	for _, invalid := range []string{"", "abc", strings.ToUpper(expect), strings.Repeat("g", 64)} {
+		_, err = s.Path(invalid)
+		assert.Error(t, err)
+		assert.False(t, s.Has(invalid))
+		_, err = s.Open(invalid)
+		assert.Error(t, err)
+		assert.Error(t, s.Verify(invalid))
+	}
+
+	// corrupted
+	assert.NoError(t, os.WriteFile(path, []byte("corrupted"), 0o600))
+	assert.ErrorContains(t, s.Verify(digest), "is corrupted")
+}
+
+func TestBlobStore_Walk(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBlobStore(dir)
+	assert.NoError(t, err)
+	digests := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		digest, err0 := s.PutBytes([]byte(fmt.Sprintf("blob-%d", i)))
+		assert.NoError(t, err0)
+		digests[digest] = true
+	}
+	// other files are ignored
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "meta"), nil, 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "zz", "00"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "00", "zz"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "00", "00"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "00", "00", strings.Repeat("1", 64)), nil, 0o600))
+
+	walked := make(map[string]bool)
+	assert.NoError(t, s.Walk(func(digest string, info fs.FileInfo) error {
+		walked[digest] = true
+		assert.Equal(t, int64(6), info.Size())
+		return nil
+	}))
+	assert.Equal(t, digests, walked)
+	assert.Error(t, s.Walk(func(_ string, _ fs.FileInfo) error {
+		return fmt.Errorf("err")
+	}))
+}
+
+func TestBlobStore_Sweep(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+		removeFunc = os.Remove
+	}()
+	dir := t.TempDir()
+	s, err := NewBlobStore(dir)
+	assert.NoError(t, err)
+	live, err := s.PutBytes([]byte("live"))
+	assert.NoError(t, err)
+	dead, err := s.PutBytes([]byte("dead"))
+	assert.NoError(t, err)
+	recent, err := s.PutBytes([]byte("recent"))
+	assert.NoError(t, err)
+	for _, digest := range []string{live, dead} {
+		path, _ := s.Path(digest)
+		modTime := time.Now().Add(-2 * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	isLive := func(digest string) bool { return digest == live }
+
+	result, err := s.Sweep(isLive, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, &BlobSweepResult{Scanned: 3, Removed: []string{dead}, RemovedBytes: 4}, result)
+	assert.False(t, s.Has(dead))
+	assert.True(t, s.Has(live))
+	assert.True(t, s.Has(recent))
+
+	// putting identical blob refreshes the modification time
+	nowFunc = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = s.PutBytes([]byte("live"))
+	assert.NoError(t, err)
+	result, err = s.Sweep(func(_ string) bool { return false }, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{recent}, result.Removed)
+	assert.True(t, s.Has(live))
+
+	// remove failure
+	removeFunc = func(_ string) error {
+		return fmt.Errorf("err")
+	}
+	result, err = s.Sweep(func(_ string) bool { return false }, 0)
+	assert.Error(t, err)
+	assert.Equal(t, 1, result.Scanned)
+	assert.Empty(t, result.Removed)
+
+	// walk failure
+	result, err = (&BlobStore{dir: filepath.Join(dir, "not-exist")}).Sweep(isLive, 0)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestBlobStore_Sweep_ConcurrentPut(t *testing.T) {
+	s, err := NewBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	digest, err := s.PutBytes([]byte("blob"))
+	assert.NoError(t, err)
+	removed, err := s.PutBytes([]byte("removed"))
+	assert.NoError(t, err)
+	for _, d := range []string{digest, removed} {
+		path, _ := s.Path(d)
+		modTime := time.Now().Add(-2 * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	// blob is deduplicated by Put after walking, before removing
+	result, err := s.Sweep(func(d string) bool {
+		if d == digest {
+			putDigest, err0 := s.PutBytes([]byte("blob"))
+			assert.NoError(t, err0)
+			assert.Equal(t, digest, putDigest)
+		}
+		return false
+	}, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{removed}, result.Removed)
+	assert.True(t, s.Has(digest))
+	assert.NoError(t, s.Verify(digest))
+
+	// blob is removed by others after walking
+	result, err = s.Sweep(func(d string) bool {
+		path, _ := s.Path(d)
+		assert.NoError(t, os.Remove(path))
+		return false
+	}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Scanned)
+	assert.Empty(t, result.Removed)
+}
+
+func TestBlobStore_Put_Failure(t *testing.T) {
+	defer func() {
+		renameFunc = os.Rename
+		syncFunc = func(f *os.File) error { return f.Sync() }
+	}()
+	dir := t.TempDir()
+	s, err := NewBlobStore(dir)
+	assert.NoError(t, err)
+	assertNoTempFiles := func() {
+		tmpFiles, err0 := ListDir(filepath.Join(dir, blobTempDir))
+		assert.NoError(t, err0)
+		assert.Empty(t, tmpFiles)
+	}
+
+	_, _, err = s.Put(&errReader{})
+	assert.Error(t, err)
+	assertNoTempFiles()
+
+	syncFunc = func(_ *os.File) error { return fmt.Errorf("err") }
+	_, err = s.PutBytes([]byte("a"))
+	assert.Error(t, err)
+	assertNoTempFiles()
+	syncFunc = func(f *os.File) error { return f.Sync() }
+
+	renameFunc = func(_, _ string) error { return fmt.Errorf("err") }
+	_, err = s.PutBytes([]byte("a"))
+	assert.Error(t, err)
+	assertNoTempFiles()
+
+	// written concurrently
+	renameFunc = func(oldPath, newPath string) error {
+		_ = os.Rename(oldPath, newPath)
+		return fmt.Errorf("err")
+	}
+	digest, err := s.PutBytes([]byte("b"))
+	assert.NoError(t, err)
+	assert.True(t, s.Has(digest))
+	assertNoTempFiles()
+
+	// temp dir removed
+	assert.NoError(t, os.RemoveAll(filepath.Join(dir, blobTempDir)))
+	_, err = s.PutBytes([]byte("c"))
+	assert.Error(t, err)
+}
+
+func TestBlobStore_ConcurrentPut(t *testing.T) {
+	s, err := NewBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			digest, err0 := s.PutBytes([]byte("identical"))
+			assert.NoError(t, err0)
+			assert.NoError(t, s.Verify(digest))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewBlobStore_Failure(t *testing.T) {
+	defer func() {
+		mkdirAllFunc = os.MkdirAll
+		removeAllFunc = os.RemoveAll
+	}()
+	mkdirAllFunc = func(_ string, _ os.FileMode) error {
+		return fmt.Errorf("err")
+	}
+	_, err := NewBlobStore(t.TempDir())
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, blobTempDir), 0o755))
+	removeAllFunc = func(_ string) error {
+		return fmt.Errorf("err")
+	}
+	_, err = NewBlobStore(dir)
+	assert.Error(t, err)
+}
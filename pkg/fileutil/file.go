@@ -32,7 +32,7 @@ var (
 // MkDirIfNotExist creates given dir if it's not exist.
 func MkDirIfNotExist(path string) error {
 	if !Exist(path) {
-		if e := mkdirAllFunc(path, os.ModePerm); e != nil {
+		if e := mkdirAllFunc(fixPath(path), os.ModePerm); e != nil {
 			return e
 		}
 	}
@@ -42,7 +42,7 @@ func MkDirIfNotExist(path string) error {
 // RemoveDir deletes dir include children if exist.
 func RemoveDir(path string) error {
 	if Exist(path) {
-		if e := removeAllFunc(path); e != nil {
+		if e := removeAllFunc(fixPath(path)); e != nil {
 			return e
 		}
 	}
@@ -52,7 +52,7 @@ func RemoveDir(path string) error {
 // RemoveFile removes the file if exist.
 func RemoveFile(file string) error {
 	if Exist(file) {
-		if e := removeFunc(file); e != nil {
+		if e := removeFunc(fixPath(file)); e != nil {
 			return e
 		}
 	}
@@ -61,7 +61,7 @@ func RemoveFile(file string) error {
 
 // MkDir creates dir.
 func MkDir(path string) error {
-	if e := mkdirAllFunc(path, os.ModePerm); e != nil {
+	if e := mkdirAllFunc(fixPath(path), os.ModePerm); e != nil {
 		return e
 	}
 	return nil
@@ -93,7 +93,7 @@ func GetDirectoryList(path string) ([]string, error) {
 
 // Exist checks file or dir if exist.
 func Exist(file string) bool {
-	if _, err := os.Stat(file); err != nil && os.IsNotExist(err) {
+	if _, err := os.Stat(fixPath(file)); err != nil && os.IsNotExist(err) {
 		return false
 	}
 	return true
@@ -117,7 +117,7 @@ func GetExistPath(path string) string {
 
 // readDir lists all files/directories.
 func readDir(path string, fn func(f fs.DirEntry)) error {
-	files, err := os.ReadDir(path)
+	files, err := os.ReadDir(fixPath(path))
 	if err != nil {
 		return err
 	}
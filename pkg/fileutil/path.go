@@ -0,0 +1,149 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxShortPath is the max length of windows path without extended-length prefix,
+	// directory path must leave room for 8.3 file name(MAX_PATH(260) - 12).
+	maxShortPath = 248
+	// longPathPrefix is the extended-length path prefix of windows.
+	longPathPrefix = `\\?\`
+	// longUNCPathPrefix is the extended-length path prefix of windows UNC path(\\server\share).
+	longUNCPathPrefix = `\\?\UNC\`
+)
+
+// toLongPath converts the absolute windows path into extended-length path(\\?\C:\... or \\?\UNC\server\share\...)
+// if it's too long, so that the windows API doesn't fail with MAX_PATH limit.
+// Extended-length path disables the path normalization of windows API, so the path is normalized here:
+// forward slash is replaced, duplicated separators and ./.. are resolved.
+// Relative path and device path are returned as is.
+func toLongPath(path string) string {
+	if len(path) < maxShortPath || strings.HasPrefix(path, longPathPrefix) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	p := strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		// UNC path: \\server\share\...
+		parts := splitWindowsPath(p[2:])
+		if len(parts) < 2 {
+			return path
+		}
+		return longUNCPathPrefix + joinWindowsPath(parts[:2], parts[2:])
+	case len(p) >= 3 && isDriveLetter(p[0]) && p[1] == ':' && p[2] == '\\':
+		return longPathPrefix + joinWindowsPath([]string{p[:2]}, splitWindowsPath(p[3:]))
+	default:
+		return path
+	}
+}
+
+// splitWindowsPath splits the path by backslash, empty and . elements are ignored.
+func splitWindowsPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, `\`) {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// joinWindowsPath joins the root and the elements with backslash, .. element removes the previous element,
+// but never goes above root.
+func joinWindowsPath(root, elements []string) string {
+	var rs []string
+	for _, e := range elements {
+		if e == ".." {
+			if len(rs) > 0 {
+				rs = rs[:len(rs)-1]
+			}
+			continue
+		}
+		rs = append(rs, e)
+	}
+	return strings.Join(append(root, rs...), `\`)
+}
+
+// isDriveLetter returns if c is a windows drive letter.
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// windowsPerm returns the effective permission on windows, which only supports the read-only attribute:
+// read-only if owner write bit is not set, and all users have the same permission.
+func windowsPerm(perm os.FileMode, isDir bool) os.FileMode {
+	rs := os.FileMode(0o444)
+	if perm&0o200 != 0 {
+		rs |= 0o222
+	}
+	if isDir {
+		rs |= 0o111
+	}
+	return rs
+}
+
+// FindCaseCollisions returns the groups of names which are equal under case folding(e.g. Data/data),
+// these names refer to the same file on case-insensitive file system(windows/macOS by default).
+// Groups are sorted by the first name, names in group keep the input order.
+func FindCaseCollisions(names []string) [][]string {
+	groups := make(map[string][]string)
+	var keys []string
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], name)
+	}
+	var rs [][]string
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			rs = append(rs, groups[key])
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i][0] < rs[j][0]
+	})
+	return rs
+}
+
+// CaseCollision returns the entry in dir whose name differs from name only by case,
+// returns false if not exist. Creating the name would overwrite the entry on case-insensitive file system.
+func CaseCollision(dir, name string) (string, bool, error) {
+	var (
+		existing string
+		found    bool
+	)
+	if err := readDir(dir, func(f os.DirEntry) {
+		if !found && f.Name() != name && strings.EqualFold(f.Name(), name) {
+			existing, found = f.Name(), true
+		}
+	}); err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return existing, found, nil
+}
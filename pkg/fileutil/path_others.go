@@ -0,0 +1,34 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !windows
+
+package fileutil
+
+import (
+	"os"
+)
+
+// fixPath returns the path as is, no path length limit like windows.
+func fixPath(path string) string {
+	return path
+}
+
+// NormalizePerm returns the effective permission of perm on current platform.
+func NormalizePerm(perm os.FileMode, _ bool) os.FileMode {
+	return perm & os.ModePerm
+}
@@ -0,0 +1,39 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !windows
+
+package fileutil
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixPath(t *testing.T) {
+	long := "/tmp/" + strings.Repeat("a", maxShortPath)
+	assert.Equal(t, long, fixPath(long))
+	assert.Equal(t, "data", fixPath("data"))
+}
+
+func TestNormalizePerm(t *testing.T) {
+	assert.Equal(t, os.FileMode(0o640), NormalizePerm(0o640, false))
+	assert.Equal(t, os.FileMode(0o750), NormalizePerm(os.ModeDir|0o750, true))
+}
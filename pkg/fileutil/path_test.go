@@ -0,0 +1,92 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLongPath(t *testing.T) {
+	long := strings.Repeat("a", maxShortPath)
+	cases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "short path", path: `C:\data\lind`, want: `C:\data\lind`},
+		{name: "drive path", path: `C:\data\` + long, want: `\\?\C:\data\` + long},
+		{name: "forward slash", path: `c:/data//./` + long, want: `\\?\c:\data\` + long},
+		{name: "parent", path: `C:\data\..\..\tmp\` + long + `\..\b`, want: `\\?\C:\tmp\b`},
+		{name: "unc path", path: `\\server\share\` + long, want: `\\?\UNC\server\share\` + long},
+		{name: "unc parent", path: `\\server\share\..\` + long, want: `\\?\UNC\server\share\` + long},
+		{name: "invalid unc path", path: `\\` + long, want: `\\` + long},
+		{name: "already long path", path: `\\?\C:\` + long, want: `\\?\C:\` + long},
+		{name: "device path", path: `\\.\pipe\` + long, want: `\\.\pipe\` + long},
+		{name: "relative path", path: `data\` + long, want: `data\` + long},
+		{name: "drive relative path", path: `C:data\` + long, want: `C:data\` + long},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, toLongPath(tt.path))
+		})
+	}
+}
+
+func TestWindowsPerm(t *testing.T) {
+	assert.Equal(t, os.FileMode(0o666), windowsPerm(0o600, false))
+	assert.Equal(t, os.FileMode(0o444), windowsPerm(0o400, false))
+	assert.Equal(t, os.FileMode(0o444), windowsPerm(0o044, false))
+	assert.Equal(t, os.FileMode(0o777), windowsPerm(0o700, true))
+	assert.Equal(t, os.FileMode(0o555), windowsPerm(0o500, true))
+}
+
+func TestFindCaseCollisions(t *testing.T) {
+	assert.Empty(t, FindCaseCollisions(nil))
+	assert.Empty(t, FindCaseCollisions([]string{"a", "b"}))
+	assert.Equal(t, [][]string{
+		{"DATA", "data", "Data"},
+		{"Log", "log"},
+	}, FindCaseCollisions([]string{"Log", "DATA", "x", "data", "log", "Data"}))
+}
+
+func TestCaseCollision(t *testing.T) {
+	dir := t.TempDir()
+	name, ok, err := CaseCollision(filepath.Join(dir, "not-exist"), "data")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, name)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Data"), []byte("data"), 0o600))
+	name, ok, err = CaseCollision(dir, "data")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Data", name)
+	// same name is not collision
+	_, ok, err = CaseCollision(dir, "Data")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = CaseCollision(filepath.Join(dir, "Data"), "data")
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build windows
+
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixPath(t *testing.T) {
+	assert.Equal(t, "data", fixPath("data"))
+	long := strings.Repeat("a", maxShortPath)
+	assert.True(t, strings.HasPrefix(fixPath(long), longPathPrefix))
+	assert.Equal(t, `\\?\C:\`+long, fixPath(`C:\`+long))
+}
+
+func TestNormalizePerm(t *testing.T) {
+	assert.Equal(t, os.FileMode(0o666), NormalizePerm(0o640, false))
+	assert.Equal(t, os.FileMode(0o555), NormalizePerm(0o550, true))
+}
+
+func TestLongPath_FileAPIs(t *testing.T) {
+	dir := t.TempDir()
+	path := dir
+	for len(path) <= 300 {
+		path = filepath.Join(path, strings.Repeat("d", 50))
+	}
+	assert.NoError(t, MkDirIfNotExist(path))
+	assert.True(t, Exist(path))
+	assert.NoError(t, os.WriteFile(fixPath(filepath.Join(path, "file")), []byte("data"), 0o600))
+	files, err := ListDir(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file"}, files)
+	assert.NoError(t, RemoveFile(filepath.Join(path, "file")))
+	assert.NoError(t, RemoveDir(filepath.Join(dir, strings.Repeat("d", 50))))
+	assert.False(t, Exist(path))
+}
@@ -0,0 +1,137 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// for testing
+var (
+	nowFunc = time.Now
+)
+
+// RetentionPolicy represents the retention policy of files in a directory,
+// the newest files are kept until any limit is exceeded, 0 means no limit.
+type RetentionPolicy struct {
+	// Patterns are the glob patterns of file name(e.g. "*.pprof", "core.*"), all files if empty.
+	Patterns []string
+	// MaxAge removes the files modified before now-MaxAge.
+	MaxAge time.Duration
+	// MaxTotalSize removes the oldest files when total size of files exceeds it.
+	MaxTotalSize int64
+	// MaxFiles removes the oldest files when the number of files exceeds it.
+	MaxFiles int
+}
+
+// RetentionResult represents the result of applying retention policy.
+type RetentionResult struct {
+	Scanned        int      // number of matched files
+	Removed        []string // paths of removed files
+	RemovedBytes   int64    // total size of removed files
+	RemainingBytes int64    // total size of kept files
+}
+
+type retentionFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// ApplyRetention removes the files(not recursive, directories are ignored) in dir which exceed the retention policy,
+// keeps removing others when some file cannot be removed, returns the joined error. Not exist dir is ignored.
+func ApplyRetention(dir string, policy RetentionPolicy) (*RetentionResult, error) {
+	result := &RetentionResult{}
+	entries, err := readDirFunc(fixPath(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+	var files []retentionFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		matched, err0 := matchRetentionPatterns(policy.Patterns, entry.Name())
+		if err0 != nil {
+			return nil, err0
+		}
+		if !matched {
+			continue
+		}
+		info, err0 := entry.Info()
+		if err0 != nil {
+			// removed concurrently
+			continue
+		}
+		files = append(files, retentionFile{
+			path:    filepath.Join(dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	result.Scanned = len(files)
+	// newest first
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	now := nowFunc()
+	kept := 0
+	var errs []error
+	for _, file := range files {
+		keep := (policy.MaxAge <= 0 || now.Sub(file.modTime) <= policy.MaxAge) &&
+			(policy.MaxFiles <= 0 || kept < policy.MaxFiles) &&
+			(policy.MaxTotalSize <= 0 || result.RemainingBytes+file.size <= policy.MaxTotalSize)
+		if keep {
+			kept++
+			result.RemainingBytes += file.size
+			continue
+		}
+		if err = removeFunc(fixPath(file.path)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			result.RemainingBytes += file.size
+			continue
+		}
+		result.Removed = append(result.Removed, file.path)
+		result.RemovedBytes += file.size
+	}
+	return result, errors.Join(errs...)
+}
+
+// matchRetentionPatterns returns if the file name matches any pattern, true if no pattern.
+func matchRetentionPatterns(patterns []string, name string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
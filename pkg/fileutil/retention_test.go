@@ -0,0 +1,135 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createRetentionFiles creates files named 0.pprof..n-1.pprof with 100 bytes,
+// i.pprof is modified i hours ago.
+func createRetentionFiles(t *testing.T, dir string, n int, now time.Time) {
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.pprof", i))
+		assert.NoError(t, os.WriteFile(path, make([]byte, 100), 0o600))
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+
+	cases := []struct {
+		name    string
+		policy  RetentionPolicy
+		removed []string
+	}{
+		{name: "no limit", policy: RetentionPolicy{}},
+		{name: "max age", policy: RetentionPolicy{MaxAge: 150 * time.Minute}, removed: []string{"3.pprof", "4.pprof"}},
+		{name: "max files", policy: RetentionPolicy{MaxFiles: 4}, removed: []string{"4.pprof"}},
+		{name: "max size", policy: RetentionPolicy{MaxTotalSize: 250}, removed: []string{"2.pprof", "3.pprof", "4.pprof"}},
+		{
+			name:    "combined",
+			policy:  RetentionPolicy{MaxAge: 150 * time.Minute, MaxFiles: 4, MaxTotalSize: 350},
+			removed: []string{"3.pprof", "4.pprof"},
+		},
+		{name: "patterns", policy: RetentionPolicy{Patterns: []string{"core.*"}, MaxFiles: 1}},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			createRetentionFiles(t, dir, 5, now)
+			assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), os.ModePerm))
+			result, err := ApplyRetention(dir, tt.policy)
+			assert.NoError(t, err)
+			var removed []string
+			for _, path := range result.Removed {
+				removed = append(removed, filepath.Base(path))
+				assert.False(t, Exist(path))
+			}
+			assert.Equal(t, tt.removed, removed)
+			assert.Equal(t, int64(len(tt.removed)*100), result.RemovedBytes)
+			if len(tt.policy.Patterns) == 0 {
+				assert.Equal(t, 5, result.Scanned)
+				assert.Equal(t, int64(500-len(tt.removed)*100), result.RemainingBytes)
+			} else {
+				assert.Equal(t, 0, result.Scanned)
+			}
+		})
+	}
+}
+
+func TestApplyRetention_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	createRetentionFiles(t, dir, 2, time.Now())
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "core.123"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lind.log"), nil, 0o600))
+	result, err := ApplyRetention(dir, RetentionPolicy{Patterns: []string{"*.pprof", "core.*"}, MaxFiles: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Scanned)
+	assert.Len(t, result.Removed, 2)
+	assert.True(t, Exist(filepath.Join(dir, "lind.log")))
+
+	// bad pattern
+	_, err = ApplyRetention(dir, RetentionPolicy{Patterns: []string{"["}})
+	assert.Error(t, err)
+}
+
+func TestApplyRetention_Errors(t *testing.T) {
+	defer func() {
+		readDirFunc = os.ReadDir
+		removeFunc = os.Remove
+	}()
+	// not exist
+	result, err := ApplyRetention(filepath.Join(t.TempDir(), "not-exist"), RetentionPolicy{MaxFiles: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Scanned)
+
+	readDirFunc = func(_ string) ([]fs.DirEntry, error) {
+		return nil, fmt.Errorf("err")
+	}
+	_, err = ApplyRetention(t.TempDir(), RetentionPolicy{})
+	assert.Error(t, err)
+	readDirFunc = os.ReadDir
+
+	dir := t.TempDir()
+	createRetentionFiles(t, dir, 3, time.Now())
+	removeFunc = func(name string) error {
+		if filepath.Base(name) == "1.pprof" {
+			return fmt.Errorf("err")
+		}
+		return os.Remove(name)
+	}
+	result, err = ApplyRetention(dir, RetentionPolicy{MaxFiles: 1})
+	assert.Error(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "2.pprof")}, result.Removed)
+	assert.Equal(t, int64(200), result.RemainingBytes)
+}
@@ -0,0 +1,164 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// for testing
+var (
+	readDirFunc = os.ReadDir
+)
+
+// WalkFunc is the function called for each file or directory visited by WalkParallel,
+// returning fs.SkipDir for a directory skips its children, other errors are collected without stopping the walk.
+// WalkFunc may be invoked concurrently.
+type WalkFunc func(path string, d fs.DirEntry) error
+
+// walkError represents the error of visiting the path.
+type walkError struct {
+	path string
+	err  error
+}
+
+// parallelWalker walks the directories using a pool of workers.
+type parallelWalker struct {
+	ctx     context.Context
+	fn      WalkFunc
+	queue   []string // directories waiting for reading
+	pending int      // directories queued or being read
+	errs    []walkError
+
+	lock sync.Mutex
+	cond *sync.Cond
+}
+
+// WalkParallel walks the file tree rooted at root(include root) with concurrency workers(GOMAXPROCS if <= 0),
+// the entries of different directories are visited in parallel, no order guaranteed.
+// Walking stops when ctx is done. All errors are returned joined in order of path.
+func WalkParallel(ctx context.Context, root string, fn WalkFunc, concurrency int) error {
+	info, err := os.Lstat(fixPath(root))
+	if err != nil {
+		return err
+	}
+	if err := fn(root, fs.FileInfoToDirEntry(info)); err != nil {
+		if errors.Is(err, fs.SkipDir) {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	w := &parallelWalker{
+		ctx:     ctx,
+		fn:      fn,
+		queue:   []string{root},
+		pending: 1,
+	}
+	w.cond = sync.NewCond(&w.lock)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+	return w.result()
+}
+
+// work takes directory from queue until all directories are visited.
+func (w *parallelWalker) work() {
+	for {
+		w.lock.Lock()
+		for len(w.queue) == 0 && w.pending > 0 {
+			w.cond.Wait()
+		}
+		if w.pending == 0 {
+			w.lock.Unlock()
+			return
+		}
+		dir := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.lock.Unlock()
+
+		dirs, errs := w.visit(dir)
+
+		w.lock.Lock()
+		w.queue = append(w.queue, dirs...)
+		w.pending += len(dirs) - 1
+		w.errs = append(w.errs, errs...)
+		w.lock.Unlock()
+		w.cond.Broadcast()
+	}
+}
+
+// visit reads the directory and visits its entries, returns the sub directories for walking.
+func (w *parallelWalker) visit(dir string) (dirs []string, errs []walkError) {
+	if w.ctx.Err() != nil {
+		return nil, nil
+	}
+	entries, err := readDirFunc(fixPath(dir))
+	if err != nil {
+		return nil, []walkError{{path: dir, err: err}}
+	}
+	for _, entry := range entries {
+		if w.ctx.Err() != nil {
+			return dirs, errs
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := w.fn(path, entry); err != nil {
+			if !errors.Is(err, fs.SkipDir) {
+				errs = append(errs, walkError{path: path, err: err})
+			}
+			continue
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs, errs
+}
+
+// result returns the errors joined in order of path.
+func (w *parallelWalker) result() error {
+	sort.Slice(w.errs, func(i, j int) bool {
+		return w.errs[i].path < w.errs[j].path
+	})
+	errs := make([]error, 0, len(w.errs)+1)
+	if err := w.ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, e := range w.errs {
+		errs = append(errs, e.err)
+	}
+	return errors.Join(errs...)
+}
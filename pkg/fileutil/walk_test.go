@@ -0,0 +1,164 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createWalkTree(t *testing.T) string {
+	root := t.TempDir()
+	for i := 0; i < 5; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("shard-%d", i), "segment")
+		assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+		for j := 0; j < 10; j++ {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.sst", j)), nil, 0o600))
+		}
+	}
+	return root
+}
+
+func TestWalkParallel(t *testing.T) {
+	root := createWalkTree(t)
+	var (
+		lock  sync.Mutex
+		paths []string
+	)
+	err := WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		lock.Lock()
+		defer lock.Unlock()
+		paths = append(paths, path)
+		return nil
+	}, 4)
+	assert.NoError(t, err)
+
+	var expect []string
+	assert.NoError(t, filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		expect = append(expect, path)
+		return err
+	}))
+	sort.Strings(paths)
+	sort.Strings(expect)
+	assert.Equal(t, expect, paths)
+	assert.Len(t, paths, 1+5*2+5*10)
+}
+
+func TestWalkParallel_SkipDir(t *testing.T) {
+	root := createWalkTree(t)
+	var (
+		lock  sync.Mutex
+		count int
+	)
+	err := WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		lock.Lock()
+		defer lock.Unlock()
+		count++
+		if d.IsDir() && d.Name() == "segment" {
+			return fs.SkipDir
+		}
+		return nil
+	}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1+5*2, count)
+
+	// skip root
+	assert.NoError(t, WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		return fs.SkipDir
+	}, 1))
+}
+
+func TestWalkParallel_Errors(t *testing.T) {
+	defer func() {
+		readDirFunc = os.ReadDir
+	}()
+	root := createWalkTree(t)
+	// root not exist
+	assert.Error(t, WalkParallel(context.TODO(), filepath.Join(root, "not-exist"), nil, 1))
+	// root error
+	err := WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		return fmt.Errorf("root")
+	}, 1)
+	assert.EqualError(t, err, "root")
+	// root is file
+	file := filepath.Join(root, "shard-0", "segment", "0.sst")
+	count := 0
+	assert.NoError(t, WalkParallel(context.TODO(), file, func(path string, d fs.DirEntry) error {
+		count++
+		return nil
+	}, 1))
+	assert.Equal(t, 1, count)
+
+	// errors in order of path
+	err = WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		if d.Name() == "1.sst" {
+			rel, _ := filepath.Rel(root, path)
+			return errors.New(rel)
+		}
+		return nil
+	}, 4)
+	assert.Equal(t, "shard-0/segment/1.sst\nshard-1/segment/1.sst\nshard-2/segment/1.sst\n"+
+		"shard-3/segment/1.sst\nshard-4/segment/1.sst", err.Error())
+
+	// read dir failure
+	readDirFunc = func(name string) ([]os.DirEntry, error) {
+		return nil, fmt.Errorf("read dir failure")
+	}
+	err = WalkParallel(context.TODO(), root, func(path string, d fs.DirEntry) error {
+		return nil
+	}, 4)
+	assert.EqualError(t, err, "read dir failure")
+}
+
+func TestWalkParallel_Cancel(t *testing.T) {
+	root := createWalkTree(t)
+	ctx, cancel := context.WithCancel(context.TODO())
+	var (
+		lock  sync.Mutex
+		count int
+	)
+	err := WalkParallel(ctx, root, func(path string, d fs.DirEntry) error {
+		lock.Lock()
+		defer lock.Unlock()
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return nil
+	}, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, count, 1+5*2+5*10)
+
+	// cancelled before walking
+	count = 0
+	err = WalkParallel(ctx, root, func(path string, d fs.DirEntry) error {
+		count++
+		return nil
+	}, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,177 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+const (
+	// CompressionNone represents no compression.
+	CompressionNone = "none"
+	// CompressionGzip represents gzip compression.
+	CompressionGzip = gzip.Name
+	// CompressionSnappy represents snappy compression.
+	CompressionSnappy = snappyName
+)
+
+// Setting represents grpc message size, compression and keepalive configuration.
+type Setting struct {
+	MaxRecvMsgSize      ltoml.Size     `env:"MAX_RECV_MSG_SIZE" toml:"max-recv-msg-size"`
+	MaxSendMsgSize      ltoml.Size     `env:"MAX_SEND_MSG_SIZE" toml:"max-send-msg-size"`
+	Compression         string         `env:"COMPRESSION" toml:"compression"`
+	KeepaliveTime       ltoml.Duration `env:"KEEPALIVE_TIME" toml:"keepalive-time"`
+	KeepaliveTimeout    ltoml.Duration `env:"KEEPALIVE_TIMEOUT" toml:"keepalive-timeout"`
+	PermitWithoutStream bool           `env:"PERMIT_WITHOUT_STREAM" toml:"permit-without-stream"`
+}
+
+// TOML returns grpc setting's toml config string.
+func (s *Setting) TOML(prefix string) string {
+	return fmt.Sprintf(`
+## grpc related configuration.
+[grpc]
+## MaxRecvMsgSize is the max message size in bytes the server/client can receive.
+## Default: %s
+## Env: %s_GRPC_MAX_RECV_MSG_SIZE
+max-recv-msg-size = "%s"
+## MaxSendMsgSize is the max message size in bytes the server/client can send.
+## Default: %s
+## Env: %s_GRPC_MAX_SEND_MSG_SIZE
+max-send-msg-size = "%s"
+## Compression is the compressor used by client calls.
+## none, gzip, and snappy are available
+## Default: %s
+## Env: %s_GRPC_COMPRESSION
+compression = "%s"
+## KeepaliveTime is the interval of pinging the peer if no activity.
+## Default: %s
+## Env: %s_GRPC_KEEPALIVE_TIME
+keepalive-time = "%s"
+## KeepaliveTimeout is the timeout of waiting for keepalive ping ack.
+## Default: %s
+## Env: %s_GRPC_KEEPALIVE_TIMEOUT
+keepalive-timeout = "%s"
+## PermitWithoutStream allows keepalive pings even if there are no active streams.
+## Default: %t
+## Env: %s_GRPC_PERMIT_WITHOUT_STREAM
+permit-without-stream = %t`,
+		s.MaxRecvMsgSize,
+		prefix,
+		s.MaxRecvMsgSize,
+		s.MaxSendMsgSize,
+		prefix,
+		s.MaxSendMsgSize,
+		s.Compression,
+		prefix,
+		s.Compression,
+		s.KeepaliveTime,
+		prefix,
+		s.KeepaliveTime,
+		s.KeepaliveTimeout,
+		prefix,
+		s.KeepaliveTimeout,
+		s.PermitWithoutStream,
+		prefix,
+		s.PermitWithoutStream,
+	)
+}
+
+// Validate checks if the setting is valid.
+func (s *Setting) Validate() error {
+	switch s.Compression {
+	case "", CompressionNone, CompressionGzip, CompressionSnappy:
+	default:
+		return fmt.Errorf("grpc compression: %s not support", s.Compression)
+	}
+	if s.KeepaliveTime < 0 || s.KeepaliveTimeout < 0 {
+		return fmt.Errorf("grpc keepalive time/timeout cannot be negative")
+	}
+	return nil
+}
+
+// ServerOptions returns the grpc server options based on the setting.
+func (s *Setting) ServerOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if s.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(s.MaxRecvMsgSize)))
+	}
+	if s.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(int(s.MaxSendMsgSize)))
+	}
+	if s.KeepaliveTime > 0 {
+		opts = append(opts,
+			grpc.KeepaliveParams(keepalive.ServerParameters{
+				Time:    s.KeepaliveTime.Duration(),
+				Timeout: s.KeepaliveTimeout.Duration(),
+			}),
+			// allow client pings which are as frequent as server pings
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             s.KeepaliveTime.Duration(),
+				PermitWithoutStream: s.PermitWithoutStream,
+			}),
+		)
+	}
+	return opts
+}
+
+// DialOptions returns the grpc dial options based on the setting.
+func (s *Setting) DialOptions() []grpc.DialOption {
+	var (
+		opts     []grpc.DialOption
+		callOpts []grpc.CallOption
+	)
+	if s.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(int(s.MaxRecvMsgSize)))
+	}
+	if s.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(int(s.MaxSendMsgSize)))
+	}
+	if s.Compression != "" && s.Compression != CompressionNone {
+		callOpts = append(callOpts, grpc.UseCompressor(s.Compression))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	if s.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                s.KeepaliveTime.Duration(),
+			Timeout:             s.KeepaliveTimeout.Duration(),
+			PermitWithoutStream: s.PermitWithoutStream,
+		}))
+	}
+	return opts
+}
+
+// NewDefaultSetting returns a new default grpc setting.
+func NewDefaultSetting() *Setting {
+	return &Setting{
+		MaxRecvMsgSize:      ltoml.Size(16 * 1024 * 1024),
+		MaxSendMsgSize:      ltoml.Size(16 * 1024 * 1024),
+		Compression:         CompressionNone,
+		KeepaliveTime:       ltoml.Duration(time.Minute),
+		KeepaliveTimeout:    ltoml.Duration(20 * time.Second),
+		PermitWithoutStream: true,
+	}
+}
@@ -0,0 +1,60 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+func TestSetting_TOML(t *testing.T) {
+	setting := NewDefaultSetting()
+	cfgFile := filepath.Join(t.TempDir(), "grpc.toml")
+	assert.NoError(t, ltoml.WriteConfig(cfgFile, setting.TOML("TEST")))
+
+	cfg := struct {
+		GRPC Setting `toml:"grpc"`
+	}{}
+	assert.NoError(t, ltoml.DecodeToml(cfgFile, &cfg))
+	assert.Equal(t, *setting, cfg.GRPC)
+}
+
+func TestSetting_Validate(t *testing.T) {
+	assert.NoError(t, NewDefaultSetting().Validate())
+	assert.NoError(t, (&Setting{Compression: CompressionSnappy}).Validate())
+	assert.Error(t, (&Setting{Compression: "lz4"}).Validate())
+	assert.Error(t, (&Setting{KeepaliveTime: ltoml.Duration(-time.Second)}).Validate())
+}
+
+func TestSetting_Options(t *testing.T) {
+	setting := NewDefaultSetting()
+	assert.Len(t, setting.ServerOptions(), 4)
+	assert.Len(t, setting.DialOptions(), 2)
+
+	setting.Compression = CompressionGzip
+	assert.Len(t, setting.DialOptions(), 2)
+
+	setting = &Setting{}
+	assert.Empty(t, setting.ServerOptions())
+	assert.Empty(t, setting.DialOptions())
+}
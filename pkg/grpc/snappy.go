@@ -0,0 +1,95 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"google.golang.org/grpc/encoding"
+)
+
+const snappyName = "snappy"
+
+func init() {
+	encoding.RegisterCompressor(newSnappyCompressor())
+}
+
+// snappyCompressor implements grpc encoding.Compressor using snappy framing format.
+type snappyCompressor struct {
+	writerPool sync.Pool
+	readerPool sync.Pool
+}
+
+func newSnappyCompressor() *snappyCompressor {
+	c := &snappyCompressor{}
+	c.writerPool.New = func() any {
+		return &snappyWriter{Writer: snappy.NewBufferedWriter(nil), pool: &c.writerPool}
+	}
+	c.readerPool.New = func() any {
+		return &snappyReader{Reader: snappy.NewReader(nil), pool: &c.readerPool}
+	}
+	return c
+}
+
+// Compress returns a writer which compresses data into w.
+func (c *snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	writer := c.writerPool.Get().(*snappyWriter)
+	writer.Reset(w)
+	return writer, nil
+}
+
+// Decompress returns a reader which decompresses data from r.
+func (c *snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	reader := c.readerPool.Get().(*snappyReader)
+	reader.Reset(r)
+	return reader, nil
+}
+
+// Name returns the compressor name.
+func (c *snappyCompressor) Name() string {
+	return snappyName
+}
+
+// snappyWriter puts the writer back to pool after closed.
+type snappyWriter struct {
+	*snappy.Writer
+	pool *sync.Pool
+}
+
+// Close flushes data then puts the writer back to pool.
+func (w *snappyWriter) Close() error {
+	defer w.pool.Put(w)
+	return w.Writer.Close()
+}
+
+// snappyReader puts the reader back to pool after reading EOF.
+type snappyReader struct {
+	*snappy.Reader
+	pool *sync.Pool
+}
+
+// Read reads decompressed data, puts the reader back to pool if EOF.
+func (r *snappyReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r)
+	}
+	return n, err
+}
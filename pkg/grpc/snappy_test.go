@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestSnappyCompressor(t *testing.T) {
+	compressor := encoding.GetCompressor(CompressionSnappy)
+	assert.NotNil(t, compressor)
+	assert.Equal(t, CompressionSnappy, compressor.Name())
+
+	for i := 0; i < 3; i++ {
+		data := bytes.Repeat([]byte("lindb"), 1024*(i+1))
+		buf := &bytes.Buffer{}
+		w, err := compressor.Compress(buf)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.Less(t, buf.Len(), len(data))
+
+		r, err := compressor.Decompress(buf)
+		assert.NoError(t, err)
+		decompressed, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+}
@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hashutil
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/spaolacci/murmur3"
+	"github.com/zeebo/xxh3"
+)
+
+// Hash128 represents a 128-bit hash value.
+type Hash128 struct {
+	Hi, Lo uint64
+}
+
+// Bytes returns the hash as big-endian bytes.
+func (h Hash128) Bytes() [16]byte {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], h.Hi)
+	binary.BigEndian.PutUint64(buf[8:], h.Lo)
+	return buf
+}
+
+// String returns the hex string of the hash.
+func (h Hash128) String() string {
+	buf := h.Bytes()
+	return hex.EncodeToString(buf[:])
+}
+
+// XXH3Hash128 returns the 128-bit xxh3 hash of data.
+func XXH3Hash128(data []byte) Hash128 {
+	h := xxh3.Hash128(data)
+	return Hash128{Hi: h.Hi, Lo: h.Lo}
+}
+
+// XXH3Hash128String returns the 128-bit xxh3 hash of string.
+func XXH3Hash128String(s string) Hash128 {
+	h := xxh3.HashString128(s)
+	return Hash128{Hi: h.Hi, Lo: h.Lo}
+}
+
+// Murmur3Hash128 returns the 128-bit murmur3(x64) hash of data.
+func Murmur3Hash128(data []byte) Hash128 {
+	h1, h2 := murmur3.Sum128(data)
+	return Hash128{Hi: h1, Lo: h2}
+}
@@ -0,0 +1,37 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hashutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash128(t *testing.T) {
+	h := XXH3Hash128([]byte("lindb"))
+	assert.Equal(t, h, XXH3Hash128String("lindb"))
+	assert.NotEqual(t, h, XXH3Hash128([]byte("lindb1")))
+	assert.Len(t, h.String(), 32)
+	buf := h.Bytes()
+	assert.Len(t, buf, 16)
+
+	h = Murmur3Hash128([]byte("lindb"))
+	assert.NotEqual(t, h, Murmur3Hash128([]byte("lindb1")))
+	assert.Equal(t, Hash128{Hi: 0x1, Lo: 0x2}.String(), "00000000000000010000000000000002")
+}
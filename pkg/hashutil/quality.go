@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hashutil
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// CollisionStats represents the collision test result of a hash function.
+type CollisionStats struct {
+	Keys       int
+	Collisions int
+}
+
+// CollisionRate returns collisions / keys.
+func (s CollisionStats) CollisionRate() float64 {
+	if s.Keys == 0 {
+		return 0
+	}
+	return float64(s.Collisions) / float64(s.Keys)
+}
+
+// CheckCollisions hashes n distinct keys generated by keyFn, returns how many keys collide with former keys.
+func CheckCollisions(n int, keyFn func(i int) []byte, hashFn func(data []byte) Hash128) CollisionStats {
+	seen := make(map[Hash128]struct{}, n)
+	stats := CollisionStats{Keys: n}
+	for i := 0; i < n; i++ {
+		h := hashFn(keyFn(i))
+		if _, ok := seen[h]; ok {
+			stats.Collisions++
+			continue
+		}
+		seen[h] = struct{}{}
+	}
+	return stats
+}
+
+// CheckCollisions64 is the same as CheckCollisions for 64-bit hash function.
+func CheckCollisions64(n int, keyFn func(i int) []byte, hashFn func(data []byte) uint64) CollisionStats {
+	return CheckCollisions(n, keyFn, func(data []byte) Hash128 {
+		return Hash128{Lo: hashFn(data)}
+	})
+}
+
+// Avalanche flips each input bit of random keys, returns the average ratio of changed output bits.
+// A good hash function is expected to return a ratio close to 0.5.
+func Avalanche(samples, keyLen int, seed int64, hashFn func(data []byte) Hash128) float64 {
+	if samples <= 0 || keyLen <= 0 {
+		return 0
+	}
+	r := rand.New(rand.NewSource(seed)) //nolint:gosec
+	key := make([]byte, keyLen)
+	var changed, total int
+	for i := 0; i < samples; i++ {
+		_, _ = r.Read(key)
+		origin := hashFn(key)
+		for bit := 0; bit < keyLen*8; bit++ {
+			key[bit/8] ^= 1 << (bit % 8)
+			h := hashFn(key)
+			key[bit/8] ^= 1 << (bit % 8)
+			changed += bits.OnesCount64(origin.Hi^h.Hi) + bits.OnesCount64(origin.Lo^h.Lo)
+			total += 128
+		}
+	}
+	return float64(changed) / float64(total)
+}
@@ -0,0 +1,49 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hashutil
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCollisions(t *testing.T) {
+	keyFn := func(i int) []byte {
+		return []byte("host=host-" + strconv.Itoa(i) + ",ip=127.0.0.1")
+	}
+	assert.Zero(t, CheckCollisions(100000, keyFn, XXH3Hash128).Collisions)
+	assert.Zero(t, CheckCollisions(100000, keyFn, Murmur3Hash128).Collisions)
+	assert.Zero(t, CheckCollisions64(100000, keyFn, xxhash.Sum64).Collisions)
+
+	// bad hash function
+	stats := CheckCollisions64(100, keyFn, func(data []byte) uint64 { return uint64(len(data)) })
+	assert.Equal(t, 98, stats.Collisions)
+	assert.Equal(t, 0.98, stats.CollisionRate())
+	assert.Zero(t, CollisionStats{}.CollisionRate())
+}
+
+func TestAvalanche(t *testing.T) {
+	assert.InDelta(t, 0.5, Avalanche(10, 16, 1, XXH3Hash128), 0.02)
+	assert.InDelta(t, 0.5, Avalanche(10, 16, 1, Murmur3Hash128), 0.02)
+	assert.Zero(t, Avalanche(0, 16, 1, XXH3Hash128))
+	// identity hash function
+	assert.Less(t, Avalanche(10, 8, 1, func(data []byte) Hash128 { return Hash128{Lo: uint64(data[0])} }), 0.01)
+}
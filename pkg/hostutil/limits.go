@@ -0,0 +1,281 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hostutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// for testing
+var (
+	cgroupRoot     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+	procMeminfo    = "/proc/meminfo"
+	readFileFunc   = os.ReadFile
+	numCPUFunc     = runtime.NumCPU
+)
+
+// cgroupV1UnlimitedMemory is the threshold of unlimited memory of cgroup v1(page aligned max int64).
+const cgroupV1UnlimitedMemory = math.MaxInt64 / 2
+
+// Limits represents the CPU/memory resources available for current process,
+// which are limited by container(cgroup v1/v2) or the host.
+type Limits struct {
+	CgroupVersion int     // 0 means not in cgroup(or not detected)
+	CPUQuota      float64 // number of cores limited by cgroup, 0 means unlimited
+	MemoryLimit   uint64  // bytes limited by cgroup, 0 means unlimited
+	HostMemory    uint64  // total memory of the host, 0 means unknown
+}
+
+// DetectLimits detects the CPU quota and memory limit of cgroup v1/v2, the limits are
+// unlimited if cgroup isn't mounted(e.g. not linux).
+func DetectLimits() (*Limits, error) {
+	limits := &Limits{}
+	hostMemory, err := readHostMemory()
+	if err != nil {
+		return nil, err
+	}
+	limits.HostMemory = hostMemory
+	if isFile(filepath.Join(cgroupRoot, "cgroup.controllers")) {
+		limits.CgroupVersion = 2
+		err = detectCgroupV2(limits)
+	} else if isDir(filepath.Join(cgroupRoot, "memory")) || isDir(filepath.Join(cgroupRoot, "cpu")) {
+		limits.CgroupVersion = 1
+		err = detectCgroupV1(limits)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// GOMAXPROCS returns the proper GOMAXPROCS, which is the ceiling of CPU quota(at least 1)
+// but not more than the number of CPUs.
+func (l *Limits) GOMAXPROCS() int {
+	numCPU := numCPUFunc()
+	if l.CPUQuota <= 0 {
+		return numCPU
+	}
+	procs := int(math.Ceil(l.CPUQuota))
+	if procs < 1 {
+		procs = 1
+	}
+	if procs > numCPU {
+		procs = numCPU
+	}
+	return procs
+}
+
+// SetGOMAXPROCS sets GOMAXPROCS by CPU quota if GOMAXPROCS env isn't set, returns the value in effect.
+func (l *Limits) SetGOMAXPROCS() int {
+	if os.Getenv("GOMAXPROCS") != "" {
+		return runtime.GOMAXPROCS(0)
+	}
+	procs := l.GOMAXPROCS()
+	runtime.GOMAXPROCS(procs)
+	return procs
+}
+
+// AvailableMemory returns the memory available for current process, the smaller of memory limit
+// and host memory, 0 means unknown.
+func (l *Limits) AvailableMemory() uint64 {
+	if l.MemoryLimit > 0 && (l.HostMemory == 0 || l.MemoryLimit < l.HostMemory) {
+		return l.MemoryLimit
+	}
+	return l.HostMemory
+}
+
+// CacheSize returns the default cache size by the ratio of available memory,
+// returns fallback if available memory is unknown.
+func (l *Limits) CacheSize(ratio float64, fallback uint64) uint64 {
+	available := l.AvailableMemory()
+	if available == 0 || ratio <= 0 {
+		return fallback
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return uint64(float64(available) * ratio)
+}
+
+// String returns the string of limits.
+func (l *Limits) String() string {
+	return fmt.Sprintf("cgroup: v%d, cpu quota: %g, memory limit: %d, host memory: %d",
+		l.CgroupVersion, l.CPUQuota, l.MemoryLimit, l.HostMemory)
+}
+
+// detectCgroupV2 reads cpu.max and memory.max of the cgroup of current process.
+func detectCgroupV2(limits *Limits) error {
+	paths, err := readSelfCgroupPaths()
+	if err != nil {
+		return err
+	}
+	dirs := cgroupDirs(cgroupRoot, paths[""])
+	if data, ok := readFirst(dirs, "cpu.max"); ok {
+		// $MAX $PERIOD, e.g. "max 100000" or "200000 100000"
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, err0 := strconv.ParseFloat(fields[0], 64)
+			period, err1 := strconv.ParseFloat(fields[1], 64)
+			if err0 != nil || err1 != nil || period <= 0 {
+				return fmt.Errorf("invalid cgroup v2 cpu.max: %q", data)
+			}
+			limits.CPUQuota = quota / period
+		}
+	}
+	if data, ok := readFirst(dirs, "memory.max"); ok {
+		value := strings.TrimSpace(string(data))
+		if value != "max" {
+			limit, err0 := strconv.ParseUint(value, 10, 64)
+			if err0 != nil {
+				return fmt.Errorf("invalid cgroup v2 memory.max: %q", data)
+			}
+			limits.MemoryLimit = limit
+		}
+	}
+	return nil
+}
+
+// detectCgroupV1 reads cpu.cfs_quota_us/cpu.cfs_period_us and memory.limit_in_bytes of the cgroup of current process.
+func detectCgroupV1(limits *Limits) error {
+	paths, err := readSelfCgroupPaths()
+	if err != nil {
+		return err
+	}
+	var cpuDirs []string
+	for _, controller := range []string{"cpu,cpuacct", "cpu"} {
+		cpuDirs = append(cpuDirs, cgroupDirs(filepath.Join(cgroupRoot, controller), paths["cpu"])...)
+	}
+	quotaData, ok := readFirst(cpuDirs, "cpu.cfs_quota_us")
+	periodData, ok1 := readFirst(cpuDirs, "cpu.cfs_period_us")
+	if ok && ok1 {
+		quota, err0 := strconv.ParseInt(strings.TrimSpace(string(quotaData)), 10, 64)
+		period, err1 := strconv.ParseInt(strings.TrimSpace(string(periodData)), 10, 64)
+		if err0 != nil || err1 != nil {
+			return fmt.Errorf("invalid cgroup v1 cpu quota: %q, period: %q", quotaData, periodData)
+		}
+		if quota > 0 && period > 0 {
+			limits.CPUQuota = float64(quota) / float64(period)
+		}
+	}
+	if data, ok := readFirst(cgroupDirs(filepath.Join(cgroupRoot, "memory"), paths["memory"]), "memory.limit_in_bytes"); ok {
+		limit, err0 := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err0 != nil {
+			return fmt.Errorf("invalid cgroup v1 memory.limit_in_bytes: %q", data)
+		}
+		if limit < cgroupV1UnlimitedMemory {
+			limits.MemoryLimit = limit
+		}
+	}
+	return nil
+}
+
+// readSelfCgroupPaths reads the cgroup paths of current process by controller,
+// the key of cgroup v2 is empty, e.g.
+//
+//	v1: 4:cpu,cpuacct:/kubepods/pod1/container1
+//	v2: 0::/kubepods/pod1/container1
+func readSelfCgroupPaths() (map[string]string, error) {
+	paths := make(map[string]string)
+	data, err := readFileFunc(procSelfCgroup)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return paths, nil
+		}
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths, nil
+}
+
+// cgroupDirs returns the candidate dirs of the cgroup, the mount root is the fallback,
+// because the cgroup path is the mount root if cgroup namespace is enabled in container.
+func cgroupDirs(mount, path string) []string {
+	if path == "" || path == "/" {
+		return []string{mount}
+	}
+	return []string{filepath.Join(mount, path), mount}
+}
+
+// readFirst reads the file in the first dir which has it.
+func readFirst(dirs []string, name string) ([]byte, bool) {
+	for _, dir := range dirs {
+		if data, err := readFileFunc(filepath.Join(dir, name)); err == nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// readHostMemory reads MemTotal of /proc/meminfo, returns 0 if not exist.
+func readHostMemory() (uint64, error) {
+	data, err := readFileFunc(procMeminfo)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// MemTotal:       16318412 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err0 := strconv.ParseUint(fields[1], 10, 64)
+		if err0 != nil {
+			return 0, fmt.Errorf("invalid MemTotal of meminfo: %q", scanner.Text())
+		}
+		return kb * 1024, nil
+	}
+	return 0, nil
+}
+
+// isFile checks if the path is a regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// isDir checks if the path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
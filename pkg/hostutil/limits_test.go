@@ -0,0 +1,260 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hostutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProc mocks the cgroup/proc files under a temp dir, files are relative paths.
+func mockProc(t *testing.T, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	oldCgroupRoot, oldSelfCgroup, oldMeminfo := cgroupRoot, procSelfCgroup, procMeminfo
+	cgroupRoot = filepath.Join(root, "sys/fs/cgroup")
+	procSelfCgroup = filepath.Join(root, "proc/self/cgroup")
+	procMeminfo = filepath.Join(root, "proc/meminfo")
+	t.Cleanup(func() {
+		cgroupRoot, procSelfCgroup, procMeminfo = oldCgroupRoot, oldSelfCgroup, oldMeminfo
+	})
+}
+
+const meminfo = "MemTotal:       16777216 kB\nMemFree:         1024 kB\n"
+
+func TestDetectLimits_CgroupV2(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		cpu   float64
+		mem   uint64
+		err   bool
+	}{
+		{
+			name: "limited in cgroup namespace",
+			files: map[string]string{
+				"sys/fs/cgroup/cgroup.controllers": "cpu memory",
+				"sys/fs/cgroup/cpu.max":            "150000 100000\n",
+				"sys/fs/cgroup/memory.max":         "1073741824\n",
+				"proc/self/cgroup":                 "0::/\n",
+			},
+			cpu: 1.5,
+			mem: 1 << 30,
+		},
+		{
+			name: "limited in nested cgroup",
+			files: map[string]string{
+				"sys/fs/cgroup/cgroup.controllers":    "cpu memory",
+				"sys/fs/cgroup/cpu.max":               "max 100000\n",
+				"sys/fs/cgroup/pod/c1/cpu.max":        "50000 100000\n",
+				"sys/fs/cgroup/pod/c1/memory.max":     "2147483648\n",
+				"proc/self/cgroup":                    "0::/pod/c1\n",
+				"sys/fs/cgroup/pod/c1/memory.current": "1",
+			},
+			cpu: 0.5,
+			mem: 2 << 30,
+		},
+		{
+			name: "unlimited",
+			files: map[string]string{
+				"sys/fs/cgroup/cgroup.controllers": "cpu memory",
+				"sys/fs/cgroup/cpu.max":            "max 100000\n",
+				"sys/fs/cgroup/memory.max":         "max\n",
+			},
+		},
+		{
+			name: "invalid cpu.max",
+			files: map[string]string{
+				"sys/fs/cgroup/cgroup.controllers": "cpu memory",
+				"sys/fs/cgroup/cpu.max":            "abc 100000\n",
+			},
+			err: true,
+		},
+		{
+			name: "invalid memory.max",
+			files: map[string]string{
+				"sys/fs/cgroup/cgroup.controllers": "cpu memory",
+				"sys/fs/cgroup/memory.max":         "abc\n",
+			},
+			err: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files["proc/meminfo"] = meminfo
+			mockProc(t, tt.files)
+			limits, err := DetectLimits()
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, limits.CgroupVersion)
+			assert.Equal(t, tt.cpu, limits.CPUQuota)
+			assert.Equal(t, tt.mem, limits.MemoryLimit)
+			assert.Equal(t, uint64(16<<30), limits.HostMemory)
+		})
+	}
+}
+
+func TestDetectLimits_CgroupV1(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		cpu   float64
+		mem   uint64
+		err   bool
+	}{
+		{
+			name: "limited",
+			files: map[string]string{
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "200000\n",
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+				"sys/fs/cgroup/memory/memory.limit_in_bytes":  "536870912\n",
+				"proc/self/cgroup":                            "4:cpu,cpuacct:/docker/c1\n3:memory:/docker/c1\n1:name=systemd:/\nbad\n",
+			},
+			cpu: 2,
+			mem: 512 << 20,
+		},
+		{
+			name: "nested path",
+			files: map[string]string{
+				"sys/fs/cgroup/cpu/docker/c1/cpu.cfs_quota_us":         "25000\n",
+				"sys/fs/cgroup/cpu/docker/c1/cpu.cfs_period_us":        "100000\n",
+				"sys/fs/cgroup/memory/docker/c1/memory.limit_in_bytes": "9223372036854771712\n",
+				"proc/self/cgroup": "4:cpu:/docker/c1\n3:memory:/docker/c1\n",
+			},
+			cpu: 0.25,
+		},
+		{
+			name: "unlimited",
+			files: map[string]string{
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "-1\n",
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+			},
+		},
+		{
+			name: "invalid quota",
+			files: map[string]string{
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "abc\n",
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+			},
+			err: true,
+		},
+		{
+			name: "invalid memory limit",
+			files: map[string]string{
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "abc\n",
+			},
+			err: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockProc(t, tt.files)
+			limits, err := DetectLimits()
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, limits.CgroupVersion)
+			assert.Equal(t, tt.cpu, limits.CPUQuota)
+			assert.Equal(t, tt.mem, limits.MemoryLimit)
+			assert.Zero(t, limits.HostMemory)
+		})
+	}
+}
+
+func TestDetectLimits_NoCgroup(t *testing.T) {
+	mockProc(t, map[string]string{"proc/meminfo": meminfo})
+	limits, err := DetectLimits()
+	assert.NoError(t, err)
+	assert.Equal(t, &Limits{HostMemory: 16 << 30}, limits)
+
+	// real host
+	cgroupRoot, procSelfCgroup, procMeminfo = "/sys/fs/cgroup", "/proc/self/cgroup", "/proc/meminfo"
+	_, err = DetectLimits()
+	assert.NoError(t, err)
+}
+
+func TestDetectLimits_ReadFailure(t *testing.T) {
+	defer func() {
+		readFileFunc = os.ReadFile
+	}()
+	mockProc(t, map[string]string{"sys/fs/cgroup/cgroup.controllers": "cpu", "proc/meminfo": "MemTotal: abc kB\n"})
+	_, err := DetectLimits()
+	assert.Error(t, err)
+
+	readFileFunc = func(name string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	_, err = DetectLimits()
+	assert.Error(t, err)
+	_, err = readSelfCgroupPaths()
+	assert.Error(t, err)
+	assert.Error(t, detectCgroupV1(&Limits{}))
+	assert.Error(t, detectCgroupV2(&Limits{}))
+}
+
+func TestLimits_GOMAXPROCS(t *testing.T) {
+	defer func() {
+		numCPUFunc = runtime.NumCPU
+	}()
+	numCPUFunc = func() int { return 8 }
+	assert.Equal(t, 8, (&Limits{}).GOMAXPROCS())
+	assert.Equal(t, 2, (&Limits{CPUQuota: 1.5}).GOMAXPROCS())
+	assert.Equal(t, 1, (&Limits{CPUQuota: 0.1}).GOMAXPROCS())
+	assert.Equal(t, 8, (&Limits{CPUQuota: 16}).GOMAXPROCS())
+
+	procs := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(procs)
+	t.Setenv("GOMAXPROCS", "")
+	assert.Equal(t, 1, (&Limits{CPUQuota: 0.5}).SetGOMAXPROCS())
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	t.Setenv("GOMAXPROCS", "3")
+	assert.Equal(t, 1, (&Limits{CPUQuota: 2}).SetGOMAXPROCS())
+}
+
+func TestLimits_Memory(t *testing.T) {
+	assert.Zero(t, (&Limits{}).AvailableMemory())
+	assert.Equal(t, uint64(100), (&Limits{MemoryLimit: 100}).AvailableMemory())
+	assert.Equal(t, uint64(100), (&Limits{MemoryLimit: 100, HostMemory: 200}).AvailableMemory())
+	assert.Equal(t, uint64(200), (&Limits{MemoryLimit: 300, HostMemory: 200}).AvailableMemory())
+	assert.Equal(t, uint64(200), (&Limits{HostMemory: 200}).AvailableMemory())
+
+	limits := &Limits{MemoryLimit: 1000}
+	assert.Equal(t, uint64(250), limits.CacheSize(0.25, 10))
+	assert.Equal(t, uint64(1000), limits.CacheSize(2, 10))
+	assert.Equal(t, uint64(10), limits.CacheSize(0, 10))
+	assert.Equal(t, uint64(10), (&Limits{}).CacheSize(0.25, 10))
+
+	assert.Equal(t, "cgroup: v2, cpu quota: 1.5, memory limit: 1000, host memory: 0",
+		(&Limits{CgroupVersion: 2, CPUQuota: 1.5, MemoryLimit: 1000}).String())
+}
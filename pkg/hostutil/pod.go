@@ -0,0 +1,168 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hostutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// Env names of pod metadata exposed by downward API, e.g.
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.name
+const (
+	PodNameEnv      = "POD_NAME"
+	PodNamespaceEnv = "POD_NAMESPACE"
+	PodIPEnv        = "POD_IP"
+	NodeNameEnv     = "NODE_NAME"
+	// kubernetesServiceHostEnv is set in all pods by kubelet.
+	kubernetesServiceHostEnv = "KUBERNETES_SERVICE_HOST"
+)
+
+// Tag keys of pod metadata.
+const (
+	PodTagKey          = "pod"
+	PodNamespaceTagKey = "pod_namespace"
+	NodeTagKey         = "node"
+	PodLabelTagPrefix  = "label_"
+)
+
+// DefaultPodInfoDir is the mount path of downward API volume, which has files: name/namespace/labels.
+var DefaultPodInfoDir = "/etc/podinfo"
+
+// for testing
+var getEnvFunc = os.Getenv
+
+// PodInfo represents the metadata of current pod.
+type PodInfo struct {
+	Name      string
+	Namespace string
+	Node      string
+	IP        string
+	Labels    map[string]string
+}
+
+// DetectPodInfo reads the pod metadata from downward API env and volume(dir, DefaultPodInfoDir if empty),
+// the volume takes precedence over env. Returns nil if not running in kubernetes.
+func DetectPodInfo(dir string) (*PodInfo, error) {
+	if dir == "" {
+		dir = DefaultPodInfoDir
+	}
+	pod := &PodInfo{
+		Name:      getEnvFunc(PodNameEnv),
+		Namespace: getEnvFunc(PodNamespaceEnv),
+		Node:      getEnvFunc(NodeNameEnv),
+		IP:        getEnvFunc(PodIPEnv),
+	}
+	for file, value := range map[string]*string{"name": &pod.Name, "namespace": &pod.Namespace} {
+		data, err := readFileFunc(filepath.Join(dir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if v := strings.TrimSpace(string(data)); v != "" {
+			*value = v
+		}
+	}
+	data, err := readFileFunc(filepath.Join(dir, "labels"))
+	switch {
+	case err == nil:
+		if pod.Labels, err = parseDownwardAPIMap(data); err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if pod.Name == "" && getEnvFunc(kubernetesServiceHostEnv) == "" {
+		return nil, nil
+	}
+	return pod, nil
+}
+
+// parseDownwardAPIMap parses the labels/annotations file of downward API volume, each line is key="value".
+func parseDownwardAPIMap(data []byte) (map[string]string, error) {
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid downward api line: %q", line)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid downward api value: %q, %w", line, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Tags returns the tags of pod metadata for global tag enrichment(e.g. series.WithGlobalTags),
+// the labels are included with PodLabelTagPrefix if the keys are specified.
+func (p *PodInfo) Tags(labelKeys ...string) map[string]string {
+	tags := make(map[string]string)
+	for key, value := range map[string]string{PodTagKey: p.Name, PodNamespaceTagKey: p.Namespace, NodeTagKey: p.Node} {
+		if value != "" {
+			tags[key] = value
+		}
+	}
+	for _, key := range labelKeys {
+		if value := p.Labels[key]; value != "" {
+			tags[PodLabelTagPrefix+key] = value
+		}
+	}
+	return tags
+}
+
+// LoggerFields returns the logger fields of pod metadata, e.g. logger.WithFields(log, pod.LoggerFields()...).
+func (p *PodInfo) LoggerFields() []zap.Field {
+	tags := p.Tags()
+	if p.IP != "" {
+		tags["pod_ip"] = p.IP
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, logger.String(key, tags[key]))
+	}
+	return fields
+}
@@ -0,0 +1,119 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hostutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+func TestDetectPodInfo(t *testing.T) {
+	t.Setenv(PodNameEnv, "env-pod")
+	t.Setenv(PodNamespaceEnv, "env-ns")
+	t.Setenv(NodeNameEnv, "node1")
+	t.Setenv(PodIPEnv, "10.0.0.1")
+	dir := t.TempDir()
+
+	// env only
+	pod, err := DetectPodInfo(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &PodInfo{Name: "env-pod", Namespace: "env-ns", Node: "node1", IP: "10.0.0.1"}, pod)
+
+	// volume takes precedence
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "name"), []byte("broker-0\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "labels"),
+		[]byte("app=\"lindb\"\nrole=\"broker\\n1\"\n\n"), 0o600))
+	pod, err = DetectPodInfo(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "broker-0", pod.Name)
+	assert.Equal(t, "env-ns", pod.Namespace)
+	assert.Equal(t, map[string]string{"app": "lindb", "role": "broker\n1"}, pod.Labels)
+
+	// invalid labels
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "labels"), []byte("app\n"), 0o600))
+	_, err = DetectPodInfo(dir)
+	assert.Error(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "labels"), []byte("app=lindb\n"), 0o600))
+	_, err = DetectPodInfo(dir)
+	assert.Error(t, err)
+}
+
+func TestDetectPodInfo_NotInKubernetes(t *testing.T) {
+	for _, env := range []string{PodNameEnv, PodNamespaceEnv, NodeNameEnv, PodIPEnv, kubernetesServiceHostEnv} {
+		t.Setenv(env, "")
+	}
+	pod, err := DetectPodInfo(filepath.Join(t.TempDir(), "not-exist"))
+	assert.NoError(t, err)
+	assert.Nil(t, pod)
+
+	// in kubernetes without downward api
+	t.Setenv(kubernetesServiceHostEnv, "10.96.0.1")
+	pod, err = DetectPodInfo(filepath.Join(t.TempDir(), "not-exist"))
+	assert.NoError(t, err)
+	assert.Equal(t, &PodInfo{}, pod)
+
+	// default dir
+	_, err = DetectPodInfo("")
+	assert.NoError(t, err)
+}
+
+func TestDetectPodInfo_ReadFailure(t *testing.T) {
+	defer func() {
+		readFileFunc = os.ReadFile
+	}()
+	readFileFunc = func(name string) ([]byte, error) {
+		if filepath.Base(name) == "labels" {
+			return nil, fmt.Errorf("err")
+		}
+		return nil, os.ErrNotExist
+	}
+	_, err := DetectPodInfo(t.TempDir())
+	assert.Error(t, err)
+	readFileFunc = func(name string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	_, err = DetectPodInfo(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestPodInfo_Tags(t *testing.T) {
+	pod := &PodInfo{
+		Name:      "broker-0",
+		Namespace: "lindb",
+		IP:        "10.0.0.1",
+		Labels:    map[string]string{"app": "lindb", "role": "broker", "empty": ""},
+	}
+	assert.Equal(t, map[string]string{"pod": "broker-0", "pod_namespace": "lindb"}, pod.Tags())
+	assert.Equal(t, map[string]string{
+		"pod": "broker-0", "pod_namespace": "lindb", "label_app": "lindb",
+	}, pod.Tags("app", "empty", "not-exist"))
+
+	assert.Equal(t, []zap.Field{
+		logger.String("pod", "broker-0"),
+		logger.String("pod_ip", "10.0.0.1"),
+		logger.String("pod_namespace", "lindb"),
+	}, pod.LoggerFields())
+}
@@ -0,0 +1,59 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"net/http"
+	"time"
+)
+
+// CookieOptions represents the attributes of cookie.
+type CookieOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   time.Duration // 0 means session cookie, < 0 means deleting cookie
+	Secure   bool
+	HTTPOnly bool
+	SameSite http.SameSite // http.SameSiteStrictMode if not set
+}
+
+// NewSameSiteCookie creates a cookie with the options, SameSite is strict by default.
+func NewSameSiteCookie(name, value string, options CookieOptions) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		Secure:   options.Secure,
+		HttpOnly: options.HTTPOnly,
+		SameSite: options.SameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteStrictMode
+	}
+	switch {
+	case options.MaxAge > 0:
+		cookie.MaxAge = int(options.MaxAge.Seconds())
+	case options.MaxAge < 0:
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
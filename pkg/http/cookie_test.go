@@ -0,0 +1,46 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSameSiteCookie(t *testing.T) {
+	cookie := NewSameSiteCookie("name", "value", CookieOptions{})
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.Equal(t, 0, cookie.MaxAge)
+
+	cookie = NewSameSiteCookie("name", "value", CookieOptions{
+		Path:     "/console",
+		Domain:   "lindb.io",
+		MaxAge:   time.Hour,
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	assert.Equal(t, "name=value; Path=/console; Domain=lindb.io; Max-Age=3600; HttpOnly; Secure; SameSite=Lax", cookie.String())
+
+	cookie = NewSameSiteCookie("name", "", CookieOptions{MaxAge: -time.Second})
+	assert.Equal(t, -1, cookie.MaxAge)
+}
@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/lindb/common/models"
+	"github.com/lindb/common/pkg/logger"
+)
+
+const (
+	// LogAdminPath is the path prefix of logging admin endpoints.
+	LogAdminPath = "/debug/log"
+	// LogModulesPath is the path which lists all logger modules.
+	LogModulesPath = LogAdminPath + "/modules"
+	// LogLevelPath is the path which gets/changes the global logging level.
+	LogLevelPath = LogAdminPath + "/level"
+	// LogModuleLevelPath is the path which gets/changes the logging level of module.
+	LogModuleLevelPath = LogLevelPath + "/:module"
+)
+
+// RegisterLogAdmin registers the logging admin endpoints into router:
+//   - GET /debug/log/modules: lists all logger modules with effective levels and output targets.
+//   - GET/PUT /debug/log/level: gets/changes the global logging level, e.g. {"level":"debug"}.
+//   - GET/PUT/DELETE /debug/log/level/{module}: gets/overrides/resets the logging level of module.
+func RegisterLogAdmin(router gin.IRoutes) {
+	router.GET(LogModulesPath, LoggerModules)
+	router.GET(LogLevelPath, GetLogLevel)
+	router.PUT(LogLevelPath, SetLogLevel)
+	router.GET(LogModuleLevelPath, GetModuleLogLevel)
+	router.PUT(LogModuleLevelPath, SetModuleLogLevel)
+	router.DELETE(LogModuleLevelPath, ResetModuleLogLevel)
+}
+
+// GetLogLevel responses the global logging level.
+func GetLogLevel(c *gin.Context) {
+	OK(c, &models.LoggerLevel{Level: logger.RunningAtomicLevel.Level().String()})
+}
+
+// SetLogLevel changes the global logging level on the fly.
+func SetLogLevel(c *gin.Context) {
+	level, err := bindLogLevel(c)
+	if err != nil {
+		BadRequest(c, err)
+		return
+	}
+	logger.RunningAtomicLevel.SetLevel(level)
+	GetLogLevel(c)
+}
+
+// GetModuleLogLevel responses the effective logging level of module.
+func GetModuleLogLevel(c *gin.Context) {
+	module := c.Param("module")
+	if level, ok := logger.ModuleLevel(module); ok {
+		OK(c, &models.LoggerLevel{Module: module, Level: level.String(), Overridden: true})
+		return
+	}
+	for _, m := range logger.Modules() {
+		if m.Module == module {
+			OK(c, &models.LoggerLevel{Module: module, Level: m.Level})
+			return
+		}
+	}
+	NotFound(c)
+}
+
+// SetModuleLogLevel overrides the logging level of module on the fly.
+func SetModuleLogLevel(c *gin.Context) {
+	level, err := bindLogLevel(c)
+	if err != nil {
+		BadRequest(c, err)
+		return
+	}
+	logger.SetModuleLevel(c.Param("module"), level)
+	GetModuleLogLevel(c)
+}
+
+// ResetModuleLogLevel removes the level override of module, the global level is used again.
+func ResetModuleLogLevel(c *gin.Context) {
+	logger.ResetModuleLevel(c.Param("module"))
+	NoContent(c)
+}
+
+// bindLogLevel parses the logging level from request body.
+func bindLogLevel(c *gin.Context) (zapcore.Level, error) {
+	var param models.LoggerLevel
+	if err := c.ShouldBindJSON(&param); err != nil {
+		return zapcore.InfoLevel, err
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(param.Level)); err != nil || param.Level == "" {
+		return zapcore.InfoLevel, fmt.Errorf("invalid log level: %q", param.Level)
+	}
+	return level, nil
+}
+
+// LoggerModules responses all registered logger modules.
+func LoggerModules(c *gin.Context) {
+	OK(c, loggerModules())
+}
+
+// loggerModules converts the logger modules into models.
+func loggerModules() models.LoggerModules {
+	modules := logger.Modules()
+	result := make(models.LoggerModules, len(modules))
+	for i, m := range modules {
+		result[i] = &models.LoggerModule{
+			Module: m.Module,
+			Roles:  m.Roles,
+			Level:  m.Level,
+			Sinks:  m.Sinks,
+		}
+	}
+	return result
+}
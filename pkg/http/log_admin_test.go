@@ -0,0 +1,113 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/lindb/common/models"
+	"github.com/lindb/common/pkg/logger"
+)
+
+func TestLoggerModules(t *testing.T) {
+	logger.GetLogger("LogAdmin", "Broker")
+	r := gin.New()
+	RegisterLogAdmin(r)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, LogModulesPath, http.NoBody))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var modules models.LoggerModules
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &modules))
+	var found *models.LoggerModule
+	for _, m := range modules {
+		if m.Module == "LogAdmin" {
+			found = m
+		}
+	}
+	assert.NotNil(t, found)
+	assert.Equal(t, []string{"Broker"}, found.Roles)
+	assert.NotEmpty(t, found.Level)
+}
+
+// newLogLevelRequester returns the function which requests the log level endpoints.
+func newLogLevelRequester(t *testing.T) func(method, path, body string) (int, *models.LoggerLevel) {
+	r := gin.New()
+	RegisterLogAdmin(r)
+	return func(method, path, body string) (int, *models.LoggerLevel) {
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, httptest.NewRequest(method, path, strings.NewReader(body)))
+		level := &models.LoggerLevel{}
+		if resp.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), level))
+		}
+		return resp.Code, level
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	defer logger.RunningAtomicLevel.SetLevel(logger.RunningAtomicLevel.Level())
+	do := newLogLevelRequester(t)
+
+	code, level := do(http.MethodPut, LogLevelPath, `{"level":"warn"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, &models.LoggerLevel{Level: "warn"}, level)
+	_, level = do(http.MethodGet, LogLevelPath, "")
+	assert.Equal(t, "warn", level.Level)
+	assert.Equal(t, zapcore.WarnLevel, logger.RunningAtomicLevel.Level())
+
+	code, _ = do(http.MethodPut, LogLevelPath, `{"level":"unknown"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, LogLevelPath, `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, LogLevelPath, `level`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestModuleLogLevel(t *testing.T) {
+	defer logger.ResetModuleLevel("LevelAdmin")
+	do := newLogLevelRequester(t)
+	path := LogLevelPath + "/LevelAdmin"
+
+	code, _ := do(http.MethodGet, path, "")
+	assert.Equal(t, http.StatusNotFound, code)
+	log := logger.GetLogger("LevelAdmin", "Broker")
+	code, level := do(http.MethodGet, path, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, &models.LoggerLevel{Module: "LevelAdmin", Level: logger.RunningAtomicLevel.Level().String()}, level)
+
+	code, level = do(http.MethodPut, path, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, &models.LoggerLevel{Module: "LevelAdmin", Level: "debug", Overridden: true}, level)
+	assert.True(t, log.Enabled(logger.DebugLevel))
+	code, _ = do(http.MethodPut, path, `{"level":"unknown"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = do(http.MethodDelete, path, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	_, ok := logger.ModuleLevel("LevelAdmin")
+	assert.False(t, ok)
+}
@@ -0,0 +1,117 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MethodOverrideHeader is the header which overrides the method of POST request.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// overridableMethods are the methods which can override POST.
+var overridableMethods = map[string]struct{}{
+	http.MethodPut:    {},
+	http.MethodPatch:  {},
+	http.MethodDelete: {},
+}
+
+// MethodOverride returns a handler which overrides the method of POST request by X-HTTP-Method-Override header
+// (PUT/PATCH/DELETE) before routing, for the clients behind restrictive proxies which only allow GET/POST.
+func MethodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			method := strings.ToUpper(strings.TrimSpace(r.Header.Get(MethodOverrideHeader)))
+			if _, ok := overridableMethods[method]; ok {
+				r = r.WithContext(r.Context())
+				r.Method = method
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AutoHead returns a handler which serves HEAD request by the GET handler of next(e.g. health checks of
+// load balancer), the body is discarded, Content-Length is set by the size of body if not set by handler.
+// NOTE: the HEAD routes of next are shadowed.
+func AutoHead(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.WithContext(r.Context())
+		r.Method = http.MethodGet
+		hw := &headResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(hw, r)
+		hw.commit()
+	})
+}
+
+// headResponseWriter discards the body, and delays writing header until the handler is finished,
+// so that Content-Length can be set by the size of body.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	size      int
+	committed bool
+}
+
+// WriteHeader records the status code.
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write discards the body, counts the size of body.
+func (w *headResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.size += len(p)
+	return len(p), nil
+}
+
+// Flush writes the header without Content-Length(e.g. streaming response).
+func (w *headResponseWriter) Flush() {
+	if !w.committed {
+		w.WriteHeader(http.StatusOK)
+		w.committed = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// commit writes the header with Content-Length if not written.
+func (w *headResponseWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	header := w.Header()
+	if header.Get("Content-Length") == "" && w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		header.Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,113 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMethodEngine() *gin.Engine {
+	r := gin.New()
+	r.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/length", func(c *gin.Context) {
+		c.Header("Content-Length", "100")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString("data")
+		c.Writer.Flush()
+	})
+	r.GET("/none", func(_ *gin.Context) {})
+	r.DELETE("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "deleted")
+	})
+	r.PUT("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "put")
+	})
+	r.POST("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "post")
+	})
+	return r
+}
+
+func TestAutoHead(t *testing.T) {
+	handler := AutoHead(newMethodEngine())
+	cases := []struct {
+		method string
+		path   string
+		status int
+		length string
+		body   string
+	}{
+		{method: http.MethodHead, path: "/health", status: http.StatusOK, length: "2"},
+		{method: http.MethodHead, path: "/empty", status: http.StatusNoContent},
+		{method: http.MethodHead, path: "/length", status: http.StatusOK, length: "100"},
+		{method: http.MethodHead, path: "/stream", status: http.StatusAccepted},
+		{method: http.MethodHead, path: "/none", status: http.StatusOK, length: "0"},
+		{method: http.MethodHead, path: "/not-found", status: http.StatusNotFound, length: "18"},
+		{method: http.MethodGet, path: "/health", status: http.StatusOK, body: "ok"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+			assert.Equal(t, tt.status, resp.Code)
+			assert.Equal(t, tt.length, resp.Header().Get("Content-Length"))
+			assert.Equal(t, tt.body, resp.Body.String())
+		})
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	handler := MethodOverride(newMethodEngine())
+	cases := []struct {
+		method   string
+		override string
+		body     string
+	}{
+		{method: http.MethodPost, override: "delete", body: "deleted"},
+		{method: http.MethodPost, override: " PUT ", body: "put"},
+		{method: http.MethodPost, override: http.MethodGet, body: "post"},
+		{method: http.MethodPost, body: "post"},
+		{method: http.MethodGet, override: http.MethodDelete, body: "ok"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.method+tt.override, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/health", http.NoBody)
+			req.Header.Set(MethodOverrideHeader, tt.override)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.body, resp.Body.String())
+		})
+	}
+}
@@ -36,10 +36,15 @@ var (
 	pathUnescapeFunc = url.PathUnescape
 )
 
-// AccessLog returns access log middleware
+// AccessLog returns access log middleware, the access log carries request id/trace id fields,
+// and a logger with these fields is attached to request context if not exist, handlers can get it by logger.FromContext.
 func AccessLog(log logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
+		accessLog := log.With(requestFields(c)...)
+		if _, ok := logger.ContextLogger(c.Request.Context()); !ok {
+			c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), accessLog))
+		}
 		r := c.Request
 		defer func() {
 			// add access log
@@ -59,9 +64,9 @@ func AccessLog(log logger.Logger) gin.HandlerFunc {
 				requestInfo += strings.TrimRight(errMsg, "\n")
 			}
 			if status >= 400 {
-				log.Error(requestInfo)
+				accessLog.Error(requestInfo)
 			} else {
-				log.Debug(requestInfo)
+				accessLog.Debug(requestInfo)
 			}
 		}()
 		c.Next()
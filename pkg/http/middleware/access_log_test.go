@@ -29,6 +29,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/lindb/common/pkg/logger"
 )
@@ -54,6 +57,36 @@ func TestAccessLogMiddleware(t *testing.T) {
 	_ = DoRequest(t, r, http.MethodGet, "/home", `{"username": "admin", "password": "admin123"}`)
 }
 
+func TestAccessLog_Context(t *testing.T) {
+	accessLog := logger.GetLogger(logger.AccessLogModule, "HTTP")
+	var log logger.Logger
+	r := gin.New()
+	r.Use(AccessLog(accessLog))
+	r.GET("/home", func(c *gin.Context) {
+		log = logger.FromContext(c.Request.Context())
+		c.JSON(http.StatusOK, "ok")
+	})
+	resp := DoRequest(t, r, http.MethodGet, "/home", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, resp.Header().Get(RequestIDHeader), 32)
+	assert.NotNil(t, log)
+	assert.NotEqual(t, logger.FromContext(context.TODO()), log)
+
+	// keep request logger in context
+	r = gin.New()
+	r.Use(RequestLogger(logger.GetLogger("HTTP", "Test"), nil), AccessLog(accessLog))
+	var reqLog logger.Logger
+	r.GET("/home", func(c *gin.Context) {
+		log = logger.FromContext(c.Request.Context())
+		reqLog = LoggerFromGin(c)
+		c.JSON(http.StatusOK, "ok")
+	})
+	resp = DoRequest(t, r, http.MethodGet, "/home", "", http.Header{RequestIDHeader: []string{"req-1"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "req-1", resp.Header().Get(RequestIDHeader))
+	assert.Equal(t, reqLog, log)
+}
+
 func Test_real_ip(t *testing.T) {
 	req, _ := http.NewRequestWithContext(context.TODO(), "GET", "/health-check", bytes.NewReader([]byte("test")))
 	req.Header.Add("X-Real-Ip", "real-ip")
@@ -103,3 +136,22 @@ func newCloseNotifyingRecorder() *closeNotifyingRecorder {
 func (c *closeNotifyingRecorder) CloseNotify() <-chan bool {
 	return c.closed
 }
+
+func TestAccessLog_Redact(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.RegisterLogger("RedactAccessLog", zap.New(logger.NewRedactCore(core, logger.DefaultRedactKeys...)), false)
+	r := gin.New()
+	r.Use(AccessLog(logger.GetLogger("RedactAccessLog", "HTTP")))
+	r.GET("/login", func(c *gin.Context) {
+		c.JSON(http.StatusUnauthorized, "unauthorized")
+	})
+	// RequestURI is set by server
+	req := httptest.NewRequest(http.MethodGet, "/login?user=admin&password=admin123", http.NoBody)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, 1, logs.Len())
+	msg := logs.All()[0].Message
+	assert.Contains(t, msg, "/login?user=admin&password=******")
+	assert.NotContains(t, msg, "admin123")
+}
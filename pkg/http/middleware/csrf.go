@@ -0,0 +1,174 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httppkg "github.com/lindb/common/pkg/http"
+	"github.com/lindb/common/pkg/logger"
+)
+
+const (
+	// CSRFCookieName is the default cookie name of csrf token.
+	CSRFCookieName = "lindb_csrf"
+	// CSRFHeaderName is the default header name which submits the csrf token.
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFormField is the default form field name which submits the csrf token.
+	CSRFFormField = "csrf_token"
+
+	csrfTokenKey  = "csrfToken"
+	csrfTokenSize = 32
+)
+
+// for testing
+var (
+	csrfRandReadFunc = rand.Read
+)
+
+// CSRFOptions represents the options of csrf middleware.
+type CSRFOptions struct {
+	// CookieName is the cookie which holds the token, CSRFCookieName if empty.
+	CookieName string
+	// HeaderName is the header which submits the token, CSRFHeaderName if empty.
+	HeaderName string
+	// FormField is the form field which submits the token, CSRFFormField if empty.
+	FormField string
+	// Cookie is the attributes of token cookie, HTTPOnly is ignored because the console script reads the token.
+	Cookie httppkg.CookieOptions
+	// Secret signs the token(HMAC-SHA256) if not empty, so that the token injected by other sub-domain is rejected.
+	Secret []byte
+	// Exempt returns true if the request is not issued by browser session(e.g. token-authenticated api call),
+	// DefaultCSRFExempt is used if nil.
+	Exempt func(c *gin.Context) bool
+}
+
+// DefaultCSRFExempt exempts the request with bearer token, which is never attached by browser automatically.
+// Basic auth is not exempted, because browser resends the credentials automatically.
+func DefaultCSRFExempt(c *gin.Context) bool {
+	return strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// CSRF returns a double-submit-cookie csrf middleware for browser-facing console endpoints,
+// the token is issued by cookie(also X-CSRF-Token response header) if absent or invalid,
+// unsafe requests(POST/PUT/DELETE etc.) must submit the same token by header or form field, else respond 403.
+func CSRF(options CSRFOptions) gin.HandlerFunc {
+	if options.CookieName == "" {
+		options.CookieName = CSRFCookieName
+	}
+	if options.HeaderName == "" {
+		options.HeaderName = CSRFHeaderName
+	}
+	if options.FormField == "" {
+		options.FormField = CSRFFormField
+	}
+	if options.Exempt == nil {
+		options.Exempt = DefaultCSRFExempt
+	}
+	options.Cookie.HTTPOnly = false
+	return func(c *gin.Context) {
+		if options.Exempt(c) {
+			c.Next()
+			return
+		}
+		token, _ := c.Cookie(options.CookieName)
+		valid := token != "" && verifyCSRFToken(token, options.Secret)
+		if !valid {
+			newToken, err := newCSRFToken(options.Secret)
+			if err != nil {
+				log.Error("generate csrf token failure", logger.Error(err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, "generate csrf token failure")
+				return
+			}
+			http.SetCookie(c.Writer, httppkg.NewSameSiteCookie(options.CookieName, newToken, options.Cookie))
+			token = newToken
+		}
+		c.Set(csrfTokenKey, token)
+		c.Header(options.HeaderName, token)
+
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		submitted := c.GetHeader(options.HeaderName)
+		if submitted == "" {
+			submitted = c.PostForm(options.FormField)
+		}
+		// the token issued by this request cannot be submitted by it
+		if !valid || submitted == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, "invalid csrf token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CSRFToken returns the csrf token of request set by csrf middleware, e.g. rendering into console page.
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(csrfTokenKey)
+}
+
+// isSafeMethod returns if the http method is safe(no side effect) which doesn't need csrf protection.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// newCSRFToken generates a random token, signed by secret if not empty.
+func newCSRFToken(secret []byte) (string, error) {
+	var b [csrfTokenSize]byte
+	if _, err := csrfRandReadFunc(b[:]); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b[:])
+	if len(secret) == 0 {
+		return token, nil
+	}
+	return token + "." + signCSRFToken(token, secret), nil
+}
+
+// verifyCSRFToken checks the format and signature of token.
+func verifyCSRFToken(token string, secret []byte) bool {
+	random, signature, signed := strings.Cut(token, ".")
+	if b, err := base64.RawURLEncoding.DecodeString(random); err != nil || len(b) != csrfTokenSize {
+		return false
+	}
+	if len(secret) == 0 {
+		return !signed
+	}
+	return signed && hmac.Equal([]byte(signature), []byte(signCSRFToken(random, secret)))
+}
+
+// signCSRFToken returns the HMAC-SHA256 signature of token.
+func signCSRFToken(token string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,139 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCSRFRouter(options CSRFOptions) *gin.Engine {
+	r := gin.New()
+	r.Use(CSRF(options))
+	handle := func(c *gin.Context) {
+		c.String(http.StatusOK, CSRFToken(c))
+	}
+	r.GET("/console", handle)
+	r.POST("/console", handle)
+	return r
+}
+
+func issueCSRFToken(t *testing.T, r *gin.Engine) string {
+	resp := DoRequest(t, r, http.MethodGet, "/console", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	cookies := resp.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, CSRFCookieName, cookies[0].Name)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	assert.False(t, cookies[0].HttpOnly)
+	assert.Equal(t, cookies[0].Value, resp.Header().Get(CSRFHeaderName))
+	assert.Equal(t, cookies[0].Value, resp.Body.String())
+	return cookies[0].Value
+}
+
+func csrfHeader(cookie, token string) http.Header {
+	h := http.Header{}
+	h.Set("Cookie", CSRFCookieName+"="+cookie)
+	if token != "" {
+		h.Set(CSRFHeaderName, token)
+	}
+	return h
+}
+
+func TestCSRF(t *testing.T) {
+	r := newCSRFRouter(CSRFOptions{})
+	token := issueCSRFToken(t, r)
+
+	// reuse valid cookie
+	resp := DoRequest(t, r, http.MethodGet, "/console", "", csrfHeader(token, ""))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Result().Cookies())
+	assert.Equal(t, token, resp.Body.String())
+
+	// no cookie
+	resp = DoRequest(t, r, http.MethodPost, "/console", "")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	// no token submitted
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(token, ""))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	// token mismatch
+	other := issueCSRFToken(t, r)
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(token, other))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	// invalid cookie, submitted same value
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader("abc", "abc"))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Len(t, resp.Result().Cookies(), 1)
+	// header token
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(token, token))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// form token
+	h := csrfHeader(token, "")
+	h.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp = DoRequest(t, r, http.MethodPost, "/console", CSRFFormField+"="+token, h)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestCSRF_Exempt(t *testing.T) {
+	r := newCSRFRouter(CSRFOptions{})
+	resp := DoRequest(t, r, http.MethodPost, "/console", "", http.Header{"Authorization": []string{"Bearer abc"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Result().Cookies())
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", http.Header{"Authorization": []string{"Basic YWRtaW46YWRtaW4="}})
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	r = newCSRFRouter(CSRFOptions{Exempt: func(c *gin.Context) bool { return c.GetHeader("X-API-Key") != "" }})
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", http.Header{"X-Api-Key": []string{"key"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestCSRF_Signed(t *testing.T) {
+	r := newCSRFRouter(CSRFOptions{Secret: []byte("secret")})
+	token := issueCSRFToken(t, r)
+	resp := DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(token, token))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// unsigned token
+	unsigned := issueCSRFToken(t, newCSRFRouter(CSRFOptions{}))
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(unsigned, unsigned))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	// signed by other secret
+	forged := issueCSRFToken(t, newCSRFRouter(CSRFOptions{Secret: []byte("other")}))
+	resp = DoRequest(t, r, http.MethodPost, "/console", "", csrfHeader(forged, forged))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	// signed token not accepted without secret
+	resp = DoRequest(t, newCSRFRouter(CSRFOptions{}), http.MethodPost, "/console", "", csrfHeader(token, token))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestCSRF_GenerateFailure(t *testing.T) {
+	defer func() {
+		csrfRandReadFunc = rand.Read
+	}()
+	csrfRandReadFunc = func(_ []byte) (int, error) {
+		return 0, fmt.Errorf("err")
+	}
+	r := newCSRFRouter(CSRFOptions{})
+	resp := DoRequest(t, r, http.MethodGet, "/console", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
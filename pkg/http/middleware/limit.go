@@ -0,0 +1,44 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/common/models"
+)
+
+// Limit returns a middleware which consults the qps limit of checker for each request,
+// responds 429 with the limit exceeded error(name/max/used) if exceeded.
+func Limit(checker models.LimitChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checker.Check(models.LimitMaxQPS, 1); err != nil {
+			_ = c.Error(err)
+			if e, ok := models.IsLimitExceeded(err); ok {
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, e)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
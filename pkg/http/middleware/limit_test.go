@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/models"
+)
+
+// mockLimitChecker returns the error for checking.
+type mockLimitChecker struct {
+	err error
+}
+
+func (c *mockLimitChecker) Check(_ string, _ int64) error { return c.err }
+
+func (c *mockLimitChecker) Usages() models.LimitUsages { return nil }
+
+func TestLimit(t *testing.T) {
+	r := gin.New()
+	r.Use(Limit(models.NewLimitChecker(models.Limits{MaxQPS: 2}, nil)))
+	r.GET("/api", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	var resp = DoRequest(t, r, http.MethodGet, "/api", "")
+	for i := 0; i < 5 && resp.Code == http.StatusOK; i++ {
+		resp = DoRequest(t, r, http.MethodGet, "/api", "")
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+	e := &models.LimitExceededError{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), e))
+	assert.Equal(t, models.LimitMaxQPS, e.Name)
+	assert.Equal(t, int64(2), e.Max)
+
+	r = gin.New()
+	r.Use(Limit(&mockLimitChecker{err: fmt.Errorf("err")}))
+	r.GET("/api", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	resp = DoRequest(t, r, http.MethodGet, "/api", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
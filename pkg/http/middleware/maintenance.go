@@ -0,0 +1,167 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// MaintenanceStatus represents the status of maintenance mode.
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	Since   int64  `json:"since,omitempty"` // enabled time in millisecond
+}
+
+// Maintenance represents the runtime-toggleable maintenance mode,
+// requests are rejected with 503 when enabled, except the allowed paths/ips.
+type Maintenance struct {
+	allowPaths []string
+	allowIPs   []*net.IPNet
+	retryAfter time.Duration
+	status     MaintenanceStatus
+
+	lock sync.RWMutex
+}
+
+// NewMaintenance creates a maintenance mode with allowlist,
+// allowPaths are path prefixes, allowIPs are ip or cidr(e.g. 10.0.0.0/8),
+// retryAfter is the Retry-After header of 503 response, ignored if <= 0.
+func NewMaintenance(allowPaths, allowIPs []string, retryAfter time.Duration) (*Maintenance, error) {
+	m := &Maintenance{
+		allowPaths: allowPaths,
+		retryAfter: retryAfter,
+	}
+	for _, ip := range allowIPs {
+		if !strings.Contains(ip, "/") {
+			if strings.Contains(ip, ":") {
+				ip += "/128"
+			} else {
+				ip += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(ip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance allow ip: %s, %w", ip, err)
+		}
+		m.allowIPs = append(m.allowIPs, ipNet)
+	}
+	return m, nil
+}
+
+// Enable enables the maintenance mode with the reason.
+func (m *Maintenance) Enable(reason string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.status.Enabled {
+		m.status.Since = nowFunc().UnixMilli()
+	}
+	m.status.Enabled = true
+	m.status.Reason = reason
+	log.Warn("maintenance mode enabled", logger.String("reason", reason))
+}
+
+// Disable disables the maintenance mode.
+func (m *Maintenance) Disable() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.status.Enabled {
+		log.Info("maintenance mode disabled")
+	}
+	m.status = MaintenanceStatus{}
+}
+
+// Status returns the current status of maintenance mode.
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.status
+}
+
+// allow checks if the request can bypass the maintenance mode.
+func (m *Maintenance) allow(r *http.Request) bool {
+	for _, path := range m.allowPaths {
+		if strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+	if len(m.allowIPs) == 0 {
+		return false
+	}
+	ip := net.ParseIP(realIP(r))
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range m.allowIPs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns a middleware which rejects requests with 503 when maintenance mode enabled.
+func (m *Maintenance) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := m.Status()
+		if !status.Enabled || m.allow(c.Request) {
+			c.Next()
+			return
+		}
+		if m.retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, status)
+	}
+}
+
+// Register registers the admin endpoint for maintenance mode,
+// GET returns the status, PUT flips the mode with body: {"enabled": true, "reason": "upgrade"}.
+// The path should be in allowlist for disabling maintenance mode.
+func (m *Maintenance) Register(route gin.IRoutes, path string) {
+	route.GET(path, func(c *gin.Context) {
+		c.JSON(http.StatusOK, m.Status())
+	})
+	route.PUT(path, func(c *gin.Context) {
+		param := MaintenanceStatus{}
+		if err := c.ShouldBindJSON(&param); err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+		if param.Enabled {
+			m.Enable(param.Reason)
+		} else {
+			m.Disable()
+		}
+		c.JSON(http.StatusOK, m.Status())
+	})
+}
@@ -0,0 +1,112 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMaintenance(t *testing.T) {
+	_, err := NewMaintenance(nil, []string{"abc"}, 0)
+	assert.Error(t, err)
+	m, err := NewMaintenance(nil, []string{"10.0.0.1", "::1", "192.168.0.0/16"}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, m.allowIPs, 3)
+}
+
+func TestMaintenance(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	nowFunc = func() time.Time {
+		return time.UnixMilli(1000)
+	}
+	m, err := NewMaintenance([]string{"/api/admin"}, []string{"10.0.0.0/8"}, 30*time.Second)
+	assert.NoError(t, err)
+	r := gin.New()
+	r.Use(m.Handler())
+	m.Register(r, "/api/admin/maintenance")
+	r.GET("/api/data", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	resp := DoRequest(t, r, http.MethodGet, "/api/data", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// enable by admin endpoint
+	resp = DoRequest(t, r, http.MethodPut, "/api/admin/maintenance", `{"enabled":true,"reason":"upgrade"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, MaintenanceStatus{Enabled: true, Reason: "upgrade", Since: 1000}, m.Status())
+
+	resp = DoRequest(t, r, http.MethodGet, "/api/data", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "30", resp.Header().Get("Retry-After"))
+	status := MaintenanceStatus{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	assert.Equal(t, "upgrade", status.Reason)
+
+	// allowed ip
+	resp = DoRequest(t, r, http.MethodGet, "/api/data", "", http.Header{"X-Real-Ip": []string{"10.1.1.1"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = DoRequest(t, r, http.MethodGet, "/api/data", "", http.Header{"X-Real-Ip": []string{"11.1.1.1"}})
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	resp = DoRequest(t, r, http.MethodGet, "/api/data", "", http.Header{"X-Real-Ip": []string{"bad-ip"}})
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+	// allowed path
+	resp = DoRequest(t, r, http.MethodGet, "/api/admin/maintenance", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// enable again keeps since
+	nowFunc = func() time.Time {
+		return time.UnixMilli(2000)
+	}
+	m.Enable("migration")
+	assert.Equal(t, MaintenanceStatus{Enabled: true, Reason: "migration", Since: 1000}, m.Status())
+
+	// bad request
+	resp = DoRequest(t, r, http.MethodPut, "/api/admin/maintenance", `{"enabled":"abc"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// disable
+	resp = DoRequest(t, r, http.MethodPut, "/api/admin/maintenance", `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, MaintenanceStatus{}, m.Status())
+	m.Disable()
+	resp = DoRequest(t, r, http.MethodGet, "/api/data", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestMaintenance_NoAllowlist(t *testing.T) {
+	m, err := NewMaintenance(nil, nil, 0)
+	assert.NoError(t, err)
+	m.Enable("upgrade")
+	r := gin.New()
+	r.Use(m.Handler())
+	r.GET("/api/data", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	resp := DoRequest(t, r, http.MethodGet, "/api/data", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Empty(t, resp.Header().Get("Retry-After"))
+}
@@ -0,0 +1,361 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxRefDepth is the max depth of resolving $ref, avoids infinite loop of circular references.
+const maxRefDepth = 32
+
+// openAPISchema represents the subset of OpenAPI 3 schema used for request validation,
+// the composition keywords(allOf/oneOf/anyOf etc.) are ignored.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref"`
+	Type                 string                    `json:"type"`
+	Nullable             bool                      `json:"nullable"`
+	Enum                 []interface{}             `json:"enum"`
+	Required             []string                  `json:"required"`
+	Properties           map[string]*openAPISchema `json:"properties"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties"`
+	Items                *openAPISchema            `json:"items"`
+	Minimum              *json.Number              `json:"minimum"`
+	Maximum              *json.Number              `json:"maximum"`
+	MinLength            *int                      `json:"minLength"`
+	MaxLength            *int                      `json:"maxLength"`
+	MinItems             *int                      `json:"minItems"`
+	MaxItems             *int                      `json:"maxItems"`
+	Pattern              string                    `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// openAPIParameter represents the parameter(path/query/header) of operation.
+type openAPIParameter struct {
+	Ref      string         `json:"$ref"`
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+// openAPIMediaType represents the media type of request body.
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPIRequestBody represents the request body of operation.
+type openAPIRequestBody struct {
+	Ref      string                       `json:"$ref"`
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+// openAPIOperation represents the operation of path.
+type openAPIOperation struct {
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+}
+
+// openAPIPathItem represents the operations of path.
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `json:"parameters"`
+	Get        *openAPIOperation   `json:"get"`
+	Put        *openAPIOperation   `json:"put"`
+	Post       *openAPIOperation   `json:"post"`
+	Delete     *openAPIOperation   `json:"delete"`
+	Patch      *openAPIOperation   `json:"patch"`
+	Head       *openAPIOperation   `json:"head"`
+	Options    *openAPIOperation   `json:"options"`
+}
+
+// openAPIComponents represents the reusable objects referenced by $ref.
+type openAPIComponents struct {
+	Schemas       map[string]*openAPISchema      `json:"schemas"`
+	Parameters    map[string]*openAPIParameter   `json:"parameters"`
+	RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+}
+
+// openAPIRoute represents a path template of document, e.g. /metric/{name}.
+type openAPIRoute struct {
+	segments []string
+	params   int // number of templated segments
+	item     *openAPIPathItem
+}
+
+// OpenAPIDocument represents the OpenAPI 3 document(JSON) used for validating requests,
+// only the parameters and JSON request body of operations are used.
+type OpenAPIDocument struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]*openAPIPathItem `json:"paths"`
+	Components openAPIComponents           `json:"components"`
+
+	basePath string
+	routes   []*openAPIRoute
+}
+
+// ParseOpenAPIDocument parses the OpenAPI 3 document in JSON(e.g. generated by API docs generator).
+func ParseOpenAPIDocument(data []byte) (*OpenAPIDocument, error) {
+	doc := &OpenAPIDocument{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(doc); err != nil {
+		return nil, fmt.Errorf("parse openapi document failure: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version: %q, 3.x is available", doc.OpenAPI)
+	}
+	if len(doc.Servers) > 0 {
+		serverURL, err := url.Parse(doc.Servers[0].URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse server url: %q failure: %w", doc.Servers[0].URL, err)
+		}
+		doc.basePath = strings.TrimRight(serverURL.Path, "/")
+	}
+	for _, schema := range doc.Components.Schemas {
+		if err := schema.compile(); err != nil {
+			return nil, err
+		}
+	}
+	for _, param := range doc.Components.Parameters {
+		if err := param.Schema.compile(); err != nil {
+			return nil, err
+		}
+	}
+	for _, body := range doc.Components.RequestBodies {
+		if err := body.compile(); err != nil {
+			return nil, err
+		}
+	}
+	for path, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+		if err := item.compile(); err != nil {
+			return nil, fmt.Errorf("path: %s, %w", path, err)
+		}
+		route := &openAPIRoute{segments: splitPath(path), item: item}
+		for _, segment := range route.segments {
+			if isPathParam(segment) {
+				route.params++
+			}
+		}
+		doc.routes = append(doc.routes, route)
+	}
+	// prefer the static segments, e.g. /metric/names over /metric/{name}
+	sort.SliceStable(doc.routes, func(i, j int) bool {
+		return doc.routes[i].params < doc.routes[j].params
+	})
+	return doc, nil
+}
+
+// match returns the path item and path parameters of request path, false if not found.
+func (doc *OpenAPIDocument) match(path string) (item *openAPIPathItem, params map[string]string, ok bool) {
+	if doc.basePath != "" {
+		if !strings.HasPrefix(path, doc.basePath) {
+			return nil, nil, false
+		}
+		path = path[len(doc.basePath):]
+	}
+	segments := splitPath(path)
+	for _, route := range doc.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		params = nil
+		matched := true
+		for i, segment := range route.segments {
+			if isPathParam(segment) {
+				if segments[i] == "" {
+					matched = false
+					break
+				}
+				if params == nil {
+					params = make(map[string]string)
+				}
+				params[segment[1:len(segment)-1]] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.item, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// operation returns the operation of http method, nil if not defined.
+func (item *openAPIPathItem) operation(method string) *openAPIOperation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return item.Get
+	case "PUT":
+		return item.Put
+	case "POST":
+		return item.Post
+	case "DELETE":
+		return item.Delete
+	case "PATCH":
+		return item.Patch
+	case "HEAD":
+		return item.Head
+	case "OPTIONS":
+		return item.Options
+	default:
+		return nil
+	}
+}
+
+// compile compiles the schemas of path item.
+func (item *openAPIPathItem) compile() error {
+	for _, param := range item.Parameters {
+		if err := param.compile(); err != nil {
+			return err
+		}
+	}
+	for _, op := range []*openAPIOperation{item.Get, item.Put, item.Post, item.Delete, item.Patch, item.Head, item.Options} {
+		if op == nil {
+			continue
+		}
+		for _, param := range op.Parameters {
+			if err := param.compile(); err != nil {
+				return err
+			}
+		}
+		if op.RequestBody != nil {
+			if err := op.RequestBody.compile(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compile compiles the schema of parameter.
+func (p *openAPIParameter) compile() error {
+	if p == nil {
+		return nil
+	}
+	return p.Schema.compile()
+}
+
+// compile compiles the schemas of request body.
+func (b *openAPIRequestBody) compile() error {
+	if b == nil {
+		return nil
+	}
+	for _, media := range b.Content {
+		if media == nil {
+			continue
+		}
+		if err := media.Schema.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile compiles the patterns of schema recursively.
+func (s *openAPISchema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile schema pattern: %q failure: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	for _, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return err
+		}
+	}
+	return s.Items.compile()
+}
+
+// allowAdditionalProperties returns if the properties not defined are allowed.
+func (s *openAPISchema) allowAdditionalProperties() bool {
+	return !bytes.Equal(bytes.TrimSpace(s.AdditionalProperties), []byte("false"))
+}
+
+// schema resolves the schema reference, returns nil if not found.
+func (doc *OpenAPIDocument) schema(s *openAPISchema) *openAPISchema {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		if depth >= maxRefDepth {
+			return nil
+		}
+		s = doc.Components.Schemas[refName(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// parameter resolves the parameter reference, returns nil if not found.
+func (doc *OpenAPIDocument) parameter(p *openAPIParameter) *openAPIParameter {
+	for depth := 0; p != nil && p.Ref != ""; depth++ {
+		if depth >= maxRefDepth {
+			return nil
+		}
+		p = doc.Components.Parameters[refName(p.Ref, "#/components/parameters/")]
+	}
+	return p
+}
+
+// requestBody resolves the request body reference, returns nil if not found.
+func (doc *OpenAPIDocument) requestBody(b *openAPIRequestBody) *openAPIRequestBody {
+	for depth := 0; b != nil && b.Ref != ""; depth++ {
+		if depth >= maxRefDepth {
+			return nil
+		}
+		b = doc.Components.RequestBodies[refName(b.Ref, "#/components/requestBodies/")]
+	}
+	return b
+}
+
+// refName returns the component name of local reference, empty if reference is not under prefix.
+func refName(ref, prefix string) string {
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return ref[len(prefix):]
+}
+
+// splitPath splits the path into segments.
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// isPathParam returns if the path segment is templated, e.g. {name}.
+func isPathParam(segment string) bool {
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+}
@@ -0,0 +1,171 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testOpenAPIDocument is the OpenAPI document for testing.
+const testOpenAPIDocument = `{
+  "openapi": "3.0.1",
+  "servers": [{"url": "http://localhost:9000/api/v1"}],
+  "paths": {
+    "/metric/names": {
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100}},
+          {"name": "prefix", "in": "query", "required": true, "schema": {"type": "string", "minLength": 1}}
+        ]
+      }
+    },
+    "/metric/{name}": {
+      "parameters": [{"$ref": "#/components/parameters/name"}],
+      "get": {
+        "parameters": [
+          {"name": "X-Tenant", "in": "header", "required": true, "schema": {"type": "string", "enum": ["a", "b"]}},
+          {"name": "tags", "in": "query", "schema": {"type": "array", "maxItems": 2, "items": {"type": "string"}}},
+          {"name": "sid", "in": "cookie", "required": true, "schema": {"type": "string"}}
+        ]
+      },
+      "put": {
+        "requestBody": {"$ref": "#/components/requestBodies/metric"}
+      },
+      "post": {
+        "requestBody": {"content": {"text/plain": {"schema": {"type": "string"}}}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "name": {"name": "name", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^[a-z]+$"}}
+    },
+    "requestBodies": {
+      "metric": {
+        "required": true,
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Metric"}}}
+      }
+    },
+    "schemas": {
+      "Metric": {
+        "type": "object",
+        "required": ["name", "fields"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "maxLength": 8},
+          "interval": {"type": "integer", "minimum": 0},
+          "ratio": {"type": "number", "maximum": 1},
+          "enabled": {"type": "boolean"},
+          "type": {"type": "string", "enum": ["sum", "last"], "nullable": true},
+          "version": {"type": "integer", "enum": [1, 2]},
+          "tags": {"type": "object"},
+          "fields": {"type": "array", "minItems": 1, "items": {"$ref": "#/components/schemas/Field"}}
+        }
+      },
+      "Field": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {"name": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func TestParseOpenAPIDocument(t *testing.T) {
+	doc, err := ParseOpenAPIDocument([]byte(testOpenAPIDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1", doc.basePath)
+
+	cases := []struct {
+		name   string
+		path   string
+		ok     bool
+		params map[string]string
+	}{
+		{name: "static path", path: "/api/v1/metric/names", ok: true},
+		{name: "templated path", path: "/api/v1/metric/cpu", ok: true, params: map[string]string{"name": "cpu"}},
+		{name: "without base path", path: "/metric/cpu"},
+		{name: "empty path param", path: "/api/v1/metric/"},
+		{name: "not found", path: "/api/v1/metric/cpu/fields"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			item, params, ok := doc.match(tt.path)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.NotNil(t, item)
+				assert.Equal(t, tt.params, params)
+			}
+		})
+	}
+}
+
+func TestParseOpenAPIDocument_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  string
+	}{
+		{name: "invalid json", doc: `{`},
+		{name: "swagger 2", doc: `{"swagger": "2.0"}`},
+		{name: "invalid server url", doc: `{"openapi": "3.0.0", "servers": [{"url": "http://a b:x"}]}`},
+		{name: "invalid component schema pattern", doc: `{"openapi": "3.0.0", "components": {"schemas": {"a": {"pattern": "["}}}}`},
+		{name: "invalid component parameter pattern",
+			doc: `{"openapi": "3.0.0", "components": {"parameters": {"a": {"schema": {"pattern": "["}}}}}`},
+		{name: "invalid component request body pattern",
+			doc: `{"openapi": "3.0.0", "components": {"requestBodies": {"a": {"content": {"application/json": {"schema": {"pattern": "["}}}}}}}`},
+		{name: "invalid path parameter pattern",
+			doc: `{"openapi": "3.0.0", "paths": {"/a": {"parameters": [{"schema": {"pattern": "["}}]}}}`},
+		{name: "invalid operation parameter pattern",
+			doc: `{"openapi": "3.0.0", "paths": {"/a": {"get": {"parameters": [{"schema": {"pattern": "["}}]}}}}`},
+		{name: "invalid property pattern",
+			doc: `{"openapi": "3.0.0", "paths": {"/a": {"post": {"requestBody": {"content": {"application/json": ` +
+				`{"schema": {"properties": {"a": {"items": {"pattern": "["}}}}}}}}}}}`},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseOpenAPIDocument([]byte(tt.doc))
+			assert.Error(t, err)
+			assert.Nil(t, doc)
+		})
+	}
+}
+
+func TestOpenAPIDocument_Ref(t *testing.T) {
+	doc, err := ParseOpenAPIDocument([]byte(`{
+  "openapi": "3.0.0",
+  "paths": {"/a": null},
+  "components": {
+    "schemas": {"loop": {"$ref": "#/components/schemas/loop"}},
+    "parameters": {"loop": {"$ref": "#/components/parameters/loop"}},
+    "requestBodies": {"loop": {"$ref": "#/components/requestBodies/loop"}}
+  }
+}`))
+	assert.NoError(t, err)
+	assert.Nil(t, doc.schema(&openAPISchema{Ref: "#/components/schemas/loop"}))
+	assert.Nil(t, doc.schema(&openAPISchema{Ref: "#/definitions/a"}))
+	assert.Nil(t, doc.parameter(&openAPIParameter{Ref: "#/components/parameters/loop"}))
+	assert.Nil(t, doc.requestBody(&openAPIRequestBody{Ref: "#/components/requestBodies/loop"}))
+	item := &openAPIPathItem{}
+	for _, method := range []string{"GET", "PUT", "POST", "DELETE", "PATCH", "HEAD", "OPTIONS", "TRACE"} {
+		assert.Nil(t, item.operation(method))
+	}
+}
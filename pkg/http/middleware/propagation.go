@@ -0,0 +1,203 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TraceStateHeader is the header of W3C trace context vendor specific state.
+	TraceStateHeader = "tracestate"
+	// BaggageHeader is the header of W3C baggage, format: key1=value1,key2=value2.
+	BaggageHeader = "baggage"
+	// B3SampledHeader is the header of zipkin b3 sampling decision.
+	B3SampledHeader = "X-B3-Sampled"
+	// TenantHeader is the header of tenant.
+	TenantHeader = "X-Lindb-Tenant"
+	// FeatureFlagsHeader is the header of feature flags enabled for request, format: flag1,flag2.
+	FeatureFlagsHeader = "X-Lindb-Feature-Flags"
+)
+
+// DefaultPropagatedHeaders are the headers propagated from incoming request to outgoing calls by default,
+// include trace context, baggage, request id, tenant and feature flags.
+var DefaultPropagatedHeaders = []string{
+	TraceParentHeader, TraceStateHeader, BaggageHeader,
+	B3TraceIDHeader, B3SpanIDHeader, B3SampledHeader,
+	RequestIDHeader, TenantHeader, FeatureFlagsHeader,
+}
+
+// propagationKey is the key of propagated headers in context.
+type propagationKey struct{}
+
+// Propagation returns a middleware which takes the defined headers(DefaultPropagatedHeaders if empty) from
+// incoming request, then attaches them to request context, so that they are injected into outgoing
+// HTTP(see PropagationTransport)/gRPC(see UnaryClientPropagator/StreamClientPropagator) calls automatically.
+// The request id generated by RequestLogger is propagated if the request has no request id header.
+func Propagation(headers ...string) gin.HandlerFunc {
+	if len(headers) == 0 {
+		headers = DefaultPropagatedHeaders
+	}
+	keys := make([]string, len(headers))
+	for i, header := range headers {
+		keys[i] = http.CanonicalHeaderKey(header)
+	}
+	return func(c *gin.Context) {
+		propagated := make(http.Header)
+		for _, key := range keys {
+			if values := c.Request.Header.Values(key); len(values) > 0 {
+				propagated[key] = append([]string(nil), values...)
+			}
+		}
+		if requestID := RequestIDFromGin(c); requestID != "" && propagated.Get(RequestIDHeader) == "" {
+			if containsHeader(keys, RequestIDHeader) {
+				propagated.Set(RequestIDHeader, requestID)
+			}
+		}
+		if len(propagated) > 0 {
+			c.Request = c.Request.WithContext(WithPropagatedHeaders(c.Request.Context(), propagated))
+		}
+		c.Next()
+	}
+}
+
+// containsHeader returns if the header is in the canonical header keys.
+func containsHeader(keys []string, header string) bool {
+	header = http.CanonicalHeaderKey(header)
+	for _, key := range keys {
+		if key == header {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPropagatedHeaders returns a copy of ctx which carries the headers propagated to outgoing calls,
+// the headers are merged with the headers already in ctx(later wins).
+func WithPropagatedHeaders(ctx context.Context, headers http.Header) context.Context {
+	merged := PropagatedHeaders(ctx)
+	if merged == nil {
+		merged = make(http.Header, len(headers))
+	}
+	for key, values := range headers {
+		merged[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, propagationKey{}, merged)
+}
+
+// PropagatedHeaders returns a copy of the headers carried by ctx, returns nil if not exist.
+func PropagatedHeaders(ctx context.Context) http.Header {
+	if ctx == nil {
+		return nil
+	}
+	headers, ok := ctx.Value(propagationKey{}).(http.Header)
+	if !ok {
+		return nil
+	}
+	return headers.Clone()
+}
+
+// InjectHeaders sets the headers carried by ctx into outgoing request headers,
+// the header already set by caller is kept.
+func InjectHeaders(ctx context.Context, header http.Header) {
+	injectHeaders(PropagatedHeaders(ctx), header)
+}
+
+// injectHeaders sets the propagated headers which aren't set in dst.
+func injectHeaders(headers, dst http.Header) {
+	for key, values := range headers {
+		if _, ok := dst[key]; !ok {
+			dst[key] = values
+		}
+	}
+}
+
+// propagationTransport injects the propagated headers of request context into outgoing request.
+type propagationTransport struct {
+	base http.RoundTripper
+}
+
+// PropagationTransport returns a http.RoundTripper which injects the headers carried by request context
+// into outgoing request, base is http.DefaultTransport if nil.
+func PropagationTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &propagationTransport{base: base}
+}
+
+// RoundTrip injects the propagated headers into a clone of request, the request of caller isn't modified.
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := PropagatedHeaders(req.Context())
+	if len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	clone := req.Clone(req.Context())
+	injectHeaders(headers, clone.Header)
+	return t.base.RoundTrip(clone)
+}
+
+// outgoingContext returns the context with propagated headers appended into outgoing grpc metadata,
+// the metadata key is lowercase header name, the key already set by caller is kept.
+func outgoingContext(ctx context.Context) context.Context {
+	headers := PropagatedHeaders(ctx)
+	if len(headers) == 0 {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	var kvs []string
+	for key, values := range headers {
+		key = strings.ToLower(key)
+		if len(md.Get(key)) > 0 {
+			continue
+		}
+		for _, value := range values {
+			kvs = append(kvs, key, value)
+		}
+	}
+	if len(kvs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kvs...)
+}
+
+// UnaryClientPropagator returns a grpc unary client interceptor which injects the headers carried by ctx
+// into outgoing metadata.
+func UnaryClientPropagator() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientPropagator returns a grpc stream client interceptor which injects the headers carried by ctx
+// into outgoing metadata.
+func StreamClientPropagator() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,139 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+func TestPropagation_HTTP(t *testing.T) {
+	var received http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+	client := &http.Client{Transport: PropagationTransport(nil)}
+
+	r := gin.New()
+	r.Use(RequestLogger(logger.GetLogger("HTTP", "Test"), nil), Propagation())
+	r.GET("/api", func(c *gin.Context) {
+		req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, backend.URL, http.NoBody)
+		assert.NoError(t, err)
+		req.Header.Set(TenantHeader, "caller")
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		// request of caller isn't modified
+		assert.Empty(t, req.Header.Get(BaggageHeader))
+		c.Status(http.StatusOK)
+	})
+
+	headers := http.Header{}
+	headers.Set(TraceParentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	headers.Add(BaggageHeader, "userId=1")
+	headers.Add(BaggageHeader, "region=cn")
+	headers.Set(TenantHeader, "tenant1")
+	headers.Set(FeatureFlagsHeader, "new-planner")
+	headers.Set("X-Not-Propagated", "v")
+	resp := DoRequest(t, r, http.MethodGet, "/api", "", headers)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", received.Get(TraceParentHeader))
+	assert.Equal(t, []string{"userId=1", "region=cn"}, received.Values(BaggageHeader))
+	assert.Equal(t, "caller", received.Get(TenantHeader))
+	assert.Equal(t, "new-planner", received.Get(FeatureFlagsHeader))
+	assert.Empty(t, received.Get("X-Not-Propagated"))
+	// generated request id is propagated
+	assert.Len(t, received.Get(RequestIDHeader), 32)
+	assert.Equal(t, resp.Header().Get(RequestIDHeader), received.Get(RequestIDHeader))
+
+	// no header to propagate
+	r = gin.New()
+	r.Use(Propagation(TenantHeader))
+	r.GET("/api", func(c *gin.Context) {
+		assert.Nil(t, PropagatedHeaders(c.Request.Context()))
+		req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, backend.URL, http.NoBody)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		c.Status(http.StatusOK)
+	})
+	resp = DoRequest(t, r, http.MethodGet, "/api", "", http.Header{RequestIDHeader: []string{"req-1"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, received.Get(RequestIDHeader))
+}
+
+func TestPropagation_Context(t *testing.T) {
+	assert.Nil(t, PropagatedHeaders(nil)) //nolint:staticcheck
+	ctx := WithPropagatedHeaders(context.TODO(), http.Header{"x-lindb-tenant": {"t1"}, "baggage": {"k=v"}})
+	ctx = WithPropagatedHeaders(ctx, http.Header{TenantHeader: {"t2"}})
+	headers := PropagatedHeaders(ctx)
+	assert.Equal(t, "t2", headers.Get(TenantHeader))
+	assert.Equal(t, "k=v", headers.Get(BaggageHeader))
+	// copy is returned
+	headers.Set(TenantHeader, "t3")
+	assert.Equal(t, "t2", PropagatedHeaders(ctx).Get(TenantHeader))
+
+	header := http.Header{}
+	header.Set(BaggageHeader, "a=b")
+	InjectHeaders(ctx, header)
+	assert.Equal(t, "a=b", header.Get(BaggageHeader))
+	assert.Equal(t, "t2", header.Get(TenantHeader))
+}
+
+func TestPropagation_GRPC(t *testing.T) {
+	ctx := WithPropagatedHeaders(context.TODO(), http.Header{
+		TenantHeader:  {"t1"},
+		BaggageHeader: {"k1=v1", "k2=v2"},
+	})
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-lindb-tenant", "caller")
+
+	var md metadata.MD
+	err := UnaryClientPropagator()(ctx, "/svc/method", nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"caller"}, md.Get(TenantHeader))
+	assert.Equal(t, []string{"k1=v1", "k2=v2"}, md.Get(BaggageHeader))
+
+	_, err = StreamClientPropagator()(context.TODO(), &grpc.StreamDesc{}, nil, "/svc/stream",
+		func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil, nil
+		})
+	assert.NoError(t, err)
+	assert.Empty(t, md)
+
+	// all keys are set by caller
+	ctx = WithPropagatedHeaders(context.TODO(), http.Header{TenantHeader: {"t1"}})
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-lindb-tenant", "caller")
+	assert.Equal(t, ctx, outgoingContext(ctx))
+}
@@ -0,0 +1,315 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/common/pkg/logger"
+	"github.com/lindb/common/pkg/state"
+)
+
+const (
+	// QuotaLimitHeader is the header of the limit which is closest to be exhausted.
+	QuotaLimitHeader = "X-Quota-Limit"
+	// QuotaRemainingHeader is the header of the remaining quota of the limit.
+	QuotaRemainingHeader = "X-Quota-Remaining"
+	// QuotaWindowHeader is the header of the window(in seconds) of the limit.
+	QuotaWindowHeader = "X-Quota-Window"
+)
+
+// QuotaLimit represents the max requests/bytes of a principal in a sliding window, 0 means unlimited.
+type QuotaLimit struct {
+	Window   time.Duration `json:"window"`
+	Requests int64         `json:"requests"`
+	Bytes    int64         `json:"bytes"` // request body bytes
+}
+
+// QuotaCounter counts the usage of quota in sliding windows,
+// the implementation can be in-memory(MemoryQuotaCounter) or backed by state store(StateQuotaCounter, shared by all brokers).
+type QuotaCounter interface {
+	// Add adds delta into the sliding window of key, returns the usage in window after adding.
+	Add(ctx context.Context, key string, window time.Duration, delta int64) (int64, error)
+}
+
+// QuotaOptions represents the options of quota middleware.
+type QuotaOptions struct {
+	// Principal returns the principal(e.g. user/tenant) of request, request without principal is not limited.
+	Principal func(c *gin.Context) string
+	// Limits are the default limits for all principals.
+	Limits []QuotaLimit
+	// Overrides are the limits for specific principals, which replace the default limits.
+	Overrides map[string][]QuotaLimit
+	// Counter counts the usage, in-memory counter is used if nil.
+	Counter QuotaCounter
+}
+
+// Quota returns a middleware which enforces the requests/bytes quota per principal,
+// responds 429 with quota headers if any limit is exceeded, the rejected request is also counted.
+// The request is allowed if the counter fails.
+func Quota(options QuotaOptions) gin.HandlerFunc {
+	counter := options.Counter
+	if counter == nil {
+		counter = NewMemoryQuotaCounter()
+	}
+	return func(c *gin.Context) {
+		if options.Principal == nil {
+			c.Next()
+			return
+		}
+		principal := options.Principal(c)
+		if principal == "" {
+			c.Next()
+			return
+		}
+		limits, ok := options.Overrides[principal]
+		if !ok {
+			limits = options.Limits
+		}
+		var (
+			closest   QuotaLimit
+			limit     int64
+			remaining int64 = -1
+			exceeded  bool
+		)
+		check := func(l QuotaLimit, kind string, max, delta int64) {
+			if max <= 0 || exceeded {
+				return
+			}
+			key := fmt.Sprintf("%s|%s|%d", principal, kind, l.Window)
+			used, err := counter.Add(c.Request.Context(), key, l.Window, delta)
+			if err != nil {
+				log.Warn("count quota failure, allow request", logger.String("principal", principal), logger.Error(err))
+				return
+			}
+			left := max - used
+			if left < 0 {
+				exceeded = true
+				left = 0
+			}
+			if exceeded || remaining < 0 || left < remaining {
+				closest, limit, remaining = l, max, left
+			}
+		}
+		for _, l := range limits {
+			check(l, "requests", l.Requests, 1)
+			if c.Request.ContentLength > 0 {
+				check(l, "bytes", l.Bytes, c.Request.ContentLength)
+			}
+		}
+		if remaining >= 0 {
+			c.Header(QuotaLimitHeader, strconv.FormatInt(limit, 10))
+			c.Header(QuotaRemainingHeader, strconv.FormatInt(remaining, 10))
+			c.Header(QuotaWindowHeader, strconv.Itoa(int(closest.Window.Seconds())))
+		}
+		if exceeded {
+			c.Header("Retry-After", strconv.Itoa(int(closest.Window.Seconds())))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests,
+				fmt.Sprintf("quota exceeded for principal: %s, limit: %d per %s", principal, limit, closest.Window))
+			return
+		}
+		c.Next()
+	}
+}
+
+// slidingWindow counts the usage of current and previous fixed window,
+// the usage of sliding window is weighted by the overlap of previous window.
+type slidingWindow struct {
+	size     int64 // window size in nanoseconds
+	start    int64 // start of current window in nanoseconds
+	current  int64
+	previous int64
+}
+
+// MemoryQuotaCounter counts the usage in memory, which is only for single node.
+type MemoryQuotaCounter struct {
+	windows     map[string]*slidingWindow
+	lastCleanup int64
+
+	lock sync.Mutex
+}
+
+// NewMemoryQuotaCounter creates an in-memory quota counter.
+func NewMemoryQuotaCounter() *MemoryQuotaCounter {
+	return &MemoryQuotaCounter{
+		windows:     make(map[string]*slidingWindow),
+		lastCleanup: nowFunc().UnixNano(),
+	}
+}
+
+// Add adds delta into the sliding window of key, returns the usage in window after adding.
+func (m *MemoryQuotaCounter) Add(_ context.Context, key string, window time.Duration, delta int64) (int64, error) {
+	if window <= 0 {
+		return 0, fmt.Errorf("invalid quota window: %s", window)
+	}
+	now := nowFunc().UnixNano()
+	size := int64(window)
+	start := now - now%size
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.cleanup(now)
+	w, ok := m.windows[key]
+	if !ok {
+		w = &slidingWindow{size: size, start: start}
+		m.windows[key] = w
+	}
+	switch {
+	case w.start == start:
+	case w.start+size == start:
+		w.previous, w.current = w.current, 0
+		w.start = start
+	default:
+		// expired
+		w.previous, w.current = 0, 0
+		w.start = start
+	}
+	w.current += delta
+	weight := float64(size-(now-start)) / float64(size)
+	return w.current + int64(float64(w.previous)*weight), nil
+}
+
+// cleanup removes the windows which are not used for a while, runs once per minute.
+func (m *MemoryQuotaCounter) cleanup(now int64) {
+	if now-m.lastCleanup < int64(time.Minute) {
+		return
+	}
+	m.lastCleanup = now
+	for key, w := range m.windows {
+		// both current and previous window are expired
+		if now-w.start >= 2*w.size {
+			delete(m.windows, key)
+		}
+	}
+}
+
+// quotaLease represents the fixed window which the lease of counters belongs to.
+type quotaLease struct {
+	size  int64
+	start int64
+}
+
+// StateQuotaCounter counts the usage in state repository, which is shared by all nodes.
+// The usage of each fixed window is stored as key: prefix/key/window start, attached to a lease
+// which expires after two windows, so the counters are removed automatically.
+// The state repository has no atomic increment, the usage is read-modify-write, the adds on same node are
+// serialized, but concurrent adds on different nodes may be under counted.
+type StateQuotaCounter struct {
+	repo       state.Repository
+	prefix     string
+	isNotExist func(err error) bool
+	leases     map[quotaLease]state.LeaseID
+
+	lock sync.Mutex
+}
+
+// NewStateQuotaCounter creates a quota counter backed by state repository,
+// isNotExist checks if the error returned by state repository means the key not exists.
+func NewStateQuotaCounter(repo state.Repository, prefix string, isNotExist func(err error) bool) *StateQuotaCounter {
+	if isNotExist == nil {
+		isNotExist = func(_ error) bool { return false }
+	}
+	return &StateQuotaCounter{
+		repo:       repo,
+		prefix:     strings.TrimRight(prefix, "/"),
+		isNotExist: isNotExist,
+		leases:     make(map[quotaLease]state.LeaseID),
+	}
+}
+
+// Add adds delta into the sliding window of key, returns the usage in window after adding.
+func (s *StateQuotaCounter) Add(ctx context.Context, key string, window time.Duration, delta int64) (int64, error) {
+	if window <= 0 {
+		return 0, fmt.Errorf("invalid quota window: %s", window)
+	}
+	now := nowFunc().UnixNano()
+	size := int64(window)
+	start := now - now%size
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	previous, err := s.get(ctx, s.key(key, start-size))
+	if err != nil {
+		return 0, err
+	}
+	current, err := s.get(ctx, s.key(key, start))
+	if err != nil {
+		return 0, err
+	}
+	current += delta
+	lease, err := s.lease(ctx, now, quotaLease{size: size, start: start})
+	if err != nil {
+		return 0, err
+	}
+	if err = s.repo.PutWithLease(ctx, s.key(key, start), []byte(strconv.FormatInt(current, 10)), lease); err != nil {
+		return 0, err
+	}
+	weight := float64(size-(now-start)) / float64(size)
+	return current + int64(float64(previous)*weight), nil
+}
+
+// get returns the usage stored in key, 0 if not exists.
+func (s *StateQuotaCounter) get(ctx context.Context, key string) (int64, error) {
+	data, err := s.repo.Get(ctx, key)
+	if err != nil {
+		if s.isNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+// lease returns the lease of window, grants a lease expired after two windows if not exists.
+// The leases of expired windows are removed.
+func (s *StateQuotaCounter) lease(ctx context.Context, now int64, window quotaLease) (state.LeaseID, error) {
+	if lease, ok := s.leases[window]; ok {
+		return lease, nil
+	}
+	for w := range s.leases {
+		if now-w.start >= 2*w.size {
+			delete(s.leases, w)
+		}
+	}
+	// the lease starts from now, which keeps the counter until the end of next window
+	ttl := time.Duration(window.start + 2*window.size - now)
+	lease, err := s.repo.Grant(ctx, (ttl + time.Second - 1).Truncate(time.Second))
+	if err != nil {
+		return 0, err
+	}
+	s.leases[window] = lease
+	return lease, nil
+}
+
+// key returns the state key of usage in window.
+func (s *StateQuotaCounter) key(key string, start int64) string {
+	return s.prefix + "/" + key + "/" + strconv.FormatInt(start, 10)
+}
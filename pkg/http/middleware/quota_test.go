@@ -0,0 +1,292 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/state"
+)
+
+var errNotExist = errors.New("not exist")
+
+// mockQuotaRepo implements state.Repository in memory, records the ttl of leases.
+type mockQuotaRepo struct {
+	state.Repository
+
+	data     map[string][]byte
+	leases   []time.Duration
+	getErr   error
+	grantErr error
+	putErr   error
+
+	lock sync.Mutex
+}
+
+func newMockQuotaRepo() *mockQuotaRepo {
+	return &mockQuotaRepo{data: make(map[string][]byte)}
+}
+
+func (r *mockQuotaRepo) Get(_ context.Context, key string) ([]byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	value, ok := r.data[key]
+	if !ok {
+		return nil, errNotExist
+	}
+	return value, nil
+}
+
+func (r *mockQuotaRepo) Grant(_ context.Context, ttl time.Duration) (state.LeaseID, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.grantErr != nil {
+		return 0, r.grantErr
+	}
+	r.leases = append(r.leases, ttl)
+	return state.LeaseID(len(r.leases)), nil
+}
+
+func (r *mockQuotaRepo) PutWithLease(_ context.Context, key string, value []byte, _ state.LeaseID) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.putErr != nil {
+		return r.putErr
+	}
+	r.data[key] = value
+	return nil
+}
+
+type errQuotaCounter struct{}
+
+func (errQuotaCounter) Add(_ context.Context, _ string, _ time.Duration, _ int64) (int64, error) {
+	return 0, fmt.Errorf("err")
+}
+
+func newQuotaRouter(options QuotaOptions) *gin.Engine {
+	r := gin.New()
+	r.Use(Quota(options))
+	r.POST("/write", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	return r
+}
+
+func principalHeader(c *gin.Context) string {
+	return c.GetHeader("X-User")
+}
+
+func TestQuota_Requests(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Unix(600, 0)
+	nowFunc = func() time.Time { return now }
+
+	r := newQuotaRouter(QuotaOptions{
+		Principal: principalHeader,
+		Limits:    []QuotaLimit{{Window: time.Minute, Requests: 2}, {Window: time.Hour, Requests: 10}},
+		Overrides: map[string][]QuotaLimit{"admin": nil},
+	})
+	user := http.Header{"X-User": []string{"user"}}
+	resp := DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "2", resp.Header().Get(QuotaLimitHeader))
+	assert.Equal(t, "1", resp.Header().Get(QuotaRemainingHeader))
+	assert.Equal(t, "60", resp.Header().Get(QuotaWindowHeader))
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(QuotaRemainingHeader))
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	assert.Equal(t, "0", resp.Header().Get(QuotaRemainingHeader))
+
+	// other principal
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", http.Header{"X-User": []string{"other"}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// override without limits
+	for i := 0; i < 5; i++ {
+		resp = DoRequest(t, r, http.MethodPost, "/write", "", http.Header{"X-User": []string{"admin"}})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get(QuotaLimitHeader))
+	}
+	// no principal
+	resp = DoRequest(t, r, http.MethodPost, "/write", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// sliding window, previous window(3 requests) weighted by 0.5
+	now = now.Add(90 * time.Second)
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(QuotaRemainingHeader))
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	// both windows of minute limit expired
+	now = now.Add(2 * time.Minute)
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get(QuotaRemainingHeader))
+}
+
+func TestQuota_Bytes(t *testing.T) {
+	r := newQuotaRouter(QuotaOptions{
+		Principal: principalHeader,
+		Limits:    []QuotaLimit{{Window: time.Minute, Bytes: 10}},
+	})
+	user := http.Header{"X-User": []string{"user"}}
+	resp := DoRequest(t, r, http.MethodPost, "/write", "12345678", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "10", resp.Header().Get(QuotaLimitHeader))
+	assert.Equal(t, "2", resp.Header().Get(QuotaRemainingHeader))
+	resp = DoRequest(t, r, http.MethodPost, "/write", "12345678", user)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	// empty body not counted
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestQuota_NoPrincipal_CounterFailure(t *testing.T) {
+	r := newQuotaRouter(QuotaOptions{Limits: []QuotaLimit{{Window: time.Minute, Requests: 1}}})
+	for i := 0; i < 3; i++ {
+		resp := DoRequest(t, r, http.MethodPost, "/write", "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+	}
+	r = newQuotaRouter(QuotaOptions{
+		Principal: principalHeader,
+		Limits:    []QuotaLimit{{Window: time.Minute, Requests: 1}},
+		Counter:   errQuotaCounter{},
+	})
+	for i := 0; i < 3; i++ {
+		resp := DoRequest(t, r, http.MethodPost, "/write", "", http.Header{"X-User": []string{"user"}})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get(QuotaLimitHeader))
+	}
+}
+
+func TestMemoryQuotaCounter(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Unix(600, 0)
+	nowFunc = func() time.Time { return now }
+	c := NewMemoryQuotaCounter()
+	_, err := c.Add(context.TODO(), "k", 0, 1)
+	assert.Error(t, err)
+
+	used, err := c.Add(context.TODO(), "k", time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), used)
+	now = now.Add(75 * time.Second)
+	used, _ = c.Add(context.TODO(), "k", time.Minute, 1)
+	// 10 * 0.75 + 1
+	assert.Equal(t, int64(8), used)
+
+	// cleanup expired windows
+	now = now.Add(3 * time.Minute)
+	_, _ = c.Add(context.TODO(), "k2", time.Minute, 1)
+	assert.Len(t, c.windows, 1)
+}
+
+func TestStateQuotaCounter(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Unix(600, 0)
+	nowFunc = func() time.Time { return now }
+	repo := newMockQuotaRepo()
+	c := NewStateQuotaCounter(repo, "/quota/", func(err error) bool { return errors.Is(err, errNotExist) })
+	_, err := c.Add(context.TODO(), "k", 0, 1)
+	assert.Error(t, err)
+
+	used, err := c.Add(context.TODO(), "k", time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), used)
+	assert.Equal(t, []byte("10"), repo.data["/quota/k/600000000000"])
+	now = now.Add(15 * time.Second)
+	used, _ = c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Equal(t, int64(11), used)
+	// lease is shared by counters of same window, expired after next window
+	assert.Equal(t, []time.Duration{2 * time.Minute}, repo.leases)
+	now = now.Add(60 * time.Second)
+	used, _ = c.Add(context.TODO(), "k", time.Minute, 1)
+	// 11 * 0.75 + 1
+	assert.Equal(t, int64(9), used)
+	assert.Equal(t, []time.Duration{2 * time.Minute, 105 * time.Second}, repo.leases)
+
+	// expired leases are removed
+	now = now.Add(3 * time.Minute)
+	_, _ = c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Len(t, c.leases, 1)
+
+	// counter is shared by nodes
+	other := NewStateQuotaCounter(repo, "/quota", func(err error) bool { return errors.Is(err, errNotExist) })
+	used, err = other.Add(context.TODO(), "k", time.Minute, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), used)
+}
+
+func TestStateQuotaCounter_Failure(t *testing.T) {
+	repo := newMockQuotaRepo()
+	c := NewStateQuotaCounter(repo, "/quota", nil)
+	// not exist error isn't recognized
+	_, err := c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Error(t, err)
+
+	c = NewStateQuotaCounter(repo, "/quota", func(err error) bool { return errors.Is(err, errNotExist) })
+	repo.grantErr = fmt.Errorf("err")
+	_, err = c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Error(t, err)
+	repo.grantErr = nil
+	repo.putErr = fmt.Errorf("err")
+	_, err = c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Error(t, err)
+	repo.putErr = nil
+
+	repo.data["/quota/k/"+fmt.Sprint(time.Now().UnixNano()/int64(time.Hour)*int64(time.Hour))] = []byte("invalid")
+	_, err = c.Add(context.TODO(), "k", time.Hour, 1)
+	assert.Error(t, err)
+	repo.getErr = fmt.Errorf("err")
+	_, err = c.Add(context.TODO(), "k", time.Minute, 1)
+	assert.Error(t, err)
+}
+
+func TestQuota_StateCounter(t *testing.T) {
+	r := newQuotaRouter(QuotaOptions{
+		Principal: principalHeader,
+		Limits:    []QuotaLimit{{Window: time.Hour, Requests: 1}},
+		Counter:   NewStateQuotaCounter(newMockQuotaRepo(), "/quota", func(err error) bool { return errors.Is(err, errNotExist) }),
+	})
+	user := http.Header{"X-User": []string{"user"}}
+	resp := DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = DoRequest(t, r, http.MethodPost, "/write", "", user)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+}
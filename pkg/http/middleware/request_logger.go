@@ -0,0 +1,172 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+const (
+	// RequestIDHeader is the header of request id.
+	RequestIDHeader = "X-Request-Id"
+	// TraceParentHeader is the header of W3C trace context, format: version-traceID-spanID-flags.
+	TraceParentHeader = "traceparent"
+	// B3TraceIDHeader is the header of zipkin b3 trace id.
+	B3TraceIDHeader = "X-B3-TraceId"
+	// B3SpanIDHeader is the header of zipkin b3 span id.
+	B3SpanIDHeader = "X-B3-SpanId"
+
+	requestIDKey     = "requestID"
+	requestLoggerKey = "requestLogger"
+)
+
+// for testing
+var (
+	randReadFunc = rand.Read
+)
+
+// defaultRequestLogger is used if no request logger in gin context.
+var defaultRequestLogger = logger.GetLogger("HTTP", "Request")
+
+// RequestLogger returns a middleware which creates a request scoped logger with request id/trace id/route/principal fields,
+// request id is taken from X-Request-Id header or generated, principal is optional for getting current user.
+// The logger is also attached to request context, handlers can get it by logger.FromContext.
+func RequestLogger(log logger.Logger, principal func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fields := requestFields(c)
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		fields = append(fields, logger.String("route", c.Request.Method+" "+route))
+		if principal != nil {
+			if p := principal(c); p != "" {
+				fields = append(fields, logger.String("principal", p))
+			}
+		}
+		reqLog := log.With(fields...)
+		c.Set(requestLoggerKey, reqLog)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), reqLog))
+		c.Next()
+	}
+}
+
+// LoggerFromGin returns the request scoped logger in gin context, returns default logger if not exist.
+func LoggerFromGin(c *gin.Context) logger.Logger {
+	if log, ok := c.Get(requestLoggerKey); ok {
+		return log.(logger.Logger)
+	}
+	return defaultRequestLogger
+}
+
+// RequestIDFromGin returns the request id in gin context, returns empty string if not exist.
+func RequestIDFromGin(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestFields returns the request id/trace id/span id fields of request,
+// request id is generated if not exist.
+func requestFields(c *gin.Context) []zap.Field {
+	requestID := RequestIDFromGin(c)
+	if requestID == "" {
+		requestID = c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set(requestIDKey, requestID)
+	}
+	fields := []zap.Field{logger.String("requestID", requestID)}
+	if traceID, spanID := traceFromRequest(c); traceID != "" {
+		fields = append(fields, logger.String("traceID", traceID))
+		if spanID != "" {
+			fields = append(fields, logger.String("spanID", spanID))
+		}
+	}
+	return fields
+}
+
+// traceFromRequest returns the trace id/span id from W3C traceparent header or zipkin b3 headers.
+func traceFromRequest(c *gin.Context) (traceID, spanID string) {
+	var ok bool
+	if traceID, spanID, ok = ParseTraceParent(c.GetHeader(TraceParentHeader)); ok {
+		return traceID, spanID
+	}
+	traceID = c.GetHeader(B3TraceIDHeader)
+	if !isHex(traceID) {
+		return "", ""
+	}
+	spanID = c.GetHeader(B3SpanIDHeader)
+	if !isHex(spanID) {
+		spanID = ""
+	}
+	return strings.ToLower(traceID), strings.ToLower(spanID)
+}
+
+// ParseTraceParent parses the W3C traceparent header(version-traceID-spanID-flags), returns false if invalid.
+func ParseTraceParent(traceParent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || !isHex(version) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if len(traceID) != 32 || !isHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return "", "", false
+	}
+	if len(spanID) != 16 || !isHex(spanID) || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	if len(flags) != 2 || !isHex(flags) {
+		return "", "", false
+	}
+	return strings.ToLower(traceID), strings.ToLower(spanID), true
+}
+
+// isHex returns if s is a non-empty lowercase/uppercase hex string.
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request id.
+func newRequestID() string {
+	var id [16]byte
+	if _, err := randReadFunc(id[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
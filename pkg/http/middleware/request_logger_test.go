@@ -0,0 +1,203 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+func TestRequestLogger(t *testing.T) {
+	defer func() {
+		randReadFunc = rand.Read
+	}()
+	r := gin.New()
+	r.Use(RequestLogger(logger.GetLogger("HTTP", "Test"), func(c *gin.Context) string {
+		return c.GetHeader("X-User")
+	}))
+	var (
+		log       logger.Logger
+		requestID string
+	)
+	r.GET("/api/:name", func(c *gin.Context) {
+		log = LoggerFromGin(c)
+		requestID = RequestIDFromGin(c)
+		assert.Equal(t, log, logger.FromContext(c.Request.Context()))
+		log.Info("handle request")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	resp := DoRequest(t, r, http.MethodGet, "/api/test", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, requestID, 32)
+	assert.Equal(t, requestID, resp.Header().Get(RequestIDHeader))
+	assert.NotEqual(t, defaultRequestLogger, log)
+
+	resp = DoRequest(t, r, http.MethodGet, "/api/test", "", http.Header{
+		RequestIDHeader: []string{"req-1"},
+		"X-User":        []string{"admin"},
+	})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "req-1", requestID)
+	assert.Equal(t, "req-1", resp.Header().Get(RequestIDHeader))
+
+	// route not found
+	resp = DoRequest(t, r, http.MethodGet, "/not-found", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	randReadFunc = func(_ []byte) (int, error) {
+		return 0, fmt.Errorf("err")
+	}
+	_ = DoRequest(t, r, http.MethodGet, "/api/test", "")
+	assert.Empty(t, requestID)
+}
+
+func TestLoggerFromGin(t *testing.T) {
+	c, _ := gin.CreateTestContext(nil)
+	assert.Equal(t, defaultRequestLogger, LoggerFromGin(c))
+	assert.Empty(t, RequestIDFromGin(c))
+}
+
+func TestRequestLogger_Trace(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestLogger(logger.GetLogger("HTTP", "Test"), nil))
+	var fields []zap.Field
+	r.GET("/api", func(c *gin.Context) {
+		fields = requestFields(c)
+		c.JSON(http.StatusOK, "ok")
+	})
+	cases := []struct {
+		name    string
+		headers map[string]string
+		fields  []zap.Field
+	}{
+		{
+			name:    "no trace",
+			headers: map[string]string{RequestIDHeader: "req-1"},
+			fields:  []zap.Field{logger.String("requestID", "req-1")},
+		},
+		{
+			name: "traceparent",
+			headers: map[string]string{
+				RequestIDHeader:   "req-1",
+				TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				B3TraceIDHeader:   "463ac35c9f6413ad",
+			},
+			fields: []zap.Field{
+				logger.String("requestID", "req-1"),
+				logger.String("traceID", "4bf92f3577b34da6a3ce929d0e0e4736"),
+				logger.String("spanID", "00f067aa0ba902b7"),
+			},
+		},
+		{
+			name: "b3",
+			headers: map[string]string{
+				RequestIDHeader: "req-1",
+				B3TraceIDHeader: "463AC35C9F6413AD",
+				B3SpanIDHeader:  "a2fb4a1d1a96d312",
+			},
+			fields: []zap.Field{
+				logger.String("requestID", "req-1"),
+				logger.String("traceID", "463ac35c9f6413ad"),
+				logger.String("spanID", "a2fb4a1d1a96d312"),
+			},
+		},
+		{
+			name: "b3 without span",
+			headers: map[string]string{
+				RequestIDHeader: "req-1",
+				B3TraceIDHeader: "463ac35c9f6413ad",
+				B3SpanIDHeader:  "xyz",
+			},
+			fields: []zap.Field{
+				logger.String("requestID", "req-1"),
+				logger.String("traceID", "463ac35c9f6413ad"),
+			},
+		},
+		{
+			name: "invalid trace",
+			headers: map[string]string{
+				RequestIDHeader:   "req-1",
+				TraceParentHeader: "invalid",
+				B3TraceIDHeader:   "xyz",
+			},
+			fields: []zap.Field{logger.String("requestID", "req-1")},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			for k, v := range tt.headers {
+				headers.Set(k, v)
+			}
+			resp := DoRequest(t, r, http.MethodGet, "/api", "", headers)
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	cases := []struct {
+		traceParent string
+		traceID     string
+		spanID      string
+		ok          bool
+	}{
+		{
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", ok: true,
+		},
+		{
+			traceParent: " 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-00 ",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", ok: true,
+		},
+		{
+			// future version may have more parts
+			traceParent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", ok: true,
+		},
+		{traceParent: ""},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{traceParent: "0-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b-01"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x"},
+	}
+	for _, tt := range cases {
+		traceID, spanID, ok := ParseTraceParent(tt.traceParent)
+		assert.Equal(t, tt.ok, ok, tt.traceParent)
+		assert.Equal(t, tt.traceID, traceID, tt.traceParent)
+		assert.Equal(t, tt.spanID, spanID, tt.traceParent)
+	}
+}
@@ -0,0 +1,400 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ValidationInPath represents the error of path parameter.
+	ValidationInPath = "path"
+	// ValidationInQuery represents the error of query parameter.
+	ValidationInQuery = "query"
+	// ValidationInHeader represents the error of header parameter.
+	ValidationInHeader = "header"
+	// ValidationInBody represents the error of request body.
+	ValidationInBody = "body"
+)
+
+// RequestValidationError represents a violation of request against the OpenAPI document.
+type RequestValidationError struct {
+	In      string `json:"in"`             // path/query/header/body
+	Name    string `json:"name,omitempty"` // parameter name or field path of body, e.g. tags[0].key
+	Message string `json:"message"`
+}
+
+// RequestValidationErrors represents the response of invalid request(400).
+type RequestValidationErrors struct {
+	Message string                   `json:"message"`
+	Errors  []RequestValidationError `json:"errors"`
+}
+
+// Error returns the error message.
+func (e *RequestValidationErrors) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Message)
+	for i, err := range e.Errors {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.In)
+		if err.Name != "" {
+			sb.WriteString(" " + err.Name)
+		}
+		sb.WriteString(" " + err.Message)
+	}
+	return sb.String()
+}
+
+// ValidateRequest returns a middleware which validates the parameters(path/query/header) and JSON body of requests
+// against the OpenAPI document, responds 400 with the violations(RequestValidationErrors) if invalid.
+// The requests of paths/operations not defined in the document are passed through.
+func ValidateRequest(doc *OpenAPIDocument) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		item, pathParams, ok := doc.match(c.Request.URL.Path)
+		if !ok {
+			c.Next()
+			return
+		}
+		op := item.operation(c.Request.Method)
+		if op == nil {
+			c.Next()
+			return
+		}
+		v := &requestValidator{doc: doc}
+		v.validateParameters(c.Request, pathParams, item.Parameters, op.Parameters)
+		if err := v.validateBody(c.Request, doc.requestBody(op.RequestBody)); err != nil {
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(v.errs) > 0 {
+			err := &RequestValidationErrors{Message: "invalid request", Errors: v.errs}
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestValidator collects the violations of a request.
+type requestValidator struct {
+	doc  *OpenAPIDocument
+	errs []RequestValidationError
+}
+
+// addError adds a violation.
+func (v *requestValidator) addError(in, name, format string, args ...interface{}) {
+	v.errs = append(v.errs, RequestValidationError{In: in, Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateParameters validates the parameters, the operation parameters override the path item parameters.
+func (v *requestValidator) validateParameters(r *http.Request, pathParams map[string]string, common, params []*openAPIParameter) {
+	resolved := make(map[string]*openAPIParameter)
+	var keys []string
+	for _, p := range append(append([]*openAPIParameter{}, common...), params...) {
+		if p = v.doc.parameter(p); p == nil {
+			continue
+		}
+		key := p.In + ":" + p.Name
+		if _, ok := resolved[key]; !ok {
+			keys = append(keys, key)
+		}
+		resolved[key] = p
+	}
+	query := r.URL.Query()
+	for _, key := range keys {
+		p := resolved[key]
+		var values []string
+		switch p.In {
+		case ValidationInPath:
+			if value, ok := pathParams[p.Name]; ok {
+				values = []string{value}
+			}
+		case ValidationInQuery:
+			values = query[p.Name]
+		case ValidationInHeader:
+			values = r.Header.Values(p.Name)
+		default:
+			// cookie parameter isn't validated
+			continue
+		}
+		if len(values) == 0 {
+			if p.Required || p.In == ValidationInPath {
+				v.addError(p.In, p.Name, "is required")
+			}
+			continue
+		}
+		schema := v.doc.schema(p.Schema)
+		if schema == nil {
+			continue
+		}
+		if schema.Type == "array" {
+			items := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				items = append(items, v.convertParameter(v.doc.schema(schema.Items), value))
+			}
+			v.validateValue(p.In, p.Name, schema, items, 0)
+			continue
+		}
+		v.validateValue(p.In, p.Name, schema, v.convertParameter(schema, values[0]), 0)
+	}
+}
+
+// convertParameter converts the parameter value into JSON value by schema type,
+// returns the raw string if it can't be converted, which is reported by validateValue.
+func (v *requestValidator) convertParameter(schema *openAPISchema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateBody validates the JSON request body, the body is restored for the handlers.
+// Returns error if the body can't be read.
+func (v *requestValidator) validateBody(r *http.Request, body *openAPIRequestBody) error {
+	if body == nil {
+		return nil
+	}
+	var schema *openAPISchema
+	for contentType, media := range body.Content {
+		if isJSONContentType(contentType) && media != nil {
+			schema = media.Schema
+			break
+		}
+	}
+	if schema == nil {
+		// only JSON body is validated
+		return nil
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !isJSONContentType(contentType) {
+		return nil
+	}
+	var data []byte
+	if r.Body != nil {
+		var err error
+		if data, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			v.addError(ValidationInBody, "", "is required")
+		}
+		return nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		v.addError(ValidationInBody, "", "is invalid json: %s", err)
+		return nil
+	}
+	v.validateValue(ValidationInBody, "", v.doc.schema(schema), value, 0)
+	return nil
+}
+
+// validateValue validates the JSON value against the schema recursively.
+func (v *requestValidator) validateValue(in, name string, schema *openAPISchema, value interface{}, depth int) {
+	if schema = v.doc.schema(schema); schema == nil || depth > maxRefDepth {
+		return
+	}
+	if value == nil {
+		if schema.Type != "" && !schema.Nullable {
+			v.addError(in, name, "must not be null")
+		}
+		return
+	}
+	if len(schema.Enum) > 0 && !containsJSONValue(schema.Enum, value) {
+		v.addError(in, name, "must be one of %s", formatEnum(schema.Enum))
+		return
+	}
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.addError(in, name, "must be string")
+			return
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			v.addError(in, name, "length must be >= %d", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			v.addError(in, name, "length must be <= %d", *schema.MaxLength)
+		}
+		if schema.pattern != nil && !schema.pattern.MatchString(s) {
+			v.addError(in, name, "must match pattern %s", schema.Pattern)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.addError(in, name, "must be %s", schema.Type)
+			return
+		}
+		f, err := n.Float64()
+		if err != nil || (schema.Type == "integer" && f != math.Trunc(f)) {
+			v.addError(in, name, "must be %s", schema.Type)
+			return
+		}
+		if schema.Minimum != nil {
+			if lower, err0 := schema.Minimum.Float64(); err0 == nil && f < lower {
+				v.addError(in, name, "must be >= %s", schema.Minimum.String())
+			}
+		}
+		if schema.Maximum != nil {
+			if upper, err0 := schema.Maximum.Float64(); err0 == nil && f > upper {
+				v.addError(in, name, "must be <= %s", schema.Maximum.String())
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.addError(in, name, "must be boolean")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.addError(in, name, "must be array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			v.addError(in, name, "must have >= %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			v.addError(in, name, "must have <= %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				v.validateValue(in, fmt.Sprintf("%s[%d]", name, i), schema.Items, item, depth+1)
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.addError(in, name, "must be object")
+			return
+		}
+		v.validateObject(in, name, schema, obj, depth)
+	}
+}
+
+// validateObject validates the properties of object.
+func (v *requestValidator) validateObject(in, name string, schema *openAPISchema, obj map[string]interface{}, depth int) {
+	for _, required := range schema.Required {
+		if _, ok := obj[required]; !ok {
+			v.addError(in, fieldPath(name, required), "is required")
+		}
+	}
+	// sorted for stable error order
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		property, ok := schema.Properties[key]
+		if !ok {
+			if !schema.allowAdditionalProperties() {
+				v.addError(in, fieldPath(name, key), "is not allowed")
+			}
+			continue
+		}
+		v.validateValue(in, fieldPath(name, key), property, obj[key], depth+1)
+	}
+}
+
+// fieldPath returns the path of object field.
+func fieldPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+// isJSONContentType returns if the content type is JSON, e.g. application/json, application/merge-patch+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// containsJSONValue returns if the value is in the enum values.
+func containsJSONValue(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if equalJSONValue(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSONValue returns if two scalar JSON values are equal, numbers are compared by value.
+func equalJSONValue(a, b interface{}) bool {
+	an, aNum := a.(json.Number)
+	bn, bNum := b.(json.Number)
+	if aNum && bNum {
+		af, err0 := an.Float64()
+		bf, err1 := bn.Float64()
+		return err0 == nil && err1 == nil && af == bf
+	}
+	switch a.(type) {
+	case string, bool, nil:
+		return a == b
+	default:
+		return false
+	}
+}
+
+// formatEnum returns the string of enum values.
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprintf("%v", e)
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
@@ -0,0 +1,183 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest(t *testing.T) {
+	doc, err := ParseOpenAPIDocument([]byte(testOpenAPIDocument))
+	assert.NoError(t, err)
+	r := gin.New()
+	r.Use(ValidateRequest(doc))
+	handler := func(c *gin.Context) {
+		// body is restored for handler
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+		}
+		c.String(http.StatusOK, string(body))
+	}
+	r.GET("/api/v1/metric/names", handler)
+	r.GET("/api/v1/metric/:name", handler)
+	r.PUT("/api/v1/metric/:name", handler)
+	r.POST("/api/v1/metric/:name", handler)
+	r.DELETE("/api/v1/metric/:name", handler)
+	r.GET("/api/v1/other", handler)
+
+	jsonHeader := http.Header{"Content-Type": []string{"application/json"}}
+	getHeader := http.Header{"X-Tenant": []string{"a"}}
+	cases := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		headers http.Header
+		errs    []RequestValidationError
+	}{
+		{name: "valid query", method: http.MethodGet, path: "/api/v1/metric/names?limit=10&prefix=cpu"},
+		{name: "invalid query", method: http.MethodGet, path: "/api/v1/metric/names?limit=a",
+			errs: []RequestValidationError{
+				{In: "query", Name: "limit", Message: "must be integer"},
+				{In: "query", Name: "prefix", Message: "is required"},
+			}},
+		{name: "query out of range", method: http.MethodGet, path: "/api/v1/metric/names?limit=101&prefix=",
+			errs: []RequestValidationError{
+				{In: "query", Name: "limit", Message: "must be <= 100"},
+				{In: "query", Name: "prefix", Message: "length must be >= 1"},
+			}},
+		{name: "query below minimum", method: http.MethodGet, path: "/api/v1/metric/names?limit=0&prefix=a",
+			errs: []RequestValidationError{{In: "query", Name: "limit", Message: "must be >= 1"}}},
+		{name: "fractional integer", method: http.MethodGet, path: "/api/v1/metric/names?limit=1.5&prefix=a",
+			errs: []RequestValidationError{{In: "query", Name: "limit", Message: "must be integer"}}},
+		{name: "valid path and header", method: http.MethodGet, path: "/api/v1/metric/cpu?tags=host&tags=ip", headers: getHeader},
+		{name: "invalid path and header", method: http.MethodGet, path: "/api/v1/metric/CPU?tags=a&tags=b&tags=c",
+			headers: http.Header{"X-Tenant": []string{"c"}},
+			errs: []RequestValidationError{
+				{In: "path", Name: "name", Message: "must match pattern ^[a-z]+$"},
+				{In: "header", Name: "X-Tenant", Message: "must be one of [a, b]"},
+				{In: "query", Name: "tags", Message: "must have <= 2 items"},
+			}},
+		{name: "missing header", method: http.MethodGet, path: "/api/v1/metric/cpu", headers: http.Header{},
+			errs: []RequestValidationError{{In: "header", Name: "X-Tenant", Message: "is required"}}},
+		{name: "valid body", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader,
+			body: `{"name":"cpu","interval":10,"ratio":0.5,"enabled":true,"type":null,"version":2,"tags":{},"fields":[{"name":"f"}]}`},
+		{name: "missing body", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader,
+			errs: []RequestValidationError{{In: "body", Message: "is required"}}},
+		{name: "invalid json", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader, body: `{`,
+			errs: []RequestValidationError{{In: "body", Message: "is invalid json: unexpected EOF"}}},
+		{name: "not object", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader, body: `[]`,
+			errs: []RequestValidationError{{In: "body", Message: "must be object"}}},
+		{name: "null body", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader, body: `null`,
+			errs: []RequestValidationError{{In: "body", Message: "must not be null"}}},
+		{name: "invalid body", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader,
+			body: `{"name":"cpu-usage-total","interval":-1,"ratio":2,"enabled":"true","type":"max","version":3,` +
+				`"tags":[],"fields":[{"name":1},{}],"unknown":1}`,
+			errs: []RequestValidationError{
+				{In: "body", Name: "enabled", Message: "must be boolean"},
+				{In: "body", Name: "fields[0].name", Message: "must be string"},
+				{In: "body", Name: "fields[1].name", Message: "is required"},
+				{In: "body", Name: "interval", Message: "must be >= 0"},
+				{In: "body", Name: "name", Message: "length must be <= 8"},
+				{In: "body", Name: "ratio", Message: "must be <= 1"},
+				{In: "body", Name: "tags", Message: "must be object"},
+				{In: "body", Name: "type", Message: "must be one of [sum, last]"},
+				{In: "body", Name: "unknown", Message: "is not allowed"},
+				{In: "body", Name: "version", Message: "must be one of [1, 2]"},
+			}},
+		{name: "wrong types", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader,
+			body: `{"name":1,"interval":"1","fields":{}}`,
+			errs: []RequestValidationError{
+				{In: "body", Name: "fields", Message: "must be array"},
+				{In: "body", Name: "interval", Message: "must be integer"},
+				{In: "body", Name: "name", Message: "must be string"},
+			}},
+		{name: "empty array", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader,
+			body: `{"name":"cpu","fields":[]}`,
+			errs: []RequestValidationError{{In: "body", Name: "fields", Message: "must have >= 1 items"}}},
+		{name: "missing fields", method: http.MethodPut, path: "/api/v1/metric/cpu", headers: jsonHeader, body: `{"name":"cpu"}`,
+			errs: []RequestValidationError{{In: "body", Name: "fields", Message: "is required"}}},
+		{name: "not json content type", method: http.MethodPut, path: "/api/v1/metric/cpu", body: `{`,
+			headers: http.Header{"Content-Type": []string{"text/plain"}}},
+		{name: "not json body", method: http.MethodPost, path: "/api/v1/metric/cpu", headers: jsonHeader, body: `{`},
+		{name: "operation not defined", method: http.MethodDelete, path: "/api/v1/metric/cpu"},
+		{name: "path not defined", method: http.MethodGet, path: "/api/v1/other"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.headers
+			if headers == nil {
+				headers = http.Header{"Content-Type": []string{"application/json"}}
+			}
+			resp := DoRequest(t, r, tt.method, tt.path, tt.body, headers)
+			if len(tt.errs) == 0 {
+				assert.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+				assert.Equal(t, tt.body, resp.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			errs := &RequestValidationErrors{}
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), errs))
+			assert.Equal(t, "invalid request", errs.Message)
+			assert.Equal(t, tt.errs, errs.Errors)
+		})
+	}
+}
+
+// errReader fails to read.
+type errReader struct{}
+
+func (r *errReader) Read(_ []byte) (int, error) { return 0, errors.New("read err") }
+
+func TestValidateRequest_ReadBodyFailure(t *testing.T) {
+	doc, err := ParseOpenAPIDocument([]byte(testOpenAPIDocument))
+	assert.NoError(t, err)
+	r := gin.New()
+	r.Use(ValidateRequest(doc))
+	r.PUT("/api/v1/metric/:name", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/metric/cpu", &errReader{})
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "read err")
+}
+
+func TestRequestValidationErrors_Error(t *testing.T) {
+	err := &RequestValidationErrors{Message: "invalid request", Errors: []RequestValidationError{
+		{In: "body", Message: "is required"},
+		{In: "query", Name: "limit", Message: "must be integer"},
+	}}
+	assert.Equal(t, "invalid request: body is required; query limit must be integer", err.Error())
+	assert.False(t, isJSONContentType("application/json; charset=\""))
+	assert.True(t, isJSONContentType("application/merge-patch+json"))
+	assert.True(t, strings.HasPrefix(formatEnum([]interface{}{1, "a"}), "[1, a"))
+	assert.False(t, equalJSONValue([]interface{}{}, []interface{}{}))
+}
@@ -0,0 +1,236 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/common/models"
+)
+
+var nowFunc = time.Now
+
+const (
+	// SLORequests is the metric name of requests in rolling window by route.
+	SLORequests = "lindb.http.slo.requests"
+	// SLOFailed is the metric name of 5xx requests in rolling window by route.
+	SLOFailed = "lindb.http.slo.failed"
+	// SLOSlow is the metric name of requests slower than latency threshold in rolling window by route.
+	SLOSlow = "lindb.http.slo.slow"
+	// SLOAvailability is the metric name of availability ratio by route.
+	SLOAvailability = "lindb.http.slo.availability"
+	// SLOAvailabilityBurnRate is the metric name of availability burn rate by route.
+	SLOAvailabilityBurnRate = "lindb.http.slo.availability_burn_rate"
+	// SLOErrorBudgetRemaining is the metric name of remaining error budget ratio by route.
+	SLOErrorBudgetRemaining = "lindb.http.slo.error_budget_remaining"
+	// SLOLatencyRatio is the metric name of ratio of requests faster than latency threshold by route.
+	SLOLatencyRatio = "lindb.http.slo.latency_ratio"
+	// SLOLatencyBurnRate is the metric name of latency burn rate by route.
+	SLOLatencyBurnRate = "lindb.http.slo.latency_burn_rate"
+)
+
+// SLOStatsHook receives the metric of slo status, e.g. converting to flat metric rows.
+type SLOStatsHook func(name string, tags map[string]string, value float64)
+
+// SLOObjective represents the availability and latency objectives of a route.
+type SLOObjective struct {
+	// Availability is the target ratio of non 5xx requests, e.g. 0.999.
+	Availability float64
+	// LatencyThreshold is the latency which a request should be served within.
+	LatencyThreshold time.Duration
+	// Latency is the target ratio of requests served within LatencyThreshold, e.g. 0.99.
+	Latency float64
+}
+
+// sloBucket records the requests of one time slot in the rolling window.
+type sloBucket struct {
+	slot   int64
+	total  uint64
+	failed uint64
+	slow   uint64
+}
+
+// routeSLO tracks the requests of a route using a ring of buckets.
+type routeSLO struct {
+	objective SLOObjective
+	buckets   []sloBucket
+}
+
+// SLOTracker tracks the availability/latency of routes in a rolling window,
+// then computes the error budget and burn rate based on the objectives.
+type SLOTracker struct {
+	window           time.Duration
+	slotSize         int64
+	buckets          int
+	defaultObjective SLOObjective
+	objectives       map[string]SLOObjective
+	routes           map[string]*routeSLO
+
+	lock sync.Mutex
+}
+
+// NewSLOTracker creates a slo tracker with the rolling window which is divided into n buckets.
+func NewSLOTracker(window time.Duration, buckets int, defaultObjective SLOObjective) *SLOTracker {
+	if buckets <= 0 {
+		buckets = 1
+	}
+	slotSize := int64(window) / int64(buckets)
+	if slotSize <= 0 {
+		slotSize = 1
+	}
+	return &SLOTracker{
+		window:           window,
+		slotSize:         slotSize,
+		buckets:          buckets,
+		defaultObjective: defaultObjective,
+		objectives:       make(map[string]SLOObjective),
+		routes:           make(map[string]*routeSLO),
+	}
+}
+
+// SetObjective sets the objective for given route(method + " " + path pattern, e.g. "GET /api/v1/exec").
+func (t *SLOTracker) SetObjective(route string, objective SLOObjective) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.objectives[route] = objective
+	if r, ok := t.routes[route]; ok {
+		r.objective = objective
+	}
+}
+
+// Record records a finished request of the route.
+func (t *SLOTracker) Record(route string, status int, cost time.Duration) {
+	slot := nowFunc().UnixNano() / t.slotSize
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	r, ok := t.routes[route]
+	if !ok {
+		objective, ok := t.objectives[route]
+		if !ok {
+			objective = t.defaultObjective
+		}
+		r = &routeSLO{
+			objective: objective,
+			buckets:   make([]sloBucket, t.buckets),
+		}
+		t.routes[route] = r
+	}
+	bucket := &r.buckets[slot%int64(len(r.buckets))]
+	if bucket.slot != slot {
+		*bucket = sloBucket{slot: slot}
+	}
+	bucket.total++
+	if status >= http.StatusInternalServerError {
+		bucket.failed++
+	}
+	if r.objective.LatencyThreshold > 0 && cost > r.objective.LatencyThreshold {
+		bucket.slow++
+	}
+}
+
+// Stats returns the slo status of all tracked routes in the rolling window, sorted by route.
+func (t *SLOTracker) Stats() models.SLOStatusList {
+	slot := nowFunc().UnixNano() / t.slotSize
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	rs := make(models.SLOStatusList, 0, len(t.routes))
+	for route, r := range t.routes {
+		status := &models.SLOStatus{
+			Route:                 route,
+			Window:                int64(t.window),
+			AvailabilityObjective: r.objective.Availability,
+			LatencyThreshold:      int64(r.objective.LatencyThreshold),
+			LatencyObjective:      r.objective.Latency,
+		}
+		for idx := range r.buckets {
+			bucket := &r.buckets[idx]
+			// ignore expired bucket
+			if slot-bucket.slot >= int64(len(r.buckets)) {
+				continue
+			}
+			status.Total += bucket.total
+			status.Failed += bucket.failed
+			status.Slow += bucket.slow
+		}
+		status.Availability, status.AvailabilityBurnRate = burnRate(status.Total, status.Failed, r.objective.Availability)
+		// budget is exhausted if burn rate > 1, remaining budget is clamped to 0
+		status.ErrorBudgetRemaining = max(1-status.AvailabilityBurnRate, 0)
+		status.LatencyRatio, status.LatencyBurnRate = burnRate(status.Total, status.Slow, r.objective.Latency)
+		rs = append(rs, status)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Route < rs[j].Route
+	})
+	return rs
+}
+
+// Walk invokes hook with each metric of slo status, the metrics are tagged by route.
+func (t *SLOTracker) Walk(hook SLOStatsHook) {
+	for _, status := range t.Stats() {
+		tags := map[string]string{"route": status.Route}
+		hook(SLORequests, tags, float64(status.Total))
+		hook(SLOFailed, tags, float64(status.Failed))
+		hook(SLOSlow, tags, float64(status.Slow))
+		hook(SLOAvailability, tags, status.Availability)
+		hook(SLOAvailabilityBurnRate, tags, status.AvailabilityBurnRate)
+		hook(SLOErrorBudgetRemaining, tags, status.ErrorBudgetRemaining)
+		hook(SLOLatencyRatio, tags, status.LatencyRatio)
+		hook(SLOLatencyBurnRate, tags, status.LatencyBurnRate)
+	}
+}
+
+// burnRate returns the good ratio and the burn rate(bad ratio / allowed bad ratio).
+func burnRate(total, bad uint64, objective float64) (ratio, rate float64) {
+	if total == 0 {
+		return 1, 0
+	}
+	badRatio := float64(bad) / float64(total)
+	budget := 1 - objective
+	if budget <= 0 {
+		// no error budget, any bad request burns out the budget
+		if bad > 0 {
+			return 1 - badRatio, 1
+		}
+		return 1, 0
+	}
+	return 1 - badRatio, badRatio / budget
+}
+
+// SLO returns a middleware which records each request into the slo tracker.
+func SLO(tracker *SLOTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := nowFunc()
+		c.Next()
+		path := c.FullPath()
+		if path == "" {
+			// route not found
+			return
+		}
+		tracker.Record(c.Request.Method+" "+path, c.Writer.Status(), nowFunc().Sub(start))
+	}
+}
@@ -0,0 +1,111 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLO(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+
+	tracker := NewSLOTracker(time.Minute, 6, SLOObjective{Availability: 0.9, LatencyThreshold: time.Second, Latency: 0.9})
+	tracker.SetObjective("GET /fail", SLOObjective{Availability: 0.5})
+	r := gin.New()
+	r.Use(SLO(tracker))
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, "fail")
+	})
+	for i := 0; i < 10; i++ {
+		_ = DoRequest(t, r, http.MethodGet, "/ok", "")
+	}
+	_ = DoRequest(t, r, http.MethodGet, "/fail", "")
+	_ = DoRequest(t, r, http.MethodGet, "/fail", "")
+	// route not found
+	_ = DoRequest(t, r, http.MethodGet, "/not-found", "")
+
+	stats := tracker.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "GET /fail", stats[0].Route)
+	assert.Equal(t, uint64(2), stats[0].Failed)
+	assert.Equal(t, float64(0), stats[0].Availability)
+	assert.Equal(t, float64(2), stats[0].AvailabilityBurnRate)
+	// budget exhausted
+	assert.Equal(t, float64(0), stats[0].ErrorBudgetRemaining)
+	assert.Equal(t, "GET /ok", stats[1].Route)
+	assert.Equal(t, uint64(10), stats[1].Total)
+	assert.Equal(t, float64(1), stats[1].Availability)
+	assert.Equal(t, float64(1), stats[1].ErrorBudgetRemaining)
+
+	metrics := make(map[string]float64)
+	tracker.Walk(func(name string, tags map[string]string, value float64) {
+		metrics[name+"|"+tags["route"]] = value
+	})
+	assert.Len(t, metrics, 16)
+	assert.Equal(t, float64(2), metrics[SLOFailed+"|GET /fail"])
+	assert.Equal(t, float64(0), metrics[SLOErrorBudgetRemaining+"|GET /fail"])
+	assert.Equal(t, float64(10), metrics[SLORequests+"|GET /ok"])
+	assert.Equal(t, float64(1), metrics[SLOAvailability+"|GET /ok"])
+
+	// slow request
+	tracker.Record("GET /ok", http.StatusOK, 2*time.Second)
+	stats = tracker.Stats()
+	assert.Equal(t, uint64(1), stats[1].Slow)
+	assert.InDelta(t, 10.0/11, stats[1].LatencyRatio, 0.0001)
+	// update objective for tracked route
+	tracker.SetObjective("GET /ok", SLOObjective{Availability: 1})
+	assert.Equal(t, float64(1), tracker.Stats()[1].AvailabilityObjective)
+
+	// rolling window expired
+	now = now.Add(2 * time.Minute)
+	stats = tracker.Stats()
+	assert.Zero(t, stats[0].Total)
+	assert.Zero(t, stats[1].Total)
+}
+
+func TestSLOTracker_Buckets(t *testing.T) {
+	tracker := NewSLOTracker(0, 0, SLOObjective{})
+	tracker.Record("GET /", http.StatusOK, time.Second)
+	assert.Len(t, tracker.Stats(), 1)
+}
+
+func Test_burnRate(t *testing.T) {
+	ratio, rate := burnRate(0, 0, 0.99)
+	assert.Equal(t, float64(1), ratio)
+	assert.Zero(t, rate)
+	ratio, rate = burnRate(10, 1, 1)
+	assert.Equal(t, 0.9, ratio)
+	assert.Equal(t, float64(1), rate)
+	ratio, rate = burnRate(10, 0, 1)
+	assert.Equal(t, float64(1), ratio)
+	assert.Zero(t, rate)
+	_, rate = burnRate(100, 1, 0.99)
+	assert.InDelta(t, 1, rate, 0.0001)
+}
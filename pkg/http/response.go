@@ -46,6 +46,12 @@ func Error(c *gin.Context, err error) {
 	response(c, http.StatusInternalServerError, err.Error())
 }
 
+// BadRequest responses error message and set the http status code 400.
+func BadRequest(c *gin.Context, err error) {
+	_ = c.Error(err)
+	response(c, http.StatusBadRequest, err.Error())
+}
+
 // Forbidden() returns permission denied response.
 func Forbidden(c *gin.Context) {
 	c.String(http.StatusForbidden, "Permission denied")
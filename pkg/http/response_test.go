@@ -60,6 +60,14 @@ func TestError(t *testing.T) {
 	assert.Equal(t, `"err"`, resp.Body.String())
 }
 
+func TestBadRequest(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	BadRequest(c, fmt.Errorf("err"))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, `"err"`, resp.Body.String())
+}
+
 func TestForbidden(t *testing.T) {
 	resp := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(resp)
@@ -0,0 +1,202 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultDrainGracePeriod is the default grace period waiting for streaming connections to finish when draining.
+const DefaultDrainGracePeriod = 10 * time.Second
+
+// WebSocketCloseGoingAway is the close code of websocket when server is going down(RFC 6455 7.4.1).
+const WebSocketCloseGoingAway = 1001
+
+// ServerOptions represents the options of http server.
+type ServerOptions struct {
+	Addr    string
+	Handler http.Handler
+	// DrainGracePeriod is the max duration waiting for streaming connections to finish after notifying them,
+	// DefaultDrainGracePeriod if not set.
+	DrainGracePeriod time.Duration
+}
+
+// Server wraps http.Server with graceful draining of long-lived streaming connections(WebSocket/SSE),
+// which are notified(close frame/final event) before shutting down, avoiding abrupt console disconnects on deploys.
+type Server struct {
+	server      *http.Server
+	gracePeriod time.Duration
+
+	draining chan struct{} // closed when draining
+	idle     chan struct{} // closed when no stream after draining
+	streams  map[uint64]*Stream
+	seq      uint64
+	closed   bool
+	isIdle   bool
+
+	mutex sync.Mutex
+}
+
+// NewServer creates a http server with options.
+func NewServer(options ServerOptions) *Server {
+	gracePeriod := options.DrainGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultDrainGracePeriod
+	}
+	return &Server{
+		server: &http.Server{
+			Addr:              options.Addr,
+			Handler:           options.Handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		gracePeriod: gracePeriod,
+		draining:    make(chan struct{}),
+		idle:        make(chan struct{}),
+		streams:     make(map[uint64]*Stream),
+	}
+}
+
+// ListenAndServe listens on the address then serves, returns nil after shutdown.
+func (s *Server) ListenAndServe() error {
+	return ignoreServerClosed(s.server.ListenAndServe())
+}
+
+// Serve serves on the listener, returns nil after shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	return ignoreServerClosed(s.server.Serve(l))
+}
+
+// Draining returns the channel which is closed when the server starts draining.
+func (s *Server) Draining() <-chan struct{} {
+	return s.draining
+}
+
+// Shutdown drains the streaming connections: notifies them, waits for them to finish
+// at most the grace period, then shuts down the server gracefully.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var hooks []func()
+	s.mutex.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.draining)
+		for _, stream := range s.streams {
+			if stream.onDrain != nil {
+				hooks = append(hooks, stream.onDrain)
+			}
+		}
+		s.checkIdle()
+	}
+	s.mutex.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	timer := time.NewTimer(s.gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-s.idle:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return s.server.Shutdown(ctx)
+}
+
+// TrackStream tracks a long-lived streaming connection, onDrain(optional) is invoked once when draining
+// (immediately if the server is draining already), e.g. writing websocket close frame.
+// Stream.Done must be invoked after the connection is finished.
+func (s *Server) TrackStream(onDrain func()) *Stream {
+	s.mutex.Lock()
+	s.seq++
+	stream := &Stream{id: s.seq, server: s, onDrain: onDrain}
+	s.streams[stream.id] = stream
+	closed := s.closed
+	s.mutex.Unlock()
+
+	if closed && onDrain != nil {
+		onDrain()
+	}
+	return stream
+}
+
+// ActiveStreams returns the number of tracked streaming connections.
+func (s *Server) ActiveStreams() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.streams)
+}
+
+// done untracks the stream.
+func (s *Server) done(stream *Stream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.streams[stream.id]; !ok {
+		return
+	}
+	delete(s.streams, stream.id)
+	if s.closed {
+		s.checkIdle()
+	}
+}
+
+// checkIdle closes the idle channel(only once) if no stream, must be invoked with lock held.
+func (s *Server) checkIdle() {
+	if !s.isIdle && len(s.streams) == 0 {
+		s.isIdle = true
+		close(s.idle)
+	}
+}
+
+// Stream represents a tracked long-lived streaming connection.
+type Stream struct {
+	id      uint64
+	server  *Server
+	onDrain func()
+}
+
+// Draining returns the channel which is closed when the server starts draining.
+func (st *Stream) Draining() <-chan struct{} {
+	return st.server.draining
+}
+
+// Done untracks the stream, it's idempotent.
+func (st *Stream) Done() {
+	st.server.done(st)
+}
+
+// FormatWebSocketClose returns the payload of websocket close frame with code and reason,
+// which can be written by websocket library(e.g. as close control message) in drain hook.
+func FormatWebSocketClose(code int, reason string) []byte {
+	payload := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(reason)), uint16(code))
+	return append(payload, reason...)
+}
+
+// ignoreServerClosed ignores the error of closed server.
+func ignoreServerClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, s *Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		assert.NoError(t, s.Serve(l))
+	}()
+	return "http://" + l.Addr().String()
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	s := NewServer(ServerOptions{Handler: http.NewServeMux()})
+	assert.Equal(t, DefaultDrainGracePeriod, s.gracePeriod)
+	startServer(t, s)
+
+	var drained atomic.Int32
+	stream1 := s.TrackStream(func() { drained.Add(1) })
+	stream2 := s.TrackStream(nil)
+	assert.Equal(t, 2, s.ActiveStreams())
+	go func() {
+		<-stream2.Draining()
+		stream2.Done()
+		stream2.Done()
+	}()
+	go func() {
+		<-stream1.Draining()
+		stream1.Done()
+	}()
+
+	start := time.Now()
+	assert.NoError(t, s.Shutdown(context.TODO()))
+	assert.Less(t, time.Since(start), DefaultDrainGracePeriod)
+	assert.Equal(t, int32(1), drained.Load())
+	assert.Equal(t, 0, s.ActiveStreams())
+	select {
+	case <-s.Draining():
+	default:
+		assert.Fail(t, "server should be draining")
+	}
+
+	// track after draining
+	s.TrackStream(func() { drained.Add(1) }).Done()
+	assert.Equal(t, int32(2), drained.Load())
+	// shutdown again
+	assert.NoError(t, s.Shutdown(context.TODO()))
+}
+
+func TestServer_Shutdown_GracePeriod(t *testing.T) {
+	s := NewServer(ServerOptions{Handler: http.NewServeMux(), DrainGracePeriod: 50 * time.Millisecond})
+	startServer(t, s)
+	// stream never finishes(e.g. hijacked websocket connection)
+	s.TrackStream(nil)
+	start := time.Now()
+	assert.NoError(t, s.Shutdown(context.TODO()))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, 1, s.ActiveStreams())
+
+	// ctx done before grace period
+	s = NewServer(ServerOptions{Handler: http.NewServeMux()})
+	s.TrackStream(nil)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+}
+
+func TestServer_ListenAndServe(t *testing.T) {
+	s := NewServer(ServerOptions{Addr: "127.0.0.1:0", Handler: http.NewServeMux()})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.ListenAndServe()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, s.Shutdown(context.TODO()))
+	assert.NoError(t, <-errCh)
+
+	s = NewServer(ServerOptions{Addr: "invalid-addr", Handler: http.NewServeMux()})
+	assert.Error(t, s.ListenAndServe())
+}
+
+func TestFormatWebSocketClose(t *testing.T) {
+	assert.Equal(t, []byte{0x03, 0xe9, 'b', 'y', 'e'}, FormatWebSocketClose(WebSocketCloseGoingAway, "bye"))
+	assert.Equal(t, []byte{0x03, 0xe8}, FormatWebSocketClose(1000, ""))
+}
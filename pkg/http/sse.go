@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SSEEvent represents a server-sent event.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry int // reconnection time in milliseconds, ignored if <= 0
+}
+
+// SSEShutdownEvent is the default final event sent to client when server is draining,
+// the client should reconnect after retry time.
+var SSEShutdownEvent = SSEEvent{Event: "shutdown", Data: "server is shutting down", Retry: 3000}
+
+// SSEWriter writes server-sent events into response.
+type SSEWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewSSEWriter creates a server-sent events writer, the response headers are written.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported by response writer")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &SSEWriter{w: w, flusher: flusher}, nil
+}
+
+// WriteEvent writes the event then flushes it, multi-line data is split into data fields.
+func (sw *SSEWriter) WriteEvent(event SSEEvent) error {
+	var sb strings.Builder
+	if event.ID != "" {
+		sb.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		sb.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		sb.WriteString(fmt.Sprintf("retry: %d\n", event.Retry))
+	}
+	for _, line := range strings.Split(event.Data, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+	if _, err := sw.w.Write([]byte(sb.String())); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+// StreamSSE streams the events to client until the events channel is closed, the client disconnects or
+// the server starts draining, the final event(e.g. SSEShutdownEvent) is sent when draining.
+func (s *Server) StreamSSE(w http.ResponseWriter, r *http.Request, events <-chan SSEEvent, final SSEEvent) error {
+	stream := s.TrackStream(nil)
+	defer stream.Done()
+
+	sw, err := NewSSEWriter(w)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-stream.Draining():
+			return sw.WriteEvent(final)
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err = sw.WriteEvent(event); err != nil {
+				return err
+			}
+		}
+	}
+}
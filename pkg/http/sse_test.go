@@ -0,0 +1,137 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readSSEEvent reads an event(until empty line) from stream.
+func readSSEEvent(reader *bufio.Reader) (string, error) {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return strings.Join(lines, "\n"), err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestSSEWriter(t *testing.T) {
+	resp := httptest.NewRecorder()
+	sw, err := NewSSEWriter(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+	assert.NoError(t, sw.WriteEvent(SSEEvent{ID: "1", Event: "log", Data: "line1\nline2", Retry: 100}))
+	assert.NoError(t, sw.WriteEvent(SSEEvent{Data: "msg"}))
+	assert.Equal(t, "id: 1\nevent: log\nretry: 100\ndata: line1\ndata: line2\n\ndata: msg\n\n", resp.Body.String())
+}
+
+type noFlushWriter struct {
+	http.ResponseWriter
+}
+
+type failureWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *failureWriter) Write(_ []byte) (int, error) {
+	return 0, fmt.Errorf("err")
+}
+
+func TestSSEWriter_Failure(t *testing.T) {
+	_, err := NewSSEWriter(&noFlushWriter{ResponseWriter: httptest.NewRecorder()})
+	assert.Error(t, err)
+
+	s := NewServer(ServerOptions{})
+	req := httptest.NewRequest(http.MethodGet, "/events", http.NoBody)
+	err = s.StreamSSE(&noFlushWriter{ResponseWriter: httptest.NewRecorder()}, req, nil, SSEShutdownEvent)
+	assert.Error(t, err)
+
+	events := make(chan SSEEvent, 1)
+	events <- SSEEvent{Data: "msg"}
+	err = s.StreamSSE(&failureWriter{ResponseRecorder: httptest.NewRecorder()}, req, events, SSEShutdownEvent)
+	assert.Error(t, err)
+	assert.Equal(t, 0, s.ActiveStreams())
+}
+
+func TestServer_StreamSSE(t *testing.T) {
+	mux := http.NewServeMux()
+	s := NewServer(ServerOptions{Handler: mux, DrainGracePeriod: time.Second})
+	events := make(chan SSEEvent)
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, s.StreamSSE(w, r, events, SSEShutdownEvent))
+	})
+	addr := startServer(t, s)
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, addr+"/events", http.NoBody)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	events <- SSEEvent{Event: "log", Data: "hello"}
+	event, err := readSSEEvent(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "event: log\ndata: hello", event)
+	assert.Equal(t, 1, s.ActiveStreams())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Shutdown(context.TODO())
+	}()
+	event, err = readSSEEvent(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "event: shutdown\nretry: 3000\ndata: server is shutting down", event)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, s.ActiveStreams())
+
+	// stream after draining gets final event immediately
+	resp2 := httptest.NewRecorder()
+	assert.NoError(t, s.StreamSSE(resp2, httptest.NewRequest(http.MethodGet, "/events", http.NoBody), events, SSEShutdownEvent))
+	assert.Contains(t, resp2.Body.String(), "event: shutdown")
+}
+
+func TestServer_StreamSSE_Finished(t *testing.T) {
+	s := NewServer(ServerOptions{})
+	// events closed
+	events := make(chan SSEEvent)
+	close(events)
+	req := httptest.NewRequest(http.MethodGet, "/events", http.NoBody)
+	assert.NoError(t, s.StreamSSE(httptest.NewRecorder(), req, events, SSEShutdownEvent))
+	// client disconnected
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.NoError(t, s.StreamSSE(httptest.NewRecorder(), req.WithContext(ctx), make(chan SSEEvent), SSEShutdownEvent))
+	assert.Equal(t, 0, s.ActiveStreams())
+}
@@ -0,0 +1,215 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// AsyncPolicyBlock blocks the log site if the async buffer is full.
+	AsyncPolicyBlock = "block"
+	// AsyncPolicyDrop drops the log entry if the async buffer is full, the dropped entries are counted.
+	AsyncPolicyDrop = "drop"
+	// DefaultAsyncBufferSize is the default number of entries buffered by async writer.
+	DefaultAsyncBufferSize = 8192
+)
+
+var (
+	// asyncWriters are the async writers created by InitLogger, for statistics and flushing.
+	asyncWriters     []*AsyncWriter
+	asyncWritersLock sync.Mutex
+)
+
+// AsyncWriter is a zapcore.WriteSyncer which buffers the encoded log entries in a ring buffer,
+// a background flusher writes them into the underlying writer, so that the log site is not blocked by slow disk.
+// If the buffer is full, the log site is blocked or the entry is dropped according to the policy.
+type AsyncWriter struct {
+	w      zapcore.WriteSyncer
+	policy string
+
+	entries  [][]byte // ring buffer
+	head     int
+	size     int
+	flushing bool // if flusher is writing a batch taken from buffer
+	closed   bool
+
+	lock     sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	drained  *sync.Cond
+	done     chan struct{}
+
+	dropped  atomic.Int64
+	written  atomic.Int64
+	failures atomic.Int64
+}
+
+// NewAsyncWriter creates an async writer with buffer size(number of entries) and backpressure policy(block/drop),
+// the background flusher is started.
+func NewAsyncWriter(w zapcore.WriteSyncer, bufferSize int, policy string) (*AsyncWriter, error) {
+	switch policy {
+	case "":
+		policy = AsyncPolicyBlock
+	case AsyncPolicyBlock, AsyncPolicyDrop:
+	default:
+		return nil, fmt.Errorf("unknown async policy: %q, block or drop is available", policy)
+	}
+	if bufferSize <= 0 {
+		bufferSize = DefaultAsyncBufferSize
+	}
+	aw := &AsyncWriter{
+		w:       w,
+		policy:  policy,
+		entries: make([][]byte, bufferSize),
+		done:    make(chan struct{}),
+	}
+	aw.notEmpty = sync.NewCond(&aw.lock)
+	aw.notFull = sync.NewCond(&aw.lock)
+	aw.drained = sync.NewCond(&aw.lock)
+	go aw.run()
+	return aw, nil
+}
+
+// Write copies the encoded entry into buffer, the entry is written into underlying writer directly if closed.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.lock.Lock()
+	for !aw.closed && aw.size == len(aw.entries) && aw.policy == AsyncPolicyBlock {
+		aw.notFull.Wait()
+	}
+	if aw.closed {
+		aw.lock.Unlock()
+		return aw.w.Write(p)
+	}
+	if aw.size == len(aw.entries) {
+		aw.lock.Unlock()
+		aw.dropped.Add(1)
+		loggingStats.dropped.Add(1)
+		return len(p), nil
+	}
+	// zap reuses the buffer after write, copy it
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	aw.entries[(aw.head+aw.size)%len(aw.entries)] = entry
+	aw.size++
+	aw.notEmpty.Signal()
+	aw.lock.Unlock()
+	return len(p), nil
+}
+
+// Sync waits until the buffered entries are written, then syncs the underlying writer.
+func (aw *AsyncWriter) Sync() error {
+	aw.lock.Lock()
+	for aw.size > 0 || aw.flushing {
+		aw.drained.Wait()
+	}
+	aw.lock.Unlock()
+	return aw.w.Sync()
+}
+
+// Close stops the background flusher after the buffered entries are written, then syncs the underlying writer.
+func (aw *AsyncWriter) Close() error {
+	aw.lock.Lock()
+	aw.closed = true
+	aw.notEmpty.Broadcast()
+	aw.notFull.Broadcast()
+	aw.lock.Unlock()
+	<-aw.done
+	return aw.w.Sync()
+}
+
+// Dropped returns the number of entries dropped because the buffer is full.
+func (aw *AsyncWriter) Dropped() int64 {
+	return aw.dropped.Load()
+}
+
+// Written returns the number of entries written by background flusher.
+func (aw *AsyncWriter) Written() int64 {
+	return aw.written.Load()
+}
+
+// Failures returns the number of entries failed to write by background flusher.
+func (aw *AsyncWriter) Failures() int64 {
+	return aw.failures.Load()
+}
+
+// run takes the buffered entries in batch then writes them into underlying writer, until closed and drained.
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+
+	batch := make([][]byte, 0, len(aw.entries))
+	for {
+		aw.lock.Lock()
+		for aw.size == 0 && !aw.closed {
+			aw.notEmpty.Wait()
+		}
+		if aw.size == 0 {
+			aw.drained.Broadcast()
+			aw.lock.Unlock()
+			return
+		}
+		for i := 0; i < aw.size; i++ {
+			idx := (aw.head + i) % len(aw.entries)
+			batch = append(batch, aw.entries[idx])
+			aw.entries[idx] = nil
+		}
+		aw.head, aw.size = 0, 0
+		aw.flushing = true
+		aw.notFull.Broadcast()
+		aw.lock.Unlock()
+
+		for _, entry := range batch {
+			if _, err := aw.w.Write(entry); err != nil {
+				aw.failures.Add(1)
+				loggingStats.writeErrors.Add(1)
+			} else {
+				aw.written.Add(1)
+			}
+		}
+		batch = batch[:0]
+
+		aw.lock.Lock()
+		aw.flushing = false
+		aw.drained.Broadcast()
+		aw.lock.Unlock()
+	}
+}
+
+// AsyncDropped returns the number of entries dropped by all async writers created by InitLogger.
+func AsyncDropped() int64 {
+	asyncWritersLock.Lock()
+	defer asyncWritersLock.Unlock()
+
+	var dropped int64
+	for _, aw := range asyncWriters {
+		dropped += aw.Dropped()
+	}
+	return dropped
+}
+
+// registerAsyncWriter registers the async writer for statistics and flushing.
+func registerAsyncWriter(aw *AsyncWriter) {
+	asyncWritersLock.Lock()
+	defer asyncWritersLock.Unlock()
+
+	asyncWriters = append(asyncWriters, aw)
+}
@@ -0,0 +1,165 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// slowWriter blocks writing until released.
+type slowWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+	err     error
+	syncs   int
+	lock    sync.Mutex
+}
+
+func newSlowWriter() *slowWriter {
+	return &slowWriter{release: make(chan struct{})}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.syncs++
+	return nil
+}
+
+func (w *slowWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.String()
+}
+
+func TestNewAsyncWriter(t *testing.T) {
+	aw, err := NewAsyncWriter(newSlowWriter(), 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, AsyncPolicyBlock, aw.policy)
+	assert.Len(t, aw.entries, DefaultAsyncBufferSize)
+	assert.NoError(t, aw.Close())
+
+	aw, err = NewAsyncWriter(newSlowWriter(), 10, "wait")
+	assert.Error(t, err)
+	assert.Nil(t, aw)
+}
+
+func TestAsyncWriter_Drop(t *testing.T) {
+	w := newSlowWriter()
+	aw, err := NewAsyncWriter(w, 2, AsyncPolicyDrop)
+	assert.NoError(t, err)
+	// first entry is taken by flusher, which is blocked by writer
+	_, _ = aw.Write([]byte("1\n"))
+	assert.Eventually(t, func() bool {
+		aw.lock.Lock()
+		defer aw.lock.Unlock()
+		return aw.flushing
+	}, time.Second, time.Millisecond)
+	for i := 2; i <= 5; i++ {
+		n, err0 := aw.Write([]byte(fmt.Sprintf("%d\n", i)))
+		assert.NoError(t, err0)
+		assert.Equal(t, 2, n)
+	}
+	assert.Equal(t, int64(2), aw.Dropped())
+	close(w.release)
+	assert.NoError(t, aw.Sync())
+	assert.Equal(t, "1\n2\n3\n", w.String())
+	assert.Equal(t, int64(3), aw.Written())
+	assert.Equal(t, 1, w.syncs)
+
+	assert.NoError(t, aw.Close())
+	// write directly after closed
+	_, err = aw.Write([]byte("6\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n6\n", w.String())
+	assert.NoError(t, aw.Close())
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	w := newSlowWriter()
+	aw, err := NewAsyncWriter(w, 1, AsyncPolicyBlock)
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			_, _ = aw.Write([]byte(fmt.Sprintf("%d\n", i)))
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(w.release)
+	wg.Wait()
+	assert.NoError(t, aw.Close())
+	assert.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n", w.String())
+	assert.Zero(t, aw.Dropped())
+	assert.Equal(t, int64(10), aw.Written())
+}
+
+func TestAsyncWriter_Failure(t *testing.T) {
+	w := newSlowWriter()
+	w.err = fmt.Errorf("err")
+	close(w.release)
+	aw, err := NewAsyncWriter(w, 10, AsyncPolicyBlock)
+	assert.NoError(t, err)
+	_, err = aw.Write([]byte("1\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, aw.Sync())
+	assert.Equal(t, int64(1), aw.Failures())
+	assert.Zero(t, aw.Written())
+	assert.NoError(t, aw.Close())
+}
+
+func Test_InitLogger_Async(t *testing.T) {
+	dir := t.TempDir()
+	encoderConfig := zap.NewProductionEncoderConfig()
+	setting := Setting{Dir: dir, Level: "info", Async: true, AsyncPolicy: "wait"}
+	log, err := InitLogger("async.log", setting, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	setting.AsyncPolicy = AsyncPolicyDrop
+	log, err = InitLogger("async.log", setting, &encoderConfig)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		log.Info("async message")
+	}
+	assert.NoError(t, log.Sync())
+	data, err := os.ReadFile(filepath.Join(dir, "async.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10-int(AsyncDropped()), bytes.Count(data, []byte("async message")))
+}
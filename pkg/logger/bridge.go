@@ -0,0 +1,184 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	stdlog "log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+const (
+	// stdLogCallerSkip skips the frames of log.Printf, log.(*Logger).output and stdLogWriter.Write.
+	stdLogCallerSkip = 3
+	// grpcLogCallerSkip skips the frames of grpclog.Info etc. and the method of GRPCLogger.
+	grpcLogCallerSkip = 2
+)
+
+// withCallerSkip returns a logger which skips more frames when adding caller, for the adapters of other logging APIs.
+func withCallerSkip(log Logger, skip int) Logger {
+	if l, ok := log.(*logger); ok {
+		child := *l
+		child.log = l.log.WithOptions(zap.AddCallerSkip(skip))
+		return &child
+	}
+	return log
+}
+
+// stdLogWriter writes the output of standard library log into logger.
+type stdLogWriter struct {
+	log   Logger
+	level zapcore.Level
+}
+
+// Write logs the line written by standard library log.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	switch w.level {
+	case DebugLevel:
+		w.log.Debug(msg)
+	case WarnLevel:
+		w.log.Warn(msg)
+	case ErrorLevel:
+		w.log.Error(msg)
+	default:
+		w.log.Info(msg)
+	}
+	return len(p), nil
+}
+
+// RedirectStdLog redirects the output of standard library log(used by third-party libraries) into log at level
+// (debug/info/warn/error, info for others), so it's captured with the same format, rotation and level control.
+// Returns the function which restores the previous output, flags and prefix of standard library log.
+func RedirectStdLog(log Logger, level zapcore.Level) (restore func()) {
+	flags, prefix, output := stdlog.Flags(), stdlog.Prefix(), stdlog.Writer()
+	// time and caller are added by logger
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(&stdLogWriter{log: withCallerSkip(log, stdLogCallerSkip), level: level})
+	return func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetOutput(output)
+	}
+}
+
+// GRPCLogger implements grpclog.LoggerV2 on top of Logger, grpc info/warning/error logs are logged at
+// info/warn/error level, fatal logs are logged at fatal level then os.Exit(1) is called.
+type GRPCLogger struct {
+	log       Logger
+	verbosity int
+}
+
+// NewGRPCLogger creates a grpc logger, the verbose logs of grpc which level <= verbosity are enabled.
+func NewGRPCLogger(log Logger, verbosity int) *GRPCLogger {
+	return &GRPCLogger{log: withCallerSkip(log, grpcLogCallerSkip), verbosity: verbosity}
+}
+
+// RedirectGRPCLog sets the grpc logger(grpclog.SetLoggerV2) using log,
+// it must be called before any grpc functions(not mutex protected by grpc).
+func RedirectGRPCLog(log Logger, verbosity int) {
+	grpclog.SetLoggerV2(NewGRPCLogger(log, verbosity))
+}
+
+// Info logs to INFO log. Arguments are handled in the manner of fmt.Print.
+func (l *GRPCLogger) Info(args ...any) {
+	if l.log.Enabled(InfoLevel) {
+		l.log.Info(fmt.Sprint(args...))
+	}
+}
+
+// Infoln logs to INFO log. Arguments are handled in the manner of fmt.Println.
+func (l *GRPCLogger) Infoln(args ...any) {
+	if l.log.Enabled(InfoLevel) {
+		l.log.Info(sprintln(args...))
+	}
+}
+
+// Infof logs to INFO log. Arguments are handled in the manner of fmt.Printf.
+func (l *GRPCLogger) Infof(format string, args ...any) {
+	l.log.Infof(format, args...)
+}
+
+// Warning logs to WARNING log. Arguments are handled in the manner of fmt.Print.
+func (l *GRPCLogger) Warning(args ...any) {
+	if l.log.Enabled(WarnLevel) {
+		l.log.Warn(fmt.Sprint(args...))
+	}
+}
+
+// Warningln logs to WARNING log. Arguments are handled in the manner of fmt.Println.
+func (l *GRPCLogger) Warningln(args ...any) {
+	if l.log.Enabled(WarnLevel) {
+		l.log.Warn(sprintln(args...))
+	}
+}
+
+// Warningf logs to WARNING log. Arguments are handled in the manner of fmt.Printf.
+func (l *GRPCLogger) Warningf(format string, args ...any) {
+	l.log.Warnf(format, args...)
+}
+
+// Error logs to ERROR log. Arguments are handled in the manner of fmt.Print.
+func (l *GRPCLogger) Error(args ...any) {
+	if l.log.Enabled(ErrorLevel) {
+		l.log.Error(fmt.Sprint(args...))
+	}
+}
+
+// Errorln logs to ERROR log. Arguments are handled in the manner of fmt.Println.
+func (l *GRPCLogger) Errorln(args ...any) {
+	if l.log.Enabled(ErrorLevel) {
+		l.log.Error(sprintln(args...))
+	}
+}
+
+// Errorf logs to ERROR log. Arguments are handled in the manner of fmt.Printf.
+func (l *GRPCLogger) Errorf(format string, args ...any) {
+	l.log.Errorf(format, args...)
+}
+
+// Fatal logs to FATAL log, then calls os.Exit(1). Arguments are handled in the manner of fmt.Print.
+func (l *GRPCLogger) Fatal(args ...any) {
+	l.log.Fatal(fmt.Sprint(args...))
+}
+
+// Fatalln logs to FATAL log, then calls os.Exit(1). Arguments are handled in the manner of fmt.Println.
+func (l *GRPCLogger) Fatalln(args ...any) {
+	l.log.Fatal(sprintln(args...))
+}
+
+// Fatalf logs to FATAL log, then calls os.Exit(1). Arguments are handled in the manner of fmt.Printf.
+func (l *GRPCLogger) Fatalf(format string, args ...any) {
+	l.log.Fatalf(format, args...)
+}
+
+// V reports whether verbosity level l is at least the requested verbose level.
+func (l *GRPCLogger) V(level int) bool {
+	return level <= l.verbosity
+}
+
+// sprintln formats the arguments in the manner of fmt.Println without trailing newline.
+func sprintln(args ...any) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
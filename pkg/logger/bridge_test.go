@@ -0,0 +1,143 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/grpclog"
+)
+
+// newBridgeLogger returns a logger recording logs with caller, fatal logs panic instead of exiting.
+func newBridgeLogger(module string, level zapcore.Level) (Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	RegisterLogger(module, zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.OnFatal(zapcore.WriteThenPanic)), true)
+	return GetLogger(module, ""), logs
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	defer cleanModules("StdLog")
+	log, logs := newBridgeLogger("StdLog", DebugLevel)
+
+	var buf bytes.Buffer
+	stdlog.SetOutput(&buf)
+	stdlog.SetPrefix("prefix ")
+	restore := RedirectStdLog(log, WarnLevel)
+	stdlog.Printf("third-party %s", "message")
+	stdlog.Println("println message")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "third-party message", entries[0].Message)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "bridge_test.go", filepath.Base(entries[0].Caller.File))
+	assert.Equal(t, "println message", entries[1].Message)
+	assert.Zero(t, buf.Len())
+
+	restore()
+	stdlog.Print("restored")
+	assert.Equal(t, "prefix ", stdlog.Prefix())
+	assert.Contains(t, buf.String(), "prefix")
+	assert.Contains(t, buf.String(), "restored")
+	assert.Equal(t, 2, logs.Len())
+	stdlog.SetOutput(os.Stderr)
+	stdlog.SetPrefix("")
+
+	for _, level := range []zapcore.Level{DebugLevel, InfoLevel, ErrorLevel, PanicLevel} {
+		w := &stdLogWriter{log: log, level: level}
+		n, err := w.Write([]byte("level\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 6, n)
+	}
+	var levels []zapcore.Level
+	for _, entry := range logs.AllUntimed()[2:] {
+		levels = append(levels, entry.Level)
+	}
+	assert.Equal(t, []zapcore.Level{DebugLevel, InfoLevel, ErrorLevel, InfoLevel}, levels)
+}
+
+func TestGRPCLogger(t *testing.T) {
+	defer cleanModules("GRPC")
+	log, logs := newBridgeLogger("GRPC", DebugLevel)
+	l := NewGRPCLogger(log, 2)
+	assert.True(t, l.V(2))
+	assert.False(t, l.V(3))
+
+	l.Info("info", 1)
+	l.Infoln("info", 2)
+	l.Infof("info %d", 3)
+	l.Warning("warn", 1)
+	l.Warningln("warn", 2)
+	l.Warningf("warn %d", 3)
+	l.Error("error", 1)
+	l.Errorln("error", 2)
+	l.Errorf("error %d", 3)
+	assert.Panics(t, func() { l.Fatal("fatal", 1) })
+	assert.Panics(t, func() { l.Fatalln("fatal", 2) })
+	assert.Panics(t, func() { l.Fatalf("fatal %d", 3) })
+
+	var messages []string
+	for _, entry := range logs.AllUntimed() {
+		messages = append(messages, entry.Level.String()+" "+entry.Message)
+	}
+	assert.Equal(t, []string{
+		"info info1", "info info 2", "info info 3",
+		"warn warn1", "warn warn 2", "warn warn 3",
+		"error error1", "error error 2", "error error 3",
+		"fatal fatal1", "fatal fatal 2", "fatal fatal 3",
+	}, messages)
+
+	// disabled levels
+	log, logs = newBridgeLogger("GRPC", FatalLevel)
+	l = NewGRPCLogger(log, 0)
+	l.Info("info")
+	l.Infoln("info")
+	l.Warning("warn")
+	l.Warningln("warn")
+	l.Error("error")
+	l.Errorln("error")
+	assert.Zero(t, logs.Len())
+}
+
+func TestRedirectGRPCLog(t *testing.T) {
+	defer func() {
+		cleanModules("GRPC")
+		grpclog.SetLoggerV2(grpclog.NewLoggerV2(io.Discard, io.Discard, os.Stderr))
+	}()
+	log, logs := newBridgeLogger("GRPC", InfoLevel)
+	RedirectGRPCLog(log, 0)
+	grpclog.Infof("grpc %s", "message")
+	grpclog.Warning("grpc warning")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "grpc message", entries[0].Message)
+	assert.Equal(t, "bridge_test.go", filepath.Base(entries[0].Caller.File))
+	assert.Equal(t, "bridge_test.go", filepath.Base(entries[1].Caller.File))
+	assert.True(t, grpclog.V(0))
+	assert.False(t, grpclog.V(1))
+}
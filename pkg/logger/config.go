@@ -20,6 +20,8 @@ package logger
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/lindb/common/pkg/ltoml"
@@ -30,6 +32,13 @@ var (
 	defaultParentDir = filepath.Join(".", "data")
 )
 
+const (
+	// FormatConsole is the human-readable console log format.
+	FormatConsole = "console"
+	// FormatJSON is the json log format, which can be shipped to log systems(e.g. ELK/Loki) without regex parsing.
+	FormatJSON = "json"
+)
+
 // Setting represents a logging configuration.
 type Setting struct {
 	Dir        string     `env:"DIR" toml:"dir"`
@@ -37,6 +46,27 @@ type Setting struct {
 	MaxSize    ltoml.Size `env:"MAX_SIZE" toml:"maxsize"`
 	MaxBackups uint16     `env:"MAX_BACKUPS" toml:"maxbackups"`
 	MaxAge     uint16     `env:"MAX_AGE" toml:"maxage"`
+	// RotateInterval rotates log file by time besides size, daily or hourly, empty means size based only.
+	RotateInterval string `env:"ROTATE_INTERVAL" toml:"rotateinterval"`
+	Format         string `env:"FORMAT" toml:"format"` // console(default) or json
+	// Sinks are the outputs of log(file/stdout/stderr/syslog/journald/otlp/registered sink), empty means stdout if terminal else file.
+	Sinks []string `env:"SINKS" toml:"sinks"`
+	// ModuleSinks overrides the sinks of module.
+	ModuleSinks map[string][]string `toml:"modulesinks"`
+	// Output configures the syslog/journald/otlp sinks.
+	Output Output `envPrefix:"OUTPUT_" toml:"output"`
+	// RedactKeys are the key patterns of sensitive values(e.g. password/token) which are masked before writing.
+	RedactKeys []string `env:"REDACT_KEYS" toml:"redactkeys"`
+	// DedupWindow collapses the consecutive identical entries within the window into one entry with repeat count.
+	DedupWindow ltoml.Duration `env:"DEDUP_WINDOW" toml:"dedupwindow"`
+	// StacktraceLevel is the level at and above which stacktrace is attached, empty or none means disabled.
+	StacktraceLevel string `env:"STACKTRACE_LEVEL" toml:"stacktracelevel"`
+	// DisableCallerModules are the modules which log without caller annotation(e.g. AccessLog).
+	DisableCallerModules []string `env:"DISABLE_CALLER_MODULES" toml:"disablecallermodules"`
+	// Async writes log entries by background flusher, avoid blocking write path by slow disk.
+	Async           bool   `env:"ASYNC" toml:"async"`
+	AsyncBufferSize uint32 `env:"ASYNC_BUFFER_SIZE" toml:"asyncbuffersize"`
+	AsyncPolicy     string `env:"ASYNC_POLICY" toml:"asyncpolicy"` // block(default) or drop if buffer is full
 }
 
 // TOML returns logger setting's toml config string.
@@ -68,7 +98,124 @@ maxbackups = %d
 ## The default is not to remove old log files based on age.
 ## Default: %d
 ## Env: %s_LOGGING_MAX_AGE
-maxage = %d`,
+maxage = %d
+## RotateInterval rotates the log file by time besides size, daily and hourly are available.
+## The rotated file is named with timestamp, e.g. lind-2006-01-02.log(daily), lind-2006-01-02T15.log(hourly),
+## and retained by both MaxAge and MaxBackups. Empty means size based rotation only.
+## Default: %s
+## Env: %s_LOGGING_ROTATE_INTERVAL
+rotateinterval = "%s"
+## Format is the encoding of log output, console and json are available.
+## json format is friendly for log systems(e.g. ELK/Loki).
+## Default: %s
+## Env: %s_LOGGING_FORMAT
+format = "%s"
+## Sinks are the outputs which log is written into simultaneously,
+## file, stdout, stderr, syslog, journald, otlp and the sinks registered by application are available.
+## Empty means stdout if running in terminal, else file.
+## Default: %s
+## Env: %s_LOGGING_SINKS
+sinks = %s
+## ModuleSinks overrides the sinks of module, e.g. { AccessLog = ["file"] }.
+## Default: %s
+modulesinks = %s
+## RedactKeys are the key patterns(case-insensitive) of sensitive values which are masked before writing into sinks,
+## include the fields which key contains any pattern and the key/value pairs in message, e.g. password=123.
+## Default: %s
+## Env: %s_LOGGING_REDACT_KEYS
+redactkeys = %s
+## DedupWindow collapses the consecutive identical(module, level, message) entries within the window
+## into one entry with repeat count, like syslog "last message repeated N times". 0 means disabled.
+## Default: %s
+## Env: %s_LOGGING_DEDUP_WINDOW
+dedupwindow = "%s"
+## StacktraceLevel is the level at and above which the stacktrace is attached to log entry,
+## debug, info, warn, error, dpanic, panic and fatal are available, none means disabled.
+## Default: %s
+## Env: %s_LOGGING_STACKTRACE_LEVEL
+stacktracelevel = "%s"
+## DisableCallerModules are the modules which log without caller annotation(file:line), e.g. ["AccessLog"].
+## Default: %s
+## Env: %s_LOGGING_DISABLE_CALLER_MODULES
+disablecallermodules = %s
+## Async writes log entries by a background flusher,
+## which avoids latency spikes of write path on slow disks.
+## Default: %t
+## Env: %s_LOGGING_ASYNC
+async = %t
+## AsyncBufferSize is the maximum number of log entries buffered when async is enabled.
+## Default: %d
+## Env: %s_LOGGING_ASYNC_BUFFER_SIZE
+asyncbuffersize = %d
+## AsyncPolicy is the policy if async buffer is full, block and drop are available.
+## block waits for the buffer, drop discards the log entry and counts it.
+## Default: %s
+## Env: %s_LOGGING_ASYNC_POLICY
+asyncpolicy = "%s"
+
+## Output configures the syslog/journald/otlp sinks, which are selected by sinks, e.g. sinks = ["file", "syslog"].
+[logging.output.syslog]
+## Network is the network of remote syslog server, udp and tcp are available.
+## Empty means local syslog socket(/dev/log, /var/run/syslog or /var/run/log).
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_SYSLOG_NETWORK
+network = "%s"
+## Address is the address of remote syslog server(host:port), or the path of local syslog socket.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_SYSLOG_ADDRESS
+address = "%s"
+## Facility is the syslog facility, e.g. daemon, local0-local7.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_SYSLOG_FACILITY
+facility = "%s"
+## Tag is the APP-NAME of syslog message, empty means process name.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_SYSLOG_TAG
+tag = "%s"
+
+[logging.output.journald]
+## Socket is the native protocol socket of systemd-journald.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_JOURNALD_SOCKET
+socket = "%s"
+## Identifier is the SYSLOG_IDENTIFIER of journal entry, empty means process name.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_JOURNALD_IDENTIFIER
+identifier = "%s"
+
+[logging.output.otlp]
+## Endpoint is the address(host:port) of OpenTelemetry collector which receives logs by OTLP/gRPC.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_OTLP_ENDPOINT
+endpoint = "%s"
+## Insecure disables TLS of the connection to collector.
+## Default: %t
+## Env: %s_LOGGING_OUTPUT_OTLP_INSECURE
+insecure = %t
+## ServiceName is the service.name resource attribute, empty means process name.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_OTLP_SERVICE_NAME
+servicename = "%s"
+## Role is the lindb.role resource attribute, e.g. broker/storage.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_OTLP_ROLE
+role = "%s"
+## BatchSize is the maximum number of log records exported in one request.
+## Default: %d
+## Env: %s_LOGGING_OUTPUT_OTLP_BATCH_SIZE
+batchsize = %d
+## MaxQueueSize is the maximum number of log records buffered, the later records are dropped if queue is full.
+## Default: %d
+## Env: %s_LOGGING_OUTPUT_OTLP_MAX_QUEUE_SIZE
+maxqueuesize = %d
+## FlushInterval is the interval of exporting the buffered log records.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_OTLP_FLUSH_INTERVAL
+flushinterval = "%s"
+## Timeout is the timeout of export request.
+## Default: %s
+## Env: %s_LOGGING_OUTPUT_OTLP_TIMEOUT
+timeout = "%s"`,
 		strings.ReplaceAll(l.Dir, "\\", "\\\\"),
 		prefix,
 		strings.ReplaceAll(l.Dir, "\\", "\\\\"),
@@ -84,16 +231,132 @@ maxage = %d`,
 		l.MaxAge,
 		prefix,
 		l.MaxAge,
+		l.RotateInterval,
+		prefix,
+		l.RotateInterval,
+		l.Format,
+		prefix,
+		l.Format,
+		tomlStrings(l.Sinks),
+		prefix,
+		tomlStrings(l.Sinks),
+		tomlStringsMap(l.ModuleSinks),
+		tomlStringsMap(l.ModuleSinks),
+		tomlStrings(l.RedactKeys),
+		prefix,
+		tomlStrings(l.RedactKeys),
+		l.DedupWindow.String(),
+		prefix,
+		l.DedupWindow.String(),
+		l.StacktraceLevel,
+		prefix,
+		l.StacktraceLevel,
+		tomlStrings(l.DisableCallerModules),
+		prefix,
+		tomlStrings(l.DisableCallerModules),
+		l.Async,
+		prefix,
+		l.Async,
+		l.AsyncBufferSize,
+		prefix,
+		l.AsyncBufferSize,
+		l.AsyncPolicy,
+		prefix,
+		l.AsyncPolicy,
+		l.Output.Syslog.Network,
+		prefix,
+		l.Output.Syslog.Network,
+		l.Output.Syslog.Address,
+		prefix,
+		l.Output.Syslog.Address,
+		l.Output.Syslog.Facility,
+		prefix,
+		l.Output.Syslog.Facility,
+		l.Output.Syslog.Tag,
+		prefix,
+		l.Output.Syslog.Tag,
+		l.Output.Journald.Socket,
+		prefix,
+		l.Output.Journald.Socket,
+		l.Output.Journald.Identifier,
+		prefix,
+		l.Output.Journald.Identifier,
+		l.Output.OTLP.Endpoint,
+		prefix,
+		l.Output.OTLP.Endpoint,
+		l.Output.OTLP.Insecure,
+		prefix,
+		l.Output.OTLP.Insecure,
+		l.Output.OTLP.ServiceName,
+		prefix,
+		l.Output.OTLP.ServiceName,
+		l.Output.OTLP.Role,
+		prefix,
+		l.Output.OTLP.Role,
+		l.Output.OTLP.BatchSize,
+		prefix,
+		l.Output.OTLP.BatchSize,
+		l.Output.OTLP.MaxQueueSize,
+		prefix,
+		l.Output.OTLP.MaxQueueSize,
+		l.Output.OTLP.FlushInterval,
+		prefix,
+		l.Output.OTLP.FlushInterval,
+		l.Output.OTLP.Timeout,
+		prefix,
+		l.Output.OTLP.Timeout,
 	)
 }
 
+// tomlStrings returns the toml array of strings, e.g. ["file", "stdout"].
+func tomlStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// tomlStringsMap returns the toml inline table of string arrays sorted by key, e.g. { AccessLog = ["file"] }.
+func tomlStringsMap(values map[string][]string) string {
+	if len(values) == 0 {
+		return "{}"
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]string, len(keys))
+	for i, k := range keys {
+		kvs[i] = strconv.Quote(k) + " = " + tomlStrings(values[k])
+	}
+	return "{ " + strings.Join(kvs, ", ") + " }"
+}
+
 // NewDefaultSetting returns a new default logging setting.
 func NewDefaultSetting() *Setting {
 	return &Setting{
-		Dir:        filepath.Join(defaultParentDir, "log"),
-		Level:      "info",
-		MaxSize:    ltoml.Size(100 * 1024 * 1024),
-		MaxBackups: 3,
-		MaxAge:     7,
+		Dir:                  filepath.Join(defaultParentDir, "log"),
+		Level:                "info",
+		MaxSize:              ltoml.Size(100 * 1024 * 1024),
+		MaxBackups:           3,
+		MaxAge:               7,
+		Format:               FormatConsole,
+		RedactKeys:           append([]string(nil), DefaultRedactKeys...),
+		AsyncBufferSize:      DefaultAsyncBufferSize,
+		AsyncPolicy:          AsyncPolicyBlock,
+		StacktraceLevel:      DefaultStacktraceLevel,
+		DisableCallerModules: []string{},
+		Output: Output{
+			Syslog:   SyslogOutput{Facility: "local0"},
+			Journald: JournaldOutput{Socket: DefaultJournaldSocket},
+			OTLP: OTLPOutput{
+				BatchSize:     DefaultOTLPBatchSize,
+				MaxQueueSize:  DefaultOTLPMaxQueueSize,
+				FlushInterval: ltoml.Duration(DefaultOTLPFlushInterval),
+				Timeout:       ltoml.Duration(DefaultOTLPTimeout),
+			},
+		},
 	}
 }
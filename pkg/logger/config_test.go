@@ -25,4 +25,5 @@ import (
 
 func TestSetting_TOML(t *testing.T) {
 	assert.NotEmpty(t, NewDefaultSetting().TOML("TEST"))
+	assert.Contains(t, NewDefaultSetting().TOML("TEST"), `asyncpolicy = "block"`)
 }
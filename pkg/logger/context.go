@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"context"
+)
+
+// contextKey is the key of logger in context.
+type contextKey struct{}
+
+// defaultContextLogger is used if no logger in context.
+var defaultContextLogger = GetLogger("Common", "Context")
+
+// WithContext returns a copy of ctx which carries the logger(e.g. with request id/trace id fields).
+func WithContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx, returns default logger if not exist.
+func FromContext(ctx context.Context) Logger {
+	if log, ok := ContextLogger(ctx); ok {
+		return log
+	}
+	return defaultContextLogger
+}
+
+// ContextLogger returns the logger carried by ctx, returns false if not exist.
+func ContextLogger(ctx context.Context) (Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	log, ok := ctx.Value(contextKey{}).(Logger)
+	return log, ok && log != nil
+}
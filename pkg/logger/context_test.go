@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.TODO()
+	assert.Equal(t, defaultContextLogger, FromContext(ctx))
+	_, ok := ContextLogger(ctx)
+	assert.False(t, ok)
+	//nolint:staticcheck
+	_, ok = ContextLogger(nil)
+	assert.False(t, ok)
+	_, ok = ContextLogger(WithContext(ctx, nil))
+	assert.False(t, ok)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := (&logger{log: zap.New(core), ignoreModuleAndRole: true}).With(String("requestID", "req-1"))
+	ctx = WithContext(ctx, log)
+	ctxLog, ok := ContextLogger(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, log, ctxLog)
+	FromContext(ctx).Info("handle")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{"requestID": "req-1"}, logs.All()[0].ContextMap())
+}
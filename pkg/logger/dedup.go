@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RepeatedKey is the field key of repeat count which is added into the collapsed entry by dedup core.
+const RepeatedKey = "repeated"
+
+// dedupKey identifies the duplicate entries.
+type dedupKey struct {
+	module  string
+	level   zapcore.Level
+	message string
+}
+
+// dedupState is the state of last entry, shared by the dedup core and its children.
+type dedupState struct {
+	mutex    sync.Mutex
+	window   time.Duration
+	key      dedupKey
+	first    time.Time // time of the last written entry
+	repeated int64
+	// last repeated entry and the core which writes it.
+	ent    zapcore.Entry
+	fields []zap.Field
+	core   zapcore.Core
+}
+
+// dedupCore collapses the consecutive identical(module, level, message) entries within the window,
+// the first entry is written immediately, the repeated ones are written as one entry with repeat count
+// when a different entry arrives, an identical entry arrives after the window or syncing.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+// NewDedupCore returns a core which collapses the consecutive identical entries within window,
+// returns core as is if window <= 0.
+func NewDedupCore(core zapcore.Core, window time.Duration) zapcore.Core {
+	if window <= 0 {
+		return core
+	}
+	return &dedupCore{Core: core, state: &dedupState{window: window}}
+}
+
+// WithDedup returns a zap option which wraps the core of logger by dedup core.
+func WithDedup(window time.Duration) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewDedupCore(core, window)
+	})
+}
+
+// With adds structured context to the wrapped core, the state of last entry is shared.
+func (c *dedupCore) With(fields []zap.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+// Check adds the dedup core into checked entry if the level is enabled.
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the entry if it isn't the duplicate of last entry within window, else counts it.
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zap.Field) error {
+	key := dedupKey{module: moduleOf(ent, fields), level: ent.Level, message: ent.Message}
+	s := c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if key == s.key && !s.first.IsZero() && ent.Time.Sub(s.first) < s.window {
+		s.repeated++
+		s.ent = ent
+		// copy fields, which may be reused by caller after writing
+		s.fields = append(s.fields[:0], fields...)
+		s.core = c.Core
+		return nil
+	}
+	err := s.flush()
+	s.key = key
+	s.first = ent.Time
+	if err0 := c.Core.Write(ent, fields); err0 != nil {
+		return err0
+	}
+	return err
+}
+
+// Sync writes the pending repeated entry, then syncs the wrapped core.
+func (c *dedupCore) Sync() error {
+	c.state.mutex.Lock()
+	err := c.state.flush()
+	c.state.mutex.Unlock()
+	if err0 := c.Core.Sync(); err0 != nil {
+		return err0
+	}
+	return err
+}
+
+// flush writes the last repeated entry with repeat count if repeated, must hold the lock.
+func (s *dedupState) flush() error {
+	if s.repeated == 0 {
+		return nil
+	}
+	fields := append(s.fields, zap.Int64(RepeatedKey, s.repeated))
+	err := s.core.Write(s.ent, fields)
+	s.repeated = 0
+	s.fields = s.fields[:0]
+	s.core = nil
+	// the next identical entry starts a new window
+	s.first = time.Time{}
+	return err
+}
+
+// moduleOf returns the module of entry, which is logged as field in json format, else logger name.
+func moduleOf(ent zapcore.Entry, fields []zap.Field) string {
+	for i := range fields {
+		if fields[i].Key == "module" && fields[i].Type == zapcore.StringType {
+			return fields[i].String
+		}
+	}
+	return ent.LoggerName
+}
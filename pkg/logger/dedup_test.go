@@ -0,0 +1,106 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// mockCore fails the writing and syncing.
+type mockCore struct {
+	zapcore.Core
+}
+
+func (c *mockCore) Write(_ zapcore.Entry, _ []zap.Field) error { return fmt.Errorf("write err") }
+
+func (c *mockCore) Sync() error { return fmt.Errorf("sync err") }
+
+func TestDedupCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	assert.Equal(t, core, NewDedupCore(core, 0))
+
+	dedup := NewDedupCore(core, time.Minute)
+	now := time.Unix(1000, 0)
+	write := func(module, msg string, fields ...zap.Field) {
+		assert.NoError(t, dedup.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: msg, Time: now},
+			append(fields, String("module", module))))
+	}
+	for i := 0; i < 4; i++ {
+		write("Query", "query failure", Int("i", i))
+		now = now.Add(time.Second)
+	}
+	// different module
+	write("Write", "query failure")
+	write("Write", "query failure")
+	// window expired
+	now = now.Add(time.Minute)
+	write("Write", "query failure")
+	assert.NoError(t, dedup.Sync())
+	// no repeated
+	assert.NoError(t, dedup.Sync())
+
+	msgs := logs.AllUntimed()
+	assert.Len(t, msgs, 5)
+	assert.Equal(t, map[string]interface{}{"i": int64(0), "module": "Query"}, msgs[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"i": int64(3), "module": "Query", RepeatedKey: int64(3)}, msgs[1].ContextMap())
+	assert.Equal(t, "query failure", msgs[1].Message)
+	assert.Equal(t, map[string]interface{}{"module": "Write"}, msgs[2].ContextMap())
+	assert.Equal(t, map[string]interface{}{"module": "Write", RepeatedKey: int64(1)}, msgs[3].ContextMap())
+	assert.Equal(t, map[string]interface{}{"module": "Write"}, msgs[4].ContextMap())
+}
+
+func TestDedupCore_Logger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := zap.New(core, WithDedup(time.Hour)).Named("Broker")
+	child := log.With(String("db", "test"))
+	child.Warn("slow query")
+	child.Warn("slow query")
+	log.Warn("slow query")
+	log.Debug("slow query")
+	log.Info("slow query")
+	assert.NoError(t, log.Sync())
+
+	msgs := logs.AllUntimed()
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, map[string]interface{}{"db": "test"}, msgs[0].ContextMap())
+	// repeated entry is written by the core of last repeated entry
+	assert.Equal(t, map[string]interface{}{RepeatedKey: int64(2)}, msgs[1].ContextMap())
+	assert.Equal(t, zapcore.WarnLevel, msgs[1].Level)
+	assert.Equal(t, zapcore.InfoLevel, msgs[2].Level)
+}
+
+func TestDedupCore_Failure(t *testing.T) {
+	dedup := NewDedupCore(&mockCore{}, time.Minute)
+	ent := zapcore.Entry{Message: "msg", Time: time.Now()}
+	assert.Error(t, dedup.Write(ent, nil))
+	assert.NoError(t, dedup.Write(ent, nil))
+	assert.Error(t, dedup.Sync())
+
+	ent.Time = ent.Time.Add(time.Minute)
+	assert.Error(t, dedup.Write(ent, nil))
+	assert.NoError(t, dedup.Write(ent, nil))
+	ent.Message = "msg2"
+	assert.Error(t, dedup.Write(ent, nil))
+}
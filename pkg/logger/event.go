@@ -0,0 +1,130 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// EventIDKey is the field key of the machine-readable event id,
+// alerting rules should match on it rather than the message.
+const EventIDKey = "event_id"
+
+// EventID represents the stable id of a log event, e.g. "storage.flush.failed".
+// Never change/reuse an event id once it is released.
+type EventID string
+
+// EventInfo represents a registered event id with its description.
+type EventInfo struct {
+	ID          EventID `json:"id"`
+	Description string  `json:"description"`
+}
+
+var (
+	events     = make(map[EventID]string)
+	eventsLock sync.RWMutex
+)
+
+// RegisterEvent registers the event id with its description, panics if the id is empty or registered twice.
+// It is expected to be called when initializing package vars, e.g.
+// var FlushFailed = logger.RegisterEvent("storage.flush.failed", "flush memory database failure").
+func RegisterEvent(id EventID, description string) EventID {
+	if id == "" {
+		panic("logger: event id cannot be empty")
+	}
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	if _, ok := events[id]; ok {
+		panic(fmt.Sprintf("logger: event id: %s registered twice", id))
+	}
+	events[id] = description
+	return id
+}
+
+// IsRegisteredEvent returns if the event id is registered.
+func IsRegisteredEvent(id EventID) bool {
+	eventsLock.RLock()
+	defer eventsLock.RUnlock()
+
+	_, ok := events[id]
+	return ok
+}
+
+// Events returns all registered event ids sorted by id.
+func Events() []EventInfo {
+	eventsLock.RLock()
+	defer eventsLock.RUnlock()
+
+	rs := make([]EventInfo, 0, len(events))
+	for id, desc := range events {
+		rs = append(rs, EventInfo{ID: id, Description: desc})
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].ID < rs[j].ID
+	})
+	return rs
+}
+
+// Field returns the event id field.
+func (id EventID) Field() zap.Field {
+	return zap.String(EventIDKey, string(id))
+}
+
+// LogEvent represents a log message with the event id.
+type LogEvent struct {
+	id  EventID
+	msg string
+}
+
+// Event returns a log event with the id and message, e.g.
+// logger.Event(FlushFailed, "flush memory database failure").Error(log, logger.Error(err)).
+func Event(id EventID, msg string) LogEvent {
+	return LogEvent{id: id, msg: msg}
+}
+
+// Debug logs the event at DebugLevel.
+func (e LogEvent) Debug(log Logger, fields ...zap.Field) {
+	log.Debug(e.msg, e.fields(fields)...)
+}
+
+// Info logs the event at InfoLevel.
+func (e LogEvent) Info(log Logger, fields ...zap.Field) {
+	log.Info(e.msg, e.fields(fields)...)
+}
+
+// Warn logs the event at WarnLevel.
+func (e LogEvent) Warn(log Logger, fields ...zap.Field) {
+	log.Warn(e.msg, e.fields(fields)...)
+}
+
+// Error logs the event at ErrorLevel.
+func (e LogEvent) Error(log Logger, fields ...zap.Field) {
+	log.Error(e.msg, e.fields(fields)...)
+}
+
+// fields returns the event id field with the fields of log site.
+func (e LogEvent) fields(fields []zap.Field) []zap.Field {
+	rs := make([]zap.Field, 0, len(fields)+1)
+	rs = append(rs, e.id.Field())
+	return append(rs, fields...)
+}
@@ -0,0 +1,60 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRegisterEvent(t *testing.T) {
+	defer func() {
+		events = make(map[EventID]string)
+	}()
+	b := RegisterEvent("test.b", "b")
+	a := RegisterEvent("test.a", "a")
+	assert.Equal(t, EventID("test.b"), b)
+	assert.True(t, IsRegisteredEvent(a))
+	assert.False(t, IsRegisteredEvent("test.c"))
+	assert.Equal(t, []EventInfo{{ID: "test.a", Description: "a"}, {ID: "test.b", Description: "b"}}, Events())
+
+	assert.Panics(t, func() { RegisterEvent("test.a", "a") })
+	assert.Panics(t, func() { RegisterEvent("", "empty") })
+}
+
+func TestEvent(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	id := EventID("test.flush.failed")
+	Event(id, "debug").Debug(log)
+	Event(id, "info").Info(log, Int("count", 1))
+	Event(id, "warn").Warn(log)
+	Event(id, "error").Error(WithFields(log, String("db", "test")))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, map[string]interface{}{EventIDKey: "test.flush.failed"}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{EventIDKey: "test.flush.failed", "count": int64(1)}, entries[1].ContextMap())
+	assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	assert.Equal(t, "error", entries[3].Message)
+	assert.Equal(t, map[string]interface{}{EventIDKey: "test.flush.failed", "db": "test"}, entries[3].ContextMap())
+}
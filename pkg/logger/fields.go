@@ -0,0 +1,141 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fieldsLogger is a logger which adds the pre-populated fields to each log.
+type fieldsLogger struct {
+	log    Logger
+	fields []zap.Field
+}
+
+// WithFields returns a logger which adds the fields to each log, e.g. request scoped fields.
+func WithFields(log Logger, fields ...zap.Field) Logger {
+	if len(fields) == 0 {
+		return log
+	}
+	if l, ok := log.(*fieldsLogger); ok {
+		// flatten nested fields logger
+		return &fieldsLogger{log: l.log, fields: append(append([]zap.Field{}, l.fields...), fields...)}
+	}
+	return &fieldsLogger{log: log, fields: fields}
+}
+
+// Debug logs a message at DebugLevel with the pre-populated fields.
+func (l *fieldsLogger) Debug(msg string, fields ...zap.Field) {
+	l.log.Debug(msg, l.merge(fields)...)
+}
+
+// Info logs a message at InfoLevel with the pre-populated fields.
+func (l *fieldsLogger) Info(msg string, fields ...zap.Field) {
+	l.log.Info(msg, l.merge(fields)...)
+}
+
+// Warn logs a message at WarnLevel with the pre-populated fields.
+func (l *fieldsLogger) Warn(msg string, fields ...zap.Field) {
+	l.log.Warn(msg, l.merge(fields)...)
+}
+
+// Error logs a message at ErrorLevel with the pre-populated fields.
+func (l *fieldsLogger) Error(msg string, fields ...zap.Field) {
+	l.log.Error(msg, l.merge(fields)...)
+}
+
+// DPanic logs a message at DPanicLevel with the pre-populated fields.
+func (l *fieldsLogger) DPanic(msg string, fields ...zap.Field) {
+	l.log.DPanic(msg, l.merge(fields)...)
+}
+
+// Panic logs a message at PanicLevel with the pre-populated fields, then panics.
+func (l *fieldsLogger) Panic(msg string, fields ...zap.Field) {
+	l.log.Panic(msg, l.merge(fields)...)
+}
+
+// Fatal logs a message at FatalLevel with the pre-populated fields, then calls os.Exit(1).
+func (l *fieldsLogger) Fatal(msg string, fields ...zap.Field) {
+	l.log.Fatal(msg, l.merge(fields)...)
+}
+
+// Debugf formats the message then logs it at DebugLevel with the pre-populated fields.
+func (l *fieldsLogger) Debugf(template string, args ...any) {
+	if l.Enabled(DebugLevel) {
+		l.log.Debug(fmt.Sprintf(template, args...), l.fields...)
+	}
+}
+
+// Infof formats the message then logs it at InfoLevel with the pre-populated fields.
+func (l *fieldsLogger) Infof(template string, args ...any) {
+	if l.Enabled(InfoLevel) {
+		l.log.Info(fmt.Sprintf(template, args...), l.fields...)
+	}
+}
+
+// Warnf formats the message then logs it at WarnLevel with the pre-populated fields.
+func (l *fieldsLogger) Warnf(template string, args ...any) {
+	if l.Enabled(WarnLevel) {
+		l.log.Warn(fmt.Sprintf(template, args...), l.fields...)
+	}
+}
+
+// Errorf formats the message then logs it at ErrorLevel with the pre-populated fields.
+func (l *fieldsLogger) Errorf(template string, args ...any) {
+	if l.Enabled(ErrorLevel) {
+		l.log.Error(fmt.Sprintf(template, args...), l.fields...)
+	}
+}
+
+// DPanicf formats the message then logs it at DPanicLevel with the pre-populated fields.
+func (l *fieldsLogger) DPanicf(template string, args ...any) {
+	l.log.DPanic(fmt.Sprintf(template, args...), l.fields...)
+}
+
+// Panicf formats the message, logs it at PanicLevel with the pre-populated fields then panics.
+func (l *fieldsLogger) Panicf(template string, args ...any) {
+	l.log.Panic(fmt.Sprintf(template, args...), l.fields...)
+}
+
+// Fatalf formats the message, logs it at FatalLevel with the pre-populated fields then calls os.Exit(1).
+func (l *fieldsLogger) Fatalf(template string, args ...any) {
+	l.log.Fatal(fmt.Sprintf(template, args...), l.fields...)
+}
+
+// Enabled decides whether a given logging level is enabled when logging a message.
+func (l *fieldsLogger) Enabled(level zapcore.Level) bool {
+	return l.log.Enabled(level)
+}
+
+// With returns a child logger with the pre-populated fields and the fields.
+func (l *fieldsLogger) With(fields ...zap.Field) Logger {
+	return WithFields(l, fields...)
+}
+
+// merge returns the pre-populated fields with the fields of log site.
+func (l *fieldsLogger) merge(fields []zap.Field) []zap.Field {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	rs := make([]zap.Field, 0, len(l.fields)+len(fields))
+	rs = append(rs, l.fields...)
+	return append(rs, fields...)
+}
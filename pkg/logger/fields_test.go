@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	assert.Equal(t, Logger(log), WithFields(log))
+
+	l1 := WithFields(log, String("requestID", "1"))
+	l2 := WithFields(l1, String("route", "GET /"))
+	assert.True(t, l2.Enabled(DebugLevel))
+	l1.Debug("debug")
+	l2.Info("info", Int("count", 1))
+	l2.Warn("warn")
+	l2.Error("error")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, map[string]interface{}{"requestID": "1"}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"requestID": "1", "route": "GET /", "count": int64(1)}, entries[1].ContextMap())
+	assert.Equal(t, map[string]interface{}{"requestID": "1", "route": "GET /"}, entries[2].ContextMap())
+	assert.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+}
+
+func TestWithFields_Formatted(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := &logger{log: zap.New(core, zap.OnFatal(zapcore.WriteThenPanic)), ignoreModuleAndRole: true}
+	l := WithFields(log, String("requestID", "1"))
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 1)
+	l.Warnf("warn %d", 1)
+	l.Errorf("error %d", 1)
+	l.DPanic("dpanic", Int("count", 1))
+	l.DPanicf("dpanic %d", 1)
+	assert.Panics(t, func() { l.Panic("panic") })
+	assert.Panics(t, func() { l.Panicf("panic %d", 1) })
+	assert.Panics(t, func() { l.Fatal("fatal") })
+	assert.Panics(t, func() { l.Fatalf("fatal %d", 1) })
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 9)
+	assert.Equal(t, "info 1", entries[0].Message)
+	for _, entry := range entries {
+		assert.Equal(t, "1", entry.ContextMap()["requestID"])
+	}
+	assert.Equal(t, map[string]interface{}{"requestID": "1", "count": int64(1)}, entries[3].ContextMap())
+	assert.Equal(t, "fatal 1", entries[8].Message)
+}
+
+func TestLogger_With(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	assert.Equal(t, Logger(log), log.With())
+
+	db := log.With(String("database", "db"))
+	shard := db.With(Int32("shard", 1))
+	req := WithFields(shard, String("requestID", "1"))
+	child := req.With(String("route", "GET /"))
+	log.Info("root")
+	db.Info("db")
+	shard.Warnf("shard %d", 1)
+	child.Error("child", Int("count", 1))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 4)
+	assert.Empty(t, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{"database": "db"}, entries[1].ContextMap())
+	assert.Equal(t, map[string]interface{}{"database": "db", "shard": int32(1)}, entries[2].ContextMap())
+	assert.Equal(t, map[string]interface{}{
+		"database": "db", "shard": int32(1), "requestID": "1", "route": "GET /", "count": int64(1),
+	}, entries[3].ContextMap())
+}
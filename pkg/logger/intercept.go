@@ -0,0 +1,153 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// interceptEntry is the core installed by InstallInterceptCore/InstallModuleInterceptCore.
+type interceptEntry struct {
+	module string // empty means all modules
+	core   zapcore.Core
+}
+
+// match returns if the entry receives the logs of module and level.
+func (e *interceptEntry) match(module string, level zapcore.Level) bool {
+	return (e.module == "" || e.module == module) && e.core.Enabled(level)
+}
+
+var (
+	// interceptors are the cores installed(e.g. loggertest recorder), nil if not installed.
+	interceptors atomic.Pointer[[]*interceptEntry]
+	// interceptLock serializes the copy-on-write updates of interceptors.
+	interceptLock sync.Mutex
+)
+
+// InstallInterceptCore installs a core which also receives the logs of all loggers(including the loggers
+// created before installing), returns the function which removes the core, it's designed for testing.
+func InstallInterceptCore(core zapcore.Core) (restore func()) {
+	return installInterceptCore("", core)
+}
+
+// InstallModuleInterceptCore installs a core which also receives the logs of the loggers of module(including
+// the loggers created before installing), returns the function which removes the core, it's designed for testing.
+func InstallModuleInterceptCore(module string, core zapcore.Core) (restore func()) {
+	return installInterceptCore(module, core)
+}
+
+// installInterceptCore appends the intercept core, returns the function which removes it.
+func installInterceptCore(module string, core zapcore.Core) func() {
+	entry := &interceptEntry{module: module, core: core}
+
+	interceptLock.Lock()
+	defer interceptLock.Unlock()
+
+	var entries []*interceptEntry
+	if prev := interceptors.Load(); prev != nil {
+		entries = append(entries, *prev...)
+	}
+	entries = append(entries, entry)
+	interceptors.Store(&entries)
+
+	return func() {
+		interceptLock.Lock()
+		defer interceptLock.Unlock()
+
+		prev := interceptors.Load()
+		if prev == nil {
+			return
+		}
+		var entries []*interceptEntry
+		for _, e := range *prev {
+			if e != entry {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			interceptors.Store(nil)
+			return
+		}
+		interceptors.Store(&entries)
+	}
+}
+
+// interceptCore forwards the logs of module to the installed intercept cores besides the wrapped core.
+type interceptCore struct {
+	zapcore.Core
+	module string
+	fields []zap.Field
+}
+
+// wrapInterceptCore returns the function which wraps the core of module logger with intercept core.
+func wrapInterceptCore(module string) func(core zapcore.Core) zapcore.Core {
+	return func(core zapcore.Core) zapcore.Core {
+		if _, ok := core.(*interceptCore); ok {
+			return core
+		}
+		return &interceptCore{Core: core, module: module}
+	}
+}
+
+// Enabled returns if the level is enabled by wrapped core or intercept cores.
+func (c *interceptCore) Enabled(level zapcore.Level) bool {
+	if c.Core.Enabled(level) {
+		return true
+	}
+	if entries := interceptors.Load(); entries != nil {
+		for _, e := range *entries {
+			if e.match(c.module, level) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// With adds structured context to both wrapped core and intercept cores.
+func (c *interceptCore) With(fields []zap.Field) zapcore.Core {
+	return &interceptCore{
+		Core:   c.Core.With(fields),
+		module: c.module,
+		fields: append(append([]zap.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check adds the intercept cores into checked entry if they are installed and enabled.
+func (c *interceptCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	entries := interceptors.Load()
+	if entries == nil {
+		return ce
+	}
+	for _, e := range *entries {
+		if !e.match(c.module, ent.Level) {
+			continue
+		}
+		target := e.core
+		if len(c.fields) > 0 {
+			target = target.With(c.fields)
+		}
+		ce = ce.AddCore(ent, target)
+	}
+	return ce
+}
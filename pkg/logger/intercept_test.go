@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInstallInterceptCore(t *testing.T) {
+	// logger created before installing
+	log := GetLogger("Test", "Intercept").With(zap.String("k", "v"))
+	log.Error("before install")
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	restore := InstallInterceptCore(core)
+	log.Info("info log")
+	log.Warn("warn log")
+	log.Error("error log", zap.Int("n", 1))
+	WithFields(GetLogger("Test", "Intercept"), zap.String("f", "1")).Error("fields log")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+	assert.Contains(t, entries[0].Message, "warn log")
+	assert.Equal(t, map[string]any{"k": "v", "n": int64(1)}, entries[1].ContextMap())
+	assert.Equal(t, map[string]any{"f": "1"}, entries[2].ContextMap())
+
+	restore()
+	log.Error("after restore")
+	assert.Equal(t, 3, logs.Len())
+}
+
+func TestInterceptCore_Enabled(t *testing.T) {
+	level := RunningAtomicLevel.Level()
+	defer RunningAtomicLevel.SetLevel(level)
+	RunningAtomicLevel.SetLevel(zapcore.ErrorLevel)
+
+	log := GetLogger("Test", "Intercept")
+	assert.False(t, log.Enabled(zapcore.DebugLevel))
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := InstallInterceptCore(core)
+	defer restore()
+	assert.True(t, log.Enabled(zapcore.DebugLevel))
+	log.Debug("debug log")
+	assert.Equal(t, 1, logs.Len())
+
+	wrap := wrapInterceptCore("Test")
+	assert.Same(t, core, wrap(core).(*interceptCore).Core)
+	wrapped := wrap(core)
+	assert.Same(t, wrapped, wrap(wrapped))
+}
+
+func TestInstallModuleInterceptCore(t *testing.T) {
+	// loggers created before installing
+	log := GetLogger("InterceptModule", "Broker").With(zap.String("k", "v"))
+	other := GetLogger("OtherModule", "Broker")
+
+	allCore, allLogs := observer.New(zapcore.ErrorLevel)
+	restoreAll := InstallInterceptCore(allCore)
+	defer restoreAll()
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := InstallModuleInterceptCore("InterceptModule", core)
+	log.Debug("module log")
+	other.Error("other module log")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "module log", entries[0].Message)
+	assert.Equal(t, map[string]any{"k": "v"}, entries[0].ContextMap())
+	assert.Equal(t, 1, allLogs.Len())
+
+	restore()
+	log.Error("after restore")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, 2, allLogs.Len())
+	// restore twice
+	restore()
+	assert.NotNil(t, interceptors.Load())
+}
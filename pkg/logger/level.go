@@ -0,0 +1,113 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// moduleLevels are the level overrides of modules, key is module, value is zapcore.Level.
+// It's copy-on-write(guarded by modulesLock) so the level check of logging is lock-free.
+var moduleLevels atomic.Pointer[map[string]zapcore.Level]
+
+// SetModuleLevel overrides the logging level of module on the fly, which takes precedence over RunningAtomicLevel.
+// NOTE: entries enabled only by the override are written to all sinks of the module's logger.
+func SetModuleLevel(module string, level zapcore.Level) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	levels := copyModuleLevels()
+	levels[module] = level
+	moduleLevels.Store(&levels)
+}
+
+// ResetModuleLevel removes the level override of module, RunningAtomicLevel is used again.
+func ResetModuleLevel(module string) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	levels := copyModuleLevels()
+	delete(levels, module)
+	moduleLevels.Store(&levels)
+}
+
+// ModuleLevel returns the level override of module, false if not overridden.
+func ModuleLevel(module string) (zapcore.Level, bool) {
+	levels := moduleLevels.Load()
+	if levels == nil {
+		return zapcore.InfoLevel, false
+	}
+	level, ok := (*levels)[module]
+	return level, ok
+}
+
+// copyModuleLevels returns a copy of module levels, must hold the lock.
+func copyModuleLevels() map[string]zapcore.Level {
+	levels := make(map[string]zapcore.Level)
+	if current := moduleLevels.Load(); current != nil {
+		for module, level := range *current {
+			levels[module] = level
+		}
+	}
+	return levels
+}
+
+// moduleLevelCore checks the entries by the level override of module if it's set.
+type moduleLevelCore struct {
+	zapcore.Core
+	module string
+}
+
+// wrapModuleLevelCore returns the function which wraps the core of logger with module level core.
+func wrapModuleLevelCore(module string) func(core zapcore.Core) zapcore.Core {
+	return func(core zapcore.Core) zapcore.Core {
+		return &moduleLevelCore{Core: core, module: module}
+	}
+}
+
+// Enabled returns if the level is enabled by the level override, or by the wrapped core if not overridden.
+func (c *moduleLevelCore) Enabled(level zapcore.Level) bool {
+	if override, ok := ModuleLevel(c.module); ok {
+		return override.Enabled(level)
+	}
+	return c.Core.Enabled(level)
+}
+
+// With adds structured context to the wrapped core.
+func (c *moduleLevelCore) With(fields []zap.Field) zapcore.Core {
+	return &moduleLevelCore{Core: c.Core.With(fields), module: c.module}
+}
+
+// Check adds the wrapped core into checked entry if the level is enabled.
+func (c *moduleLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	override, ok := ModuleLevel(c.module)
+	switch {
+	case !ok:
+		return c.Core.Check(ent, ce)
+	case !override.Enabled(ent.Level):
+		return ce
+	case c.Core.Enabled(ent.Level):
+		return c.Core.Check(ent, ce)
+	default:
+		// the level is filtered by the wrapped core, write it directly
+		return ce.AddCore(ent, c.Core)
+	}
+}
@@ -0,0 +1,79 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestModuleLevel(t *testing.T) {
+	defer cleanModules("LevelModule", "OtherLevelModule")
+	defer ResetModuleLevel("LevelModule")
+
+	_, ok := ModuleLevel("LevelModule")
+	assert.False(t, ok)
+	core, logs := observer.New(zapcore.InfoLevel)
+	RegisterLogger("LevelModule", zap.New(core), false)
+	log := GetLogger("LevelModule", "Broker")
+	childLog := log.With(String("key", "value"))
+
+	log.Debug("debug before override")
+	assert.False(t, log.Enabled(DebugLevel))
+
+	// lower level than wrapped core
+	SetModuleLevel("LevelModule", zapcore.DebugLevel)
+	level, ok := ModuleLevel("LevelModule")
+	assert.True(t, ok)
+	assert.Equal(t, zapcore.DebugLevel, level)
+	assert.True(t, log.Enabled(DebugLevel))
+	log.Debug("debug after override")
+	childLog.Debug("child debug after override")
+	info, _ := findModule("LevelModule")
+	assert.Equal(t, "debug", info.Level)
+
+	// higher level than wrapped core
+	SetModuleLevel("LevelModule", zapcore.ErrorLevel)
+	log.Info("info filtered")
+	log.Error("error after override")
+
+	// other modules are not affected
+	assert.False(t, GetLogger("OtherLevelModule", "").Enabled(DebugLevel))
+
+	ResetModuleLevel("LevelModule")
+	_, ok = ModuleLevel("LevelModule")
+	assert.False(t, ok)
+	log.Debug("debug after reset")
+	log.Info("info after reset")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Len(t, messages, 4)
+	assert.Contains(t, messages[0], "debug after override")
+	assert.Contains(t, messages[1], "child debug after override")
+	assert.Contains(t, messages[2], "error after override")
+	assert.Contains(t, messages[3], "info after reset")
+	info, _ = findModule("LevelModule")
+	assert.Equal(t, "info", info.Level)
+}
@@ -19,6 +19,7 @@ package logger
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"runtime"
 	"sync/atomic"
@@ -105,8 +106,32 @@ type Logger interface {
 	// Error logs a message at ErrorLevel. The message includes any fields passed
 	// at the log site, as well as any fields accumulated on the logger.
 	Error(msg string, fields ...zap.Field)
+	// DPanic logs a message at DPanicLevel, then panics if the logger is in development mode.
+	DPanic(msg string, fields ...zap.Field)
+	// Panic logs a message at PanicLevel, then panics, even if logging at PanicLevel is disabled.
+	Panic(msg string, fields ...zap.Field)
+	// Fatal logs a message at FatalLevel, then calls os.Exit(1), even if logging at FatalLevel is disabled.
+	Fatal(msg string, fields ...zap.Field)
+	// Debugf formats the message according to template then logs it at DebugLevel,
+	// the message isn't formatted if DebugLevel is disabled.
+	Debugf(template string, args ...any)
+	// Infof formats the message according to template then logs it at InfoLevel.
+	Infof(template string, args ...any)
+	// Warnf formats the message according to template then logs it at WarnLevel.
+	Warnf(template string, args ...any)
+	// Errorf formats the message according to template then logs it at ErrorLevel.
+	Errorf(template string, args ...any)
+	// DPanicf formats the message according to template then logs it at DPanicLevel.
+	DPanicf(template string, args ...any)
+	// Panicf formats the message according to template, logs it at PanicLevel then panics.
+	Panicf(template string, args ...any)
+	// Fatalf formats the message according to template, logs it at FatalLevel then calls os.Exit(1).
+	Fatalf(template string, args ...any)
 	// Enabled decides whether a given logging level is enabled when logging a message.
 	Enabled(level zapcore.Level) bool
+	// With returns a child logger which adds the fields to each log(e.g. database, shard, request id),
+	// the fields of the parent logger are kept, the parent logger isn't affected.
+	With(fields ...zap.Field) Logger
 }
 
 // logger implements Logger interface.
@@ -115,6 +140,7 @@ type logger struct {
 	module              string
 	role                string
 	ignoreModuleAndRole bool
+	jsonFormat          bool // module/role are logged as fields instead of message prefix
 }
 
 // Enabled decides whether a given logging level is enabled when logging a message.
@@ -122,33 +148,102 @@ func (l *logger) Enabled(level zapcore.Level) bool {
 	return l.log.Core().Enabled(level)
 }
 
+// With returns a child logger with the accumulated fields, which are encoded once when creating.
+func (l *logger) With(fields ...zap.Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	child := *l
+	child.log = l.log.With(fields...)
+	return &child
+}
+
 // Debug logs a message at DebugLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *logger) Debug(msg string, fields ...zap.Field) {
-	l.log.Debug(l.formatMsg(msg), fields...)
+	l.log.Debug(l.formatMsg(msg), l.moduleFields(fields)...)
 }
 
 // Info logs a message at InfoLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *logger) Info(msg string, fields ...zap.Field) {
-	l.log.Info(l.formatMsg(msg), fields...)
+	l.log.Info(l.formatMsg(msg), l.moduleFields(fields)...)
 }
 
 // Warn logs a message at WarnLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *logger) Warn(msg string, fields ...zap.Field) {
-	l.log.Warn(l.formatMsg(msg), fields...)
+	l.log.Warn(l.formatMsg(msg), l.moduleFields(fields)...)
 }
 
 // Error logs a message at ErrorLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *logger) Error(msg string, fields ...zap.Field) {
-	l.log.Error(l.formatMsg(msg), fields...)
+	l.log.Error(l.formatMsg(msg), l.moduleFields(fields)...)
+}
+
+// DPanic logs a message at DPanicLevel, then panics if the logger is in development mode.
+func (l *logger) DPanic(msg string, fields ...zap.Field) {
+	l.log.DPanic(l.formatMsg(msg), l.moduleFields(fields)...)
+}
+
+// Panic logs a message at PanicLevel, then panics, even if logging at PanicLevel is disabled.
+func (l *logger) Panic(msg string, fields ...zap.Field) {
+	l.log.Panic(l.formatMsg(msg), l.moduleFields(fields)...)
+}
+
+// Fatal logs a message at FatalLevel, then calls os.Exit(1), even if logging at FatalLevel is disabled.
+func (l *logger) Fatal(msg string, fields ...zap.Field) {
+	l.log.Fatal(l.formatMsg(msg), l.moduleFields(fields)...)
+}
+
+// Debugf formats the message according to template then logs it at DebugLevel,
+// the message isn't formatted if DebugLevel is disabled.
+func (l *logger) Debugf(template string, args ...any) {
+	if l.Enabled(DebugLevel) {
+		l.log.Debug(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+	}
+}
+
+// Infof formats the message according to template then logs it at InfoLevel.
+func (l *logger) Infof(template string, args ...any) {
+	if l.Enabled(InfoLevel) {
+		l.log.Info(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+	}
+}
+
+// Warnf formats the message according to template then logs it at WarnLevel.
+func (l *logger) Warnf(template string, args ...any) {
+	if l.Enabled(WarnLevel) {
+		l.log.Warn(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+	}
+}
+
+// Errorf formats the message according to template then logs it at ErrorLevel.
+func (l *logger) Errorf(template string, args ...any) {
+	if l.Enabled(ErrorLevel) {
+		l.log.Error(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+	}
+}
+
+// DPanicf formats the message according to template then logs it at DPanicLevel.
+func (l *logger) DPanicf(template string, args ...any) {
+	l.log.DPanic(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+}
+
+// Panicf formats the message according to template, logs it at PanicLevel then panics.
+func (l *logger) Panicf(template string, args ...any) {
+	l.log.Panic(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
+}
+
+// Fatalf formats the message according to template, logs it at FatalLevel then calls os.Exit(1).
+func (l *logger) Fatalf(template string, args ...any) {
+	l.log.Fatal(l.formatMsg(fmt.Sprintf(template, args...)), l.moduleFields(nil)...)
 }
 
 // formatMsg formats msg using module name
 func (l *logger) formatMsg(msg string) string {
-	if !isTerminal || l.ignoreModuleAndRole {
+	if !isTerminal || l.ignoreModuleAndRole || l.jsonFormat {
 		return msg
 	}
 	moduleName := fmt.Sprintf("[%*s]", atomic.LoadUint32(&maxModuleNameLen), l.module)
@@ -163,6 +258,19 @@ func (l *logger) formatMsg(msg string) string {
 		moduleName, l.role, msg)
 }
 
+// moduleFields appends module/role as fields in json format.
+func (l *logger) moduleFields(fields []zap.Field) []zap.Field {
+	if l.ignoreModuleAndRole || !l.jsonFormat {
+		return fields
+	}
+	// copy on append, don't modify the fields of caller
+	fields = append(fields[:len(fields):len(fields)], String("module", l.module))
+	if l.role != "" {
+		fields = append(fields, String("role", l.role))
+	}
+	return fields
+}
+
 // String constructs a field with the given key and value.
 func String(key, val string) zap.Field {
 	return zap.Field{Key: key, Type: zapcore.StringType, String: val}
@@ -214,12 +322,48 @@ func Int32(key string, val int32) zap.Field {
 	return zap.Field{Key: key, Type: zapcore.Int32Type, Integer: int64(val)}
 }
 
-// Int is a shortcut for int.
+// Int is a shortcut for int, which is encoded as int64 without truncating.
 func Int(key string, val int) zap.Field {
-	return zap.Field{Key: key, Type: zapcore.Int32Type, Integer: int64(val)}
+	return zap.Field{Key: key, Type: zapcore.Int64Type, Integer: int64(val)}
 }
 
 // Int64 constructs a field with the given key and value.
 func Int64(key string, val int64) zap.Field {
 	return zap.Field{Key: key, Type: zapcore.Int64Type, Integer: val}
 }
+
+// Uint64 constructs a field with the given key and value.
+func Uint64(key string, val uint64) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.Uint64Type, Integer: int64(val)}
+}
+
+// Float64 constructs a field with the given key and value.
+func Float64(key string, val float64) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.Float64Type, Integer: int64(math.Float64bits(val))}
+}
+
+// Bool constructs a field with the given key and value.
+func Bool(key string, val bool) zap.Field {
+	var integer int64
+	if val {
+		integer = 1
+	}
+	return zap.Field{Key: key, Type: zapcore.BoolType, Integer: integer}
+}
+
+// Duration constructs a field with the given key and value.
+func Duration(key string, val time.Duration) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.DurationType, Integer: int64(val)}
+}
+
+// Time constructs a field with the given key and value, which doesn't allocate
+// unless the time is out of the range of int64 nanoseconds(1678~2262).
+func Time(key string, val time.Time) zap.Field {
+	return zap.Time(key, val)
+}
+
+// Strings constructs a field that carries a slice of strings,
+// which allocates once for boxing the slice.
+func Strings(key string, val []string) zap.Field {
+	return zap.Strings(key, val)
+}
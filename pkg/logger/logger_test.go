@@ -19,12 +19,15 @@ package logger
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func Test_Logger_Enabled(t *testing.T) {
@@ -49,6 +52,55 @@ func Test_Logger(t *testing.T) {
 	logger3.Error("error test")
 }
 
+func Test_Logger_Formatted(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	log.Debugf("debug %d", 1)
+	log.Infof("info %d", 2)
+	log.Warnf("warn %s", "3")
+	log.Errorf("error %v", fmt.Errorf("4"))
+	log.DPanicf("dpanic %d", 5)
+	log.DPanic("dpanic")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 5)
+	assert.Equal(t, "info 2", entries[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "warn 3", entries[1].Message)
+	assert.Equal(t, "error 4", entries[2].Message)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	assert.Equal(t, "dpanic 5", entries[3].Message)
+	assert.Equal(t, zapcore.DPanicLevel, entries[3].Level)
+
+	// level disabled
+	core, logs = observer.New(zapcore.FatalLevel)
+	log = &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	log.Infof("info")
+	log.Warnf("warn")
+	log.Errorf("error")
+	assert.Zero(t, logs.Len())
+}
+
+func Test_Logger_PanicAndFatal(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &logger{log: zap.New(core, zap.Development(), zap.OnFatal(zapcore.WriteThenPanic)), ignoreModuleAndRole: true}
+	log.Debugf("debug %d", 1)
+	assert.Panics(t, func() { log.DPanic("dpanic") })
+	assert.Panics(t, func() { log.DPanicf("dpanic %d", 1) })
+	assert.PanicsWithValue(t, "panic", func() { log.Panic("panic", String("k", "v")) })
+	assert.PanicsWithValue(t, "panic 1", func() { log.Panicf("panic %d", 1) })
+	assert.Panics(t, func() { log.Fatal("fatal") })
+	assert.Panics(t, func() { log.Fatalf("fatal %d", 1) })
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 7)
+	assert.Equal(t, "debug 1", entries[0].Message)
+	assert.Equal(t, zapcore.PanicLevel, entries[3].Level)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, entries[3].ContextMap())
+	assert.Equal(t, zapcore.FatalLevel, entries[6].Level)
+	assert.Equal(t, "fatal 1", entries[6].Message)
+}
+
 func Test_Level_String(t *testing.T) {
 	defer func() {
 		isTerminal = false
@@ -104,3 +156,67 @@ func Test_SimpleAccessLevelEncoder(t *testing.T) {
 	log := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))
 	log.Info("hello", Stack())
 }
+
+var fieldSink zap.Field
+
+// fieldConstructors are the escape-free field constructors used in hot paths.
+var fieldConstructors = map[string]func() zap.Field{
+	"String":   func() zap.Field { return String("key", "value") },
+	"Error":    func() zap.Field { return Error(os.ErrNotExist) },
+	"Uint16":   func() zap.Field { return Uint16("key", 1) },
+	"Uint32":   func() zap.Field { return Uint32("key", 1) },
+	"Uint64":   func() zap.Field { return Uint64("key", 1) },
+	"Int":      func() zap.Field { return Int("key", 1) },
+	"Int32":    func() zap.Field { return Int32("key", 1) },
+	"Int64":    func() zap.Field { return Int64("key", 1) },
+	"Float64":  func() zap.Field { return Float64("key", 1.5) },
+	"Bool":     func() zap.Field { return Bool("key", true) },
+	"Duration": func() zap.Field { return Duration("key", time.Second) },
+	"Time":     func() zap.Field { return Time("key", time.Unix(1, 0)) },
+}
+
+func Test_FieldConstructors_Allocs(t *testing.T) {
+	for name, fn := range fieldConstructors {
+		fn := fn
+		allocs := testing.AllocsPerRun(100, func() {
+			fieldSink = fn()
+		})
+		assert.Zero(t, allocs, name)
+	}
+	strs := []string{"a", "b"}
+	assert.LessOrEqual(t, testing.AllocsPerRun(100, func() {
+		fieldSink = Strings("key", strs)
+	}), float64(1))
+}
+
+func Test_FieldConstructors(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := &logger{log: zap.New(core), ignoreModuleAndRole: true}
+	now := time.Unix(1700000000, 0)
+	log.Info("fields",
+		Int("int", math.MaxInt64), Uint64("uint64", math.MaxUint64), Float64("float64", 1.5),
+		Bool("true", true), Bool("false", false), Duration("duration", time.Second),
+		Time("time", now), Strings("strings", []string{"a", "b"}))
+	assert.Equal(t, map[string]interface{}{
+		"int":      int64(math.MaxInt64),
+		"uint64":   uint64(math.MaxUint64),
+		"float64":  1.5,
+		"true":     true,
+		"false":    false,
+		"duration": time.Second,
+		"time":     now,
+		"strings":  []interface{}{"a", "b"},
+	}, logs.AllUntimed()[0].ContextMap())
+}
+
+func Benchmark_FieldConstructors(b *testing.B) {
+	for name, fn := range fieldConstructors {
+		fn := fn
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				fieldSink = fn()
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package loggertest
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// TB is the subset of testing.TB used by Recorder.
+type TB interface {
+	Helper()
+	Cleanup(f func())
+	Errorf(format string, args ...any)
+}
+
+// Option represents the option of Recorder.
+type Option func(r *Recorder)
+
+// ExpectError marks the error logs which message contains substr as expected.
+func ExpectError(substr string) Option {
+	return func(r *Recorder) {
+		r.expected = append(r.expected, substr)
+	}
+}
+
+// WithLevel sets the minimum level of recorded logs, ErrorLevel by default.
+func WithLevel(level zapcore.Level) Option {
+	return func(r *Recorder) {
+		r.level = level
+	}
+}
+
+// AllowErrors disables failing the test at cleanup, the logs are only recorded.
+func AllowErrors() Option {
+	return func(r *Recorder) {
+		r.allowErrors = true
+	}
+}
+
+// Recorder records the logs of all loggers during testing,
+// and fails the test at cleanup if there are unexpected error logs.
+type Recorder struct {
+	logs        *observer.ObservedLogs
+	level       zapcore.Level
+	allowErrors bool
+
+	mutex    sync.Mutex
+	expected []string
+}
+
+// Record installs a recorder which records the logs(ErrorLevel and above by default),
+// the recorder is removed at cleanup of t, then t fails if there are unexpected error logs.
+func Record(t TB, options ...Option) *Recorder {
+	t.Helper()
+	r := &Recorder{level: zapcore.ErrorLevel}
+	for _, option := range options {
+		option(r)
+	}
+	core, logs := observer.New(r.level)
+	r.logs = logs
+	restore := logger.InstallInterceptCore(core)
+	t.Cleanup(func() {
+		restore()
+		r.verify(t)
+	})
+	return r
+}
+
+// Expect marks the error logs which message contains substr as expected.
+func (r *Recorder) Expect(substr string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.expected = append(r.expected, substr)
+}
+
+// Entries returns all recorded logs.
+func (r *Recorder) Entries() []observer.LoggedEntry {
+	return r.logs.AllUntimed()
+}
+
+// Errors returns the recorded logs of ErrorLevel and above.
+func (r *Recorder) Errors() []observer.LoggedEntry {
+	return r.filter(func(entry observer.LoggedEntry) bool {
+		return entry.Level >= zapcore.ErrorLevel
+	})
+}
+
+// Unexpected returns the recorded error logs which are not expected.
+func (r *Recorder) Unexpected() []observer.LoggedEntry {
+	r.mutex.Lock()
+	expected := append([]string{}, r.expected...)
+	r.mutex.Unlock()
+	return r.filter(func(entry observer.LoggedEntry) bool {
+		if entry.Level < zapcore.ErrorLevel {
+			return false
+		}
+		for _, substr := range expected {
+			if strings.Contains(entry.Message, substr) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// filter returns the recorded logs which match the predicate.
+func (r *Recorder) filter(predicate func(entry observer.LoggedEntry) bool) (rs []observer.LoggedEntry) {
+	for _, entry := range r.logs.AllUntimed() {
+		if predicate(entry) {
+			rs = append(rs, entry)
+		}
+	}
+	return rs
+}
+
+// verify fails the test if there are unexpected error logs.
+func (r *Recorder) verify(t TB) {
+	t.Helper()
+	if r.allowErrors {
+		return
+	}
+	for _, entry := range r.Unexpected() {
+		t.Errorf("unexpected %s log: %s, fields: %v", entry.Level.CapitalString(), entry.Message, entry.ContextMap())
+	}
+}
@@ -0,0 +1,124 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package loggertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// mockTB records the failures and cleanups instead of failing the test.
+type mockTB struct {
+	name     string
+	errors   []string
+	cleanups []func()
+}
+
+func (t *mockTB) Helper() {}
+
+func (t *mockTB) Name() string { return t.name }
+
+func (t *mockTB) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *mockTB) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *mockTB) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+var log = logger.GetLogger("Test", "Recorder")
+
+func TestRecord(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []Option
+		prepare func(r *Recorder)
+		errors  int
+	}{
+		{
+			name:   "unexpected error",
+			errors: 2,
+		},
+		{
+			name:    "expected by option",
+			options: []Option{ExpectError("disk full")},
+			errors:  1,
+		},
+		{
+			name: "expected by recorder",
+			prepare: func(r *Recorder) {
+				r.Expect("disk full")
+				r.Expect("timeout")
+			},
+		},
+		{
+			name:    "allow errors",
+			options: []Option{AllowErrors()},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &mockTB{}
+			r := Record(tb, tt.options...)
+			if tt.prepare != nil {
+				tt.prepare(r)
+			}
+			log.Info("info log")
+			log.Error("disk full", logger.String("path", "/data"))
+			log.Errorf("request %s timeout", "q1")
+			tb.cleanup()
+			assert.Len(t, tb.errors, tt.errors)
+			assert.Len(t, r.Errors(), 2)
+
+			// removed at cleanup
+			log.Error("after cleanup")
+			assert.Len(t, r.Entries(), 2)
+		})
+	}
+}
+
+func TestRecord_Level(t *testing.T) {
+	tb := &mockTB{}
+	r := Record(tb, WithLevel(zapcore.WarnLevel))
+	log.Warn("warn log")
+	log.Error("error log")
+	tb.cleanup()
+	assert.Len(t, r.Entries(), 2)
+	assert.Len(t, r.Errors(), 1)
+	assert.Len(t, r.Unexpected(), 1)
+	assert.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "unexpected ERROR log")
+}
+
+func TestRecord_Testing(t *testing.T) {
+	r := Record(t, ExpectError("expected failure"))
+	log.Error("expected failure")
+	assert.Empty(t, r.Unexpected())
+}
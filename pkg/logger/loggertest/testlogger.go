@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package loggertest
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// TestingT is the subset of testing.TB used by TestLogger.
+type TestingT interface {
+	TB
+	Name() string
+}
+
+// TestLogger is a Logger which records the logs of module in memory for assertions in tests,
+// instead of eyeballing stdout.
+type TestLogger struct {
+	logger.Logger
+
+	t      TestingT
+	module string
+	logs   *observer.ObservedLogs
+}
+
+// NewTestLogger creates a test logger which records the logs(all levels) of module named by test name.
+func NewTestLogger(t TestingT) *TestLogger {
+	t.Helper()
+	return NewModuleTestLogger(t, t.Name())
+}
+
+// NewModuleTestLogger creates a test logger which records the logs(all levels) of module,
+// including the logs of loggers got by logger.GetLogger(module, role) in the code under test,
+// even if they are created before the call(e.g. package level loggers).
+// The recording is removed at cleanup of t.
+func NewModuleTestLogger(t TestingT, module string) *TestLogger {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.InstallModuleInterceptCore(module, core))
+	return &TestLogger{
+		Logger: logger.GetLogger(module, ""),
+		t:      t,
+		module: module,
+		logs:   logs,
+	}
+}
+
+// Module returns the module recorded by test logger.
+func (l *TestLogger) Module() string {
+	return l.module
+}
+
+// Entries returns all recorded logs.
+func (l *TestLogger) Entries() []observer.LoggedEntry {
+	return l.logs.AllUntimed()
+}
+
+// Messages returns the messages of recorded logs with level.
+func (l *TestLogger) Messages(level zapcore.Level) (messages []string) {
+	for _, entry := range l.logs.AllUntimed() {
+		if entry.Level == level {
+			messages = append(messages, entry.Message)
+		}
+	}
+	return messages
+}
+
+// Reset removes all recorded logs.
+func (l *TestLogger) Reset() {
+	_ = l.logs.TakeAll()
+}
+
+// Contains returns if there is a recorded log with level which message contains substr.
+func (l *TestLogger) Contains(level zapcore.Level, substr string) bool {
+	for _, message := range l.Messages(level) {
+		if strings.Contains(message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertContains asserts that there is a recorded log with level which message contains substr.
+func (l *TestLogger) AssertContains(level zapcore.Level, substr string) bool {
+	l.t.Helper()
+	if l.Contains(level, substr) {
+		return true
+	}
+	l.t.Errorf("no %s log contains: %q, recorded logs:\n%s", level.CapitalString(), substr, l.dump())
+	return false
+}
+
+// AssertNotContains asserts that there is no recorded log with level which message contains substr.
+func (l *TestLogger) AssertNotContains(level zapcore.Level, substr string) bool {
+	l.t.Helper()
+	if !l.Contains(level, substr) {
+		return true
+	}
+	l.t.Errorf("unexpected %s log contains: %q, recorded logs:\n%s", level.CapitalString(), substr, l.dump())
+	return false
+}
+
+// dump returns the string of recorded logs for failure message.
+func (l *TestLogger) dump() string {
+	var sb strings.Builder
+	for _, entry := range l.logs.AllUntimed() {
+		sb.WriteString("\t" + entry.Level.CapitalString() + " " + entry.Message)
+		if fields := entry.ContextMap(); len(fields) > 0 {
+			sb.WriteString(fmt.Sprintf(" %v", fields))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
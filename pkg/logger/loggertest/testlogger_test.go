@@ -0,0 +1,81 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package loggertest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// brokerLog is the package level logger of code under test, created before the test logger.
+var brokerLog = logger.GetLogger("TestLoggerModule", "Broker")
+
+func TestNewTestLogger(t *testing.T) {
+	tb := &mockTB{name: "TestLoggerModule"}
+	log := NewTestLogger(tb)
+	assert.Equal(t, "TestLoggerModule", log.Module())
+	log.Debug("debug log")
+	log.Info("info log", zap.String("key", "value"))
+	// logs of code under test
+	brokerLog.Warn("disk is almost full")
+	logger.GetLogger("OtherModule", "Broker").Warn("disk is full")
+
+	assert.Len(t, log.Entries(), 3)
+	assert.True(t, log.AssertContains(logger.DebugLevel, "debug log"))
+	assert.True(t, log.AssertContains(logger.WarnLevel, "almost full"))
+	assert.True(t, log.AssertNotContains(logger.WarnLevel, "disk is full"))
+	assert.True(t, log.AssertNotContains(logger.ErrorLevel, "disk"))
+	assert.Empty(t, tb.errors)
+
+	assert.False(t, log.AssertContains(logger.ErrorLevel, "disk"))
+	assert.False(t, log.AssertNotContains(logger.InfoLevel, "info"))
+	assert.Len(t, tb.errors, 2)
+	assert.Contains(t, tb.errors[0], `no ERROR log contains: "disk"`)
+	assert.Contains(t, tb.errors[0], "INFO info log map[key:value]")
+	assert.Contains(t, tb.errors[1], `unexpected INFO log contains: "info"`)
+
+	log.Reset()
+	assert.Empty(t, log.Entries())
+	assert.Empty(t, log.Messages(logger.InfoLevel))
+
+	tb.cleanup()
+	brokerLog.Warn("disk is almost full")
+	assert.Empty(t, log.Entries())
+}
+
+func TestNewModuleTestLogger_WithRecorder(t *testing.T) {
+	tb := &mockTB{}
+	r := Record(tb)
+	log := NewModuleTestLogger(tb, "TestLoggerModule")
+	brokerLog.Error("disk is full")
+	log.AssertContains(logger.ErrorLevel, "disk is full")
+	tb.cleanup()
+
+	assert.Len(t, r.Errors(), 1)
+	assert.Len(t, tb.errors, 1)
+}
+
+func TestNewTestLogger_Testing(t *testing.T) {
+	log := NewTestLogger(t)
+	log.Errorf("request %s failure", "q1")
+	log.AssertContains(logger.ErrorLevel, "q1 failure")
+}
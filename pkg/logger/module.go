@@ -0,0 +1,141 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleInfo represents a logger module discovered by GetLogger or initialized by InitModuleLogger.
+type ModuleInfo struct {
+	Module string   `json:"module"`
+	Roles  []string `json:"roles,omitempty"`
+	Level  string   `json:"level"`           // effective level, the level override or the lowest enabled level
+	Sinks  []string `json:"sinks,omitempty"` // output targets, empty if unknown(e.g. logger registered directly)
+}
+
+var (
+	// modulesLock protects loggers/modules/moduleSinks.
+	modulesLock sync.RWMutex
+	// modules are the roles of discovered modules.
+	modules = make(map[string]map[string]struct{})
+	// moduleSinks are the sinks of initialized loggers, key is module("" means default logger).
+	moduleSinks = make(map[string][]string)
+)
+
+// discoverModule records the module/role, returns the logger registered for module.
+func discoverModule(module, role string) (*log, bool) {
+	modulesLock.RLock()
+	log, ok := loggers[module]
+	_, seen := modules[module][role]
+	modulesLock.RUnlock()
+	if seen {
+		return log, ok
+	}
+
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	roles, exist := modules[module]
+	if !exist {
+		roles = make(map[string]struct{})
+		modules[module] = roles
+	}
+	roles[role] = struct{}{}
+	return log, ok
+}
+
+// recordModuleSinks records the sinks of initialized logger.
+func recordModuleSinks(module string, sinkNames []string) {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, name := range sinkNames {
+		name = strings.TrimSpace(name)
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	moduleSinks[module] = names
+}
+
+// Modules returns the discovered and registered logger modules sorted by name,
+// with the effective levels and output targets.
+func Modules() []ModuleInfo {
+	modulesLock.RLock()
+	defer modulesLock.RUnlock()
+
+	names := make(map[string]struct{}, len(modules)+len(loggers))
+	for module := range modules {
+		names[module] = struct{}{}
+	}
+	for module := range loggers {
+		names[module] = struct{}{}
+	}
+	result := make([]ModuleInfo, 0, len(names))
+	for module := range names {
+		info := ModuleInfo{Module: module}
+		for role := range modules[module] {
+			if role != "" {
+				info.Roles = append(info.Roles, role)
+			}
+		}
+		sort.Strings(info.Roles)
+		zapLogger, sinkNames := moduleLogger(module)
+		if level, ok := ModuleLevel(module); ok {
+			info.Level = level.String()
+		} else {
+			info.Level = effectiveLevel(zapLogger.Core()).String()
+		}
+		info.Sinks = append([]string(nil), sinkNames...)
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Module < result[j].Module
+	})
+	return result
+}
+
+// moduleLogger returns the zap logger and sinks used by module, must hold the lock.
+func moduleLogger(module string) (*zap.Logger, []string) {
+	if log, ok := loggers[module]; ok {
+		return log.logger, moduleSinks[module]
+	}
+	if defaultLog, ok := DefaultLogger.Load().(*zap.Logger); ok && defaultLog != nil {
+		return defaultLog, moduleSinks[""]
+	}
+	return defaultLogger, []string{SinkStdout}
+}
+
+// effectiveLevel returns the lowest enabled level of core, FatalLevel+1 if all levels are disabled.
+func effectiveLevel(core zapcore.Core) zapcore.Level {
+	for level := zapcore.DebugLevel; level <= zapcore.FatalLevel; level++ {
+		if core.Enabled(level) {
+			return level
+		}
+	}
+	return zapcore.FatalLevel + 1
+}
@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// findModule returns the module info by name.
+func findModule(module string) (ModuleInfo, bool) {
+	for _, info := range Modules() {
+		if info.Module == module {
+			return info, true
+		}
+	}
+	return ModuleInfo{}, false
+}
+
+// cleanModules removes the modules from registry.
+func cleanModules(names ...string) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	for _, name := range names {
+		delete(modules, name)
+		delete(loggers, name)
+		delete(moduleSinks, name)
+	}
+}
+
+func TestModules(t *testing.T) {
+	defer cleanModules("DiscoverModule", "RegisteredModule", "SinkModule")
+
+	GetLogger("DiscoverModule", "Broker")
+	GetLogger("DiscoverModule", "Storage")
+	GetLogger("DiscoverModule", "Broker")
+	GetLogger("DiscoverModule", "")
+	info, ok := findModule("DiscoverModule")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Broker", "Storage"}, info.Roles)
+	assert.Equal(t, RunningAtomicLevel.Level().String(), info.Level)
+
+	// registered without getting
+	core, _ := observer.New(zapcore.WarnLevel)
+	RegisterLogger("RegisteredModule", zap.New(core), false)
+	info, ok = findModule("RegisteredModule")
+	assert.True(t, ok)
+	assert.Equal(t, ModuleInfo{Module: "RegisteredModule", Level: "warn"}, info)
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	setting := Setting{Level: "info", ModuleSinks: map[string][]string{"SinkModule": {SinkStdout, " stdout", SinkStderr}}}
+	log, err := InitModuleLogger("SinkModule", "sink.log", setting, &encoderConfig)
+	assert.NoError(t, err)
+	RegisterLogger("SinkModule", log, false)
+	GetLogger("SinkModule", "Broker")
+	info, ok = findModule("SinkModule")
+	assert.True(t, ok)
+	assert.Equal(t, ModuleInfo{Module: "SinkModule", Roles: []string{"Broker"}, Level: "info", Sinks: []string{"stdout", "stderr"}}, info)
+
+	modules := Modules()
+	for i := 1; i < len(modules); i++ {
+		assert.Less(t, modules[i-1].Module, modules[i].Module)
+	}
+}
+
+func TestModules_DefaultLogger(t *testing.T) {
+	prev := DefaultLogger.Load()
+	if prev == nil {
+		prev = defaultLogger
+	}
+	defer func() {
+		DefaultLogger.Store(prev)
+		cleanModules("DefaultModule")
+		modulesLock.Lock()
+		delete(moduleSinks, "")
+		modulesLock.Unlock()
+	}()
+
+	GetLogger("DefaultModule", "")
+	log, err := InitLogger("default.log", Setting{Level: "info", Sinks: []string{SinkStderr}}, &zapcore.EncoderConfig{})
+	assert.NoError(t, err)
+	DefaultLogger.Store(log)
+	info, ok := findModule("DefaultModule")
+	assert.True(t, ok)
+	assert.Equal(t, []string{SinkStderr}, info.Sinks)
+
+	assert.Equal(t, zapcore.FatalLevel+1, effectiveLevel(zapcore.NewNopCore()))
+}
@@ -0,0 +1,389 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	collogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+const (
+	// SinkOTLP exports log into OpenTelemetry collector by OTLP/gRPC, configured by Setting.Output.OTLP.
+	SinkOTLP = "otlp"
+
+	// DefaultOTLPBatchSize is the default number of log records exported in one request.
+	DefaultOTLPBatchSize = 512
+	// DefaultOTLPMaxQueueSize is the default maximum number of log records buffered before exporting.
+	DefaultOTLPMaxQueueSize = 8192
+	// DefaultOTLPFlushInterval is the default interval of exporting the buffered log records.
+	DefaultOTLPFlushInterval = 5 * time.Second
+	// DefaultOTLPTimeout is the default timeout of export request.
+	DefaultOTLPTimeout = 10 * time.Second
+
+	// otlpScopeName is the instrumentation scope name of exported log records.
+	otlpScopeName = "github.com/lindb/common/pkg/logger"
+)
+
+// for testing
+var otlpDialFunc = grpc.Dial
+
+// OTLPOutput represents the configuration of OTLP log sink.
+type OTLPOutput struct {
+	Endpoint      string         `env:"ENDPOINT" toml:"endpoint"` // host:port of OpenTelemetry collector
+	Insecure      bool           `env:"INSECURE" toml:"insecure"` // disable TLS
+	ServiceName   string         `env:"SERVICE_NAME" toml:"servicename"`
+	Role          string         `env:"ROLE" toml:"role"` // role of component, e.g. broker/storage
+	BatchSize     int            `env:"BATCH_SIZE" toml:"batchsize"`
+	MaxQueueSize  int            `env:"MAX_QUEUE_SIZE" toml:"maxqueuesize"`
+	FlushInterval ltoml.Duration `env:"FLUSH_INTERVAL" toml:"flushinterval"`
+	Timeout       ltoml.Duration `env:"TIMEOUT" toml:"timeout"`
+}
+
+// otlpCore implements zapcore.Core which converts log entry into OTLP log record,
+// the records are batched and exported by exporter.
+type otlpCore struct {
+	zapcore.LevelEnabler
+	fields   []zapcore.Field
+	exporter *otlpExporter
+}
+
+// newOTLPCore creates the core of OTLP sink, the connection is established lazily.
+func newOTLPCore(module string, cfg *OTLPOutput, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	exporter, err := newOTLPExporter(module, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &otlpCore{LevelEnabler: level, exporter: exporter}, nil
+}
+
+// With adds structured context to the core.
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &otlpCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+		exporter:     c.exporter,
+	}
+}
+
+// Check adds the core into checked entry if the level is enabled.
+func (c *otlpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write converts the log entry into log record, then enqueues it for exporting.
+func (c *otlpCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.exporter.enqueue(newOTLPLogRecord(ent, c.fields, fields))
+	return nil
+}
+
+// Sync exports the buffered log records.
+func (c *otlpCore) Sync() error {
+	return c.exporter.flush()
+}
+
+// newOTLPLogRecord converts the log entry and its fields into log record.
+func newOTLPLogRecord(ent zapcore.Entry, contextFields, fields []zapcore.Field) *logsv1.LogRecord {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range contextFields {
+		contextFields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	attributes := make([]*commonv1.KeyValue, 0, len(enc.Fields)+4)
+	if ent.LoggerName != "" {
+		attributes = append(attributes, otlpKeyValue("logger", ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		attributes = append(attributes,
+			otlpKeyValue("code.filepath", ent.Caller.File),
+			otlpKeyValue("code.lineno", int64(ent.Caller.Line)))
+		if ent.Caller.Function != "" {
+			attributes = append(attributes, otlpKeyValue("code.function", ent.Caller.Function))
+		}
+	}
+	if ent.Stack != "" {
+		attributes = append(attributes, otlpKeyValue("exception.stacktrace", ent.Stack))
+	}
+	for _, key := range sortedKeys(enc.Fields) {
+		attributes = append(attributes, otlpKeyValue(key, enc.Fields[key]))
+	}
+	return &logsv1.LogRecord{
+		TimeUnixNano:         uint64(ent.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       otlpSeverity(ent.Level),
+		SeverityText:         ent.Level.CapitalString(),
+		Body:                 otlpValue(ent.Message),
+		Attributes:           attributes,
+	}
+}
+
+// otlpSeverity returns the severity number of level.
+func otlpSeverity(level zapcore.Level) logsv1.SeverityNumber {
+	switch level {
+	case zapcore.DebugLevel:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case zapcore.InfoLevel:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_INFO
+	case zapcore.WarnLevel:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_WARN
+	case zapcore.ErrorLevel:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR
+	default:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL
+	}
+}
+
+// otlpKeyValue returns the attribute of key/value.
+func otlpKeyValue(key string, value any) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: key, Value: otlpValue(value)}
+}
+
+// otlpValue converts the value encoded by map object encoder into any value.
+func otlpValue(value any) *commonv1.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case int8:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case int16:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case int32:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: v}}
+	case uint8:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint16:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint32:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: int64(v)}}
+	case float32:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_BytesValue{BytesValue: v}}
+	case []any:
+		values := make([]*commonv1.AnyValue, len(v))
+		for i := range v {
+			values[i] = otlpValue(v[i])
+		}
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_ArrayValue{ArrayValue: &commonv1.ArrayValue{Values: values}}}
+	case map[string]any:
+		kvs := make([]*commonv1.KeyValue, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			kvs = append(kvs, otlpKeyValue(key, v[key]))
+		}
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_KvlistValue{KvlistValue: &commonv1.KeyValueList{Values: kvs}}}
+	default:
+		// uint64 may overflow int64, time/duration/complex etc. are exported as string
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+}
+
+// sortedKeys returns the sorted keys of map.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// otlpExporter batches the log records, exports them by interval or if batch is full.
+type otlpExporter struct {
+	client       collogsv1.LogsServiceClient
+	resource     *resourcev1.Resource
+	batchSize    int
+	maxQueueSize int
+	timeout      time.Duration
+
+	mutex       sync.Mutex
+	records     []*logsv1.LogRecord
+	exportMutex sync.Mutex // serializes export requests
+	flushCh     chan struct{}
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failures atomic.Int64
+}
+
+// newOTLPExporter creates the exporter of OTLP sink, starts the background flusher.
+func newOTLPExporter(module string, cfg *OTLPOutput) (*otlpExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint is empty")
+	}
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if cfg.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := otlpDialFunc(cfg.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	e := &otlpExporter{
+		client:       collogsv1.NewLogsServiceClient(conn),
+		resource:     newOTLPResource(module, cfg),
+		batchSize:    cfg.BatchSize,
+		maxQueueSize: cfg.MaxQueueSize,
+		timeout:      cfg.Timeout.Duration(),
+		flushCh:      make(chan struct{}, 1),
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultOTLPBatchSize
+	}
+	if e.maxQueueSize < e.batchSize {
+		e.maxQueueSize = max(DefaultOTLPMaxQueueSize, e.batchSize)
+	}
+	if e.timeout <= 0 {
+		e.timeout = DefaultOTLPTimeout
+	}
+	interval := cfg.FlushInterval.Duration()
+	if interval <= 0 {
+		interval = DefaultOTLPFlushInterval
+	}
+	go e.run(interval)
+	return e, nil
+}
+
+// newOTLPResource returns the resource with attributes of service/host/role/module.
+func newOTLPResource(module string, cfg *OTLPOutput) *resourcev1.Resource {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = processName()
+	}
+	attributes := []*commonv1.KeyValue{otlpKeyValue("service.name", serviceName)}
+	if hostname, err := hostnameFunc(); err == nil && hostname != "" {
+		attributes = append(attributes, otlpKeyValue("host.name", hostname))
+	}
+	if cfg.Role != "" {
+		attributes = append(attributes, otlpKeyValue("lindb.role", cfg.Role))
+	}
+	if module != "" {
+		attributes = append(attributes, otlpKeyValue("lindb.module", module))
+	}
+	return &resourcev1.Resource{Attributes: attributes}
+}
+
+// enqueue buffers the log record, drops it if the queue is full, notifies flusher if batch is full.
+func (e *otlpExporter) enqueue(record *logsv1.LogRecord) {
+	e.mutex.Lock()
+	if len(e.records) >= e.maxQueueSize {
+		e.mutex.Unlock()
+		e.dropped.Add(1)
+		loggingStats.dropped.Add(1)
+		return
+	}
+	e.records = append(e.records, record)
+	full := len(e.records) >= e.batchSize
+	e.mutex.Unlock()
+
+	if full {
+		select {
+		case e.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports the buffered log records by interval or if batch is full.
+func (e *otlpExporter) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flushCh:
+		}
+		_ = e.flush()
+	}
+}
+
+// flush exports all buffered log records in batches, returns the last export error.
+func (e *otlpExporter) flush() error {
+	e.exportMutex.Lock()
+	defer e.exportMutex.Unlock()
+
+	e.mutex.Lock()
+	records := e.records
+	e.records = nil
+	e.mutex.Unlock()
+
+	var err error
+	for len(records) > 0 {
+		n := min(len(records), e.batchSize)
+		if err0 := e.export(records[:n]); err0 != nil {
+			e.failures.Add(int64(n))
+			loggingStats.writeErrors.Add(int64(n))
+			err = err0
+		} else {
+			e.exported.Add(int64(n))
+		}
+		records = records[n:]
+	}
+	return err
+}
+
+// export sends the log records to collector.
+func (e *otlpExporter) export(records []*logsv1.LogRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	resp, err := e.client.Export(ctx, &collogsv1.ExportLogsServiceRequest{
+		ResourceLogs: []*logsv1.ResourceLogs{{
+			Resource: e.resource,
+			ScopeLogs: []*logsv1.ScopeLogs{{
+				Scope:      &commonv1.InstrumentationScope{Name: otlpScopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("export otlp logs failure: %w", err)
+	}
+	if partial := resp.GetPartialSuccess(); partial != nil && partial.RejectedLogRecords > 0 {
+		return fmt.Errorf("otlp collector rejected %d log records: %s", partial.RejectedLogRecords, partial.ErrorMessage)
+	}
+	return nil
+}
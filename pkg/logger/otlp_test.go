@@ -0,0 +1,277 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	collogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+// mockLogsServer records the exported requests.
+type mockLogsServer struct {
+	collogsv1.UnimplementedLogsServiceServer
+
+	mutex    sync.Mutex
+	requests []*collogsv1.ExportLogsServiceRequest
+	resp     *collogsv1.ExportLogsServiceResponse
+	err      error
+}
+
+func (s *mockLogsServer) Export(_ context.Context, req *collogsv1.ExportLogsServiceRequest) (*collogsv1.ExportLogsServiceResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.requests = append(s.requests, req)
+	if s.resp != nil {
+		return s.resp, nil
+	}
+	return &collogsv1.ExportLogsServiceResponse{}, nil
+}
+
+func (s *mockLogsServer) records() (records []*logsv1.LogRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, req := range s.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}
+	return records
+}
+
+func (s *mockLogsServer) setResult(resp *collogsv1.ExportLogsServiceResponse, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.resp, s.err = resp, err
+}
+
+func startLogsServer(t *testing.T) (*mockLogsServer, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	mock := &mockLogsServer{}
+	collogsv1.RegisterLogsServiceServer(server, mock)
+	go func() {
+		_ = server.Serve(l)
+	}()
+	t.Cleanup(server.Stop)
+	return mock, l.Addr().String()
+}
+
+func attributesMap(kvs []*commonv1.KeyValue) map[string]any {
+	m := make(map[string]any)
+	for _, kv := range kvs {
+		switch v := kv.Value.Value.(type) {
+		case *commonv1.AnyValue_StringValue:
+			m[kv.Key] = v.StringValue
+		case *commonv1.AnyValue_IntValue:
+			m[kv.Key] = v.IntValue
+		case *commonv1.AnyValue_BoolValue:
+			m[kv.Key] = v.BoolValue
+		case *commonv1.AnyValue_DoubleValue:
+			m[kv.Key] = v.DoubleValue
+		default:
+			m[kv.Key] = kv.Value
+		}
+	}
+	return m
+}
+
+func TestOTLP_Export(t *testing.T) {
+	defer func() {
+		hostnameFunc = os.Hostname
+	}()
+	hostnameFunc = func() (string, error) {
+		return "host1", nil
+	}
+	server, endpoint := startLogsServer(t)
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkOTLP, SinkOTLP}
+	setting.Output.OTLP.Endpoint = endpoint
+	setting.Output.OTLP.Insecure = true
+	setting.Output.OTLP.Role = "broker"
+	setting.Output.OTLP.ServiceName = "lindb"
+	encoderConfig := zap.NewProductionEncoderConfig()
+	log, err := InitModuleLogger("Query", "otlp.log", *setting, &encoderConfig, zap.AddCaller())
+	assert.NoError(t, err)
+
+	log.Named("exec").With(zap.String("db", "test")).Warn("slow query",
+		zap.Int("rows", 10), zap.Bool("ok", true), zap.Float64("cost", 1.5),
+		zap.Strings("tags", []string{"a"}), zap.Any("obj", map[string]any{"k": "v"}),
+		zap.Duration("d", time.Second), zap.Binary("b", []byte("x")))
+	log.Debug("ignored")
+	assert.NoError(t, log.Sync())
+
+	records := server.records()
+	assert.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, logsv1.SeverityNumber_SEVERITY_NUMBER_WARN, record.SeverityNumber)
+	assert.Equal(t, "WARN", record.SeverityText)
+	assert.Equal(t, "slow query", record.Body.GetStringValue())
+	assert.NotZero(t, record.TimeUnixNano)
+	attributes := attributesMap(record.Attributes)
+	assert.Equal(t, "exec", attributes["logger"])
+	assert.Equal(t, "test", attributes["db"])
+	assert.Equal(t, int64(10), attributes["rows"])
+	assert.Equal(t, true, attributes["ok"])
+	assert.Equal(t, 1.5, attributes["cost"])
+	assert.Equal(t, "1s", attributes["d"])
+	assert.Contains(t, attributes["code.filepath"], "otlp_test.go")
+	assert.Equal(t, "github.com/lindb/common/pkg/logger.TestOTLP_Export", attributes["code.function"])
+	assert.Equal(t, "a", attributes["tags"].(*commonv1.AnyValue).GetArrayValue().Values[0].GetStringValue())
+	assert.Equal(t, "k", attributes["obj"].(*commonv1.AnyValue).GetKvlistValue().Values[0].Key)
+	assert.Equal(t, []byte("x"), attributes["b"].(*commonv1.AnyValue).GetBytesValue())
+
+	rl := server.requests[0].ResourceLogs[0]
+	assert.Equal(t, map[string]any{
+		"service.name": "lindb",
+		"host.name":    "host1",
+		"lindb.role":   "broker",
+		"lindb.module": "Query",
+	}, attributesMap(rl.Resource.Attributes))
+	assert.Equal(t, otlpScopeName, rl.ScopeLogs[0].Scope.Name)
+}
+
+func TestOTLP_Batch(t *testing.T) {
+	server, endpoint := startLogsServer(t)
+	e, err := newOTLPExporter("", &OTLPOutput{
+		Endpoint:      endpoint,
+		Insecure:      true,
+		BatchSize:     2,
+		MaxQueueSize:  3,
+		FlushInterval: ltoml.Duration(time.Hour),
+	})
+	assert.NoError(t, err)
+	core := &otlpCore{LevelEnabler: zapcore.DebugLevel, exporter: e}
+	log := zap.New(core)
+	for i := 0; i < 3; i++ {
+		log.Info(fmt.Sprintf("msg-%d", i))
+	}
+	// flushed by full batch
+	assert.Eventually(t, func() bool {
+		return e.exported.Load() >= 2
+	}, 5*time.Second, time.Millisecond)
+	assert.NoError(t, log.Sync())
+	assert.Equal(t, int64(3), e.exported.Load())
+	for i, record := range server.records() {
+		assert.Equal(t, fmt.Sprintf("msg-%d", i), record.Body.GetStringValue())
+	}
+
+	// queue is full
+	e.mutex.Lock()
+	e.records = make([]*logsv1.LogRecord, 3)
+	e.mutex.Unlock()
+	log.Info("dropped")
+	assert.Equal(t, int64(1), e.dropped.Load())
+}
+
+func TestOTLP_ExportFailure(t *testing.T) {
+	server, endpoint := startLogsServer(t)
+	e, err := newOTLPExporter("", &OTLPOutput{Endpoint: endpoint, Insecure: true})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultOTLPBatchSize, e.batchSize)
+	assert.Equal(t, DefaultOTLPMaxQueueSize, e.maxQueueSize)
+	assert.Equal(t, DefaultOTLPTimeout, e.timeout)
+	log := zap.New(&otlpCore{LevelEnabler: zapcore.DebugLevel, exporter: e})
+
+	server.setResult(nil, errors.New("unavailable"))
+	log.Error("failure")
+	assert.Error(t, log.Sync())
+	assert.Equal(t, int64(1), e.failures.Load())
+
+	server.setResult(&collogsv1.ExportLogsServiceResponse{
+		PartialSuccess: &collogsv1.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "too old"},
+	}, nil)
+	log.DPanic("rejected")
+	err = log.Sync()
+	assert.ErrorContains(t, err, "too old")
+	assert.Equal(t, int64(2), e.failures.Load())
+	assert.Equal(t, logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL, server.records()[0].SeverityNumber)
+
+	// nothing to export
+	assert.NoError(t, log.Sync())
+}
+
+func TestOTLP_Config(t *testing.T) {
+	defer func() {
+		otlpDialFunc = grpc.Dial
+	}()
+	_, err := newOTLPCore("", &OTLPOutput{}, zapcore.InfoLevel)
+	assert.Error(t, err)
+
+	otlpDialFunc = func(_ string, _ ...grpc.DialOption) (*grpc.ClientConn, error) {
+		return nil, fmt.Errorf("err")
+	}
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkOTLP}
+	setting.Output.OTLP.Endpoint = "localhost:4317"
+	encoderConfig := zap.NewProductionEncoderConfig()
+	_, err = InitLogger("otlp.log", *setting, &encoderConfig)
+	assert.Error(t, err)
+
+	// tls by default, connected lazily
+	otlpDialFunc = grpc.Dial
+	e, err := newOTLPExporter("", &setting.Output.OTLP)
+	assert.NoError(t, err)
+	assert.Equal(t, "service.name", e.resource.Attributes[0].Key)
+
+	for _, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.ErrorLevel, zapcore.FatalLevel} {
+		assert.NotEqual(t, logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, otlpSeverity(level))
+	}
+	assert.Equal(t, int64(1), otlpValue(uint16(1)).GetIntValue())
+	assert.Equal(t, int64(1), otlpValue(int8(1)).GetIntValue())
+	assert.Equal(t, "18446744073709551615", otlpValue(uint64(18446744073709551615)).GetStringValue())
+	assert.Equal(t, float64(float32(1.5)), otlpValue(float32(1.5)).GetDoubleValue())
+}
+
+func TestSetting_TOML_OTLP(t *testing.T) {
+	setting := NewDefaultSetting()
+	setting.Output.OTLP.Endpoint = "collector:4317"
+	setting.Output.OTLP.Insecure = true
+	setting.Output.OTLP.Role = "storage"
+	decoded := &struct {
+		Logging Setting `toml:"logging"`
+	}{}
+	_, err := toml.Decode(setting.TOML("LINDB"), decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, setting.Output.OTLP, decoded.Logging.Output.OTLP)
+}
@@ -0,0 +1,393 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// SinkSyslog writes log into syslog(RFC5424), configured by Setting.Output.Syslog.
+	SinkSyslog = "syslog"
+	// SinkJournald writes log into systemd-journald by native protocol, configured by Setting.Output.Journald.
+	SinkJournald = "journald"
+
+	// DefaultJournaldSocket is the native protocol socket of systemd-journald.
+	DefaultJournaldSocket = "/run/systemd/journal/socket"
+	// rfc5424Time is the timestamp format of RFC5424.
+	rfc5424Time = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var (
+	// localSyslogSockets are the local syslog sockets tried in order.
+	localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	// syslogFacilities are the facility codes of RFC5424.
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+		"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+	// for testing
+	dialFunc     = net.Dial
+	hostnameFunc = os.Hostname
+)
+
+// Output represents the configuration of syslog/journald/otlp sinks, which are selected by Setting.Sinks.
+type Output struct {
+	Syslog   SyslogOutput   `envPrefix:"SYSLOG_" toml:"syslog"`
+	Journald JournaldOutput `envPrefix:"JOURNALD_" toml:"journald"`
+	OTLP     OTLPOutput     `envPrefix:"OTLP_" toml:"otlp"`
+}
+
+// SyslogOutput represents the configuration of syslog sink.
+type SyslogOutput struct {
+	// Network is the network of remote syslog server, udp or tcp, empty means local syslog socket.
+	Network string `env:"NETWORK" toml:"network"`
+	// Address is the address of remote syslog server(host:port), or the path of local syslog socket.
+	Address  string `env:"ADDRESS" toml:"address"`
+	Facility string `env:"FACILITY" toml:"facility"` // local0 by default
+	Tag      string `env:"TAG" toml:"tag"`           // APP-NAME, process name by default
+}
+
+// JournaldOutput represents the configuration of journald sink.
+type JournaldOutput struct {
+	Socket     string `env:"SOCKET" toml:"socket"`         // DefaultJournaldSocket by default
+	Identifier string `env:"IDENTIFIER" toml:"identifier"` // SYSLOG_IDENTIFIER, process name by default
+}
+
+// newOutputCores creates the cores of syslog/journald/otlp sinks selected by module.
+func newOutputCores(module string, setting *Setting, encoder zapcore.Encoder, level zapcore.LevelEnabler) ([]zapcore.Core, error) {
+	var cores []zapcore.Core
+	seen := make(map[string]struct{})
+	for _, name := range setting.sinkNames(module) {
+		name = strings.TrimSpace(name)
+		if _, ok := seen[name]; ok || (name != SinkSyslog && name != SinkJournald && name != SinkOTLP) {
+			continue
+		}
+		seen[name] = struct{}{}
+		core, err := newOutputCore(name, module, setting, encoder.Clone(), level)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, core)
+	}
+	return cores, nil
+}
+
+// newOutputCore creates the core of syslog/journald/otlp sink, which needs the level of each log entry.
+func newOutputCore(name, module string, setting *Setting, encoder zapcore.Encoder, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	var (
+		w   outputWriter
+		err error
+	)
+	switch name {
+	case SinkSyslog:
+		w, err = newSyslogWriter(module, &setting.Output.Syslog)
+	case SinkJournald:
+		w, err = newJournaldWriter(&setting.Output.Journald)
+	case SinkOTLP:
+		return newOTLPCore(module, &setting.Output.OTLP, level)
+	default:
+		return nil, fmt.Errorf("log output: %q not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &outputCore{LevelEnabler: level, encoder: encoder, w: w}, nil
+}
+
+// outputWriter writes the encoded log line with the entry(level/caller etc.).
+type outputWriter interface {
+	write(ent zapcore.Entry, line []byte) error
+}
+
+// outputCore implements zapcore.Core which writes log entry into syslog/journald.
+type outputCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	w       outputWriter
+}
+
+// With adds structured context to the core.
+func (c *outputCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &outputCore{LevelEnabler: c.LevelEnabler, encoder: c.encoder.Clone(), w: c.w}
+	for i := range fields {
+		fields[i].AddTo(clone.encoder)
+	}
+	return clone
+}
+
+// Check adds the core into checked entry if the level is enabled.
+func (c *outputCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write encodes the log entry, then writes it into syslog/journald.
+func (c *outputCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	line := bytes.TrimRight(buf.Bytes(), "\n")
+	if err = c.w.write(ent, line); err != nil {
+		return err
+	}
+	loggingStats.bytesWritten.Add(int64(len(line)))
+	return nil
+}
+
+// Sync does nothing, because log entry is sent immediately.
+func (c *outputCore) Sync() error {
+	return nil
+}
+
+// processName returns the name of current process.
+func processName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// syslogSeverity returns the syslog severity of level.
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2 // critical
+	}
+}
+
+// syslogWriter writes log into syslog server by RFC5424 format,
+// message is framed by octet-counting(RFC6587) over tcp/unix stream.
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	tag      string
+	msgID    string
+	pid      string
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	stream bool
+	buf    bytes.Buffer
+}
+
+// newSyslogWriter creates the writer of syslog, returns error if facility is unknown or cannot connect syslog.
+func newSyslogWriter(module string, cfg *SyslogOutput) (*syslogWriter, error) {
+	switch cfg.Network {
+	case "", "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unknown syslog network: %q, udp or tcp is available", cfg.Network)
+	}
+	if cfg.Network != "" && cfg.Address == "" {
+		return nil, fmt.Errorf("syslog address is empty")
+	}
+	facility := "local0"
+	if cfg.Facility != "" {
+		facility = strings.ToLower(cfg.Facility)
+	}
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility: %q", cfg.Facility)
+	}
+	hostname, err := hostnameFunc()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	w := &syslogWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: code,
+		hostname: hostname,
+		tag:      syslogHeaderValue(cfg.Tag, processName(), 48),
+		msgID:    syslogHeaderValue(module, "-", 32),
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	if err0 := w.connect(); err0 != nil {
+		return nil, err0
+	}
+	return w, nil
+}
+
+// syslogHeaderValue returns the header value(printable ascii without space) limited by max length.
+func syslogHeaderValue(value, defaultValue string, maxLen int) string {
+	if value == "" {
+		value = defaultValue
+	}
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// connect connects syslog server, tries local syslog sockets if network is empty.
+func (w *syslogWriter) connect() error {
+	if w.network != "" {
+		conn, err := dialFunc(w.network, w.address)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		w.stream = w.network == "tcp" || w.network == "unix"
+		return nil
+	}
+	addresses := localSyslogSockets
+	if w.address != "" {
+		addresses = []string{w.address}
+	}
+	for _, address := range addresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := dialFunc(network, address)
+			if err == nil {
+				w.conn = conn
+				w.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("cannot connect local syslog: %s", strings.Join(addresses, ","))
+}
+
+// write sends the log by RFC5424 format, reconnects once if sends failure.
+func (w *syslogWriter) write(ent zapcore.Entry, line []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf.Reset()
+	_, _ = fmt.Fprintf(&w.buf, "<%d>1 %s %s %s %s %s - ",
+		w.facility*8+syslogSeverity(ent.Level), ent.Time.Format(rfc5424Time), w.hostname, w.tag, w.pid, w.msgID)
+	w.buf.Write(line)
+	msg := w.buf.Bytes()
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return err
+	}
+	return w.send(msg)
+}
+
+// send writes the message into connection, message is framed by octet-counting over stream.
+func (w *syslogWriter) send(msg []byte) error {
+	if w.stream {
+		if _, err := w.conn.Write([]byte(strconv.Itoa(len(msg)) + " ")); err != nil {
+			return err
+		}
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// journaldWriter writes log into systemd-journald by native protocol.
+type journaldWriter struct {
+	identifier string
+
+	mutex sync.Mutex
+	conn  net.Conn
+	buf   bytes.Buffer
+}
+
+// newJournaldWriter creates the writer of journald, returns error if cannot connect journald socket.
+func newJournaldWriter(cfg *JournaldOutput) (*journaldWriter, error) {
+	socket := cfg.Socket
+	if socket == "" {
+		socket = DefaultJournaldSocket
+	}
+	conn, err := dialFunc("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect journald socket: %s, error: %w", socket, err)
+	}
+	identifier := cfg.Identifier
+	if identifier == "" {
+		identifier = processName()
+	}
+	return &journaldWriter{identifier: identifier, conn: conn}, nil
+}
+
+// write sends the log entry as journal fields(MESSAGE/PRIORITY/SYSLOG_IDENTIFIER/CODE_*).
+func (w *journaldWriter) write(ent zapcore.Entry, line []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf.Reset()
+	w.field("MESSAGE", line)
+	w.field("PRIORITY", []byte(strconv.Itoa(syslogSeverity(ent.Level))))
+	w.field("SYSLOG_IDENTIFIER", []byte(w.identifier))
+	w.field("SYSLOG_TIMESTAMP", []byte(ent.Time.Format(time.RFC3339Nano)))
+	if ent.LoggerName != "" {
+		w.field("LOGGER_NAME", []byte(ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		w.field("CODE_FILE", []byte(ent.Caller.File))
+		w.field("CODE_LINE", []byte(strconv.Itoa(ent.Caller.Line)))
+		if ent.Caller.Function != "" {
+			w.field("CODE_FUNC", []byte(ent.Caller.Function))
+		}
+	}
+	_, err := w.conn.Write(w.buf.Bytes())
+	return err
+}
+
+// field appends the journal field, the value with newline is encoded by binary format(name\n<le64 length><value>\n).
+func (w *journaldWriter) field(name string, value []byte) {
+	w.buf.WriteString(name)
+	if bytes.IndexByte(value, '\n') < 0 {
+		w.buf.WriteByte('=')
+		w.buf.Write(value)
+		w.buf.WriteByte('\n')
+		return
+	}
+	w.buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	w.buf.Write(size[:])
+	w.buf.Write(value)
+	w.buf.WriteByte('\n')
+}
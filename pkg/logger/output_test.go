@@ -0,0 +1,258 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// readPacket reads a datagram from conn.
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 64*1024)
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	return string(buf[:n])
+}
+
+func newOutputLogger(t *testing.T, setting Setting) *zap.Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	log, err := InitLogger("output.log", setting, &encoderConfig)
+	assert.NoError(t, err)
+	return log
+}
+
+func TestSyslog_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkSyslog, SinkSyslog}
+	setting.Output.Syslog = SyslogOutput{Network: "udp", Address: conn.LocalAddr().String(), Facility: "DAEMON", Tag: "lind db"}
+	log := newOutputLogger(t, *setting)
+	log.Warn("syslog message", zap.String("k", "v"))
+	msg := readPacket(t, conn)
+	// daemon(3)*8+warning(4)
+	assert.True(t, strings.HasPrefix(msg, "<28>1 "), msg)
+	parts := strings.SplitN(msg, " ", 8)
+	_, err = time.Parse(rfc5424Time, parts[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "lind_db", parts[3])
+	assert.Equal(t, fmt.Sprint(os.Getpid()), parts[4])
+	assert.Equal(t, "-", parts[5])
+	assert.Equal(t, "-", parts[6])
+	assert.Equal(t, "warn\tsyslog message\t{\"k\": \"v\"}", parts[7])
+	assert.NoError(t, log.Sync())
+}
+
+func TestSyslog_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err0 := l.Accept()
+		if err0 != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var size int
+			if _, err0 = fmt.Fscanf(r, "%d ", &size); err0 != nil {
+				return
+			}
+			buf := make([]byte, size)
+			if _, err0 = r.Read(buf); err0 != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkSyslog}
+	setting.ModuleSinks = map[string][]string{"Query": {SinkSyslog}}
+	setting.Output.Syslog = SyslogOutput{Network: "tcp", Address: l.Addr().String()}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	log, err := InitModuleLogger("Query", "output.log", *setting, &encoderConfig)
+	assert.NoError(t, err)
+	log.Error("first")
+	log.Info("second")
+	// local0(16)*8+error(3)
+	assert.Contains(t, <-received, "<131>1 ")
+	msg := <-received
+	assert.True(t, strings.HasPrefix(msg, "<134>1 "), msg)
+	assert.Contains(t, msg, " Query - ")
+}
+
+func TestSyslog_Reconnect(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	w, err := newSyslogWriter("", &SyslogOutput{Network: "udp", Address: conn.LocalAddr().String()})
+	assert.NoError(t, err)
+	_ = w.conn.Close()
+	assert.NoError(t, w.write(zapcore.Entry{Level: zapcore.DebugLevel, Time: time.Now()}, []byte("reconnected")))
+	msg := readPacket(t, conn)
+	assert.True(t, strings.HasPrefix(msg, "<135>1 "), msg)
+	assert.True(t, strings.HasSuffix(msg, "reconnected"), msg)
+
+	defer func() {
+		dialFunc = net.Dial
+	}()
+	_ = w.conn.Close()
+	dialFunc = func(_, _ string) (net.Conn, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, w.write(zapcore.Entry{Level: zapcore.PanicLevel, Time: time.Now()}, []byte("lost")))
+	assert.Nil(t, w.conn)
+}
+
+func TestSyslog_Local(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket")
+	}
+	defer func() {
+		localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+		hostnameFunc = os.Hostname
+	}()
+	socket := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", socket)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	localSyslogSockets = []string{filepath.Join(t.TempDir(), "not-exist"), socket}
+	hostnameFunc = func() (string, error) {
+		return "", fmt.Errorf("err")
+	}
+	w, err := newSyslogWriter("Broker", &SyslogOutput{})
+	assert.NoError(t, err)
+	assert.False(t, w.stream)
+	assert.NoError(t, w.write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now()}, []byte("local")))
+	parts := strings.SplitN(readPacket(t, conn), " ", 8)
+	assert.Equal(t, "-", parts[2])
+	assert.Equal(t, "Broker", parts[5])
+
+	localSyslogSockets = []string{filepath.Join(t.TempDir(), "not-exist")}
+	_, err = newSyslogWriter("", &SyslogOutput{})
+	assert.Error(t, err)
+}
+
+func TestSyslog_Config(t *testing.T) {
+	for _, cfg := range []SyslogOutput{
+		{Network: "http", Address: "localhost:514"},
+		{Network: "udp"},
+		{Network: "udp", Address: "localhost:514", Facility: "unknown"},
+	} {
+		_, err := newSyslogWriter("", &cfg)
+		assert.Error(t, err)
+	}
+	// cannot connect
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkSyslog}
+	setting.Output.Syslog = SyslogOutput{Network: "tcp", Address: "127.0.0.1:1"}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	log, err := InitLogger("output.log", *setting, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	assert.Equal(t, "-", syslogHeaderValue("", "-", 32))
+	assert.Equal(t, "a_b_c", syslogHeaderValue("a b\tc", "-", 32))
+	assert.Equal(t, "abc", syslogHeaderValue("abcdef", "-", 3))
+	_, err = newOutputCore("not-exist", "", setting, nil, RunningAtomicLevel)
+	assert.Error(t, err)
+}
+
+func TestJournald(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket")
+	}
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenPacket("unixgram", socket)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	setting := NewDefaultSetting()
+	setting.Dir = t.TempDir()
+	setting.Sinks = []string{SinkFile, SinkJournald}
+	setting.Output.Journald = JournaldOutput{Socket: socket, Identifier: "lind"}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	encoderConfig.StacktraceKey = ""
+	log, err := InitLogger("output.log", *setting, &encoderConfig, zap.AddCaller())
+	assert.NoError(t, err)
+	log = log.Named("test").With(zap.String("k", "v"))
+	log.Error("journald message")
+	fields := readPacket(t, conn)
+	assert.True(t, strings.HasPrefix(fields, "MESSAGE=error\ttest\t"), fields)
+	assert.Contains(t, fields, "journald message\t{\"k\": \"v\"}\n")
+	assert.Contains(t, fields, "\nPRIORITY=3\n")
+	assert.Contains(t, fields, "\nSYSLOG_IDENTIFIER=lind\n")
+	assert.Contains(t, fields, "\nLOGGER_NAME=test\n")
+	assert.Contains(t, fields, "\nCODE_FILE=")
+	assert.Contains(t, fields, "\nCODE_FUNC=github.com/lindb/common/pkg/logger.TestJournald\n")
+	// file sink
+	data, err := os.ReadFile(filepath.Join(setting.Dir, "output.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "journald message")
+
+	// multi-line message is encoded by binary format
+	w, err := newJournaldWriter(&JournaldOutput{Socket: socket})
+	assert.NoError(t, err)
+	assert.NoError(t, w.write(zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now()}, []byte("line1\nline2")))
+	fields = readPacket(t, conn)
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, 11)
+	assert.True(t, strings.HasPrefix(fields, "MESSAGE\n"+string(size)+"line1\nline2\n"), fields)
+	assert.Contains(t, fields, "\nSYSLOG_IDENTIFIER="+processName()+"\n")
+	assert.NotContains(t, fields, "CODE_FILE")
+
+	_, err = newJournaldWriter(&JournaldOutput{Socket: filepath.Join(t.TempDir(), "not-exist")})
+	assert.Error(t, err)
+}
+
+func TestSetting_TOML_Output(t *testing.T) {
+	setting := NewDefaultSetting()
+	setting.Output.Syslog = SyslogOutput{Network: "udp", Address: "log:514", Facility: "local3", Tag: "lind"}
+	setting.Output.Journald.Identifier = "broker"
+	decoded := &struct {
+		Logging Setting `toml:"logging"`
+	}{}
+	_, err := toml.Decode(setting.TOML("LINDB"), decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, setting.Output, decoded.Logging.Output)
+	assert.Equal(t, "local0", NewDefaultSetting().Output.Syslog.Facility)
+}
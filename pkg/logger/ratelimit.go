@@ -0,0 +1,207 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// for testing
+var rateLimitNowFunc = time.Now
+
+// RateLimiter limits the logs per call-site, the suppressed count is appended to the next emitted message.
+// Panic/Fatal logs are never limited.
+type RateLimiter struct {
+	interval time.Duration
+	n        int64
+	sites    sync.Map // call-site pc => *callSite
+}
+
+// Every returns a rate limiter which emits at most one message per interval per call-site.
+func Every(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// EveryN returns a rate limiter which emits the first message of every n messages per call-site.
+func EveryN(n int) *RateLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return &RateLimiter{n: int64(n)}
+}
+
+// Wrap returns a logger which is limited by the rate limiter, the loggers wrapped by the same
+// rate limiter share the state of call-site.
+func (r *RateLimiter) Wrap(log Logger) Logger {
+	if l, ok := log.(*logger); ok {
+		// skip the frame of rate limited logger when adding caller
+		child := *l
+		child.log = l.log.WithOptions(zap.AddCallerSkip(1))
+		log = &child
+	}
+	return &rateLimitedLogger{Logger: log, limiter: r}
+}
+
+// callSite records the state of call-site.
+type callSite struct {
+	mutex      sync.Mutex
+	last       time.Time
+	count      int64
+	suppressed int64
+}
+
+// allow returns if the message of the call-site(the caller of rate limited logger) can be emitted,
+// and the count of suppressed messages since last emitted.
+func (r *RateLimiter) allow() (ok bool, suppressed int64) {
+	var pcs [1]uintptr
+	// skip runtime.Callers, allow and the method of rate limited logger
+	runtime.Callers(3, pcs[:])
+	site, _ := r.sites.LoadOrStore(pcs[0], &callSite{})
+	return site.(*callSite).allow(r)
+}
+
+// allow returns if the message can be emitted based on the interval/n of rate limiter.
+func (s *callSite) allow(r *RateLimiter) (ok bool, suppressed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.n > 0 {
+		ok = s.count%r.n == 0
+		s.count++
+	} else {
+		now := rateLimitNowFunc()
+		ok = s.last.IsZero() || now.Sub(s.last) >= r.interval
+		if ok {
+			s.last = now
+		}
+	}
+	if !ok {
+		s.suppressed++
+		return false, 0
+	}
+	suppressed = s.suppressed
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// suppressedMsg appends the suppressed count to msg if suppressed.
+func suppressedMsg(msg string, suppressed int64) string {
+	if suppressed == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s (suppressed %d similar messages)", msg, suppressed)
+}
+
+// rateLimitedLogger emits the logs of the wrapped logger at most once per window per call-site.
+type rateLimitedLogger struct {
+	Logger
+	limiter *RateLimiter
+}
+
+// With returns a child logger with the accumulated fields, which shares the rate limiter.
+func (l *rateLimitedLogger) With(fields ...zap.Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return &rateLimitedLogger{Logger: l.Logger.With(fields...), limiter: l.limiter}
+}
+
+// Debug logs a message at DebugLevel if it's not limited.
+func (l *rateLimitedLogger) Debug(msg string, fields ...zap.Field) {
+	if !l.Enabled(DebugLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Debug(suppressedMsg(msg, suppressed), fields...)
+	}
+}
+
+// Info logs a message at InfoLevel if it's not limited.
+func (l *rateLimitedLogger) Info(msg string, fields ...zap.Field) {
+	if !l.Enabled(InfoLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Info(suppressedMsg(msg, suppressed), fields...)
+	}
+}
+
+// Warn logs a message at WarnLevel if it's not limited.
+func (l *rateLimitedLogger) Warn(msg string, fields ...zap.Field) {
+	if !l.Enabled(WarnLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Warn(suppressedMsg(msg, suppressed), fields...)
+	}
+}
+
+// Error logs a message at ErrorLevel if it's not limited.
+func (l *rateLimitedLogger) Error(msg string, fields ...zap.Field) {
+	if !l.Enabled(ErrorLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Error(suppressedMsg(msg, suppressed), fields...)
+	}
+}
+
+// Debugf formats the message according to template then logs it at DebugLevel if it's not limited.
+func (l *rateLimitedLogger) Debugf(template string, args ...any) {
+	if !l.Enabled(DebugLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Debug(suppressedMsg(fmt.Sprintf(template, args...), suppressed))
+	}
+}
+
+// Infof formats the message according to template then logs it at InfoLevel if it's not limited.
+func (l *rateLimitedLogger) Infof(template string, args ...any) {
+	if !l.Enabled(InfoLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Info(suppressedMsg(fmt.Sprintf(template, args...), suppressed))
+	}
+}
+
+// Warnf formats the message according to template then logs it at WarnLevel if it's not limited.
+func (l *rateLimitedLogger) Warnf(template string, args ...any) {
+	if !l.Enabled(WarnLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Warn(suppressedMsg(fmt.Sprintf(template, args...), suppressed))
+	}
+}
+
+// Errorf formats the message according to template then logs it at ErrorLevel if it's not limited.
+func (l *rateLimitedLogger) Errorf(template string, args ...any) {
+	if !l.Enabled(ErrorLevel) {
+		return
+	}
+	if ok, suppressed := l.limiter.allow(); ok {
+		l.Logger.Error(suppressedMsg(fmt.Sprintf(template, args...), suppressed))
+	}
+}
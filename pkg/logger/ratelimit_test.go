@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger(level zapcore.Level) (Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	return &logger{log: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)), ignoreModuleAndRole: true}, logs
+}
+
+func TestRateLimiter_EveryN(t *testing.T) {
+	log, logs := newObservedLogger(zapcore.InfoLevel)
+	log = EveryN(3).Wrap(log)
+	for i := 0; i < 7; i++ {
+		log.Error("write failure", Int("i", i))
+	}
+	for i := 0; i < 4; i++ {
+		log.Warnf("warn %d", i)
+	}
+	// disabled level isn't counted
+	log.Debug("debug")
+	log.Debugf("debug")
+	msgs := logs.AllUntimed()
+	assert.Len(t, msgs, 5)
+	assert.Equal(t, "write failure", msgs[0].Message)
+	assert.Equal(t, "write failure (suppressed 2 similar messages)", msgs[1].Message)
+	assert.Equal(t, int64(3), msgs[1].ContextMap()["i"])
+	assert.Equal(t, "write failure (suppressed 2 similar messages)", msgs[2].Message)
+	assert.Equal(t, "warn 0", msgs[3].Message)
+	assert.Equal(t, "warn 3 (suppressed 2 similar messages)", msgs[4].Message)
+	// caller is the call-site of rate limited logger
+	assert.Contains(t, msgs[0].Caller.File, "ratelimit_test.go")
+
+	assert.Equal(t, int64(1), EveryN(0).n)
+}
+
+func TestRateLimiter_Every(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rateLimitNowFunc = func() time.Time { return now }
+	defer func() {
+		rateLimitNowFunc = time.Now
+	}()
+	log, logs := newObservedLogger(zapcore.DebugLevel)
+	limiter := Every(time.Minute)
+	log = limiter.Wrap(log)
+	child := log.With(String("db", "test"))
+	assert.Equal(t, child, child.With())
+
+	logAll := func() {
+		log.Debug("debug")
+		log.Info("info")
+		log.Debugf("debug %s", "f")
+		log.Infof("info %s", "f")
+		log.Errorf("error %s", "f")
+		child.Warn("warn")
+	}
+	logAll()
+	assert.Equal(t, 6, logs.Len())
+	now = now.Add(30 * time.Second)
+	logAll()
+	logAll()
+	assert.Equal(t, 6, logs.Len())
+	now = now.Add(30 * time.Second)
+	logAll()
+	msgs := logs.AllUntimed()
+	assert.Len(t, msgs, 12)
+	assert.Equal(t, "debug (suppressed 2 similar messages)", msgs[6].Message)
+	assert.Equal(t, "error f (suppressed 2 similar messages)", msgs[10].Message)
+	assert.Equal(t, "warn (suppressed 2 similar messages)", msgs[11].Message)
+	assert.Equal(t, "test", msgs[11].ContextMap()["db"])
+	// panic isn't limited
+	assert.Panics(t, func() { log.Panic("panic") })
+	assert.Panics(t, func() { log.Panic("panic") })
+}
@@ -0,0 +1,172 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue is the mask of sensitive value.
+const RedactedValue = "******"
+
+// DefaultRedactKeys are the default key patterns of sensitive fields.
+var DefaultRedactKeys = []string{"password", "passwd", "token", "secret"}
+
+// Redactor masks the sensitive values which key contains any key pattern(case-insensitive), include:
+//   - the value of field which key matches, e.g. zap.String("dbPassword", "123")
+//   - the value of key/value pair in message or string field, e.g. password=123, token: abc, "secret":"xyz"
+type Redactor struct {
+	keys    []string
+	pattern *regexp.Regexp
+}
+
+// NewRedactor creates a redactor with key patterns, returns nil if no valid pattern.
+func NewRedactor(keys ...string) *Redactor {
+	r := &Redactor{}
+	var quoted []string
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		r.keys = append(r.keys, key)
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	if len(r.keys) == 0 {
+		return nil
+	}
+	// key(with prefix/suffix) + separator(=, :, ":) + optional quote + value
+	r.pattern = regexp.MustCompile(`(?i)([\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*"?\s*[=:]\s*"?)([^&\s",;'}]+)`)
+	return r
+}
+
+// MatchKey returns if the key contains any key pattern.
+func (r *Redactor) MatchKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keys {
+		if strings.Contains(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks the values of sensitive key/value pairs in s.
+func (r *Redactor) RedactString(s string) string {
+	if !r.maybeSensitive(s) {
+		return s
+	}
+	return r.pattern.ReplaceAllString(s, "${1}"+RedactedValue)
+}
+
+// maybeSensitive returns if s may contain any key pattern, avoids regexp matching for most logs.
+func (r *Redactor) maybeSensitive(s string) bool {
+	if !strings.ContainsAny(s, "=:") {
+		return false
+	}
+	return r.MatchKey(s)
+}
+
+// RedactFields masks the sensitive fields, returns fields as is if nothing is masked, else a copy.
+func (r *Redactor) RedactFields(fields []zap.Field) []zap.Field {
+	var redacted []zap.Field
+	for i := range fields {
+		field, ok := r.redactField(fields[i])
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]zap.Field(nil), fields...)
+		}
+		redacted[i] = field
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// redactField returns the masked field, returns false if the field isn't sensitive.
+func (r *Redactor) redactField(field zap.Field) (zap.Field, bool) {
+	switch {
+	case field.Type == zapcore.SkipType:
+		return field, false
+	case r.MatchKey(field.Key) && field.Type != zapcore.NamespaceType:
+		return zap.String(field.Key, RedactedValue), true
+	case field.Type == zapcore.StringType:
+		if value := r.RedactString(field.String); value != field.String {
+			return zap.String(field.Key, value), true
+		}
+	case field.Type == zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			if msg := err.Error(); r.maybeSensitive(msg) {
+				if value := r.RedactString(msg); value != msg {
+					return zap.String(field.Key, value), true
+				}
+			}
+		}
+	}
+	return field, false
+}
+
+// redactCore masks the sensitive values of message and fields before writing into the wrapped core(all sinks).
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewRedactCore returns a core which masks the sensitive values by key patterns(see Redactor),
+// returns core as is if no valid pattern.
+func NewRedactCore(core zapcore.Core, keys ...string) zapcore.Core {
+	redactor := NewRedactor(keys...)
+	if redactor == nil {
+		return core
+	}
+	return &redactCore{Core: core, redactor: redactor}
+}
+
+// WithRedaction returns a zap option which wraps the core of logger by redaction core.
+func WithRedaction(keys ...string) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewRedactCore(core, keys...)
+	})
+}
+
+// With masks the sensitive context fields, then adds them into the wrapped core.
+func (c *redactCore) With(fields []zap.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.RedactFields(fields)), redactor: c.redactor}
+}
+
+// Check adds the redaction core into checked entry if the level is enabled,
+// so that the message and fields are masked before writing.
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write masks the message and fields, then writes them into the wrapped core.
+func (c *redactCore) Write(ent zapcore.Entry, fields []zap.Field) error {
+	ent.Message = c.redactor.RedactString(ent.Message)
+	return c.Core.Write(ent, c.redactor.RedactFields(fields))
+}
@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactor_RedactString(t *testing.T) {
+	assert.Nil(t, NewRedactor())
+	assert.Nil(t, NewRedactor(" ", ""))
+	r := NewRedactor(DefaultRedactKeys...)
+	cases := []struct {
+		in, out string
+	}{
+		{in: "no sensitive value", out: "no sensitive value"},
+		{in: "password reset", out: "password reset"},
+		{in: "GET /login?user=admin&password=admin123 HTTP/1.1", out: "GET /login?user=admin&password=****** HTTP/1.1"},
+		{in: "POST /api?access_token=abc.def-1&x=1", out: "POST /api?access_token=******&x=1"},
+		{in: `{"username": "admin", "Password": "admin123"}`, out: `{"username": "admin", "Password": "******"}`},
+		{in: "db secret: s3cr3t, user: root", out: "db secret: ******, user: root"},
+		{in: "TOKEN=a;PASSWD=b", out: "TOKEN=******;PASSWD=******"},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.out, r.RedactString(tt.in), tt.in)
+	}
+	assert.True(t, r.MatchKey("dbPassword"))
+	assert.False(t, r.MatchKey("user"))
+}
+
+func TestRedactor_RedactFields(t *testing.T) {
+	r := NewRedactor("password", "token")
+	fields := []zap.Field{zap.String("user", "admin"), zap.Int("n", 1)}
+	assert.Equal(t, fields, r.RedactFields(fields))
+
+	fields = []zap.Field{
+		zap.String("user", "admin"),
+		zap.String("dbPassword", "123"),
+		zap.Int("token", 1),
+		zap.String("url", "http://host?token=abc"),
+		zap.Error(errors.New("login failure, password=123")),
+		zap.Error(errors.New("no sensitive: value")),
+		zap.Namespace("tokens"),
+		zap.Skip(),
+	}
+	redacted := r.RedactFields(fields)
+	assert.Equal(t, zap.String("dbPassword", RedactedValue), redacted[1])
+	assert.Equal(t, zap.String("token", RedactedValue), redacted[2])
+	assert.Equal(t, zap.String("url", "http://host?token="+RedactedValue), redacted[3])
+	assert.Equal(t, zap.String("error", "login failure, password="+RedactedValue), redacted[4])
+	assert.Equal(t, fields[5], redacted[5])
+	assert.Equal(t, fields[6], redacted[6])
+	assert.Equal(t, fields[7], redacted[7])
+	// origin fields aren't modified
+	assert.Equal(t, zap.String("dbPassword", "123"), fields[1])
+}
+
+func TestRedactCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	assert.Equal(t, core, NewRedactCore(core))
+
+	log := zap.New(core, WithRedaction(DefaultRedactKeys...)).With(zap.String("secret", "s"))
+	log.Debug("password=1")
+	log.Info("login user=admin password=admin123", zap.String("token", "abc"))
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "login user=admin password="+RedactedValue, entries[0].Message)
+	assert.Equal(t, map[string]any{"secret": RedactedValue, "token": RedactedValue}, entries[0].ContextMap())
+}
+
+func Test_InitLogger_Redact(t *testing.T) {
+	setting := NewDefaultSetting()
+	setting.Dir = t.TempDir()
+	setting.Sinks = []string{SinkFile}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	log, err := InitLogger("redact.log", *setting, &encoderConfig)
+	assert.NoError(t, err)
+	log.Info("connect db, password=123", zap.String("token", "abc"))
+	assert.NoError(t, log.Sync())
+	data, err := os.ReadFile(filepath.Join(setting.Dir, "redact.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "password=******")
+	assert.NotContains(t, string(data), "123")
+	assert.NotContains(t, string(data), "abc")
+}
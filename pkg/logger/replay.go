@@ -0,0 +1,188 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// for testing
+var openFileFunc = os.OpenFile
+
+// ReplayBuffer keeps the last N log records of all levels(even below the running level) in memory,
+// then dumps them into the crash file on panic/fatal, so post-mortem has debug context
+// without running at debug level.
+type ReplayBuffer struct {
+	crashFile string
+	records   [][]byte // ring of encoded records
+	next      int
+	full      bool
+
+	lock sync.Mutex
+}
+
+// NewReplayBuffer creates a replay buffer which keeps the last size records,
+// records are dumped into stderr if crash file is empty.
+func NewReplayBuffer(size int, crashFile string) *ReplayBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &ReplayBuffer{
+		crashFile: crashFile,
+		records:   make([][]byte, size),
+	}
+}
+
+// WithReplayBuffer returns a zap option which tees all log records into the replay buffer.
+func WithReplayBuffer(buffer *ReplayBuffer) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, buffer.Core())
+	})
+}
+
+// Core returns a zap core which records all log records into the replay buffer,
+// records are dumped if the level >= DPanicLevel.
+func (b *ReplayBuffer) Core() zapcore.Core {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = SimpleTimeEncoder
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	return &replayCore{
+		buffer:  b,
+		encoder: zapcore.NewConsoleEncoder(encoderConfig),
+	}
+}
+
+// Records returns the copy of kept records, oldest first.
+func (b *ReplayBuffer) Records() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var rs []string
+	if b.full {
+		for _, record := range b.records[b.next:] {
+			rs = append(rs, string(record))
+		}
+	}
+	for _, record := range b.records[:b.next] {
+		rs = append(rs, string(record))
+	}
+	return rs
+}
+
+// Dump writes the kept records with the reason into the crash file.
+func (b *ReplayBuffer) Dump(reason string) error {
+	records := b.Records()
+	var (
+		w    io.StringWriter = os.Stderr
+		file *os.File
+	)
+	if b.crashFile != "" {
+		f, err := openFileFunc(b.crashFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("open crash file: %s failure: %w", b.crashFile, err)
+		}
+		defer f.Close()
+		w, file = f, f
+	}
+	if _, err := w.WriteString(fmt.Sprintf("==== crash dump at %s, reason: %s, records: %d ====\n",
+		time.Now().Format("2006-01-02 15:04:05.000"), reason, len(records))); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if _, err := w.WriteString(record); err != nil {
+			return err
+		}
+	}
+	if file != nil {
+		return file.Sync()
+	}
+	return nil
+}
+
+// DumpOnPanic dumps the kept records if the goroutine is panicking, then re-panics,
+// it should be deferred at the entry of goroutine.
+func (b *ReplayBuffer) DumpOnPanic() {
+	if r := recover(); r != nil {
+		_ = b.Dump(fmt.Sprintf("panic: %v", r))
+		panic(r)
+	}
+}
+
+// append copies the encoded record into the ring.
+func (b *ReplayBuffer) append(record []byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.records[b.next] = append(b.records[b.next][:0], record...)
+	b.next++
+	if b.next == len(b.records) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// replayCore implements zapcore.Core which writes records of all levels into replay buffer.
+type replayCore struct {
+	buffer  *ReplayBuffer
+	encoder zapcore.Encoder
+}
+
+// Enabled returns true for all levels.
+func (c *replayCore) Enabled(_ zapcore.Level) bool {
+	return true
+}
+
+// With adds structured context to the core.
+func (c *replayCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for idx := range fields {
+		fields[idx].AddTo(encoder)
+	}
+	return &replayCore{buffer: c.buffer, encoder: encoder}
+}
+
+// Check adds the core to checked entry for all levels.
+func (c *replayCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(entry, c)
+}
+
+// Write encodes the record into replay buffer, dumps the buffer if level >= DPanicLevel.
+func (c *replayCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	c.buffer.append(buf.Bytes())
+	buf.Free()
+	if entry.Level >= zapcore.DPanicLevel {
+		return c.buffer.Dump(fmt.Sprintf("%s: %s", entry.Level.CapitalString(), entry.Message))
+	}
+	return nil
+}
+
+// Sync does nothing, records are kept in memory.
+func (c *replayCore) Sync() error {
+	return nil
+}
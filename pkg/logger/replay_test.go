@@ -0,0 +1,96 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	crashFile := filepath.Join(t.TempDir(), "crash.log")
+	buffer := NewReplayBuffer(3, crashFile)
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := zap.New(core, WithReplayBuffer(buffer)).With(zap.String("module", "test"))
+
+	log.Debug("debug-1")
+	assert.Len(t, buffer.Records(), 1)
+	assert.Zero(t, logs.Len())
+	assert.Contains(t, buffer.Records()[0], "DEBUG\tdebug-1\t{\"module\": \"test\"}")
+
+	for i := 2; i <= 4; i++ {
+		log.Info(fmt.Sprintf("info-%d", i))
+	}
+	records := buffer.Records()
+	assert.Len(t, records, 3)
+	assert.Contains(t, records[0], "info-2")
+	assert.Contains(t, records[2], "info-4")
+	assert.Equal(t, 3, logs.Len())
+
+	assert.Panics(t, func() {
+		log.Panic("panic-5")
+	})
+	data, err := os.ReadFile(crashFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "reason: PANIC: panic-5, records: 3")
+	assert.Contains(t, string(data), "info-3")
+	assert.Contains(t, string(data), "panic-5")
+	assert.NotContains(t, string(data), "info-2")
+	assert.NoError(t, buffer.Core().Sync())
+}
+
+func TestReplayBuffer_DumpOnPanic(t *testing.T) {
+	crashFile := filepath.Join(t.TempDir(), "crash.log")
+	buffer := NewReplayBuffer(0, crashFile)
+	log := zap.New(zapcore.NewNopCore(), WithReplayBuffer(buffer))
+	log.Debug("before panic")
+
+	assert.Panics(t, func() {
+		defer buffer.DumpOnPanic()
+		panic("oops")
+	})
+	data, err := os.ReadFile(crashFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "reason: panic: oops, records: 1")
+	assert.Contains(t, string(data), "before panic")
+
+	// no panic
+	assert.NotPanics(t, func() {
+		defer buffer.DumpOnPanic()
+	})
+}
+
+func TestReplayBuffer_Dump(t *testing.T) {
+	defer func() {
+		openFileFunc = os.OpenFile
+	}()
+	// dump into stderr
+	assert.NoError(t, NewReplayBuffer(1, "").Dump("test"))
+
+	openFileFunc = func(_ string, _ int, _ os.FileMode) (*os.File, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, NewReplayBuffer(1, "crash.log").Dump("test"))
+}
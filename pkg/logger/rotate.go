@@ -0,0 +1,211 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// RotateDaily rotates the log file at local midnight, backup file is named as name-2006-01-02.ext.
+	RotateDaily = "daily"
+	// RotateHourly rotates the log file at the beginning of local hour, backup file is named as name-2006-01-02T15.ext.
+	RotateHourly = "hourly"
+
+	dailyPattern  = "2006-01-02"
+	hourlyPattern = "2006-01-02T15"
+)
+
+// for testing
+var (
+	nowFunc    = time.Now
+	renameFunc = os.Rename
+)
+
+// timeRotateWriter rotates the log file by time(daily/hourly) besides the size based rotation of lumberjack,
+// the time based backups are retained by both age(MaxAge days) and count(MaxBackups).
+type timeRotateWriter struct {
+	lj       *lumberjack.Logger
+	interval string
+	pattern  string
+	loc      *time.Location // the location of period boundary, local by default
+	period   time.Time      // the period of current log file
+	errOut   io.Writer      // reports the rotation failure, stderr by default
+
+	lock sync.Mutex
+}
+
+// newTimeRotateWriter creates a time based rotate writer, the period of existing log file is taken from its mod time.
+func newTimeRotateWriter(lj *lumberjack.Logger, interval string) (*timeRotateWriter, error) {
+	w := &timeRotateWriter{lj: lj, interval: interval, loc: time.Local, errOut: os.Stderr}
+	switch interval {
+	case RotateDaily:
+		w.pattern = dailyPattern
+	case RotateHourly:
+		w.pattern = hourlyPattern
+	default:
+		return nil, fmt.Errorf("unknown rotate interval: %q, daily or hourly is available", interval)
+	}
+	w.period = w.periodOf(nowFunc())
+	if stat, err := os.Stat(lj.Filename); err == nil {
+		w.period = w.periodOf(stat.ModTime())
+	}
+	return w, nil
+}
+
+// Write writes the log into current file, rotates the file first if the period is passed.
+// The log is still written if rotation fails, the rotation failure is counted in logging statistics
+// and reported into error output instead of returned, because the log is written completely.
+func (w *timeRotateWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if period := w.periodOf(nowFunc()); period.After(w.period) {
+		if err := w.rotate(); err != nil {
+			loggingStats.rotateErrors.Add(1)
+			_, _ = fmt.Fprintf(w.errOut, "%s rotate log file: %s failure: %v\n",
+				nowFunc().Format(time.RFC3339), w.lj.Filename, err)
+		}
+		w.period = period
+	}
+	return w.lj.Write(p)
+}
+
+// Close closes the current log file.
+func (w *timeRotateWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.lj.Close()
+}
+
+// rotate renames the current file with the timestamp of its period, then removes the expired backups.
+func (w *timeRotateWriter) rotate() error {
+	if err := w.lj.Close(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.lj.Filename); err != nil {
+		if os.IsNotExist(err) {
+			return w.cleanup()
+		}
+		return err
+	}
+	backup := w.backupName(w.period)
+	if _, err := os.Stat(backup); err == nil {
+		// backup of the period exists(e.g. clock goes back), fallback to size based backup naming
+		if err = w.lj.Rotate(); err != nil {
+			return err
+		}
+	} else if err = renameFunc(w.lj.Filename, backup); err != nil {
+		return err
+	}
+	return w.cleanup()
+}
+
+// cleanup removes the time based backups which are older than MaxAge days or exceed MaxBackups.
+func (w *timeRotateWriter) cleanup() error {
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+	// newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].period.After(backups[j].period)
+	})
+	var cutoff time.Time
+	if w.lj.MaxAge > 0 {
+		cutoff = nowFunc().Add(-time.Duration(w.lj.MaxAge) * 24 * time.Hour)
+	}
+	var errs []string
+	for i, b := range backups {
+		expired := (w.lj.MaxBackups > 0 && i >= w.lj.MaxBackups) || (!cutoff.IsZero() && b.period.Before(cutoff))
+		if !expired {
+			continue
+		}
+		if err = os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("remove log backups failure: %s", strings.Join(errs, ","))
+	}
+	return nil
+}
+
+// timeBackup represents a time based backup file.
+type timeBackup struct {
+	path   string
+	period time.Time
+}
+
+// backups returns the time based backups of the log file.
+func (w *timeRotateWriter) backups() ([]timeBackup, error) {
+	dir := filepath.Dir(w.lj.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := w.prefixAndExt()
+	var backups []timeBackup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ts := name[len(prefix) : len(name)-len(ext)]
+		period, err0 := time.ParseInLocation(w.pattern, ts, w.loc)
+		if err0 != nil {
+			// not time based backup(e.g. size based backup of lumberjack)
+			continue
+		}
+		backups = append(backups, timeBackup{path: filepath.Join(dir, name), period: period})
+	}
+	return backups, nil
+}
+
+// backupName returns the backup file name of the period, e.g. /dir/lind-2006-01-02.log.
+func (w *timeRotateWriter) backupName(period time.Time) string {
+	prefix, ext := w.prefixAndExt()
+	return filepath.Join(filepath.Dir(w.lj.Filename), prefix+period.Format(w.pattern)+ext)
+}
+
+// prefixAndExt returns the file name prefix(with dash) and extension of the log file.
+func (w *timeRotateWriter) prefixAndExt() (prefix, ext string) {
+	name := filepath.Base(w.lj.Filename)
+	ext = filepath.Ext(name)
+	return name[:len(name)-len(ext)] + "-", ext
+}
+
+// periodOf returns the beginning of local day/hour which t belongs to.
+func (w *timeRotateWriter) periodOf(t time.Time) time.Time {
+	t = t.In(w.loc)
+	y, m, d := t.Date()
+	if w.interval == RotateHourly {
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, w.loc)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, w.loc)
+}
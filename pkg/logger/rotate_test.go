@@ -0,0 +1,186 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+func listFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTimeRotateWriter_Daily(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return now }
+	lj := &lumberjack.Logger{Filename: filepath.Join(dir, "lind.log"), MaxBackups: 2}
+	w, err := newTimeRotateWriter(lj, RotateDaily)
+	assert.NoError(t, err)
+	w.loc = time.UTC
+	w.period = w.periodOf(now)
+	defer func() {
+		_ = w.Close()
+	}()
+
+	_, err = w.Write([]byte("day1\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lind.log"}, listFiles(t, dir))
+
+	for i := 1; i <= 3; i++ {
+		now = now.Add(24 * time.Hour)
+		_, err = w.Write([]byte(fmt.Sprintf("day%d\n", i+1)))
+		assert.NoError(t, err)
+	}
+	// retain 2 backups
+	assert.Equal(t, []string{"lind-2024-03-11.log", "lind-2024-03-12.log", "lind.log"}, listFiles(t, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "lind-2024-03-12.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "day3\n", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "lind.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "day4\n", string(data))
+
+	// same period, no rotation
+	now = now.Add(time.Second)
+	_, err = w.Write([]byte("day4\n"))
+	assert.NoError(t, err)
+	assert.Len(t, listFiles(t, dir), 3)
+}
+
+func TestTimeRotateWriter_Hourly(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return now }
+	// size based backup is ignored by time based retention(lumberjack retains it by wall clock)
+	sizeBackup := "lind-" + time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15-04-05.000") + ".log"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, sizeBackup), []byte("size"), 0o600))
+	// expired by max age
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lind-2024-03-01T10.log"), []byte("old"), 0o600))
+	lj := &lumberjack.Logger{Filename: filepath.Join(dir, "lind.log"), MaxAge: 1}
+	w, err := newTimeRotateWriter(lj, RotateHourly)
+	assert.NoError(t, err)
+	w.loc = time.UTC
+	w.period = w.periodOf(now)
+	defer func() {
+		_ = w.Close()
+	}()
+
+	_, err = w.Write([]byte("hour10\n"))
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = w.Write([]byte("hour11\n"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{sizeBackup, "lind-2024-03-10T10.log", "lind.log"}, listFiles(t, dir))
+
+	// backup of the period exists, fallback to size based backup
+	now = now.Add(-time.Hour)
+	w.period = w.periodOf(now)
+	now = now.Add(time.Hour)
+	_, err = w.Write([]byte("hour11\n"))
+	assert.NoError(t, err)
+	assert.Len(t, listFiles(t, dir), 4)
+}
+
+func TestTimeRotateWriter_Failure(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+		renameFunc = os.Rename
+	}()
+	w, err := newTimeRotateWriter(&lumberjack.Logger{}, "weekly")
+	assert.Error(t, err)
+	assert.Nil(t, w)
+
+	dir := t.TempDir()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+	w, err = newTimeRotateWriter(&lumberjack.Logger{Filename: filepath.Join(dir, "lind.log")}, RotateDaily)
+	assert.NoError(t, err)
+	defer func() {
+		_ = w.Close()
+	}()
+	// no log file when rotating
+	now = now.Add(24 * time.Hour)
+	_, err = w.Write([]byte("1\n"))
+	assert.NoError(t, err)
+
+	// rename failure, log is still written, the failure is counted and reported
+	loggingStats.reset()
+	defer loggingStats.reset()
+	errOut := &bytes.Buffer{}
+	w.errOut = errOut
+	renameFunc = func(_, _ string) error {
+		return fmt.Errorf("err")
+	}
+	now = now.Add(24 * time.Hour)
+	n, err := w.Write([]byte("2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(1), GetStats().RotateErrors)
+	assert.Contains(t, errOut.String(), "rotate log file")
+	data, err := os.ReadFile(filepath.Join(dir, "lind.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(data))
+
+	// existing log file, period is taken from mod time
+	assert.NoError(t, w.Close())
+	w, err = newTimeRotateWriter(&lumberjack.Logger{Filename: filepath.Join(dir, "lind.log")}, RotateDaily)
+	assert.NoError(t, err)
+	stat, err := os.Stat(filepath.Join(dir, "lind.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, w.periodOf(stat.ModTime()), w.period)
+}
+
+func Test_InitLogger_RotateInterval(t *testing.T) {
+	dir := t.TempDir()
+	encoderConfig := zap.NewProductionEncoderConfig()
+	log, err := InitLogger("rotate.log", Setting{Dir: dir, Level: "info", RotateInterval: "weekly"}, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	log, err = InitLogger("rotate.log", Setting{Dir: dir, Level: "info", RotateInterval: RotateHourly}, &encoderConfig)
+	assert.NoError(t, err)
+	log.Info("rotate message")
+	assert.NoError(t, log.Sync())
+	data, err := os.ReadFile(filepath.Join(dir, "rotate.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "rotate message")
+}
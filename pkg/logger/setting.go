@@ -18,13 +18,14 @@
 package logger
 
 import (
+	"fmt"
 	"os"
-	"path/filepath"
 	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/lindb/common/pkg/ltoml"
 )
 
 type log struct {
@@ -51,9 +52,16 @@ func init() {
 	// get log level from evn
 	level := os.Getenv("LOG_LEVEL")
 	initLogLevel(level)
+	// warn the deprecated config keys using logger
+	ltoml.SetDeprecationWarner(func(msg string) {
+		GetLogger("Config", "Migration").Warn(msg)
+	})
 }
 
 func RegisterLogger(module string, logger *zap.Logger, ignoreModuleAndRole bool) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
 	loggers[module] = &log{
 		logger:              logger,
 		ignoreModuleAndRole: ignoreModuleAndRole,
@@ -78,7 +86,8 @@ func newDefaultLogger() *zap.Logger {
 		zapcore.NewConsoleEncoder(encoderConfig),
 		os.Stdout,
 		RunningAtomicLevel)
-	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))
+	stacktraceLevel, _ := parseStacktraceLevel(DefaultStacktraceLevel)
+	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(callerSkip), zap.AddStacktrace(stacktraceLevel))
 }
 
 func IsDebug() bool {
@@ -99,7 +108,7 @@ func GetLogger(module, role string) Logger {
 	}
 	var zapLogger *zap.Logger
 	ignoreModuleAndRole := false
-	log, ok := loggers[module]
+	log, ok := discoverModule(module, role)
 	if !ok {
 		defaultLog := DefaultLogger.Load()
 		if defaultLog != nil {
@@ -112,42 +121,114 @@ func GetLogger(module, role string) Logger {
 	if zapLogger == nil {
 		zapLogger = defaultLogger
 	}
+	jsonFormat := isJSONCore(zapLogger.Core())
+	zapLogger = zapLogger.WithOptions(zap.WrapCore(wrapModuleLevelCore(module)), zap.WrapCore(wrapInterceptCore(module)))
+	if isCallerDisabled(module) {
+		zapLogger = zapLogger.WithOptions(zap.WithCaller(false))
+	}
 	return &logger{
 		module:              module,
 		role:                role,
 		log:                 zapLogger,
 		ignoreModuleAndRole: ignoreModuleAndRole,
+		jsonFormat:          jsonFormat,
 	}
 }
 
 // InitLogger initializes a zap logger from user config
 func InitLogger(fileName string, setting Setting, cfg *zapcore.EncoderConfig, options ...zap.Option) (*zap.Logger, error) {
-	logger, err := initLogger(fileName, setting, cfg, options...)
+	logger, err := initLogger("", fileName, setting, cfg, options...)
 	if err != nil {
 		return nil, err
 	}
 	return logger, nil
 }
 
+// InitModuleLogger initializes a zap logger of module from user config, the sinks of module(Setting.ModuleSinks)
+// override the default sinks.
+func InitModuleLogger(module, fileName string, setting Setting, cfg *zapcore.EncoderConfig, options ...zap.Option) (*zap.Logger, error) {
+	return initLogger(module, fileName, setting, cfg, options...)
+}
+
 // initLogger initializes a zap logger for different module
-func initLogger(logFilename string, setting Setting, cfg *zapcore.EncoderConfig, options ...zap.Option) (*zap.Logger, error) {
-	w := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   filepath.Join(setting.Dir, logFilename),
-		MaxSize:    int(setting.MaxSize / 1024 / 1024), // because in lumberjack will * megabyte
-		MaxBackups: int(setting.MaxBackups),
-		MaxAge:     int(setting.MaxAge),
-	})
-	// check if it is terminal
-	if !IsCli && isTerminal {
-		w = os.Stdout
-	}
+func initLogger(module, logFilename string, setting Setting, cfg *zapcore.EncoderConfig, options ...zap.Option) (*zap.Logger, error) {
 	// parse logging level
 	if err := RunningAtomicLevel.UnmarshalText([]byte(setting.Level)); err != nil {
 		return nil, err
 	}
-	core := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(*cfg),
-		w,
-		RunningAtomicLevel)
+	stacktrace, err := setting.stacktraceOption()
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := newEncoder(setting.Format, *cfg)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newSinkWriter(module, logFilename, &setting)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := newOutputCores(module, &setting, encoder, RunningAtomicLevel)
+	if err != nil {
+		return nil, err
+	}
+	var cores []zapcore.Core
+	if w != nil {
+		w = &countingWriter{WriteSyncer: w}
+		if setting.Async {
+			aw, err0 := NewAsyncWriter(w, int(setting.AsyncBufferSize), setting.AsyncPolicy)
+			if err0 != nil {
+				return nil, err0
+			}
+			registerAsyncWriter(aw)
+			w = aw
+		}
+		cores = append(cores, zapcore.NewCore(encoder, w, RunningAtomicLevel))
+	}
+	core := NewRedactCore(zapcore.NewTee(append(cores, outputs...)...), setting.RedactKeys...)
+	core = newStatsCore(NewDedupCore(core, setting.DedupWindow.Duration()))
+	if setting.Format == FormatJSON {
+		core = &jsonCore{Core: core}
+	}
+	recordModuleSinks(module, setting.sinkNames(module))
+	if module == "" {
+		storeCallerDisabledModules(setting.DisableCallerModules)
+	}
+	// stacktrace policy can be overridden by options, caller annotation can't if it's disabled for module
+	options = append([]zap.Option{stacktrace}, options...)
+	if module != "" && setting.callerDisabled(module) {
+		options = append(options, zap.WithCaller(false))
+	}
 	return zap.New(core, options...), nil
 }
+
+// newEncoder creates the encoder of log format, console if format is empty.
+// Level is encoded without color in json format.
+func newEncoder(format string, cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch format {
+	case "", FormatConsole:
+		return zapcore.NewConsoleEncoder(cfg), nil
+	case FormatJSON:
+		cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		return zapcore.NewJSONEncoder(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %q, console or json is available", format)
+	}
+}
+
+// jsonCore marks the core of logger initialized with json format,
+// module/role are logged as fields instead of message prefix.
+type jsonCore struct {
+	zapcore.Core
+}
+
+// With adds structured context to the wrapped core, keeps the json format mark.
+func (c *jsonCore) With(fields []zap.Field) zapcore.Core {
+	return &jsonCore{Core: c.Core.With(fields)}
+}
+
+// isJSONCore returns if the core of logger is initialized with json format.
+func isJSONCore(core zapcore.Core) bool {
+	_, ok := core.(*jsonCore)
+	return ok
+}
@@ -18,6 +18,10 @@
 package logger
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,3 +84,59 @@ func TestRegisterLogger_Default(t *testing.T) {
 	DefaultLogger.Store(defaultLogger)
 	assert.NotNil(t, GetLogger("test11", "test"))
 }
+
+func Test_InitLogger_Format(t *testing.T) {
+	dir := t.TempDir()
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeLevel = SimpleLevelEncoder
+
+	log, err := InitLogger("test.log", Setting{Dir: dir, Level: "info", Format: "xml"}, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	consoleLog, err := InitLogger("console.log", Setting{Dir: dir, Level: "info", Format: FormatConsole}, &encoderConfig)
+	assert.NoError(t, err)
+	assert.False(t, isJSONCore(consoleLog.Core()))
+	consoleLog.Info("console message")
+	assert.NoError(t, consoleLog.Sync())
+	data, err := os.ReadFile(filepath.Join(dir, "console.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "INFO\tconsole message")
+
+	log, err = InitLogger("json.log", Setting{Dir: dir, Level: "info", Format: FormatJSON}, &encoderConfig)
+	assert.NoError(t, err)
+	assert.True(t, isJSONCore(log.Core()))
+	assert.True(t, isJSONCore(log.With(String("k", "v")).Core()))
+	RegisterLogger("json-module", log, false)
+	RegisterLogger("console-module", consoleLog, false)
+	defer cleanModules("json-module", "console-module")
+	fields := []zap.Field{String("key", "value")}
+	GetLogger("json-module", "broker").Info("json message", fields...)
+	GetLogger("json-module", "").Warn("json warn")
+	assert.Len(t, fields, 1)
+	assert.NoError(t, log.Sync())
+
+	data, err = os.ReadFile(filepath.Join(dir, "json.log"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	entry := make(map[string]any)
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "json message", entry["msg"])
+	assert.Equal(t, "value", entry["key"])
+	assert.Equal(t, "json-module", entry["module"])
+	assert.Equal(t, "broker", entry["role"])
+	entry = make(map[string]any)
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.NotContains(t, entry, "role")
+
+	// json format of a logger doesn't affect console loggers
+	GetLogger("console-module", "broker").Info("console module message")
+	assert.NoError(t, consoleLog.Sync())
+	data, err = os.ReadFile(filepath.Join(dir, "console.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "console module message\n")
+	assert.NotContains(t, string(data), `"module"`)
+}
@@ -0,0 +1,96 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Sync flushes all registered loggers(including DefaultLogger) and async buffers,
+// e.g. pending repeated entries, buffered entries of async writer and OTLP exporter.
+func Sync() error {
+	var errs []error
+	for module, zapLogger := range registeredZapLoggers() {
+		if err := zapLogger.Sync(); err != nil && !isUnsupportedSync(err) {
+			errs = append(errs, fmt.Errorf("sync logger of module: %q failure: %w", module, err))
+		}
+	}
+	for _, aw := range registeredAsyncWriters() {
+		if err := aw.Sync(); err != nil {
+			errs = append(errs, fmt.Errorf("sync async writer failure: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes all loggers like Sync, then stops the background flushers of async writers,
+// the entries logged after closing are written into underlying writers directly,
+// so it should be called by server shutdown hooks to make sure the last lines before exit are not lost.
+func Close() error {
+	errs := []error{Sync()}
+	for _, aw := range registeredAsyncWriters() {
+		if err := aw.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close async writer failure: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// registeredZapLoggers returns the registered zap loggers by module, DefaultLogger's module is "",
+// the logger registered for multiple modules is returned once.
+func registeredZapLoggers() map[string]*zap.Logger {
+	result := make(map[string]*zap.Logger)
+	seen := make(map[*zap.Logger]struct{})
+	add := func(module string, zapLogger *zap.Logger) {
+		if zapLogger == nil {
+			return
+		}
+		if _, ok := seen[zapLogger]; !ok {
+			seen[zapLogger] = struct{}{}
+			result[module] = zapLogger
+		}
+	}
+	if defaultLog, ok := DefaultLogger.Load().(*zap.Logger); ok {
+		add("", defaultLog)
+	}
+
+	modulesLock.RLock()
+	defer modulesLock.RUnlock()
+
+	for module, log := range loggers {
+		add(module, log.logger)
+	}
+	return result
+}
+
+// registeredAsyncWriters returns a copy of the async writers created by InitLogger.
+func registeredAsyncWriters() []*AsyncWriter {
+	asyncWritersLock.Lock()
+	defer asyncWritersLock.Unlock()
+
+	return append([]*AsyncWriter(nil), asyncWriters...)
+}
+
+// isUnsupportedSync returns if the error is caused by syncing console(tty/pipe), see consoleWriter.
+func isUnsupportedSync(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
@@ -0,0 +1,103 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/lindb/common/pkg/ltoml"
+)
+
+// errSyncer fails to sync.
+type errSyncer struct {
+	bytes.Buffer
+}
+
+func (w *errSyncer) Sync() error {
+	return fmt.Errorf("sync err")
+}
+
+func TestSync(t *testing.T) {
+	defer cleanModules("SyncModule", "SyncModule2", "SyncErrModule")
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	sink := newSlowWriter()
+	close(sink.release)
+	assert.NoError(t, RegisterSink("sync", sink))
+	defer UnregisterSink("sync")
+	log, err := InitModuleLogger("SyncModule", "sync.log", Setting{Level: "info", Sinks: []string{"sync"},
+		DedupWindow: ltoml.Duration(time.Hour)}, &encoderConfig)
+	assert.NoError(t, err)
+	RegisterLogger("SyncModule", log, false)
+	// registered for multiple modules
+	RegisterLogger("SyncModule2", log, false)
+	for i := 0; i < 3; i++ {
+		log.Info("repeated message")
+	}
+	assert.NoError(t, Sync())
+	sink.lock.Lock()
+	assert.Contains(t, sink.buf.String(), `{"repeated": 2}`)
+	assert.Equal(t, 1, sink.syncs)
+	sink.lock.Unlock()
+
+	RegisterLogger("SyncErrModule", zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), &errSyncer{}, zapcore.InfoLevel)), false)
+	err = Sync()
+	assert.ErrorContains(t, err, `sync logger of module: "SyncErrModule" failure: sync err`)
+
+	assert.True(t, isUnsupportedSync(fmt.Errorf("sync /dev/stdout: %w", syscall.EINVAL)))
+	assert.False(t, isUnsupportedSync(fmt.Errorf("sync err")))
+}
+
+func TestClose(t *testing.T) {
+	defer cleanModules("CloseModule")
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	sink := newSlowWriter()
+	assert.NoError(t, RegisterSink("close", sink))
+	defer UnregisterSink("close")
+	log, err := InitModuleLogger("CloseModule", "close.log", Setting{Level: "info", Sinks: []string{"close"}, Async: true},
+		&encoderConfig)
+	assert.NoError(t, err)
+	RegisterLogger("CloseModule", log, false)
+	for i := 0; i < 3; i++ {
+		log.Info(fmt.Sprintf("message before close %d", i))
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(sink.release)
+	}()
+	assert.NoError(t, Close())
+	sink.lock.Lock()
+	assert.Equal(t, 3, bytes.Count(sink.buf.Bytes(), []byte("message before close")))
+	sink.lock.Unlock()
+
+	// written directly after closing
+	log.Info("message after close")
+	sink.lock.Lock()
+	assert.Contains(t, sink.buf.String(), "message after close")
+	sink.lock.Unlock()
+	assert.NoError(t, Close())
+}
@@ -0,0 +1,167 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// SinkFile writes log into the rotated log file under Setting.Dir.
+	SinkFile = "file"
+	// SinkStdout writes log into stdout.
+	SinkStdout = "stdout"
+	// SinkStderr writes log into stderr.
+	SinkStderr = "stderr"
+)
+
+var (
+	// sinks are the additional sinks registered by RegisterSink.
+	sinks     = make(map[string]zapcore.WriteSyncer)
+	sinksLock sync.RWMutex
+)
+
+// RegisterSink registers an additional sink(e.g. network log collector) which can be selected by Setting.Sinks,
+// the sink registered with same name is replaced. Built-in sink names(file/stdout/stderr/syslog/journald/otlp) cannot be registered.
+func RegisterSink(name string, w zapcore.WriteSyncer) error {
+	switch name {
+	case "", SinkFile, SinkStdout, SinkStderr, SinkSyslog, SinkJournald, SinkOTLP:
+		return fmt.Errorf("sink name: %q is reserved or empty", name)
+	}
+	if w == nil {
+		return fmt.Errorf("sink: %s is nil", name)
+	}
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+
+	sinks[name] = w
+	return nil
+}
+
+// UnregisterSink removes the sink registered by RegisterSink, the loggers already initialized keep writing into it.
+func UnregisterSink(name string) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+
+	delete(sinks, name)
+}
+
+// sinkNames returns the sink names of module, module sinks override the default sinks,
+// stdout is used if it's terminal and not cli, else file is used if no sink configured.
+func (l *Setting) sinkNames(module string) []string {
+	if names, ok := l.ModuleSinks[module]; ok && len(names) > 0 {
+		return names
+	}
+	if len(l.Sinks) > 0 {
+		return l.Sinks
+	}
+	if !IsCli && isTerminal {
+		return []string{SinkStdout}
+	}
+	return []string{SinkFile}
+}
+
+// newSinkWriter creates the writer of module which writes into all sinks(tee), returns error if sink not exist.
+// syslog/journald/otlp sinks are skipped, which are created as core by newOutputCore, returns nil if no writer sink.
+func newSinkWriter(module, logFilename string, setting *Setting) (zapcore.WriteSyncer, error) {
+	var ws []zapcore.WriteSyncer
+	seen := make(map[string]struct{})
+	for _, name := range setting.sinkNames(module) {
+		name = strings.TrimSpace(name)
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		switch name {
+		case SinkFile:
+			w, err := newFileWriter(logFilename, setting)
+			if err != nil {
+				return nil, err
+			}
+			ws = append(ws, w)
+		case SinkStdout:
+			ws = append(ws, consoleWriter(os.Stdout))
+		case SinkStderr:
+			ws = append(ws, consoleWriter(os.Stderr))
+		case SinkSyslog, SinkJournald, SinkOTLP:
+			continue
+		default:
+			sinksLock.RLock()
+			w, ok := sinks[name]
+			sinksLock.RUnlock()
+			if !ok {
+				return nil, fmt.Errorf("log sink: %q not found, registered sinks: %s", name, registeredSinks())
+			}
+			ws = append(ws, w)
+		}
+	}
+	switch len(ws) {
+	case 0:
+		return nil, nil
+	case 1:
+		return ws[0], nil
+	}
+	return zapcore.NewMultiWriteSyncer(ws...), nil
+}
+
+// newFileWriter creates the writer of log file, which is rotated by size(and time if RotateInterval is set).
+func newFileWriter(logFilename string, setting *Setting) (zapcore.WriteSyncer, error) {
+	lj := &lumberjack.Logger{
+		Filename:   filepath.Join(setting.Dir, logFilename),
+		MaxSize:    int(setting.MaxSize / 1024 / 1024), // because in lumberjack will * megabyte
+		MaxBackups: int(setting.MaxBackups),
+		MaxAge:     int(setting.MaxAge),
+	}
+	if setting.RotateInterval == "" {
+		return zapcore.AddSync(lj), nil
+	}
+	rw, err := newTimeRotateWriter(lj, setting.RotateInterval)
+	if err != nil {
+		return nil, err
+	}
+	return zapcore.AddSync(rw), nil
+}
+
+// consoleWriter returns the writer of stdout/stderr without sync,
+// because sync of console(tty/pipe) fails with EINVAL on some platforms, which breaks syncing of tee writer.
+func consoleWriter(f *os.File) zapcore.WriteSyncer {
+	return zapcore.AddSync(struct{ io.Writer }{f})
+}
+
+// registeredSinks returns the names of all available sinks.
+func registeredSinks() string {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+
+	names := []string{SinkFile, SinkStdout, SinkStderr, SinkSyslog, SinkJournald, SinkOTLP}
+	builtin := len(names)
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names[builtin:])
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,139 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// bufferSink is an in-memory sink for testing.
+type bufferSink struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (s *bufferSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *bufferSink) Sync() error { return nil }
+
+func (s *bufferSink) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.String()
+}
+
+func TestRegisterSink(t *testing.T) {
+	for _, name := range []string{"", SinkFile, SinkStdout, SinkStderr, SinkSyslog, SinkJournald, SinkOTLP} {
+		assert.Error(t, RegisterSink(name, &bufferSink{}))
+	}
+	assert.Error(t, RegisterSink("collector", nil))
+	assert.NoError(t, RegisterSink("collector", &bufferSink{}))
+	defer UnregisterSink("collector")
+	assert.Equal(t, "file,stdout,stderr,syslog,journald,otlp,collector", registeredSinks())
+}
+
+func TestSetting_sinkNames(t *testing.T) {
+	defer func() {
+		isTerminal = false
+		IsCli = false
+	}()
+	setting := Setting{}
+	isTerminal = false
+	assert.Equal(t, []string{SinkFile}, setting.sinkNames(""))
+	isTerminal = true
+	assert.Equal(t, []string{SinkStdout}, setting.sinkNames(""))
+	IsCli = true
+	assert.Equal(t, []string{SinkFile}, setting.sinkNames(""))
+
+	setting.Sinks = []string{SinkFile, SinkStderr}
+	setting.ModuleSinks = map[string][]string{AccessLogModule: {SinkFile}, "empty": nil}
+	assert.Equal(t, []string{SinkFile, SinkStderr}, setting.sinkNames(""))
+	assert.Equal(t, []string{SinkFile, SinkStderr}, setting.sinkNames("empty"))
+	assert.Equal(t, []string{SinkFile}, setting.sinkNames(AccessLogModule))
+}
+
+func Test_InitLogger_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	encoderConfig := zap.NewProductionEncoderConfig()
+	sink := &bufferSink{}
+	assert.NoError(t, RegisterSink("collector", sink))
+	defer UnregisterSink("collector")
+
+	log, err := InitLogger("tee.log", Setting{Dir: dir, Level: "info", Sinks: []string{"not-exist"}}, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+	log, err = InitLogger("tee.log",
+		Setting{Dir: dir, Level: "info", Sinks: []string{SinkFile}, RotateInterval: "weekly"}, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	setting := Setting{
+		Dir:         dir,
+		Level:       "info",
+		Sinks:       []string{SinkFile, " collector", "collector", SinkStderr},
+		ModuleSinks: map[string][]string{AccessLogModule: {"collector"}},
+	}
+	log, err = InitLogger("tee.log", setting, &encoderConfig)
+	assert.NoError(t, err)
+	log.Info("tee message")
+	assert.NoError(t, log.Sync())
+	data, err := os.ReadFile(filepath.Join(dir, "tee.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "tee message")
+	assert.Contains(t, sink.String(), "tee message")
+
+	// module sinks
+	log, err = InitModuleLogger(AccessLogModule, "access.log", setting, &encoderConfig)
+	assert.NoError(t, err)
+	log.Info("access message")
+	assert.NoError(t, log.Sync())
+	assert.Contains(t, sink.String(), "access message")
+	_, err = os.Stat(filepath.Join(dir, "access.log"))
+	assert.True(t, os.IsNotExist(err))
+
+	// registered sink is zapcore.WriteSyncer
+	var _ zapcore.WriteSyncer = sink
+}
+
+func TestSetting_TOML_Sinks(t *testing.T) {
+	setting := NewDefaultSetting()
+	setting.Sinks = []string{SinkFile, SinkStdout}
+	setting.ModuleSinks = map[string][]string{"Slow SQL": {SinkFile}, AccessLogModule: {"collector", SinkFile}}
+	cfg := struct {
+		Logging Setting `toml:"logging"`
+	}{}
+	_, err := toml.Decode(setting.TOML("TEST"), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, *setting, cfg.Logging)
+
+	_, err = toml.Decode(NewDefaultSetting().TOML("TEST"), &cfg)
+	assert.NoError(t, err)
+}
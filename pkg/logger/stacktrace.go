@@ -0,0 +1,90 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultStacktraceLevel is the default level at and above which stacktrace is attached.
+	DefaultStacktraceLevel = "error"
+	// StacktraceNone disables the stacktrace.
+	StacktraceNone = "none"
+	// callerSkip skips the frames of logger wrapper when annotating caller.
+	callerSkip = 2
+)
+
+// callerDisabledModules are the modules logging without caller, set by InitLogger(Setting.DisableCallerModules).
+var callerDisabledModules atomic.Pointer[map[string]struct{}]
+
+// parseStacktraceLevel parses the stacktrace level, returns the level above fatal if disabled.
+func parseStacktraceLevel(level string) (zapcore.Level, error) {
+	level = strings.TrimSpace(level)
+	if level == "" || strings.EqualFold(level, StacktraceNone) {
+		return zapcore.FatalLevel + 1, nil
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return l, fmt.Errorf("invalid stacktrace level: %q, %w", level, err)
+	}
+	return l, nil
+}
+
+// stacktraceOption returns the zap option which attaches stacktrace at and above StacktraceLevel.
+func (l *Setting) stacktraceOption() (zap.Option, error) {
+	level, err := parseStacktraceLevel(l.StacktraceLevel)
+	if err != nil {
+		return nil, err
+	}
+	return zap.AddStacktrace(level), nil
+}
+
+// callerDisabled returns if the caller annotation of module is disabled.
+func (l *Setting) callerDisabled(module string) bool {
+	for _, m := range l.DisableCallerModules {
+		if strings.TrimSpace(m) == module {
+			return true
+		}
+	}
+	return false
+}
+
+// storeCallerDisabledModules stores the modules logging without caller, which are applied by GetLogger.
+func storeCallerDisabledModules(names []string) {
+	disabled := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		disabled[strings.TrimSpace(name)] = struct{}{}
+	}
+	callerDisabledModules.Store(&disabled)
+}
+
+// isCallerDisabled returns if the caller annotation of module is disabled by InitLogger.
+func isCallerDisabled(module string) bool {
+	disabled := callerDisabledModules.Load()
+	if disabled == nil {
+		return false
+	}
+	_, ok := (*disabled)[module]
+	return ok
+}
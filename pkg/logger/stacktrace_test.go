@@ -0,0 +1,130 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_parseStacktraceLevel(t *testing.T) {
+	cases := []struct {
+		level  string
+		expect zapcore.Level
+		err    bool
+	}{
+		{level: "", expect: zapcore.FatalLevel + 1},
+		{level: " None ", expect: zapcore.FatalLevel + 1},
+		{level: "error", expect: zapcore.ErrorLevel},
+		{level: "WARN", expect: zapcore.WarnLevel},
+		{level: "trace", err: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := parseStacktraceLevel(tt.level)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, level)
+		})
+	}
+	level, err := parseStacktraceLevel(NewDefaultSetting().StacktraceLevel)
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.ErrorLevel, level)
+}
+
+// decodeLines decodes the json lines of sink.
+func decodeLines(t *testing.T, sink *bufferSink) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(sink.String()), "\n") {
+		entry := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestInitLogger_StacktracePolicy(t *testing.T) {
+	sink := &bufferSink{}
+	assert.NoError(t, RegisterSink("stacktrace", sink))
+	defer UnregisterSink("stacktrace")
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	setting := Setting{Level: "info", Format: FormatJSON, Sinks: []string{"stacktrace"}, StacktraceLevel: "trace"}
+	log, err := InitModuleLogger("StacktraceModule", "stacktrace.log", setting, &encoderConfig)
+	assert.Error(t, err)
+	assert.Nil(t, log)
+
+	setting.StacktraceLevel = "warn"
+	setting.DisableCallerModules = []string{" CallerModule"}
+	log, err = InitModuleLogger("StacktraceModule", "stacktrace.log", setting, &encoderConfig, zap.AddCaller())
+	assert.NoError(t, err)
+	log.Info("info")
+	log.Warn("warn")
+	// caller disabled by module, can't be overridden by options
+	log, err = InitModuleLogger("CallerModule", "stacktrace.log", setting, &encoderConfig, zap.AddCaller())
+	assert.NoError(t, err)
+	log.Info("info")
+	// stacktrace policy overridden by options
+	setting.StacktraceLevel = StacktraceNone
+	log, err = InitModuleLogger("StacktraceModule", "stacktrace.log", setting, &encoderConfig, zap.AddStacktrace(zapcore.InfoLevel))
+	assert.NoError(t, err)
+	log.Info("info")
+
+	entries := decodeLines(t, sink)
+	assert.Len(t, entries, 4)
+	assert.NotContains(t, entries[0], "stacktrace")
+	assert.Contains(t, entries[0], "caller")
+	assert.Contains(t, entries[1], "stacktrace")
+	assert.NotContains(t, entries[2], "caller")
+	assert.Contains(t, entries[3], "stacktrace")
+}
+
+func TestGetLogger_CallerDisabled(t *testing.T) {
+	defer func() {
+		callerDisabledModules.Store(nil)
+		cleanModules("CallerModule", "CallerEnabledModule")
+	}()
+	assert.False(t, isCallerDisabled("CallerModule"))
+
+	sink := &bufferSink{}
+	assert.NoError(t, RegisterSink("caller", sink))
+	defer UnregisterSink("caller")
+	encoderConfig := zap.NewProductionEncoderConfig()
+	setting := Setting{Level: "info", Format: FormatJSON, Sinks: []string{"caller"}, DisableCallerModules: []string{"CallerModule"}}
+	log, err := InitLogger("caller.log", setting, &encoderConfig, zap.AddCaller(), zap.AddCallerSkip(1))
+	assert.NoError(t, err)
+	assert.True(t, isCallerDisabled("CallerModule"))
+	RegisterLogger("CallerModule", log, true)
+	RegisterLogger("CallerEnabledModule", log, true)
+	GetLogger("CallerModule", "").Info("disabled")
+	GetLogger("CallerEnabledModule", "").Info("enabled")
+
+	entries := decodeLines(t, sink)
+	assert.Len(t, entries, 2)
+	assert.NotContains(t, entries[0], "caller")
+	assert.Contains(t, entries[1]["caller"], "stacktrace_test.go")
+}
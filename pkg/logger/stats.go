@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// StatsEntries is the metric name of log entries by level.
+	StatsEntries = "lindb.logger.entries"
+	// StatsDropped is the metric name of dropped log entries(e.g. async buffer or otlp queue is full).
+	StatsDropped = "lindb.logger.dropped"
+	// StatsWriteErrors is the metric name of log entries failed to write.
+	StatsWriteErrors = "lindb.logger.write_errors"
+	// StatsBytesWritten is the metric name of bytes written into sinks.
+	StatsBytesWritten = "lindb.logger.bytes_written"
+	// StatsRotateErrors is the metric name of log file rotation failures.
+	StatsRotateErrors = "lindb.logger.rotate_errors"
+)
+
+// loggingStats is the self-monitoring counters of all loggers created by InitLogger.
+var loggingStats logStats
+
+// logStats represents the counters of logging subsystem.
+type logStats struct {
+	entries      [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Int64
+	dropped      atomic.Int64
+	writeErrors  atomic.Int64
+	bytesWritten atomic.Int64
+	rotateErrors atomic.Int64
+}
+
+// addEntry counts the entry of level.
+func (s *logStats) addEntry(level zapcore.Level) {
+	if level >= zapcore.DebugLevel && level <= zapcore.FatalLevel {
+		s.entries[level-zapcore.DebugLevel].Add(1)
+	}
+}
+
+// reset resets all counters.
+func (s *logStats) reset() {
+	for i := range s.entries {
+		s.entries[i].Store(0)
+	}
+	s.dropped.Store(0)
+	s.writeErrors.Store(0)
+	s.bytesWritten.Store(0)
+	s.rotateErrors.Store(0)
+}
+
+// Stats represents the snapshot of logging statistics.
+type Stats struct {
+	Entries      map[string]int64 `json:"entries"` // entries by level, e.g. info/error
+	Dropped      int64            `json:"dropped"`
+	WriteErrors  int64            `json:"writeErrors"`
+	BytesWritten int64            `json:"bytesWritten"`
+	RotateErrors int64            `json:"rotateErrors"`
+}
+
+// StatsHook receives the metric of logging statistics, e.g. converting to flat metric rows.
+type StatsHook func(name string, tags map[string]string, value float64)
+
+// GetStats returns the snapshot of logging statistics, which can be scraped periodically.
+func GetStats() Stats {
+	stats := Stats{
+		Entries:      make(map[string]int64, len(loggingStats.entries)),
+		Dropped:      loggingStats.dropped.Load(),
+		WriteErrors:  loggingStats.writeErrors.Load(),
+		BytesWritten: loggingStats.bytesWritten.Load(),
+		RotateErrors: loggingStats.rotateErrors.Load(),
+	}
+	for i := range loggingStats.entries {
+		stats.Entries[(zapcore.DebugLevel + zapcore.Level(i)).String()] = loggingStats.entries[i].Load()
+	}
+	return stats
+}
+
+// Walk invokes hook with each metric of statistics, the entries are tagged by level.
+func (s Stats) Walk(hook StatsHook) {
+	for i := zapcore.DebugLevel; i <= zapcore.FatalLevel; i++ {
+		level := i.String()
+		if count, ok := s.Entries[level]; ok {
+			hook(StatsEntries, map[string]string{"level": level}, float64(count))
+		}
+	}
+	hook(StatsDropped, nil, float64(s.Dropped))
+	hook(StatsWriteErrors, nil, float64(s.WriteErrors))
+	hook(StatsBytesWritten, nil, float64(s.BytesWritten))
+	hook(StatsRotateErrors, nil, float64(s.RotateErrors))
+}
+
+// statsCore counts the entries by level and the write errors of wrapped core.
+type statsCore struct {
+	zapcore.Core
+}
+
+// newStatsCore returns a core which counts the entries and write errors into logging statistics.
+func newStatsCore(core zapcore.Core) zapcore.Core {
+	return &statsCore{Core: core}
+}
+
+// With adds structured context to the wrapped core.
+func (c *statsCore) With(fields []zap.Field) zapcore.Core {
+	return &statsCore{Core: c.Core.With(fields)}
+}
+
+// Check adds the stats core into checked entry if the level is enabled.
+func (c *statsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write counts the entry, then writes it into the wrapped core, counts the write error if failure.
+func (c *statsCore) Write(ent zapcore.Entry, fields []zap.Field) error {
+	loggingStats.addEntry(ent.Level)
+	err := c.Core.Write(ent, fields)
+	if err != nil {
+		loggingStats.writeErrors.Add(1)
+	}
+	return err
+}
+
+// countingWriter counts the bytes written into the wrapped writer.
+type countingWriter struct {
+	zapcore.WriteSyncer
+}
+
+// Write writes p into the wrapped writer, counts the written bytes.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	loggingStats.bytesWritten.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStats_InitLogger(t *testing.T) {
+	loggingStats.reset()
+	defer loggingStats.reset()
+
+	dir := t.TempDir()
+	encoderConfig := zap.NewProductionEncoderConfig()
+	log, err := InitLogger("stats.log", Setting{Dir: dir, Level: "info", Sinks: []string{SinkFile}}, &encoderConfig)
+	assert.NoError(t, err)
+	log.Info("info message")
+	log.Warn("warn message")
+	log.Warn("warn message")
+	log.Debug("debug message")
+	assert.NoError(t, log.Sync())
+	data, err := os.ReadFile(filepath.Join(dir, "stats.log"))
+	assert.NoError(t, err)
+
+	stats := GetStats()
+	assert.Equal(t, int64(1), stats.Entries["info"])
+	assert.Equal(t, int64(2), stats.Entries["warn"])
+	assert.Zero(t, stats.Entries["debug"])
+	assert.Len(t, stats.Entries, 7)
+	assert.Equal(t, int64(len(data)), stats.BytesWritten)
+	assert.Zero(t, stats.WriteErrors)
+	assert.Zero(t, stats.Dropped)
+}
+
+func TestStats_Counters(t *testing.T) {
+	loggingStats.reset()
+	defer loggingStats.reset()
+
+	observed, _ := observer.New(zapcore.InfoLevel)
+	core := newStatsCore(&mockCore{Core: observed})
+	log := zap.New(core)
+	log.Error("error")
+	log.With(String("db", "test")).Info("info")
+	// level out of range isn't counted
+	loggingStats.addEntry(zapcore.Level(100))
+	loggingStats.dropped.Add(3)
+
+	stats := GetStats()
+	assert.Equal(t, int64(1), stats.Entries["error"])
+	assert.Equal(t, int64(1), stats.Entries["info"])
+	assert.Equal(t, int64(1), stats.WriteErrors)
+	assert.Equal(t, int64(3), stats.Dropped)
+
+	type metric struct {
+		name  string
+		tags  map[string]string
+		value float64
+	}
+	var metrics []metric
+	stats.Walk(func(name string, tags map[string]string, value float64) {
+		metrics = append(metrics, metric{name: name, tags: tags, value: value})
+	})
+	assert.Len(t, metrics, 11)
+	assert.Equal(t, metric{name: StatsEntries, tags: map[string]string{"level": "debug"}}, metrics[0])
+	assert.Equal(t, metric{name: StatsEntries, tags: map[string]string{"level": "info"}, value: 1}, metrics[1])
+	assert.Equal(t, metric{name: StatsEntries, tags: map[string]string{"level": "error"}, value: 1}, metrics[3])
+	assert.Equal(t, metric{name: StatsDropped, value: 3}, metrics[7])
+	assert.Equal(t, metric{name: StatsWriteErrors, value: 1}, metrics[8])
+	assert.Equal(t, metric{name: StatsBytesWritten}, metrics[9])
+	assert.Equal(t, metric{name: StatsRotateErrors}, metrics[10])
+}
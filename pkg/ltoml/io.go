@@ -65,10 +65,12 @@ func WriteConfig(fileName, content string) error {
 	return nil
 }
 
-// DecodeToml decodes data from file using toml format
+// DecodeToml decodes data from file using toml format,
+// the values of deprecated keys(see RegisterKeyMigrations) are migrated to the renamed keys.
 func DecodeToml(fileName string, v interface{}) error {
-	if _, err := toml.DecodeFile(fileName, v); err != nil {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
 		return err
 	}
-	return nil
+	return decodeWithMigrations(string(data), v)
 }
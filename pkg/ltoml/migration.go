@@ -0,0 +1,157 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ltoml
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// KeyMigration declares the config key(dotted path, e.g. "storage.tsdb.max-series") is renamed.
+type KeyMigration struct {
+	OldKey string
+	NewKey string
+	// Since is the release which deprecates the old key, e.g. "v2.1.0".
+	Since string
+}
+
+// String returns the deprecation warning of key migration.
+func (m KeyMigration) String() string {
+	msg := fmt.Sprintf("config key [%s] is deprecated", m.OldKey)
+	if m.Since != "" {
+		msg += fmt.Sprintf(" since %s", m.Since)
+	}
+	return fmt.Sprintf("%s, please use [%s] instead", msg, m.NewKey)
+}
+
+var (
+	migrations   []KeyMigration
+	migrationsMu sync.RWMutex
+	// deprecationWarner warns the usage of deprecated keys.
+	deprecationWarner = func(msg string) {
+		_, _ = fmt.Fprintf(os.Stderr, "WARN: %s\n", msg)
+	}
+)
+
+// RegisterKeyMigrations registers the renamed keys, the value of old key is migrated to new key
+// when decoding toml file, the value of new key takes precedence if both keys are set.
+func RegisterKeyMigrations(keyMigrations ...KeyMigration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	migrations = append(migrations, keyMigrations...)
+}
+
+// SetDeprecationWarner sets the function which warns the usage of deprecated keys(writes stderr by default).
+func SetDeprecationWarner(warner func(msg string)) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	deprecationWarner = warner
+}
+
+// getMigrations returns the registered key migrations and deprecation warner.
+func getMigrations() ([]KeyMigration, func(msg string)) {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	return migrations, deprecationWarner
+}
+
+// decodeWithMigrations decodes the toml data into v, migrates the values of deprecated keys if set.
+func decodeWithMigrations(data string, v interface{}) error {
+	md, err := toml.Decode(data, v)
+	if err != nil {
+		return err
+	}
+	keyMigrations, warn := getMigrations()
+	var deprecated []KeyMigration
+	for _, m := range keyMigrations {
+		if md.IsDefined(strings.Split(m.OldKey, ".")...) {
+			deprecated = append(deprecated, m)
+		}
+	}
+	if len(deprecated) == 0 {
+		return nil
+	}
+	raw := make(map[string]interface{})
+	if _, err = toml.Decode(data, &raw); err != nil {
+		return err
+	}
+	for _, m := range deprecated {
+		if migrateKey(raw, m) {
+			warn(m.String())
+		} else {
+			warn(fmt.Sprintf("%s, value of [%s] is ignored", m, m.OldKey))
+		}
+	}
+	var buf bytes.Buffer
+	if err = toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return err
+	}
+	_, err = toml.Decode(buf.String(), v)
+	return err
+}
+
+// migrateKey moves the value of old key to new key, returns false if the new key is already set.
+func migrateKey(raw map[string]interface{}, m KeyMigration) bool {
+	oldPath := strings.Split(m.OldKey, ".")
+	oldParent := lookupTable(raw, oldPath[:len(oldPath)-1], false)
+	oldName := oldPath[len(oldPath)-1]
+	value := oldParent[oldName]
+	delete(oldParent, oldName)
+
+	newPath := strings.Split(m.NewKey, ".")
+	newParent := lookupTable(raw, newPath[:len(newPath)-1], true)
+	newName := newPath[len(newPath)-1]
+	if newParent == nil {
+		// new parent isn't a table
+		return false
+	}
+	if _, ok := newParent[newName]; ok {
+		return false
+	}
+	newParent[newName] = value
+	return true
+}
+
+// lookupTable returns the table of path, creates the missing tables if create is true.
+func lookupTable(raw map[string]interface{}, path []string, create bool) map[string]interface{} {
+	table := raw
+	for _, name := range path {
+		child, ok := table[name]
+		if !ok {
+			if !create {
+				return nil
+			}
+			child = make(map[string]interface{})
+			table[name] = child
+		}
+		childTable, ok := child.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		table = childTable
+	}
+	return table
+}
@@ -0,0 +1,130 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ltoml
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tsdbCfg struct {
+	MaxSeries int    `toml:"max-series"`
+	Dir       string `toml:"dir"`
+}
+
+type storageCfg struct {
+	TSDB tsdbCfg `toml:"tsdb"`
+}
+
+type migrationCfg struct {
+	Storage storageCfg `toml:"storage"`
+	Path    string     `toml:"path"`
+}
+
+func registerTestMigrations(t *testing.T, keyMigrations ...KeyMigration) *[]string {
+	var warnings []string
+	prevMigrations, prevWarner := getMigrations()
+	RegisterKeyMigrations(keyMigrations...)
+	SetDeprecationWarner(func(msg string) {
+		warnings = append(warnings, msg)
+	})
+	t.Cleanup(func() {
+		migrationsMu.Lock()
+		migrations = prevMigrations
+		migrationsMu.Unlock()
+		SetDeprecationWarner(prevWarner)
+	})
+	return &warnings
+}
+
+func TestDecodeToml_Migration(t *testing.T) {
+	warnings := registerTestMigrations(t,
+		KeyMigration{OldKey: "storage.tsdb.maxSeries", NewKey: "storage.tsdb.max-series", Since: "v2.1.0"},
+		KeyMigration{OldKey: "storage.dir", NewKey: "storage.tsdb.dir"},
+		KeyMigration{OldKey: "data-path", NewKey: "path"},
+		KeyMigration{OldKey: "unused", NewKey: "unused2"},
+	)
+	cases := []struct {
+		name     string
+		content  string
+		cfg      migrationCfg
+		warnings []string
+	}{
+		{
+			name:    "no deprecated keys",
+			content: "path = \"/data\"\n[storage.tsdb]\nmax-series = 10\n",
+			cfg:     migrationCfg{Path: "/data", Storage: storageCfg{TSDB: tsdbCfg{MaxSeries: 10}}},
+		},
+		{
+			name:    "migrate deprecated keys",
+			content: "data-path = \"/data\"\n[storage]\ndir = \"/tsdb\"\n[storage.tsdb]\nmaxSeries = 10\n",
+			cfg:     migrationCfg{Path: "/data", Storage: storageCfg{TSDB: tsdbCfg{MaxSeries: 10, Dir: "/tsdb"}}},
+			warnings: []string{
+				"config key [storage.tsdb.maxSeries] is deprecated since v2.1.0, please use [storage.tsdb.max-series] instead",
+				"config key [storage.dir] is deprecated, please use [storage.tsdb.dir] instead",
+				"config key [data-path] is deprecated, please use [path] instead",
+			},
+		},
+		{
+			name:    "new key takes precedence",
+			content: "data-path = \"/old\"\npath = \"/new\"\n",
+			cfg:     migrationCfg{Path: "/new"},
+			warnings: []string{
+				"config key [data-path] is deprecated, please use [path] instead, value of [data-path] is ignored",
+			},
+		},
+		{
+			name:     "create missing table",
+			content:  "[storage]\ndir = \"/tsdb\"\n",
+			cfg:      migrationCfg{Storage: storageCfg{TSDB: tsdbCfg{Dir: "/tsdb"}}},
+			warnings: []string{"config key [storage.dir] is deprecated, please use [storage.tsdb.dir] instead"},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			*warnings = nil
+			cfgFile := filepath.Join(t.TempDir(), "cfg.toml")
+			assert.NoError(t, WriteConfig(cfgFile, tt.content))
+			cfg := migrationCfg{}
+			assert.NoError(t, DecodeToml(cfgFile, &cfg))
+			assert.Equal(t, tt.cfg, cfg)
+			assert.Equal(t, tt.warnings, *warnings)
+		})
+	}
+}
+
+func TestDecodeToml_Migration_Failure(t *testing.T) {
+	warnings := registerTestMigrations(t, KeyMigration{OldKey: "dir", NewKey: "path.dir"})
+	cfgFile := filepath.Join(t.TempDir(), "cfg.toml")
+	// new parent isn't a table
+	assert.NoError(t, WriteConfig(cfgFile, "path = \"/data\"\ndir = \"/dir\"\n"))
+	cfg := migrationCfg{}
+	assert.NoError(t, DecodeToml(cfgFile, &cfg))
+	assert.Equal(t, "/data", cfg.Path)
+	assert.Len(t, *warnings, 1)
+
+	assert.Error(t, DecodeToml(filepath.Join(t.TempDir(), "not-exist"), &cfg))
+}
+
+func TestKeyMigration_DefaultWarner(t *testing.T) {
+	_, warner := getMigrations()
+	warner(KeyMigration{OldKey: "a", NewKey: "b"}.String())
+}
@@ -0,0 +1,198 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ltoml
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of exported schema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+const (
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	sizePattern     = `^[0-9]+(\.[0-9]+)?\s*([kKmMgGtTpPeE]([iI]?[bB])?|[bB])?$`
+)
+
+var (
+	durationType        = reflect.TypeOf(Duration(0))
+	sizeType            = reflect.TypeOf(Size(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// JSONSchema represents a JSON Schema document of config.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+}
+
+// GenerateJSONSchema reflects the config struct(e.g. default config) into JSON Schema,
+// property name is taken from toml tag(then json tag, field name), non-zero values are exported as default.
+//   - Duration: string with duration pattern, e.g. "30s"
+//   - Size: string with size pattern, e.g. "100 MiB"
+func GenerateJSONSchema(title string, cfg interface{}) *JSONSchema {
+	schema := schemaOf(reflect.ValueOf(cfg), make(map[reflect.Type]bool))
+	schema.Schema = JSONSchemaDraft
+	schema.Title = title
+	return schema
+}
+
+// ExportJSONSchema returns the indented JSON Schema document of the config struct.
+func ExportJSONSchema(title string, cfg interface{}) ([]byte, error) {
+	return json.MarshalIndent(GenerateJSONSchema(title, cfg), "", "  ")
+}
+
+// schemaOf returns the schema of value, visiting records the struct types in current path for breaking cycle.
+func schemaOf(v reflect.Value, visiting map[reflect.Type]bool) *JSONSchema {
+	if !v.IsValid() {
+		return &JSONSchema{}
+	}
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.Zero(t)
+		} else {
+			v = v.Elem()
+		}
+	}
+	switch {
+	case t == durationType:
+		return &JSONSchema{Type: "string", Format: "duration", Pattern: durationPattern, Default: textDefault(v)}
+	case t == sizeType:
+		return &JSONSchema{Type: "string", Pattern: sizePattern, Default: textDefault(v)}
+	case t.Kind() != reflect.Struct && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &JSONSchema{Type: "string", Default: textDefault(v)}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean", Default: nonZero(v)}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer", Default: nonZero(v)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &JSONSchema{Type: "integer", Minimum: &minimum, Default: nonZero(v)}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number", Default: nonZero(v)}
+	case reflect.String:
+		return &JSONSchema{Type: "string", Default: nonZero(v)}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(reflect.Zero(t.Elem()), visiting)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaOf(reflect.Zero(t.Elem()), visiting)}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object"}
+		if visiting[t] {
+			return schema
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema.Properties = make(map[string]*JSONSchema)
+		addStructProperties(schema, v, visiting)
+		return schema
+	default:
+		// interface/func/chan, any value
+		return &JSONSchema{}
+	}
+}
+
+// addStructProperties adds the exported fields of struct as properties, anonymous struct without tag is inlined.
+func addStructProperties(schema *JSONSchema, v reflect.Value, visiting map[reflect.Type]bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv = reflect.Zero(fv.Type().Elem())
+				} else {
+					fv = fv.Elem()
+				}
+			}
+			if fv.Kind() == reflect.Struct {
+				addStructProperties(schema, fv, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOf(fv, visiting)
+	}
+}
+
+// propertyName returns the name in toml/json tag, returns false if the field is ignored.
+func propertyName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"toml", "json"} {
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			return "", false
+		}
+		return name, true
+	}
+	return "", true
+}
+
+// nonZero returns the value as default if it is not zero.
+func nonZero(v reflect.Value) interface{} {
+	if v.IsZero() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// textDefault returns the text of value as default if it is not zero.
+func textDefault(v reflect.Value) interface{} {
+	if v.IsZero() {
+		return nil
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	if m, ok := ptr.Interface().(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ltoml
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaStorage struct {
+	Dir      string   `toml:"dir"`
+	Interval Duration `toml:"interval"`
+	MaxSize  Size     `toml:"max-size"`
+}
+
+type schemaCommon struct {
+	Namespace string `toml:"namespace"`
+}
+
+type schemaNode struct {
+	Children []*schemaNode `toml:"children"`
+}
+
+type schemaConfig struct {
+	schemaCommon
+	Storage  *schemaStorage           `toml:"storage"`
+	Ratio    float64                  `toml:"ratio"`
+	Port     uint16                   `toml:"port"`
+	Retry    int                      `toml:"retry"`
+	Enabled  bool                     `toml:"enabled"`
+	Tags     []string                 `toml:"tags"`
+	Labels   map[string]string        `json:"labels"`
+	Brokers  map[string]schemaStorage `toml:"brokers"`
+	IP       net.IP                   `toml:"ip"`
+	Node     schemaNode               `toml:"node"`
+	Any      interface{}              `toml:"any"`
+	Ignored  string                   `toml:"-"`
+	NoTag    string
+	internal string
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	cfg := &schemaConfig{
+		schemaCommon: schemaCommon{Namespace: "default"},
+		Storage:      &schemaStorage{Dir: "/data", Interval: Duration(10 * time.Second), MaxSize: Size(1024)},
+		Port:         9000,
+		Enabled:      true,
+		internal:     "x",
+	}
+	schema := GenerateJSONSchema("config", cfg)
+	assert.Equal(t, JSONSchemaDraft, schema.Schema)
+	assert.Equal(t, "config", schema.Title)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, "default", schema.Properties["namespace"].Default)
+	assert.NotContains(t, schema.Properties, "Ignored")
+	assert.NotContains(t, schema.Properties, "internal")
+	assert.Contains(t, schema.Properties, "NoTag")
+	assert.Contains(t, schema.Properties, "labels")
+
+	storage := schema.Properties["storage"]
+	assert.Equal(t, "object", storage.Type)
+	assert.Equal(t, "/data", storage.Properties["dir"].Default)
+	assert.Equal(t, "10s", storage.Properties["interval"].Default)
+	assert.Equal(t, "duration", storage.Properties["interval"].Format)
+	assert.Equal(t, "1.0 KiB", storage.Properties["max-size"].Default)
+	assert.Regexp(t, regexp.MustCompile(storage.Properties["interval"].Pattern), "1m30s")
+	assert.Regexp(t, regexp.MustCompile(storage.Properties["max-size"].Pattern), "100 MiB")
+	assert.Regexp(t, regexp.MustCompile(storage.Properties["max-size"].Pattern), "1g")
+
+	assert.Equal(t, "number", schema.Properties["ratio"].Type)
+	assert.Nil(t, schema.Properties["ratio"].Default)
+	assert.Equal(t, "integer", schema.Properties["port"].Type)
+	assert.Equal(t, 0.0, *schema.Properties["port"].Minimum)
+	assert.Equal(t, uint16(9000), schema.Properties["port"].Default)
+	assert.Equal(t, "integer", schema.Properties["retry"].Type)
+	assert.Equal(t, "boolean", schema.Properties["enabled"].Type)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+	assert.Equal(t, "object", schema.Properties["brokers"].Type)
+	assert.Equal(t, "string", schema.Properties["brokers"].AdditionalProperties.Properties["interval"].Type)
+	assert.Equal(t, "string", schema.Properties["ip"].Type)
+	assert.Equal(t, &JSONSchema{}, schema.Properties["any"])
+	// recursive type
+	children := schema.Properties["node"].Properties["children"]
+	assert.Equal(t, "array", children.Type)
+	assert.Equal(t, &JSONSchema{Type: "object"}, children.Items)
+
+	// nil storage
+	schema = GenerateJSONSchema("config", schemaConfig{})
+	assert.Nil(t, schema.Properties["storage"].Properties["interval"].Default)
+	assert.Equal(t, &JSONSchema{}, schemaOf(reflect.Value{}, nil))
+}
+
+func TestExportJSONSchema(t *testing.T) {
+	data, err := ExportJSONSchema("storage", &schemaStorage{Dir: "/data"})
+	assert.NoError(t, err)
+	rs := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(data, &rs))
+	assert.Equal(t, JSONSchemaDraft, rs["$schema"])
+	assert.Equal(t, "/data", rs["properties"].(map[string]interface{})["dir"].(map[string]interface{})["default"])
+}
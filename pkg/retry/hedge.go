@@ -0,0 +1,120 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultHedgeDelay is the default delay before issuing a backup request.
+const DefaultHedgeDelay = 50 * time.Millisecond
+
+// ErrNoTarget is returned when hedging without any target.
+var ErrNoTarget = errors.New("no target for hedged request")
+
+// HedgeOptions represents the options of hedged requests.
+type HedgeOptions struct {
+	// Delay is the duration waiting for in-flight requests before issuing a backup request
+	// to next target, DefaultHedgeDelay if not set.
+	Delay time.Duration
+	// MaxAttempts is the max number of requests(including the primary), all targets if not set.
+	MaxAttempts int
+}
+
+// hedgeResult represents the result of a request.
+type hedgeResult[R any] struct {
+	value R
+	err   error
+}
+
+// Hedge issues the request to the first target(primary), then issues a backup request to next target(e.g. alternate
+// replica) after each delay until any request succeeds, which reduces the tail latency caused by a slow replica.
+// The backup request is issued immediately if all in-flight requests failed.
+// Returns the first success and cancels other in-flight requests, or the joined errors if all requests failed,
+// or the error of context if it's done. fn must respect the cancellation of ctx.
+func Hedge[T, R any](ctx context.Context, targets []T, options HedgeOptions,
+	fn func(ctx context.Context, target T) (R, error),
+) (R, error) {
+	var zero R
+	if len(targets) == 0 {
+		return zero, ErrNoTarget
+	}
+	attempts := len(targets)
+	if options.MaxAttempts > 0 && options.MaxAttempts < attempts {
+		attempts = options.MaxAttempts
+	}
+	delay := options.Delay
+	if delay <= 0 {
+		delay = DefaultHedgeDelay
+	}
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered, so that the losers never block after returning
+	results := make(chan hedgeResult[R], attempts)
+	launched := 0
+	launch := func() {
+		target := targets[launched]
+		launched++
+		go func() {
+			value, err := fn(hedgeCtx, target)
+			results <- hedgeResult[R]{value: value, err: err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var errs []error
+	for {
+		var timerC <-chan time.Time
+		if launched < attempts {
+			timerC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-timerC:
+			launch()
+			timer.Reset(delay)
+		case result := <-results:
+			if result.err == nil {
+				return result.value, nil
+			}
+			errs = append(errs, result.err)
+			if len(errs) == attempts {
+				return zero, errors.Join(errs...)
+			}
+			if len(errs) == launched && launched < attempts {
+				// all in-flight requests failed, no need to wait
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				launch()
+				timer.Reset(delay)
+			}
+		}
+	}
+}
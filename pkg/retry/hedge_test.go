@@ -0,0 +1,110 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedge_NoTarget(t *testing.T) {
+	_, err := Hedge(context.TODO(), nil, HedgeOptions{}, func(_ context.Context, _ string) (string, error) {
+		return "", nil
+	})
+	assert.ErrorIs(t, err, ErrNoTarget)
+}
+
+func TestHedge_PrimarySuccess(t *testing.T) {
+	var calls atomic.Int32
+	rs, err := Hedge(context.TODO(), []string{"node-1", "node-2"}, HedgeOptions{Delay: time.Second},
+		func(_ context.Context, target string) (string, error) {
+			calls.Add(1)
+			return target, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "node-1", rs)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHedge_BackupWins(t *testing.T) {
+	var canceled atomic.Bool
+	done := make(chan struct{})
+	rs, err := Hedge(context.TODO(), []string{"slow", "fast"}, HedgeOptions{Delay: 10 * time.Millisecond},
+		func(ctx context.Context, target string) (string, error) {
+			if target == "slow" {
+				defer close(done)
+				<-ctx.Done()
+				canceled.Store(true)
+				return "", ctx.Err()
+			}
+			return target, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", rs)
+	// slow request is canceled
+	<-done
+	assert.True(t, canceled.Load())
+}
+
+func TestHedge_FailFast(t *testing.T) {
+	start := time.Now()
+	rs, err := Hedge(context.TODO(), []int{1, 2, 3}, HedgeOptions{Delay: time.Minute},
+		func(_ context.Context, target int) (int, error) {
+			if target < 3 {
+				return 0, fmt.Errorf("target %d failure", target)
+			}
+			return target * 10, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, rs)
+	// backup requests are issued without waiting the delay
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestHedge_AllFailed(t *testing.T) {
+	var calls atomic.Int32
+	_, err := Hedge(context.TODO(), []int{1, 2, 3}, HedgeOptions{Delay: time.Millisecond, MaxAttempts: 2},
+		func(_ context.Context, target int) (int, error) {
+			calls.Add(1)
+			time.Sleep(5 * time.Millisecond)
+			return 0, fmt.Errorf("target %d failure", target)
+		})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "target 1 failure")
+	assert.Contains(t, err.Error(), "target 2 failure")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHedge_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	var calls atomic.Int32
+	_, err := Hedge(ctx, []int{1, 2}, HedgeOptions{},
+		func(ctx context.Context, _ int) (int, error) {
+			calls.Add(1)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.LessOrEqual(t, calls.Load(), int32(2))
+}
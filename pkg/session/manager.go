@@ -0,0 +1,227 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	httppkg "github.com/lindb/common/pkg/http"
+	"github.com/lindb/common/pkg/logger"
+)
+
+const (
+	// DefaultCookieName is the default cookie name of session id.
+	DefaultCookieName = "lindb_session"
+	// DefaultMaxAge is the default lifetime of session.
+	DefaultMaxAge = 30 * time.Minute
+
+	sessionKey    = "session"
+	sessionIDSize = 32
+)
+
+// for testing
+var (
+	nowFunc          = time.Now
+	randReadFunc     = rand.Read
+	newSessionIDFunc = newSessionID
+)
+
+// Options represents the options of session manager.
+type Options struct {
+	// CookieName is the cookie which holds the signed session id, DefaultCookieName if empty.
+	CookieName string
+	// Secret signs the session id(HMAC-SHA256), required.
+	Secret []byte
+	// MaxAge is the idle lifetime of session, DefaultMaxAge if not set.
+	MaxAge time.Duration
+	// RenewWindow renews the session if it's accessed within the window before expiration, MaxAge/2 if not set.
+	RenewWindow time.Duration
+	// Cookie is the attributes of session cookie, HTTPOnly is always set, MaxAge is ignored.
+	Cookie httppkg.CookieOptions
+}
+
+// Manager manages the sessions of console, which loads the session from signed cookie and backing store.
+type Manager struct {
+	store   Store
+	options Options
+
+	logger logger.Logger
+}
+
+// NewManager creates a session manager.
+func NewManager(store Store, options Options) (*Manager, error) {
+	if store == nil {
+		return nil, errors.New("session store is required")
+	}
+	if len(options.Secret) == 0 {
+		return nil, errors.New("session secret is required")
+	}
+	if options.CookieName == "" {
+		options.CookieName = DefaultCookieName
+	}
+	if options.MaxAge <= 0 {
+		options.MaxAge = DefaultMaxAge
+	}
+	if options.RenewWindow <= 0 || options.RenewWindow > options.MaxAge {
+		options.RenewWindow = options.MaxAge / 2
+	}
+	options.Cookie.HTTPOnly = true
+	options.Cookie.MaxAge = 0
+	return &Manager{
+		store:   store,
+		options: options,
+		logger:  logger.GetLogger("Session", "Manager"),
+	}, nil
+}
+
+// Middleware returns a gin middleware which loads(or creates) the session for each request,
+// the session is accessed by SessionFromContext. The session is saved(and cookie is set) when
+// the handler modifies it, so that the handler must call Manager.Save before writing the response body,
+// else it is saved after handling the request but the cookie may be lost.
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, err := m.load(c)
+		if err != nil {
+			m.logger.Error("load session failure", logger.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, "load session failure")
+			return
+		}
+		c.Set(sessionKey, s)
+		// renew the active session before handling, because the response header is writable now
+		if !s.isNew && s.expiresAt.Sub(nowFunc()) < m.options.RenewWindow {
+			s.modified = true
+			if err = m.Save(c); err != nil {
+				m.logger.Warn("renew session failure", logger.Error(err))
+			}
+		}
+		c.Next()
+
+		if s.modified || s.destroyed {
+			if c.Writer.Written() {
+				m.logger.Warn("session is modified after writing response, cookie may be lost",
+					logger.String("path", c.Request.URL.Path))
+			}
+			if err = m.Save(c); err != nil {
+				m.logger.Error("save session failure", logger.Error(err))
+			}
+		}
+	}
+}
+
+// Save saves(or deletes if destroyed) the session of the request and sets the cookie.
+func (m *Manager) Save(c *gin.Context) error {
+	s := SessionFromContext(c)
+	if s == nil {
+		return errors.New("session not found in context, session middleware is not used")
+	}
+	ctx := c.Request.Context()
+	if s.oldID != "" {
+		if err := m.store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+		s.oldID = ""
+	}
+	if s.destroyed {
+		if !s.isNew {
+			if err := m.store.Delete(ctx, s.id); err != nil {
+				return err
+			}
+		}
+		cookie := m.options.Cookie
+		cookie.MaxAge = -1
+		http.SetCookie(c.Writer, httppkg.NewSameSiteCookie(m.options.CookieName, "", cookie))
+		s.modified = false
+		s.destroyed = false
+		s.isNew = true
+		return nil
+	}
+	if !s.modified {
+		return nil
+	}
+	s.expiresAt = nowFunc().Add(m.options.MaxAge)
+	if err := m.store.Save(ctx, s); err != nil {
+		return err
+	}
+	http.SetCookie(c.Writer, httppkg.NewSameSiteCookie(m.options.CookieName, m.sign(s.id), m.options.Cookie))
+	s.modified = false
+	s.isNew = false
+	return nil
+}
+
+// load returns the session of request, creates a new one if not exist/invalid/expired.
+func (m *Manager) load(c *gin.Context) (*Session, error) {
+	now := nowFunc()
+	if value, err := c.Cookie(m.options.CookieName); err == nil {
+		if id, ok := m.verify(value); ok {
+			s, err0 := m.store.Load(c.Request.Context(), id)
+			if err0 != nil {
+				return nil, err0
+			}
+			if s != nil && !s.isExpired(now) {
+				return s, nil
+			}
+		}
+	}
+	id, err := newSessionIDFunc()
+	if err != nil {
+		return nil, err
+	}
+	return newSession(id, now, m.options.MaxAge), nil
+}
+
+// sign returns the signed cookie value of session id.
+func (m *Manager) sign(id string) string {
+	mac := hmac.New(sha256.New, m.options.Secret)
+	_, _ = mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify verifies the signed cookie value, returns the session id.
+func (m *Manager) verify(value string) (string, bool) {
+	id, _, ok := strings.Cut(value, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+	return id, hmac.Equal([]byte(value), []byte(m.sign(id)))
+}
+
+// SessionFromContext returns the session of request, returns nil if session middleware isn't used.
+func SessionFromContext(c *gin.Context) *Session {
+	if s, ok := c.Get(sessionKey); ok {
+		return s.(*Session)
+	}
+	return nil
+}
+
+// newSessionID generates a random session id.
+func newSessionID() (string, error) {
+	var b [sessionIDSize]byte
+	if _, err := randReadFunc(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,241 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRouter(t *testing.T, store Store) (*gin.Engine, *Manager) {
+	m, err := NewManager(store, Options{Secret: []byte("secret"), MaxAge: time.Minute})
+	assert.NoError(t, err)
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.POST("/login", func(c *gin.Context) {
+		s := SessionFromContext(c)
+		assert.NoError(t, s.RegenerateID())
+		s.Set("user", c.Query("user"))
+		assert.NoError(t, m.Save(c))
+		c.String(http.StatusOK, s.ID())
+	})
+	r.GET("/me", func(c *gin.Context) {
+		user, _ := SessionFromContext(c).Get("user")
+		c.String(http.StatusOK, user)
+	})
+	r.POST("/logout", func(c *gin.Context) {
+		SessionFromContext(c).Destroy()
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/late", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+		SessionFromContext(c).Set("late", "true")
+	})
+	return r, m
+}
+
+func doRequest(r *gin.Engine, method, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, http.NoBody)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func sessionCookie(t *testing.T, resp *httptest.ResponseRecorder) *http.Cookie {
+	cookies := resp.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	if len(cookies) == 0 {
+		return nil
+	}
+	return cookies[0]
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(nil, Options{Secret: []byte("secret")})
+	assert.Error(t, err)
+	_, err = NewManager(NewMemoryStore(), Options{})
+	assert.Error(t, err)
+	m, err := NewManager(NewMemoryStore(), Options{Secret: []byte("secret"), RenewWindow: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCookieName, m.options.CookieName)
+	assert.Equal(t, DefaultMaxAge, m.options.MaxAge)
+	assert.Equal(t, DefaultMaxAge/2, m.options.RenewWindow)
+	assert.True(t, m.options.Cookie.HTTPOnly)
+}
+
+func TestManager_Middleware(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+	store := NewMemoryStore()
+	r, _ := newTestRouter(t, store)
+
+	// anonymous session isn't stored
+	resp := doRequest(r, http.MethodGet, "/me")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Result().Cookies())
+	assert.Equal(t, 0, store.Len())
+
+	resp = doRequest(r, http.MethodPost, "/login?user=admin")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	cookie := sessionCookie(t, resp)
+	assert.Equal(t, DefaultCookieName, cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.Equal(t, 1, store.Len())
+
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Equal(t, "admin", resp.Body.String())
+	assert.Empty(t, resp.Result().Cookies())
+
+	// tampered cookie
+	resp = doRequest(r, http.MethodGet, "/me", &http.Cookie{Name: DefaultCookieName, Value: cookie.Value + "x"})
+	assert.Empty(t, resp.Body.String())
+	resp = doRequest(r, http.MethodGet, "/me", &http.Cookie{Name: DefaultCookieName, Value: "abc"})
+	assert.Empty(t, resp.Body.String())
+
+	// login again, regenerate id and delete old session
+	resp = doRequest(r, http.MethodPost, "/login?user=root", cookie)
+	newCookie := sessionCookie(t, resp)
+	assert.NotEqual(t, cookie.Value, newCookie.Value)
+	assert.Equal(t, 1, store.Len())
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Empty(t, resp.Body.String())
+	cookie = newCookie
+
+	// renew within window
+	now = now.Add(40 * time.Second)
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Equal(t, "root", resp.Body.String())
+	sessionCookie(t, resp)
+	now = now.Add(40 * time.Second)
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Equal(t, "root", resp.Body.String())
+
+	// modified after writing response
+	resp = doRequest(r, http.MethodPost, "/late", cookie)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// logout
+	resp = doRequest(r, http.MethodPost, "/logout", cookie)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, -1, sessionCookie(t, resp).MaxAge)
+	assert.Equal(t, 0, store.Len())
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Empty(t, resp.Body.String())
+	// logout anonymous
+	resp = doRequest(r, http.MethodPost, "/logout")
+	assert.Equal(t, -1, sessionCookie(t, resp).MaxAge)
+
+	// expired
+	resp = doRequest(r, http.MethodPost, "/login?user=admin")
+	cookie = sessionCookie(t, resp)
+	now = now.Add(time.Minute)
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Empty(t, resp.Body.String())
+}
+
+type mockStore struct {
+	*MemoryStore
+	loadErr, saveErr, deleteErr error
+}
+
+func (s *mockStore) Load(ctx context.Context, id string) (*Session, error) {
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	return s.MemoryStore.Load(ctx, id)
+}
+
+func (s *mockStore) Save(ctx context.Context, session *Session) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	return s.MemoryStore.Save(ctx, session)
+}
+
+func (s *mockStore) Delete(ctx context.Context, id string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	return s.MemoryStore.Delete(ctx, id)
+}
+
+func TestManager_Failure(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+		randReadFunc = rand.Read
+	}()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+	store := &mockStore{MemoryStore: NewMemoryStore()}
+	r, m := newTestRouter(t, store)
+	cookie := sessionCookie(t, doRequest(r, http.MethodPost, "/login?user=admin"))
+
+	store.loadErr = fmt.Errorf("err")
+	resp := doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	store.loadErr = nil
+
+	// renew/save failure
+	store.saveErr = fmt.Errorf("err")
+	now = now.Add(40 * time.Second)
+	resp = doRequest(r, http.MethodGet, "/me", cookie)
+	assert.Equal(t, "admin", resp.Body.String())
+	resp = doRequest(r, http.MethodPost, "/late", cookie)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	store.saveErr = nil
+
+	// delete failure
+	store.deleteErr = fmt.Errorf("err")
+	resp = doRequest(r, http.MethodPost, "/logout", cookie)
+	for _, setCookie := range resp.Result().Cookies() {
+		assert.NotEqual(t, -1, setCookie.MaxAge)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	s := newSession("id", now, time.Minute)
+	s.oldID = "old"
+	c.Set(sessionKey, s)
+	assert.Error(t, m.Save(c))
+	store.deleteErr = nil
+
+	// no session
+	c.Keys = nil
+	assert.Nil(t, SessionFromContext(c))
+	assert.Error(t, m.Save(c))
+
+	randReadFunc = func(_ []byte) (int, error) {
+		return 0, fmt.Errorf("err")
+	}
+	resp = doRequest(r, http.MethodGet, "/me")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
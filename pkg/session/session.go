@@ -0,0 +1,136 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Session represents the server-side state of a console login session.
+// Session is accessed by the request goroutine only, so it isn't thread-safe.
+type Session struct {
+	id        string
+	values    map[string]string
+	createdAt time.Time
+	expiresAt time.Time
+
+	oldID     string // previous id after RegenerateID, deleted from store when saving
+	isNew     bool
+	modified  bool
+	destroyed bool
+}
+
+// newSession creates a new session with the id.
+func newSession(id string, now time.Time, maxAge time.Duration) *Session {
+	return &Session{
+		id:        id,
+		values:    make(map[string]string),
+		createdAt: now,
+		expiresAt: now.Add(maxAge),
+		isNew:     true,
+	}
+}
+
+// ID returns the session id.
+func (s *Session) ID() string { return s.id }
+
+// CreatedAt returns the creation time of the session.
+func (s *Session) CreatedAt() time.Time { return s.createdAt }
+
+// ExpiresAt returns the expiration time of the session.
+func (s *Session) ExpiresAt() time.Time { return s.expiresAt }
+
+// IsNew returns if the session is created by current request.
+func (s *Session) IsNew() bool { return s.isNew }
+
+// IsDestroyed returns if the session is destroyed(e.g. logout).
+func (s *Session) IsDestroyed() bool { return s.destroyed }
+
+// Get returns the value of the key.
+func (s *Session) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set sets the value of the key.
+func (s *Session) Set(key, value string) {
+	if old, ok := s.values[key]; ok && old == value {
+		return
+	}
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value of the key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+	delete(s.values, key)
+	s.modified = true
+}
+
+// Keys returns the sorted keys of the session.
+func (s *Session) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Destroy destroys the session, which is deleted from store and cookie after handling request.
+func (s *Session) Destroy() {
+	s.destroyed = true
+}
+
+// RegenerateID changes the session id and keeps the values, which should be called after login
+// to prevent session fixation.
+func (s *Session) RegenerateID() error {
+	id, err := newSessionIDFunc()
+	if err != nil {
+		return err
+	}
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = id
+	s.modified = true
+	return nil
+}
+
+// isExpired returns if the session is expired at the time.
+func (s *Session) isExpired(now time.Time) bool {
+	return !now.Before(s.expiresAt)
+}
+
+// clone returns a deep copy of the session for storing.
+func (s *Session) clone() *Session {
+	values := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return &Session{
+		id:        s.id,
+		values:    values,
+		createdAt: s.createdAt,
+		expiresAt: s.expiresAt,
+	}
+}
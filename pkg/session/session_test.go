@@ -0,0 +1,85 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	now := time.Now()
+	s := newSession("id", now, time.Minute)
+	assert.Equal(t, "id", s.ID())
+	assert.True(t, s.IsNew())
+	assert.Equal(t, now, s.CreatedAt())
+	assert.Equal(t, now.Add(time.Minute), s.ExpiresAt())
+	assert.False(t, s.isExpired(now))
+	assert.True(t, s.isExpired(now.Add(time.Minute)))
+
+	s.Delete("user")
+	assert.False(t, s.modified)
+	s.Set("user", "admin")
+	s.Set("role", "admin")
+	assert.True(t, s.modified)
+	value, ok := s.Get("user")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", value)
+	assert.Equal(t, []string{"role", "user"}, s.Keys())
+
+	s.modified = false
+	s.Set("user", "admin")
+	assert.False(t, s.modified)
+	s.Delete("role")
+	assert.True(t, s.modified)
+	_, ok = s.Get("role")
+	assert.False(t, ok)
+
+	c := s.clone()
+	c.Set("user", "other")
+	value, _ = s.Get("user")
+	assert.Equal(t, "admin", value)
+
+	s.Destroy()
+	assert.True(t, s.IsDestroyed())
+}
+
+func TestSession_RegenerateID(t *testing.T) {
+	defer func() {
+		newSessionIDFunc = newSessionID
+	}()
+	s := newSession("id", time.Now(), time.Minute)
+	// new session not stored
+	assert.NoError(t, s.RegenerateID())
+	assert.NotEqual(t, "id", s.ID())
+	assert.Empty(t, s.oldID)
+
+	s.isNew = false
+	id := s.ID()
+	assert.NoError(t, s.RegenerateID())
+	assert.NoError(t, s.RegenerateID())
+	assert.Equal(t, id, s.oldID)
+
+	newSessionIDFunc = func() (string, error) {
+		return "", fmt.Errorf("err")
+	}
+	assert.Error(t, s.RegenerateID())
+}
@@ -0,0 +1,205 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lindb/common/pkg/state"
+)
+
+// Store represents the backing store of sessions.
+type Store interface {
+	// Load returns the session by id, returns nil if not exist or expired.
+	Load(ctx context.Context, id string) (*Session, error)
+	// Save saves the session until it's expired.
+	Save(ctx context.Context, s *Session) error
+	// Delete deletes the session by id.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore implements Store in memory, which is used by standalone mode.
+type MemoryStore struct {
+	sessions map[string]*Session
+	mutex    sync.Mutex
+}
+
+// NewMemoryStore creates a memory session store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]*Session)}
+}
+
+// Load returns the session by id, returns nil if not exist or expired.
+func (ms *MemoryStore) Load(_ context.Context, id string) (*Session, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	s, ok := ms.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if s.isExpired(nowFunc()) {
+		delete(ms.sessions, id)
+		return nil, nil
+	}
+	return s.clone(), nil
+}
+
+// Save saves the session until it's expired.
+func (ms *MemoryStore) Save(_ context.Context, s *Session) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.sessions[s.id] = s.clone()
+	return nil
+}
+
+// Delete deletes the session by id.
+func (ms *MemoryStore) Delete(_ context.Context, id string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.sessions, id)
+	return nil
+}
+
+// GC removes the expired sessions, returns the number of removed sessions.
+func (ms *MemoryStore) GC() int {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	now := nowFunc()
+	removed := 0
+	for id, s := range ms.sessions {
+		if s.isExpired(now) {
+			delete(ms.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of sessions(including expired but not removed).
+func (ms *MemoryStore) Len() int {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	return len(ms.sessions)
+}
+
+// StateStore implements Store based on state repository, so that the sessions are shared by all console nodes,
+// each session is attached to a lease with its remaining lifetime, so that abandoned sessions are removed
+// by state repository.
+type StateStore struct {
+	repo       state.Repository
+	prefix     string
+	isNotExist func(err error) bool
+}
+
+// NewStateStore creates a session store based on state repository, the sessions are stored under the key prefix,
+// isNotExist checks if the error returned by state repository means the key not exists.
+func NewStateStore(repo state.Repository, prefix string, isNotExist func(err error) bool) *StateStore {
+	if isNotExist == nil {
+		isNotExist = func(_ error) bool { return false }
+	}
+	return &StateStore{
+		repo:       repo,
+		prefix:     prefix,
+		isNotExist: isNotExist,
+	}
+}
+
+// storedSession represents the stored format of session.
+type storedSession struct {
+	Values    map[string]string `json:"values"`
+	CreatedAt int64             `json:"createdAt"`
+	ExpiresAt int64             `json:"expiresAt"`
+}
+
+// Load returns the session by id, returns nil if not exist or expired.
+func (ss *StateStore) Load(ctx context.Context, id string) (*Session, error) {
+	data, err := ss.repo.Get(ctx, ss.key(id))
+	if err != nil {
+		if ss.isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	stored := &storedSession{}
+	if err = json.Unmarshal(data, stored); err != nil {
+		return nil, err
+	}
+	s := &Session{
+		id:        id,
+		values:    stored.Values,
+		createdAt: time.UnixMilli(stored.CreatedAt),
+		expiresAt: time.UnixMilli(stored.ExpiresAt),
+	}
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	if s.isExpired(nowFunc()) {
+		// expired session is removed lazily
+		if err = ss.repo.Delete(ctx, ss.key(id)); err != nil && !ss.isNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return s, nil
+}
+
+// Save saves the session with a lease until it's expired, the expired session is deleted.
+func (ss *StateStore) Save(ctx context.Context, s *Session) error {
+	ttl := s.expiresAt.Sub(nowFunc())
+	if ttl <= 0 {
+		return ss.Delete(ctx, s.id)
+	}
+	data, err := json.Marshal(&storedSession{
+		Values:    s.values,
+		CreatedAt: s.createdAt.UnixMilli(),
+		ExpiresAt: s.expiresAt.UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	// lease ttl is in seconds(e.g. etcd), round up so that the session isn't removed before expired
+	lease, err := ss.repo.Grant(ctx, (ttl + time.Second - 1).Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+	return ss.repo.PutWithLease(ctx, ss.key(s.id), data, lease)
+}
+
+// Delete deletes the session by id.
+func (ss *StateStore) Delete(ctx context.Context, id string) error {
+	if err := ss.repo.Delete(ctx, ss.key(id)); err != nil && !ss.isNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// key returns the state key of the session.
+func (ss *StateStore) key(id string) string {
+	return ss.prefix + "/" + id
+}
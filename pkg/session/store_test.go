@@ -0,0 +1,250 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/pkg/state"
+)
+
+var errNotExist = errors.New("not exist")
+
+// mockRepo implements state.Repository in memory, records the ttl of lease attached to key.
+type mockRepo struct {
+	data      map[string][]byte
+	leases    map[state.LeaseID]time.Duration
+	keyLeases map[string]state.LeaseID
+	getErr    error
+	deleteErr error
+	grantErr  error
+	mutex     sync.Mutex
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{
+		data:      make(map[string][]byte),
+		leases:    make(map[state.LeaseID]time.Duration),
+		keyLeases: make(map[string]state.LeaseID),
+	}
+}
+
+func (r *mockRepo) Grant(_ context.Context, ttl time.Duration) (state.LeaseID, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.grantErr != nil {
+		return 0, r.grantErr
+	}
+	lease := state.LeaseID(len(r.leases) + 1)
+	r.leases[lease] = ttl
+	return lease, nil
+}
+
+func (r *mockRepo) KeepAliveOnce(_ context.Context, _ state.LeaseID) error {
+	return nil
+}
+
+func (r *mockRepo) Revoke(_ context.Context, _ state.LeaseID) error {
+	return nil
+}
+
+func (r *mockRepo) PutWithLease(_ context.Context, key string, value []byte, lease state.LeaseID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.data[key] = value
+	r.keyLeases[key] = lease
+	return nil
+}
+
+func (r *mockRepo) List(_ context.Context, _ string) ([]state.KeyValue, error) {
+	return nil, nil
+}
+
+func (r *mockRepo) Watch(_ context.Context, _ string) <-chan state.Event {
+	return nil
+}
+
+// leaseTTL returns the ttl of lease attached to the key.
+func (r *mockRepo) leaseTTL(key string) time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.leases[r.keyLeases[key]]
+}
+
+func (r *mockRepo) Get(_ context.Context, key string) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	data, ok := r.data[key]
+	if !ok {
+		return nil, errNotExist
+	}
+	return data, nil
+}
+
+func (r *mockRepo) Put(_ context.Context, key string, value []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.data[key] = value
+	return nil
+}
+
+func (r *mockRepo) Delete(_ context.Context, key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.deleteErr != nil {
+		return r.deleteErr
+	}
+	if _, ok := r.data[key]; !ok {
+		return errNotExist
+	}
+	delete(r.data, key)
+	return nil
+}
+
+func TestMemoryStore(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+	ctx := context.TODO()
+
+	store := NewMemoryStore()
+	s, err := store.Load(ctx, "id1")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	s1 := newSession("id1", now, time.Minute)
+	s1.Set("user", "admin")
+	assert.NoError(t, store.Save(ctx, s1))
+	assert.NoError(t, store.Save(ctx, newSession("id2", now, 2*time.Minute)))
+	assert.NoError(t, store.Save(ctx, newSession("id3", now, 3*time.Minute)))
+	s, err = store.Load(ctx, "id1")
+	assert.NoError(t, err)
+	assert.False(t, s.IsNew())
+	value, _ := s.Get("user")
+	assert.Equal(t, "admin", value)
+	// stored copy
+	s.Set("user", "other")
+	s, _ = store.Load(ctx, "id1")
+	value, _ = s.Get("user")
+	assert.Equal(t, "admin", value)
+
+	assert.NoError(t, store.Delete(ctx, "id1"))
+	s, _ = store.Load(ctx, "id1")
+	assert.Nil(t, s)
+
+	now = now.Add(2 * time.Minute)
+	s, _ = store.Load(ctx, "id2")
+	assert.Nil(t, s)
+	assert.Equal(t, 1, store.Len())
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, store.GC())
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStateStore(t *testing.T) {
+	defer func() {
+		nowFunc = time.Now
+	}()
+	now := time.UnixMilli(time.Now().UnixMilli())
+	nowFunc = func() time.Time { return now }
+	ctx := context.TODO()
+	repo := newMockRepo()
+
+	store := NewStateStore(repo, "/console/sessions", func(err error) bool { return errors.Is(err, errNotExist) })
+	s, err := store.Load(ctx, "id1")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	s1 := newSession("id1", now, time.Minute)
+	s1.Set("user", "admin")
+	assert.NoError(t, store.Save(ctx, s1))
+	assert.Contains(t, repo.data, "/console/sessions/id1")
+	assert.Equal(t, time.Minute, repo.leaseTTL("/console/sessions/id1"))
+	// lease ttl is rounded up to second
+	now = now.Add(time.Millisecond)
+	assert.NoError(t, store.Save(ctx, s1))
+	assert.Equal(t, time.Minute, repo.leaseTTL("/console/sessions/id1"))
+	now = now.Add(-time.Millisecond)
+	repo.grantErr = fmt.Errorf("err")
+	assert.Error(t, store.Save(ctx, s1))
+	repo.grantErr = nil
+	s, err = store.Load(ctx, "id1")
+	assert.NoError(t, err)
+	assert.Equal(t, "id1", s.ID())
+	assert.Equal(t, now, s.CreatedAt())
+	assert.Equal(t, now.Add(time.Minute), s.ExpiresAt())
+	value, _ := s.Get("user")
+	assert.Equal(t, "admin", value)
+
+	assert.NoError(t, store.Delete(ctx, "id1"))
+	assert.NoError(t, store.Delete(ctx, "id1"))
+
+	// empty values
+	repo.data["/console/sessions/id2"] = []byte(fmt.Sprintf(`{"expiresAt":%d}`, now.Add(time.Minute).UnixMilli()))
+	s, err = store.Load(ctx, "id2")
+	assert.NoError(t, err)
+	s.Set("k", "v")
+	// empty data
+	repo.data["/console/sessions/id2"] = nil
+	s, err = store.Load(ctx, "id2")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+	// corrupted data
+	repo.data["/console/sessions/id2"] = []byte("abc")
+	_, err = store.Load(ctx, "id2")
+	assert.Error(t, err)
+
+	// expired
+	assert.NoError(t, store.Save(ctx, newSession("id3", now, time.Minute)))
+	now = now.Add(time.Minute)
+	repo.deleteErr = fmt.Errorf("err")
+	_, err = store.Load(ctx, "id3")
+	assert.Error(t, err)
+	assert.Error(t, store.Delete(ctx, "id3"))
+	repo.deleteErr = nil
+	s, err = store.Load(ctx, "id3")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+	assert.NotContains(t, repo.data, "/console/sessions/id3")
+	// expired session is deleted instead of saved
+	repo.data["/console/sessions/id4"] = []byte("{}")
+	assert.NoError(t, store.Save(ctx, newSession("id4", now.Add(-time.Minute), time.Minute)))
+	assert.NotContains(t, repo.data, "/console/sessions/id4")
+
+	repo.getErr = fmt.Errorf("err")
+	_, err = store.Load(ctx, "id3")
+	assert.Error(t, err)
+
+	// default not exist checker
+	store = NewStateStore(repo, "/sessions", nil)
+	repo.getErr = nil
+	_, err = store.Load(ctx, "id3")
+	assert.ErrorIs(t, err, errNotExist)
+}
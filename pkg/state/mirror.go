@@ -0,0 +1,200 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/common/pkg/logger"
+)
+
+// Mirror mirrors the key/values under prefix into local cache. The watch events are coalesced by key and
+// delivered in batch every batch interval, so bursts of changes don't thrash the consumer; the cache is
+// fully resynced with state repository every resync interval, which repairs the changes missed by watch.
+type Mirror struct {
+	repo           Repository
+	prefix         string
+	batchInterval  time.Duration
+	resyncInterval time.Duration
+
+	cache   map[string][]byte
+	pending map[string]Event
+	keys    []string // pending keys by arrival order
+
+	lock   sync.RWMutex
+	logger logger.Logger
+}
+
+// NewMirror creates a mirror of the key/values under prefix, resync is disabled if resync interval <= 0.
+func NewMirror(repo Repository, prefix string, batchInterval, resyncInterval time.Duration) (*Mirror, error) {
+	if batchInterval <= 0 {
+		return nil, fmt.Errorf("mirror batch interval: %s should > 0", batchInterval)
+	}
+	return &Mirror{
+		repo:           repo,
+		prefix:         prefix,
+		batchInterval:  batchInterval,
+		resyncInterval: resyncInterval,
+		cache:          make(map[string][]byte),
+		pending:        make(map[string]Event),
+		logger:         logger.GetLogger("State", "Mirror"),
+	}, nil
+}
+
+// Get returns the cached value of the key.
+func (m *Mirror) Get(key string) ([]byte, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, ok := m.cache[key]
+	return value, ok
+}
+
+// List returns the cached key/values sorted by key.
+func (m *Mirror) List() []KeyValue {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	kvs := make([]KeyValue, 0, len(m.cache))
+	for key, value := range m.cache {
+		kvs = append(kvs, KeyValue{Key: key, Value: value})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+// Run loads the key/values then keeps the cache in sync until ctx is done, fn is invoked with the changes
+// applied to cache(initial load included), events of same key in one batch are coalesced into the last one,
+// changes which don't modify the cache are dropped.
+func (m *Mirror) Run(ctx context.Context, fn func(events []Event)) error {
+	// watch before list, avoid missing changes between list and watch
+	events := m.repo.Watch(ctx, m.prefix)
+	if err := m.resync(ctx, fn); err != nil {
+		return err
+	}
+	batch := time.NewTicker(m.batchInterval)
+	defer batch.Stop()
+	var resyncC <-chan time.Time
+	if m.resyncInterval > 0 {
+		resync := time.NewTicker(m.resyncInterval)
+		defer resync.Stop()
+		resyncC = resync.C
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				m.flush(fn)
+				return nil
+			}
+			m.enqueue(event)
+		case <-batch.C:
+			m.flush(fn)
+		case <-resyncC:
+			m.flush(fn)
+			if err := m.resync(ctx, fn); err != nil && ctx.Err() == nil {
+				m.logger.Warn("resync failure, retry next resync interval",
+					logger.String("prefix", m.prefix), logger.Error(err))
+			}
+		}
+	}
+}
+
+// enqueue coalesces the event into pending changes.
+func (m *Mirror) enqueue(event Event) {
+	if !strings.HasPrefix(event.Key, m.prefix) {
+		return
+	}
+	if _, ok := m.pending[event.Key]; !ok {
+		m.keys = append(m.keys, event.Key)
+	}
+	m.pending[event.Key] = event
+}
+
+// flush applies the pending changes to cache, then invokes fn with the effective changes.
+func (m *Mirror) flush(fn func(events []Event)) {
+	if len(m.keys) == 0 {
+		return
+	}
+	changes := make([]Event, 0, len(m.keys))
+	m.lock.Lock()
+	for _, key := range m.keys {
+		if event, ok := m.apply(m.pending[key]); ok {
+			changes = append(changes, event)
+		}
+	}
+	m.lock.Unlock()
+	m.keys = m.keys[:0]
+	clear(m.pending)
+	if len(changes) > 0 {
+		fn(changes)
+	}
+}
+
+// resync lists the key/values, then invokes fn with the differences between cache and state repository.
+func (m *Mirror) resync(ctx context.Context, fn func(events []Event)) error {
+	kvs, err := m.repo.List(ctx, m.prefix)
+	if err != nil {
+		return err
+	}
+	var changes []Event
+	m.lock.Lock()
+	keys := make(map[string]struct{}, len(kvs))
+	for _, kv := range kvs {
+		keys[kv.Key] = struct{}{}
+		if event, ok := m.apply(Event{Type: EventPut, Key: kv.Key, Value: kv.Value}); ok {
+			changes = append(changes, event)
+		}
+	}
+	for key := range m.cache {
+		if _, ok := keys[key]; !ok {
+			delete(m.cache, key)
+			changes = append(changes, Event{Type: EventDelete, Key: key})
+		}
+	}
+	m.lock.Unlock()
+	if len(changes) > 0 {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+		fn(changes)
+	}
+	return nil
+}
+
+// apply applies the event to cache, returns false if the cache isn't modified.
+func (m *Mirror) apply(event Event) (Event, bool) {
+	old, exist := m.cache[event.Key]
+	switch event.Type {
+	case EventPut:
+		if exist && string(old) == string(event.Value) {
+			return event, false
+		}
+		m.cache[event.Key] = event.Value
+		return event, true
+	case EventDelete:
+		if !exist {
+			return event, false
+		}
+		delete(m.cache, event.Key)
+		return event, true
+	default:
+		return event, false
+	}
+}
@@ -0,0 +1,129 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMirror(t *testing.T) {
+	m, err := NewMirror(newMockRepo(), "/db/", 0, 0)
+	assert.Error(t, err)
+	assert.Nil(t, m)
+}
+
+func TestMirror_Run(t *testing.T) {
+	repo := newMockRepo()
+	repo.put("/db/1", []byte("1"))
+	repo.put("/other/1", []byte("1"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	// large batch interval, events are flushed when watch is closed
+	m, err := NewMirror(repo, "/db/", time.Hour, 0)
+	assert.NoError(t, err)
+
+	changes := make(chan []Event, 10)
+	done := make(chan error)
+	go func() {
+		done <- m.Run(ctx, func(events []Event) {
+			changes <- events
+		})
+	}()
+	assert.Equal(t, []Event{{Type: EventPut, Key: "/db/1", Value: []byte("1")}}, <-changes)
+
+	repo.put("/db/2", []byte("2"))
+	repo.put("/db/2", []byte("3"))
+	repo.put("/db/3", []byte("3"))
+	assert.NoError(t, repo.Delete(ctx, "/db/3"))
+	repo.put("/db/1", []byte("1"))
+	repo.put("/other/2", []byte("2"))
+	cancel()
+	assert.NoError(t, <-done)
+	// coalesced by key, changes which don't modify cache are dropped
+	assert.Equal(t, []Event{{Type: EventPut, Key: "/db/2", Value: []byte("3")}}, <-changes)
+	assert.Empty(t, changes)
+
+	value, ok := m.Get("/db/2")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), value)
+	_, ok = m.Get("/db/3")
+	assert.False(t, ok)
+	assert.Equal(t, []KeyValue{{Key: "/db/1", Value: []byte("1")}, {Key: "/db/2", Value: []byte("3")}}, m.List())
+
+	repo.listErr = fmt.Errorf("err")
+	assert.Error(t, m.Run(context.TODO(), func(_ []Event) {}))
+}
+
+func TestMirror_Batch(t *testing.T) {
+	repo := newMockRepo()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	m, err := NewMirror(repo, "/db/", time.Millisecond, 0)
+	assert.NoError(t, err)
+
+	changes := make(chan []Event, 10)
+	go func() {
+		_ = m.Run(ctx, func(events []Event) {
+			changes <- events
+		})
+	}()
+	repo.put("/db/1", []byte("1"))
+	assert.Equal(t, []Event{{Type: EventPut, Key: "/db/1", Value: []byte("1")}}, <-changes)
+}
+
+func TestMirror_Resync(t *testing.T) {
+	repo := newMockRepo()
+	repo.put("/db/1", []byte("1"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	m, err := NewMirror(repo, "/db/", time.Hour, time.Millisecond)
+	assert.NoError(t, err)
+
+	changes := make(chan []Event, 10)
+	go func() {
+		_ = m.Run(ctx, func(events []Event) {
+			changes <- events
+		})
+	}()
+	assert.Equal(t, []Event{{Type: EventPut, Key: "/db/1", Value: []byte("1")}}, <-changes)
+
+	// changes missed by watch are repaired by resync
+	repo.lock.Lock()
+	delete(repo.kvs, "/db/1")
+	repo.kvs["/db/2"] = []byte("2")
+	repo.lock.Unlock()
+	assert.Equal(t, []Event{
+		{Type: EventDelete, Key: "/db/1"},
+		{Type: EventPut, Key: "/db/2", Value: []byte("2")},
+	}, <-changes)
+
+	// resync failure is retried
+	repo.lock.Lock()
+	repo.listErr = fmt.Errorf("err")
+	repo.lock.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	repo.lock.Lock()
+	repo.listErr = nil
+	repo.kvs["/db/3"] = []byte("3")
+	repo.lock.Unlock()
+	assert.Equal(t, []Event{{Type: EventPut, Key: "/db/3", Value: []byte("3")}}, <-changes)
+}
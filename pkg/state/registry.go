@@ -0,0 +1,232 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/common/models"
+	"github.com/lindb/common/pkg/logger"
+)
+
+// Registry registers the member itself with ttl lease into state repository, the lease is renewed by heartbeat,
+// so the registration is removed automatically if the member is down. Members of same prefix can be listed/watched.
+type Registry[T any] struct {
+	repo   Repository
+	prefix string
+	ttl    time.Duration
+
+	id     string
+	value  []byte
+	lease  LeaseID
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock   sync.Mutex
+	logger logger.Logger
+}
+
+// NewRegistry creates a registry for members under the prefix, the ttl is the lease ttl of registration.
+func NewRegistry[T any](repo Repository, prefix string, ttl time.Duration) (*Registry[T], error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("registry ttl: %s should > 0", ttl)
+	}
+	return &Registry[T]{
+		repo:   repo,
+		prefix: strings.TrimRight(prefix, "/"),
+		ttl:    ttl,
+		logger: logger.GetLogger("State", "Registry"),
+	}, nil
+}
+
+// NewNodeRegistry creates a registry for live nodes under the prefix.
+func NewNodeRegistry(repo Repository, prefix string, ttl time.Duration) (*Registry[models.LiveNode], error) {
+	return NewRegistry[models.LiveNode](repo, prefix, ttl)
+}
+
+// Register registers the member with id, then starts the heartbeat which renews the lease every ttl/3,
+// the member is registered again if the lease is lost(e.g. expired by network partition).
+func (r *Registry[T]) Register(ctx context.Context, id string, member T) error {
+	value, err := json.Marshal(member)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.cancel != nil {
+		return fmt.Errorf("member: %s is already registered", r.id)
+	}
+	r.id = id
+	r.value = value
+	if err = r.register(ctx); err != nil {
+		return err
+	}
+	heartbeatCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.heartbeat(heartbeatCtx, r.done)
+	return nil
+}
+
+// Deregister stops the heartbeat then revokes the lease, the registration is removed.
+func (r *Registry[T]) Deregister(ctx context.Context) error {
+	r.lock.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.lock.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.repo.Revoke(ctx, r.lease)
+}
+
+// Members returns the live members sorted by id.
+func (r *Registry[T]) Members(ctx context.Context) ([]T, error) {
+	members, err := r.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sortedMembers(members), nil
+}
+
+// Watch invokes fn with the live members sorted by id at beginning and after each membership change,
+// it blocks until ctx is done.
+func (r *Registry[T]) Watch(ctx context.Context, fn func(members []T)) error {
+	// watch before list, avoid missing changes between list and watch
+	events := r.repo.Watch(ctx, r.prefix+"/")
+	members, err := r.list(ctx)
+	if err != nil {
+		return err
+	}
+	fn(sortedMembers(members))
+	for event := range events {
+		id := strings.TrimPrefix(event.Key, r.prefix+"/")
+		switch event.Type {
+		case EventPut:
+			var member T
+			if err = json.Unmarshal(event.Value, &member); err != nil {
+				r.logger.Warn("decode member failure, ignore it",
+					logger.String("key", event.Key), logger.Error(err))
+				continue
+			}
+			members[id] = member
+		case EventDelete:
+			delete(members, id)
+		default:
+			continue
+		}
+		fn(sortedMembers(members))
+	}
+	return nil
+}
+
+// register grants a lease then puts the member attached to the lease.
+func (r *Registry[T]) register(ctx context.Context) error {
+	lease, err := r.repo.Grant(ctx, r.ttl)
+	if err != nil {
+		return err
+	}
+	if err = r.repo.PutWithLease(ctx, r.key(r.id), r.value, lease); err != nil {
+		return err
+	}
+	r.lease = lease
+	return nil
+}
+
+// heartbeat renews the lease until ctx is done.
+func (r *Registry[T]) heartbeat(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(max(r.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.renew(ctx)
+		}
+	}
+}
+
+// renew renews the lease, registers again if renewal fails.
+func (r *Registry[T]) renew(ctx context.Context) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	err := r.repo.KeepAliveOnce(ctx, r.lease)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	r.logger.Warn("renew lease failure, register again",
+		logger.String("id", r.id), logger.Error(err))
+	if err = r.register(ctx); err != nil && ctx.Err() == nil {
+		r.logger.Error("register again failure, retry next heartbeat",
+			logger.String("id", r.id), logger.Error(err))
+	}
+}
+
+// list returns the live members by id.
+func (r *Registry[T]) list(ctx context.Context) (map[string]T, error) {
+	kvs, err := r.repo.List(ctx, r.prefix+"/")
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]T, len(kvs))
+	for _, kv := range kvs {
+		var member T
+		if err = json.Unmarshal(kv.Value, &member); err != nil {
+			r.logger.Warn("decode member failure, ignore it",
+				logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		members[strings.TrimPrefix(kv.Key, r.prefix+"/")] = member
+	}
+	return members, nil
+}
+
+// key returns the state key of member.
+func (r *Registry[T]) key(id string) string {
+	return r.prefix + "/" + id
+}
+
+// sortedMembers returns the members sorted by id.
+func sortedMembers[T any](members map[string]T) []T {
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rs := make([]T, 0, len(ids))
+	for _, id := range ids {
+		rs = append(rs, members[id])
+	}
+	return rs
+}
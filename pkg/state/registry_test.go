@@ -0,0 +1,307 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/models"
+)
+
+// mockRepo implements Repository in memory, leases are expired manually.
+type mockRepo struct {
+	kvs      map[string][]byte
+	leases   map[LeaseID][]string
+	nextID   LeaseID
+	watchers []chan Event
+
+	grantErr, keepAliveErr, putErr, listErr error
+
+	lock sync.Mutex
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{kvs: make(map[string][]byte), leases: make(map[LeaseID][]string)}
+}
+
+func (r *mockRepo) Grant(_ context.Context, _ time.Duration) (LeaseID, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.grantErr != nil {
+		return 0, r.grantErr
+	}
+	r.nextID++
+	r.leases[r.nextID] = nil
+	return r.nextID, nil
+}
+
+func (r *mockRepo) Get(_ context.Context, key string) ([]byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	value, ok := r.kvs[key]
+	if !ok {
+		return nil, fmt.Errorf("key not found")
+	}
+	return value, nil
+}
+
+func (r *mockRepo) Put(_ context.Context, key string, value []byte) error {
+	r.put(key, value)
+	return nil
+}
+
+func (r *mockRepo) Delete(_ context.Context, key string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.kvs, key)
+	r.notify(Event{Type: EventDelete, Key: key})
+	return nil
+}
+
+func (r *mockRepo) KeepAliveOnce(_ context.Context, lease LeaseID) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.keepAliveErr != nil {
+		return r.keepAliveErr
+	}
+	if _, ok := r.leases[lease]; !ok {
+		return fmt.Errorf("lease not found")
+	}
+	return nil
+}
+
+func (r *mockRepo) Revoke(_ context.Context, lease LeaseID) error {
+	r.expire(lease)
+	return nil
+}
+
+func (r *mockRepo) PutWithLease(_ context.Context, key string, value []byte, lease LeaseID) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.putErr != nil {
+		return r.putErr
+	}
+	r.kvs[key] = value
+	r.leases[lease] = append(r.leases[lease], key)
+	r.notify(Event{Type: EventPut, Key: key, Value: value})
+	return nil
+}
+
+func (r *mockRepo) List(_ context.Context, prefix string) ([]KeyValue, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.listErr != nil {
+		return nil, r.listErr
+	}
+	var kvs []KeyValue
+	for k, v := range r.kvs {
+		if strings.HasPrefix(k, prefix) {
+			kvs = append(kvs, KeyValue{Key: k, Value: v})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs, nil
+}
+
+func (r *mockRepo) Watch(ctx context.Context, _ string) <-chan Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ch := make(chan Event, 100)
+	r.watchers = append(r.watchers, ch)
+	go func() {
+		<-ctx.Done()
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		close(ch)
+		r.watchers = nil
+	}()
+	return ch
+}
+
+// expire expires the lease, the keys attached are deleted.
+func (r *mockRepo) expire(lease LeaseID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, key := range r.leases[lease] {
+		delete(r.kvs, key)
+		r.notify(Event{Type: EventDelete, Key: key})
+	}
+	delete(r.leases, lease)
+}
+
+func (r *mockRepo) notify(event Event) {
+	for _, ch := range r.watchers {
+		ch <- event
+	}
+}
+
+func (r *mockRepo) put(key string, value []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.kvs[key] = value
+	r.notify(Event{Type: EventPut, Key: key, Value: value})
+}
+
+func (r *mockRepo) leaseCount() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.leases)
+}
+
+func TestNewRegistry(t *testing.T) {
+	r, err := NewNodeRegistry(newMockRepo(), "/live/nodes/", 0)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	r, err = NewNodeRegistry(newMockRepo(), "/live/nodes/", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "/live/nodes/1", r.key("1"))
+	// deregister without register
+	assert.NoError(t, r.Deregister(context.TODO()))
+}
+
+func TestRegistry_Register(t *testing.T) {
+	repo := newMockRepo()
+	ctx := context.TODO()
+	r1, err := NewNodeRegistry(repo, "/live/nodes", time.Hour)
+	assert.NoError(t, err)
+	r2, err := NewNodeRegistry(repo, "/live/nodes", time.Hour)
+	assert.NoError(t, err)
+
+	node1 := models.LiveNode{ID: "1", HostIP: "1.1.1.1", GRPCPort: 2891}
+	node2 := models.LiveNode{ID: "2", HostIP: "1.1.1.2", GRPCPort: 2891}
+	assert.NoError(t, r2.Register(ctx, "2", node2))
+	assert.NoError(t, r1.Register(ctx, "1", node1))
+	assert.Error(t, r1.Register(ctx, "1", node1))
+
+	nodes, err := r1.Members(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LiveNode{node1, node2}, nodes)
+
+	// ignore invalid member
+	repo.put("/live/nodes/3", []byte("invalid"))
+	nodes, err = r1.Members(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	assert.NoError(t, r2.Deregister(ctx))
+	nodes, err = r1.Members(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LiveNode{node1}, nodes)
+
+	repo.listErr = fmt.Errorf("err")
+	_, err = r1.Members(ctx)
+	assert.Error(t, err)
+	assert.NoError(t, r1.Deregister(ctx))
+}
+
+func TestRegistry_Register_Failure(t *testing.T) {
+	repo := newMockRepo()
+	ctx := context.TODO()
+	r, err := NewRegistry[func()](repo, "/live", time.Hour)
+	assert.NoError(t, err)
+	// marshal failure
+	assert.Error(t, r.Register(ctx, "1", func() {}))
+
+	nr, err := NewNodeRegistry(repo, "/live/nodes", time.Hour)
+	assert.NoError(t, err)
+	repo.grantErr = fmt.Errorf("err")
+	assert.Error(t, nr.Register(ctx, "1", models.LiveNode{}))
+	repo.grantErr = nil
+	repo.putErr = fmt.Errorf("err")
+	assert.Error(t, nr.Register(ctx, "1", models.LiveNode{}))
+	repo.putErr = nil
+	assert.NoError(t, nr.Register(ctx, "1", models.LiveNode{}))
+	assert.NoError(t, nr.Deregister(ctx))
+}
+
+func TestRegistry_Heartbeat(t *testing.T) {
+	repo := newMockRepo()
+	ctx := context.TODO()
+	r, err := NewNodeRegistry(repo, "/live/nodes", 3*time.Millisecond)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Register(ctx, "1", models.LiveNode{ID: "1"}))
+
+	// lease lost, register again
+	r.lock.Lock()
+	lease := r.lease
+	r.lock.Unlock()
+	repo.expire(lease)
+	assert.Eventually(t, func() bool {
+		nodes, _ := r.Members(ctx)
+		return len(nodes) == 1
+	}, time.Second, time.Millisecond)
+	r.lock.Lock()
+	assert.NotEqual(t, lease, r.lease)
+	r.lock.Unlock()
+
+	// register again failure
+	repo.lock.Lock()
+	repo.keepAliveErr = fmt.Errorf("err")
+	repo.grantErr = fmt.Errorf("err")
+	repo.lock.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	repo.lock.Lock()
+	repo.keepAliveErr = nil
+	repo.grantErr = nil
+	repo.lock.Unlock()
+
+	assert.NoError(t, r.Deregister(ctx))
+	assert.Zero(t, repo.leaseCount())
+}
+
+func TestRegistry_Watch(t *testing.T) {
+	repo := newMockRepo()
+	ctx, cancel := context.WithCancel(context.TODO())
+	r, err := NewNodeRegistry(repo, "/live/nodes", time.Hour)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Register(ctx, "1", models.LiveNode{ID: "1"}))
+
+	changes := make(chan []models.LiveNode, 10)
+	done := make(chan error)
+	go func() {
+		done <- r.Watch(ctx, func(nodes []models.LiveNode) {
+			changes <- nodes
+		})
+	}()
+	assert.Equal(t, []models.LiveNode{{ID: "1"}}, <-changes)
+
+	peer, err := NewNodeRegistry(repo, "/live/nodes", time.Hour)
+	assert.NoError(t, err)
+	assert.NoError(t, peer.Register(ctx, "2", models.LiveNode{ID: "2"}))
+	assert.Equal(t, []models.LiveNode{{ID: "1"}, {ID: "2"}}, <-changes)
+
+	// ignore invalid member
+	repo.put("/live/nodes/3", []byte("invalid"))
+	assert.NoError(t, peer.Deregister(ctx))
+	assert.Equal(t, []models.LiveNode{{ID: "1"}}, <-changes)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.NoError(t, r.Deregister(context.TODO()))
+
+	repo.listErr = fmt.Errorf("err")
+	assert.Error(t, r.Watch(context.TODO(), func(_ []models.LiveNode) {}))
+}
@@ -0,0 +1,77 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// LeaseID represents the id of lease, the keys attached to the lease are deleted when it's expired/revoked.
+type LeaseID int64
+
+// KeyValue represents the key/value in state repository.
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// EventType represents the type of watch event.
+type EventType int
+
+const (
+	// EventPut means the key is created or updated.
+	EventPut EventType = iota + 1
+	// EventDelete means the key is deleted(include expired by lease).
+	EventDelete
+)
+
+// Event represents the change of key under watched prefix.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value []byte // empty for delete event
+}
+
+// Store represents the key/value state store(e.g. etcd) shared by all nodes,
+// which is implemented by the application(e.g. lindb etcd repository).
+type Store interface {
+	// Get returns the value of the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put puts the value of the key.
+	Put(ctx context.Context, key string, value []byte) error
+	// Delete deletes the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Repository represents the state store which supports lease and watch.
+type Repository interface {
+	Store
+	// Grant creates a lease with ttl.
+	Grant(ctx context.Context, ttl time.Duration) (LeaseID, error)
+	// KeepAliveOnce renews the lease once, returns error if the lease is expired/not exist.
+	KeepAliveOnce(ctx context.Context, lease LeaseID) error
+	// Revoke revokes the lease, the keys attached to the lease are deleted.
+	Revoke(ctx context.Context, lease LeaseID) error
+	// PutWithLease puts the value of the key which is attached to the lease.
+	PutWithLease(ctx context.Context, key string, value []byte, lease LeaseID) error
+	// List returns the key/values under the prefix.
+	List(ctx context.Context, prefix string) ([]KeyValue, error)
+	// Watch watches the changes under the prefix, the channel is closed when ctx is done.
+	Watch(ctx context.Context, prefix string) <-chan Event
+}
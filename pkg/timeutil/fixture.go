@@ -0,0 +1,114 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+// TimeFixture represents a verified tricky instant(leap second, daylight saving time transition,
+// non-hour offset etc.) for validating timestamp math in non-UTC deployments.
+type TimeFixture struct {
+	// Name is the description of the instant.
+	Name string
+	// Location is the IANA time zone name.
+	Location string
+	// Timestamp is the unix time in millisecond.
+	Timestamp int64
+	// Wall is the local wall clock of timestamp in DataTimeFormat2.
+	Wall string
+	// Offset is the zone offset in seconds east of UTC at timestamp.
+	Offset int
+	// StartOfDay is the first millisecond of the local day.
+	StartOfDay int64
+	// DayLength is the length(in millisecond) of the local day.
+	DayLength int64
+}
+
+// timeFixtures are verified against tzdata, see fixture_test.go.
+var timeFixtures = []TimeFixture{
+	{
+		Name: "new york before spring forward", Location: "America/New_York",
+		Timestamp: 1710053999000, Wall: "2024-03-10 01:59:59", Offset: -5 * 3600,
+		StartOfDay: 1710046800000, DayLength: 23 * OneHour,
+	},
+	{
+		Name: "new york spring forward, 02:00 is skipped", Location: "America/New_York",
+		Timestamp: 1710054000000, Wall: "2024-03-10 03:00:00", Offset: -4 * 3600,
+		StartOfDay: 1710046800000, DayLength: 23 * OneHour,
+	},
+	{
+		Name: "new york fall back, first 01:30", Location: "America/New_York",
+		Timestamp: 1730611800000, Wall: "2024-11-03 01:30:00", Offset: -4 * 3600,
+		StartOfDay: 1730606400000, DayLength: 25 * OneHour,
+	},
+	{
+		Name: "new york fall back, second 01:30", Location: "America/New_York",
+		Timestamp: 1730615400000, Wall: "2024-11-03 01:30:00", Offset: -5 * 3600,
+		StartOfDay: 1730606400000, DayLength: 25 * OneHour,
+	},
+	{
+		Name: "london spring forward", Location: "Europe/London",
+		Timestamp: 1711846800000, Wall: "2024-03-31 02:00:00", Offset: 3600,
+		StartOfDay: 1711843200000, DayLength: 23 * OneHour,
+	},
+	{
+		Name: "london fall back, second 01:30", Location: "Europe/London",
+		Timestamp: 1729992600000, Wall: "2024-10-27 01:30:00", Offset: 0,
+		StartOfDay: 1729983600000, DayLength: 25 * OneHour,
+	},
+	{
+		Name: "sydney fall back(southern hemisphere)", Location: "Australia/Sydney",
+		Timestamp: 1712419200000, Wall: "2024-04-07 02:00:00", Offset: 10 * 3600,
+		StartOfDay: 1712408400000, DayLength: 25 * OneHour,
+	},
+	{
+		Name: "lord howe half hour spring forward", Location: "Australia/Lord_Howe",
+		Timestamp: 1728142200000, Wall: "2024-10-06 02:30:00", Offset: 11 * 3600,
+		StartOfDay: 1728135000000, DayLength: 23*OneHour + 30*OneMinute,
+	},
+	{
+		Name: "kolkata half hour offset", Location: "Asia/Kolkata",
+		Timestamp: 1710054000000, Wall: "2024-03-10 12:30:00", Offset: 5*3600 + 30*60,
+		StartOfDay: 1710009000000, DayLength: OneDay,
+	},
+	{
+		Name: "kathmandu quarter hour offset", Location: "Asia/Kathmandu",
+		Timestamp: 1710054000000, Wall: "2024-03-10 12:45:00", Offset: 5*3600 + 45*60,
+		StartOfDay: 1710008100000, DayLength: OneDay,
+	},
+	{
+		Name: "sao paulo spring forward, midnight is skipped", Location: "America/Sao_Paulo",
+		Timestamp: 1541300400000, Wall: "2018-11-04 01:00:00", Offset: -2 * 3600,
+		StartOfDay: 1541300400000, DayLength: 23 * OneHour,
+	},
+	{
+		// unix time doesn't count leap second, 23:59:60 isn't representable.
+		Name: "before leap second 2016-12-31 23:59:60", Location: "UTC",
+		Timestamp: 1483228799000, Wall: "2016-12-31 23:59:59", Offset: 0,
+		StartOfDay: 1483142400000, DayLength: OneDay,
+	},
+	{
+		Name: "after leap second 2016-12-31 23:59:60", Location: "UTC",
+		Timestamp: 1483228800000, Wall: "2017-01-01 00:00:00", Offset: 0,
+		StartOfDay: 1483228800000, DayLength: OneDay,
+	},
+}
+
+// TimeFixtures returns the verified tricky instants, which can be used by dependents' tests.
+func TimeFixtures() []TimeFixture {
+	rs := make([]TimeFixture, len(timeFixtures))
+	copy(rs, timeFixtures)
+	return rs
+}
@@ -0,0 +1,78 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeFixtures_Verify(t *testing.T) {
+	fixtures := TimeFixtures()
+	assert.NotEmpty(t, fixtures)
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.Name, func(t *testing.T) {
+			loc, err := time.LoadLocation(f.Location)
+			assert.NoError(t, err)
+			tm := time.UnixMilli(f.Timestamp).In(loc)
+			_, offset := tm.Zone()
+			assert.Equal(t, f.Offset, offset)
+			assert.Equal(t, f.Wall, tm.Format(DataTimeFormat2))
+			assert.Equal(t, f.StartOfDay, StartOfDay(f.Timestamp, loc))
+			assert.Equal(t, f.DayLength, DayLength(f.Timestamp, loc))
+			// start of day is always in the same local day
+			assert.Equal(t, tm.Format("2006-01-02"), time.UnixMilli(f.StartOfDay).In(loc).Format("2006-01-02"))
+			assert.Equal(t, f.StartOfDay, StartOfDay(f.StartOfDay, loc))
+			assert.Equal(t, f.StartOfDay, StartOfDay(f.StartOfDay-1, loc)+DayLength(f.StartOfDay-1, loc))
+			// utc alignment doesn't depend on location
+			assert.Equal(t, f.Timestamp-f.Timestamp%OneHour, AlignTimestamp(f.Timestamp, OneHour))
+
+			assert.Equal(t, f.Wall, FormatTimestampIn(f.Timestamp, DataTimeFormat2, loc))
+			ts, err := ParseTimestampIn(f.Wall, loc)
+			assert.NoError(t, err)
+			// ambiguous wall clock(fall back) resolves to one of the instants
+			assert.Equal(t, f.Wall, FormatTimestampIn(ts, DataTimeFormat2, loc))
+		})
+	}
+	// fixtures are copied
+	fixtures[0].Name = "changed"
+	assert.NotEqual(t, "changed", TimeFixtures()[0].Name)
+}
+
+func TestTimeFixtures_LeapSecond(t *testing.T) {
+	_, err := ParseTimestampIn("2016-12-31 23:59:60", time.UTC)
+	assert.Error(t, err)
+	before, err := ParseTimestampIn("2016-12-31 23:59:59", time.UTC)
+	assert.NoError(t, err)
+	after, err := ParseTimestampIn("2017-01-01 00:00:00", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, OneSecond, after-before)
+}
+
+func TestTimeFixtures_SkippedWallClock(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	// 02:30 doesn't exist at spring forward day
+	ts, err := ParseTimestampIn("2024-03-10 02:30:00", loc)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "2024-03-10 02:30:00", FormatTimestampIn(ts, DataTimeFormat2, loc))
+}
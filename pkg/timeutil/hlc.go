@@ -0,0 +1,214 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/common/pkg/fasttime"
+)
+
+const (
+	hlcLogicalBits = 16
+	// HLCMaxLogical is the max logical counter of hybrid logical clock timestamp.
+	HLCMaxLogical = 1<<hlcLogicalBits - 1
+	// HLCTimestampSize is the size of binary encoded hybrid logical clock timestamp.
+	HLCTimestampSize = 8
+)
+
+// HLCTimestamp represents the timestamp of hybrid logical clock,
+// physical time in millisecond with a logical counter for the events in the same millisecond.
+type HLCTimestamp struct {
+	WallTime int64  // physical time in millisecond
+	Logical  uint16 // logical counter
+}
+
+// HLCTimestampFromUint64 returns the timestamp packed by Uint64.
+func HLCTimestampFromUint64(v uint64) HLCTimestamp {
+	return HLCTimestamp{
+		WallTime: int64(v >> hlcLogicalBits),
+		Logical:  uint16(v & HLCMaxLogical),
+	}
+}
+
+// Uint64 packs the timestamp into uint64(high 48 bits for wall time, low 16 bits for logical),
+// the order of packed value is the same as timestamp.
+func (ts HLCTimestamp) Uint64() uint64 {
+	return uint64(ts.WallTime)<<hlcLogicalBits | uint64(ts.Logical)
+}
+
+// Compare returns -1 if ts < o, 0 if ts == o, 1 if ts > o.
+func (ts HLCTimestamp) Compare(o HLCTimestamp) int {
+	switch {
+	case ts.WallTime < o.WallTime:
+		return -1
+	case ts.WallTime > o.WallTime:
+		return 1
+	case ts.Logical < o.Logical:
+		return -1
+	case ts.Logical > o.Logical:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Less returns if ts happened before o.
+func (ts HLCTimestamp) Less(o HLCTimestamp) bool {
+	return ts.Compare(o) < 0
+}
+
+// IsZero returns if the timestamp is zero value.
+func (ts HLCTimestamp) IsZero() bool {
+	return ts.WallTime == 0 && ts.Logical == 0
+}
+
+// String returns the string value of timestamp, format: wall_time.logical.
+func (ts HLCTimestamp) String() string {
+	return strconv.FormatInt(ts.WallTime, 10) + "." + strconv.FormatUint(uint64(ts.Logical), 10)
+}
+
+// MarshalBinary encodes the timestamp as 8 bytes(big endian), which keeps the order in bytes comparison.
+func (ts HLCTimestamp) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, HLCTimestampSize)
+	binary.BigEndian.PutUint64(buf, ts.Uint64())
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the timestamp from bytes encoded by MarshalBinary.
+func (ts *HLCTimestamp) UnmarshalBinary(data []byte) error {
+	if len(data) != HLCTimestampSize {
+		return fmt.Errorf("invalid hlc timestamp length: %d", len(data))
+	}
+	*ts = HLCTimestampFromUint64(binary.BigEndian.Uint64(data))
+	return nil
+}
+
+// MarshalText encodes the timestamp as string.
+func (ts HLCTimestamp) MarshalText() ([]byte, error) {
+	return []byte(ts.String()), nil
+}
+
+// UnmarshalText decodes the timestamp from string encoded by MarshalText.
+func (ts *HLCTimestamp) UnmarshalText(text []byte) error {
+	rs, err := ParseHLCTimestamp(string(text))
+	if err != nil {
+		return err
+	}
+	*ts = rs
+	return nil
+}
+
+// ParseHLCTimestamp parses the timestamp string, format: wall_time.logical.
+func ParseHLCTimestamp(s string) (HLCTimestamp, error) {
+	wall, logical, ok := strings.Cut(s, ".")
+	if !ok {
+		return HLCTimestamp{}, fmt.Errorf("invalid hlc timestamp: %s", s)
+	}
+	wallTime, err := strconv.ParseInt(wall, 10, 64)
+	if err != nil || wallTime < 0 {
+		return HLCTimestamp{}, fmt.Errorf("invalid hlc timestamp: %s, wall time: %s", s, wall)
+	}
+	counter, err := strconv.ParseUint(logical, 10, hlcLogicalBits)
+	if err != nil {
+		return HLCTimestamp{}, fmt.Errorf("invalid hlc timestamp: %s, logical: %s", s, logical)
+	}
+	return HLCTimestamp{WallTime: wallTime, Logical: uint16(counter)}, nil
+}
+
+// HLC represents the hybrid logical clock, which generates monotonic timestamps
+// close to physical time, and keeps causality of events across nodes by Update with remote timestamps.
+type HLC struct {
+	physicalClock func() int64
+	maxOffset     int64
+	last          HLCTimestamp
+
+	lock sync.Mutex
+}
+
+// NewHLC creates a hybrid logical clock using fasttime as physical clock,
+// remote timestamp ahead of physical time more than maxOffset is rejected, no limit if maxOffset <= 0.
+func NewHLC(maxOffset time.Duration) *HLC {
+	return &HLC{
+		physicalClock: fasttime.UnixMilliseconds,
+		maxOffset:     maxOffset.Milliseconds(),
+	}
+}
+
+// Now returns a timestamp for local or send event, which is greater than all timestamps returned before.
+func (c *HLC) Now() HLCTimestamp {
+	physical := c.physicalClock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if physical > c.last.WallTime {
+		c.last = HLCTimestamp{WallTime: physical}
+	} else {
+		c.tick()
+	}
+	return c.last
+}
+
+// Update updates the clock with the timestamp of received event, returns a timestamp
+// greater than both the remote timestamp and all timestamps returned before.
+func (c *HLC) Update(remote HLCTimestamp) (HLCTimestamp, error) {
+	physical := c.physicalClock()
+	if c.maxOffset > 0 && remote.WallTime-physical > c.maxOffset {
+		return HLCTimestamp{}, fmt.Errorf("remote hlc timestamp: %s ahead of physical time: %d more than max offset: %dms",
+			remote, physical, c.maxOffset)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch {
+	case physical > c.last.WallTime && physical > remote.WallTime:
+		c.last = HLCTimestamp{WallTime: physical}
+		return c.last, nil
+	case remote.WallTime > c.last.WallTime:
+		c.last = remote
+	case remote.WallTime == c.last.WallTime && remote.Logical > c.last.Logical:
+		c.last.Logical = remote.Logical
+	}
+	c.tick()
+	return c.last, nil
+}
+
+// Last returns the last timestamp generated by the clock.
+func (c *HLC) Last() HLCTimestamp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.last
+}
+
+// tick increases the logical counter, moves wall time forward if logical counter overflows.
+func (c *HLC) tick() {
+	if c.last.Logical == HLCMaxLogical {
+		c.last.WallTime++
+		c.last.Logical = 0
+		return
+	}
+	c.last.Logical++
+}
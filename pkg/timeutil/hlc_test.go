@@ -0,0 +1,144 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHLC(physical *int64, maxOffset time.Duration) *HLC {
+	c := NewHLC(maxOffset)
+	c.physicalClock = func() int64 {
+		return *physical
+	}
+	return c
+}
+
+func TestHLC_Now(t *testing.T) {
+	physical := int64(1000)
+	c := newTestHLC(&physical, 0)
+	assert.Equal(t, HLCTimestamp{WallTime: 1000}, c.Now())
+	assert.Equal(t, HLCTimestamp{WallTime: 1000, Logical: 1}, c.Now())
+	// physical clock goes backward
+	physical = 900
+	assert.Equal(t, HLCTimestamp{WallTime: 1000, Logical: 2}, c.Now())
+	physical = 1001
+	assert.Equal(t, HLCTimestamp{WallTime: 1001}, c.Now())
+	assert.Equal(t, HLCTimestamp{WallTime: 1001}, c.Last())
+
+	// logical overflow
+	c.last.Logical = HLCMaxLogical
+	assert.Equal(t, HLCTimestamp{WallTime: 1002}, c.Now())
+}
+
+func TestHLC_Update(t *testing.T) {
+	physical := int64(1000)
+	c := newTestHLC(&physical, time.Second)
+	// physical time is the greatest
+	ts, err := c.Update(HLCTimestamp{WallTime: 900, Logical: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, HLCTimestamp{WallTime: 1000}, ts)
+	// remote is greater
+	ts, err = c.Update(HLCTimestamp{WallTime: 1500, Logical: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, HLCTimestamp{WallTime: 1500, Logical: 11}, ts)
+	// same wall time, remote logical is greater
+	ts, err = c.Update(HLCTimestamp{WallTime: 1500, Logical: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, HLCTimestamp{WallTime: 1500, Logical: 21}, ts)
+	// local is greater
+	ts, err = c.Update(HLCTimestamp{WallTime: 1200, Logical: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, HLCTimestamp{WallTime: 1500, Logical: 22}, ts)
+	assert.True(t, ts.Less(c.Now()))
+	// remote too far ahead
+	_, err = c.Update(HLCTimestamp{WallTime: 2001})
+	assert.Error(t, err)
+	assert.Equal(t, HLCTimestamp{WallTime: 1500, Logical: 23}, c.Last())
+}
+
+func TestHLC_Monotonic(t *testing.T) {
+	c := NewHLC(0)
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		seen = make(map[HLCTimestamp]struct{})
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := HLCTimestamp{}
+			for j := 0; j < 1000; j++ {
+				ts := c.Now()
+				assert.True(t, last.Less(ts))
+				last = ts
+				lock.Lock()
+				seen[ts] = struct{}{}
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 4000)
+}
+
+func TestHLCTimestamp_Compare(t *testing.T) {
+	ts := HLCTimestamp{WallTime: 10, Logical: 1}
+	assert.Equal(t, 0, ts.Compare(ts))
+	assert.Equal(t, -1, ts.Compare(HLCTimestamp{WallTime: 11}))
+	assert.Equal(t, 1, ts.Compare(HLCTimestamp{WallTime: 9, Logical: 10}))
+	assert.Equal(t, -1, ts.Compare(HLCTimestamp{WallTime: 10, Logical: 2}))
+	assert.Equal(t, 1, ts.Compare(HLCTimestamp{WallTime: 10}))
+	assert.True(t, HLCTimestamp{}.IsZero())
+	assert.False(t, ts.IsZero())
+}
+
+func TestHLCTimestamp_Serialization(t *testing.T) {
+	ts := HLCTimestamp{WallTime: 1700000000000, Logical: 12}
+	assert.Equal(t, ts, HLCTimestampFromUint64(ts.Uint64()))
+	assert.True(t, ts.Uint64() < HLCTimestamp{WallTime: 1700000000000, Logical: 13}.Uint64())
+	assert.True(t, ts.Uint64() < HLCTimestamp{WallTime: 1700000000001}.Uint64())
+
+	data, err := ts.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, HLCTimestampSize)
+	ts2 := HLCTimestamp{}
+	assert.NoError(t, ts2.UnmarshalBinary(data))
+	assert.Equal(t, ts, ts2)
+	assert.Error(t, ts2.UnmarshalBinary([]byte{1}))
+
+	assert.Equal(t, "1700000000000.12", ts.String())
+	data, err = json.Marshal(map[string]HLCTimestamp{"ts": ts})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ts":"1700000000000.12"}`, string(data))
+	rs := map[string]HLCTimestamp{}
+	assert.NoError(t, json.Unmarshal(data, &rs))
+	assert.Equal(t, ts, rs["ts"])
+	assert.Error(t, json.Unmarshal([]byte(`{"ts":"abc"}`), &rs))
+
+	for _, s := range []string{"1", "a.1", "-1.1", "1.a", "1.65536"} {
+		_, err = ParseHLCTimestamp(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NextAlignedTime returns the first instant after now which is aligned to interval(from unix epoch) plus offset,
+// e.g. interval 10s with offset 2s returns :02/:12/:22... seconds.
+func NextAlignedTime(now time.Time, interval, offset time.Duration) time.Time {
+	if interval <= 0 {
+		return now
+	}
+	offset %= interval
+	if offset < 0 {
+		offset += interval
+	}
+	intervalNs := int64(interval)
+	next := AlignTimestamp(now.UnixNano()-int64(offset), intervalNs) + intervalNs + int64(offset)
+	return time.Unix(0, next).In(now.Location())
+}
+
+// AlignedTicker represents a ticker which fires at wall-clock-aligned instants,
+// so that the jobs(flush, downsample etc.) on different nodes produce comparable windows.
+// Each tick is scheduled from the current wall clock instead of the previous tick, so the drift
+// is corrected, and the ticks are dropped if the receiver is slow(same as time.Ticker).
+type AlignedTicker struct {
+	// C is the channel on which the aligned instants are delivered.
+	C <-chan time.Time
+
+	c        chan time.Time
+	interval time.Duration
+	offset   time.Duration
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAlignedTicker creates an aligned ticker which fires at every interval boundary plus offset,
+// the aligned instant(not the firing time) is sent on the channel. It panics if interval <= 0.
+func NewAlignedTicker(interval, offset time.Duration) *AlignedTicker {
+	return newAlignedTicker(interval, offset, time.Now)
+}
+
+// newAlignedTicker creates an aligned ticker with the clock.
+func newAlignedTicker(interval, offset time.Duration, now func() time.Time) *AlignedTicker {
+	if interval <= 0 {
+		panic(fmt.Sprintf("non-positive interval for NewAlignedTicker: %s", interval))
+	}
+	c := make(chan time.Time, 1)
+	t := &AlignedTicker{
+		C:        c,
+		c:        c,
+		interval: interval,
+		offset:   offset,
+		now:      now,
+		stop:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Interval returns the interval of ticker.
+func (t *AlignedTicker) Interval() time.Duration {
+	return t.interval
+}
+
+// Stop turns off the ticker, no more ticks will be sent, the channel is not closed.
+func (t *AlignedTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+}
+
+// run fires the ticks until stopped.
+func (t *AlignedTicker) run() {
+	now := t.now()
+	next := NextAlignedTime(now, t.interval, t.offset)
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-timer.C:
+		}
+		now = t.now()
+		wait := next.Sub(now)
+		if wait > t.interval {
+			// wall clock is set backward, re-align to current time
+			next = NextAlignedTime(now, t.interval, t.offset)
+			wait = next.Sub(now)
+		}
+		if wait > 0 {
+			// timer fires early(e.g. wall clock is adjusted), wait for the boundary
+			timer.Reset(wait)
+			continue
+		}
+		select {
+		case t.c <- next:
+		default:
+			// receiver is slow, drop the tick
+		}
+		// schedule from current time, skips the missed boundaries
+		next = NextAlignedTime(now, t.interval, t.offset)
+		timer.Reset(next.Sub(now))
+	}
+}
@@ -0,0 +1,112 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextAlignedTime(t *testing.T) {
+	base := time.Date(2023, 6, 1, 10, 20, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		offset   time.Duration
+		expect   time.Time
+	}{
+		{name: "on boundary", now: base, interval: 10 * time.Second, expect: base.Add(10 * time.Second)},
+		{name: "in interval", now: base.Add(3 * time.Second), interval: 10 * time.Second, expect: base.Add(10 * time.Second)},
+		{name: "with offset", now: base.Add(3 * time.Second), interval: 10 * time.Second, offset: 2 * time.Second,
+			expect: base.Add(12 * time.Second)},
+		{name: "before offset", now: base.Add(time.Second), interval: 10 * time.Second, offset: 2 * time.Second,
+			expect: base.Add(2 * time.Second)},
+		{name: "offset larger than interval", now: base.Add(3 * time.Second), interval: 10 * time.Second, offset: 12 * time.Second,
+			expect: base.Add(12 * time.Second)},
+		{name: "negative offset", now: base.Add(3 * time.Second), interval: 10 * time.Second, offset: -2 * time.Second,
+			expect: base.Add(8 * time.Second)},
+		{name: "minute", now: base.Add(59 * time.Second), interval: time.Minute, expect: base.Add(time.Minute)},
+		{name: "invalid interval", now: base, interval: 0, expect: base},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expect.Equal(NextAlignedTime(tt.now, tt.interval, tt.offset)))
+		})
+	}
+	local := time.FixedZone("UTC+8", 8*3600)
+	assert.Equal(t, local, NextAlignedTime(base.In(local), time.Hour, 0).Location())
+}
+
+func TestAlignedTicker(t *testing.T) {
+	interval := 20 * time.Millisecond
+	ticker := NewAlignedTicker(interval, 5*time.Millisecond)
+	assert.Equal(t, interval, ticker.Interval())
+	var prev time.Time
+	for i := 0; i < 3; i++ {
+		tick := <-ticker.C
+		assert.Equal(t, int64(5*time.Millisecond), tick.UnixNano()%int64(interval))
+		assert.False(t, time.Now().Before(tick))
+		if !prev.IsZero() {
+			assert.True(t, tick.After(prev))
+		}
+		prev = tick
+	}
+	ticker.Stop()
+	ticker.Stop()
+	time.Sleep(2 * interval)
+	select {
+	case <-ticker.C:
+	default:
+	}
+	select {
+	case <-ticker.C:
+		t.Fatal("tick after stopped")
+	case <-time.After(2 * interval):
+	}
+}
+
+func TestAlignedTicker_ClockAdjusted(t *testing.T) {
+	interval := 20 * time.Millisecond
+	var shift atomic.Int64
+	ticker := newAlignedTicker(interval, 0, func() time.Time {
+		return time.Now().Add(time.Duration(shift.Load()))
+	})
+	defer ticker.Stop()
+
+	// wall clock is set backward, ticks are still aligned
+	shift.Store(int64(-time.Hour))
+	for i := 0; i < 2; i++ {
+		tick := <-ticker.C
+		assert.Zero(t, tick.UnixNano()%int64(interval))
+	}
+	// timer fires early
+	shift.Store(int64(-interval / 2))
+	tick := <-ticker.C
+	assert.Zero(t, tick.UnixNano()%int64(interval))
+}
+
+func TestNewAlignedTicker_Panic(t *testing.T) {
+	assert.Panics(t, func() {
+		NewAlignedTicker(0, 0)
+	})
+}
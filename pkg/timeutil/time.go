@@ -50,12 +50,22 @@ const (
 
 // FormatTimestamp returns timestamp format based on layout
 func FormatTimestamp(timestamp int64, layout string) string {
-	t := time.Unix(timestamp/1000, 0)
+	return FormatTimestampIn(timestamp, layout, time.Local)
+}
+
+// FormatTimestampIn returns timestamp format based on layout in the location.
+func FormatTimestampIn(timestamp int64, layout string, loc *time.Location) string {
+	t := time.Unix(timestamp/1000, 0).In(loc)
 	return t.Format(layout)
 }
 
 // ParseTimestamp parses timestamp str value based on layout using local zone
 func ParseTimestamp(timestampStr string, layout ...string) (int64, error) {
+	return ParseTimestampIn(timestampStr, time.Local, layout...)
+}
+
+// ParseTimestampIn parses timestamp str value based on layout in the location.
+func ParseTimestampIn(timestampStr string, loc *time.Location, layout ...string) (int64, error) {
 	var format string
 	if len(layout) > 0 {
 		format = layout[0]
@@ -71,7 +81,7 @@ func ParseTimestamp(timestampStr string, layout ...string) (int64, error) {
 			format = DataTimeFormat4
 		}
 	}
-	tm, err := parseTimeFunc(format, timestampStr, time.Local)
+	tm, err := parseTimeFunc(format, timestampStr, loc)
 	if err != nil {
 		return 0, err
 	}
@@ -89,3 +99,33 @@ func Now() int64 {
 func NowNano() int64 {
 	return time.Now().UnixNano()
 }
+
+// StartOfDay returns the first millisecond of the local day in location which the timestamp belongs to,
+// the local midnight may be skipped by daylight saving time(e.g. America/Sao_Paulo), in which case
+// the day starts at the transition.
+func StartOfDay(timestamp int64, loc *time.Location) int64 {
+	return startOfDay(time.UnixMilli(timestamp).In(loc)).UnixMilli()
+}
+
+// DayLength returns the length(in millisecond) of the local day in location which the timestamp belongs to,
+// which is 23h/25h(or 23.5h/24.5h etc.) at the daylight saving time transition days.
+func DayLength(timestamp int64, loc *time.Location) int64 {
+	t := time.UnixMilli(timestamp).In(loc)
+	start := startOfDay(t)
+	y, m, d := t.Date()
+	// noon always exists, avoid hitting the transition
+	next := startOfDay(time.Date(y, m, d+1, 12, 0, 0, 0, loc))
+	return next.Sub(start).Milliseconds()
+}
+
+// startOfDay returns the first instant of the local day of t.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	if sy, sm, sd := start.Date(); sy != y || sm != m || sd != d {
+		// midnight is skipped, start is the previous day, move to the transition
+		hour, minute, sec := start.Clock()
+		start = start.Add(24*time.Hour - time.Duration(hour*3600+minute*60+sec)*time.Second)
+	}
+	return start
+}
@@ -0,0 +1,147 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"fmt"
+	"math"
+)
+
+// TimeRange represents a time range [Start, End) in milliseconds.
+type TimeRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// IsEmpty returns if the time range contains no timestamp.
+func (r TimeRange) IsEmpty() bool {
+	return r.End <= r.Start
+}
+
+// Contains returns if the timestamp is in the time range.
+func (r TimeRange) Contains(timestamp int64) bool {
+	return timestamp >= r.Start && timestamp < r.End
+}
+
+// Intersect returns the intersection of two time ranges, returns false if no intersection.
+func (r TimeRange) Intersect(o TimeRange) (TimeRange, bool) {
+	rs := TimeRange{Start: max(r.Start, o.Start), End: min(r.End, o.End)}
+	return rs, !rs.IsEmpty()
+}
+
+// String returns the string of time range.
+func (r TimeRange) String() string {
+	return fmt.Sprintf("[%d, %d)", r.Start, r.End)
+}
+
+// AlignTimestamp returns the start of the interval which the timestamp belongs to,
+// intervals are aligned to unix epoch, e.g. align 1:23:45 with 1h returns 1:00:00.
+func AlignTimestamp(timestamp, interval int64) int64 {
+	if interval <= 0 {
+		return timestamp
+	}
+	rem := timestamp % interval
+	if rem < 0 {
+		// floor for negative timestamp
+		rem += interval
+	}
+	if timestamp < math.MinInt64+rem {
+		// underflow
+		return math.MinInt64
+	}
+	return timestamp - rem
+}
+
+// Window represents an aligned window which overlaps the time range.
+type Window struct {
+	// Aligned is the aligned window bounds [Start, Start+interval).
+	Aligned TimeRange
+	// Range is the part of time range within the window.
+	Range TimeRange
+}
+
+// IsPartial returns if the time range only covers part of the window,
+// which happens for the first/last window if the time range is not aligned.
+func (w Window) IsPartial() bool {
+	return w.Aligned != w.Range
+}
+
+// WindowIterator walks a time range by interval, yielding aligned windows in order.
+type WindowIterator struct {
+	timeRange TimeRange
+	interval  int64
+	next      int64
+	window    Window
+}
+
+// NewWindowIterator creates a window iterator for the time range with the interval(in milliseconds).
+func NewWindowIterator(timeRange TimeRange, interval int64) (*WindowIterator, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval: %d should > 0", interval)
+	}
+	return &WindowIterator{
+		timeRange: timeRange,
+		interval:  interval,
+		next:      AlignTimestamp(timeRange.Start, interval),
+	}, nil
+}
+
+// Next moves to next window, returns false if no more window.
+func (itr *WindowIterator) Next() bool {
+	if itr.timeRange.IsEmpty() || itr.next >= itr.timeRange.End {
+		return false
+	}
+	start := itr.next
+	end := start + itr.interval
+	if end < start {
+		// overflow
+		end = math.MaxInt64
+	}
+	aligned := TimeRange{Start: start, End: end}
+	r, _ := aligned.Intersect(itr.timeRange)
+	itr.window = Window{Aligned: aligned, Range: r}
+	if end >= itr.timeRange.End {
+		itr.next = itr.timeRange.End
+	} else {
+		itr.next = end
+	}
+	return true
+}
+
+// Window returns current window.
+func (itr *WindowIterator) Window() Window {
+	return itr.window
+}
+
+// Windows returns all aligned windows of the time range, returns error if interval is invalid.
+// If fullOnly is true, the partial first/last windows are excluded.
+func Windows(timeRange TimeRange, interval int64, fullOnly bool) ([]Window, error) {
+	itr, err := NewWindowIterator(timeRange, interval)
+	if err != nil {
+		return nil, err
+	}
+	var rs []Window
+	for itr.Next() {
+		w := itr.Window()
+		if fullOnly && w.IsPartial() {
+			continue
+		}
+		rs = append(rs, w)
+	}
+	return rs, nil
+}
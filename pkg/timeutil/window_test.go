@@ -0,0 +1,189 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeRange(t *testing.T) {
+	r := TimeRange{Start: 10, End: 20}
+	assert.False(t, r.IsEmpty())
+	assert.True(t, TimeRange{Start: 10, End: 10}.IsEmpty())
+	assert.True(t, TimeRange{Start: 10, End: 5}.IsEmpty())
+	assert.True(t, r.Contains(10))
+	assert.True(t, r.Contains(19))
+	assert.False(t, r.Contains(20))
+	assert.False(t, r.Contains(9))
+	rs, ok := r.Intersect(TimeRange{Start: 15, End: 30})
+	assert.True(t, ok)
+	assert.Equal(t, TimeRange{Start: 15, End: 20}, rs)
+	_, ok = r.Intersect(TimeRange{Start: 20, End: 30})
+	assert.False(t, ok)
+	assert.Equal(t, "[10, 20)", r.String())
+}
+
+func TestAlignTimestamp(t *testing.T) {
+	cases := []struct {
+		timestamp, interval, expect int64
+	}{
+		{timestamp: 0, interval: 10, expect: 0},
+		{timestamp: 9, interval: 10, expect: 0},
+		{timestamp: 10, interval: 10, expect: 10},
+		{timestamp: 11, interval: 10, expect: 10},
+		{timestamp: -1, interval: 10, expect: -10},
+		{timestamp: -10, interval: 10, expect: -10},
+		{timestamp: -11, interval: 10, expect: -20},
+		{timestamp: 5, interval: 0, expect: 5},
+		{timestamp: 5, interval: -1, expect: 5},
+		{timestamp: OneHour + 23*OneMinute + 45*OneSecond, interval: OneHour, expect: OneHour},
+		{timestamp: math.MinInt64 + 5, interval: 10, expect: math.MinInt64},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.expect, AlignTimestamp(tt.timestamp, tt.interval), "align %d by %d", tt.timestamp, tt.interval)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	w := func(alignedStart, alignedEnd, start, end int64) Window {
+		return Window{Aligned: TimeRange{Start: alignedStart, End: alignedEnd}, Range: TimeRange{Start: start, End: end}}
+	}
+	cases := []struct {
+		name      string
+		timeRange TimeRange
+		interval  int64
+		expect    []Window
+		full      []Window
+	}{
+		{
+			name:      "aligned range",
+			timeRange: TimeRange{Start: 0, End: 30},
+			interval:  10,
+			expect:    []Window{w(0, 10, 0, 10), w(10, 20, 10, 20), w(20, 30, 20, 30)},
+			full:      []Window{w(0, 10, 0, 10), w(10, 20, 10, 20), w(20, 30, 20, 30)},
+		},
+		{
+			name:      "partial first/last window",
+			timeRange: TimeRange{Start: 5, End: 25},
+			interval:  10,
+			expect:    []Window{w(0, 10, 5, 10), w(10, 20, 10, 20), w(20, 30, 20, 25)},
+			full:      []Window{w(10, 20, 10, 20)},
+		},
+		{
+			name:      "end at window start",
+			timeRange: TimeRange{Start: 10, End: 21},
+			interval:  10,
+			expect:    []Window{w(10, 20, 10, 20), w(20, 30, 20, 21)},
+			full:      []Window{w(10, 20, 10, 20)},
+		},
+		{
+			name:      "within one window",
+			timeRange: TimeRange{Start: 12, End: 18},
+			interval:  10,
+			expect:    []Window{w(10, 20, 12, 18)},
+		},
+		{
+			name:      "single timestamp",
+			timeRange: TimeRange{Start: 19, End: 20},
+			interval:  10,
+			expect:    []Window{w(10, 20, 19, 20)},
+		},
+		{
+			name:      "negative timestamp",
+			timeRange: TimeRange{Start: -15, End: 5},
+			interval:  10,
+			expect:    []Window{w(-20, -10, -15, -10), w(-10, 0, -10, 0), w(0, 10, 0, 5)},
+			full:      []Window{w(-10, 0, -10, 0)},
+		},
+		{
+			name:      "interval larger than range",
+			timeRange: TimeRange{Start: 5, End: 25},
+			interval:  100,
+			expect:    []Window{w(0, 100, 5, 25)},
+		},
+		{
+			name:      "interval is 1",
+			timeRange: TimeRange{Start: 5, End: 7},
+			interval:  1,
+			expect:    []Window{w(5, 6, 5, 6), w(6, 7, 6, 7)},
+			full:      []Window{w(5, 6, 5, 6), w(6, 7, 6, 7)},
+		},
+		{
+			name:      "empty range",
+			timeRange: TimeRange{Start: 10, End: 10},
+			interval:  10,
+		},
+		{
+			name:      "reversed range",
+			timeRange: TimeRange{Start: 20, End: 10},
+			interval:  10,
+		},
+		{
+			name:      "max timestamp",
+			timeRange: TimeRange{Start: math.MaxInt64 - 5, End: math.MaxInt64},
+			interval:  10,
+			expect:    []Window{w(math.MaxInt64-7, math.MaxInt64, math.MaxInt64-5, math.MaxInt64)},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := Windows(tt.timeRange, tt.interval, false)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, rs)
+			rs, err = Windows(tt.timeRange, tt.interval, true)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.full, rs)
+		})
+	}
+
+	_, err := Windows(TimeRange{Start: 0, End: 10}, 0, false)
+	assert.Error(t, err)
+	itr, err := NewWindowIterator(TimeRange{Start: 0, End: 10}, -1)
+	assert.Error(t, err)
+	assert.Nil(t, itr)
+}
+
+func TestWindowIterator_Cover(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		start := r.Int63n(2*OneDay) - OneDay
+		timeRange := TimeRange{Start: start, End: start + r.Int63n(OneDay)}
+		interval := 1 + r.Int63n(OneHour)
+		itr, err := NewWindowIterator(timeRange, interval)
+		assert.NoError(t, err)
+		next := timeRange.Start
+		for itr.Next() {
+			window := itr.Window()
+			// windows are aligned, contiguous and cover the time range exactly
+			assert.Zero(t, (window.Aligned.Start%interval+interval)%interval)
+			assert.Equal(t, interval, window.Aligned.End-window.Aligned.Start)
+			assert.Equal(t, next, window.Range.Start)
+			assert.False(t, window.Range.IsEmpty())
+			next = window.Range.End
+		}
+		if !timeRange.IsEmpty() {
+			assert.Equal(t, timeRange.End, next)
+		}
+		assert.False(t, itr.Next())
+	}
+}
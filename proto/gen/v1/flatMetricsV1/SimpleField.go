@@ -82,8 +82,20 @@ func (rcv *SimpleField) MutateValue(n float64) bool {
 	return rcv._tab.MutateFloat64Slot(8, n)
 }
 
+func (rcv *SimpleField) Absent() bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.GetBool(o + rcv._tab.Pos)
+	}
+	return false
+}
+
+func (rcv *SimpleField) MutateAbsent(n bool) bool {
+	return rcv._tab.MutateBoolSlot(10, n)
+}
+
 func SimpleFieldStart(builder *flatbuffers.Builder) {
-	builder.StartObject(3)
+	builder.StartObject(4)
 }
 func SimpleFieldAddName(builder *flatbuffers.Builder, name flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(name), 0)
@@ -94,6 +106,9 @@ func SimpleFieldAddType(builder *flatbuffers.Builder, type_ SimpleFieldType) {
 func SimpleFieldAddValue(builder *flatbuffers.Builder, value float64) {
 	builder.PrependFloat64Slot(2, value, 0.0)
 }
+func SimpleFieldAddAbsent(builder *flatbuffers.Builder, absent bool) {
+	builder.PrependBoolSlot(3, absent, false)
+}
 func SimpleFieldEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -28,6 +28,8 @@ function gen() {
 
 # brew install flatbuffers
 flatc --go -o ./proto/gen/v1 ./proto/v1/metrics.fbs
+# v2 includes v1 tables, module name is used for importing flatMetricsV1
+flatc --go --go-module-name github.com/lindb/common/proto/gen/v1 -I ./proto -o ./proto/gen/v2 ./proto/v2/metrics.fbs
 
 # for dir in v1 opentelemetry-v1
 for dir in v1; do
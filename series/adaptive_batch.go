@@ -0,0 +1,232 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"time"
+)
+
+// AdaptiveBatchConfig represents the config of adaptive batch sizing, zero value means default value.
+type AdaptiveBatchConfig struct {
+	// MinRows/MaxRows is the range of rows per batch, default 100/10000.
+	MinRows int
+	MaxRows int
+	// InitialRows is the rows per batch at beginning, default MinRows.
+	InitialRows int
+	// MinInterval/MaxInterval is the range of flush interval, which is scaled by rows per batch, default 100ms/5s.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// TargetLatency is the expected flush latency, batch is decreased if exceeds, default 1s.
+	TargetLatency time.Duration
+	// MaxErrorRate is the max flush error rate(moving average), batch is decreased if exceeds, default 0.1.
+	MaxErrorRate float64
+	// IncreaseRows is the additive increase of rows per batch after a healthy flush, default MinRows.
+	IncreaseRows int
+	// DecreaseFactor is the multiplicative decrease of rows per batch after an unhealthy flush, (0, 1), default 0.5.
+	DecreaseFactor float64
+}
+
+// errorRateAlpha is the smoothing factor of error rate moving average.
+const errorRateAlpha = 0.2
+
+// withDefaults returns the config with default values filled, returns error if invalid.
+func (cfg AdaptiveBatchConfig) withDefaults() (AdaptiveBatchConfig, error) {
+	if cfg.MinRows <= 0 {
+		cfg.MinRows = 100
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 10000
+	}
+	if cfg.MinRows > cfg.MaxRows {
+		return cfg, fmt.Errorf("min rows: %d should <= max rows: %d", cfg.MinRows, cfg.MaxRows)
+	}
+	if cfg.InitialRows <= 0 {
+		cfg.InitialRows = cfg.MinRows
+	}
+	cfg.InitialRows = min(max(cfg.InitialRows, cfg.MinRows), cfg.MaxRows)
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = 100 * time.Millisecond
+	}
+	if cfg.MaxInterval <= 0 {
+		cfg.MaxInterval = 5 * time.Second
+	}
+	if cfg.MinInterval > cfg.MaxInterval {
+		return cfg, fmt.Errorf("min interval: %s should <= max interval: %s", cfg.MinInterval, cfg.MaxInterval)
+	}
+	if cfg.TargetLatency <= 0 {
+		cfg.TargetLatency = time.Second
+	}
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = 0.1
+	}
+	if cfg.IncreaseRows <= 0 {
+		cfg.IncreaseRows = cfg.MinRows
+	}
+	if cfg.DecreaseFactor <= 0 {
+		cfg.DecreaseFactor = 0.5
+	}
+	if cfg.DecreaseFactor >= 1 {
+		return cfg, fmt.Errorf("decrease factor: %f should in (0, 1)", cfg.DecreaseFactor)
+	}
+	return cfg, nil
+}
+
+// FlushFunc flushes the batch payload to downstream(e.g. write to broker).
+type FlushFunc func(payload []byte, rows int) error
+
+// AdaptiveBatchStats represents the statistics of adaptive batch builder.
+type AdaptiveBatchStats struct {
+	Flushes       int64         `json:"flushes"`
+	Failures      int64         `json:"failures"`
+	FlushedRows   int64         `json:"flushedRows"`
+	BatchRows     int           `json:"batchRows"`
+	FlushInterval time.Duration `json:"flushInterval"`
+	ErrorRate     float64       `json:"errorRate"`
+	LastLatency   time.Duration `json:"lastLatency"`
+}
+
+// AdaptiveBatchBuilder wraps the BatchBuilder, flushes the batch when rows reach the batch size or
+// flush interval elapses, and adjusts the batch size by observed flush latency and error rate(AIMD):
+//   - additive increase after a healthy flush(latency <= target and error rate <= max error rate).
+//   - multiplicative decrease after a failed/slow flush, or error rate exceeds.
+//
+// The flush interval is scaled with the batch size, so small batches are flushed more frequently.
+// NOTE: it's not goroutine safe as BatchBuilder, FlushIfDue should be invoked by the same goroutine(e.g. select loop).
+type AdaptiveBatchBuilder struct {
+	bb    *BatchBuilder
+	cfg   AdaptiveBatchConfig
+	flush FlushFunc
+
+	batchRows int
+	lastFlush time.Time
+	stats     AdaptiveBatchStats
+
+	now func() time.Time
+}
+
+// NewAdaptiveBatchBuilder creates an adaptive batch builder, the options are applied to the shared row builder.
+func NewAdaptiveBatchBuilder(cfg AdaptiveBatchConfig, flush FlushFunc, options ...RowBuilderOption) (*AdaptiveBatchBuilder, error) {
+	if flush == nil {
+		return nil, fmt.Errorf("flush func is nil")
+	}
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	ab := &AdaptiveBatchBuilder{
+		bb:        NewBatchBuilder(options...),
+		cfg:       cfg,
+		flush:     flush,
+		batchRows: cfg.InitialRows,
+		now:       time.Now,
+	}
+	ab.lastFlush = ab.now()
+	return ab, nil
+}
+
+// BatchBuilder returns the wrapped batch builder.
+func (ab *AdaptiveBatchBuilder) BatchBuilder() *BatchBuilder {
+	return ab.bb
+}
+
+// RowBuilder returns the shared row builder for building next row, invoke Commit after the row is filled.
+func (ab *AdaptiveBatchBuilder) RowBuilder() *RowBuilder {
+	return ab.bb.RowBuilder()
+}
+
+// Commit commits the current row, flushes the batch if rows reach the batch size.
+func (ab *AdaptiveBatchBuilder) Commit() error {
+	if err := ab.bb.Commit(); err != nil {
+		return err
+	}
+	if ab.bb.Rows() >= ab.batchRows {
+		return ab.Flush()
+	}
+	return nil
+}
+
+// FlushIfDue flushes the batch if the flush interval elapses since last flush.
+func (ab *AdaptiveBatchBuilder) FlushIfDue() error {
+	if ab.now().Sub(ab.lastFlush) < ab.FlushInterval() {
+		return nil
+	}
+	return ab.Flush()
+}
+
+// Flush flushes the batch to downstream then adjusts the batch size,
+// the batch is reset whether successful or not, retry should be done by flush func.
+func (ab *AdaptiveBatchBuilder) Flush() error {
+	start := ab.now()
+	ab.lastFlush = start
+	rows := ab.bb.Rows()
+	if rows == 0 {
+		return nil
+	}
+	defer ab.bb.Reset()
+
+	err := ab.flush(ab.bb.Payload(), rows)
+	end := ab.now()
+	ab.lastFlush = end
+	ab.adjust(end.Sub(start), err)
+	if err != nil {
+		return err
+	}
+	ab.stats.FlushedRows += int64(rows)
+	return nil
+}
+
+// adjust adjusts the batch size by flush latency and error(AIMD).
+func (ab *AdaptiveBatchBuilder) adjust(latency time.Duration, err error) {
+	ab.stats.Flushes++
+	ab.stats.LastLatency = latency
+	failed := 0.0
+	if err != nil {
+		ab.stats.Failures++
+		failed = 1
+	}
+	ab.stats.ErrorRate = ab.stats.ErrorRate*(1-errorRateAlpha) + failed*errorRateAlpha
+
+	if err != nil || latency > ab.cfg.TargetLatency || ab.stats.ErrorRate > ab.cfg.MaxErrorRate {
+		ab.batchRows = max(int(float64(ab.batchRows)*ab.cfg.DecreaseFactor), ab.cfg.MinRows)
+		return
+	}
+	ab.batchRows = min(ab.batchRows+ab.cfg.IncreaseRows, ab.cfg.MaxRows)
+}
+
+// BatchRows returns the current rows per batch.
+func (ab *AdaptiveBatchBuilder) BatchRows() int {
+	return ab.batchRows
+}
+
+// FlushInterval returns the current flush interval, which is scaled with the batch size.
+func (ab *AdaptiveBatchBuilder) FlushInterval() time.Duration {
+	if ab.cfg.MaxRows == ab.cfg.MinRows {
+		return ab.cfg.MaxInterval
+	}
+	ratio := float64(ab.batchRows-ab.cfg.MinRows) / float64(ab.cfg.MaxRows-ab.cfg.MinRows)
+	return ab.cfg.MinInterval + time.Duration(ratio*float64(ab.cfg.MaxInterval-ab.cfg.MinInterval))
+}
+
+// Stats returns the statistics of adaptive batch builder.
+func (ab *AdaptiveBatchBuilder) Stats() AdaptiveBatchStats {
+	stats := ab.stats
+	stats.BatchRows = ab.batchRows
+	stats.FlushInterval = ab.FlushInterval()
+	return stats
+}
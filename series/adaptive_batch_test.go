@@ -0,0 +1,174 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestAdaptiveBatchConfig(t *testing.T) {
+	cfg, err := AdaptiveBatchConfig{}.withDefaults()
+	assert.NoError(t, err)
+	assert.Equal(t, AdaptiveBatchConfig{
+		MinRows: 100, MaxRows: 10000, InitialRows: 100,
+		MinInterval: 100 * time.Millisecond, MaxInterval: 5 * time.Second,
+		TargetLatency: time.Second, MaxErrorRate: 0.1, IncreaseRows: 100, DecreaseFactor: 0.5,
+	}, cfg)
+	cfg, err = AdaptiveBatchConfig{MinRows: 10, MaxRows: 20, InitialRows: 100}.withDefaults()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.InitialRows)
+
+	cases := []AdaptiveBatchConfig{
+		{MinRows: 10, MaxRows: 5},
+		{MinInterval: time.Second, MaxInterval: time.Millisecond},
+		{DecreaseFactor: 1},
+	}
+	for _, c := range cases {
+		_, err = c.withDefaults()
+		assert.Error(t, err)
+		_, err = NewAdaptiveBatchBuilder(c, func(_ []byte, _ int) error { return nil })
+		assert.Error(t, err)
+	}
+	_, err = NewAdaptiveBatchBuilder(AdaptiveBatchConfig{}, nil)
+	assert.Error(t, err)
+}
+
+func TestAdaptiveBatchBuilder_AIMD(t *testing.T) {
+	var (
+		now      time.Time
+		latency  time.Duration
+		flushErr error
+		flushed  []int
+	)
+	ab, err := NewAdaptiveBatchBuilder(AdaptiveBatchConfig{
+		MinRows: 2, MaxRows: 6, IncreaseRows: 2,
+		MinInterval: time.Second, MaxInterval: 3 * time.Second, TargetLatency: 100 * time.Millisecond,
+	}, func(payload []byte, rows int) error {
+		itr := NewBatchIterator(payload)
+		count := 0
+		for itr.HasNext() {
+			count++
+		}
+		assert.Equal(t, rows, count)
+		flushed = append(flushed, rows)
+		now = now.Add(latency)
+		return flushErr
+	})
+	assert.NoError(t, err)
+	ab.now = func() time.Time { return now }
+	assert.NotNil(t, ab.BatchBuilder())
+
+	commit := func(n int) {
+		for i := 0; i < n; i++ {
+			rb := ab.RowBuilder()
+			rb.AddMetricName([]byte("cpu"))
+			rb.AddTimestamp(int64(i + 1))
+			assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, float64(i)))
+			_ = ab.Commit()
+		}
+	}
+	assert.Equal(t, 2, ab.BatchRows())
+	assert.Equal(t, time.Second, ab.FlushInterval())
+
+	// additive increase
+	commit(2)
+	assert.Equal(t, []int{2}, flushed)
+	assert.Equal(t, 4, ab.BatchRows())
+	assert.Equal(t, 2*time.Second, ab.FlushInterval())
+	commit(4)
+	assert.Equal(t, 6, ab.BatchRows())
+	commit(6)
+	assert.Equal(t, 6, ab.BatchRows())
+	assert.Equal(t, []int{2, 4, 6}, flushed)
+
+	// multiplicative decrease by slow flush
+	latency = time.Second
+	commit(6)
+	assert.Equal(t, 3, ab.BatchRows())
+	latency = 0
+
+	// multiplicative decrease by failure
+	flushErr = fmt.Errorf("err")
+	rb := ab.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, ab.Commit())
+	rb = ab.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, ab.Commit())
+	rb = ab.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.Error(t, ab.Commit())
+	assert.Equal(t, 2, ab.BatchRows())
+	assert.Zero(t, ab.BatchBuilder().Rows())
+	flushErr = nil
+
+	// decrease while error rate exceeds
+	commit(2)
+	assert.Equal(t, 2, ab.BatchRows())
+
+	// build failure
+	assert.Error(t, ab.Commit())
+
+	stats := ab.Stats()
+	assert.Equal(t, int64(6), stats.Flushes)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, int64(20), stats.FlushedRows)
+	assert.Equal(t, 2, stats.BatchRows)
+	assert.Equal(t, time.Second, stats.FlushInterval)
+	assert.InDelta(t, 0.16, stats.ErrorRate, 0.0001)
+}
+
+func TestAdaptiveBatchBuilder_FlushIfDue(t *testing.T) {
+	now := time.Now()
+	flushes := 0
+	ab, err := NewAdaptiveBatchBuilder(AdaptiveBatchConfig{MinRows: 10, MaxRows: 10},
+		func(_ []byte, _ int) error {
+			flushes++
+			return nil
+		})
+	assert.NoError(t, err)
+	ab.now = func() time.Time { return now }
+	ab.lastFlush = now
+	assert.Equal(t, 5*time.Second, ab.FlushInterval())
+
+	// empty batch
+	now = now.Add(10 * time.Second)
+	assert.NoError(t, ab.FlushIfDue())
+	assert.Zero(t, flushes)
+
+	rb := ab.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, ab.Commit())
+	now = now.Add(time.Second)
+	assert.NoError(t, ab.FlushIfDue())
+	assert.Zero(t, flushes)
+	now = now.Add(5 * time.Second)
+	assert.NoError(t, ab.FlushIfDue())
+	assert.Equal(t, 1, flushes)
+	assert.Zero(t, ab.BatchBuilder().Rows())
+}
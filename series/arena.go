@@ -0,0 +1,105 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+// defaultArenaChunkSize is the default size of arena chunk.
+const defaultArenaChunkSize = 64 * 1024
+
+// Arena allocates the intermediate tag/field buffers of row builders from reusable chunks,
+// all buffers are released together by Reset(e.g. per batch), which cuts GC pressure of high-throughput encoding.
+// The buffers allocated from arena are invalid after Reset. Arena is not thread-safe.
+type Arena struct {
+	chunkSize int
+	chunks    [][]byte
+	chunkIdx  int // index of current chunk
+	offset    int // offset of current chunk
+	allocated int // bytes allocated since last Reset
+}
+
+// NewArena creates an arena with the chunk size, defaultArenaChunkSize if chunk size <= 0.
+func NewArena(chunkSize int) *Arena {
+	if chunkSize <= 0 {
+		chunkSize = defaultArenaChunkSize
+	}
+	return &Arena{chunkSize: chunkSize}
+}
+
+// Alloc returns a buffer with length n from arena, the capacity of buffer is limited to n,
+// so that appending to it never overwrites other buffers.
+// The buffer larger than chunk size is allocated from heap directly.
+func (a *Arena) Alloc(n int) []byte {
+	a.allocated += n
+	if n > a.chunkSize {
+		return make([]byte, n)
+	}
+	for a.chunkIdx < len(a.chunks) {
+		chunk := a.chunks[a.chunkIdx]
+		if a.offset+n <= len(chunk) {
+			buf := chunk[a.offset : a.offset+n : a.offset+n]
+			a.offset += n
+			return buf
+		}
+		// current chunk is full, try next reusable chunk
+		a.chunkIdx++
+		a.offset = 0
+	}
+	a.chunks = append(a.chunks, make([]byte, a.chunkSize))
+	a.chunkIdx = len(a.chunks) - 1
+	a.offset = n
+	return a.chunks[a.chunkIdx][:n:n]
+}
+
+// Copy copies the data into a buffer allocated from arena.
+func (a *Arena) Copy(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	buf := a.Alloc(len(data))
+	copy(buf, data)
+	return buf
+}
+
+// Reset releases all buffers allocated from arena, the chunks are kept for reusing.
+func (a *Arena) Reset() {
+	a.chunkIdx = 0
+	a.offset = 0
+	a.allocated = 0
+}
+
+// Allocated returns the bytes allocated since last Reset.
+func (a *Arena) Allocated() int { return a.allocated }
+
+// Cap returns the total capacity of chunks held by arena.
+func (a *Arena) Cap() int { return len(a.chunks) * a.chunkSize }
+
+// WithArena allocates the intermediate tag/field buffers of row builder from the arena,
+// which is shared by the row builders of a batch(e.g. picked by NewRowBuilder) and reset per batch.
+// BatchBuilder resets the arena when it's reset. The rows must be built before the arena is reset.
+func WithArena(arena *Arena) RowBuilderOption {
+	return func(rb *RowBuilder) {
+		rb.arena = arena
+	}
+}
+
+// copyBytes copies the data into dst buffer, which is allocated from arena if set, else reuses dst buffer.
+func (rb *RowBuilder) copyBytes(dst, data []byte) []byte {
+	if rb.arena != nil {
+		return rb.arena.Copy(data)
+	}
+	return append(dst[:0], data...)
+}
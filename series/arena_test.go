@@ -0,0 +1,133 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestArena(t *testing.T) {
+	a := NewArena(0)
+	assert.Equal(t, defaultArenaChunkSize, a.chunkSize)
+
+	a = NewArena(8)
+	b1 := a.Copy([]byte("abc"))
+	b2 := a.Copy([]byte("def"))
+	assert.Equal(t, "abc", string(b1))
+	assert.Equal(t, "def", string(b2))
+	assert.Equal(t, 3, cap(b1))
+	// append never overwrites other buffers
+	b1 = append(b1, 'x')
+	assert.Equal(t, "abcx", string(b1))
+	assert.Equal(t, "def", string(b2))
+	// next chunk
+	b3 := a.Copy([]byte("ghijk"))
+	assert.Equal(t, "ghijk", string(b3))
+	assert.Equal(t, 16, a.Cap())
+	// larger than chunk
+	assert.Len(t, a.Alloc(20), 20)
+	assert.Equal(t, 16, a.Cap())
+	assert.Equal(t, 31, a.Allocated())
+	assert.Nil(t, a.Copy(nil))
+
+	// chunks are reused after reset
+	a.Reset()
+	assert.Zero(t, a.Allocated())
+	b4 := a.Copy([]byte("12345678"))
+	assert.Equal(t, "12345678", string(b4))
+	assert.Equal(t, &a.chunks[0][0], &b4[0])
+	b5 := a.Copy([]byte("1"))
+	assert.Equal(t, &a.chunks[1][0], &b5[0])
+	assert.Equal(t, 16, a.Cap())
+}
+
+func buildArenaRow(rb *RowBuilder, i int) {
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddTimestamp(int64(i + 1))
+	_ = rb.AddTag([]byte("ip"), []byte("1.1.1."+strconv.Itoa(i)))
+	_ = rb.AddTag([]byte("host"), []byte("host"+strconv.Itoa(i)))
+	_ = rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, float64(i))
+	_ = rb.AddStringField([]byte("state"), []byte("running"))
+	_ = rb.AddInt64Field([]byte("bytes"), flatMetricsV1.SimpleFieldTypeDeltaSum, int64(i))
+	_ = rb.AddExemplar([]byte("idle"), []byte("trace"), []byte("span"), 10)
+	_ = rb.AddSummaryField([]byte("latency"), 1, 1, []float64{0.5}, []float64{1})
+}
+
+func TestRowBuilder_WithArena(t *testing.T) {
+	arena := NewArena(128)
+	rb, release := NewRowBuilder(WithArena(arena))
+	defer release(rb)
+	expect := CreateRowBuilder()
+	for i := 0; i < 10; i++ {
+		buildArenaRow(rb, i)
+		buildArenaRow(expect, i)
+		data, err := rb.Build()
+		assert.NoError(t, err)
+		expectData, err := expect.Build()
+		assert.NoError(t, err)
+		assert.Equal(t, expectData, data)
+		rb.Reset()
+		expect.Reset()
+	}
+	assert.NotZero(t, arena.Allocated())
+}
+
+func TestBatchBuilder_WithArena(t *testing.T) {
+	arena := NewArena(0)
+	bb := NewBatchBuilder(WithArena(arena))
+	expect := NewBatchBuilder()
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 100; i++ {
+			buildArenaRow(bb.RowBuilder(), i)
+			assert.NoError(t, bb.Commit())
+			buildArenaRow(expect.RowBuilder(), i)
+			assert.NoError(t, expect.Commit())
+		}
+		assert.Equal(t, expect.Payload(), bb.Payload())
+		bb.Reset()
+		expect.Reset()
+		// arena is reset per batch
+		assert.Zero(t, arena.Allocated())
+		assert.Equal(t, defaultArenaChunkSize, arena.Cap())
+	}
+}
+
+func Benchmark_BatchBuilder_Arena(b *testing.B) {
+	run := func(b *testing.B, options ...RowBuilderOption) {
+		bb := NewBatchBuilder(options...)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 100; j++ {
+				rb, release := NewRowBuilder(options...)
+				buildArenaRow(rb, j)
+				_, _ = rb.Build()
+				release(rb)
+			}
+			bb.Reset()
+		}
+	}
+	b.Run("heap", func(b *testing.B) { run(b) })
+	b.Run("arena", func(b *testing.B) { run(b, WithArena(NewArena(0))) })
+}
@@ -0,0 +1,233 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// aggregateKey is the identity of series point in a batch.
+type aggregateKey struct {
+	nameHash  uint64 // hash of namespace and metric name
+	tagsHash  uint64
+	timestamp int64
+}
+
+// BatchAggregator merges the rows sharing (hash, timestamp) within a batch on gateway side,
+// reduces the row count from chatty clients before the broker sees them.
+// The simple fields are merged according to field type(see WithFieldMerge), the histogram compound fields
+// with same bounds are summed and the exemplars are concatenated.
+// The rows with summary fields, typed(int64/string) fields, original tags or different histogram bounds
+// cannot be merged, the series of them are kept as is.
+type BatchAggregator struct {
+	builder *BatchBuilder
+	index   map[aggregateKey]int // key => index of groups
+	groups  [][]rowRange         // row ranges of each series in order of first occurrence
+	itr     RowIterator
+	buf     []byte
+
+	rows   int
+	merged int64
+}
+
+// NewBatchAggregator creates a batch aggregator, the options are applied to the row builder of merged rows.
+func NewBatchAggregator(options ...RowBuilderOption) *BatchAggregator {
+	return &BatchAggregator{
+		builder: NewBatchBuilder(append(options, WithFieldMerge())...),
+		index:   make(map[aggregateKey]int),
+	}
+}
+
+// SetHashStrategy sets the hash strategy of merged rows, which should be same as the strategy of the batch.
+func (a *BatchAggregator) SetHashStrategy(strategy HashStrategy) {
+	a.builder.SetHashStrategy(strategy)
+}
+
+// Aggregate merges the duplicate rows of the size prefixed rows payload(see BatchBuilder.Payload),
+// the merged row is placed at the position of the first row of its series.
+// Returns payload as is if no duplicate row, else the result is only valid until next Aggregate.
+func (a *BatchAggregator) Aggregate(payload []byte) ([]byte, error) {
+	a.reset()
+	err := walkRows(payload, func(pos, end int) error {
+		metric := flatMetricsV1.GetSizePrefixedRootAsMetric(payload[pos:end], 0)
+		key := aggregateKey{nameHash: metric.NameHash(), tagsHash: metric.KvsHash(), timestamp: metric.Timestamp()}
+		idx, ok := a.index[key]
+		if !ok {
+			idx = len(a.groups)
+			a.index[key] = idx
+			a.groups = append(a.groups, nil)
+		}
+		a.groups[idx] = append(a.groups[idx], rowRange{start: pos, end: end})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows := 0
+	for _, ranges := range a.groups {
+		rows += len(ranges)
+	}
+	if len(a.groups) == rows {
+		a.rows = rows
+		return payload, nil
+	}
+	result := a.buf[:0]
+	for _, ranges := range a.groups {
+		if len(ranges) > 1 && a.mergeable(payload, ranges) {
+			data, err0 := a.merge(payload, ranges)
+			if err0 != nil {
+				return nil, err0
+			}
+			result = append(result, data...)
+			a.rows++
+			a.merged += int64(len(ranges) - 1)
+			continue
+		}
+		for _, r := range ranges {
+			result = append(result, payload[r.start:r.end]...)
+		}
+		a.rows += len(ranges)
+	}
+	a.buf = result
+	return result, nil
+}
+
+// Rows returns the number of rows of the last aggregated result.
+func (a *BatchAggregator) Rows() int { return a.rows }
+
+// MergedRows returns the total number of rows eliminated by merging.
+func (a *BatchAggregator) MergedRows() int64 { return a.merged }
+
+// reset clears the state of last batch.
+func (a *BatchAggregator) reset() {
+	clear(a.index)
+	a.groups = a.groups[:0]
+	a.rows = 0
+}
+
+// mergeable returns if the rows of series can be merged into one row.
+func (a *BatchAggregator) mergeable(payload []byte, ranges []rowRange) bool {
+	var bounds []float64
+	for _, r := range ranges {
+		itr := a.rowIterator(payload, r)
+		if !itr.Next() {
+			return false
+		}
+		if itr.SummaryFieldsLen() > 0 || itr.OriginalTagsLen() > 0 || typedFieldsLen(itr.Metric()) > 0 {
+			return false
+		}
+		if !itr.HasCompoundField() {
+			continue
+		}
+		if bounds == nil {
+			for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+				bound, _ := itr.CompoundFieldBucket(i)
+				bounds = append(bounds, bound)
+			}
+			continue
+		}
+		if itr.CompoundFieldBucketsLen() != len(bounds) {
+			return false
+		}
+		for i := range bounds {
+			if bound, _ := itr.CompoundFieldBucket(i); bound != bounds[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// merge builds the rows of series into one row.
+func (a *BatchAggregator) merge(payload []byte, ranges []rowRange) ([]byte, error) {
+	a.builder.Reset()
+	rb := a.builder.RowBuilder()
+	var (
+		hasCompound        bool
+		values, bounds     []float64
+		minValue, maxValue float64
+		sum, count         float64
+	)
+	for i, r := range ranges {
+		itr := a.rowIterator(payload, r)
+		_ = itr.Next() // checked by mergeable
+		if i == 0 {
+			rb.AddNameSpace(itr.Namespace())
+			rb.AddMetricName(itr.Name())
+			rb.AddTimestamp(itr.Timestamp())
+			for j := 0; j < itr.TagsLen(); j++ {
+				if err := rb.AddTag(itr.Tag(j)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for j := 0; j < itr.SimpleFieldsLen(); j++ {
+			name, fieldType, value := itr.SimpleField(j)
+			var err error
+			if itr.SimpleFieldIsAbsent(j) {
+				err = rb.AddAbsentSimpleField(name, fieldType)
+			} else {
+				err = rb.AddSimpleField(name, fieldType, value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if itr.HasCompoundField() {
+			rowMin, rowMax, rowSum, rowCount := itr.CompoundFieldMMSC()
+			if !hasCompound {
+				hasCompound = true
+				minValue, maxValue = rowMin, rowMax
+				values = make([]float64, itr.CompoundFieldBucketsLen())
+				bounds = make([]float64, itr.CompoundFieldBucketsLen())
+			}
+			minValue = min(minValue, rowMin)
+			maxValue = max(maxValue, rowMax)
+			sum += rowSum
+			count += rowCount
+			for j := range values {
+				bound, value := itr.CompoundFieldBucket(j)
+				bounds[j] = bound
+				values[j] += value
+			}
+		}
+		for j := 0; j < itr.ExemplarsLen(); j++ {
+			if err := rb.AddExemplar(itr.Exemplar(j)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if hasCompound {
+		if err := rb.AddCompoundFieldData(values, bounds); err != nil {
+			return nil, err
+		}
+		if err := rb.AddCompoundFieldMMSC(minValue, maxValue, sum, count); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.builder.Commit(); err != nil {
+		return nil, err
+	}
+	return a.builder.Payload(), nil
+}
+
+// rowIterator returns the reused iterator of the row.
+func (a *BatchAggregator) rowIterator(payload []byte, r rowRange) *RowIterator {
+	a.itr = RowIterator{batch: BatchIterator{payload: payload[r.start:r.end]}}
+	return &a.itr
+}
@@ -0,0 +1,217 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// aggregateRow represents a row for building the payload of aggregation test.
+type aggregateRow struct {
+	name      string
+	host      string
+	timestamp int64
+	build     func(rb *RowBuilder)
+}
+
+func buildAggregatePayload(t *testing.T, rows []aggregateRow, options ...RowBuilderOption) []byte {
+	bb := NewBatchBuilder(options...)
+	for _, row := range rows {
+		rb := bb.RowBuilder()
+		rb.AddNameSpace([]byte("ns"))
+		rb.AddMetricName([]byte(row.name))
+		rb.AddTimestamp(row.timestamp)
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte(row.host)))
+		if row.build != nil {
+			row.build(rb)
+		} else {
+			assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+		}
+		assert.NoError(t, bb.Commit())
+	}
+	return append([]byte(nil), bb.Payload()...)
+}
+
+func aggregatedRows(t *testing.T, payload []byte) (rows []string) {
+	itr := NewRowIterator(payload)
+	for itr.Next() {
+		rows = append(rows, itr.Row().String())
+	}
+	assert.NoError(t, itr.Err())
+	return rows
+}
+
+func TestBatchAggregator_Aggregate(t *testing.T) {
+	simple := func(fields ...any) func(rb *RowBuilder) {
+		return func(rb *RowBuilder) {
+			for i := 0; i < len(fields); i += 3 {
+				assert.NoError(t, rb.AddSimpleField([]byte(fields[i].(string)),
+					fields[i+1].(flatMetricsV1.SimpleFieldType), fields[i+2].(float64)))
+			}
+		}
+	}
+	histogram := func(values []float64, minValue, maxValue, sum, count float64) func(rb *RowBuilder) {
+		return func(rb *RowBuilder) {
+			assert.NoError(t, rb.AddCompoundFieldData(values, []float64{1, 10, math.Inf(1)}))
+			assert.NoError(t, rb.AddCompoundFieldMMSC(minValue, maxValue, sum, count))
+			assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+		}
+	}
+	payload := buildAggregatePayload(t, []aggregateRow{
+		{name: "cpu", host: "h1", timestamp: 100, build: simple(
+			"count", flatMetricsV1.SimpleFieldTypeDeltaSum, 1.0,
+			"max", flatMetricsV1.SimpleFieldTypeMax, 5.0,
+			"last", flatMetricsV1.SimpleFieldTypeLast, 1.0)},
+		{name: "cpu", host: "h2", timestamp: 100, build: simple("count", flatMetricsV1.SimpleFieldTypeDeltaSum, 1.0)},
+		{name: "cpu", host: "h1", timestamp: 200, build: simple("count", flatMetricsV1.SimpleFieldTypeDeltaSum, 1.0)},
+		{name: "cpu", host: "h1", timestamp: 100, build: simple(
+			"count", flatMetricsV1.SimpleFieldTypeDeltaSum, 2.0,
+			"max", flatMetricsV1.SimpleFieldTypeMax, 3.0,
+			"last", flatMetricsV1.SimpleFieldTypeLast, 2.0,
+			"min", flatMetricsV1.SimpleFieldTypeMin, 2.0)},
+		{name: "latency", host: "h1", timestamp: 100, build: histogram([]float64{1, 2, 0}, 0.5, 8, 20, 3)},
+		{name: "cpu", host: "h1", timestamp: 100, build: func(rb *RowBuilder) {
+			assert.NoError(t, rb.AddAbsentSimpleField([]byte("last"), flatMetricsV1.SimpleFieldTypeLast))
+			assert.NoError(t, rb.AddSimpleField([]byte("max"), flatMetricsV1.SimpleFieldTypeMax, 7))
+		}},
+		{name: "latency", host: "h1", timestamp: 100, build: histogram([]float64{0, 1, 1}, 2, 12, 30, 2)},
+	})
+
+	a := NewBatchAggregator()
+	result, err := a.Aggregate(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, a.Rows())
+	assert.Equal(t, int64(3), a.MergedRows())
+	assert.Equal(t, []string{
+		"ns:cpu{host=h1} 100 count(DeltaSum)=3 max(Max)=7 last(Last)=2 min(Min)=2",
+		"ns:cpu{host=h2} 100 count(DeltaSum)=1",
+		"ns:cpu{host=h1} 200 count(DeltaSum)=1",
+		"ns:latency{host=h1} 100 histogram{min=0.5,max=12,sum=50,count=5,buckets=[1:1,10:3,+Inf:1]}" +
+			" exemplar{name=e,trace=trace,span=span,duration=10} exemplar{name=e,trace=trace,span=span,duration=10}",
+	}, aggregatedRows(t, result))
+
+	// merged row has same hash as original row
+	itr := NewRowIterator(payload)
+	assert.True(t, itr.Next())
+	nameHash, tagsHash := itr.NameHash(), itr.TagsHash()
+	itr = NewRowIterator(result)
+	assert.True(t, itr.Next())
+	assert.Equal(t, nameHash, itr.NameHash())
+	assert.Equal(t, tagsHash, itr.TagsHash())
+
+	// state is reset for next batch
+	result, err = a.Aggregate(payload[:0])
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, 0, a.Rows())
+	assert.Equal(t, int64(3), a.MergedRows())
+}
+
+func TestBatchAggregator_NoDuplicate(t *testing.T) {
+	payload := buildAggregatePayload(t, []aggregateRow{
+		{name: "cpu", host: "h1", timestamp: 100},
+		{name: "cpu", host: "h1", timestamp: 200},
+		{name: "mem", host: "h1", timestamp: 100},
+		{name: "cpu", host: "h2", timestamp: 100},
+	})
+	a := NewBatchAggregator()
+	result, err := a.Aggregate(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, result)
+	assert.Equal(t, 4, a.Rows())
+	assert.Equal(t, int64(0), a.MergedRows())
+
+	_, err = a.Aggregate([]byte{1, 2})
+	assert.Error(t, err)
+}
+
+func TestBatchAggregator_NotMergeable(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []RowBuilderOption
+		build   func(rb *RowBuilder)
+		second  func(rb *RowBuilder)
+	}{
+		{
+			name: "summary field",
+			build: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddSummaryField([]byte("s"), 1, 1, []float64{0.5}, []float64{1}))
+			},
+		},
+		{
+			name: "typed field",
+			build: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddInt64Field([]byte("i"), flatMetricsV1.SimpleFieldTypeLast, 1))
+			},
+		},
+		{
+			name:    "original tags",
+			options: []RowBuilderOption{WithTagOrder()},
+		},
+		{
+			name: "different histogram bounds",
+			build: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 1}, []float64{1, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 1, 2))
+			},
+			second: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 1}, []float64{2, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 1, 2))
+			},
+		},
+		{
+			name: "different histogram buckets",
+			build: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 1}, []float64{1, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 1, 2))
+			},
+			second: func(rb *RowBuilder) {
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 1, 1}, []float64{1, 2, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 1, 3))
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			second := tt.second
+			if second == nil {
+				second = tt.build
+			}
+			payload := buildAggregatePayload(t, []aggregateRow{
+				{name: "cpu", host: "h1", timestamp: 100, build: tt.build},
+				{name: "mem", host: "h1", timestamp: 100, build: tt.build},
+				{name: "cpu", host: "h1", timestamp: 100, build: second},
+			}, tt.options...)
+			a := NewBatchAggregator()
+			result, err := a.Aggregate(payload)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, a.Rows())
+			assert.Equal(t, int64(0), a.MergedRows())
+			rows := aggregatedRows(t, result)
+			assert.Len(t, rows, 3)
+			// rows of series are kept in order of first occurrence
+			assert.Equal(t, aggregatedRows(t, payload), []string{rows[0], rows[2], rows[1]})
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// maxSharedStringLen is the max length of string in shared string table,
+// long values(e.g. trace id) are unlikely to be shared.
+const maxSharedStringLen = 128
+
+// BatchBuilder builds multi flat metrics into one payload.
+// All rows share one row builder(include the flat builder) and a string table which is cleared by Reset,
+// each row is appended into payload with size prefix.
+//
+//	+------+-------------+------+-------------+-----+
+//	| size | flat metric | size | flat metric | ... |
+//	+------+-------------+------+-------------+-----+
+type BatchBuilder struct {
+	rowBuilder *RowBuilder
+	payload    []byte
+	rows       int
+	hasV2Rows  bool
+
+	groups *namespaceGroups // row ranges grouped by namespace if GroupByNamespace is enabled
+}
+
+// NewBatchBuilder creates a batch builder for building multi flat metrics,
+// the options are applied to the shared row builder.
+func NewBatchBuilder(options ...RowBuilderOption) *BatchBuilder {
+	rb := CreateRowBuilder(options...)
+	rb.sharedStrings = make(map[string]string)
+	return &BatchBuilder{rowBuilder: rb}
+}
+
+// RowBuilder returns the shared row builder for building next row,
+// invoke Commit after the row is filled.
+func (bb *BatchBuilder) RowBuilder() *RowBuilder {
+	return bb.rowBuilder
+}
+
+// SetLimits sets the limits of each row, nil means unlimited.
+func (bb *BatchBuilder) SetLimits(limits *Limits) {
+	bb.rowBuilder.SetLimits(limits)
+}
+
+// SetHashStrategy sets the hash strategy of each row, nil means DefaultHashStrategy.
+func (bb *BatchBuilder) SetHashStrategy(strategy HashStrategy) {
+	bb.rowBuilder.SetHashStrategy(strategy)
+}
+
+// SetTagInterner sets the tag interner of each row, nil means no interning.
+func (bb *BatchBuilder) SetTagInterner(interner *TagInterner) {
+	bb.rowBuilder.SetTagInterner(interner)
+}
+
+// SetSanitizer sets the sanitizer of each row, nil means the default sanitizing.
+func (bb *BatchBuilder) SetSanitizer(sanitizer Sanitizer) {
+	bb.rowBuilder.SetSanitizer(sanitizer)
+}
+
+// Commit builds the current row then appends it into payload,
+// the row builder is reset for next row whether successful or not.
+func (bb *BatchBuilder) Commit() error {
+	defer bb.rowBuilder.Reset()
+
+	data, err := bb.rowBuilder.Build()
+	if err != nil {
+		return err
+	}
+	if bb.groups != nil {
+		bb.groups.add(bb.rowBuilder.nameSpace, len(bb.payload), len(bb.payload)+len(data))
+	}
+	bb.payload = append(bb.payload, data...)
+	bb.rows++
+	bb.hasV2Rows = bb.hasV2Rows || bb.rowBuilder.IsV2()
+	return nil
+}
+
+// BatchMark records the committed rows of batch builder, see Mark/Rollback.
+type BatchMark struct {
+	size      int
+	rows      int
+	hasV2Rows bool
+}
+
+// Mark returns the mark of committed rows, the rows committed after it can be discarded by Rollback,
+// e.g. a converter fails halfway through a request.
+func (bb *BatchBuilder) Mark() BatchMark {
+	return BatchMark{size: len(bb.payload), rows: bb.rows, hasV2Rows: bb.hasV2Rows}
+}
+
+// Rollback discards the rows committed after the mark and resets the row builder,
+// the mark is ignored if the batch is reset after Mark.
+func (bb *BatchBuilder) Rollback(mark BatchMark) {
+	bb.rowBuilder.Reset()
+	if mark.size > len(bb.payload) || mark.rows > bb.rows {
+		return
+	}
+	bb.payload = bb.payload[:mark.size]
+	bb.rows = mark.rows
+	bb.hasV2Rows = mark.hasV2Rows
+	if bb.groups != nil {
+		bb.groups.truncate(mark.size)
+	}
+}
+
+// Rows returns the number of committed rows.
+func (bb *BatchBuilder) Rows() int { return bb.rows }
+
+// Size returns the byte size of payload.
+func (bb *BatchBuilder) Size() int { return len(bb.payload) }
+
+// Payload returns the payload of all committed rows, it's only valid until next Reset.
+func (bb *BatchBuilder) Payload() []byte { return bb.payload }
+
+// Reset resets the builder for building next batch.
+func (bb *BatchBuilder) Reset() {
+	bb.rowBuilder.Reset()
+	if bb.rowBuilder.arena != nil {
+		bb.rowBuilder.arena.Reset()
+	}
+	clear(bb.rowBuilder.sharedStrings)
+	bb.payload = bb.payload[:0]
+	bb.rows = 0
+	bb.hasV2Rows = false
+	if bb.groups != nil {
+		bb.groups.reset()
+	}
+}
+
+// BatchIterator iterates the flat metrics of payload built by BatchBuilder.
+type BatchIterator struct {
+	payload []byte
+	pos     int
+	metric  flatMetricsV1.Metric
+	err     error
+}
+
+// NewBatchIterator creates an iterator for the batch payload.
+func NewBatchIterator(payload []byte) *BatchIterator {
+	return &BatchIterator{payload: payload}
+}
+
+// HasNext moves to next flat metric, returns false if no more metric or payload is corrupted.
+func (itr *BatchIterator) HasNext() bool {
+	if itr.err != nil || itr.pos >= len(itr.payload) {
+		return false
+	}
+	if len(itr.payload)-itr.pos < flatbuffers.SizeUint32 {
+		itr.err = fmt.Errorf("corrupted batch payload, size prefix is truncated at: %d", itr.pos)
+		return false
+	}
+	size := int(binary.LittleEndian.Uint32(itr.payload[itr.pos:]))
+	end := itr.pos + flatbuffers.SizeUint32 + size
+	if size < flatbuffers.SizeUOffsetT || end > len(itr.payload) {
+		itr.err = fmt.Errorf("corrupted batch payload, metric size: %d is invalid at: %d", size, itr.pos)
+		return false
+	}
+	root := flatbuffers.GetUOffsetT(itr.payload[itr.pos+flatbuffers.SizeUint32:]) + flatbuffers.SizeUint32
+	if int(root) >= end-itr.pos {
+		itr.err = fmt.Errorf("corrupted batch payload, root offset: %d is invalid at: %d", root, itr.pos)
+		return false
+	}
+	itr.metric.Init(itr.payload[itr.pos:end], root)
+	itr.pos = end
+	return true
+}
+
+// Metric returns the current flat metric, it's only valid until next HasNext.
+func (itr *BatchIterator) Metric() *flatMetricsV1.Metric { return &itr.metric }
+
+// Err returns the error when iterating the payload.
+func (itr *BatchIterator) Err() error { return itr.err }
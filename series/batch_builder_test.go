@@ -0,0 +1,122 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestBatchBuilder(t *testing.T) {
+	bb := NewBatchBuilder()
+	for i := 0; i < 10; i++ {
+		rb := bb.RowBuilder()
+		rb.AddMetricName([]byte("cpu"))
+		rb.AddTimestamp(int64(i + 1))
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("host"+strconv.Itoa(i))))
+		assert.NoError(t, rb.AddTag([]byte("ip"), []byte("host"+strconv.Itoa(i))))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, float64(i)))
+		assert.NoError(t, bb.Commit())
+	}
+	// build failure
+	assert.Error(t, bb.Commit())
+	assert.Equal(t, 10, bb.Rows())
+	assert.NotZero(t, bb.Size())
+
+	itr := NewBatchIterator(bb.Payload())
+	count := 0
+	for itr.HasNext() {
+		m := itr.Metric()
+		assert.Equal(t, "cpu", string(m.Name()))
+		assert.Equal(t, int64(count+1), m.Timestamp())
+		assert.Equal(t, 2, m.KeyValuesLength())
+		var kv flatMetricsV1.KeyValue
+		assert.True(t, m.KeyValues(&kv, 1))
+		assert.Equal(t, "host"+strconv.Itoa(count), string(kv.Value()))
+		count++
+	}
+	assert.NoError(t, itr.Err())
+	assert.Equal(t, 10, count)
+
+	bb.Reset()
+	assert.Zero(t, bb.Rows())
+	assert.Zero(t, bb.Size())
+}
+
+func TestBatchBuilder_SharedStrings(t *testing.T) {
+	name, host, zone, value, field := []byte("cpu"), []byte("host"), []byte("zone"), []byte("host1"), []byte("idle")
+	commitFunc := func(bb *BatchBuilder) func() {
+		return func() {
+			rb := bb.RowBuilder()
+			rb.AddMetricName(name)
+			rb.AddTimestamp(1)
+			_ = rb.AddTag(host, value)
+			_ = rb.AddTag(zone, value)
+			_ = rb.AddSimpleField(field, flatMetricsV1.SimpleFieldTypeLast, 1)
+			_ = bb.Commit()
+		}
+	}
+	bb := NewBatchBuilder()
+	commit := commitFunc(bb)
+	commit()
+	// same strings of row are written once
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, []Tag{{Key: "host", Value: "host1"}, {Key: "zone", Value: "host1"}}, itr.Row().Tags)
+	assert.Len(t, bb.rowBuilder.sharedStrings, 4)
+
+	// strings are converted once per batch, no more allocation than building without shared strings
+	noShared := NewBatchBuilder()
+	noShared.rowBuilder.sharedStrings = nil
+	noShared.payload = make([]byte, 0, 64*1024)
+	bb.payload = make([]byte, 0, 64*1024)
+	assert.Equal(t, testing.AllocsPerRun(100, commitFunc(noShared)), testing.AllocsPerRun(100, commit))
+	assert.Greater(t, noShared.Size(), bb.Size())
+	assert.Len(t, bb.rowBuilder.sharedStrings, 4)
+
+	bb.Reset()
+	assert.Empty(t, bb.rowBuilder.sharedStrings)
+}
+
+func TestBatchIterator_Corrupted(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	payload := bb.Payload()
+
+	// size prefix truncated
+	itr := NewBatchIterator(append(append([]byte{}, payload...), 1, 2))
+	assert.True(t, itr.HasNext())
+	assert.False(t, itr.HasNext())
+	assert.Error(t, itr.Err())
+	assert.False(t, itr.HasNext())
+	// metric truncated
+	itr = NewBatchIterator(payload[:len(payload)-1])
+	assert.False(t, itr.HasNext())
+	assert.Error(t, itr.Err())
+	// root offset invalid
+	itr = NewBatchIterator([]byte{4, 0, 0, 0, 100, 0, 0, 0})
+	assert.False(t, itr.HasNext())
+	assert.Error(t, itr.Err())
+}
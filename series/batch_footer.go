@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// BatchFooterSize is the byte size of batch footer.
+	BatchFooterSize = 16
+	// batchFooterMagic is the magic number of batch footer("LBF1").
+	batchFooterMagic uint32 = 0x3146424c
+)
+
+// PayloadWithFooter returns the payload of all committed rows with an integrity footer,
+// it's only valid until next Commit/Reset.
+//
+//	+------+------+-----+-----------+----------------+--------------+
+//	| rows | ...  | ... | row count | xxhash of rows | footer magic |
+//	+------+------+-----+-----------+----------------+--------------+
+//	                    |  4 bytes  |    8 bytes     |   4 bytes    |
+func (bb *BatchBuilder) PayloadWithFooter() []byte {
+	return AppendBatchFooter(bb.payload, bb.rows)
+}
+
+// AppendBatchFooter appends the footer with row count and checksum of payload.
+func AppendBatchFooter(payload []byte, rows int) []byte {
+	var footer [BatchFooterSize]byte
+	binary.LittleEndian.PutUint32(footer[0:], uint32(rows))
+	binary.LittleEndian.PutUint64(footer[4:], xxhash.Sum64(payload))
+	binary.LittleEndian.PutUint32(footer[12:], batchFooterMagic)
+	return append(payload, footer[:]...)
+}
+
+// HasBatchFooter checks if the payload ends with a batch footer.
+func HasBatchFooter(payload []byte) bool {
+	return len(payload) >= BatchFooterSize &&
+		binary.LittleEndian.Uint32(payload[len(payload)-4:]) == batchFooterMagic
+}
+
+// VerifyBatchPayload verifies the checksum and row count of payload with footer,
+// returns the payload without footer which can be iterated by BatchIterator/RowIterator.
+func VerifyBatchPayload(payload []byte) ([]byte, error) {
+	if len(payload) < BatchFooterSize {
+		return nil, fmt.Errorf("corrupted batch payload, size: %d is less than footer size", len(payload))
+	}
+	footer := payload[len(payload)-BatchFooterSize:]
+	if magic := binary.LittleEndian.Uint32(footer[12:]); magic != batchFooterMagic {
+		return nil, fmt.Errorf("corrupted batch payload, footer magic: %x mismatch", magic)
+	}
+	rows := payload[:len(payload)-BatchFooterSize]
+	expectChecksum := binary.LittleEndian.Uint64(footer[4:])
+	if checksum := xxhash.Sum64(rows); checksum != expectChecksum {
+		return nil, fmt.Errorf("corrupted batch payload, checksum: %x mismatch, expect: %x", checksum, expectChecksum)
+	}
+	expectRows := int(binary.LittleEndian.Uint32(footer[0:]))
+	count := 0
+	itr := NewBatchIterator(rows)
+	for itr.HasNext() {
+		count++
+	}
+	if err := itr.Err(); err != nil {
+		return nil, err
+	}
+	if count != expectRows {
+		return nil, fmt.Errorf("corrupted batch payload, row count: %d mismatch, expect: %d", count, expectRows)
+	}
+	return rows, nil
+}
@@ -0,0 +1,84 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestBatchFooter(t *testing.T) {
+	bb := NewBatchBuilder()
+	for i := 0; i < 3; i++ {
+		rb := bb.RowBuilder()
+		rb.AddMetricName([]byte("cpu"))
+		rb.AddTimestamp(int64(i + 1))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, float64(i)))
+		assert.NoError(t, bb.Commit())
+	}
+	payload := append([]byte{}, bb.PayloadWithFooter()...)
+	assert.Len(t, payload, bb.Size()+BatchFooterSize)
+	assert.True(t, HasBatchFooter(payload))
+	assert.False(t, HasBatchFooter(bb.Payload()))
+
+	rows, err := VerifyBatchPayload(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, bb.Payload(), rows)
+	itr := NewRowIterator(rows)
+	count := 0
+	for itr.Next() {
+		count++
+	}
+	assert.Equal(t, 3, count)
+
+	// empty batch
+	bb.Reset()
+	rows, err = VerifyBatchPayload(bb.PayloadWithFooter())
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestVerifyBatchPayload_Corrupted(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	payload := append([]byte{}, bb.Payload()...)
+
+	// too short
+	_, err := VerifyBatchPayload([]byte{1, 2, 3})
+	assert.ErrorContains(t, err, "less than footer size")
+	// no footer
+	_, err = VerifyBatchPayload(payload)
+	assert.ErrorContains(t, err, "footer magic")
+	// checksum mismatch
+	data := AppendBatchFooter(append([]byte{}, payload...), 1)
+	data[10]++
+	_, err = VerifyBatchPayload(data)
+	assert.ErrorContains(t, err, "checksum")
+	// row count mismatch
+	_, err = VerifyBatchPayload(AppendBatchFooter(append([]byte{}, payload...), 2))
+	assert.ErrorContains(t, err, "row count")
+	// rows corrupted before checksum computed
+	_, err = VerifyBatchPayload(AppendBatchFooter(append([]byte{}, payload[:len(payload)-1]...), 1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// NamespacePayload represents the rows of one namespace in a batch.
+type NamespacePayload struct {
+	Namespace string // empty means the default namespace
+	Payload   []byte // size prefixed rows, can be iterated by BatchIterator/RowIterator
+	Rows      int
+}
+
+// rowRange represents the byte range of a row in batch payload.
+type rowRange struct {
+	start, end int
+}
+
+// namespaceGroups records the row ranges of each namespace in committed order.
+type namespaceGroups struct {
+	namespaces []string
+	ranges     map[string][]rowRange
+}
+
+// add records the range of a committed row.
+func (g *namespaceGroups) add(namespace []byte, start, end int) {
+	ranges, ok := g.ranges[string(namespace)]
+	if !ok {
+		g.namespaces = append(g.namespaces, string(namespace))
+	}
+	g.ranges[string(namespace)] = append(ranges, rowRange{start: start, end: end})
+}
+
+// truncate removes the ranges of rows starting from the size.
+func (g *namespaceGroups) truncate(size int) {
+	namespaces := g.namespaces[:0]
+	for _, namespace := range g.namespaces {
+		ranges := g.ranges[namespace]
+		n := len(ranges)
+		for n > 0 && ranges[n-1].start >= size {
+			n--
+		}
+		if n == 0 {
+			delete(g.ranges, namespace)
+			continue
+		}
+		g.ranges[namespace] = ranges[:n]
+		namespaces = append(namespaces, namespace)
+	}
+	g.namespaces = namespaces
+}
+
+// reset clears the recorded ranges.
+func (g *namespaceGroups) reset() {
+	g.namespaces = g.namespaces[:0]
+	clear(g.ranges)
+}
+
+// GroupByNamespace enables grouping the committed rows by namespace, so that NamespacePayloads
+// emits one payload per namespace, since the write path shards by (namespace, metric).
+// It should be invoked before committing any row, which is kept after Reset.
+func (bb *BatchBuilder) GroupByNamespace() {
+	if bb.groups != nil {
+		return
+	}
+	bb.groups = &namespaceGroups{ranges: make(map[string][]rowRange)}
+	if bb.rows > 0 {
+		// group committed rows
+		_ = walkRows(bb.payload, func(pos, end int) error {
+			metric := flatMetricsV1.GetSizePrefixedRootAsMetric(bb.payload[pos:end], 0)
+			bb.groups.add(metric.Namespace(), pos, end)
+			return nil
+		})
+	}
+}
+
+// NamespacePayloads returns the payloads of committed rows grouped by namespace,
+// in the order of first committed row of each namespace. Returns the whole payload as one group
+// if GroupByNamespace isn't enabled. The payloads are only valid until next Commit/Reset.
+func (bb *BatchBuilder) NamespacePayloads() []NamespacePayload {
+	if bb.rows == 0 {
+		return nil
+	}
+	if bb.groups == nil {
+		return []NamespacePayload{{Payload: bb.payload, Rows: bb.rows}}
+	}
+	if len(bb.groups.namespaces) == 1 {
+		namespace := bb.groups.namespaces[0]
+		return []NamespacePayload{{Namespace: namespace, Payload: bb.payload, Rows: bb.rows}}
+	}
+	payloads := make([]NamespacePayload, 0, len(bb.groups.namespaces))
+	for _, namespace := range bb.groups.namespaces {
+		ranges := bb.groups.ranges[namespace]
+		size := 0
+		for _, r := range ranges {
+			size += r.end - r.start
+		}
+		payload := make([]byte, 0, size)
+		for _, r := range ranges {
+			payload = append(payload, bb.payload[r.start:r.end]...)
+		}
+		payloads = append(payloads, NamespacePayload{Namespace: namespace, Payload: payload, Rows: len(ranges)})
+	}
+	return payloads
+}
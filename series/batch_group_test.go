@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func commitNamespaceRow(t *testing.T, bb *BatchBuilder, namespace, name string) {
+	rb := bb.RowBuilder()
+	rb.AddNameSpace([]byte(namespace))
+	rb.AddMetricName([]byte(name))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+}
+
+func namespacePayloadRows(t *testing.T, payload NamespacePayload) (rows []string) {
+	itr := NewRowIterator(payload.Payload)
+	for itr.Next() {
+		assert.Equal(t, payload.Namespace, string(itr.Namespace()))
+		rows = append(rows, string(itr.Name()))
+	}
+	assert.NoError(t, itr.Err())
+	assert.Len(t, rows, payload.Rows)
+	return rows
+}
+
+func TestBatchBuilder_GroupByNamespace(t *testing.T) {
+	bb := NewBatchBuilder()
+	bb.GroupByNamespace()
+	bb.GroupByNamespace()
+	assert.Nil(t, bb.NamespacePayloads())
+
+	commitNamespaceRow(t, bb, "ns1", "cpu")
+	commitNamespaceRow(t, bb, "ns2", "cpu")
+	commitNamespaceRow(t, bb, "", "cpu")
+	commitNamespaceRow(t, bb, "ns1", "mem")
+	// failed row isn't grouped
+	bb.RowBuilder().AddNameSpace([]byte("ns3"))
+	assert.Error(t, bb.Commit())
+
+	payloads := bb.NamespacePayloads()
+	assert.Len(t, payloads, 3)
+	assert.Equal(t, "ns1", payloads[0].Namespace)
+	assert.Equal(t, []string{"cpu", "mem"}, namespacePayloadRows(t, payloads[0]))
+	assert.Equal(t, "ns2", payloads[1].Namespace)
+	assert.Equal(t, []string{"cpu"}, namespacePayloadRows(t, payloads[1]))
+	assert.Equal(t, "", payloads[2].Namespace)
+	assert.Equal(t, []string{"cpu"}, namespacePayloadRows(t, payloads[2]))
+	size := 0
+	for _, p := range payloads {
+		size += len(p.Payload)
+	}
+	assert.Equal(t, bb.Size(), size)
+	// whole payload is kept
+	assert.Equal(t, 4, bb.Rows())
+
+	// single namespace shares payload
+	bb.Reset()
+	commitNamespaceRow(t, bb, "ns1", "cpu")
+	commitNamespaceRow(t, bb, "ns1", "mem")
+	payloads = bb.NamespacePayloads()
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "ns1", payloads[0].Namespace)
+	assert.Equal(t, bb.Payload(), payloads[0].Payload)
+	assert.Equal(t, []string{"cpu", "mem"}, namespacePayloadRows(t, payloads[0]))
+}
+
+func TestBatchBuilder_NamespacePayloads_NotGrouped(t *testing.T) {
+	bb := NewBatchBuilder()
+	commitNamespaceRow(t, bb, "ns1", "cpu")
+	commitNamespaceRow(t, bb, "ns2", "cpu")
+	payloads := bb.NamespacePayloads()
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, bb.Payload(), payloads[0].Payload)
+	assert.Equal(t, 2, payloads[0].Rows)
+
+	// enable grouping after committing
+	bb.GroupByNamespace()
+	commitNamespaceRow(t, bb, "ns1", "mem")
+	payloads = bb.NamespacePayloads()
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, []string{"cpu", "mem"}, namespacePayloadRows(t, payloads[0]))
+	assert.Equal(t, []string{"cpu"}, namespacePayloadRows(t, payloads[1]))
+}
+
+func TestBatchBuilder_Rollback(t *testing.T) {
+	bb := NewBatchBuilder()
+	bb.GroupByNamespace()
+	commitNamespaceRow(t, bb, "ns1", "cpu")
+	mark := bb.Mark()
+	size := bb.Size()
+	commitNamespaceRow(t, bb, "ns2", "cpu")
+	commitNamespaceRow(t, bb, "ns1", "mem")
+	bb.RowBuilder().AddMetricName([]byte("disk"))
+	bb.Rollback(mark)
+
+	assert.Equal(t, 1, bb.Rows())
+	assert.Equal(t, size, bb.Size())
+	payloads := bb.NamespacePayloads()
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, []string{"cpu"}, namespacePayloadRows(t, payloads[0]))
+	// row builder is reset
+	commitNamespaceRow(t, bb, "ns2", "mem")
+	payloads = bb.NamespacePayloads()
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, []string{"mem"}, namespacePayloadRows(t, payloads[1]))
+
+	// mark is ignored after reset
+	bb.Reset()
+	bb.Rollback(BatchMark{size: size, rows: 1})
+	assert.Zero(t, bb.Rows())
+	assert.Zero(t, bb.Size())
+}
@@ -0,0 +1,96 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// batchHeaderMagic is the magic number of batch header("LBH1"),
+	// which is not a valid size prefix of row(too large).
+	batchHeaderMagic uint32 = 0x3148424c
+	// batchHeaderPrefixSize is the byte size of magic and header size.
+	batchHeaderPrefixSize = 8
+)
+
+// BatchHeader represents the producer identity of a batch, which can be read without decoding rows,
+// e.g. routing/quota by tenant on gateway, or finding out the bad producer.
+type BatchHeader struct {
+	Source       string `json:"source,omitempty"`
+	AgentVersion string `json:"agentVersion,omitempty"`
+	Host         string `json:"host,omitempty"`
+	Tenant       string `json:"tenant,omitempty"`
+}
+
+// PayloadWithHeader returns a new payload which is prefixed the header before all committed rows.
+func (bb *BatchBuilder) PayloadWithHeader(header *BatchHeader) []byte {
+	return append(AppendBatchHeader(nil, header), bb.payload...)
+}
+
+// AppendBatchHeader appends the encoded header into dst, the header should be the first part of payload,
+// new fields are appended in the end of header for compatibility.
+//
+//	+-------+-------------+--------+---------------+------+--------+------+
+//	| magic | header size | source | agent version | host | tenant | rows |
+//	+-------+-------------+--------+---------------+------+--------+------+
+//	|   4   |      4      |     uvarint length + bytes for each field |
+func AppendBatchHeader(dst []byte, header *BatchHeader) []byte {
+	start := len(dst)
+	dst = binary.LittleEndian.AppendUint32(dst, batchHeaderMagic)
+	dst = binary.LittleEndian.AppendUint32(dst, 0)
+	for _, field := range []string{header.Source, header.AgentVersion, header.Host, header.Tenant} {
+		dst = binary.AppendUvarint(dst, uint64(len(field)))
+		dst = append(dst, field...)
+	}
+	binary.LittleEndian.PutUint32(dst[start+4:], uint32(len(dst)-start-batchHeaderPrefixSize))
+	return dst
+}
+
+// HasBatchHeader checks if the payload starts with a batch header.
+func HasBatchHeader(payload []byte) bool {
+	return len(payload) >= batchHeaderPrefixSize && binary.LittleEndian.Uint32(payload) == batchHeaderMagic
+}
+
+// ReadBatchHeader reads the header of payload, returns nil header and the original payload if no header,
+// else returns the header and the remaining payload(rows, maybe with footer).
+func ReadBatchHeader(payload []byte) (*BatchHeader, []byte, error) {
+	if !HasBatchHeader(payload) {
+		return nil, payload, nil
+	}
+	size := int(binary.LittleEndian.Uint32(payload[4:]))
+	if size > len(payload)-batchHeaderPrefixSize {
+		return nil, nil, fmt.Errorf("corrupted batch header, size: %d is invalid", size)
+	}
+	data := payload[batchHeaderPrefixSize : batchHeaderPrefixSize+size]
+	header := &BatchHeader{}
+	for _, field := range []*string{&header.Source, &header.AgentVersion, &header.Host, &header.Tenant} {
+		if len(data) == 0 {
+			// header written by older version
+			break
+		}
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return nil, nil, fmt.Errorf("corrupted batch header, field length is invalid")
+		}
+		*field = string(data[n : n+int(length)])
+		data = data[n+int(length):]
+	}
+	return header, payload[batchHeaderPrefixSize+size:], nil
+}
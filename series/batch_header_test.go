@@ -0,0 +1,101 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestBatchHeader(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+
+	header := &BatchHeader{Source: "agent", AgentVersion: "v1.0.0", Host: "host1", Tenant: "t1"}
+	payload := bb.PayloadWithHeader(header)
+	assert.True(t, HasBatchHeader(payload))
+	assert.False(t, HasBatchHeader(bb.Payload()))
+
+	h, rows, err := ReadBatchHeader(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, header, h)
+	assert.Equal(t, bb.Payload(), rows)
+	itr := NewRowIterator(rows)
+	assert.True(t, itr.Next())
+	assert.Equal(t, "cpu", string(itr.Name()))
+
+	// header with footer
+	payload = AppendBatchHeader(nil, &BatchHeader{Tenant: "t2"})
+	payload = append(payload, bb.PayloadWithFooter()...)
+	h, rows, err = ReadBatchHeader(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, &BatchHeader{Tenant: "t2"}, h)
+	rows, err = VerifyBatchPayload(rows)
+	assert.NoError(t, err)
+	assert.Equal(t, bb.Payload(), rows)
+
+	// no header
+	h, rows, err = ReadBatchHeader(bb.Payload())
+	assert.NoError(t, err)
+	assert.Nil(t, h)
+	assert.Equal(t, bb.Payload(), rows)
+}
+
+func TestReadBatchHeader_Compatibility(t *testing.T) {
+	// header written by older version with source only
+	payload := binary.LittleEndian.AppendUint32(nil, batchHeaderMagic)
+	payload = binary.LittleEndian.AppendUint32(payload, 3)
+	payload = append(payload, 2, 'a', 'b')
+	h, rows, err := ReadBatchHeader(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, &BatchHeader{Source: "ab"}, h)
+	assert.Empty(t, rows)
+
+	// header written by newer version with unknown field
+	payload = AppendBatchHeader(nil, &BatchHeader{Host: "h"})
+	binary.LittleEndian.PutUint32(payload[4:], binary.LittleEndian.Uint32(payload[4:])+2)
+	payload = append(payload, 1, 'x')
+	h, rows, err = ReadBatchHeader(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, &BatchHeader{Host: "h"}, h)
+	assert.Empty(t, rows)
+}
+
+func TestReadBatchHeader_Corrupted(t *testing.T) {
+	payload := AppendBatchHeader(nil, &BatchHeader{Source: "agent"})
+	// size too large
+	_, _, err := ReadBatchHeader(payload[:len(payload)-1])
+	assert.Error(t, err)
+	// field length too large
+	data := append([]byte{}, payload...)
+	data[batchHeaderPrefixSize] = 100
+	_, _, err = ReadBatchHeader(data)
+	assert.Error(t, err)
+	// invalid uvarint
+	data[batchHeaderPrefixSize] = 0xff
+	binary.LittleEndian.PutUint32(data[4:], 1)
+	_, _, err = ReadBatchHeader(data[:batchHeaderPrefixSize+1])
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// SplitBatch splits the batch payload(size prefixed rows) into chunks which don't exceed maxBytes,
+// rows are not re-encoded and chunks reference the payload, returns error if any row exceeds maxBytes.
+func SplitBatch(payload []byte, maxBytes int) ([][]byte, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("max bytes: %d should > 0", maxBytes)
+	}
+	if len(payload) <= maxBytes {
+		// fast path, validate framing only
+		if err := walkRows(payload, func(_, _ int) error { return nil }); err != nil {
+			return nil, err
+		}
+		if len(payload) == 0 {
+			return nil, nil
+		}
+		return [][]byte{payload}, nil
+	}
+	var (
+		chunks [][]byte
+		start  int
+	)
+	err := walkRows(payload, func(pos, end int) error {
+		if end-pos > maxBytes {
+			return fmt.Errorf("row size: %d at: %d exceeds max bytes: %d", end-pos, pos, maxBytes)
+		}
+		if end-start > maxBytes {
+			chunks = append(chunks, payload[start:pos])
+			start = pos
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if start < len(payload) {
+		chunks = append(chunks, payload[start:])
+	}
+	return chunks, nil
+}
+
+// walkRows walks the size prefixed rows of payload, invokes fn with the range of each row(include size prefix).
+func walkRows(payload []byte, fn func(pos, end int) error) error {
+	pos := 0
+	for pos < len(payload) {
+		if len(payload)-pos < flatbuffers.SizeUint32 {
+			return fmt.Errorf("corrupted batch payload, size prefix is truncated at: %d", pos)
+		}
+		size := int(binary.LittleEndian.Uint32(payload[pos:]))
+		end := pos + flatbuffers.SizeUint32 + size
+		if size < flatbuffers.SizeUOffsetT || end > len(payload) || end < pos {
+			return fmt.Errorf("corrupted batch payload, metric size: %d is invalid at: %d", size, pos)
+		}
+		if err := fn(pos, end); err != nil {
+			return err
+		}
+		pos = end
+	}
+	return nil
+}
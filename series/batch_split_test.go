@@ -0,0 +1,81 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitBatch(t *testing.T) {
+	payload := newCompressBatch(t, 10)
+	rowSize := 0
+	assert.NoError(t, walkRows(payload, func(pos, end int) error {
+		rowSize = max(rowSize, end-pos)
+		return nil
+	}))
+
+	chunks, err := SplitBatch(payload, len(payload))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{payload}, chunks)
+
+	for _, maxBytes := range []int{rowSize, rowSize*3 + 1, rowSize * 4, len(payload) - 1} {
+		chunks, err = SplitBatch(payload, maxBytes)
+		assert.NoError(t, err)
+		var (
+			merged []byte
+			rows   int
+		)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, len(chunk), maxBytes)
+			merged = append(merged, chunk...)
+			itr := NewRowIterator(chunk)
+			for itr.Next() {
+				rows++
+			}
+			assert.NoError(t, itr.Err())
+		}
+		assert.Equal(t, payload, merged)
+		assert.Equal(t, 10, rows)
+	}
+	chunks, err = SplitBatch(payload, rowSize)
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 10)
+
+	// empty payload
+	chunks, err = SplitBatch(nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestSplitBatch_Error(t *testing.T) {
+	payload := newCompressBatch(t, 2)
+	_, err := SplitBatch(payload, 0)
+	assert.Error(t, err)
+	// row too large
+	_, err = SplitBatch(payload, len(payload)/2-8)
+	assert.Error(t, err)
+	// corrupted
+	_, err = SplitBatch(payload[:len(payload)-1], len(payload))
+	assert.Error(t, err)
+	_, err = SplitBatch(append(append([]byte{}, payload...), 1), len(payload))
+	assert.Error(t, err)
+	_, err = SplitBatch(payload[:len(payload)-1], 10000)
+	assert.Error(t, err)
+}
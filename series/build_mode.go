@@ -0,0 +1,144 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// BuildMode represents how row builder handles the invalid data.
+type BuildMode int8
+
+const (
+	// BuildModeDefault rejects the invalid values, duplicate tags are deduped(last wins), duplicate fields are kept.
+	BuildModeDefault BuildMode = iota
+	// BuildModeLenient auto-corrects the invalid data instead of rejecting:
+	//   - metric name/tag key/tag value exceeding the limits are truncated
+	//   - NaN/Inf simple field is dropped, empty tag is dropped
+	//   - duplicate tags/fields are deduped silently(fields are merged, see WithFieldMerge)
+	BuildModeLenient
+	// BuildModeStrict rejects the invalid data with detailed errors, including duplicate tags/fields.
+	BuildModeStrict
+)
+
+var (
+	// ErrDuplicateTag represents the row has tags with same key in strict mode.
+	ErrDuplicateTag = errors.New("duplicate tag key")
+	// ErrDuplicateField represents the row has simple fields with same name and type in strict mode.
+	ErrDuplicateField = errors.New("duplicate field")
+)
+
+// String returns the name of build mode.
+func (m BuildMode) String() string {
+	switch m {
+	case BuildModeLenient:
+		return "lenient"
+	case BuildModeStrict:
+		return "strict"
+	default:
+		return "default"
+	}
+}
+
+// ParseBuildMode parses the build mode by name(case-insensitive), empty means default,
+// e.g. selected by the config/query parameter of gateway endpoint.
+func ParseBuildMode(name string) (BuildMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "default":
+		return BuildModeDefault, nil
+	case "lenient":
+		return BuildModeLenient, nil
+	case "strict":
+		return BuildModeStrict, nil
+	default:
+		return BuildModeDefault, fmt.Errorf("unknown build mode: %q, default, lenient and strict are available", name)
+	}
+}
+
+// WithBuildMode sets the build mode of row builder.
+func WithBuildMode(mode BuildMode) RowBuilderOption {
+	return func(rb *RowBuilder) {
+		rb.buildMode = mode
+	}
+}
+
+// SetBuildMode sets the build mode, which is kept after Reset.
+func (rb *RowBuilder) SetBuildMode(mode BuildMode) {
+	rb.buildMode = mode
+}
+
+// BuildMode returns the build mode of row builder.
+func (rb *RowBuilder) BuildMode() BuildMode {
+	return rb.buildMode
+}
+
+// truncateTag truncates the tag key/value by limits in lenient mode.
+func (l *Limits) truncateTag(key, value []byte) (truncatedKey, truncatedValue []byte) {
+	if l.MaxTagKeyLength > 0 && len(key) > l.MaxTagKeyLength {
+		key = truncateUTF8(key, l.MaxTagKeyLength)
+	}
+	if l.MaxTagValueLength > 0 && len(value) > l.MaxTagValueLength {
+		value = truncateUTF8(value, l.MaxTagValueLength)
+	}
+	return key, value
+}
+
+// truncateMetricName truncates the metric name by limits in lenient mode.
+func (rb *RowBuilder) truncateMetricName() {
+	if rb.limits != nil && rb.limits.MaxMetricNameLength > 0 && len(rb.metricName) > rb.limits.MaxMetricNameLength {
+		rb.metricName = truncateUTF8(rb.metricName, rb.limits.MaxMetricNameLength)
+	}
+}
+
+// truncateUTF8 truncates b to at most n bytes, backing off to a rune boundary,
+// so that a multi-byte rune is never split(Verify rejects invalid utf-8).
+func truncateUTF8(b []byte, n int) []byte {
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	return b[:n]
+}
+
+// checkDuplicates returns error if the row has duplicate tags or simple fields in strict mode.
+func (rb *RowBuilder) checkDuplicates() error {
+	// keep the insertion order of tags, see WithTagOrder
+	if !rb.tagsSorted {
+		for i := 1; i < rb.rowKVs.kvCount; i++ {
+			cur := &rb.rowKVs.kvs[i]
+			for j := 0; j < i; j++ {
+				if prev := &rb.rowKVs.kvs[j]; bytes.Equal(prev.key, cur.key) {
+					return fmt.Errorf("%w: %s, values: %s, %s, metric: %s",
+						ErrDuplicateTag, prev.key, prev.value, cur.value, rb.metricName)
+				}
+			}
+		}
+	}
+	for i := 1; i < rb.simpleFieldCount; i++ {
+		field := &rb.simpleFields[i]
+		for j := 0; j < i; j++ {
+			if rb.simpleFields[j].fType == field.fType && bytes.Equal(rb.simpleFields[j].name, field.name) {
+				return fmt.Errorf("%w: %s, type: %s, metric: %s", ErrDuplicateField, field.name, field.fType, rb.metricName)
+			}
+		}
+	}
+	return nil
+}
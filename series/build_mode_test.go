@@ -0,0 +1,167 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestParseBuildMode(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    BuildMode
+		wantErr bool
+	}{
+		{name: "", mode: BuildModeDefault},
+		{name: "default", mode: BuildModeDefault},
+		{name: " Lenient ", mode: BuildModeLenient},
+		{name: "STRICT", mode: BuildModeStrict},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := ParseBuildMode(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mode, mode)
+		})
+	}
+	assert.Equal(t, "default", BuildModeDefault.String())
+	assert.Equal(t, "lenient", BuildModeLenient.String())
+	assert.Equal(t, "strict", BuildModeStrict.String())
+}
+
+func TestRowBuilder_BuildModeLenient(t *testing.T) {
+	rb := CreateRowBuilder(WithBuildMode(BuildModeLenient))
+	assert.Equal(t, BuildModeLenient, rb.BuildMode())
+	rb.SetLimits(&Limits{MaxTagKeyLength: 4, MaxTagValueLength: 4, MaxMetricNameLength: 4})
+	rb.AddMetricName([]byte("memory"))
+	assert.NoError(t, rb.AddTag([]byte("hostname"), []byte("host-1")))
+	assert.NoError(t, rb.AddTag([]byte(""), []byte("a")))
+	assert.NoError(t, rb.AddTag([]byte("ip"), []byte("1.1.1.1")))
+	assert.NoError(t, rb.AddSortedTags([][]byte{[]byte("zone"), []byte("dc")}, [][]byte{[]byte("sh"), []byte("a")}))
+	assert.NoError(t, rb.AddSimpleField([]byte("used"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("used"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+	assert.NoError(t, rb.AddSimpleField([]byte("free"), flatMetricsV1.SimpleFieldTypeLast, math.NaN()))
+	assert.NoError(t, rb.AddSimpleField([]byte("free"), flatMetricsV1.SimpleFieldTypeLast, math.Inf(1)))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+
+	itr := NewRowIterator(data)
+	assert.True(t, itr.Next())
+	row := itr.Row()
+	assert.Equal(t, "memo", row.Name)
+	assert.Equal(t, []Tag{
+		{Key: "dc", Value: "a"},
+		{Key: "host", Value: "host"},
+		{Key: "ip", Value: "1.1."},
+		{Key: "zone", Value: "sh"},
+	}, row.Tags)
+	assert.Len(t, row.SimpleFields, 1)
+	assert.Equal(t, "used", row.SimpleFields[0].Name)
+	assert.Equal(t, 3.0, float64(row.SimpleFields[0].Value))
+
+	// mode is kept after reset
+	rb.Reset()
+	assert.Equal(t, BuildModeLenient, rb.BuildMode())
+
+	// multi-byte rune is not split
+	rb.AddMetricName([]byte("内存"))
+	assert.NoError(t, rb.AddTag([]byte("主机"), []byte("abc日本")))
+	assert.NoError(t, rb.AddTag([]byte("ip"), []byte("日本")))
+	assert.NoError(t, rb.AddSimpleField([]byte("used"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(data))
+	itr = NewRowIterator(data)
+	assert.True(t, itr.Next())
+	row = itr.Row()
+	assert.Equal(t, "内", row.Name)
+	assert.Equal(t, []Tag{
+		{Key: "ip", Value: "日"},
+		{Key: "主", Value: "abc"},
+	}, row.Tags)
+
+	// tag is dropped if no whole rune fits in the limit
+	rb.Reset()
+	rb.SetLimits(&Limits{MaxTagValueLength: 2})
+	rb.AddMetricName([]byte("memory"))
+	assert.NoError(t, rb.AddTag([]byte("zone"), []byte("日本")))
+	assert.NoError(t, rb.AddSimpleField([]byte("used"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(data))
+	itr = NewRowIterator(data)
+	assert.True(t, itr.Next())
+	assert.Empty(t, itr.Row().Tags)
+}
+
+func TestRowBuilder_BuildModeStrict(t *testing.T) {
+	rb := CreateRowBuilder()
+	rb.SetBuildMode(BuildModeStrict)
+	build := func(tags [][2]string, fields ...string) error {
+		rb.Reset()
+		rb.AddMetricName([]byte("cpu"))
+		for _, tag := range tags {
+			assert.NoError(t, rb.AddTag([]byte(tag[0]), []byte(tag[1])))
+		}
+		for _, field := range fields {
+			assert.NoError(t, rb.AddSimpleField([]byte(field), flatMetricsV1.SimpleFieldTypeLast, 1))
+		}
+		_, err := rb.Build()
+		return err
+	}
+	assert.NoError(t, build([][2]string{{"host", "a"}, {"ip", "1.1.1.1"}}, "idle", "user"))
+
+	err := build([][2]string{{"host", "a"}, {"ip", "1.1.1.1"}, {"host", "b"}}, "idle")
+	assert.ErrorIs(t, err, ErrDuplicateTag)
+	assert.Equal(t, "duplicate tag key: host, values: a, b, metric: cpu", err.Error())
+
+	err = build([][2]string{{"host", "a"}}, "idle", "user", "idle")
+	assert.ErrorIs(t, err, ErrDuplicateField)
+	assert.Contains(t, err.Error(), "duplicate field: idle")
+
+	// invalid values are rejected
+	rb.Reset()
+	assert.Error(t, rb.AddTag([]byte(""), []byte("a")))
+	assert.Error(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, math.NaN()))
+	assert.Error(t, rb.AddSortedTags([][]byte{[]byte("b"), []byte("a")}, [][]byte{[]byte("1"), []byte("2")}))
+}
+
+func TestRowBuilder_BuildModeStrict_TagOrder(t *testing.T) {
+	rb := CreateRowBuilder(WithTagOrder(), WithBuildMode(BuildModeStrict))
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTag([]byte("ip"), []byte("1.1.1.1")))
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("a")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	itr := NewRowIterator(data)
+	assert.True(t, itr.Next())
+	key, _ := itr.OriginalTag(0)
+	assert.Equal(t, "ip", string(key))
+}
@@ -0,0 +1,218 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// SemanticType represents the semantic metric type declared by incoming protocols.
+type SemanticType int8
+
+const (
+	// SemanticTypeUntyped is the metric without type(e.g. prometheus unknown, graphite, influx).
+	SemanticTypeUntyped SemanticType = iota
+	// SemanticTypeCounter is the cumulative monotonic counter(e.g. prometheus counter, otlp cumulative sum).
+	SemanticTypeCounter
+	// SemanticTypeDeltaCounter is the delta counter(e.g. otlp delta sum, statsd counter).
+	SemanticTypeDeltaCounter
+	// SemanticTypeGauge is the gauge.
+	SemanticTypeGauge
+	// SemanticTypeHistogram is the sum/count components of histogram.
+	SemanticTypeHistogram
+	// SemanticTypeSummary is the sum/count components of summary.
+	SemanticTypeSummary
+)
+
+var semanticTypeNames = [...]string{"untyped", "counter", "delta_counter", "gauge", "histogram", "summary"}
+
+// String returns the name of semantic type.
+func (t SemanticType) String() string {
+	if t >= 0 && int(t) < len(semanticTypeNames) {
+		return semanticTypeNames[t]
+	}
+	return fmt.Sprintf("SemanticType(%d)", t)
+}
+
+// ParseSemanticType parses the semantic type by name(case-insensitive).
+func ParseSemanticType(name string) (SemanticType, error) {
+	for i, typeName := range semanticTypeNames {
+		if strings.EqualFold(name, typeName) {
+			return SemanticType(i), nil
+		}
+	}
+	return SemanticTypeUntyped, fmt.Errorf("unknown semantic type: %q", name)
+}
+
+// Sources of incoming protocols.
+const (
+	CoercionSourceAny        = "" // rule for all sources
+	CoercionSourcePrometheus = "prometheus"
+	CoercionSourceOTLP       = "otlp"
+	CoercionSourceInflux     = "influx"
+	CoercionSourceGraphite   = "graphite"
+)
+
+// defaultCoercions are the default field types of semantic types, which are consistent with protocol converters.
+var defaultCoercions = map[SemanticType]flatMetricsV1.SimpleFieldType{
+	SemanticTypeUntyped:      flatMetricsV1.SimpleFieldTypeLast,
+	SemanticTypeCounter:      flatMetricsV1.SimpleFieldTypeLast, // cumulative value is kept as is
+	SemanticTypeDeltaCounter: flatMetricsV1.SimpleFieldTypeDeltaSum,
+	SemanticTypeGauge:        flatMetricsV1.SimpleFieldTypeLast,
+	SemanticTypeHistogram:    flatMetricsV1.SimpleFieldTypeLast,
+	SemanticTypeSummary:      flatMetricsV1.SimpleFieldTypeLast,
+}
+
+// compatibleCoercions are the field types which keep the semantic of semantic types,
+// untyped metric is compatible with all field types.
+var compatibleCoercions = map[SemanticType][]flatMetricsV1.SimpleFieldType{
+	SemanticTypeCounter:      {flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeDeltaSum},
+	SemanticTypeDeltaCounter: {flatMetricsV1.SimpleFieldTypeDeltaSum},
+	SemanticTypeGauge: {
+		flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeFirst,
+		flatMetricsV1.SimpleFieldTypeMin, flatMetricsV1.SimpleFieldTypeMax,
+	},
+	SemanticTypeHistogram: {flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeDeltaSum},
+	SemanticTypeSummary:   {flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeDeltaSum},
+}
+
+// CoercionRule represents the config of remapping semantic type of source to simple field type.
+type CoercionRule struct {
+	Source    string `toml:"source"`    // empty means all sources
+	Type      string `toml:"type"`      // semantic type, e.g. counter
+	FieldType string `toml:"fieldType"` // simple field type, e.g. DeltaSum
+}
+
+// CoercionWarning represents an incompatible remapping, e.g. gauge => DeltaSum.
+type CoercionWarning struct {
+	Source    string
+	Type      SemanticType
+	FieldType flatMetricsV1.SimpleFieldType
+}
+
+// String returns the warning message.
+func (w CoercionWarning) String() string {
+	source := w.Source
+	if source == CoercionSourceAny {
+		source = "*"
+	}
+	return fmt.Sprintf("incompatible field type coercion: %s %s => %s", source, w.Type, w.FieldType)
+}
+
+type coercionKey struct {
+	source string
+	typ    SemanticType
+}
+
+// CoercionTable maps the semantic types of multiple protocols to simple field types consistently,
+// the rule of (source, type) takes precedence over the rule of (any source, type), then the default.
+type CoercionTable struct {
+	rules     map[coercionKey]flatMetricsV1.SimpleFieldType
+	onWarning func(warning CoercionWarning)
+
+	mutex sync.RWMutex
+}
+
+// NewCoercionTable creates a coercion table with default rules, onWarning is invoked
+// when an incompatible remapping is configured, nil means ignore.
+func NewCoercionTable(onWarning func(warning CoercionWarning)) *CoercionTable {
+	if onWarning == nil {
+		onWarning = func(_ CoercionWarning) {}
+	}
+	return &CoercionTable{
+		rules:     make(map[coercionKey]flatMetricsV1.SimpleFieldType),
+		onWarning: onWarning,
+	}
+}
+
+// NewCoercionTableWithRules creates a coercion table with the configured rules.
+func NewCoercionTableWithRules(rules []CoercionRule, onWarning func(warning CoercionWarning)) (*CoercionTable, error) {
+	table := NewCoercionTable(onWarning)
+	for _, rule := range rules {
+		typ, err := ParseSemanticType(rule.Type)
+		if err != nil {
+			return nil, err
+		}
+		fieldType, ok := flatMetricsV1.EnumValuesSimpleFieldType[rule.FieldType]
+		if !ok {
+			return nil, fmt.Errorf("unknown simple field type: %q", rule.FieldType)
+		}
+		if err = table.SetRule(strings.ToLower(rule.Source), typ, fieldType); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// SetRule remaps the semantic type of source(CoercionSourceAny means all sources) to the field type,
+// the incompatible remapping is applied and surfaced via warning callback.
+func (t *CoercionTable) SetRule(source string, typ SemanticType, fieldType flatMetricsV1.SimpleFieldType) error {
+	if int(typ) < 0 || int(typ) >= len(semanticTypeNames) {
+		return fmt.Errorf("unknown semantic type: %s", typ)
+	}
+	if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+		return fmt.Errorf("field type of %s is unspecified", typ)
+	}
+	if _, ok := flatMetricsV1.EnumNamesSimpleFieldType[fieldType]; !ok {
+		return fmt.Errorf("unknown simple field type: %s", fieldType)
+	}
+	if !IsCompatibleCoercion(typ, fieldType) {
+		t.onWarning(CoercionWarning{Source: source, Type: typ, FieldType: fieldType})
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.rules[coercionKey{source: source, typ: typ}] = fieldType
+	return nil
+}
+
+// Coerce returns the simple field type of the semantic type from source.
+func (t *CoercionTable) Coerce(source string, typ SemanticType) flatMetricsV1.SimpleFieldType {
+	t.mutex.RLock()
+	fieldType, ok := t.rules[coercionKey{source: source, typ: typ}]
+	if !ok {
+		fieldType, ok = t.rules[coercionKey{source: CoercionSourceAny, typ: typ}]
+	}
+	t.mutex.RUnlock()
+	if ok {
+		return fieldType
+	}
+	if fieldType, ok = defaultCoercions[typ]; ok {
+		return fieldType
+	}
+	return flatMetricsV1.SimpleFieldTypeLast
+}
+
+// IsCompatibleCoercion returns if the field type keeps the semantic of the semantic type.
+func IsCompatibleCoercion(typ SemanticType, fieldType flatMetricsV1.SimpleFieldType) bool {
+	compatible, ok := compatibleCoercions[typ]
+	if !ok {
+		// untyped
+		return true
+	}
+	for _, ft := range compatible {
+		if ft == fieldType {
+			return true
+		}
+	}
+	return false
+}
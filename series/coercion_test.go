@@ -0,0 +1,107 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestSemanticType(t *testing.T) {
+	for i := SemanticTypeUntyped; i <= SemanticTypeSummary; i++ {
+		typ, err := ParseSemanticType(i.String())
+		assert.NoError(t, err)
+		assert.Equal(t, i, typ)
+	}
+	typ, err := ParseSemanticType("Delta_Counter")
+	assert.NoError(t, err)
+	assert.Equal(t, SemanticTypeDeltaCounter, typ)
+	_, err = ParseSemanticType("rate")
+	assert.Error(t, err)
+	assert.Equal(t, "SemanticType(100)", SemanticType(100).String())
+}
+
+func TestCoercionTable_Default(t *testing.T) {
+	table := NewCoercionTable(nil)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, table.Coerce(CoercionSourcePrometheus, SemanticTypeCounter))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, table.Coerce(CoercionSourceOTLP, SemanticTypeDeltaCounter))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, table.Coerce(CoercionSourceOTLP, SemanticTypeGauge))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, table.Coerce(CoercionSourceGraphite, SemanticTypeUntyped))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, table.Coerce(CoercionSourceInflux, SemanticType(100)))
+}
+
+func TestCoercionTable_SetRule(t *testing.T) {
+	var warnings []string
+	table := NewCoercionTable(func(warning CoercionWarning) {
+		warnings = append(warnings, warning.String())
+	})
+	assert.NoError(t, table.SetRule(CoercionSourceAny, SemanticTypeCounter, flatMetricsV1.SimpleFieldTypeDeltaSum))
+	assert.NoError(t, table.SetRule(CoercionSourcePrometheus, SemanticTypeCounter, flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, table.SetRule(CoercionSourceInflux, SemanticTypeUntyped, flatMetricsV1.SimpleFieldTypeMax))
+	assert.Empty(t, warnings)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, table.Coerce(CoercionSourcePrometheus, SemanticTypeCounter))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, table.Coerce(CoercionSourceOTLP, SemanticTypeCounter))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMax, table.Coerce(CoercionSourceInflux, SemanticTypeUntyped))
+
+	// incompatible remapping is applied with warning
+	assert.NoError(t, table.SetRule(CoercionSourceAny, SemanticTypeGauge, flatMetricsV1.SimpleFieldTypeDeltaSum))
+	assert.NoError(t, table.SetRule(CoercionSourceOTLP, SemanticTypeDeltaCounter, flatMetricsV1.SimpleFieldTypeLast))
+	assert.Equal(t, []string{
+		"incompatible field type coercion: * gauge => DeltaSum",
+		"incompatible field type coercion: otlp delta_counter => Last",
+	}, warnings)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, table.Coerce(CoercionSourceGraphite, SemanticTypeGauge))
+
+	assert.Error(t, table.SetRule(CoercionSourceAny, SemanticType(100), flatMetricsV1.SimpleFieldTypeLast))
+	assert.Error(t, table.SetRule(CoercionSourceAny, SemanticTypeGauge, flatMetricsV1.SimpleFieldTypeUnSpecified))
+	assert.Error(t, table.SetRule(CoercionSourceAny, SemanticTypeGauge, flatMetricsV1.SimpleFieldType(100)))
+}
+
+func TestNewCoercionTableWithRules(t *testing.T) {
+	var warnings []CoercionWarning
+	table, err := NewCoercionTableWithRules([]CoercionRule{
+		{Source: "Prometheus", Type: "counter", FieldType: "DeltaSum"},
+		{Type: "histogram", FieldType: "Min"},
+	}, func(warning CoercionWarning) {
+		warnings = append(warnings, warning)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, table.Coerce(CoercionSourcePrometheus, SemanticTypeCounter))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMin, table.Coerce(CoercionSourceOTLP, SemanticTypeHistogram))
+	assert.Equal(t, []CoercionWarning{
+		{Source: CoercionSourceAny, Type: SemanticTypeHistogram, FieldType: flatMetricsV1.SimpleFieldTypeMin},
+	}, warnings)
+
+	_, err = NewCoercionTableWithRules([]CoercionRule{{Type: "rate", FieldType: "Last"}}, nil)
+	assert.Error(t, err)
+	_, err = NewCoercionTableWithRules([]CoercionRule{{Type: "gauge", FieldType: "Avg"}}, nil)
+	assert.Error(t, err)
+	_, err = NewCoercionTableWithRules([]CoercionRule{{Type: "gauge", FieldType: "UnSpecified"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestIsCompatibleCoercion(t *testing.T) {
+	assert.True(t, IsCompatibleCoercion(SemanticTypeUntyped, flatMetricsV1.SimpleFieldTypeFirst))
+	assert.True(t, IsCompatibleCoercion(SemanticTypeGauge, flatMetricsV1.SimpleFieldTypeMax))
+	assert.False(t, IsCompatibleCoercion(SemanticTypeGauge, flatMetricsV1.SimpleFieldTypeDeltaSum))
+	assert.True(t, IsCompatibleCoercion(SemanticTypeSummary, flatMetricsV1.SimpleFieldTypeDeltaSum))
+	assert.False(t, IsCompatibleCoercion(SemanticTypeCounter, flatMetricsV1.SimpleFieldTypeMin))
+}
@@ -0,0 +1,203 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressCodec represents the compression codec of batch payload, which is written as one-byte header.
+type CompressCodec byte
+
+const (
+	// CompressNone means payload is not compressed.
+	CompressNone CompressCodec = iota
+	// CompressSnappy compresses payload using snappy framing format.
+	CompressSnappy
+	// CompressZstd compresses payload using zstd.
+	CompressZstd
+)
+
+// maxStreamRowSize is the max size of a row read from stream, prevents allocating huge buffer for corrupted data.
+const maxStreamRowSize = 64 * 1024 * 1024
+
+var (
+	// encoder/decoder without reader/writer are safe for concurrent EncodeAll/DecodeAll.
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// String returns the name of codec.
+func (c CompressCodec) String() string {
+	switch c {
+	case CompressNone:
+		return "none"
+	case CompressSnappy:
+		return "snappy"
+	case CompressZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+}
+
+// Compress compresses the batch payload with codec, appends codec header and compressed data into dst.
+//
+//	+-------+-----------------+
+//	| codec | compressed data |
+//	+-------+-----------------+
+//	|   1   |       ...       |
+func Compress(dst, payload []byte, codec CompressCodec) ([]byte, error) {
+	dst = append(dst, byte(codec))
+	switch codec {
+	case CompressNone:
+		return append(dst, payload...), nil
+	case CompressSnappy:
+		buf := bytes.NewBuffer(dst)
+		w := snappy.NewBufferedWriter(buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressZstd:
+		return zstdEncoder.EncodeAll(payload, dst), nil
+	default:
+		return nil, fmt.Errorf("unknown compress codec: %s", codec)
+	}
+}
+
+// Decompress decompresses the data compressed by Compress, appends the batch payload into dst.
+func Decompress(dst, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("compressed data is empty")
+	}
+	codec, compressed := CompressCodec(data[0]), data[1:]
+	switch codec {
+	case CompressNone:
+		return append(dst, compressed...), nil
+	case CompressSnappy:
+		buf := bytes.NewBuffer(dst)
+		if _, err := buf.ReadFrom(snappy.NewReader(bytes.NewReader(compressed))); err != nil {
+			return nil, fmt.Errorf("decompress snappy payload failure: %w", err)
+		}
+		return buf.Bytes(), nil
+	case CompressZstd:
+		rs, err := zstdDecoder.DecodeAll(compressed, dst)
+		if err != nil {
+			return nil, fmt.Errorf("decompress zstd payload failure: %w", err)
+		}
+		return rs, nil
+	default:
+		return nil, fmt.Errorf("unknown compress codec: %s", codec)
+	}
+}
+
+// NewDecompressReader returns a reader which decompresses the data compressed by Compress from r,
+// the reader should be closed after used.
+func NewDecompressReader(r io.Reader) (io.ReadCloser, error) {
+	var header [1]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read compress codec failure: %w", err)
+	}
+	switch codec := CompressCodec(header[0]); codec {
+	case CompressNone:
+		return io.NopCloser(r), nil
+	case CompressSnappy:
+		return io.NopCloser(snappy.NewReader(r)), nil
+	case CompressZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unknown compress codec: %s", codec)
+	}
+}
+
+// StreamRowIterator decompresses the compressed batch from reader row by row,
+// only one row is kept in memory, all accessors of RowIterator are valid until next Next.
+type StreamRowIterator struct {
+	*RowIterator
+	reader io.ReadCloser
+	buf    []byte
+	err    error
+}
+
+// NewStreamRowIterator creates a row iterator for the compressed batch(by Compress) from reader.
+func NewStreamRowIterator(r io.Reader) (*StreamRowIterator, error) {
+	reader, err := NewDecompressReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamRowIterator{
+		RowIterator: NewRowIterator(nil),
+		reader:      reader,
+	}, nil
+}
+
+// Next reads and moves to next row, returns false if no more row or the stream is corrupted(check Err).
+func (itr *StreamRowIterator) Next() bool {
+	if itr.err != nil {
+		return false
+	}
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(itr.reader, sizeBuf[:]); err != nil {
+		if err != io.EOF {
+			itr.err = fmt.Errorf("read row size failure: %w", err)
+		}
+		return false
+	}
+	size := binary.LittleEndian.Uint32(sizeBuf[:])
+	if size > maxStreamRowSize {
+		itr.err = fmt.Errorf("corrupted batch stream, row size: %d is too large", size)
+		return false
+	}
+	if cap(itr.buf) < int(size)+4 {
+		itr.buf = make([]byte, int(size)+4)
+	}
+	itr.buf = itr.buf[:int(size)+4]
+	copy(itr.buf, sizeBuf[:])
+	if _, err := io.ReadFull(itr.reader, itr.buf[4:]); err != nil {
+		itr.err = fmt.Errorf("read row failure: %w", err)
+		return false
+	}
+	itr.RowIterator.batch = BatchIterator{payload: itr.buf}
+	if !itr.RowIterator.Next() {
+		itr.err = itr.RowIterator.Err()
+		return false
+	}
+	return true
+}
+
+// Err returns the error when iterating the stream.
+func (itr *StreamRowIterator) Err() error { return itr.err }
+
+// Close closes the decompress reader.
+func (itr *StreamRowIterator) Close() error {
+	return itr.reader.Close()
+}
@@ -0,0 +1,116 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func newCompressBatch(t *testing.T, rows int) []byte {
+	bb := NewBatchBuilder()
+	for i := 0; i < rows; i++ {
+		rb := bb.RowBuilder()
+		rb.AddMetricName([]byte("cpu"))
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte(fmt.Sprintf("host-%d", i))))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, float64(i)))
+		assert.NoError(t, bb.Commit())
+	}
+	return bb.Payload()
+}
+
+func TestCompress(t *testing.T) {
+	payload := newCompressBatch(t, 100)
+	for _, codec := range []CompressCodec{CompressNone, CompressSnappy, CompressZstd} {
+		data, err := Compress(nil, payload, codec)
+		assert.NoError(t, err)
+		assert.Equal(t, byte(codec), data[0])
+		if codec != CompressNone {
+			assert.Less(t, len(data), len(payload), codec.String())
+		}
+		rs, err := Decompress(nil, data)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, rs)
+
+		// streaming
+		itr, err := NewStreamRowIterator(bytes.NewReader(data))
+		assert.NoError(t, err)
+		count := 0
+		for itr.Next() {
+			key, value := itr.Tag(0)
+			assert.Equal(t, "host", string(key))
+			assert.Equal(t, fmt.Sprintf("host-%d", count), string(value))
+			count++
+		}
+		assert.NoError(t, itr.Err())
+		assert.False(t, itr.Next())
+		assert.NoError(t, itr.Close())
+		assert.Equal(t, 100, count)
+	}
+	assert.Equal(t, "unknown(10)", CompressCodec(10).String())
+}
+
+func TestCompress_Error(t *testing.T) {
+	_, err := Compress(nil, []byte{1}, CompressCodec(10))
+	assert.Error(t, err)
+
+	_, err = Decompress(nil, nil)
+	assert.Error(t, err)
+	_, err = Decompress(nil, []byte{10, 1})
+	assert.Error(t, err)
+	_, err = Decompress(nil, []byte{byte(CompressSnappy), 1, 2, 3})
+	assert.Error(t, err)
+	_, err = Decompress(nil, []byte{byte(CompressZstd), 1, 2, 3})
+	assert.Error(t, err)
+
+	_, err = NewDecompressReader(bytes.NewReader(nil))
+	assert.Error(t, err)
+	_, err = NewStreamRowIterator(bytes.NewReader([]byte{10}))
+	assert.Error(t, err)
+}
+
+func TestStreamRowIterator_Corrupted(t *testing.T) {
+	payload := newCompressBatch(t, 2)
+	data, err := Compress(nil, payload, CompressNone)
+	assert.NoError(t, err)
+	cases := [][]byte{
+		// size truncated
+		append(append([]byte{}, data...), 1, 2),
+		// row truncated
+		data[:len(data)-1],
+		// row too large
+		binary.LittleEndian.AppendUint32([]byte{byte(CompressNone)}, maxStreamRowSize+1),
+		// invalid row
+		{byte(CompressNone), 4, 0, 0, 0, 0xff, 0xff, 0xff, 0xff},
+	}
+	for _, c := range cases {
+		itr, err := NewStreamRowIterator(bytes.NewReader(c))
+		assert.NoError(t, err)
+		for itr.Next() {
+			assert.NotEmpty(t, itr.Name())
+		}
+		assert.Error(t, itr.Err())
+		assert.False(t, itr.Next())
+	}
+}
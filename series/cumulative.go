@@ -0,0 +1,230 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"sync"
+)
+
+// HistogramDelta represents the delta of a cumulative histogram between two observations.
+type HistogramDelta struct {
+	Values []float64
+	Sum    float64
+	Count  float64
+}
+
+// cumulativeState records the last observation of a cumulative histogram series.
+type cumulativeState struct {
+	timestamp int64
+	bounds    []float64
+	values    []float64
+	sum       float64
+	count     float64
+}
+
+// CumulativeConverter converts cumulative histograms(e.g. prometheus, otlp cumulative temporality)
+// to delta histograms which are expected by compound field, the last observation is kept for each series.
+//   - first observation(or explicit bounds changed) of a series is dropped, because no base to compute delta.
+//   - counter reset(count or any bucket decreases) is detected, the current observation is used as delta.
+//   - out of order observation(timestamp <= last timestamp) is dropped.
+type CumulativeConverter struct {
+	series map[uint64]*cumulativeState // series hash => last observation
+
+	lock sync.Mutex
+}
+
+// NewCumulativeConverter creates a cumulative to delta histogram converter.
+func NewCumulativeConverter() *CumulativeConverter {
+	return &CumulativeConverter{
+		series: make(map[uint64]*cumulativeState),
+	}
+}
+
+// Convert computes the delta of the cumulative histogram observation for the series hash
+// (e.g. xxhash of namespace/metric name and sorted tags), returns false if the observation is dropped.
+func (c *CumulativeConverter) Convert(seriesHash uint64, timestamp int64,
+	bounds, values []float64, sum, count float64,
+) (delta HistogramDelta, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	state := c.series[seriesHash]
+	delta, ok, update := observeCumulative(state, timestamp, bounds, values, sum, count)
+	if update {
+		if state == nil {
+			c.series[seriesHash] = newCumulativeState(timestamp, bounds, values, sum, count)
+		} else {
+			state.update(timestamp, bounds, values, sum, count)
+		}
+	}
+	return delta, ok
+}
+
+// Begin starts a transaction which stages the observations of a request, the last observations
+// are updated only after Commit, so a rejected request doesn't move the base of its series.
+func (c *CumulativeConverter) Begin() *CumulativeTxn {
+	return &CumulativeTxn{
+		converter: c,
+		staged:    make(map[uint64]*cumulativeState),
+	}
+}
+
+// Expire removes the series which are not observed since the timestamp, returns the number of removed series.
+func (c *CumulativeConverter) Expire(before int64) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	removed := 0
+	for hash, state := range c.series {
+		if state.timestamp < before {
+			delete(c.series, hash)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of tracked series.
+func (c *CumulativeConverter) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.series)
+}
+
+// CumulativeTxn stages the observations of a request on top of the last observations of converter,
+// it isn't thread-safe, discard it without Commit if the request is rejected.
+type CumulativeTxn struct {
+	converter *CumulativeConverter
+	staged    map[uint64]*cumulativeState // series hash => staged observation
+}
+
+// Convert computes the delta of the observation against the staged or last observation of series,
+// returns false if the observation is dropped, see CumulativeConverter.Convert.
+func (t *CumulativeTxn) Convert(seriesHash uint64, timestamp int64,
+	bounds, values []float64, sum, count float64,
+) (delta HistogramDelta, ok bool) {
+	state, staged := t.staged[seriesHash]
+	if !staged {
+		t.converter.lock.Lock()
+		defer t.converter.lock.Unlock()
+		state = t.converter.series[seriesHash]
+	}
+	delta, ok, update := observeCumulative(state, timestamp, bounds, values, sum, count)
+	if update {
+		if staged {
+			state.update(timestamp, bounds, values, sum, count)
+		} else {
+			t.staged[seriesHash] = newCumulativeState(timestamp, bounds, values, sum, count)
+		}
+	}
+	return delta, ok
+}
+
+// Commit updates the last observations of converter with the staged observations,
+// the observation is ignored if a newer one is committed by another request.
+func (t *CumulativeTxn) Commit() {
+	if len(t.staged) == 0 {
+		return
+	}
+	t.converter.lock.Lock()
+	defer t.converter.lock.Unlock()
+
+	for seriesHash, state := range t.staged {
+		if last, ok := t.converter.series[seriesHash]; ok && last.timestamp >= state.timestamp {
+			continue
+		}
+		t.converter.series[seriesHash] = state
+	}
+	t.staged = make(map[uint64]*cumulativeState)
+}
+
+// observeCumulative computes the delta of the observation against the last observation(nil if not observed),
+// returns if the last observation should be updated by current observation.
+func observeCumulative(state *cumulativeState, timestamp int64,
+	bounds, values []float64, sum, count float64,
+) (delta HistogramDelta, ok, update bool) {
+	if state == nil {
+		return delta, false, true
+	}
+	if timestamp <= state.timestamp {
+		return delta, false, false
+	}
+	if !equalFloats(state.bounds, bounds) {
+		// buckets changed, restart from current observation
+		return delta, false, true
+	}
+	delta.Values = make([]float64, len(values))
+	if isCounterReset(state, values, count) {
+		copy(delta.Values, values)
+		delta.Sum = sum
+		delta.Count = count
+	} else {
+		for idx, value := range values {
+			delta.Values[idx] = value - state.values[idx]
+		}
+		delta.Sum = sum - state.sum
+		delta.Count = count - state.count
+		if delta.Sum < 0 {
+			delta.Sum = 0
+		}
+	}
+	return delta, true, true
+}
+
+// newCumulativeState creates the state with the copy of observation.
+func newCumulativeState(timestamp int64, bounds, values []float64, sum, count float64) *cumulativeState {
+	state := &cumulativeState{}
+	state.update(timestamp, bounds, values, sum, count)
+	return state
+}
+
+// update copies the observation into state.
+func (s *cumulativeState) update(timestamp int64, bounds, values []float64, sum, count float64) {
+	s.timestamp = timestamp
+	s.bounds = append(s.bounds[:0], bounds...)
+	s.values = append(s.values[:0], values...)
+	s.sum = sum
+	s.count = count
+}
+
+// isCounterReset returns if the cumulative counter is reset, count or any bucket decreases.
+func isCounterReset(state *cumulativeState, values []float64, count float64) bool {
+	if count < state.count || len(values) != len(state.values) {
+		return true
+	}
+	for idx, value := range values {
+		if value < state.values[idx] {
+			return true
+		}
+	}
+	return false
+}
+
+// equalFloats returns if two float slices are same.
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
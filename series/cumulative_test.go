@@ -0,0 +1,130 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCumulativeConverter(t *testing.T) {
+	c := NewCumulativeConverter()
+	bounds := []float64{1, 10, math.Inf(1)}
+
+	// first observation dropped
+	_, ok := c.Convert(1, 1000, bounds, []float64{1, 2, 3}, 10, 6)
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	delta, ok := c.Convert(1, 2000, bounds, []float64{2, 4, 3}, 15, 9)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 2, 0}, Sum: 5, Count: 3}, delta)
+
+	// out of order
+	_, ok = c.Convert(1, 2000, bounds, []float64{3, 4, 3}, 16, 10)
+	assert.False(t, ok)
+
+	// counter reset
+	delta, ok = c.Convert(1, 3000, bounds, []float64{1, 0, 0}, 1, 1)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 0, 0}, Sum: 1, Count: 1}, delta)
+	// bucket decreases but count increases
+	delta, ok = c.Convert(1, 4000, bounds, []float64{0, 5, 0}, 20, 5)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{0, 5, 0}, Sum: 20, Count: 5}, delta)
+	// negative sum delta
+	delta, ok = c.Convert(1, 5000, bounds, []float64{0, 6, 0}, 19, 6)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{0, 1, 0}, Sum: 0, Count: 1}, delta)
+
+	// bounds changed, restart
+	newBounds := []float64{5, math.Inf(1)}
+	_, ok = c.Convert(1, 6000, newBounds, []float64{1, 1}, 2, 2)
+	assert.False(t, ok)
+	delta, ok = c.Convert(1, 7000, newBounds, []float64{2, 1}, 3, 3)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 0}, Sum: 1, Count: 1}, delta)
+
+	// input is copied
+	values := []float64{1, 1}
+	_, _ = c.Convert(2, 1000, newBounds, values, 1, 2)
+	values[0] = 100
+	delta, ok = c.Convert(2, 2000, newBounds, []float64{2, 1}, 2, 3)
+	assert.True(t, ok)
+	assert.Equal(t, []float64{1, 0}, delta.Values)
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 1, c.Expire(5000))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 1, c.Expire(math.MaxInt64))
+	assert.Zero(t, c.Len())
+}
+
+func TestCumulativeTxn(t *testing.T) {
+	c := NewCumulativeConverter()
+	bounds := []float64{1, math.Inf(1)}
+	_, ok := c.Convert(1, 1000, bounds, []float64{1, 1}, 2, 2)
+	assert.False(t, ok)
+
+	// rejected request doesn't move the base
+	txn := c.Begin()
+	delta, ok := txn.Convert(1, 2000, bounds, []float64{2, 1}, 3, 3)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 0}, Sum: 1, Count: 1}, delta)
+	_, ok = txn.Convert(2, 2000, bounds, []float64{1, 1}, 1, 2)
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	txn = c.Begin()
+	delta, ok = txn.Convert(1, 3000, bounds, []float64{3, 1}, 4, 4)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{2, 0}, Sum: 2, Count: 2}, delta)
+	// staged observation is the base of same series in the request
+	delta, ok = txn.Convert(1, 4000, bounds, []float64{4, 2}, 6, 6)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 1}, Sum: 2, Count: 2}, delta)
+	_, ok = txn.Convert(1, 4000, bounds, []float64{5, 2}, 7, 7)
+	assert.False(t, ok)
+	_, ok = txn.Convert(2, 3000, bounds, []float64{1, 1}, 1, 2)
+	assert.False(t, ok)
+	// newer observation committed by another request
+	_, _ = c.Convert(3, 1000, bounds, []float64{1, 1}, 1, 2)
+	_, ok = txn.Convert(3, 2000, bounds, []float64{2, 1}, 2, 3)
+	assert.True(t, ok)
+	_, ok = c.Convert(3, 5000, bounds, []float64{3, 1}, 3, 4)
+	assert.True(t, ok)
+	txn.Commit()
+	txn.Commit()
+	assert.Equal(t, 3, c.Len())
+
+	delta, ok = c.Convert(1, 5000, bounds, []float64{5, 2}, 7, 7)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 0}, Sum: 1, Count: 1}, delta)
+	delta, ok = c.Convert(3, 6000, bounds, []float64{4, 1}, 4, 5)
+	assert.True(t, ok)
+	assert.Equal(t, HistogramDelta{Values: []float64{1, 0}, Sum: 1, Count: 1}, delta)
+}
+
+func TestCumulativeConverter_isCounterReset(t *testing.T) {
+	state := newCumulativeState(1, []float64{1}, []float64{1}, 1, 1)
+	assert.True(t, isCounterReset(state, []float64{1, 2}, 1))
+	assert.True(t, isCounterReset(state, []float64{1}, 0))
+	assert.False(t, isCounterReset(state, []float64{2}, 2))
+}
@@ -0,0 +1,92 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// WithFieldMerge merges the simple fields with same name and type into one field when building,
+// instead of producing duplicate entries, the values are merged according to field type:
+//   - DeltaSum: sum
+//   - Min/Max: min/max
+//   - Last: last wins
+//   - First: first wins
+//
+// Absent field is overridden by the field with value. The fields with same name but different types are kept.
+func WithFieldMerge() RowBuilderOption {
+	return func(rb *RowBuilder) {
+		rb.fieldMerge = true
+	}
+}
+
+// mergeSimpleFields merges duplicate simple fields in place, keeps the order of first occurrence.
+func (rb *RowBuilder) mergeSimpleFields() {
+	if rb.simpleFieldCount < 2 {
+		return
+	}
+	merged := 0
+	for i := 0; i < rb.simpleFieldCount; i++ {
+		field := &rb.simpleFields[i]
+		target := -1
+		for j := 0; j < merged; j++ {
+			if rb.simpleFields[j].fType == field.fType && bytes.Equal(rb.simpleFields[j].name, field.name) {
+				target = j
+				break
+			}
+		}
+		if target < 0 {
+			// swap instead of copy, so that the name buffers aren't shared
+			rb.simpleFields[merged], rb.simpleFields[i] = rb.simpleFields[i], rb.simpleFields[merged]
+			merged++
+			continue
+		}
+		mergeSimpleField(&rb.simpleFields[target], field)
+	}
+	rb.simpleFieldCount = merged
+}
+
+// mergeSimpleField merges the value of later field into the former field.
+func mergeSimpleField(former, later *rowSimpleField) {
+	switch {
+	case later.absent:
+		return
+	case former.absent:
+		former.value = later.value
+		former.absent = false
+		return
+	}
+	switch former.fType {
+	case flatMetricsV1.SimpleFieldTypeDeltaSum:
+		former.value += later.value
+	case flatMetricsV1.SimpleFieldTypeMin:
+		if later.value < former.value {
+			former.value = later.value
+		}
+	case flatMetricsV1.SimpleFieldTypeMax:
+		if later.value > former.value {
+			former.value = later.value
+		}
+	case flatMetricsV1.SimpleFieldTypeLast:
+		former.value = later.value
+	default:
+		// first wins
+	}
+}
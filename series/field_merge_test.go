@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestWithFieldMerge(t *testing.T) {
+	bb := NewBatchBuilder(WithFieldMerge())
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("min"), flatMetricsV1.SimpleFieldTypeMin, 5))
+	assert.NoError(t, rb.AddSimpleField([]byte("max"), flatMetricsV1.SimpleFieldTypeMax, 5))
+	assert.NoError(t, rb.AddSimpleField([]byte("last"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("first"), flatMetricsV1.SimpleFieldTypeFirst, 1))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("gauge"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+	assert.NoError(t, rb.AddSimpleField([]byte("min"), flatMetricsV1.SimpleFieldTypeMin, 3))
+	assert.NoError(t, rb.AddSimpleField([]byte("min"), flatMetricsV1.SimpleFieldTypeMin, 4))
+	assert.NoError(t, rb.AddSimpleField([]byte("max"), flatMetricsV1.SimpleFieldTypeMax, 7))
+	assert.NoError(t, rb.AddSimpleField([]byte("max"), flatMetricsV1.SimpleFieldTypeMax, 6))
+	assert.NoError(t, rb.AddSimpleField([]byte("last"), flatMetricsV1.SimpleFieldTypeLast, 2))
+	assert.NoError(t, rb.AddSimpleField([]byte("first"), flatMetricsV1.SimpleFieldTypeFirst, 2))
+	assert.NoError(t, rb.AddSimpleField([]byte("gauge"), flatMetricsV1.SimpleFieldTypeLast, 3))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("gauge"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 3))
+	// same name, different type
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeLast, 10))
+	assert.NoError(t, bb.Commit())
+
+	// option is kept after reset
+	rb.AddMetricName([]byte("mem"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("gauge"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("gauge"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, bb.Commit())
+
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "cpu{} 100 count(DeltaSum)=6 min(Min)=3 max(Max)=7 last(Last)=2 first(First)=1 gauge(Last)=3 count(Last)=10",
+		itr.Row().String())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "mem{} 100 gauge(Last)=absent", itr.Row().String())
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+}
+
+func TestWithFieldMerge_Disabled(t *testing.T) {
+	rb, release := NewRowBuilder()
+	defer release(rb)
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	m := flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	assert.Equal(t, 2, m.SimpleFieldsLength())
+
+	rb, release = NewRowBuilder(WithFieldMerge())
+	defer release(rb)
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	m = flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	assert.Equal(t, 1, m.SimpleFieldsLength())
+	field := &flatMetricsV1.SimpleField{}
+	assert.True(t, m.SimpleFields(field, 0))
+	assert.Equal(t, float64(3), field.Value())
+}
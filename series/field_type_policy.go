@@ -0,0 +1,142 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// FieldTypePolicy represents the config of default simple field type and histogram buckets
+// of the metrics matching namespace/metric pattern, which is used when the source gives no type hints.
+type FieldTypePolicy struct {
+	Namespace string        `toml:"namespace"` // glob pattern(path.Match) of namespace, empty means all
+	Metric    string        `toml:"metric"`    // glob pattern(path.Match) of metric name, empty means all
+	Field     string        `toml:"field"`     // glob pattern(path.Match) of simple field name, empty means all
+	FieldType string        `toml:"fieldType"` // default simple field type, e.g. DeltaSum, empty means not set
+	Buckets   *BucketConfig `toml:"buckets"`   // default histogram buckets, nil means not set
+}
+
+// fieldTypePolicy is the validated policy.
+type fieldTypePolicy struct {
+	namespace string
+	metric    string
+	field     string
+	fieldType flatMetricsV1.SimpleFieldType
+	bounds    []float64
+}
+
+// match checks if the namespace and metric name match the patterns.
+func (p *fieldTypePolicy) match(namespace, metricName string) bool {
+	return matchPolicyPattern(p.namespace, namespace) && matchPolicyPattern(p.metric, metricName)
+}
+
+// matchPolicyPattern checks if the name matches the validated pattern, empty pattern matches all.
+func matchPolicyPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// FieldTypePolicies is the policy table consulted by protocol adapters when the source gives no type hints,
+// the policies are matched in order, each attribute(field type/buckets) comes from the first matched policy which sets it.
+// Nil policies means no policy, the fallback of adapter is used.
+type FieldTypePolicies struct {
+	policies []fieldTypePolicy
+}
+
+// NewFieldTypePolicies creates the policy table, returns error if any pattern, field type or buckets is invalid.
+func NewFieldTypePolicies(policies []FieldTypePolicy) (*FieldTypePolicies, error) {
+	table := &FieldTypePolicies{policies: make([]fieldTypePolicy, 0, len(policies))}
+	for _, policy := range policies {
+		for _, pattern := range []string{policy.Namespace, policy.Metric, policy.Field} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid field type policy pattern: %q, %w", pattern, err)
+			}
+		}
+		compiled := fieldTypePolicy{namespace: policy.Namespace, metric: policy.Metric, field: policy.Field}
+		if policy.FieldType != "" {
+			fieldType, ok := flatMetricsV1.EnumValuesSimpleFieldType[policy.FieldType]
+			if !ok || fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+				return nil, fmt.Errorf("invalid field type of policy[%s/%s]: %q", policy.Namespace, policy.Metric, policy.FieldType)
+			}
+			compiled.fieldType = fieldType
+		}
+		if policy.Buckets != nil {
+			bounds, err := policy.Buckets.GenerateBounds()
+			if err != nil {
+				return nil, fmt.Errorf("invalid buckets of policy[%s/%s]: %w", policy.Namespace, policy.Metric, err)
+			}
+			compiled.bounds = bounds
+		}
+		table.policies = append(table.policies, compiled)
+	}
+	return table, nil
+}
+
+// FieldType returns the default simple field type of the field of metric, fallback if no policy matched.
+func (t *FieldTypePolicies) FieldType(namespace, metricName, fieldName string,
+	fallback flatMetricsV1.SimpleFieldType,
+) flatMetricsV1.SimpleFieldType {
+	if t == nil {
+		return fallback
+	}
+	for idx := range t.policies {
+		policy := &t.policies[idx]
+		if policy.fieldType != flatMetricsV1.SimpleFieldTypeUnSpecified && policy.match(namespace, metricName) &&
+			matchPolicyPattern(policy.field, fieldName) {
+			return policy.fieldType
+		}
+	}
+	return fallback
+}
+
+// HistogramBounds returns the default histogram bounds(ending with +Inf) of the metric, nil if no policy matched.
+// The bounds are shared, should not be modified.
+func (t *FieldTypePolicies) HistogramBounds(namespace, metricName string) []float64 {
+	if t == nil {
+		return nil
+	}
+	for idx := range t.policies {
+		policy := &t.policies[idx]
+		if policy.bounds != nil && policy.match(namespace, metricName) {
+			return policy.bounds
+		}
+	}
+	return nil
+}
+
+// WithFieldTypePolicies sets the policy table of default field types, see RowBuilder.DefaultFieldType.
+func WithFieldTypePolicies(policies *FieldTypePolicies) RowBuilderOption {
+	return func(rb *RowBuilder) {
+		rb.fieldTypePolicies = policies
+	}
+}
+
+// DefaultFieldType returns the default simple field type of the field of current namespace/metric name
+// by the policy table, fallback if no policy matched. Namespace and metric name should be added before.
+func (rb *RowBuilder) DefaultFieldType(fieldName []byte, fallback flatMetricsV1.SimpleFieldType) flatMetricsV1.SimpleFieldType {
+	if rb.fieldTypePolicies == nil {
+		return fallback
+	}
+	return rb.fieldTypePolicies.FieldType(string(rb.nameSpace), string(rb.metricName), string(fieldName), fallback)
+}
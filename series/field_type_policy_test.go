@@ -0,0 +1,115 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestNewFieldTypePolicies(t *testing.T) {
+	cases := []struct {
+		name     string
+		policies []FieldTypePolicy
+		wantErr  bool
+	}{
+		{name: "empty"},
+		{name: "valid", policies: []FieldTypePolicy{
+			{Namespace: "app-*", Metric: "*_total", FieldType: "DeltaSum"},
+			{Metric: "latency", Buckets: &BucketConfig{Type: BucketTypeExplicit, Bounds: []float64{1, 10}}},
+		}},
+		{name: "bad namespace pattern", policies: []FieldTypePolicy{{Namespace: "[", FieldType: "Last"}}, wantErr: true},
+		{name: "bad metric pattern", policies: []FieldTypePolicy{{Metric: "a[", FieldType: "Last"}}, wantErr: true},
+		{name: "bad field pattern", policies: []FieldTypePolicy{{Field: "a[", FieldType: "Last"}}, wantErr: true},
+		{name: "unknown field type", policies: []FieldTypePolicy{{FieldType: "Sum"}}, wantErr: true},
+		{name: "unspecified field type", policies: []FieldTypePolicy{{FieldType: "UnSpecified"}}, wantErr: true},
+		{name: "bad buckets", policies: []FieldTypePolicy{{Buckets: &BucketConfig{}}}, wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := NewFieldTypePolicies(tt.policies)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, policies)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, policies)
+			}
+		})
+	}
+}
+
+func TestFieldTypePolicies_Match(t *testing.T) {
+	policies, err := NewFieldTypePolicies([]FieldTypePolicy{
+		{Namespace: "app-*", Metric: "*_total", FieldType: "DeltaSum"},
+		{Namespace: "app-*", Metric: "http_*", Buckets: &BucketConfig{Type: BucketTypeExplicit, Bounds: []float64{5, 10}}},
+		{Metric: "*_max", FieldType: "Max"},
+		{FieldType: "First", Buckets: &BucketConfig{Type: BucketTypeLinear, Start: 0, Width: 1, Count: 1}},
+	})
+	assert.NoError(t, err)
+
+	fallback := flatMetricsV1.SimpleFieldTypeLast
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, policies.FieldType("app-1", "req_total", "value", fallback))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMax, policies.FieldType("app-1", "req_max", "value", fallback))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMax, policies.FieldType("db", "conn_max", "value", fallback))
+	// field type comes from the first policy which sets it
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeFirst, policies.FieldType("app-1", "http_latency", "value", fallback))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeFirst, policies.FieldType("db", "req_total", "value", fallback))
+	// field pattern
+	fieldPolicies, err := NewFieldTypePolicies([]FieldTypePolicy{{Metric: "cpu", Field: "*_total", FieldType: "DeltaSum"}})
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldPolicies.FieldType("db", "cpu", "req_total", fallback))
+	assert.Equal(t, fallback, fieldPolicies.FieldType("db", "cpu", "idle", fallback))
+	assert.Equal(t, fallback, fieldPolicies.FieldType("db", "mem", "req_total", fallback))
+
+	assert.Equal(t, []float64{5, 10, math.Inf(1)}, policies.HistogramBounds("app-1", "http_latency"))
+	assert.Equal(t, []float64{0, math.Inf(1)}, policies.HistogramBounds("db", "http_latency"))
+
+	// no policy
+	var nilPolicies *FieldTypePolicies
+	assert.Equal(t, fallback, nilPolicies.FieldType("app-1", "req_total", "value", fallback))
+	assert.Nil(t, nilPolicies.HistogramBounds("app-1", "http_latency"))
+	policies, err = NewFieldTypePolicies(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, fallback, policies.FieldType("app-1", "req_total", "value", fallback))
+	assert.Nil(t, policies.HistogramBounds("app-1", "http_latency"))
+}
+
+func TestRowBuilder_DefaultFieldType(t *testing.T) {
+	rb := CreateRowBuilder()
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, rb.DefaultFieldType([]byte("value"), flatMetricsV1.SimpleFieldTypeLast))
+
+	policies, err := NewFieldTypePolicies([]FieldTypePolicy{{Namespace: "ns", Metric: "cpu", FieldType: "DeltaSum"}})
+	assert.NoError(t, err)
+	rb, release := NewRowBuilder(WithFieldTypePolicies(policies))
+	defer release(rb)
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, rb.DefaultFieldType([]byte("value"), flatMetricsV1.SimpleFieldTypeLast))
+	rb.Reset()
+	// policies are kept after reset
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("mem"))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, rb.DefaultFieldType([]byte("value"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NotNil(t, rb.fieldTypePolicies)
+}
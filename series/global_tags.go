@@ -0,0 +1,59 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"sort"
+)
+
+// WithGlobalTags enriches each row with the global tags(e.g. pod/node of the producer) when building,
+// the tag of row takes precedence over the global tag with same key. Empty key/value is ignored.
+func WithGlobalTags(tags map[string]string) RowBuilderOption {
+	globalTags := make([]rowKV, 0, len(tags))
+	for key, value := range tags {
+		if key == "" || value == "" {
+			continue
+		}
+		globalTags = append(globalTags, rowKV{key: []byte(key), value: []byte(value)})
+	}
+	sort.Slice(globalTags, func(i, j int) bool {
+		return bytes.Compare(globalTags[i].key, globalTags[j].key) < 0
+	})
+	return func(rb *RowBuilder) {
+		rb.globalTags = globalTags
+	}
+}
+
+// applyGlobalTags appends the global tags whose key doesn't exist in row tags.
+func (rb *RowBuilder) applyGlobalTags() {
+	rowTags := rb.rowKVs.kvCount
+	for _, tag := range rb.globalTags {
+		exist := false
+		for i := 0; i < rowTags; i++ {
+			if bytes.Equal(rb.rowKVs.kvs[i].key, tag.key) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			rb.tagsSorted = false
+			rb.appendTag(tag.key, tag.value)
+		}
+	}
+}
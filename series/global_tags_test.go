@@ -0,0 +1,72 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestWithGlobalTags(t *testing.T) {
+	bb := NewBatchBuilder(WithGlobalTags(map[string]string{"pod": "broker-0", "node": "node1", "": "v", "k": ""}))
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("h1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	// row tag takes precedence
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddSortedTags([][]byte{[]byte("a"), []byte("pod")}, [][]byte{[]byte("1"), []byte("broker-1")}))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	// no row tags
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+
+	var rows []string
+	var hashes []uint64
+	itr := NewRowIterator(bb.Payload())
+	for itr.Next() {
+		rows = append(rows, itr.Row().String())
+		hashes = append(hashes, itr.TagsHash())
+	}
+	assert.Equal(t, []string{
+		"cpu{host=h1,node=node1,pod=broker-0} 100 f(Last)=1",
+		"cpu{a=1,node=node1,pod=broker-1} 100 f(Last)=1",
+		"cpu{node=node1,pod=broker-0} 100 f(Last)=1",
+	}, rows)
+
+	// same hash as the row with tags added explicitly
+	rb = CreateRowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTags(map[string]string{"pod": "broker-0", "node": "node1"}))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	itr = NewRowIterator(data)
+	assert.True(t, itr.Next())
+	assert.Equal(t, hashes[2], itr.TagsHash())
+}
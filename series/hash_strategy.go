@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultHashStrategy is the default hash strategy of row builder.
+var DefaultHashStrategy HashStrategy = XXHashStrategy{}
+
+// SortedTags represents the tags sorted by key without duplicated key.
+type SortedTags interface {
+	// Len returns the number of tags.
+	Len() int
+	// Tag returns the tag key/value at index.
+	Tag(idx int) (key, value []byte)
+}
+
+// HashStrategy computes the hashes of series identity which are written into flat metric,
+// the hashes are used for sharding and series lookup, so all producers of a cluster should use the same strategy.
+type HashStrategy interface {
+	// HashName returns the hash of namespace and metric name.
+	HashName(namespace, metricName []byte) uint64
+	// HashTags returns the hash of sorted tags.
+	HashTags(tags SortedTags) uint64
+}
+
+// XXHashStrategy hashes the concatenation of namespace and metric name,
+// and the concatenation of tags as "k1=v1,k2=v2" by xxhash64.
+type XXHashStrategy struct{}
+
+// HashName returns the xxhash of namespace + metric name.
+func (XXHashStrategy) HashName(namespace, metricName []byte) uint64 {
+	var d xxhash.Digest
+	d.Reset()
+	_, _ = d.Write(namespace)
+	_, _ = d.Write(metricName)
+	return d.Sum64()
+}
+
+// HashTags returns the xxhash of "k1=v1,k2=v2".
+func (XXHashStrategy) HashTags(tags SortedTags) uint64 {
+	var d xxhash.Digest
+	d.Reset()
+	for idx := 0; idx < tags.Len(); idx++ {
+		if idx >= 1 {
+			_, _ = d.Write(tagSeparator)
+		}
+		key, value := tags.Tag(idx)
+		_, _ = d.Write(key)
+		_, _ = d.Write(tagKVSeparator)
+		_, _ = d.Write(value)
+	}
+	return d.Sum64()
+}
+
+// SeparateKVHashStrategy hashes each tag key and value separately by xxhash64 then combines them in order,
+// so that the hash is not ambiguous when tag key/value contains '=' or ','(e.g. "a=b,c" vs "a,c=b").
+type SeparateKVHashStrategy struct{}
+
+// HashName returns the combined hash of namespace and metric name.
+func (SeparateKVHashStrategy) HashName(namespace, metricName []byte) uint64 {
+	return combineHash(combineHash(hashSeed, xxhash.Sum64(namespace)), xxhash.Sum64(metricName))
+}
+
+// HashTags returns the combined hash of each tag key and value.
+func (SeparateKVHashStrategy) HashTags(tags SortedTags) uint64 {
+	h := hashSeed
+	for idx := 0; idx < tags.Len(); idx++ {
+		key, value := tags.Tag(idx)
+		h = combineHash(h, xxhash.Sum64(key))
+		h = combineHash(h, xxhash.Sum64(value))
+	}
+	return h
+}
+
+var (
+	tagSeparator   = []byte{','}
+	tagKVSeparator = []byte{'='}
+)
+
+const (
+	hashSeed  uint64 = 14695981039346656037 // FNV offset basis
+	hashPrime uint64 = 1099511628211        // FNV prime
+)
+
+// combineHash mixes the value into the hash, the result depends on the order of values.
+func combineHash(h, v uint64) uint64 {
+	h ^= v
+	h *= hashPrime
+	return h ^ (h >> 32)
+}
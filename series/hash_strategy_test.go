@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func buildHashes(t *testing.T, rb *RowBuilder, tags ...string) (nameHash, tagsHash uint64) {
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	for idx := 0; idx < len(tags); idx += 2 {
+		assert.NoError(t, rb.AddTag([]byte(tags[idx]), []byte(tags[idx+1])))
+	}
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	m := flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	rb.Reset()
+	return m.NameHash(), m.KvsHash()
+}
+
+func TestXXHashStrategy_Compatible(t *testing.T) {
+	rb := CreateRowBuilder()
+	for _, tags := range [][]string{nil, {"host", "h1"}, {"ip", "1.1.1.1", "host", "h1", "host", "h2"}} {
+		rb.SetHashStrategy(nil)
+		nameHash, tagsHash := buildHashes(t, rb, tags...)
+		rb.SetHashStrategy(XXHashStrategy{})
+		nameHash2, tagsHash2 := buildHashes(t, rb, tags...)
+		assert.Equal(t, nameHash, nameHash2)
+		assert.Equal(t, tagsHash, tagsHash2)
+	}
+	assert.Equal(t, emptyStringHash, XXHashStrategy{}.HashTags(&rowKVs{}))
+}
+
+func TestSeparateKVHashStrategy(t *testing.T) {
+	rb := CreateRowBuilder()
+	// collision of concatenation
+	_, h1 := buildHashes(t, rb, "a", "b,c=d")
+	_, h2 := buildHashes(t, rb, "a", "b", "c", "d")
+	assert.Equal(t, h1, h2)
+
+	rb.SetHashStrategy(SeparateKVHashStrategy{})
+	nameHash, h1 := buildHashes(t, rb, "a", "b,c=d")
+	_, h2 = buildHashes(t, rb, "a", "b", "c", "d")
+	assert.NotEqual(t, h1, h2)
+	// same tags in different order
+	_, h3 := buildHashes(t, rb, "c", "d", "a", "b")
+	assert.Equal(t, h2, h3)
+	assert.Equal(t, SeparateKVHashStrategy{}.HashName([]byte("ns"), []byte("cpu")), nameHash)
+	assert.NotEqual(t, SeparateKVHashStrategy{}.HashName([]byte("n"), []byte("scpu")), nameHash)
+	// key/value swapped
+	assert.NotEqual(t,
+		SeparateKVHashStrategy{}.HashTags(&rowKVs{kvs: []rowKV{{key: []byte("a"), value: []byte("b")}}, kvCount: 1}),
+		SeparateKVHashStrategy{}.HashTags(&rowKVs{kvs: []rowKV{{key: []byte("b"), value: []byte("a")}}, kvCount: 1}),
+	)
+}
+
+func TestBatchBuilder_SetHashStrategy(t *testing.T) {
+	bb := NewBatchBuilder()
+	bb.SetHashStrategy(SeparateKVHashStrategy{})
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, SeparateKVHashStrategy{}.HashName(nil, []byte("cpu")), itr.NameHash())
+}
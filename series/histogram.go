@@ -0,0 +1,256 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Histogram is a thread-safe histogram whose buckets align with the compound field of flat metric,
+// which is used for internal latency metrics.
+type Histogram struct {
+	bounds []float64 // upper bounds, ending with +Inf
+	values []float64 // count of each bucket
+
+	min, max, sum, count float64
+
+	mutex sync.Mutex
+}
+
+// NewHistogram creates a histogram with the bucket config.
+func NewHistogram(config BucketConfig) (*Histogram, error) {
+	bounds, err := config.GenerateBounds()
+	if err != nil {
+		return nil, err
+	}
+	return &Histogram{
+		bounds: bounds,
+		values: make([]float64, len(bounds)),
+	}, nil
+}
+
+// Observe records a value, negative/NaN value is ignored, because compound field only accepts values >= 0.
+func (h *Histogram) Observe(value float64) {
+	if !(value >= 0) {
+		return
+	}
+	// the first bucket whose upper bound >= value
+	idx := sort.SearchFloat64s(h.bounds, value)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.values[idx]++
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	h.sum += value
+	h.count++
+}
+
+// Snapshot returns the snapshot of histogram.
+func (h *Histogram) Snapshot() *HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.snapshot()
+}
+
+// SnapshotAndReset returns the snapshot then resets the histogram, which is used for reporting delta.
+func (h *Histogram) SnapshotAndReset() *HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	s := h.snapshot()
+	for i := range h.values {
+		h.values[i] = 0
+	}
+	h.min, h.max, h.sum, h.count = 0, 0, 0, 0
+	return s
+}
+
+// snapshot copies the histogram, must be invoked with lock.
+func (h *Histogram) snapshot() *HistogramSnapshot {
+	return &HistogramSnapshot{
+		Bounds: h.bounds, // immutable
+		Values: append([]float64(nil), h.values...),
+		Min:    h.min,
+		Max:    h.max,
+		Sum:    h.sum,
+		Count:  h.count,
+	}
+}
+
+// HistogramSnapshot represents the snapshot of histogram.
+type HistogramSnapshot struct {
+	Bounds []float64 // upper bounds, ending with +Inf
+	Values []float64 // count of each bucket
+	Min    float64
+	Max    float64
+	Sum    float64
+	Count  float64
+}
+
+// Rebucket redistributes the counts into the buckets of config, assuming the values are uniformly distributed
+// within each bucket(the +Inf bucket is [last bound, Max]), so that the snapshot aligns with the bounds expected
+// by storage. The counts of new buckets may be fractional.
+func (s *HistogramSnapshot) Rebucket(config BucketConfig) (*HistogramSnapshot, error) {
+	bounds, err := config.GenerateBounds()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(bounds))
+	lower := 0.0
+	for i, count := range s.Values {
+		upper := s.Bounds[i]
+		if math.IsInf(upper, 1) {
+			upper = math.Max(s.Max, lower)
+		}
+		if count > 0 {
+			redistribute(bounds, values, lower, upper, count)
+		}
+		lower = s.Bounds[i]
+	}
+	return &HistogramSnapshot{
+		Bounds: bounds,
+		Values: values,
+		Min:    s.Min,
+		Max:    s.Max,
+		Sum:    s.Sum,
+		Count:  s.Count,
+	}, nil
+}
+
+// redistribute adds the count in range [lower, upper] into buckets by overlapping proportion.
+func redistribute(bounds, values []float64, lower, upper, count float64) {
+	if upper <= lower {
+		// point mass
+		values[sort.SearchFloat64s(bounds, lower)] += count
+		return
+	}
+	width := upper - lower
+	bucketLower := 0.0
+	for j, bucketUpper := range bounds {
+		overlap := math.Min(upper, bucketUpper) - math.Max(lower, bucketLower)
+		if overlap > 0 {
+			values[j] += count * overlap / width
+		}
+		if bucketUpper >= upper {
+			return
+		}
+		bucketLower = bucketUpper
+	}
+}
+
+// AddTo adds the snapshot into row builder as compound field.
+func (s *HistogramSnapshot) AddTo(rb *RowBuilder) error {
+	if err := rb.AddCompoundFieldMMSC(s.Min, s.Max, s.Sum, s.Count); err != nil {
+		return err
+	}
+	return rb.AddCompoundFieldData(s.Values, s.Bounds)
+}
+
+// HistogramRegistry registers the histograms by name, each histogram uses its configured buckets,
+// the buckets of matched policy(see SetPolicies) or the default buckets.
+type HistogramRegistry struct {
+	defaultConfig BucketConfig
+	configs       map[string]BucketConfig
+	histograms    map[string]*Histogram
+	namespace     string
+	policies      *FieldTypePolicies
+
+	mutex sync.Mutex
+}
+
+// NewHistogramRegistry creates a histogram registry with default bucket config.
+func NewHistogramRegistry(defaultConfig BucketConfig) *HistogramRegistry {
+	return &HistogramRegistry{
+		defaultConfig: defaultConfig,
+		configs:       make(map[string]BucketConfig),
+		histograms:    make(map[string]*Histogram),
+	}
+}
+
+// Configure sets the bucket config of histogram, which takes effect when the histogram is created.
+func (r *HistogramRegistry) Configure(name string, config BucketConfig) error {
+	if _, err := config.GenerateBounds(); err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.configs[name] = config
+	return nil
+}
+
+// SetPolicies sets the policy table consulted for the histograms of namespace without configured buckets,
+// which takes effect when the histogram is created.
+func (r *HistogramRegistry) SetPolicies(namespace string, policies *FieldTypePolicies) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.namespace = namespace
+	r.policies = policies
+}
+
+// Histogram returns the histogram by name, creates it if not exist.
+func (r *HistogramRegistry) Histogram(name string) (*Histogram, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if h, ok := r.histograms[name]; ok {
+		return h, nil
+	}
+	config, ok := r.configs[name]
+	if !ok {
+		config = r.defaultConfig
+		if bounds := r.policies.HistogramBounds(r.namespace, name); bounds != nil {
+			config = ExplicitBuckets(bounds...)
+		}
+	}
+	h, err := NewHistogram(config)
+	if err != nil {
+		return nil, err
+	}
+	r.histograms[name] = h
+	return h, nil
+}
+
+// Range invokes fn for each histogram in the order of name.
+func (r *HistogramRegistry) Range(fn func(name string, h *Histogram)) {
+	r.mutex.Lock()
+	names := make([]string, 0, len(r.histograms))
+	for name := range r.histograms {
+		names = append(names, name)
+	}
+	histograms := make(map[string]*Histogram, len(r.histograms))
+	for name, h := range r.histograms {
+		histograms[name] = h
+	}
+	r.mutex.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		fn(name, histograms[name])
+	}
+}
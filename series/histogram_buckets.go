@@ -0,0 +1,129 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"math"
+)
+
+// BucketType represents the strategy generating the bounds of histogram buckets.
+type BucketType string
+
+const (
+	// BucketTypeLinear generates count bounds: start, start+width, start+2*width...
+	BucketTypeLinear BucketType = "linear"
+	// BucketTypeExponential generates count bounds: start, start*factor, start*factor^2...
+	BucketTypeExponential BucketType = "exponential"
+	// BucketTypeExplicit uses the explicit bounds.
+	BucketTypeExplicit BucketType = "explicit"
+)
+
+// maxBuckets is the max number of buckets of histogram.
+const maxBuckets = 1024
+
+// BucketConfig represents the bucket configuration of histogram, the +Inf bucket is always appended.
+type BucketConfig struct {
+	Type   BucketType `toml:"type"`
+	Start  float64    `toml:"start"`
+	Width  float64    `toml:"width"`
+	Factor float64    `toml:"factor"`
+	Count  int        `toml:"count"`
+	Bounds []float64  `toml:"bounds"`
+}
+
+// LinearBuckets returns the config of linear buckets.
+func LinearBuckets(start, width float64, count int) BucketConfig {
+	return BucketConfig{Type: BucketTypeLinear, Start: start, Width: width, Count: count}
+}
+
+// ExponentialBuckets returns the config of exponential buckets.
+func ExponentialBuckets(start, factor float64, count int) BucketConfig {
+	return BucketConfig{Type: BucketTypeExponential, Start: start, Factor: factor, Count: count}
+}
+
+// ExplicitBuckets returns the config of explicit buckets.
+func ExplicitBuckets(bounds ...float64) BucketConfig {
+	return BucketConfig{Type: BucketTypeExplicit, Bounds: bounds}
+}
+
+// DefaultLatencyBuckets is the default buckets of latency(ms), from 1ms to ~32s.
+var DefaultLatencyBuckets = ExponentialBuckets(1, 2, 16)
+
+// GenerateBounds returns the increasing upper bounds of buckets ending with +Inf,
+// which are valid explicit bounds of compound field.
+func (c BucketConfig) GenerateBounds() ([]float64, error) {
+	var bounds []float64
+	switch c.Type {
+	case BucketTypeLinear:
+		if err := c.checkCount(); err != nil {
+			return nil, err
+		}
+		if c.Width <= 0 || math.IsInf(c.Width, 0) || math.IsNaN(c.Width) {
+			return nil, fmt.Errorf("linear buckets width: %f should > 0", c.Width)
+		}
+		bounds = make([]float64, c.Count)
+		for i := range bounds {
+			bounds[i] = c.Start + float64(i)*c.Width
+		}
+	case BucketTypeExponential:
+		if err := c.checkCount(); err != nil {
+			return nil, err
+		}
+		if c.Start <= 0 {
+			return nil, fmt.Errorf("exponential buckets start: %f should > 0", c.Start)
+		}
+		if c.Factor <= 1 || math.IsInf(c.Factor, 0) || math.IsNaN(c.Factor) {
+			return nil, fmt.Errorf("exponential buckets factor: %f should > 1", c.Factor)
+		}
+		bounds = make([]float64, c.Count)
+		for i := range bounds {
+			bounds[i] = c.Start * math.Pow(c.Factor, float64(i))
+		}
+	case BucketTypeExplicit:
+		bounds = append(bounds, c.Bounds...)
+		if len(bounds) > 0 && math.IsInf(bounds[len(bounds)-1], 1) {
+			bounds = bounds[:len(bounds)-1]
+		}
+		if len(bounds) == 0 || len(bounds) > maxBuckets {
+			return nil, fmt.Errorf("explicit buckets count: %d should in [1, %d]", len(bounds), maxBuckets)
+		}
+	default:
+		return nil, fmt.Errorf("unknown bucket type: %q", c.Type)
+	}
+	for i, bound := range bounds {
+		if math.IsInf(bound, 0) || math.IsNaN(bound) || bound < 0 {
+			return nil, fmt.Errorf("bucket bound: %f should be finite and >= 0", bound)
+		}
+		if i > 0 && bound <= bounds[i-1] {
+			return nil, fmt.Errorf("bucket bounds are not strictly increasing at: %d", i)
+		}
+	}
+	return append(bounds, math.Inf(1)), nil
+}
+
+// checkCount checks the bucket count of linear/exponential buckets.
+func (c BucketConfig) checkCount() error {
+	if c.Count < 1 || c.Count > maxBuckets {
+		return fmt.Errorf("buckets count: %d should in [1, %d]", c.Count, maxBuckets)
+	}
+	if c.Start < 0 || math.IsInf(c.Start, 0) || math.IsNaN(c.Start) {
+		return fmt.Errorf("buckets start: %f should be finite and >= 0", c.Start)
+	}
+	return nil
+}
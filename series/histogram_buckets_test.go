@@ -0,0 +1,79 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketConfig_GenerateBounds(t *testing.T) {
+	inf := math.Inf(1)
+	cases := []struct {
+		name   string
+		config BucketConfig
+		bounds []float64
+		err    bool
+	}{
+		{name: "linear", config: LinearBuckets(0, 10, 3), bounds: []float64{0, 10, 20, inf}},
+		{name: "exponential", config: ExponentialBuckets(1, 2, 4), bounds: []float64{1, 2, 4, 8, inf}},
+		{name: "explicit", config: ExplicitBuckets(1, 5, 10), bounds: []float64{1, 5, 10, inf}},
+		{name: "explicit with +Inf", config: ExplicitBuckets(1, inf), bounds: []float64{1, inf}},
+		{name: "default latency", config: DefaultLatencyBuckets, bounds: []float64{
+			1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, inf,
+		}},
+		{name: "unknown type", config: BucketConfig{Type: "log"}, err: true},
+		{name: "linear zero count", config: LinearBuckets(0, 10, 0), err: true},
+		{name: "linear too many", config: LinearBuckets(0, 10, maxBuckets+1), err: true},
+		{name: "linear negative start", config: LinearBuckets(-1, 10, 3), err: true},
+		{name: "linear zero width", config: LinearBuckets(0, 0, 3), err: true},
+		{name: "linear NaN width", config: LinearBuckets(0, math.NaN(), 3), err: true},
+		{name: "exponential zero start", config: ExponentialBuckets(0, 2, 3), err: true},
+		{name: "exponential zero count", config: ExponentialBuckets(1, 2, 0), err: true},
+		{name: "exponential bad factor", config: ExponentialBuckets(1, 1, 3), err: true},
+		{name: "exponential overflow", config: ExponentialBuckets(1, 1e300, 3), err: true},
+		{name: "explicit empty", config: ExplicitBuckets(), err: true},
+		{name: "explicit only +Inf", config: ExplicitBuckets(inf), err: true},
+		{name: "explicit not increasing", config: ExplicitBuckets(1, 1), err: true},
+		{name: "explicit negative", config: ExplicitBuckets(-1, 1), err: true},
+		{name: "explicit NaN", config: ExplicitBuckets(1, math.NaN()), err: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			bounds, err := tt.config.GenerateBounds()
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.bounds, bounds)
+			// valid compound bounds
+			rb := CreateRowBuilder()
+			assert.NoError(t, rb.AddCompoundFieldData(make([]float64, len(bounds)), bounds))
+		})
+	}
+
+	// explicit bounds aren't modified
+	explicit := []float64{1, 2}
+	_, err := ExplicitBuckets(explicit...).GenerateBounds()
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, explicit)
+}
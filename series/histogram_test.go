@@ -0,0 +1,161 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	_, err := NewHistogram(BucketConfig{})
+	assert.Error(t, err)
+
+	h, err := NewHistogram(LinearBuckets(10, 10, 3))
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, v := range []float64{5, 10, 15, 25, 100} {
+				h.Observe(v)
+			}
+		}()
+	}
+	wg.Wait()
+	h.Observe(-1)
+	h.Observe(math.NaN())
+
+	s := h.Snapshot()
+	assert.Equal(t, []float64{10, 20, 30, math.Inf(1)}, s.Bounds)
+	assert.Equal(t, []float64{8, 4, 4, 4}, s.Values)
+	assert.Equal(t, float64(5), s.Min)
+	assert.Equal(t, float64(100), s.Max)
+	assert.Equal(t, float64(620), s.Sum)
+	assert.Equal(t, float64(20), s.Count)
+
+	s = h.SnapshotAndReset()
+	assert.Equal(t, float64(20), s.Count)
+	s = h.Snapshot()
+	assert.Equal(t, []float64{0, 0, 0, 0}, s.Values)
+	assert.Zero(t, s.Count)
+	h.Observe(30)
+	s = h.Snapshot()
+	assert.Equal(t, float64(30), s.Min)
+	assert.Equal(t, []float64{0, 0, 1, 0}, s.Values)
+
+	// add into row
+	rb := CreateRowBuilder()
+	rb.AddMetricName([]byte("latency"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, s.AddTo(rb))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	itr := NewRowIterator(data)
+	assert.True(t, itr.Next())
+	assert.Equal(t, "latency{} 100 histogram{min=30,max=30,sum=30,count=1,buckets=[10:0,20:0,30:1,+Inf:0]}", itr.Row().String())
+
+	s.Min = -1
+	assert.Error(t, s.AddTo(rb))
+}
+
+func TestHistogramSnapshot_Rebucket(t *testing.T) {
+	inf := math.Inf(1)
+	s := &HistogramSnapshot{
+		Bounds: []float64{10, 20, inf},
+		Values: []float64{10, 10, 4},
+		Min:    1,
+		Max:    40,
+		Sum:    300,
+		Count:  24,
+	}
+	rs, err := s.Rebucket(ExplicitBuckets(5, 15, 30))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{5, 15, 30, inf}, rs.Bounds)
+	// [0,10]:10 => 5:5, 15:5; [10,20]:10 => 15:5, 30:5; [20,40]:4 => 30:2, +Inf:2
+	assert.Equal(t, []float64{5, 10, 7, 2}, rs.Values)
+	assert.Equal(t, s.Count, rs.Count)
+	assert.Equal(t, s.Sum, rs.Sum)
+	assert.Equal(t, s.Min, rs.Min)
+	assert.Equal(t, s.Max, rs.Max)
+
+	// +Inf bucket with max <= last bound is point mass
+	s.Max = 20
+	rs, err = s.Rebucket(ExplicitBuckets(5, 15, 30))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{5, 10, 9, 0}, rs.Values)
+
+	// coarser buckets keep total
+	rs, err = s.Rebucket(LinearBuckets(100, 100, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{24, 0}, rs.Values)
+
+	_, err = s.Rebucket(BucketConfig{})
+	assert.Error(t, err)
+}
+
+func TestHistogramRegistry(t *testing.T) {
+	r := NewHistogramRegistry(DefaultLatencyBuckets)
+	assert.Error(t, r.Configure("bad", BucketConfig{}))
+	assert.NoError(t, r.Configure("write", LinearBuckets(0, 10, 3)))
+
+	write, err := r.Histogram("write")
+	assert.NoError(t, err)
+	assert.Len(t, write.Snapshot().Bounds, 4)
+	query, err := r.Histogram("query")
+	assert.NoError(t, err)
+	assert.Len(t, query.Snapshot().Bounds, 17)
+	h, err := r.Histogram("write")
+	assert.NoError(t, err)
+	assert.Same(t, write, h)
+
+	var names []string
+	r.Range(func(name string, _ *Histogram) {
+		names = append(names, name)
+	})
+	assert.Equal(t, []string{"query", "write"}, names)
+
+	r = NewHistogramRegistry(BucketConfig{})
+	_, err = r.Histogram("query")
+	assert.Error(t, err)
+}
+
+func TestHistogramRegistry_SetPolicies(t *testing.T) {
+	policies, err := NewFieldTypePolicies([]FieldTypePolicy{
+		{Namespace: "ns", Metric: "rpc_*", Buckets: &BucketConfig{Type: BucketTypeExplicit, Bounds: []float64{1, 10}}},
+	})
+	assert.NoError(t, err)
+	r := NewHistogramRegistry(DefaultLatencyBuckets)
+	r.SetPolicies("ns", policies)
+	assert.NoError(t, r.Configure("rpc_write", LinearBuckets(0, 10, 3)))
+
+	// configured buckets take precedence
+	h, err := r.Histogram("rpc_write")
+	assert.NoError(t, err)
+	assert.Len(t, h.Snapshot().Bounds, 4)
+	h, err = r.Histogram("rpc_query")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 10, math.Inf(1)}, h.Snapshot().Bounds)
+	h, err = r.Histogram("query")
+	assert.NoError(t, err)
+	assert.Len(t, h.Snapshot().Bounds, 17)
+}
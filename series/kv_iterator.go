@@ -0,0 +1,125 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// KeyValueIterator iterates the tags of a decoded row in lexicographic order of key regardless of the encoded order,
+// so that storage layers can rely on sorted iteration. Rows built by RowBuilder are sorted already, the index is built
+// lazily only for unsorted rows and reused across rows, so iterating doesn't allocate.
+// The returned byte slices reference the underlying buffer.
+type KeyValueIterator struct {
+	metric *flatMetricsV1.Metric
+	kv     flatMetricsV1.KeyValue
+	other  flatMetricsV1.KeyValue // for comparing
+
+	length  int
+	pos     int
+	checked bool  // sorted/index is prepared
+	sorted  bool  // encoded in order, no need index
+	index   []int // positions of tags in sorted order
+}
+
+// KeyValues returns the sorted tag iterator of current row, the iterator is owned by RowIterator,
+// it's only valid until next Next.
+func (itr *RowIterator) KeyValues() *KeyValueIterator {
+	itr.kvIterator.reset(itr.metric)
+	return &itr.kvIterator
+}
+
+// reset resets the iterator for the row.
+func (it *KeyValueIterator) reset(metric *flatMetricsV1.Metric) {
+	it.metric = metric
+	it.length = metric.KeyValuesLength()
+	it.pos = -1
+	it.checked = false
+}
+
+// Len returns the number of tags.
+func (it *KeyValueIterator) Len() int { return it.length }
+
+// Rewind moves the iterator before the first tag.
+func (it *KeyValueIterator) Rewind() { it.pos = -1 }
+
+// Next moves to next tag, returns false if no more tag.
+func (it *KeyValueIterator) Next() bool {
+	if it.pos+1 >= it.length {
+		it.pos = it.length
+		return false
+	}
+	if !it.checked {
+		it.prepare()
+	}
+	it.pos++
+	idx := it.pos
+	if !it.sorted {
+		idx = it.index[it.pos]
+	}
+	it.metric.KeyValues(&it.kv, idx)
+	return true
+}
+
+// Key returns the key of current tag.
+func (it *KeyValueIterator) Key() []byte { return it.kv.Key() }
+
+// Value returns the value of current tag.
+func (it *KeyValueIterator) Value() []byte { return it.kv.Value() }
+
+// prepare checks if the tags are sorted, builds the sorted index if not.
+func (it *KeyValueIterator) prepare() {
+	it.checked = true
+	it.sorted = true
+	for i := 1; i < it.length; i++ {
+		if it.compare(i-1, i) > 0 {
+			it.sorted = false
+			break
+		}
+	}
+	if it.sorted {
+		return
+	}
+	it.index = it.index[:0]
+	for i := 0; i < it.length; i++ {
+		it.index = append(it.index, i)
+	}
+	// stable, so that the tags with same key keep the encoded order
+	sort.Stable((*kvIndexSorter)(it))
+}
+
+// compare compares the keys of tags at positions.
+func (it *KeyValueIterator) compare(i, j int) int {
+	it.metric.KeyValues(&it.kv, i)
+	it.metric.KeyValues(&it.other, j)
+	return bytes.Compare(it.kv.Key(), it.other.Key())
+}
+
+// kvIndexSorter sorts the index of KeyValueIterator by key.
+type kvIndexSorter KeyValueIterator
+
+func (s *kvIndexSorter) Len() int { return len(s.index) }
+
+func (s *kvIndexSorter) Swap(i, j int) { s.index[i], s.index[j] = s.index[j], s.index[i] }
+
+func (s *kvIndexSorter) Less(i, j int) bool {
+	return (*KeyValueIterator)(s).compare(s.index[i], s.index[j]) < 0
+}
@@ -0,0 +1,120 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// buildRawTagsRow builds a row with tags in the given order, bypassing the sorting of RowBuilder.
+func buildRawTagsRow(keys, values []string) []byte {
+	b := flatbuffers.NewBuilder(256)
+	kvs := make([]flatbuffers.UOffsetT, len(keys))
+	for i := range keys {
+		key, value := b.CreateString(keys[i]), b.CreateString(values[i])
+		flatMetricsV1.KeyValueStart(b)
+		flatMetricsV1.KeyValueAddKey(b, key)
+		flatMetricsV1.KeyValueAddValue(b, value)
+		kvs[i] = flatMetricsV1.KeyValueEnd(b)
+	}
+	flatMetricsV1.MetricStartKeyValuesVector(b, len(kvs))
+	for i := len(kvs) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(kvs[i])
+	}
+	kvsOffset := b.EndVector(len(kvs))
+	fieldName := b.CreateString("f")
+	flatMetricsV1.SimpleFieldStart(b)
+	flatMetricsV1.SimpleFieldAddName(b, fieldName)
+	flatMetricsV1.SimpleFieldAddType(b, flatMetricsV1.SimpleFieldTypeLast)
+	flatMetricsV1.SimpleFieldAddValue(b, 1)
+	field := flatMetricsV1.SimpleFieldEnd(b)
+	flatMetricsV1.MetricStartSimpleFieldsVector(b, 1)
+	b.PrependUOffsetT(field)
+	fields := b.EndVector(1)
+	name := b.CreateString("cpu")
+	flatMetricsV1.MetricStart(b)
+	flatMetricsV1.MetricAddName(b, name)
+	flatMetricsV1.MetricAddKeyValues(b, kvsOffset)
+	flatMetricsV1.MetricAddSimpleFields(b, fields)
+	b.FinishSizePrefixed(flatMetricsV1.MetricEnd(b))
+	return b.FinishedBytes()
+}
+
+func collectSortedTags(it *KeyValueIterator) (tags []string) {
+	for it.Next() {
+		tags = append(tags, string(it.Key())+"="+string(it.Value()))
+	}
+	return tags
+}
+
+func TestKeyValueIterator(t *testing.T) {
+	var payload []byte
+	payload = append(payload, buildRawTagsRow([]string{"c", "a", "b", "a"}, []string{"3", "1", "2", "4"})...)
+	payload = append(payload, buildRawTagsRow(nil, nil)...)
+	payload = append(payload, buildRawTagsRow([]string{"host", "az"}, []string{"h1", "a"})...)
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTags(map[string]string{"z": "1", "y": "2", "x": "3"}))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	payload = append(payload, bb.Payload()...)
+
+	itr := NewRowIterator(payload)
+	assert.True(t, itr.Next())
+	kvs := itr.KeyValues()
+	assert.Equal(t, 4, kvs.Len())
+	assert.Equal(t, []string{"a=1", "a=4", "b=2", "c=3"}, collectSortedTags(kvs))
+	assert.False(t, kvs.Next())
+	kvs.Rewind()
+	assert.Equal(t, []string{"a=1", "a=4", "b=2", "c=3"}, collectSortedTags(kvs))
+	// encoded order is kept
+	key, _ := itr.Tag(0)
+	assert.Equal(t, "c", string(key))
+
+	assert.True(t, itr.Next())
+	assert.Empty(t, collectSortedTags(itr.KeyValues()))
+	assert.True(t, itr.Next())
+	assert.Equal(t, []string{"az=a", "host=h1"}, collectSortedTags(itr.KeyValues()))
+	assert.True(t, itr.Next())
+	kvs = itr.KeyValues()
+	assert.Equal(t, []string{"x=3", "y=2", "z=1"}, collectSortedTags(kvs))
+	assert.True(t, kvs.sorted)
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+}
+
+func TestKeyValueIterator_NoAlloc(t *testing.T) {
+	payload := buildRawTagsRow([]string{"c", "a", "b", "e", "d"}, []string{"3", "1", "2", "5", "4"})
+	itr := NewRowIterator(payload)
+	assert.True(t, itr.Next())
+	kvs := itr.KeyValues()
+	assert.Len(t, collectSortedTags(kvs), 5)
+	allocs := testing.AllocsPerRun(100, func() {
+		kvs = itr.KeyValues()
+		for kvs.Next() {
+			_ = kvs.Key()
+		}
+	})
+	assert.Zero(t, allocs)
+}
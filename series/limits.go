@@ -0,0 +1,102 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTooManyTags represents the tags of row exceed the limit.
+	ErrTooManyTags = errors.New("too many tags")
+	// ErrTagKeyTooLong represents the tag key exceeds the limit.
+	ErrTagKeyTooLong = errors.New("tag key too long")
+	// ErrTagValueTooLong represents the tag value exceeds the limit.
+	ErrTagValueTooLong = errors.New("tag value too long")
+	// ErrTooManyFields represents the fields of row exceed the limit.
+	ErrTooManyFields = errors.New("too many fields")
+	// ErrMetricNameTooLong represents the metric name exceeds the limit.
+	ErrMetricNameTooLong = errors.New("metric name too long")
+	// ErrRowTooLarge represents the byte size of row exceeds the limit.
+	ErrRowTooLarge = errors.New("row too large")
+)
+
+// LimitError represents the error when row exceeds the limit, use errors.Is for checking the kind(e.g. ErrTooManyTags).
+type LimitError struct {
+	Err    error  // kind of limit error
+	Limit  int    // configured limit
+	Actual int    // actual count/length
+	Item   string // tag key/metric name which exceeds the limit, maybe empty
+}
+
+// Error returns the error message.
+func (e *LimitError) Error() string {
+	if e.Item == "" {
+		return fmt.Sprintf("%s: %d > limit: %d", e.Err, e.Actual, e.Limit)
+	}
+	return fmt.Sprintf("%s: %d > limit: %d, %s", e.Err, e.Actual, e.Limit, e.Item)
+}
+
+// Unwrap returns the kind of limit error.
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Limits represents the limits of building row, 0 means unlimited.
+type Limits struct {
+	MaxTags             int // max tags per row after dedup
+	MaxTagKeyLength     int
+	MaxTagValueLength   int
+	MaxFields           int // max simple fields per row, compound field is counted as one
+	MaxMetricNameLength int
+	MaxRowSize          int // max byte size of built row(exclude size prefix)
+}
+
+// checkTag checks the length of tag key/value.
+func (l *Limits) checkTag(key, value []byte) error {
+	if l.MaxTagKeyLength > 0 && len(key) > l.MaxTagKeyLength {
+		return &LimitError{Err: ErrTagKeyTooLong, Limit: l.MaxTagKeyLength, Actual: len(key), Item: string(key)}
+	}
+	if l.MaxTagValueLength > 0 && len(value) > l.MaxTagValueLength {
+		return &LimitError{Err: ErrTagValueTooLong, Limit: l.MaxTagValueLength, Actual: len(value), Item: string(key)}
+	}
+	return nil
+}
+
+// checkRow checks the metric name length, tags and fields count of the row.
+func (l *Limits) checkRow(metricName []byte, tags, fields int) error {
+	if l.MaxMetricNameLength > 0 && len(metricName) > l.MaxMetricNameLength {
+		return &LimitError{Err: ErrMetricNameTooLong, Limit: l.MaxMetricNameLength, Actual: len(metricName), Item: string(metricName)}
+	}
+	if l.MaxTags > 0 && tags > l.MaxTags {
+		return &LimitError{Err: ErrTooManyTags, Limit: l.MaxTags, Actual: tags, Item: string(metricName)}
+	}
+	if l.MaxFields > 0 && fields > l.MaxFields {
+		return &LimitError{Err: ErrTooManyFields, Limit: l.MaxFields, Actual: fields, Item: string(metricName)}
+	}
+	return nil
+}
+
+// checkRowSize checks the byte size of built row.
+func (l *Limits) checkRowSize(metricName []byte, size int) error {
+	if l.MaxRowSize > 0 && size > l.MaxRowSize {
+		return &LimitError{Err: ErrRowTooLarge, Limit: l.MaxRowSize, Actual: size, Item: string(metricName)}
+	}
+	return nil
+}
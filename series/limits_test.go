@@ -0,0 +1,103 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestLimitError(t *testing.T) {
+	err := error(&LimitError{Err: ErrTooManyTags, Limit: 1, Actual: 2})
+	assert.True(t, errors.Is(err, ErrTooManyTags))
+	assert.False(t, errors.Is(err, ErrTooManyFields))
+	assert.Equal(t, "too many tags: 2 > limit: 1", err.Error())
+	err = &LimitError{Err: ErrTagKeyTooLong, Limit: 1, Actual: 2, Item: "ab"}
+	assert.Equal(t, "tag key too long: 2 > limit: 1, ab", err.Error())
+	var limitErr *LimitError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, 2, limitErr.Actual)
+}
+
+func TestRowBuilder_Limits(t *testing.T) {
+	rb := CreateRowBuilder()
+	rb.SetLimits(&Limits{
+		MaxTags:             2,
+		MaxTagKeyLength:     4,
+		MaxTagValueLength:   4,
+		MaxFields:           2,
+		MaxMetricNameLength: 4,
+	})
+	assert.ErrorIs(t, rb.AddTag([]byte("hostname"), []byte("a")), ErrTagKeyTooLong)
+	assert.ErrorIs(t, rb.AddTag([]byte("host"), []byte("host-1")), ErrTagValueTooLong)
+	assert.ErrorIs(t, rb.AddSortedTags([][]byte{[]byte("hostname")}, [][]byte{[]byte("a")}), ErrTagKeyTooLong)
+	assert.ErrorIs(t, rb.AddSortedTags([][]byte{[]byte("host")}, [][]byte{[]byte("host-1")}), ErrTagValueTooLong)
+
+	buildRow := func(name string, tags []string, fields int, compound bool) error {
+		rb.Reset()
+		rb.AddMetricName([]byte(name))
+		for _, tag := range tags {
+			assert.NoError(t, rb.AddTag([]byte(tag), []byte("v")))
+		}
+		for i := 0; i < fields; i++ {
+			assert.NoError(t, rb.AddSimpleField([]byte{'f', byte('0' + i)}, flatMetricsV1.SimpleFieldTypeLast, 1))
+		}
+		if compound {
+			assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+		}
+		_, err := rb.Build()
+		return err
+	}
+	assert.NoError(t, buildRow("cpu", []string{"a", "b", "a"}, 2, false))
+	assert.ErrorIs(t, buildRow("memory", nil, 1, false), ErrMetricNameTooLong)
+	assert.ErrorIs(t, buildRow("cpu", []string{"a", "b", "c"}, 1, false), ErrTooManyTags)
+	assert.ErrorIs(t, buildRow("cpu", nil, 3, false), ErrTooManyFields)
+	assert.ErrorIs(t, buildRow("cpu", nil, 2, true), ErrTooManyFields)
+
+	// limits kept after reset, unlimited if nil
+	rb.Reset()
+	assert.NotNil(t, rb.limits)
+	rb.SetLimits(nil)
+	assert.NoError(t, buildRow("memory", []string{"a", "b", "c"}, 3, true))
+
+	bb := NewBatchBuilder()
+	bb.SetLimits(&Limits{MaxTags: 1})
+	rb = bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTags(map[string]string{"a": "1", "b": "2"}))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.ErrorIs(t, bb.Commit(), ErrTooManyTags)
+	assert.Zero(t, bb.Rows())
+
+	bb.SetLimits(&Limits{MaxRowSize: 64})
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTag([]byte("key"), bytes.Repeat([]byte("v"), 64)))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	err := bb.Commit()
+	assert.ErrorIs(t, err, ErrRowTooLarge)
+	var limitErr *LimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 64, limitErr.Limit)
+	assert.Zero(t, bb.Rows())
+}
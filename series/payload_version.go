@@ -0,0 +1,356 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/proto/gen/v2/flatMetricsV2"
+)
+
+// PayloadVersion represents the schema version of flat metric rows in payload.
+type PayloadVersion byte
+
+const (
+	// PayloadVersionV1 is the flatMetricsV1 rows, the payload has no version marker for compatibility.
+	PayloadVersionV1 PayloadVersion = 1
+	// PayloadVersionV2 is the flatMetricsV2 rows(with int64/string fields), which is prefixed by version marker.
+	PayloadVersionV2 PayloadVersion = 2
+)
+
+const (
+	// payloadVersionMagic is the magic of version marker("LBV" + version byte),
+	// a v1 row whose size prefix looks like the marker is written with v2 marker, see VersionedPayload.
+	payloadVersionMagic uint32 = 0x0056424c
+	// payloadVersionSize is the byte size of version marker.
+	payloadVersionSize = 4
+	// PayloadVersionHeader is the http header for negotiating payload version, e.g. "1,2".
+	PayloadVersionHeader = "X-LinDB-Payload-Versions"
+)
+
+// SupportedPayloadVersions is the payload versions supported by this library.
+var SupportedPayloadVersions = []PayloadVersion{PayloadVersionV1, PayloadVersionV2}
+
+// PayloadVersion returns PayloadVersionV2 if any committed row has v2 only parts, else PayloadVersionV1.
+// PayloadVersionV2 is also returned if the size prefix of first row looks like version marker,
+// because the v1 payload would be read as versioned payload, v1 rows are valid v2 rows.
+func (bb *BatchBuilder) PayloadVersion() PayloadVersion {
+	if bb.hasV2Rows || hasPayloadVersionMarker(bb.payload) {
+		return PayloadVersionV2
+	}
+	return PayloadVersionV1
+}
+
+// VersionedPayload appends the payload with version marker(if v2) of all committed rows into dst.
+func (bb *BatchBuilder) VersionedPayload(dst []byte) []byte {
+	dst = AppendPayloadVersion(dst, bb.PayloadVersion())
+	return append(dst, bb.payload...)
+}
+
+// AppendPayloadVersion appends the version marker into dst, the marker should be the first part of rows,
+// after batch header if it exists. PayloadVersionV1 has no marker.
+//
+//	+---------------+----------------+------+-------------+-----+
+//	| magic("LBV")  | version(1 byte) | size | flat metric | ... |
+//	+---------------+----------------+------+-------------+-----+
+func AppendPayloadVersion(dst []byte, version PayloadVersion) []byte {
+	if version == PayloadVersionV1 {
+		return dst
+	}
+	return binary.LittleEndian.AppendUint32(dst, payloadVersionMagic|uint32(version)<<24)
+}
+
+// ReadPayloadVersion reads the version marker of payload,
+// returns PayloadVersionV1 and the original payload if no marker, else returns the version and rows.
+func ReadPayloadVersion(payload []byte) (PayloadVersion, []byte, error) {
+	if !hasPayloadVersionMarker(payload) {
+		return PayloadVersionV1, payload, nil
+	}
+	version := PayloadVersion(payload[3])
+	if !version.IsSupported() {
+		return 0, nil, fmt.Errorf("unsupported payload version: %d", version)
+	}
+	return version, payload[payloadVersionSize:], nil
+}
+
+// hasPayloadVersionMarker returns if the payload starts with version marker.
+func hasPayloadVersionMarker(payload []byte) bool {
+	return len(payload) >= payloadVersionSize && binary.LittleEndian.Uint32(payload)&0x00ffffff == payloadVersionMagic
+}
+
+// IsSupported returns if the version is supported by this library.
+func (v PayloadVersion) IsSupported() bool {
+	return v == PayloadVersionV1 || v == PayloadVersionV2
+}
+
+// String returns the string of version, e.g. "v1".
+func (v PayloadVersion) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// FormatPayloadVersions formats the versions as header value, e.g. "1,2".
+func FormatPayloadVersions(versions []PayloadVersion) string {
+	rs := make([]string, len(versions))
+	for idx, v := range versions {
+		rs[idx] = strconv.Itoa(int(v))
+	}
+	return strings.Join(rs, ",")
+}
+
+// ParsePayloadVersions parses the header value, empty value means the peer only supports PayloadVersionV1.
+func ParsePayloadVersions(s string) ([]PayloadVersion, error) {
+	if strings.TrimSpace(s) == "" {
+		return []PayloadVersion{PayloadVersionV1}, nil
+	}
+	var rs []PayloadVersion
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload version: %s", part)
+		}
+		rs = append(rs, PayloadVersion(v))
+	}
+	return rs, nil
+}
+
+// NegotiatePayloadVersion returns the highest version supported by both sides.
+func NegotiatePayloadVersion(local, remote []PayloadVersion) (PayloadVersion, error) {
+	var rs PayloadVersion
+	for _, l := range local {
+		for _, r := range remote {
+			if l == r && l > rs {
+				rs = l
+			}
+		}
+	}
+	if rs == 0 {
+		return 0, fmt.Errorf("no common payload version, local: %s, remote: %s",
+			FormatPayloadVersions(local), FormatPayloadVersions(remote))
+	}
+	return rs, nil
+}
+
+// DowngradeStats represents the parts dropped when downgrading payload.
+type DowngradeStats struct {
+	DroppedFields int // string fields or int64 fields failed to convert
+	DroppedRows   int // rows without any field left(e.g. only string fields)
+}
+
+// ConvertPayload converts the payload into target version, appends the result into dst.
+func ConvertPayload(dst, payload []byte, target PayloadVersion) (rs []byte, stats DowngradeStats, err error) {
+	switch target {
+	case PayloadVersionV1:
+		return Downgrade(dst, payload)
+	case PayloadVersionV2:
+		rs, err = Upgrade(dst, payload)
+		return rs, stats, err
+	default:
+		return nil, stats, fmt.Errorf("unsupported payload version: %d", target)
+	}
+}
+
+// Upgrade converts the payload into v2 payload, appends the result into dst.
+// v1 row is a valid v2 row, so rows are copied as they are.
+func Upgrade(dst, payload []byte) ([]byte, error) {
+	_, rows, err := ReadPayloadVersion(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err = walkRows(rows, func(_, _ int) error { return nil }); err != nil {
+		return nil, err
+	}
+	dst = AppendPayloadVersion(dst, PayloadVersionV2)
+	return append(dst, rows...), nil
+}
+
+// Downgrade converts the payload into v1 payload(without version marker), appends the result into dst.
+// Rows without v2 only parts are copied as they are, for the others,
+// int64 fields are converted into simple fields(may lose precision if |value| > 2^53),
+// string fields are dropped, the row is dropped if no field left, the dropped parts are counted in stats.
+// Rows with v2 only parts are rebuilt, the series hashes of original row are kept,
+// so that the rows are still routed by the hash strategy of producer.
+func Downgrade(dst, payload []byte) (rs []byte, stats DowngradeStats, err error) {
+	version, rows, err := ReadPayloadVersion(payload)
+	if err != nil {
+		return nil, stats, err
+	}
+	if version == PayloadVersionV1 {
+		if err = walkRows(rows, func(_, _ int) error { return nil }); err != nil {
+			return nil, stats, err
+		}
+		return append(dst, rows...), stats, nil
+	}
+	var bb *BatchBuilder
+	itr := NewRowIteratorV2(rows)
+	for itr.Next() {
+		if itr.FieldsLen() == 0 {
+			dst = append(dst, itr.rowBytes()...)
+			continue
+		}
+		if bb == nil {
+			bb = NewBatchBuilder()
+		}
+		stats.DroppedFields += itr.downgradeTo(bb.RowBuilder())
+		if bb.Commit() == nil {
+			keepSeriesHashes(bb.Payload(), itr.NameHash(), itr.TagsHash())
+			dst = append(dst, bb.Payload()...)
+		} else {
+			stats.DroppedRows++
+		}
+		bb.Reset()
+	}
+	if err = itr.Err(); err != nil {
+		return nil, DowngradeStats{}, err
+	}
+	return dst, stats, nil
+}
+
+// keepSeriesHashes overwrites the series hashes of the rebuilt row with the hashes of original row.
+func keepSeriesHashes(row []byte, nameHash, tagsHash uint64) {
+	rows := NewBatchIterator(row)
+	if !rows.HasNext() {
+		return
+	}
+	rows.Metric().MutateNameHash(nameHash)
+	rows.Metric().MutateKvsHash(tagsHash)
+}
+
+// NewPayloadIterator dispatches the payload by version, returns the iterator which can read both v1/v2 rows.
+func NewPayloadIterator(payload []byte) (*RowIteratorV2, PayloadVersion, error) {
+	version, rows, err := ReadPayloadVersion(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	return NewRowIteratorV2(rows), version, nil
+}
+
+// RowIteratorV2 walks v2 rows(include v1 rows), which reads the v2 only parts besides RowIterator.
+type RowIteratorV2 struct {
+	*RowIterator
+
+	metricV2 flatMetricsV2.Metric
+	field    flatMetricsV2.Field
+	rowStart int
+}
+
+// NewRowIteratorV2 creates a row iterator for the concatenated v2 rows(without version marker).
+func NewRowIteratorV2(data []byte) *RowIteratorV2 {
+	return &RowIteratorV2{RowIterator: NewRowIterator(data)}
+}
+
+// Next moves to next row, returns false if no more row or the buffer is corrupted(check Err).
+func (itr *RowIteratorV2) Next() bool {
+	itr.rowStart = itr.batch.pos
+	if !itr.RowIterator.Next() {
+		return false
+	}
+	table := itr.metric.Table()
+	itr.metricV2.Init(table.Bytes, table.Pos)
+	if err := itr.validateV2(); err != nil {
+		itr.batch.err = err
+		return false
+	}
+	return true
+}
+
+// FieldsLen returns the number of int64/string fields of current row.
+func (itr *RowIteratorV2) FieldsLen() int { return itr.metricV2.FieldsLength() }
+
+// Field returns the int64/string field at index, intValue is set if value type is Int64, else stringValue.
+func (itr *RowIteratorV2) Field(idx int) (
+	name []byte, fieldType flatMetricsV1.SimpleFieldType, valueType flatMetricsV2.ValueType,
+	intValue int64, stringValue []byte,
+) {
+	itr.metricV2.Fields(&itr.field, idx)
+	return itr.field.Name(), itr.field.Type(), itr.field.ValueType(), itr.field.IntValue(), itr.field.StringValue()
+}
+
+// rowBytes returns the bytes(with size prefix) of current row.
+func (itr *RowIteratorV2) rowBytes() []byte {
+	return itr.batch.payload[itr.rowStart:itr.batch.pos]
+}
+
+// validateV2 walks the v2 only parts of current row, returns error if row is corrupted or invalid.
+func (itr *RowIteratorV2) validateV2() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("corrupted flat metric: %v", r)
+		}
+	}()
+	for i := 0; i < itr.FieldsLen(); i++ {
+		name, _, valueType, _, _ := itr.Field(i)
+		if len(name) == 0 {
+			return fmt.Errorf("fieldName is empty")
+		}
+		if _, ok := flatMetricsV2.EnumNamesValueType[valueType]; !ok {
+			return fmt.Errorf("unknown value type: %d", valueType)
+		}
+	}
+	return nil
+}
+
+// downgradeTo fills current row into row builder as v1 row, returns the number of dropped fields.
+func (itr *RowIteratorV2) downgradeTo(rb *RowBuilder) (droppedFields int) {
+	rb.AddNameSpace(itr.Namespace())
+	rb.AddMetricName(itr.Name())
+	rb.AddTimestamp(itr.Timestamp())
+	for i := 0; i < itr.TagsLen(); i++ {
+		_ = rb.AddTag(itr.Tag(i))
+	}
+	for i := 0; i < itr.SimpleFieldsLen(); i++ {
+		name, fieldType, value := itr.SimpleField(i)
+		if itr.SimpleFieldIsAbsent(i) {
+			_ = rb.AddAbsentSimpleField(name, fieldType)
+		} else {
+			_ = rb.AddSimpleField(name, fieldType, value)
+		}
+	}
+	if itr.HasCompoundField() {
+		minValue, maxValue, sum, count := itr.CompoundFieldMMSC()
+		_ = rb.AddCompoundFieldMMSC(minValue, maxValue, sum, count)
+		values := make([]float64, itr.CompoundFieldBucketsLen())
+		bounds := make([]float64, len(values))
+		for i := range values {
+			bounds[i], values[i] = itr.CompoundFieldBucket(i)
+		}
+		_ = rb.AddCompoundFieldData(values, bounds)
+	}
+	for i := 0; i < itr.SummaryFieldsLen(); i++ {
+		name, count, sum, n := itr.SummaryField(i)
+		quantiles := make([]float64, n)
+		values := make([]float64, n)
+		for j := 0; j < n; j++ {
+			quantiles[j], values[j] = itr.SummaryFieldQuantile(j)
+		}
+		_ = rb.AddSummaryField(name, count, sum, quantiles, values)
+	}
+	for i := 0; i < itr.ExemplarsLen(); i++ {
+		_ = rb.AddExemplar(itr.Exemplar(i))
+	}
+	for i := 0; i < itr.FieldsLen(); i++ {
+		name, fieldType, valueType, intValue, _ := itr.Field(i)
+		if valueType != flatMetricsV2.ValueTypeInt64 || rb.AddSimpleField(name, fieldType, float64(intValue)) != nil {
+			droppedFields++
+		}
+	}
+	return droppedFields
+}
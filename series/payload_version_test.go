@@ -0,0 +1,296 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/proto/gen/v2/flatMetricsV2"
+)
+
+func buildV2Payload(t *testing.T) *BatchBuilder {
+	bb := NewBatchBuilder()
+	// non default hash strategy, the hashes are kept after downgrade
+	bb.SetHashStrategy(SeparateKVHashStrategy{})
+	rb := bb.RowBuilder()
+	// v1 row
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.False(t, rb.IsV2())
+	assert.NoError(t, bb.Commit())
+	assert.Equal(t, PayloadVersionV1, bb.PayloadVersion())
+	// v2 row
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("jvm"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("heap"), flatMetricsV1.SimpleFieldTypeLast, 1.5))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("gc"), flatMetricsV1.SimpleFieldTypeDeltaSum))
+	assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+	assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+	assert.NoError(t, rb.AddSummaryField([]byte("latency"), 10, 20, []float64{0.5}, []float64{1}))
+	assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+	assert.NoError(t, rb.AddInt64Field([]byte("bytes"), flatMetricsV1.SimpleFieldTypeDeltaSum, math.MaxInt64))
+	assert.NoError(t, rb.AddStringField([]byte("version"), []byte("1.0.0")))
+	assert.True(t, rb.IsV2())
+	assert.GreaterOrEqual(t, rb.EstimatedSize(), 0)
+	assert.NoError(t, bb.Commit())
+	// v2 row with string field only
+	rb.AddMetricName([]byte("build"))
+	assert.NoError(t, rb.AddStringField([]byte("commit"), []byte("abc")))
+	assert.NoError(t, bb.Commit())
+	assert.Equal(t, PayloadVersionV2, bb.PayloadVersion())
+	return bb
+}
+
+func TestRowBuilder_TypedFields(t *testing.T) {
+	rb := CreateRowBuilder()
+	assert.Error(t, rb.AddInt64Field(nil, flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.Error(t, rb.AddInt64Field([]byte("f"), flatMetricsV1.SimpleFieldTypeUnSpecified, 1))
+	assert.Error(t, rb.AddStringField(nil, []byte("v")))
+
+	rb.AddMetricName([]byte("m"))
+	assert.NoError(t, rb.AddInt64Field([]byte("f1"), flatMetricsV1.SimpleFieldTypeLast, -1))
+	assert.NoError(t, rb.AddStringField([]byte("f2"), []byte("v")))
+	estimated := rb.EstimatedSize()
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, estimated, len(data))
+
+	rb.Reset()
+	assert.False(t, rb.IsV2())
+}
+
+func TestPayloadVersion(t *testing.T) {
+	version, rows, err := ReadPayloadVersion([]byte{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV1, version)
+	assert.Equal(t, []byte{1, 2}, rows)
+	assert.Empty(t, AppendPayloadVersion(nil, PayloadVersionV1))
+
+	data := AppendPayloadVersion(nil, PayloadVersionV2)
+	assert.Equal(t, []byte("LBV\x02"), data)
+	version, rows, err = ReadPayloadVersion(append(data, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV2, version)
+	assert.Equal(t, []byte{1}, rows)
+
+	_, _, err = ReadPayloadVersion([]byte("LBV\x09"))
+	assert.Error(t, err)
+	assert.Equal(t, "v2", PayloadVersionV2.String())
+	assert.False(t, PayloadVersion(3).IsSupported())
+}
+
+func TestPayloadVersion_RowSizeLikeMarker(t *testing.T) {
+	// large row is built if no limits
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTag([]byte("key"), bytes.Repeat([]byte("v"), 8*1024*1024)))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	assert.Equal(t, PayloadVersionV1, bb.PayloadVersion())
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.NoError(t, walkRows(bb.Payload(), func(_, _ int) error { return nil }))
+
+	// v1 rows whose size prefix looks like version marker are written with v2 marker
+	bb.Reset()
+	bb.payload = binary.LittleEndian.AppendUint32(nil, payloadVersionMagic|uint32(PayloadVersionV2)<<24)
+	assert.Equal(t, PayloadVersionV2, bb.PayloadVersion())
+	version, rows, err := ReadPayloadVersion(bb.VersionedPayload(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV2, version)
+	assert.Equal(t, bb.Payload(), rows)
+}
+
+func TestNegotiatePayloadVersion(t *testing.T) {
+	assert.Equal(t, "1,2", FormatPayloadVersions(SupportedPayloadVersions))
+	versions, err := ParsePayloadVersions(" 1, 2 ,3")
+	assert.NoError(t, err)
+	assert.Equal(t, []PayloadVersion{1, 2, 3}, versions)
+	versions, err = ParsePayloadVersions("")
+	assert.NoError(t, err)
+	assert.Equal(t, []PayloadVersion{PayloadVersionV1}, versions)
+	_, err = ParsePayloadVersions("1,x")
+	assert.Error(t, err)
+
+	version, err := NegotiatePayloadVersion(SupportedPayloadVersions, []PayloadVersion{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV2, version)
+	version, err = NegotiatePayloadVersion(SupportedPayloadVersions, []PayloadVersion{1})
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV1, version)
+	_, err = NegotiatePayloadVersion(SupportedPayloadVersions, []PayloadVersion{3})
+	assert.EqualError(t, err, "no common payload version, local: 1,2, remote: 3")
+}
+
+func TestNewPayloadIterator(t *testing.T) {
+	bb := buildV2Payload(t)
+	payload := bb.VersionedPayload(nil)
+	assert.NoError(t, Verify(bb.Payload()))
+
+	itr, version, err := NewPayloadIterator(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV2, version)
+	assert.True(t, itr.Next())
+	assert.Equal(t, "cpu", string(itr.Name()))
+	assert.Zero(t, itr.FieldsLen())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "jvm", string(itr.Name()))
+	assert.Equal(t, 2, itr.SimpleFieldsLen())
+	assert.Equal(t, 2, itr.FieldsLen())
+	name, fieldType, valueType, intValue, _ := itr.Field(0)
+	assert.Equal(t, "bytes", string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldType)
+	assert.Equal(t, flatMetricsV2.ValueTypeInt64, valueType)
+	assert.Equal(t, int64(math.MaxInt64), intValue)
+	name, _, valueType, _, stringValue := itr.Field(1)
+	assert.Equal(t, "version", string(name))
+	assert.Equal(t, flatMetricsV2.ValueTypeString, valueType)
+	assert.Equal(t, "1.0.0", string(stringValue))
+	assert.True(t, itr.Next())
+	assert.Equal(t, "build", string(itr.Name()))
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+
+	// v1 reader reads the v1 parts of v2 rows
+	v1Itr := NewRowIterator(bb.Payload())
+	assert.True(t, v1Itr.Next())
+	assert.True(t, v1Itr.Next())
+	assert.Equal(t, "jvm", string(v1Itr.Name()))
+	assert.True(t, v1Itr.HasCompoundField())
+	assert.True(t, v1Itr.Next())
+	assert.False(t, v1Itr.Next())
+	assert.NoError(t, v1Itr.Err())
+
+	_, _, err = NewPayloadIterator([]byte("LBV\x09"))
+	assert.Error(t, err)
+}
+
+func TestUpgrade(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	assert.Equal(t, bb.Payload(), bb.VersionedPayload(nil))
+
+	rs, err := Upgrade(nil, bb.Payload())
+	assert.NoError(t, err)
+	assert.Equal(t, append(AppendPayloadVersion(nil, PayloadVersionV2), bb.Payload()...), rs)
+	// upgrade v2 payload
+	rs2, err := Upgrade(nil, rs)
+	assert.NoError(t, err)
+	assert.Equal(t, rs, rs2)
+	rs2, stats, err := ConvertPayload(nil, bb.Payload(), PayloadVersionV2)
+	assert.NoError(t, err)
+	assert.Zero(t, stats)
+	assert.Equal(t, rs, rs2)
+
+	_, err = Upgrade(nil, []byte{1, 2})
+	assert.Error(t, err)
+	_, err = Upgrade(nil, []byte("LBV\x09"))
+	assert.Error(t, err)
+	_, _, err = ConvertPayload(nil, bb.Payload(), 9)
+	assert.Error(t, err)
+}
+
+func TestDowngrade(t *testing.T) {
+	bb := buildV2Payload(t)
+	rows := bb.Payload()
+	origin := NewRowIterator(rows)
+	assert.True(t, origin.Next())
+	assert.True(t, origin.Next())
+	nameHash, tagsHash := origin.NameHash(), origin.TagsHash()
+	rs, stats, err := Downgrade(nil, bb.VersionedPayload(nil))
+	assert.NoError(t, err)
+	// version field of jvm and commit field of build are dropped, build row without field left is dropped
+	assert.Equal(t, DowngradeStats{DroppedFields: 2, DroppedRows: 1}, stats)
+	assert.NoError(t, Verify(rs))
+
+	version, _, err := ReadPayloadVersion(rs)
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadVersionV1, version)
+	itr := NewRowIteratorV2(rs)
+	assert.True(t, itr.Next())
+	// row without v2 parts is copied
+	assert.Equal(t, rows[:len(itr.rowBytes())], itr.rowBytes())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "jvm", string(itr.Name()))
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, int64(100), itr.Timestamp())
+	assert.Zero(t, itr.FieldsLen())
+	// hashes of original row are kept
+	assert.NotEqual(t, DefaultHashStrategy.HashName([]byte("ns"), []byte("jvm")), itr.NameHash())
+	assert.Equal(t, nameHash, itr.NameHash())
+	assert.Equal(t, tagsHash, itr.TagsHash())
+	assert.Equal(t, 3, itr.SimpleFieldsLen())
+	name, fieldType, value := itr.SimpleField(2)
+	assert.Equal(t, "bytes", string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldType)
+	assert.Equal(t, float64(math.MaxInt64), value)
+	assert.True(t, itr.SimpleFieldIsAbsent(1))
+	assert.True(t, itr.HasCompoundField())
+	assert.Equal(t, 2, itr.CompoundFieldBucketsLen())
+	assert.Equal(t, 1, itr.SummaryFieldsLen())
+	assert.Equal(t, 1, itr.ExemplarsLen())
+	// build row is dropped
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+
+	// downgrade v1 payload
+	rs2, stats, err := ConvertPayload(nil, rs, PayloadVersionV1)
+	assert.NoError(t, err)
+	assert.Zero(t, stats)
+	assert.Equal(t, rs, rs2)
+
+	_, _, err = Downgrade(nil, []byte{1, 2})
+	assert.Error(t, err)
+	_, _, err = Downgrade(nil, append(AppendPayloadVersion(nil, PayloadVersionV2), 1, 2))
+	assert.Error(t, err)
+	_, _, err = Downgrade(nil, []byte("LBV\x09"))
+	assert.Error(t, err)
+}
+
+func TestRowIteratorV2_Invalid(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddMetricName([]byte("m"))
+	assert.NoError(t, rb.AddStringField([]byte("f"), []byte("v")))
+	assert.NoError(t, bb.Commit())
+	payload := bb.Payload()
+	// mutate value type
+	itr := NewRowIteratorV2(payload)
+	assert.True(t, itr.Next())
+	itr.metricV2.Fields(&itr.field, 0)
+	assert.True(t, itr.field.MutateValueType(9))
+	itr = NewRowIteratorV2(payload)
+	assert.False(t, itr.Next())
+	assert.ErrorContains(t, itr.Err(), "unknown value type: 9")
+	assert.ErrorContains(t, Verify(payload), "unknown value type: 9")
+}
@@ -0,0 +1,298 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+const (
+	// GraphiteDefaultFieldName is the field name of graphite value.
+	GraphiteDefaultFieldName = "value"
+	// GraphiteDefaultSeparator is the default separator for joining metric name nodes.
+	GraphiteDefaultSeparator = "."
+
+	graphiteMeasurement     = "measurement"
+	graphiteMeasurementRest = "measurement*"
+)
+
+// GraphiteTemplate represents the rule for splitting dotted metric path into metric name and tags.
+//
+// Filter is a dotted pattern matching the prefix nodes of the path, "*" matches any node, empty matches all paths.
+// Template is a dotted list of parts for the nodes of the path:
+//   - "measurement": the node is a part of metric name
+//   - "measurement*": the node and all remaining nodes are parts of metric name, must be the last part
+//   - "" or "_": the node is skipped
+//   - others: the node is the value of tag whose key is the part
+//
+// e.g. filter "servers.*", template ".host.measurement*" splits "servers.host1.cpu.idle" into
+// metric name "cpu.idle" and tag host=host1.
+type GraphiteTemplate struct {
+	Filter   string
+	Template string
+	Tags     map[string]string // extra tags for the matched metrics
+}
+
+// graphiteRule represents the compiled graphite template.
+type graphiteRule struct {
+	filter []string
+	parts  []string
+	tags   []GraphiteTag
+}
+
+// match checks if the path nodes matches the filter.
+func (r *graphiteRule) match(nodes []string) bool {
+	if len(r.filter) > len(nodes) {
+		return false
+	}
+	for idx, f := range r.filter {
+		if f != "*" && f != nodes[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// GraphiteTag represents a tag of graphite metric.
+type GraphiteTag struct {
+	Key   string
+	Value string
+}
+
+// GraphiteRow represents a parsed graphite plaintext line.
+type GraphiteRow struct {
+	Name      string
+	Tags      []GraphiteTag
+	Value     float64
+	Timestamp int64 // unix seconds, 0 if absent
+}
+
+// Fill fills the row into row builder with a field named "value",
+// whose type comes from the field type policies of row builder, last if no policy matched.
+func (r *GraphiteRow) Fill(rb *series.RowBuilder, namespace []byte) error {
+	rb.AddNameSpace(namespace)
+	rb.AddMetricName([]byte(r.Name))
+	rb.AddTimestamp(r.Timestamp * 1000)
+	for _, tag := range r.Tags {
+		if err := rb.AddTag([]byte(tag.Key), []byte(tag.Value)); err != nil {
+			return err
+		}
+	}
+	return rb.AddSimpleField([]byte(GraphiteDefaultFieldName), rb.DefaultFieldType([]byte(GraphiteDefaultFieldName), flatMetricsV1.SimpleFieldTypeLast), r.Value)
+}
+
+// GraphiteParser parses graphite plaintext protocol lines based on the templates.
+type GraphiteParser struct {
+	separator string
+	rules     []*graphiteRule
+}
+
+// NewGraphiteParser creates a graphite parser, the templates are matched in order,
+// the whole path is used as metric name if no template matched.
+func NewGraphiteParser(separator string, templates []GraphiteTemplate) (*GraphiteParser, error) {
+	if separator == "" {
+		separator = GraphiteDefaultSeparator
+	}
+	p := &GraphiteParser{separator: separator}
+	for _, template := range templates {
+		rule, err := compileGraphiteTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p, nil
+}
+
+// compileGraphiteTemplate validates and compiles the template.
+func compileGraphiteTemplate(template GraphiteTemplate) (*graphiteRule, error) {
+	rule := &graphiteRule{parts: strings.Split(template.Template, ".")}
+	if template.Filter != "" {
+		rule.filter = strings.Split(template.Filter, ".")
+	}
+	hasMeasurement := false
+	for idx, part := range rule.parts {
+		switch part {
+		case graphiteMeasurement:
+			hasMeasurement = true
+		case graphiteMeasurementRest:
+			if idx != len(rule.parts)-1 {
+				return nil, fmt.Errorf("invalid graphite template: %s, %s must be the last part", template.Template, part)
+			}
+			hasMeasurement = true
+		}
+	}
+	if !hasMeasurement {
+		return nil, fmt.Errorf("invalid graphite template: %s, no measurement part", template.Template)
+	}
+	for key, value := range template.Tags {
+		rule.tags = append(rule.tags, GraphiteTag{Key: key, Value: value})
+	}
+	sort.Slice(rule.tags, func(i, j int) bool {
+		return rule.tags[i].Key < rule.tags[j].Key
+	})
+	return rule, nil
+}
+
+// Parse parses one graphite plaintext line.
+//
+//	metric.path[;tag_key=tag_value...] value [timestamp]
+func (p *GraphiteParser) Parse(line []byte) (*GraphiteRow, error) {
+	items := strings.Fields(string(line))
+	if len(items) < 2 || len(items) > 3 {
+		return nil, fmt.Errorf("invalid graphite line: %s", line)
+	}
+	row := &GraphiteRow{}
+	value, err := strconv.ParseFloat(items[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid graphite value: %s", items[1])
+	}
+	row.Value = value
+	if len(items) == 3 {
+		// timestamp may be float, -1 means now
+		ts, err := strconv.ParseFloat(items[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid graphite timestamp: %s", items[2])
+		}
+		if ts > 0 {
+			row.Timestamp = int64(ts)
+		}
+	}
+	path := items[0]
+	// graphite tagged series, e.g. cpu.idle;host=host1
+	if idx := strings.IndexByte(path, ';'); idx >= 0 {
+		for _, kv := range strings.Split(path[idx+1:], ";") {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || key == "" || value == "" {
+				return nil, fmt.Errorf("invalid graphite tag: %s", kv)
+			}
+			row.Tags = append(row.Tags, GraphiteTag{Key: key, Value: value})
+		}
+		path = path[:idx]
+	}
+	if path == "" {
+		return nil, fmt.Errorf("invalid graphite line: %s, metric path is empty", line)
+	}
+	p.apply(path, row)
+	return row, nil
+}
+
+// apply splits the metric path into metric name and tags based on the first matched template.
+func (p *GraphiteParser) apply(path string, row *GraphiteRow) {
+	nodes := strings.Split(path, ".")
+	var rule *graphiteRule
+	for _, r := range p.rules {
+		if r.match(nodes) {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		row.Name = path
+		return
+	}
+	var (
+		name    []string
+		tagKeys []string
+		tags    = make(map[string][]string)
+	)
+	for idx, part := range rule.parts {
+		if idx >= len(nodes) {
+			break
+		}
+		switch part {
+		case "", "_":
+		case graphiteMeasurement:
+			name = append(name, nodes[idx])
+		case graphiteMeasurementRest:
+			name = append(name, nodes[idx:]...)
+		default:
+			if _, ok := tags[part]; !ok {
+				tagKeys = append(tagKeys, part)
+			}
+			tags[part] = append(tags[part], nodes[idx])
+		}
+	}
+	if len(name) == 0 {
+		// path is shorter than template
+		row.Name = path
+	} else {
+		row.Name = strings.Join(name, p.separator)
+	}
+	for _, key := range tagKeys {
+		row.Tags = append(row.Tags, GraphiteTag{Key: key, Value: strings.Join(tags[key], p.separator)})
+	}
+	row.Tags = append(row.Tags, rule.tags...)
+}
+
+// GraphiteLinesToRows parses graphite plaintext payload(one line per row) into the batch builder,
+// empty lines and comments are ignored, NaN values are skipped.
+// The rows of payload are discarded from batch if any line is invalid.
+func GraphiteLinesToRows(data []byte, namespace string, parser *GraphiteParser, batch *series.BatchBuilder) error {
+	mark := batch.Mark()
+	if err := graphiteLinesToRows(data, namespace, parser, batch); err != nil {
+		batch.Rollback(mark)
+		return err
+	}
+	return nil
+}
+
+// graphiteLinesToRows parses graphite plaintext payload into the batch.
+func graphiteLinesToRows(data []byte, namespace string, parser *GraphiteParser, batch *series.BatchBuilder) error {
+	var (
+		ns     = []byte(namespace)
+		lineNo int
+	)
+	for len(data) > 0 {
+		lineNo++
+		var line []byte
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			line, data = data[:idx], data[idx+1:]
+		} else {
+			line, data = data, nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		row, err := parser.Parse(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if math.IsNaN(row.Value) {
+			continue
+		}
+		rb := batch.RowBuilder()
+		if err := row.Fill(rb, ns); err != nil {
+			rb.Reset()
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if err := batch.Commit(); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+func TestNewGraphiteParser(t *testing.T) {
+	_, err := NewGraphiteParser("", []GraphiteTemplate{{Template: "host.measurement*.env"}})
+	assert.Error(t, err)
+	_, err = NewGraphiteParser("", []GraphiteTemplate{{Template: "host.region"}})
+	assert.Error(t, err)
+	p, err := NewGraphiteParser("", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, GraphiteDefaultSeparator, p.separator)
+}
+
+func TestGraphiteParser_Parse(t *testing.T) {
+	p, err := NewGraphiteParser("_", []GraphiteTemplate{
+		{Filter: "servers.*", Template: ".host.measurement*", Tags: map[string]string{"src": "graphite", "dc": "a"}},
+		{Filter: "stats.*.*", Template: "_.region.region.measurement.measurement.field"},
+		{Filter: "app", Template: "_.measurement"},
+	})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		line string
+		row  *GraphiteRow
+	}{
+		{
+			line: "servers.host1.cpu.idle 10.5 1700000000",
+			row: &GraphiteRow{Name: "cpu_idle", Value: 10.5, Timestamp: 1700000000, Tags: []GraphiteTag{
+				{Key: "host", Value: "host1"}, {Key: "dc", Value: "a"}, {Key: "src", Value: "graphite"},
+			}},
+		},
+		{
+			line: "stats.cn.sh.http.requests.count 1 1700000000.5",
+			row: &GraphiteRow{Name: "http_requests", Value: 1, Timestamp: 1700000000, Tags: []GraphiteTag{
+				{Key: "region", Value: "cn_sh"}, {Key: "field", Value: "count"},
+			}},
+		},
+		{
+			line: "app.memory.heap 2",
+			row:  &GraphiteRow{Name: "memory", Value: 2},
+		},
+		{
+			line: "app 2 -1",
+			row:  &GraphiteRow{Name: "app", Value: 2},
+		},
+		{
+			line: "servers 2",
+			row:  &GraphiteRow{Name: "servers", Value: 2},
+		},
+		{
+			line: "disk.used;host=host1;dc=a  3\t1700000000",
+			row: &GraphiteRow{Name: "disk.used", Value: 3, Timestamp: 1700000000, Tags: []GraphiteTag{
+				{Key: "host", Value: "host1"}, {Key: "dc", Value: "a"},
+			}},
+		},
+	}
+	for _, tc := range cases {
+		row, err := p.Parse([]byte(tc.line))
+		assert.NoError(t, err, tc.line)
+		assert.Equal(t, tc.row, row, tc.line)
+	}
+
+	for _, line := range []string{
+		"cpu",
+		"cpu 1 2 3",
+		"cpu abc",
+		"cpu 1 abc",
+		"cpu;host 1",
+		"cpu;host= 1",
+		";host=a 1",
+	} {
+		_, err := p.Parse([]byte(line))
+		assert.Error(t, err, line)
+	}
+}
+
+func TestGraphiteLinesToRows(t *testing.T) {
+	p, err := NewGraphiteParser("", []GraphiteTemplate{{Filter: "servers.*", Template: ".host.measurement*"}})
+	assert.NoError(t, err)
+	batch := series.NewBatchBuilder()
+	data := "# comment\n\nservers.host1.cpu.idle 10 1700000000\ncpu.load NaN 1700000000\r\ncpu.load 1.5 1700000000"
+	assert.NoError(t, GraphiteLinesToRows([]byte(data), "ns", p, batch))
+	assert.Equal(t, 2, batch.Rows())
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, "cpu.idle", string(itr.Name()))
+	assert.Equal(t, int64(1700000000000), itr.Timestamp())
+	key, value := itr.Tag(0)
+	assert.Equal(t, "host", string(key))
+	assert.Equal(t, "host1", string(value))
+	name, fieldType, v := itr.SimpleField(0)
+	assert.Equal(t, GraphiteDefaultFieldName, string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, float64(10), v)
+	assert.True(t, itr.Next())
+	assert.Equal(t, "cpu.load", string(itr.Name()))
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+
+	batch.Reset()
+	err = GraphiteLinesToRows([]byte("cpu.load 1\ncpu"), "ns", p, batch)
+	assert.EqualError(t, err, "line 2: invalid graphite line: cpu")
+	// rows of failed payload are discarded
+	assert.Zero(t, batch.Rows())
+	assert.Error(t, GraphiteLinesToRows([]byte("cpu.load +Inf 1700000000"), "ns", p, batch))
+	row := &GraphiteRow{Name: "cpu", Value: 1, Tags: []GraphiteTag{{Key: "host"}}}
+	assert.Error(t, row.Fill(batch.RowBuilder(), []byte("ns")))
+}
+
+func TestGraphiteLinesToRows_FieldTypePolicies(t *testing.T) {
+	p, err := NewGraphiteParser("", nil)
+	assert.NoError(t, err)
+	policies, err := series.NewFieldTypePolicies([]series.FieldTypePolicy{{Metric: "*.count", FieldType: "DeltaSum"}})
+	assert.NoError(t, err)
+	batch := series.NewBatchBuilder(series.WithFieldTypePolicies(policies))
+	assert.NoError(t, GraphiteLinesToRows([]byte("req.count 10 1700000000\ncpu.load 1 1700000000"), "ns", p, batch))
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	_, fieldType, _ := itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldType)
+	assert.True(t, itr.Next())
+	_, fieldType, _ = itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+}
@@ -0,0 +1,312 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+// InfluxFieldType represents the value type of influx field.
+type InfluxFieldType uint8
+
+const (
+	InfluxFieldTypeFloat InfluxFieldType = iota + 1
+	InfluxFieldTypeInteger
+	InfluxFieldTypeUnsigned
+	InfluxFieldTypeBoolean
+	InfluxFieldTypeString
+)
+
+// InfluxTag represents a tag of influx line.
+type InfluxTag struct {
+	Key   []byte
+	Value []byte
+}
+
+// InfluxField represents a field of influx line, string value is kept in Raw.
+type InfluxField struct {
+	Key   []byte
+	Type  InfluxFieldType
+	Value float64
+	Raw   []byte
+}
+
+// InfluxRow represents a parsed influx line protocol row,
+// all byte slices reference the parsed line or the row's unescaped buffer.
+type InfluxRow struct {
+	Measurement []byte
+	Tags        []InfluxTag
+	Fields      []InfluxField
+	Timestamp   int64 // raw timestamp in line precision, 0 if absent
+
+	buf []byte // unescaped bytes
+}
+
+// reset resets the row for parsing next line.
+func (r *InfluxRow) reset(lineLen int) {
+	r.Measurement = nil
+	r.Tags = r.Tags[:0]
+	r.Fields = r.Fields[:0]
+	r.Timestamp = 0
+	if cap(r.buf) < lineLen {
+		r.buf = make([]byte, 0, lineLen)
+	}
+	r.buf = r.buf[:0]
+}
+
+// unescape removes the escape char, unescaped bytes are stored in row buffer
+// which is pre-allocated with line length, so the former slices are never moved.
+func (r *InfluxRow) unescape(s []byte, escaped bool) []byte {
+	if !escaped {
+		return s
+	}
+	start := len(r.buf)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		r.buf = append(r.buf, s[i])
+	}
+	return r.buf[start:len(r.buf):len(r.buf)]
+}
+
+// ParseInfluxLine parses one influx line protocol line into a new row, which allocates for each line,
+// use InfluxRow.Parse with a reused row for zero-allocation parsing(e.g. InfluxLinesToRows).
+func ParseInfluxLine(line []byte) (*InfluxRow, error) {
+	row := &InfluxRow{}
+	if err := row.Parse(line); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Parse parses one influx line protocol line into the row, the row is reusable for avoiding allocation.
+//
+//	measurement[,tag_key=tag_value...] field_key=field_value[,field_key=field_value...] [timestamp]
+func (r *InfluxRow) Parse(line []byte) error {
+	line = bytes.TrimSpace(line)
+	r.reset(len(line))
+	if len(line) == 0 {
+		return fmt.Errorf("influx line is empty")
+	}
+	// measurement and tags
+	pos, escaped := scanInfluxToken(line, 0, func(c byte) bool { return c == ',' || c == ' ' })
+	r.Measurement = r.unescape(line[:pos], escaped)
+	if len(r.Measurement) == 0 {
+		return fmt.Errorf("influx line without measurement: %s", line)
+	}
+	for pos < len(line) && line[pos] == ',' {
+		start := pos + 1
+		keyEnd, keyEscaped := scanInfluxToken(line, start, func(c byte) bool { return c == '=' || c == ',' || c == ' ' })
+		if keyEnd >= len(line) || line[keyEnd] != '=' {
+			return fmt.Errorf("influx tag without value: %s", line)
+		}
+		valueEnd, valueEscaped := scanInfluxToken(line, keyEnd+1, func(c byte) bool { return c == ',' || c == ' ' })
+		r.Tags = append(r.Tags, InfluxTag{
+			Key:   r.unescape(line[start:keyEnd], keyEscaped),
+			Value: r.unescape(line[keyEnd+1:valueEnd], valueEscaped),
+		})
+		pos = valueEnd
+	}
+	// fields
+	pos = skipInfluxSpace(line, pos)
+	if pos >= len(line) {
+		return fmt.Errorf("influx line without fields: %s", line)
+	}
+	for {
+		start := pos
+		keyEnd, keyEscaped := scanInfluxToken(line, start, func(c byte) bool { return c == '=' || c == ',' || c == ' ' })
+		if keyEnd >= len(line) || line[keyEnd] != '=' || keyEnd == start {
+			return fmt.Errorf("influx field is invalid: %s", line)
+		}
+		field := InfluxField{Key: r.unescape(line[start:keyEnd], keyEscaped)}
+		valueEnd, err := r.parseFieldValue(line, keyEnd+1, &field)
+		if err != nil {
+			return err
+		}
+		r.Fields = append(r.Fields, field)
+		pos = valueEnd
+		if pos >= len(line) || line[pos] != ',' {
+			break
+		}
+		pos++
+	}
+	// timestamp
+	pos = skipInfluxSpace(line, pos)
+	if pos < len(line) {
+		timestamp, err := strconv.ParseInt(string(line[pos:]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("influx timestamp is invalid: %s", line)
+		}
+		r.Timestamp = timestamp
+	}
+	return nil
+}
+
+// parseFieldValue parses field value starting from pos, returns the end position.
+func (r *InfluxRow) parseFieldValue(line []byte, pos int, field *InfluxField) (int, error) {
+	if pos >= len(line) {
+		return pos, fmt.Errorf("influx field: %s without value", field.Key)
+	}
+	if line[pos] == '"' {
+		end, escaped := scanInfluxToken(line, pos+1, func(c byte) bool { return c == '"' })
+		if end >= len(line) {
+			return end, fmt.Errorf("influx string field: %s is not closed", field.Key)
+		}
+		field.Type = InfluxFieldTypeString
+		field.Raw = r.unescape(line[pos+1:end], escaped)
+		return end + 1, nil
+	}
+	end := pos
+	for end < len(line) && line[end] != ',' && line[end] != ' ' {
+		end++
+	}
+	value := line[pos:end]
+	field.Raw = value
+	if len(value) == 0 {
+		return end, fmt.Errorf("influx field: %s without value", field.Key)
+	}
+	var err error
+	switch last := value[len(value)-1]; {
+	case last == 'i':
+		var v int64
+		v, err = strconv.ParseInt(string(value[:len(value)-1]), 10, 64)
+		field.Type, field.Value = InfluxFieldTypeInteger, float64(v)
+	case last == 'u':
+		var v uint64
+		v, err = strconv.ParseUint(string(value[:len(value)-1]), 10, 64)
+		field.Type, field.Value = InfluxFieldTypeUnsigned, float64(v)
+	default:
+		switch string(value) {
+		case "t", "T", "true", "True", "TRUE":
+			field.Type, field.Value = InfluxFieldTypeBoolean, 1
+		case "f", "F", "false", "False", "FALSE":
+			field.Type, field.Value = InfluxFieldTypeBoolean, 0
+		default:
+			field.Type = InfluxFieldTypeFloat
+			field.Value, err = strconv.ParseFloat(string(value), 64)
+		}
+	}
+	if err != nil {
+		return end, fmt.Errorf("influx field: %s value: %s is invalid", field.Key, value)
+	}
+	return end, nil
+}
+
+// scanInfluxToken returns the position of first unescaped delimiter and if escape char exists.
+func scanInfluxToken(line []byte, pos int, isDelimiter func(c byte) bool) (end int, escaped bool) {
+	for end = pos; end < len(line); end++ {
+		if line[end] == '\\' {
+			escaped = true
+			end++
+			continue
+		}
+		if isDelimiter(line[end]) {
+			return end, escaped
+		}
+	}
+	return len(line), escaped
+}
+
+func skipInfluxSpace(line []byte, pos int) int {
+	for pos < len(line) && line[pos] == ' ' {
+		pos++
+	}
+	return pos
+}
+
+// Fill fills the row into row builder, string fields are ignored,
+// precision is the unit of timestamp(time.Nanosecond if 0).
+// The field type comes from the field type policies of row builder(e.g. field "*_total" as DeltaSum),
+// last if no policy matched, influx line protocol has no type hints.
+func (r *InfluxRow) Fill(rb *series.RowBuilder, namespace []byte, precision time.Duration) error {
+	rb.AddNameSpace(namespace)
+	rb.AddMetricName(r.Measurement)
+	if r.Timestamp != 0 {
+		if precision <= 0 {
+			precision = time.Nanosecond
+		}
+		rb.AddTimestamp(r.Timestamp * int64(precision) / int64(time.Millisecond))
+	}
+	for idx := range r.Tags {
+		if err := rb.AddTag(r.Tags[idx].Key, r.Tags[idx].Value); err != nil {
+			return err
+		}
+	}
+	for idx := range r.Fields {
+		field := &r.Fields[idx]
+		if field.Type == InfluxFieldTypeString {
+			continue
+		}
+		if err := rb.AddSimpleField(field.Key, rb.DefaultFieldType(field.Key, flatMetricsV1.SimpleFieldTypeLast), field.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InfluxLinesToRows parses influx line protocol payload(one line per row) into the batch builder,
+// empty lines and comments are ignored. The rows of payload are discarded from batch if any line is invalid.
+func InfluxLinesToRows(data []byte, namespace string, precision time.Duration, batch *series.BatchBuilder) error {
+	mark := batch.Mark()
+	if err := influxLinesToRows(data, namespace, precision, batch); err != nil {
+		batch.Rollback(mark)
+		return err
+	}
+	return nil
+}
+
+// influxLinesToRows parses influx line protocol payload into the batch.
+func influxLinesToRows(data []byte, namespace string, precision time.Duration, batch *series.BatchBuilder) error {
+	var (
+		row    InfluxRow
+		ns     = []byte(namespace)
+		lineNo int
+	)
+	for len(data) > 0 {
+		lineNo++
+		var line []byte
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			line, data = data[:idx], data[idx+1:]
+		} else {
+			line, data = data, nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if err := row.Parse(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		rb := batch.RowBuilder()
+		if err := row.Fill(rb, ns, precision); err != nil {
+			rb.Reset()
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if err := batch.Commit(); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+func TestParseInfluxLine(t *testing.T) {
+	row, err := ParseInfluxLine([]byte(
+		`cpu\,load,host=server\ 01,region=us\=west idle=1.5,count=2i,size=3u,ok=t,bad=FALSE,msg="hello \"lindb\"" 1465839830100400200`))
+	assert.NoError(t, err)
+	assert.Equal(t, "cpu,load", string(row.Measurement))
+	assert.Len(t, row.Tags, 2)
+	assert.Equal(t, "host", string(row.Tags[0].Key))
+	assert.Equal(t, "server 01", string(row.Tags[0].Value))
+	assert.Equal(t, "us=west", string(row.Tags[1].Value))
+	assert.Len(t, row.Fields, 6)
+	assert.Equal(t, InfluxField{Key: []byte("idle"), Type: InfluxFieldTypeFloat, Value: 1.5, Raw: []byte("1.5")}, row.Fields[0])
+	assert.Equal(t, InfluxFieldTypeInteger, row.Fields[1].Type)
+	assert.Equal(t, float64(2), row.Fields[1].Value)
+	assert.Equal(t, InfluxFieldTypeUnsigned, row.Fields[2].Type)
+	assert.Equal(t, float64(3), row.Fields[2].Value)
+	assert.Equal(t, InfluxFieldTypeBoolean, row.Fields[3].Type)
+	assert.Equal(t, float64(1), row.Fields[3].Value)
+	assert.Equal(t, float64(0), row.Fields[4].Value)
+	assert.Equal(t, InfluxFieldTypeString, row.Fields[5].Type)
+	assert.Equal(t, `hello "lindb"`, string(row.Fields[5].Raw))
+	assert.Equal(t, int64(1465839830100400200), row.Timestamp)
+
+	row, err = ParseInfluxLine([]byte("cpu value=1"))
+	assert.NoError(t, err)
+	assert.Empty(t, row.Tags)
+	assert.Zero(t, row.Timestamp)
+}
+
+func TestParseInfluxLine_Error(t *testing.T) {
+	lines := []string{
+		"",
+		",host=a value=1",
+		"cpu,host value=1",
+		"cpu,host=a",
+		"cpu,host=a ",
+		"cpu =1",
+		"cpu value",
+		"cpu value=",
+		"cpu value=1,",
+		`cpu value="abc`,
+		"cpu value=abc",
+		"cpu value=1.1i",
+		"cpu value=-1u",
+		"cpu value=1 abc",
+	}
+	for _, line := range lines {
+		_, err := ParseInfluxLine([]byte(line))
+		assert.Error(t, err, line)
+	}
+}
+
+func TestInfluxRow_Parse_Reuse(t *testing.T) {
+	line := []byte(`cpu,host=server\ 01 idle=1.5,count=2i 1465839830100400200`)
+	row := &InfluxRow{}
+	assert.NoError(t, row.Parse(line))
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = row.Parse(line)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestInfluxLinesToRows(t *testing.T) {
+	data := []byte(`
+# comment
+cpu,host=a idle=1,requests_total=10i,msg="ignored" 1000000000
+mem,host=b used=2 2000000000`)
+	batch := series.NewBatchBuilder()
+	assert.NoError(t, InfluxLinesToRows(data, "ns", 0, batch))
+	assert.Equal(t, 2, batch.Rows())
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, "cpu", string(itr.Name()))
+	assert.Equal(t, int64(1000), itr.Timestamp())
+	assert.Equal(t, 2, itr.SimpleFieldsLen())
+	name, fieldType, _ := itr.SimpleField(0)
+	assert.Equal(t, "idle", string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	// no type hints, last by default
+	name, fieldType, value := itr.SimpleField(1)
+	assert.Equal(t, "requests_total", string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, float64(10), value)
+	assert.True(t, itr.Next())
+	assert.Equal(t, int64(2000), itr.Timestamp())
+	assert.False(t, itr.Next())
+
+	batch.Reset()
+	assert.NoError(t, InfluxLinesToRows([]byte("cpu idle=1 1"), "ns", time.Second, batch))
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, int64(1000), itr.Timestamp())
+}
+
+func TestInfluxLinesToRows_FieldTypePolicies(t *testing.T) {
+	policies, err := series.NewFieldTypePolicies([]series.FieldTypePolicy{{Namespace: "ns", Metric: "cpu", FieldType: "Max"}})
+	assert.NoError(t, err)
+	batch := series.NewBatchBuilder(series.WithFieldTypePolicies(policies))
+	assert.NoError(t, InfluxLinesToRows([]byte("cpu idle=1,requests_total=1\nmem used=1"), "ns", 0, batch))
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	_, fieldType, _ := itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMax, fieldType)
+	_, fieldType, _ = itr.SimpleField(1)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMax, fieldType)
+	// no policy matched
+	assert.True(t, itr.Next())
+	_, fieldType, _ = itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+
+	// opt in delta sum by field name
+	policies, err = series.NewFieldTypePolicies([]series.FieldTypePolicy{{Field: "*_total", FieldType: "DeltaSum"}})
+	assert.NoError(t, err)
+	batch = series.NewBatchBuilder(series.WithFieldTypePolicies(policies))
+	assert.NoError(t, InfluxLinesToRows([]byte("cpu idle=1,requests_total=1,latency_sum=1"), "ns", 0, batch))
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	_, fieldType, _ = itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	_, fieldType, _ = itr.SimpleField(1)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldType)
+	_, fieldType, _ = itr.SimpleField(2)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+}
+
+func TestInfluxLinesToRows_Error(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	assert.Error(t, InfluxLinesToRows([]byte("cpu idle"), "ns", 0, batch))
+	assert.Error(t, InfluxLinesToRows([]byte(`cpu,host= idle=1`), "ns", 0, batch))
+	assert.Error(t, InfluxLinesToRows([]byte(`cpu idle=+Inf`), "ns", 0, batch))
+	assert.Error(t, InfluxLinesToRows([]byte(`cpu msg="only string"`), "ns", 0, batch))
+	assert.Zero(t, batch.Rows())
+
+	// rows of failed payload are discarded
+	assert.NoError(t, InfluxLinesToRows([]byte("before idle=1"), "ns", 0, batch))
+	assert.Error(t, InfluxLinesToRows([]byte("cpu idle=1\nmem used=1\ncpu idle"), "ns", 0, batch))
+	assert.Equal(t, 1, batch.Rows())
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "before", string(itr.Name()))
+	assert.False(t, itr.Next())
+}
@@ -0,0 +1,376 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+// OTLPDefaultFieldName is the field name for sum/gauge data point value.
+const OTLPDefaultFieldName = "value"
+
+// OTLPOptions represents the options of converting otlp metrics to flat metrics.
+type OTLPOptions struct {
+	// Namespace is the namespace of all converted metrics.
+	Namespace string
+	// FlattenResource adds resource attributes into tags of each data point.
+	FlattenResource bool
+	// ResourceAttributeKeys limits which resource attributes are flattened, all if empty.
+	ResourceAttributeKeys []string
+	// ResourceAttributePrefix is the prefix of tag key for flattened resource attribute, e.g. "resource.".
+	ResourceAttributePrefix string
+	// TagKeyMapping renames attribute key to tag key, e.g. "service.name" => "service".
+	TagKeyMapping map[string]string
+	// DropAttributeKeys are the attributes which are not converted into tags.
+	DropAttributeKeys []string
+	// CumulativeConverter converts cumulative histograms to delta histograms,
+	// cumulative histograms are ignored if not set.
+	CumulativeConverter *series.CumulativeConverter
+}
+
+// OTLPConverter converts otlp metrics to flat metrics, attributes with empty value are ignored.
+//   - Gauge => simple field(Last)
+//   - Sum(delta) => simple field(DeltaSum), Sum(cumulative) => simple field(Last)
+//   - Histogram(delta) => compound field, explicit bounds are appended with +Inf
+//   - Histogram(cumulative) => compound field converted to delta by OTLPOptions.CumulativeConverter,
+//     ignored if the converter isn't set
+//   - Summary => summary field with pre-computed quantiles
+//
+// Exponential histogram is not supported and ignored.
+type OTLPConverter struct {
+	options       OTLPOptions
+	resourceKeys  map[string]struct{}
+	dropKeys      map[string]struct{}
+	resourceAttrs []otlpTag
+	tags          []otlpTag
+	txn           *series.CumulativeTxn // observations of current request
+}
+
+// otlpTag represents a converted tag.
+type otlpTag struct {
+	key   string
+	value string
+}
+
+// NewOTLPConverter creates an otlp converter with options.
+func NewOTLPConverter(options OTLPOptions) *OTLPConverter {
+	c := &OTLPConverter{
+		options:  options,
+		dropKeys: make(map[string]struct{}),
+	}
+	if len(options.ResourceAttributeKeys) > 0 {
+		c.resourceKeys = make(map[string]struct{})
+		for _, key := range options.ResourceAttributeKeys {
+			c.resourceKeys[key] = struct{}{}
+		}
+	}
+	for _, key := range options.DropAttributeKeys {
+		c.dropKeys[key] = struct{}{}
+	}
+	return c
+}
+
+// OTLPMetricsToRows decodes otlp MetricsData protobuf bytes, then converts it to flat metrics into the batch builder.
+func OTLPMetricsToRows(data []byte, options OTLPOptions, batch *series.BatchBuilder) error {
+	metricsData := &metricsv1.MetricsData{}
+	if err := proto.Unmarshal(data, metricsData); err != nil {
+		return fmt.Errorf("decode otlp metrics data failure: %w", err)
+	}
+	return NewOTLPConverter(options).Convert(metricsData, batch)
+}
+
+// Convert converts the otlp metrics data into flat metrics.
+// The rows of metrics data are discarded from batch and the observations of cumulative histograms
+// aren't kept by the cumulative converter if any data point is invalid.
+func (c *OTLPConverter) Convert(data *metricsv1.MetricsData, batch *series.BatchBuilder) error {
+	mark := batch.Mark()
+	if c.options.CumulativeConverter != nil {
+		c.txn = c.options.CumulativeConverter.Begin()
+		defer func() {
+			c.txn = nil
+		}()
+	}
+	if err := c.convert(data, batch); err != nil {
+		batch.Rollback(mark)
+		return err
+	}
+	if c.txn != nil {
+		c.txn.Commit()
+	}
+	return nil
+}
+
+// convert converts the otlp metrics data into the batch.
+func (c *OTLPConverter) convert(data *metricsv1.MetricsData, batch *series.BatchBuilder) error {
+	for _, rm := range data.GetResourceMetrics() {
+		c.resourceAttrs = c.resourceAttrs[:0]
+		if c.options.FlattenResource {
+			for _, kv := range rm.GetResource().GetAttributes() {
+				if c.resourceKeys != nil {
+					if _, ok := c.resourceKeys[kv.GetKey()]; !ok {
+						continue
+					}
+				}
+				if tag, ok := c.toTag(kv, c.options.ResourceAttributePrefix); ok {
+					c.resourceAttrs = append(c.resourceAttrs, tag)
+				}
+			}
+		}
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, metric := range sm.GetMetrics() {
+				if err := c.convertMetric(metric, batch); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// convertMetric converts all data points of the metric.
+func (c *OTLPConverter) convertMetric(metric *metricsv1.Metric, batch *series.BatchBuilder) error {
+	switch data := metric.GetData().(type) {
+	case *metricsv1.Metric_Gauge:
+		for _, dp := range data.Gauge.GetDataPoints() {
+			if err := c.convertNumber(metric.GetName(), dp, flatMetricsV1.SimpleFieldTypeLast, batch); err != nil {
+				return err
+			}
+		}
+	case *metricsv1.Metric_Sum:
+		fieldType := flatMetricsV1.SimpleFieldTypeLast
+		if data.Sum.GetAggregationTemporality() == metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			fieldType = flatMetricsV1.SimpleFieldTypeDeltaSum
+		}
+		for _, dp := range data.Sum.GetDataPoints() {
+			if err := c.convertNumber(metric.GetName(), dp, fieldType, batch); err != nil {
+				return err
+			}
+		}
+	case *metricsv1.Metric_Histogram:
+		temporality := data.Histogram.GetAggregationTemporality()
+		switch {
+		case temporality == metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA:
+		case temporality == metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE &&
+			c.options.CumulativeConverter != nil:
+		default:
+			// cumulative counts cannot be summed as compound field
+			return nil
+		}
+		for _, dp := range data.Histogram.GetDataPoints() {
+			if err := c.convertHistogram(metric.GetName(), temporality, dp, batch); err != nil {
+				return err
+			}
+		}
+	case *metricsv1.Metric_Summary:
+		for _, dp := range data.Summary.GetDataPoints() {
+			if err := c.convertSummary(metric.GetName(), dp, batch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertNumber converts number data point as a row with simple field.
+func (c *OTLPConverter) convertNumber(name string, dp *metricsv1.NumberDataPoint,
+	fieldType flatMetricsV1.SimpleFieldType, batch *series.BatchBuilder,
+) error {
+	if otlpNoRecordedValue(dp.GetFlags()) {
+		return nil
+	}
+	var value float64
+	switch v := dp.GetValue().(type) {
+	case *metricsv1.NumberDataPoint_AsInt:
+		value = float64(v.AsInt)
+	case *metricsv1.NumberDataPoint_AsDouble:
+		value = v.AsDouble
+	default:
+		return nil
+	}
+	rb := batch.RowBuilder()
+	if err := c.fillRow(rb, name, dp.GetAttributes(), dp.GetTimeUnixNano()); err != nil {
+		rb.Reset()
+		return err
+	}
+	if err := rb.AddSimpleField([]byte(OTLPDefaultFieldName), fieldType, value); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid otlp data point of metric: %s, %w", name, err)
+	}
+	return batch.Commit()
+}
+
+// convertHistogram converts histogram data point as a row with compound field,
+// cumulative data point is converted to delta by cumulative converter.
+func (c *OTLPConverter) convertHistogram(name string, temporality metricsv1.AggregationTemporality,
+	dp *metricsv1.HistogramDataPoint, batch *series.BatchBuilder,
+) error {
+	if otlpNoRecordedValue(dp.GetFlags()) || len(dp.GetBucketCounts()) == 0 {
+		return nil
+	}
+	if len(dp.GetBucketCounts()) != len(dp.GetExplicitBounds())+1 {
+		return fmt.Errorf("invalid otlp histogram: %s, bucket counts: %d, explicit bounds: %d",
+			name, len(dp.GetBucketCounts()), len(dp.GetExplicitBounds()))
+	}
+	bounds := make([]float64, 0, len(dp.GetBucketCounts()))
+	bounds = append(bounds, dp.GetExplicitBounds()...)
+	bounds = append(bounds, math.Inf(1))
+	values := make([]float64, len(dp.GetBucketCounts()))
+	for idx, count := range dp.GetBucketCounts() {
+		values[idx] = float64(count)
+	}
+	minValue, maxValue, sum, count := dp.GetMin(), dp.GetMax(), dp.GetSum(), float64(dp.GetCount())
+	rb := batch.RowBuilder()
+	if err := c.fillRow(rb, name, dp.GetAttributes(), dp.GetTimeUnixNano()); err != nil {
+		rb.Reset()
+		return err
+	}
+	if temporality == metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		delta, ok := c.txn.Convert(c.seriesHash(name), int64(dp.GetTimeUnixNano()/1e6),
+			bounds, values, sum, count)
+		if !ok {
+			rb.Reset()
+			return nil
+		}
+		// min/max of the delta interval are unknown
+		values, minValue, maxValue, sum, count = delta.Values, 0, 0, delta.Sum, delta.Count
+	}
+	if err := rb.AddCompoundFieldData(values, bounds); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid otlp histogram: %s, %w", name, err)
+	}
+	if err := rb.AddCompoundFieldMMSC(minValue, maxValue, sum, count); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid otlp histogram: %s, %w", name, err)
+	}
+	return batch.Commit()
+}
+
+// convertSummary converts summary data point as a row with summary field, quantiles are sorted in increasing order.
+func (c *OTLPConverter) convertSummary(name string, dp *metricsv1.SummaryDataPoint, batch *series.BatchBuilder) error {
+	if otlpNoRecordedValue(dp.GetFlags()) || len(dp.GetQuantileValues()) == 0 {
+		return nil
+	}
+	quantileValues := make([]*metricsv1.SummaryDataPoint_ValueAtQuantile, len(dp.GetQuantileValues()))
+	copy(quantileValues, dp.GetQuantileValues())
+	sort.Slice(quantileValues, func(i, j int) bool {
+		return quantileValues[i].GetQuantile() < quantileValues[j].GetQuantile()
+	})
+	quantiles := make([]float64, len(quantileValues))
+	values := make([]float64, len(quantileValues))
+	for idx, qv := range quantileValues {
+		quantiles[idx] = qv.GetQuantile()
+		values[idx] = qv.GetValue()
+	}
+	rb := batch.RowBuilder()
+	if err := c.fillRow(rb, name, dp.GetAttributes(), dp.GetTimeUnixNano()); err != nil {
+		rb.Reset()
+		return err
+	}
+	if err := rb.AddSummaryField([]byte(OTLPDefaultFieldName), float64(dp.GetCount()), dp.GetSum(), quantiles, values); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid otlp summary: %s, %w", name, err)
+	}
+	return batch.Commit()
+}
+
+// fillRow fills metric name/namespace/tags/timestamp of the row.
+func (c *OTLPConverter) fillRow(rb *series.RowBuilder, name string, attributes []*commonv1.KeyValue, timeUnixNano uint64) error {
+	rb.AddNameSpace([]byte(c.options.Namespace))
+	rb.AddMetricName([]byte(name))
+	rb.AddTimestamp(int64(timeUnixNano / 1e6))
+	c.tags = append(c.tags[:0], c.resourceAttrs...)
+	for _, kv := range attributes {
+		if tag, ok := c.toTag(kv, ""); ok {
+			c.tags = append(c.tags, tag)
+		}
+	}
+	for _, tag := range c.tags {
+		if err := rb.AddTag([]byte(tag.key), []byte(tag.value)); err != nil {
+			return fmt.Errorf("invalid otlp attribute of metric: %s, %w", name, err)
+		}
+	}
+	return nil
+}
+
+// seriesHash returns the hash of namespace/metric name and tags of current row, tags are sorted by key.
+func (c *OTLPConverter) seriesHash(name string) uint64 {
+	sort.Slice(c.tags, func(i, j int) bool {
+		return c.tags[i].key < c.tags[j].key
+	})
+	h := xxhash.New()
+	_, _ = h.WriteString(c.options.Namespace)
+	_, _ = h.WriteString("|")
+	_, _ = h.WriteString(name)
+	for _, tag := range c.tags {
+		_, _ = h.WriteString(",")
+		_, _ = h.WriteString(tag.key)
+		_, _ = h.WriteString("=")
+		_, _ = h.WriteString(tag.value)
+	}
+	return h.Sum64()
+}
+
+// toTag converts attribute to tag based on mapping rules, returns false if attribute is dropped, empty or not scalar value.
+func (c *OTLPConverter) toTag(kv *commonv1.KeyValue, prefix string) (otlpTag, bool) {
+	key := kv.GetKey()
+	if _, ok := c.dropKeys[key]; ok {
+		return otlpTag{}, false
+	}
+	value, ok := otlpAttributeValue(kv.GetValue())
+	if !ok || value == "" {
+		return otlpTag{}, false
+	}
+	if mapping, ok := c.options.TagKeyMapping[key]; ok {
+		key = mapping
+	}
+	return otlpTag{key: prefix + key, value: value}, true
+}
+
+// otlpAttributeValue returns the string value of scalar attribute, array/kvlist value is not supported.
+func otlpAttributeValue(value *commonv1.AnyValue) (string, bool) {
+	switch v := value.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue, true
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue), true
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10), true
+	case *commonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'f', -1, 64), true
+	case *commonv1.AnyValue_BytesValue:
+		return hex.EncodeToString(v.BytesValue), true
+	default:
+		return "", false
+	}
+}
+
+func otlpNoRecordedValue(flags uint32) bool {
+	return flags&uint32(metricsv1.DataPointFlags_FLAG_NO_RECORDED_VALUE) != 0
+}
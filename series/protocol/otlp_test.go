@@ -0,0 +1,393 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+const otlpDelta = metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+func otlpStringAttr(key, value string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: key, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpHistogram(temporality metricsv1.AggregationTemporality,
+	dataPoints ...*metricsv1.HistogramDataPoint,
+) *metricsv1.Metric_Histogram {
+	return &metricsv1.Metric_Histogram{Histogram: &metricsv1.Histogram{
+		AggregationTemporality: temporality,
+		DataPoints:             dataPoints,
+	}}
+}
+
+func otlpTags(itr *series.RowIterator) map[string]string {
+	tags := make(map[string]string)
+	for i := 0; i < itr.TagsLen(); i++ {
+		key, value := itr.Tag(i)
+		tags[string(key)] = string(value)
+	}
+	return tags
+}
+
+func newOTLPMetricsData() *metricsv1.MetricsData {
+	sum := 1.5
+	min := 0.1
+	max := 0.9
+	return &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{
+			otlpStringAttr("service.name", "api"),
+			otlpStringAttr("host.name", "host1"),
+		}},
+		ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: []*metricsv1.Metric{{
+			Name: "memory",
+			Data: &metricsv1.Metric_Gauge{Gauge: &metricsv1.Gauge{DataPoints: []*metricsv1.NumberDataPoint{{
+				Attributes: []*commonv1.KeyValue{
+					otlpStringAttr("type", "heap"),
+					{Key: "pid", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 10}}},
+					{Key: "gc", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_BoolValue{BoolValue: true}}},
+					{Key: "ratio", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_DoubleValue{DoubleValue: 0.5}}},
+					{Key: "id", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_BytesValue{BytesValue: []byte{0xab}}}},
+					{Key: "list", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_ArrayValue{}}},
+					otlpStringAttr("drop", "x"),
+				},
+				TimeUnixNano: 2_000_000_000,
+				Value:        &metricsv1.NumberDataPoint_AsDouble{AsDouble: 10.5},
+			}, {
+				TimeUnixNano: 3_000_000_000,
+				Flags:        uint32(metricsv1.DataPointFlags_FLAG_NO_RECORDED_VALUE),
+				Value:        &metricsv1.NumberDataPoint_AsDouble{AsDouble: 1},
+			}, {
+				// no value
+				TimeUnixNano: 3_000_000_000,
+			}}}},
+		}, {
+			Name: "requests",
+			Data: &metricsv1.Metric_Sum{Sum: &metricsv1.Sum{
+				AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+				DataPoints: []*metricsv1.NumberDataPoint{{
+					TimeUnixNano: 2_000_000_000,
+					Value:        &metricsv1.NumberDataPoint_AsInt{AsInt: 5},
+				}},
+			}},
+		}, {
+			Name: "requests_total",
+			Data: &metricsv1.Metric_Sum{Sum: &metricsv1.Sum{
+				AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				DataPoints: []*metricsv1.NumberDataPoint{{
+					TimeUnixNano: 2_000_000_000,
+					Value:        &metricsv1.NumberDataPoint_AsInt{AsInt: 50},
+				}},
+			}},
+		}, {
+			Name: "latency",
+			Data: otlpHistogram(otlpDelta, &metricsv1.HistogramDataPoint{
+				TimeUnixNano:   2_000_000_000,
+				Count:          4,
+				Sum:            &sum,
+				Min:            &min,
+				Max:            &max,
+				ExplicitBounds: []float64{0.5, 1},
+				BucketCounts:   []uint64{1, 3, 0},
+			}, &metricsv1.HistogramDataPoint{
+				// empty buckets
+				TimeUnixNano: 2_000_000_000,
+			}),
+		}, {
+			Name: "rpc",
+			Data: &metricsv1.Metric_Summary{Summary: &metricsv1.Summary{DataPoints: []*metricsv1.SummaryDataPoint{{
+				TimeUnixNano: 2_000_000_000,
+				Count:        10,
+				Sum:          20,
+				QuantileValues: []*metricsv1.SummaryDataPoint_ValueAtQuantile{
+					{Quantile: 0.99, Value: 5}, {Quantile: 0.5, Value: 1},
+				},
+			}, {
+				// empty quantiles
+				TimeUnixNano: 2_000_000_000,
+			}}}},
+		}, {
+			// not supported
+			Name: "exponential_histogram",
+			Data: &metricsv1.Metric_ExponentialHistogram{ExponentialHistogram: &metricsv1.ExponentialHistogram{}},
+		}}}},
+	}}}
+}
+
+func TestOTLPMetricsToRows(t *testing.T) {
+	data, err := proto.Marshal(newOTLPMetricsData())
+	assert.NoError(t, err)
+	batch := series.NewBatchBuilder()
+	assert.NoError(t, OTLPMetricsToRows(data, OTLPOptions{
+		Namespace:               "ns",
+		FlattenResource:         true,
+		ResourceAttributeKeys:   []string{"service.name"},
+		ResourceAttributePrefix: "resource.",
+		TagKeyMapping:           map[string]string{"service.name": "service", "type": "kind"},
+		DropAttributeKeys:       []string{"drop"},
+	}, batch))
+	assert.Equal(t, 5, batch.Rows())
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, "memory", string(itr.Name()))
+	assert.Equal(t, int64(2000), itr.Timestamp())
+	assert.Equal(t, map[string]string{
+		"resource.service": "api",
+		"kind":             "heap",
+		"pid":              "10",
+		"gc":               "true",
+		"ratio":            "0.5",
+		"id":               "ab",
+	}, otlpTags(itr))
+	name, fieldType, value := itr.SimpleField(0)
+	assert.Equal(t, OTLPDefaultFieldName, string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, 10.5, value)
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "requests", string(itr.Name()))
+	_, fieldType, value = itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fieldType)
+	assert.Equal(t, float64(5), value)
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "requests_total", string(itr.Name()))
+	_, fieldType, value = itr.SimpleField(0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, float64(50), value)
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "latency", string(itr.Name()))
+	assert.Equal(t, map[string]string{"resource.service": "api"}, otlpTags(itr))
+	assert.True(t, itr.HasCompoundField())
+	min, max, sum, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, 0.1, min)
+	assert.Equal(t, 0.9, max)
+	assert.Equal(t, 1.5, sum)
+	assert.Equal(t, float64(4), count)
+	var bounds, values []float64
+	for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+		bound, v := itr.CompoundFieldBucket(i)
+		bounds = append(bounds, bound)
+		values = append(values, v)
+	}
+	assert.Equal(t, []float64{0.5, 1, math.Inf(1)}, bounds)
+	assert.Equal(t, []float64{1, 3, 0}, values)
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "rpc", string(itr.Name()))
+	assert.Equal(t, 1, itr.SummaryFieldsLen())
+	name, count, sum, quantiles := itr.SummaryField(0)
+	assert.Equal(t, OTLPDefaultFieldName, string(name))
+	assert.Equal(t, float64(10), count)
+	assert.Equal(t, float64(20), sum)
+	assert.Equal(t, 2, quantiles)
+	q, v := itr.SummaryFieldQuantile(0)
+	assert.Equal(t, []float64{0.5, 1}, []float64{q, v})
+	q, v = itr.SummaryFieldQuantile(1)
+	assert.Equal(t, []float64{0.99, 5}, []float64{q, v})
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+}
+
+func TestOTLPConverter_Resource(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	data := newOTLPMetricsData()
+	// flatten all resource attributes
+	assert.NoError(t, NewOTLPConverter(OTLPOptions{Namespace: "ns", FlattenResource: true}).Convert(data, batch))
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	tags := otlpTags(itr)
+	assert.Equal(t, "api", tags["service.name"])
+	assert.Equal(t, "host1", tags["host.name"])
+
+	// not flatten resource attributes
+	batch.Reset()
+	assert.NoError(t, NewOTLPConverter(OTLPOptions{Namespace: "ns"}).Convert(data, batch))
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	tags = otlpTags(itr)
+	assert.NotContains(t, tags, "service.name")
+	assert.Equal(t, "x", tags["drop"])
+}
+
+func TestOTLPConverter_Error(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	assert.Error(t, OTLPMetricsToRows([]byte{0xff}, OTLPOptions{}, batch))
+
+	newData := func(metric *metricsv1.Metric) *metricsv1.MetricsData {
+		return &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+			ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: []*metricsv1.Metric{metric}}},
+		}}}
+	}
+	badSum := -1.0
+	cases := []*metricsv1.Metric{
+		// invalid value
+		{Name: "sum", Data: &metricsv1.Metric_Sum{Sum: &metricsv1.Sum{DataPoints: []*metricsv1.NumberDataPoint{{
+			Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: math.Inf(1)},
+		}}}}},
+		// bucket counts not match bounds
+		{Name: "histogram", Data: otlpHistogram(otlpDelta, &metricsv1.HistogramDataPoint{
+			BucketCounts:   []uint64{1},
+			ExplicitBounds: []float64{1},
+		})},
+		// invalid bounds
+		{Name: "histogram", Data: otlpHistogram(otlpDelta, &metricsv1.HistogramDataPoint{
+			BucketCounts:   []uint64{1, 1, 1},
+			ExplicitBounds: []float64{2, 1},
+		})},
+		// invalid mmsc
+		{Name: "histogram", Data: otlpHistogram(otlpDelta, &metricsv1.HistogramDataPoint{
+			Sum:          &badSum,
+			BucketCounts: []uint64{1},
+		})},
+		// invalid quantile
+		{Name: "summary", Data: &metricsv1.Metric_Summary{Summary: &metricsv1.Summary{DataPoints: []*metricsv1.SummaryDataPoint{{
+			QuantileValues: []*metricsv1.SummaryDataPoint_ValueAtQuantile{{Quantile: 2, Value: 1}},
+		}}}}},
+	}
+	for _, metric := range cases {
+		assert.Error(t, NewOTLPConverter(OTLPOptions{Namespace: "ns"}).Convert(newData(metric), batch))
+	}
+	assert.Zero(t, batch.Rows())
+}
+
+func TestOTLPConverter_EmptyAttribute(t *testing.T) {
+	data := &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{otlpStringAttr("zone", "")}},
+		ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: []*metricsv1.Metric{{
+			Name: "gauge",
+			Data: &metricsv1.Metric_Gauge{Gauge: &metricsv1.Gauge{DataPoints: []*metricsv1.NumberDataPoint{{
+				Attributes: []*commonv1.KeyValue{otlpStringAttr("host", ""), otlpStringAttr("type", "heap")},
+				Value:      &metricsv1.NumberDataPoint_AsDouble{AsDouble: 1},
+			}}}},
+		}}}},
+	}}}
+	batch := series.NewBatchBuilder()
+	assert.NoError(t, NewOTLPConverter(OTLPOptions{Namespace: "ns", FlattenResource: true}).Convert(data, batch))
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, map[string]string{"type": "heap"}, otlpTags(itr))
+}
+
+func TestOTLPConverter_Rollback(t *testing.T) {
+	data := &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+		ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: []*metricsv1.Metric{{
+			Name: "gauge",
+			Data: &metricsv1.Metric_Gauge{Gauge: &metricsv1.Gauge{DataPoints: []*metricsv1.NumberDataPoint{{
+				Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: 1},
+			}, {
+				Value: &metricsv1.NumberDataPoint_AsDouble{AsDouble: math.Inf(1)},
+			}}}},
+		}}}},
+	}}}
+	batch := series.NewBatchBuilder()
+	assert.Error(t, NewOTLPConverter(OTLPOptions{Namespace: "ns"}).Convert(data, batch))
+	assert.Zero(t, batch.Rows())
+	assert.Zero(t, batch.Size())
+}
+
+func TestOTLPConverter_HistogramTemporality(t *testing.T) {
+	newData := func(temporality metricsv1.AggregationTemporality, timeUnixNano uint64, counts []uint64, sum float64) *metricsv1.MetricsData {
+		var count uint64
+		for _, c := range counts {
+			count += c
+		}
+		return &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+			ScopeMetrics: []*metricsv1.ScopeMetrics{{Metrics: []*metricsv1.Metric{{
+				Name: "latency",
+				Data: otlpHistogram(temporality, &metricsv1.HistogramDataPoint{
+					Attributes:     []*commonv1.KeyValue{otlpStringAttr("host", "h1")},
+					TimeUnixNano:   timeUnixNano,
+					Count:          count,
+					Sum:            &sum,
+					ExplicitBounds: []float64{1},
+					BucketCounts:   counts,
+				}),
+			}}}},
+		}}}
+	}
+	bucketValues := func(itr *series.RowIterator) (values []float64) {
+		for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+			_, v := itr.CompoundFieldBucket(i)
+			values = append(values, v)
+		}
+		return values
+	}
+	cumulative := metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	batch := series.NewBatchBuilder()
+
+	// delta histogram is kept as is
+	assert.NoError(t, NewOTLPConverter(OTLPOptions{}).Convert(newData(otlpDelta, 1e9, []uint64{1, 2}, 3), batch))
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, []float64{1, 2}, bucketValues(itr))
+
+	// cumulative/unspecified histograms are ignored without cumulative converter
+	batch.Reset()
+	converter := NewOTLPConverter(OTLPOptions{})
+	assert.NoError(t, converter.Convert(newData(cumulative, 1e9, []uint64{1, 2}, 3), batch))
+	assert.NoError(t, converter.Convert(newData(metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED,
+		1e9, []uint64{1, 2}, 3), batch))
+	assert.Zero(t, batch.Rows())
+
+	// cumulative histogram is converted to delta
+	converter = NewOTLPConverter(OTLPOptions{CumulativeConverter: series.NewCumulativeConverter()})
+	assert.NoError(t, converter.Convert(newData(cumulative, 1e9, []uint64{1, 2}, 3), batch))
+	assert.Zero(t, batch.Rows())
+	assert.NoError(t, converter.Convert(newData(cumulative, 2e9, []uint64{4, 3}, 10), batch))
+	assert.Equal(t, 1, batch.Rows())
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, map[string]string{"host": "h1"}, otlpTags(itr))
+	assert.Equal(t, []float64{3, 1}, bucketValues(itr))
+	minValue, maxValue, sum, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, []float64{0, 0, 7, 4}, []float64{minValue, maxValue, sum, count})
+
+	// rejected export doesn't move the base of histograms
+	data := newData(cumulative, 3e9, []uint64{6, 4}, 12)
+	scope := data.ResourceMetrics[0].ScopeMetrics[0]
+	scope.Metrics = append(scope.Metrics, &metricsv1.Metric{
+		Name: "up",
+		Data: &metricsv1.Metric_Gauge{Gauge: &metricsv1.Gauge{DataPoints: []*metricsv1.NumberDataPoint{{
+			TimeUnixNano: 3e9,
+			Value:        &metricsv1.NumberDataPoint_AsDouble{AsDouble: math.Inf(1)},
+		}}}},
+	})
+	batch.Reset()
+	assert.Error(t, converter.Convert(data, batch))
+	assert.Zero(t, batch.Rows())
+	assert.NoError(t, converter.Convert(newData(cumulative, 4e9, []uint64{7, 5}, 14), batch))
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, []float64{3, 2}, bucketValues(itr))
+	_, _, sum, count = itr.CompoundFieldMMSC()
+	assert.Equal(t, []float64{4, 5}, []float64{sum, count})
+}
@@ -0,0 +1,504 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/golang/snappy"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+const (
+	promMetricNameLabel = "__name__"
+	promBucketLabel     = "le"
+	promBucketSuffix    = "_bucket"
+	promSumSuffix       = "_sum"
+	promCountSuffix     = "_count"
+	promTotalSuffix     = "_total"
+
+	// PromDefaultFieldName is the field name for prometheus sample value.
+	PromDefaultFieldName = "value"
+)
+
+// PromMetricType represents the metric type of prometheus metadata.
+type PromMetricType int32
+
+const (
+	PromMetricTypeUnknown        PromMetricType = 0
+	PromMetricTypeCounter        PromMetricType = 1
+	PromMetricTypeGauge          PromMetricType = 2
+	PromMetricTypeHistogram      PromMetricType = 3
+	PromMetricTypeGaugeHistogram PromMetricType = 4
+	PromMetricTypeSummary        PromMetricType = 5
+	PromMetricTypeInfo           PromMetricType = 6
+	PromMetricTypeStateSet       PromMetricType = 7
+)
+
+// PromLabel represents a label of prometheus time series.
+type PromLabel struct {
+	Name  string
+	Value string
+}
+
+// PromSample represents a sample of prometheus time series.
+type PromSample struct {
+	Value     float64
+	Timestamp int64 // in milliseconds
+}
+
+// PromTimeSeries represents a prometheus time series with labels and samples.
+type PromTimeSeries struct {
+	Labels  []PromLabel
+	Samples []PromSample
+}
+
+// PromMetricMetadata represents the metadata of prometheus metric family.
+type PromMetricMetadata struct {
+	Type             PromMetricType
+	MetricFamilyName string
+	Help             string
+	Unit             string
+}
+
+// PromWriteRequest represents prometheus remote-write request.
+type PromWriteRequest struct {
+	TimeSeries []PromTimeSeries
+	Metadata   []PromMetricMetadata
+}
+
+// DecodePromRemoteWrite decodes snappy-compressed prometheus remote-write request.
+func DecodePromRemoteWrite(compressed []byte) (*PromWriteRequest, error) {
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompress prometheus write request failure: %w", err)
+	}
+	req := &PromWriteRequest{}
+	if err := decodeMessage(data, func(field *protoField) error {
+		switch field.num {
+		case 1:
+			ts := PromTimeSeries{}
+			if err := decodeTimeSeries(field.bytes, &ts); err != nil {
+				return err
+			}
+			req.TimeSeries = append(req.TimeSeries, ts)
+		case 3:
+			metadata := PromMetricMetadata{}
+			if err := decodeMetadata(field.bytes, &metadata); err != nil {
+				return err
+			}
+			req.Metadata = append(req.Metadata, metadata)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("decode prometheus write request failure: %w", err)
+	}
+	return req, nil
+}
+
+// PromRemoteWriteToRows decodes snappy-compressed prometheus remote-write request,
+// then converts it to flat metrics into the batch builder. Histograms are ignored because the cumulative
+// state isn't kept across requests, use PromConverter with SetCumulativeConverter for histograms.
+func PromRemoteWriteToRows(compressed []byte, namespace string, batch *series.BatchBuilder) error {
+	req, err := DecodePromRemoteWrite(compressed)
+	if err != nil {
+		return err
+	}
+	return NewPromConverter(namespace).Convert(req, batch)
+}
+
+// PromConverter converts prometheus time series to flat metrics, labels with empty value are
+// treated as absent(same as prometheus).
+//   - counter/gauge sample => simple field(Last), cumulative counter value is kept as is,
+//     so that the latest value of counter is queried and rate is computed at query time
+//   - unknown sample(without metadata) => simple field, whose type comes from the field type policies
+//     of row builder, Last if no policy matched
+//   - histogram(_bucket/_sum/_count) series => compound field with explicit bounds, the cumulative(over time)
+//     bucket counts are converted to delta by the cumulative converter, see SetCumulativeConverter,
+//     histograms are ignored if the converter isn't set(cumulative counts cannot be summed as compound field).
+//     Invalid histogram series(e.g. only +Inf bucket, negative sum) are skipped, see InvalidSeries.
+type PromConverter struct {
+	namespace     string
+	fieldName     string
+	cumulative    *series.CumulativeConverter
+	txn           *series.CumulativeTxn // observations of current request
+	invalidSeries int
+}
+
+// NewPromConverter creates a prometheus converter for the namespace.
+func NewPromConverter(namespace string) *PromConverter {
+	return &PromConverter{
+		namespace: namespace,
+		fieldName: PromDefaultFieldName,
+	}
+}
+
+// SetCumulativeConverter sets the converter for converting cumulative histograms to delta histograms,
+// which keeps the last observation of each histogram series across requests, nil means histograms are ignored.
+func (c *PromConverter) SetCumulativeConverter(converter *series.CumulativeConverter) {
+	c.cumulative = converter
+}
+
+// promHistogram holds the samples of a histogram series at a timestamp.
+type promHistogram struct {
+	name      string
+	labels    []PromLabel
+	timestamp int64
+	bounds    []float64
+	values    []float64 // cumulative bucket counts
+	sum       float64
+	count     float64
+}
+
+// InvalidSeries returns the number of skipped invalid histogram series.
+func (c *PromConverter) InvalidSeries() int {
+	return c.invalidSeries
+}
+
+// Convert converts the write request into flat metrics, NaN samples(staleness markers) are ignored.
+// The rows of request are discarded from batch and the observations of histograms aren't kept
+// by the cumulative converter if any series is invalid.
+func (c *PromConverter) Convert(req *PromWriteRequest, batch *series.BatchBuilder) error {
+	mark := batch.Mark()
+	if c.cumulative != nil {
+		c.txn = c.cumulative.Begin()
+		defer func() {
+			c.txn = nil
+		}()
+	}
+	if err := c.convert(req, batch); err != nil {
+		batch.Rollback(mark)
+		return err
+	}
+	if c.txn != nil {
+		c.txn.Commit()
+	}
+	return nil
+}
+
+// convert converts the write request into the batch.
+func (c *PromConverter) convert(req *PromWriteRequest, batch *series.BatchBuilder) error {
+	histogramFamilies := make(map[string]struct{})
+	typedFamilies := make(map[string]struct{})
+	for _, metadata := range req.Metadata {
+		if metadata.Type == PromMetricTypeHistogram || metadata.Type == PromMetricTypeGaugeHistogram {
+			histogramFamilies[metadata.MetricFamilyName] = struct{}{}
+		}
+		if metadata.Type != PromMetricTypeUnknown {
+			typedFamilies[metadata.MetricFamilyName] = struct{}{}
+		}
+	}
+	for idx := range req.TimeSeries {
+		name, _ := promMetricName(req.TimeSeries[idx].Labels)
+		if strings.HasSuffix(name, promBucketSuffix) && promHasLabel(req.TimeSeries[idx].Labels, promBucketLabel) {
+			histogramFamilies[strings.TrimSuffix(name, promBucketSuffix)] = struct{}{}
+		}
+	}
+
+	var (
+		histograms    = make(map[string]*promHistogram)
+		histogramKeys []string
+	)
+	for idx := range req.TimeSeries {
+		ts := &req.TimeSeries[idx]
+		name, ok := promMetricName(ts.Labels)
+		if !ok {
+			return fmt.Errorf("prometheus time series without metric name")
+		}
+		family, suffix := promHistogramFamily(name, histogramFamilies)
+		if family == "" {
+			if err := c.convertSimple(name, promIsTyped(name, typedFamilies), ts, batch); err != nil {
+				return err
+			}
+			continue
+		}
+		labels := promTagLabels(ts.Labels, suffix == promBucketSuffix)
+		var bound float64
+		if suffix == promBucketSuffix {
+			le, _ := promLabelValue(ts.Labels, promBucketLabel)
+			var err error
+			if bound, err = strconv.ParseFloat(le, 64); err != nil {
+				return fmt.Errorf("invalid prometheus histogram bucket le: %s, metric: %s", le, name)
+			}
+		}
+		for _, sample := range ts.Samples {
+			if math.IsNaN(sample.Value) {
+				continue
+			}
+			key := promSeriesKey(family, labels, sample.Timestamp)
+			histogram, exist := histograms[key]
+			if !exist {
+				histogram = &promHistogram{name: family, labels: labels, timestamp: sample.Timestamp}
+				histograms[key] = histogram
+				histogramKeys = append(histogramKeys, key)
+			}
+			switch suffix {
+			case promBucketSuffix:
+				histogram.bounds = append(histogram.bounds, bound)
+				histogram.values = append(histogram.values, sample.Value)
+			case promSumSuffix:
+				histogram.sum = sample.Value
+			case promCountSuffix:
+				histogram.count = sample.Value
+			}
+		}
+	}
+	for _, key := range histogramKeys {
+		if err := c.convertHistogram(histograms[key], batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertSimple converts each sample of the time series as a row with simple field,
+// the field type of untyped metric comes from the field type policies.
+func (c *PromConverter) convertSimple(name string, typed bool, ts *PromTimeSeries, batch *series.BatchBuilder) error {
+	for _, sample := range ts.Samples {
+		if math.IsNaN(sample.Value) {
+			continue
+		}
+		rb := batch.RowBuilder()
+		if err := c.fillRow(rb, name, ts.Labels, sample.Timestamp); err != nil {
+			rb.Reset()
+			return err
+		}
+		fieldType := flatMetricsV1.SimpleFieldTypeLast
+		if !typed {
+			fieldType = rb.DefaultFieldType([]byte(c.fieldName), fieldType)
+		}
+		if err := rb.AddSimpleField([]byte(c.fieldName), fieldType, sample.Value); err != nil {
+			rb.Reset()
+			return err
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertHistogram converts histogram buckets as a row with compound field.
+func (c *PromConverter) convertHistogram(histogram *promHistogram, batch *series.BatchBuilder) error {
+	if c.txn == nil || len(histogram.bounds) == 0 {
+		// no cumulative converter, or only _sum/_count without buckets
+		return nil
+	}
+	sort.Sort(promBuckets{bounds: histogram.bounds, values: histogram.values})
+	if !math.IsInf(histogram.bounds[len(histogram.bounds)-1], 1) {
+		histogram.bounds = append(histogram.bounds, math.Inf(1))
+		histogram.values = append(histogram.values, math.Max(histogram.count, histogram.values[len(histogram.values)-1]))
+	}
+	// cumulative counts => counts of each bucket
+	for i := len(histogram.values) - 1; i > 0; i-- {
+		histogram.values[i] = math.Max(histogram.values[i]-histogram.values[i-1], 0)
+	}
+	rb := batch.RowBuilder()
+	if err := c.fillRow(rb, histogram.name, histogram.labels, histogram.timestamp); err != nil {
+		rb.Reset()
+		return err
+	}
+	// validate the observation before it becomes the base of series, the delta of valid observation is valid
+	if rb.AddCompoundFieldData(histogram.values, histogram.bounds) != nil ||
+		rb.AddCompoundFieldMMSC(0, 0, histogram.sum, histogram.count) != nil {
+		rb.Reset()
+		c.invalidSeries++
+		return nil
+	}
+	seriesHash := xxhash.Sum64String(c.namespace + "|" + promSeriesID(histogram.name, histogram.labels))
+	delta, ok := c.txn.Convert(seriesHash, histogram.timestamp,
+		histogram.bounds, histogram.values, histogram.sum, histogram.count)
+	if !ok {
+		rb.Reset()
+		return nil
+	}
+	if err := rb.AddCompoundFieldData(delta.Values, histogram.bounds); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid prometheus histogram: %s, %w", histogram.name, err)
+	}
+	if err := rb.AddCompoundFieldMMSC(0, 0, delta.Sum, delta.Count); err != nil {
+		rb.Reset()
+		return fmt.Errorf("invalid prometheus histogram: %s, %w", histogram.name, err)
+	}
+	return batch.Commit()
+}
+
+// fillRow fills metric name/namespace/tags/timestamp of the row.
+func (c *PromConverter) fillRow(rb *series.RowBuilder, name string, labels []PromLabel, timestamp int64) error {
+	rb.AddNameSpace([]byte(c.namespace))
+	rb.AddMetricName([]byte(name))
+	rb.AddTimestamp(timestamp)
+	for _, label := range labels {
+		if label.Name == promMetricNameLabel || label.Value == "" {
+			continue
+		}
+		if err := rb.AddTag([]byte(label.Name), []byte(label.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promHistogramFamily returns the histogram family name and suffix if the metric is a part of histogram.
+func promHistogramFamily(name string, families map[string]struct{}) (family, suffix string) {
+	for _, s := range []string{promBucketSuffix, promSumSuffix, promCountSuffix} {
+		if !strings.HasSuffix(name, s) {
+			continue
+		}
+		family = strings.TrimSuffix(name, s)
+		if _, ok := families[family]; ok {
+			return family, s
+		}
+	}
+	return "", ""
+}
+
+// promIsTyped checks if the metric has type metadata, counter family name is without _total suffix.
+func promIsTyped(name string, typedFamilies map[string]struct{}) bool {
+	if _, ok := typedFamilies[name]; ok {
+		return true
+	}
+	_, ok := typedFamilies[strings.TrimSuffix(name, promTotalSuffix)]
+	return ok
+}
+
+// promMetricName returns the value of __name__ label.
+func promMetricName(labels []PromLabel) (string, bool) {
+	return promLabelValue(labels, promMetricNameLabel)
+}
+
+func promLabelValue(labels []PromLabel, name string) (string, bool) {
+	for _, label := range labels {
+		if label.Name == name {
+			return label.Value, true
+		}
+	}
+	return "", false
+}
+
+func promHasLabel(labels []PromLabel, name string) bool {
+	_, ok := promLabelValue(labels, name)
+	return ok
+}
+
+// promTagLabels returns non-empty labels except __name__(and le for histogram bucket), sorted by name.
+func promTagLabels(labels []PromLabel, excludeBucket bool) []PromLabel {
+	rs := make([]PromLabel, 0, len(labels))
+	for _, label := range labels {
+		if label.Name == promMetricNameLabel || label.Value == "" || (excludeBucket && label.Name == promBucketLabel) {
+			continue
+		}
+		rs = append(rs, label)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Name < rs[j].Name
+	})
+	return rs
+}
+
+// promSeriesKey returns the unique key of series at timestamp.
+func promSeriesKey(name string, labels []PromLabel, timestamp int64) string {
+	return promSeriesID(name, labels) + "@" + strconv.FormatInt(timestamp, 10)
+}
+
+// promSeriesID returns the unique id of series, labels are sorted by name.
+func promSeriesID(name string, labels []PromLabel) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, label := range labels {
+		sb.WriteByte(',')
+		sb.WriteString(label.Name)
+		sb.WriteByte('=')
+		sb.WriteString(label.Value)
+	}
+	return sb.String()
+}
+
+// promBuckets sorts histogram buckets by upper bound.
+type promBuckets struct {
+	bounds []float64
+	values []float64
+}
+
+func (b promBuckets) Len() int { return len(b.bounds) }
+
+func (b promBuckets) Less(i, j int) bool { return b.bounds[i] < b.bounds[j] }
+
+func (b promBuckets) Swap(i, j int) {
+	b.bounds[i], b.bounds[j] = b.bounds[j], b.bounds[i]
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+}
+
+func decodeTimeSeries(data []byte, ts *PromTimeSeries) error {
+	return decodeMessage(data, func(field *protoField) error {
+		switch field.num {
+		case 1:
+			label := PromLabel{}
+			if err := decodeMessage(field.bytes, func(field *protoField) error {
+				switch field.num {
+				case 1:
+					label.Name = string(field.bytes)
+				case 2:
+					label.Value = string(field.bytes)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, label)
+		case 2:
+			sample := PromSample{}
+			if err := decodeMessage(field.bytes, func(field *protoField) error {
+				switch field.num {
+				case 1:
+					sample.Value = field.Float64()
+				case 2:
+					sample.Timestamp = field.Int64()
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, sample)
+		}
+		return nil
+	})
+}
+
+func decodeMetadata(data []byte, metadata *PromMetricMetadata) error {
+	return decodeMessage(data, func(field *protoField) error {
+		switch field.num {
+		case 1:
+			metadata.Type = PromMetricType(field.value)
+		case 2:
+			metadata.MetricFamilyName = string(field.bytes)
+		case 4:
+			metadata.Help = string(field.bytes)
+		case 5:
+			metadata.Unit = string(field.bytes)
+		}
+		return nil
+	})
+}
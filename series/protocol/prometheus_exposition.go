@@ -0,0 +1,260 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/lindb/common/series"
+)
+
+// ErrPromFamilyConflict represents the metric family name(or sample name) is used by another family.
+var ErrPromFamilyConflict = errors.New("prometheus metric family conflict")
+
+// PromExpositionOptions represents the options of rendering flat metrics as prometheus text exposition.
+type PromExpositionOptions struct {
+	// NamespaceLabel adds namespace as label if not empty.
+	NamespaceLabel string
+	// WithTimestamp adds timestamp of row to each sample.
+	WithTimestamp bool
+}
+
+// promFamily represents the samples of a metric family.
+type promFamily struct {
+	name    string
+	typ     string
+	samples bytes.Buffer
+}
+
+// promFamilyKey identifies a metric family by name and type.
+type promFamilyKey struct {
+	name string
+	typ  string
+}
+
+// promSampleSuffixes returns the suffixes of sample names of the family type.
+func promSampleSuffixes(typ string) []string {
+	switch typ {
+	case "histogram":
+		return []string{"_bucket", "_sum", "_count"}
+	case "summary":
+		return []string{"", "_sum", "_count"}
+	default:
+		return []string{""}
+	}
+}
+
+// WritePromExposition renders flat metric rows of the payload as prometheus text exposition format(version 0.0.4),
+// samples of the same metric family are grouped in the order of first appearance.
+//   - simple field: metric name, or metric name + "_" + field name if field name is not "value",
+//     all simple fields are exposed as gauge, delta sum field is the sum of the interval instead of a
+//     monotonic counter.
+//   - compound field: histogram with cumulative buckets, _sum and _count.
+//   - summary field: summary with quantiles, _sum and _count, named same as simple field.
+//
+// Families are keyed by name and type, ErrPromFamilyConflict is returned if the family name or one of its
+// sample names is used by another family(e.g. gauge "latency_sum" and histogram "latency").
+func WritePromExposition(w io.Writer, payload []byte, options PromExpositionOptions) error {
+	var (
+		families []*promFamily
+		index    = make(map[promFamilyKey]*promFamily)
+		owners   = make(map[string]*promFamily) // family name/sample name => family
+		labels   []byte
+		itr      = series.NewRowIterator(payload)
+	)
+	getFamily := func(name, typ string) (*promFamily, error) {
+		key := promFamilyKey{name: name, typ: typ}
+		if family, ok := index[key]; ok {
+			return family, nil
+		}
+		suffixes := promSampleSuffixes(typ)
+		names := make([]string, 0, len(suffixes)+1)
+		names = append(names, name)
+		for _, suffix := range suffixes {
+			if suffix != "" {
+				names = append(names, name+suffix)
+			}
+		}
+		for _, n := range names {
+			if owner, ok := owners[n]; ok {
+				return nil, fmt.Errorf("%w: %s %s, used by %s %s", ErrPromFamilyConflict, typ, n, owner.typ, owner.name)
+			}
+		}
+		family := &promFamily{name: name, typ: typ}
+		for _, n := range names {
+			owners[n] = family
+		}
+		index[key] = family
+		families = append(families, family)
+		return family, nil
+	}
+	for itr.Next() {
+		metricName := sanitizePromName(itr.Name(), true)
+		labels = labels[:0]
+		if options.NamespaceLabel != "" {
+			labels = appendPromLabel(labels, sanitizePromName([]byte(options.NamespaceLabel), false), itr.Namespace())
+		}
+		for i := 0; i < itr.TagsLen(); i++ {
+			key, value := itr.Tag(i)
+			labels = appendPromLabel(labels, sanitizePromName(key, false), value)
+		}
+		timestamp := int64(0)
+		if options.WithTimestamp {
+			timestamp = itr.Timestamp()
+		}
+		for i := 0; i < itr.SimpleFieldsLen(); i++ {
+			fieldName, _, value := itr.SimpleField(i)
+			if itr.SimpleFieldIsAbsent(i) {
+				// no value in this interval, don't render it as zero
+				continue
+			}
+			name := metricName
+			if string(fieldName) != PromDefaultFieldName {
+				name += "_" + sanitizePromName(fieldName, true)
+			}
+			family, err := getFamily(name, "gauge")
+			if err != nil {
+				return err
+			}
+			writePromSample(&family.samples, name, labels, "", "", value, timestamp)
+		}
+		if itr.HasCompoundField() {
+			family, err := getFamily(metricName, "histogram")
+			if err != nil {
+				return err
+			}
+			cumulative := 0.0
+			for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+				bound, value := itr.CompoundFieldBucket(i)
+				cumulative += value
+				writePromSample(&family.samples, metricName+"_bucket", labels, "le", formatPromValue(bound), cumulative, timestamp)
+			}
+			_, _, sum, count := itr.CompoundFieldMMSC()
+			writePromSample(&family.samples, metricName+"_sum", labels, "", "", sum, timestamp)
+			writePromSample(&family.samples, metricName+"_count", labels, "", "", count, timestamp)
+		}
+		for i := 0; i < itr.SummaryFieldsLen(); i++ {
+			fieldName, count, sum, quantiles := itr.SummaryField(i)
+			name := metricName
+			if string(fieldName) != PromDefaultFieldName {
+				name += "_" + sanitizePromName(fieldName, true)
+			}
+			family, err := getFamily(name, "summary")
+			if err != nil {
+				return err
+			}
+			for j := 0; j < quantiles; j++ {
+				quantile, value := itr.SummaryFieldQuantile(j)
+				writePromSample(&family.samples, name, labels, "quantile", formatPromValue(quantile), value, timestamp)
+			}
+			writePromSample(&family.samples, name+"_sum", labels, "", "", sum, timestamp)
+			writePromSample(&family.samples, name+"_count", labels, "", "", count, timestamp)
+		}
+	}
+	if err := itr.Err(); err != nil {
+		return err
+	}
+	for _, family := range families {
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", family.name, family.typ); err != nil {
+			return err
+		}
+		if _, err := w.Write(family.samples.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePromSample writes a sample line, extra label(e.g. le) is appended if not empty.
+func writePromSample(buf *bytes.Buffer, name string, labels []byte, extraKey, extraValue string, value float64, timestamp int64) {
+	buf.WriteString(name)
+	if len(labels) > 0 || extraKey != "" {
+		buf.WriteByte('{')
+		buf.Write(labels)
+		if extraKey != "" {
+			buf.Write(appendPromLabel(nil, extraKey, []byte(extraValue)))
+		}
+		// remove last comma
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatPromValue(value))
+	if timestamp != 0 {
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatInt(timestamp, 10))
+	}
+	buf.WriteByte('\n')
+}
+
+// appendPromLabel appends label with escaped value and a trailing comma.
+func appendPromLabel(dst []byte, key string, value []byte) []byte {
+	dst = append(dst, key...)
+	dst = append(dst, '=', '"')
+	for _, c := range value {
+		switch c {
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '"':
+			dst = append(dst, '\\', '"')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"', ',')
+}
+
+// formatPromValue formats the float value, e.g. +Inf/-Inf/NaN.
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// sanitizePromName replaces the invalid chars of metric/label name with '_',
+// metric name allows ':', the first char must not be digit.
+func sanitizePromName(name []byte, metric bool) string {
+	rs := make([]byte, 0, len(name)+1)
+	for idx, c := range name {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9') || (metric && c == ':')
+		switch {
+		case !valid:
+			rs = append(rs, '_')
+		case idx == 0 && c >= '0' && c <= '9':
+			rs = append(rs, '_', c)
+		default:
+			rs = append(rs, c)
+		}
+	}
+	return string(rs)
+}
@@ -0,0 +1,169 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+type errPromWriter struct{ n int }
+
+func (w *errPromWriter) Write(p []byte) (int, error) {
+	w.n--
+	if w.n < 0 {
+		return 0, fmt.Errorf("err")
+	}
+	return len(p), nil
+}
+
+func TestWritePromExposition(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	addRow := func(name string, ts int64, tags [][2]string, fn func(rb *series.RowBuilder)) {
+		rb := batch.RowBuilder()
+		rb.AddNameSpace([]byte("ns"))
+		rb.AddMetricName([]byte(name))
+		rb.AddTimestamp(ts)
+		for _, tag := range tags {
+			assert.NoError(t, rb.AddTag([]byte(tag[0]), []byte(tag[1])))
+		}
+		fn(rb)
+		assert.NoError(t, batch.Commit())
+	}
+	addRow("cpu", 1000, [][2]string{{"host", "a\"b\\c"}}, func(rb *series.RowBuilder) {
+		assert.NoError(t, rb.AddSimpleField([]byte("value"), flatMetricsV1.SimpleFieldTypeLast, 1.5))
+		assert.NoError(t, rb.AddSimpleField([]byte("requests"), flatMetricsV1.SimpleFieldTypeDeltaSum, 10))
+	})
+	addRow("latency", 1000, nil, func(rb *series.RowBuilder) {
+		assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2, 3}, []float64{0.1, 1, math.Inf(1)}))
+		assert.NoError(t, rb.AddCompoundFieldMMSC(0.01, 5, 8.5, 6))
+	})
+	addRow("cpu", 2000, [][2]string{{"host.name", "h2"}}, func(rb *series.RowBuilder) {
+		assert.NoError(t, rb.AddSimpleField([]byte("value"), flatMetricsV1.SimpleFieldTypeLast, 2))
+		// absent field isn't rendered
+		assert.NoError(t, rb.AddAbsentSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast))
+	})
+	addRow("1go.gc-count", 2000, nil, func(rb *series.RowBuilder) {
+		assert.NoError(t, rb.AddSimpleField([]byte("value"), flatMetricsV1.SimpleFieldTypeMax, 3))
+	})
+	addRow("rpc", 2000, nil, func(rb *series.RowBuilder) {
+		assert.NoError(t, rb.AddSummaryField([]byte("latency"), 10, 25, []float64{0.5, 0.99}, []float64{2, 9}))
+	})
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WritePromExposition(buf, batch.Payload(), PromExpositionOptions{}))
+	assert.Equal(t, `# TYPE cpu gauge
+cpu{host="a\"b\\c"} 1.5
+cpu{host_name="h2"} 2
+# TYPE cpu_requests gauge
+cpu_requests{host="a\"b\\c"} 10
+# TYPE latency histogram
+latency_bucket{le="0.1"} 1
+latency_bucket{le="1"} 3
+latency_bucket{le="+Inf"} 6
+latency_sum 8.5
+latency_count 6
+# TYPE _1go_gc_count gauge
+_1go_gc_count 3
+# TYPE rpc_latency summary
+rpc_latency{quantile="0.5"} 2
+rpc_latency{quantile="0.99"} 9
+rpc_latency_sum 25
+rpc_latency_count 10
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WritePromExposition(buf, batch.Payload(), PromExpositionOptions{
+		NamespaceLabel: "namespace",
+		WithTimestamp:  true,
+	}))
+	assert.Contains(t, buf.String(), `cpu{namespace="ns",host="a\"b\\c"} 1.5 1000`+"\n")
+	assert.Contains(t, buf.String(), `latency_bucket{namespace="ns",le="+Inf"} 6 1000`+"\n")
+	assert.Contains(t, buf.String(), `_1go_gc_count{namespace="ns"} 3 2000`+"\n")
+
+	// write failure
+	assert.Error(t, WritePromExposition(&errPromWriter{n: 0}, batch.Payload(), PromExpositionOptions{}))
+	assert.Error(t, WritePromExposition(&errPromWriter{n: 1}, batch.Payload(), PromExpositionOptions{}))
+	// corrupted payload
+	assert.Error(t, WritePromExposition(buf, []byte{1, 2, 3, 4, 5}, PromExpositionOptions{}))
+}
+
+func TestWritePromExposition_FamilyConflict(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields func(rb *series.RowBuilder)
+	}{
+		{
+			name: "gauge and histogram with same name",
+			fields: func(rb *series.RowBuilder) {
+				assert.NoError(t, rb.AddSimpleField([]byte("value"), flatMetricsV1.SimpleFieldTypeLast, 1))
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 3, 3))
+			},
+		},
+		{
+			name: "gauge and histogram sample",
+			fields: func(rb *series.RowBuilder) {
+				assert.NoError(t, rb.AddSimpleField([]byte("sum"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+				assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+				assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 3, 3))
+			},
+		},
+		{
+			name: "gauge and summary with same name",
+			fields: func(rb *series.RowBuilder) {
+				assert.NoError(t, rb.AddSimpleField([]byte("latency"), flatMetricsV1.SimpleFieldTypeMax, 1))
+				assert.NoError(t, rb.AddSummaryField([]byte("latency"), 1, 1, []float64{0.5}, []float64{1}))
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			batch := series.NewBatchBuilder()
+			rb := batch.RowBuilder()
+			rb.AddMetricName([]byte("rpc"))
+			rb.AddTimestamp(1000)
+			tt.fields(rb)
+			assert.NoError(t, batch.Commit())
+
+			err := WritePromExposition(&bytes.Buffer{}, batch.Payload(), PromExpositionOptions{})
+			assert.ErrorIs(t, err, ErrPromFamilyConflict)
+		})
+	}
+}
+
+func TestFormatPromValue(t *testing.T) {
+	assert.Equal(t, "+Inf", formatPromValue(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatPromValue(math.Inf(-1)))
+	assert.Equal(t, "NaN", formatPromValue(math.NaN()))
+	assert.Equal(t, "1e+21", formatPromValue(1e21))
+}
+
+func TestSanitizePromName(t *testing.T) {
+	assert.Equal(t, "a:b_c", sanitizePromName([]byte("a:b.c"), true))
+	assert.Equal(t, "a_b_c", sanitizePromName([]byte("a:b.c"), false))
+	assert.Equal(t, "_0abc", sanitizePromName([]byte("0abc"), false))
+}
@@ -0,0 +1,412 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/series"
+)
+
+func encodePromWriteRequest(req *PromWriteRequest) []byte {
+	var data []byte
+	for _, ts := range req.TimeSeries {
+		var tsData []byte
+		for _, label := range ts.Labels {
+			var labelData []byte
+			labelData = protowire.AppendTag(labelData, 1, protowire.BytesType)
+			labelData = protowire.AppendString(labelData, label.Name)
+			labelData = protowire.AppendTag(labelData, 2, protowire.BytesType)
+			labelData = protowire.AppendString(labelData, label.Value)
+			tsData = protowire.AppendTag(tsData, 1, protowire.BytesType)
+			tsData = protowire.AppendBytes(tsData, labelData)
+		}
+		for _, sample := range ts.Samples {
+			var sampleData []byte
+			sampleData = protowire.AppendTag(sampleData, 1, protowire.Fixed64Type)
+			sampleData = protowire.AppendFixed64(sampleData, math.Float64bits(sample.Value))
+			sampleData = protowire.AppendTag(sampleData, 2, protowire.VarintType)
+			sampleData = protowire.AppendVarint(sampleData, uint64(sample.Timestamp))
+			tsData = protowire.AppendTag(tsData, 2, protowire.BytesType)
+			tsData = protowire.AppendBytes(tsData, sampleData)
+		}
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendBytes(data, tsData)
+	}
+	for _, metadata := range req.Metadata {
+		var metadataData []byte
+		metadataData = protowire.AppendTag(metadataData, 1, protowire.VarintType)
+		metadataData = protowire.AppendVarint(metadataData, uint64(metadata.Type))
+		metadataData = protowire.AppendTag(metadataData, 2, protowire.BytesType)
+		metadataData = protowire.AppendString(metadataData, metadata.MetricFamilyName)
+		metadataData = protowire.AppendTag(metadataData, 4, protowire.BytesType)
+		metadataData = protowire.AppendString(metadataData, metadata.Help)
+		metadataData = protowire.AppendTag(metadataData, 5, protowire.BytesType)
+		metadataData = protowire.AppendString(metadataData, metadata.Unit)
+		data = protowire.AppendTag(data, 3, protowire.BytesType)
+		data = protowire.AppendBytes(data, metadataData)
+	}
+	// unknown field
+	data = protowire.AppendTag(data, 10, protowire.Fixed32Type)
+	data = protowire.AppendFixed32(data, 1)
+	return snappy.Encode(nil, data)
+}
+
+func promLabels(kvs ...string) []PromLabel {
+	var labels []PromLabel
+	for i := 0; i < len(kvs); i += 2 {
+		labels = append(labels, PromLabel{Name: kvs[i], Value: kvs[i+1]})
+	}
+	return labels
+}
+
+func TestDecodePromRemoteWrite(t *testing.T) {
+	req := &PromWriteRequest{
+		TimeSeries: []PromTimeSeries{{
+			Labels:  promLabels("__name__", "up", "job", "node"),
+			Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+		}},
+		Metadata: []PromMetricMetadata{{
+			Type: PromMetricTypeGauge, MetricFamilyName: "up", Help: "help", Unit: "unit",
+		}},
+	}
+	decoded, err := DecodePromRemoteWrite(encodePromWriteRequest(req))
+	assert.NoError(t, err)
+	assert.Equal(t, req, decoded)
+
+	_, err = DecodePromRemoteWrite([]byte("bad snappy"))
+	assert.Error(t, err)
+	_, err = DecodePromRemoteWrite(snappy.Encode(nil, []byte{0xff}))
+	assert.Error(t, err)
+	_, err = DecodePromRemoteWrite(snappy.Encode(nil, protowire.AppendTag(nil, 1, protowire.BytesType)))
+	assert.Error(t, err)
+	_, err = DecodePromRemoteWrite(snappy.Encode(nil,
+		protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte{0xff})))
+	assert.Error(t, err)
+	_, err = DecodePromRemoteWrite(snappy.Encode(nil,
+		protowire.AppendBytes(protowire.AppendTag(nil, 3, protowire.BytesType), []byte{0xff})))
+	assert.Error(t, err)
+}
+
+func TestPromRemoteWriteToRows(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	assert.NoError(t, PromRemoteWriteToRows(encodePromWriteRequest(newPromHistogramRequest(1000, 1)), "ns", batch))
+	// histograms are ignored without cumulative converter
+	assert.Equal(t, 1, batch.Rows())
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, "http_requests_total", string(itr.Name()))
+	assert.Equal(t, int64(1000), itr.Timestamp())
+	assert.Equal(t, 1, itr.TagsLen())
+	name, fieldType, value := itr.SimpleField(0)
+	assert.Equal(t, PromDefaultFieldName, string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, float64(10), value)
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+}
+
+// newPromHistogramRequest returns a request with a counter and histograms, counts of histograms are multiplied by n.
+func newPromHistogramRequest(timestamp int64, n float64) *PromWriteRequest {
+	return &PromWriteRequest{
+		TimeSeries: []PromTimeSeries{{
+			Labels:  promLabels("__name__", "http_requests_total", "job", "api"),
+			Samples: []PromSample{{Value: 10, Timestamp: timestamp}, {Value: math.NaN(), Timestamp: timestamp + 1}},
+		}, {
+			Labels:  promLabels("__name__", "latency_bucket", "job", "api", "le", "0.5"),
+			Samples: []PromSample{{Value: 3 * n, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_bucket", "le", "0.1", "job", "api"),
+			Samples: []PromSample{{Value: 1 * n, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_bucket", "job", "api", "le", "+Inf"),
+			Samples: []PromSample{{Value: 4 * n, Timestamp: timestamp}, {Value: math.NaN(), Timestamp: timestamp + 1}},
+		}, {
+			Labels:  promLabels("__name__", "latency_sum", "job", "api"),
+			Samples: []PromSample{{Value: 1.2 * n, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_count", "job", "api"),
+			Samples: []PromSample{{Value: 4 * n, Timestamp: timestamp}},
+		}, {
+			// histogram without +Inf bucket
+			Labels:  promLabels("__name__", "size_bucket", "le", "10"),
+			Samples: []PromSample{{Value: 2 * n, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "size_count"),
+			Samples: []PromSample{{Value: 5 * n, Timestamp: timestamp}},
+		}, {
+			// histogram declared by metadata without buckets
+			Labels:  promLabels("__name__", "rpc_count"),
+			Samples: []PromSample{{Value: 5 * n, Timestamp: timestamp}},
+		}},
+		Metadata: []PromMetricMetadata{{Type: PromMetricTypeHistogram, MetricFamilyName: "rpc"}},
+	}
+}
+
+func TestPromConverter_Histogram(t *testing.T) {
+	converter := NewPromConverter("ns")
+	converter.SetCumulativeConverter(series.NewCumulativeConverter())
+	batch := series.NewBatchBuilder()
+	// zero observation as the base of delta
+	assert.NoError(t, converter.Convert(newPromHistogramRequest(1000, 0), batch))
+	batch.Reset()
+	assert.NoError(t, converter.Convert(newPromHistogramRequest(2000, 1), batch))
+	assert.Equal(t, 3, batch.Rows())
+
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "http_requests_total", string(itr.Name()))
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "latency", string(itr.Name()))
+	assert.Equal(t, 1, itr.TagsLen())
+	assert.True(t, itr.HasCompoundField())
+	_, _, sum, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, 1.2, sum)
+	assert.Equal(t, float64(4), count)
+	var bounds, values []float64
+	for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+		bound, v := itr.CompoundFieldBucket(i)
+		bounds = append(bounds, bound)
+		values = append(values, v)
+	}
+	assert.Equal(t, []float64{0.1, 0.5, math.Inf(1)}, bounds)
+	assert.Equal(t, []float64{1, 2, 1}, values)
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "size", string(itr.Name()))
+	assert.Zero(t, itr.TagsLen())
+	assert.Equal(t, 2, itr.CompoundFieldBucketsLen())
+	_, v := itr.CompoundFieldBucket(1)
+	assert.Equal(t, float64(3), v)
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+	assert.Zero(t, converter.InvalidSeries())
+}
+
+func TestPromConverter_InvalidHistogram(t *testing.T) {
+	newRequest := func(timestamp int64) *PromWriteRequest {
+		return &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+			// only +Inf bucket
+			Labels:  promLabels("__name__", "latency_bucket", "le", "+Inf"),
+			Samples: []PromSample{{Value: 1, Timestamp: timestamp}},
+		}, {
+			// negative sum
+			Labels:  promLabels("__name__", "size_bucket", "le", "1"),
+			Samples: []PromSample{{Value: 1, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "size_sum"),
+			Samples: []PromSample{{Value: -1, Timestamp: timestamp}},
+		}, {
+			// negative bound
+			Labels:  promLabels("__name__", "rpc_bucket", "le", "-1"),
+			Samples: []PromSample{{Value: 1, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "up"),
+			Samples: []PromSample{{Value: 1, Timestamp: timestamp}},
+		}}}
+	}
+	converter := NewPromConverter("ns")
+	cumulative := series.NewCumulativeConverter()
+	converter.SetCumulativeConverter(cumulative)
+	batch := series.NewBatchBuilder()
+	// invalid series are skipped, other series are kept
+	assert.NoError(t, converter.Convert(newRequest(1000), batch))
+	assert.NoError(t, converter.Convert(newRequest(2000), batch))
+	assert.Equal(t, 2, batch.Rows())
+	assert.Equal(t, 6, converter.InvalidSeries())
+	assert.Zero(t, cumulative.Len())
+}
+
+func TestPromRemoteWriteToRows_FieldTypePolicies(t *testing.T) {
+	req := &PromWriteRequest{
+		TimeSeries: []PromTimeSeries{{
+			Labels:  promLabels("__name__", "http_requests_total"),
+			Samples: []PromSample{{Value: 10, Timestamp: 1000}},
+		}, {
+			Labels:  promLabels("__name__", "jobs_total"),
+			Samples: []PromSample{{Value: 10, Timestamp: 1000}},
+		}, {
+			Labels:  promLabels("__name__", "queue_size_total"),
+			Samples: []PromSample{{Value: 10, Timestamp: 1000}},
+		}},
+		Metadata: []PromMetricMetadata{
+			{Type: PromMetricTypeCounter, MetricFamilyName: "http_requests"},
+			{Type: PromMetricTypeGauge, MetricFamilyName: "queue_size_total"},
+			{Type: PromMetricTypeUnknown, MetricFamilyName: "jobs"},
+		},
+	}
+	policies, err := series.NewFieldTypePolicies([]series.FieldTypePolicy{{Metric: "*_total", FieldType: "DeltaSum"}})
+	assert.NoError(t, err)
+	batch := series.NewBatchBuilder(series.WithFieldTypePolicies(policies))
+	assert.NoError(t, PromRemoteWriteToRows(encodePromWriteRequest(req), "ns", batch))
+
+	// typed metrics keep the field type, untyped metric uses the policy
+	expects := []flatMetricsV1.SimpleFieldType{
+		flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeDeltaSum, flatMetricsV1.SimpleFieldTypeLast,
+	}
+	itr := series.NewRowIterator(batch.Payload())
+	for _, expect := range expects {
+		assert.True(t, itr.Next())
+		_, fieldType, _ := itr.SimpleField(0)
+		assert.Equal(t, expect, fieldType)
+	}
+	assert.False(t, itr.Next())
+}
+
+func TestPromRemoteWriteToRows_Error(t *testing.T) {
+	batch := series.NewBatchBuilder()
+	assert.Error(t, PromRemoteWriteToRows([]byte("bad"), "ns", batch))
+
+	cases := [][]PromLabel{
+		promLabels("job", "api"),
+		promLabels("__name__", "latency_bucket", "le", "abc"),
+	}
+	for _, labels := range cases {
+		req := &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+			Labels:  labels,
+			Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+		}}}
+		assert.Error(t, PromRemoteWriteToRows(encodePromWriteRequest(req), "ns", batch))
+	}
+	// invalid sample value/mmsc
+	req := &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+		Labels:  promLabels("__name__", "up"),
+		Samples: []PromSample{{Value: math.Inf(1), Timestamp: 1000}},
+	}}}
+	assert.Error(t, NewPromConverter("ns").Convert(req, batch))
+	assert.Zero(t, batch.Rows())
+
+	// rows of failed request are discarded
+	req = &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+		Labels:  promLabels("__name__", "up"),
+		Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+	}, {
+		Labels:  promLabels("__name__", "down"),
+		Samples: []PromSample{{Value: 1, Timestamp: 1000}, {Value: math.Inf(1), Timestamp: 2000}},
+	}}}
+	commitPromRow(t, batch, "before")
+	assert.Error(t, NewPromConverter("ns").Convert(req, batch))
+	assert.Equal(t, 1, batch.Rows())
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "before", string(itr.Name()))
+	assert.False(t, itr.Next())
+}
+
+func commitPromRow(t *testing.T, batch *series.BatchBuilder, name string) {
+	rb := batch.RowBuilder()
+	rb.AddMetricName([]byte(name))
+	rb.AddTimestamp(1000)
+	assert.NoError(t, rb.AddSimpleField([]byte(PromDefaultFieldName), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, batch.Commit())
+}
+
+func TestPromRemoteWriteToRows_EmptyLabelValue(t *testing.T) {
+	req := &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+		Labels:  promLabels("__name__", "up", "job", "", "host", "h1"),
+		Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+	}, {
+		Labels:  promLabels("__name__", "latency_bucket", "le", "1", "job", ""),
+		Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+	}, {
+		Labels:  promLabels("__name__", "latency_count"),
+		Samples: []PromSample{{Value: 1, Timestamp: 1000}},
+	}}}
+	converter := NewPromConverter("ns")
+	converter.SetCumulativeConverter(series.NewCumulativeConverter())
+	batch := series.NewBatchBuilder()
+	assert.NoError(t, converter.Convert(req, batch))
+	batch.Reset()
+	for idx := range req.TimeSeries {
+		req.TimeSeries[idx].Samples[0].Timestamp = 2000
+		req.TimeSeries[idx].Samples[0].Value = 2
+	}
+	assert.NoError(t, converter.Convert(req, batch))
+	assert.Equal(t, 2, batch.Rows())
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, []series.Tag{{Key: "host", Value: "h1"}}, itr.Row().Tags)
+	// bucket and count series without empty label are merged
+	assert.True(t, itr.Next())
+	assert.Zero(t, itr.TagsLen())
+	_, _, _, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, 1.0, count)
+}
+
+func TestPromConverter_CumulativeHistogram(t *testing.T) {
+	newRequest := func(timestamp int64, le1, inf, sum float64) *PromWriteRequest {
+		return &PromWriteRequest{TimeSeries: []PromTimeSeries{{
+			Labels:  promLabels("__name__", "latency_bucket", "le", "1"),
+			Samples: []PromSample{{Value: le1, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_bucket", "le", "+Inf"),
+			Samples: []PromSample{{Value: inf, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_sum"),
+			Samples: []PromSample{{Value: sum, Timestamp: timestamp}},
+		}, {
+			Labels:  promLabels("__name__", "latency_count"),
+			Samples: []PromSample{{Value: inf, Timestamp: timestamp}},
+		}}}
+	}
+	converter := NewPromConverter("ns")
+	converter.SetCumulativeConverter(series.NewCumulativeConverter())
+	batch := series.NewBatchBuilder()
+	// first observation is the base of delta
+	assert.NoError(t, converter.Convert(newRequest(1000, 1, 2, 3), batch))
+	assert.Zero(t, batch.Rows())
+	assert.NoError(t, converter.Convert(newRequest(2000, 3, 5, 10), batch))
+	assert.Equal(t, 1, batch.Rows())
+	itr := series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	_, v := itr.CompoundFieldBucket(0)
+	assert.Equal(t, 2.0, v)
+	_, v = itr.CompoundFieldBucket(1)
+	assert.Equal(t, 1.0, v)
+	_, _, sum, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, 7.0, sum)
+	assert.Equal(t, 3.0, count)
+
+	// rejected request doesn't move the base of histograms
+	req := newRequest(3000, 4, 7, 12)
+	req.TimeSeries = append(req.TimeSeries, PromTimeSeries{
+		// histogram converted after latency fails
+		Labels:  promLabels("__name__", "rpc_bucket", "le", "+Inf", "too-long-tag-key", "v"),
+		Samples: []PromSample{{Value: 1, Timestamp: 3000}},
+	})
+	batch.Reset()
+	batch.SetLimits(&series.Limits{MaxTagKeyLength: 10})
+	assert.Error(t, converter.Convert(req, batch))
+	assert.Zero(t, batch.Rows())
+	batch.SetLimits(nil)
+	assert.NoError(t, converter.Convert(newRequest(4000, 5, 9, 14), batch))
+	itr = series.NewRowIterator(batch.Payload())
+	assert.True(t, itr.Next())
+	_, _, sum, count = itr.CompoundFieldMMSC()
+	assert.Equal(t, 4.0, sum)
+	assert.Equal(t, 4.0, count)
+}
@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protocol
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protoField represents a decoded field of protobuf message.
+type protoField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	bytes []byte // value of length-delimited field
+	value uint64 // value of varint/fixed32/fixed64 field
+}
+
+// Float64 returns the value of double field.
+func (f *protoField) Float64() float64 { return math.Float64frombits(f.value) }
+
+// Int64 returns the value of int64 field.
+func (f *protoField) Int64() int64 { return int64(f.value) }
+
+// decodeMessage walks all fields of the protobuf message, skips group fields.
+func decodeMessage(data []byte, fn func(field *protoField) error) error {
+	field := protoField{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		field.num = num
+		field.typ = typ
+		field.bytes = nil
+		field.value = 0
+		switch typ {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			field.value = uint64(v)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		if n < 0 {
+			return fmt.Errorf("field: %d, %w", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+		if err := fn(&field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
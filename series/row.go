@@ -0,0 +1,285 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tag represents a tag key/value pair of the decoded row.
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SimpleField represents a simple field of the decoded row.
+type SimpleField struct {
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Value  jsonFloat64 `json:"value"`
+	Absent bool        `json:"absent,omitempty"` // no value in this interval, Value is ignored
+}
+
+// Bucket represents a histogram bucket of the compound field.
+type Bucket struct {
+	Bound jsonFloat64 `json:"bound"`
+	Value jsonFloat64 `json:"value"`
+}
+
+// CompoundField represents the histogram compound field of the decoded row.
+type CompoundField struct {
+	Min     jsonFloat64 `json:"min"`
+	Max     jsonFloat64 `json:"max"`
+	Sum     jsonFloat64 `json:"sum"`
+	Count   jsonFloat64 `json:"count"`
+	Buckets []Bucket    `json:"buckets"`
+}
+
+// Quantile represents a quantile value of the summary field.
+type Quantile struct {
+	Quantile jsonFloat64 `json:"quantile"`
+	Value    jsonFloat64 `json:"value"`
+}
+
+// SummaryField represents a summary field of the decoded row.
+type SummaryField struct {
+	Name      string      `json:"name"`
+	Count     jsonFloat64 `json:"count"`
+	Sum       jsonFloat64 `json:"sum"`
+	Quantiles []Quantile  `json:"quantiles"`
+}
+
+// Exemplar represents an exemplar of the decoded row.
+type Exemplar struct {
+	Name     string `json:"name"`
+	TraceID  string `json:"traceId"`
+	SpanID   string `json:"spanId"`
+	Duration int64  `json:"duration"`
+}
+
+// Row represents a decoded flat metric row, which is used for debugging a payload.
+// Unlike RowIterator, Row holds copied values, so it can be kept after moving the iterator.
+type Row struct {
+	Namespace     string         `json:"namespace"`
+	Name          string         `json:"name"`
+	Timestamp     int64          `json:"timestamp"`
+	Tags          []Tag          `json:"tags,omitempty"`
+	SimpleFields  []SimpleField  `json:"simpleFields,omitempty"`
+	CompoundField *CompoundField `json:"compoundField,omitempty"`
+	SummaryFields []SummaryField `json:"summaryFields,omitempty"`
+	Exemplars     []Exemplar     `json:"exemplars,omitempty"`
+	OriginalTags  []Tag          `json:"originalTags,omitempty"` // tags in insertion order, see WithTagOrder
+}
+
+// Row decodes current row of the iterator.
+func (itr *RowIterator) Row() *Row {
+	row := &Row{
+		Namespace: string(itr.Namespace()),
+		Name:      string(itr.Name()),
+		Timestamp: itr.Timestamp(),
+	}
+	for i := 0; i < itr.TagsLen(); i++ {
+		key, value := itr.Tag(i)
+		row.Tags = append(row.Tags, Tag{Key: string(key), Value: string(value)})
+	}
+	for i := 0; i < itr.OriginalTagsLen(); i++ {
+		key, value := itr.OriginalTag(i)
+		row.OriginalTags = append(row.OriginalTags, Tag{Key: string(key), Value: string(value)})
+	}
+	for i := 0; i < itr.SimpleFieldsLen(); i++ {
+		name, fieldType, value := itr.SimpleField(i)
+		row.SimpleFields = append(row.SimpleFields, SimpleField{
+			Name:   string(name),
+			Type:   fieldType.String(),
+			Value:  jsonFloat64(value),
+			Absent: itr.SimpleFieldIsAbsent(i),
+		})
+	}
+	if itr.HasCompoundField() {
+		minValue, maxValue, sum, count := itr.CompoundFieldMMSC()
+		compound := &CompoundField{
+			Min:   jsonFloat64(minValue),
+			Max:   jsonFloat64(maxValue),
+			Sum:   jsonFloat64(sum),
+			Count: jsonFloat64(count),
+		}
+		for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+			bound, value := itr.CompoundFieldBucket(i)
+			compound.Buckets = append(compound.Buckets, Bucket{Bound: jsonFloat64(bound), Value: jsonFloat64(value)})
+		}
+		row.CompoundField = compound
+	}
+	for i := 0; i < itr.SummaryFieldsLen(); i++ {
+		name, count, sum, quantiles := itr.SummaryField(i)
+		summary := SummaryField{
+			Name:  string(name),
+			Count: jsonFloat64(count),
+			Sum:   jsonFloat64(sum),
+		}
+		for j := 0; j < quantiles; j++ {
+			quantile, value := itr.SummaryFieldQuantile(j)
+			summary.Quantiles = append(summary.Quantiles, Quantile{Quantile: jsonFloat64(quantile), Value: jsonFloat64(value)})
+		}
+		row.SummaryFields = append(row.SummaryFields, summary)
+	}
+	for i := 0; i < itr.ExemplarsLen(); i++ {
+		name, traceID, spanID, duration := itr.Exemplar(i)
+		row.Exemplars = append(row.Exemplars, Exemplar{
+			Name:     string(name),
+			TraceID:  string(traceID),
+			SpanID:   string(spanID),
+			Duration: duration,
+		})
+	}
+	return row
+}
+
+// MarshalJSON returns the json encoding of the row,
+// NaN/±Inf values(e.g. +Inf bucket bound) are encoded as string.
+func (r *Row) MarshalJSON() ([]byte, error) {
+	type row Row // avoid recursion
+	return json.Marshal((*row)(r))
+}
+
+// String returns the human-readable string of the row, e.g.
+// ns:cpu{host=host1} 100 idle(Last)=1 histogram{min=1,max=2,sum=3,count=4,buckets=[1:1,+Inf:2]},
+// the original tags are appended if recorded, e.g. original_tags{ip=1.1.1.1,host=host1}.
+func (r *Row) String() string {
+	var sb strings.Builder
+	if r.Namespace != "" {
+		sb.WriteString(r.Namespace)
+		sb.WriteByte(':')
+	}
+	sb.WriteString(r.Name)
+	writeTags(&sb, r.Tags)
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatInt(r.Timestamp, 10))
+	r.writeFields(&sb)
+	if len(r.OriginalTags) > 0 {
+		sb.WriteString(" original_tags")
+		writeTags(&sb, r.OriginalTags)
+	}
+	return sb.String()
+}
+
+// writeTags writes the tags in braces.
+func writeTags(sb *strings.Builder, tags []Tag) {
+	sb.WriteByte('{')
+	for i, tag := range tags {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(tag.Key)
+		sb.WriteByte('=')
+		sb.WriteString(tag.Value)
+	}
+	sb.WriteByte('}')
+}
+
+// fieldsString returns the string of fields part of the row, each field starts with a space.
+func (r *Row) fieldsString() string {
+	var sb strings.Builder
+	r.writeFields(&sb)
+	return sb.String()
+}
+
+// writeFields writes the fields part of the row.
+func (r *Row) writeFields(sb *strings.Builder) {
+	for _, f := range r.SimpleFields {
+		sb.WriteByte(' ')
+		sb.WriteString(f.Name)
+		sb.WriteByte('(')
+		sb.WriteString(f.Type)
+		sb.WriteString(")=")
+		if f.Absent {
+			sb.WriteString("absent")
+		} else {
+			sb.WriteString(f.Value.String())
+		}
+	}
+	if c := r.CompoundField; c != nil {
+		sb.WriteString(" histogram{min=")
+		sb.WriteString(c.Min.String())
+		sb.WriteString(",max=")
+		sb.WriteString(c.Max.String())
+		sb.WriteString(",sum=")
+		sb.WriteString(c.Sum.String())
+		sb.WriteString(",count=")
+		sb.WriteString(c.Count.String())
+		sb.WriteString(",buckets=[")
+		for i, b := range c.Buckets {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(b.Bound.String())
+			sb.WriteByte(':')
+			sb.WriteString(b.Value.String())
+		}
+		sb.WriteString("]}")
+	}
+	for _, s := range r.SummaryFields {
+		sb.WriteByte(' ')
+		sb.WriteString(s.Name)
+		sb.WriteString("{sum=")
+		sb.WriteString(s.Sum.String())
+		sb.WriteString(",count=")
+		sb.WriteString(s.Count.String())
+		sb.WriteString(",quantiles=[")
+		for i, q := range s.Quantiles {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(q.Quantile.String())
+			sb.WriteByte(':')
+			sb.WriteString(q.Value.String())
+		}
+		sb.WriteString("]}")
+	}
+	for _, e := range r.Exemplars {
+		sb.WriteString(" exemplar{name=")
+		sb.WriteString(e.Name)
+		sb.WriteString(",trace=")
+		sb.WriteString(e.TraceID)
+		sb.WriteString(",span=")
+		sb.WriteString(e.SpanID)
+		sb.WriteString(",duration=")
+		sb.WriteString(strconv.FormatInt(e.Duration, 10))
+		sb.WriteByte('}')
+	}
+}
+
+// jsonFloat64 is a float64 which encodes NaN/±Inf as json string,
+// because encoding/json doesn't support them.
+type jsonFloat64 float64
+
+// String returns the shortest string representation of the float.
+func (f jsonFloat64) String() string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
+
+// MarshalJSON returns the json encoding of the float.
+func (f jsonFloat64) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return []byte(strconv.Quote(f.String())), nil
+	}
+	return json.Marshal(v)
+}
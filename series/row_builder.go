@@ -29,6 +29,7 @@ import (
 
 	"github.com/lindb/common/pkg/fasttime"
 	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/proto/gen/v2/flatMetricsV2"
 )
 
 type rowKV struct {
@@ -50,6 +51,8 @@ func (items rowKVs) Less(i, j int) bool {
 	return bytes.Compare(items.kvs[i].key, items.kvs[j].key) < 0
 }
 
+func (items rowKVs) Tag(idx int) (key, value []byte) { return items.kvs[idx].key, items.kvs[idx].value }
+
 type rowExemplar struct {
 	name     []byte
 	traceID  []byte
@@ -58,9 +61,27 @@ type rowExemplar struct {
 }
 
 type rowSimpleField struct {
-	name  []byte
-	fType flatMetricsV1.SimpleFieldType
-	value float64
+	name   []byte
+	fType  flatMetricsV1.SimpleFieldType
+	value  float64
+	absent bool
+}
+
+// rowTypedField is the int64/string field of v2 row.
+type rowTypedField struct {
+	name        []byte
+	fType       flatMetricsV1.SimpleFieldType
+	valueType   flatMetricsV2.ValueType
+	intValue    int64
+	stringValue []byte
+}
+
+type rowSummaryField struct {
+	name      []byte
+	count     float64
+	sum       float64
+	quantiles []float64
+	values    []float64
 }
 
 // RowBuilder builds a flat metric in order.
@@ -70,8 +91,9 @@ type RowBuilder struct {
 	nameSpace  []byte
 	timestamp  int64
 
-	rowKVs  rowKVs
-	hashBuf bytes.Buffer // concat sorted kvs
+	rowKVs     rowKVs
+	tagsSorted bool         // tags are appended by AddSortedTags, no need to sort and dedup
+	hashBuf    bytes.Buffer // concat sorted kvs
 
 	simpleFields     []rowSimpleField
 	simpleFieldCount int
@@ -86,8 +108,32 @@ type RowBuilder struct {
 	compoundFieldSum            float64
 	compoundFieldCount          float64
 
+	summaryFields     []rowSummaryField
+	summaryFieldCount int
+
+	typedFields     []rowTypedField // int64/string fields, the row is built as v2 if not empty
+	typedFieldCount int
+
+	fieldMerge bool    // merge duplicate simple fields when building, see WithFieldMerge
+	globalTags []rowKV // tags enriched into each row when building, see WithGlobalTags
+	arena      *Arena  // allocates tag/field buffers if set, see WithArena
+
+	fieldTypePolicies *FieldTypePolicies // default field types of metrics without type hints
+	tagOrder          bool               // record original tag insertion order, see WithTagOrder
+
+	limits       *Limits      // limits of row, nil means unlimited
+	buildMode    BuildMode    // how invalid data is handled, see BuildMode
+	hashStrategy HashStrategy // nil means xxhash of concatenation
+	tagInterner  *TagInterner // nil means converting tag strings without interning
+
+	sanitizer     Sanitizer // nil means replacing '|' of namespace/metric name in place
+	sanitizeErrs  [SanitizeTargetMetricName + 1]error
+	sanitizeKey   []byte
+	sanitizeValue []byte
+
 	// context for building flat metrics
 	flatBuilder    *flatbuffers.Builder
+	sharedStrings  map[string]string // string table shared by rows of batch, see createByteString
 	keys           []flatbuffers.UOffsetT
 	values         []flatbuffers.UOffsetT
 	kvs            []flatbuffers.UOffsetT
@@ -97,12 +143,18 @@ type RowBuilder struct {
 	exemplarTraces []flatbuffers.UOffsetT
 	exemplarSpans  []flatbuffers.UOffsetT
 	exemplars      []flatbuffers.UOffsetT
+	quantiles      []flatbuffers.UOffsetT
+	summaries      []flatbuffers.UOffsetT
+	typedOffsets   []flatbuffers.UOffsetT
 }
 
+// RowBuilderOption represents the option of row builder, which is kept after Reset.
+type RowBuilderOption func(rb *RowBuilder)
+
 var rowBuilderPool sync.Pool
 
 // NewRowBuilder picks a row builder from pool for building flat metric
-func NewRowBuilder() (
+func NewRowBuilder(options ...RowBuilderOption) (
 	rb *RowBuilder,
 	releaseFunc func(rb *RowBuilder),
 ) {
@@ -112,22 +164,144 @@ func NewRowBuilder() (
 		builder := item.(*RowBuilder)
 		builder.Reset()
 	}
-	return &RowBuilder{
-		flatBuilder: flatbuffers.NewBuilder(1536),
-	}, releaseFunc
+	return CreateRowBuilder(options...), releaseFunc
 }
 
 // CreateRowBuilder creates a new row builder, not reused builder.
-func CreateRowBuilder() *RowBuilder {
-	return &RowBuilder{flatBuilder: flatbuffers.NewBuilder(1536)}
+func CreateRowBuilder(options ...RowBuilderOption) *RowBuilder {
+	rb := &RowBuilder{flatBuilder: flatbuffers.NewBuilder(1536)}
+	for _, option := range options {
+		option(rb)
+	}
+	return rb
+}
+
+// SetSanitizer sets the sanitizer of namespace/metric name/tags, which is kept after Reset,
+// nil means replacing '|' of namespace/metric name with '_' in place.
+// Sorted tags(AddSortedTags) are not sanitized, which should be canonical.
+func (rb *RowBuilder) SetSanitizer(sanitizer Sanitizer) {
+	rb.sanitizer = sanitizer
+}
+
+// SetHashStrategy sets the hash strategy of series identity, which is kept after Reset,
+// nil means DefaultHashStrategy(xxhash of concatenation).
+func (rb *RowBuilder) SetHashStrategy(strategy HashStrategy) {
+	rb.hashStrategy = strategy
+}
+
+// SetTagInterner sets the interner of tag strings(AddTagString/AddTags), which is kept after Reset,
+// nil means converting tag strings without interning.
+func (rb *RowBuilder) SetTagInterner(interner *TagInterner) {
+	rb.tagInterner = interner
+}
+
+// SetLimits sets the limits of row, which is kept after Reset, nil means unlimited.
+func (rb *RowBuilder) SetLimits(limits *Limits) {
+	rb.limits = limits
 }
 
 // AddTag appends a key-value pair
 // Return false if tag is invalid
 func (rb *RowBuilder) AddTag(key, value []byte) error {
 	if len(key) == 0 || len(value) == 0 {
+		if rb.buildMode == BuildModeLenient {
+			return nil
+		}
 		return fmt.Errorf("tag[%s: %s] is empty", string(key), string(value))
 	}
+	if rb.limits != nil {
+		if rb.buildMode == BuildModeLenient {
+			if key, value = rb.limits.truncateTag(key, value); len(key) == 0 || len(value) == 0 {
+				return nil
+			}
+		} else if err := rb.limits.checkTag(key, value); err != nil {
+			return err
+		}
+	}
+	if rb.sanitizer != nil {
+		var err error
+		if rb.sanitizeKey, err = rb.sanitizer.Sanitize(SanitizeTargetTagKey, rb.sanitizeKey[:0], key); err != nil {
+			return err
+		}
+		if rb.sanitizeValue, err = rb.sanitizer.Sanitize(SanitizeTargetTagValue, rb.sanitizeValue[:0], value); err != nil {
+			return err
+		}
+		key, value = rb.sanitizeKey, rb.sanitizeValue
+	}
+	rb.tagsSorted = false
+	rb.appendTag(key, value)
+	return nil
+}
+
+// AddTagString appends a key-value pair of strings, which are interned if tag interner is set.
+func (rb *RowBuilder) AddTagString(key, value string) error {
+	return rb.AddTag(rb.tagBytes(key), rb.tagBytes(value))
+}
+
+// AddTags appends all key-value pairs of the map.
+// Return error if any tag is invalid, the valid tags before it are kept.
+func (rb *RowBuilder) AddTags(tags map[string]string) error {
+	for key, value := range tags {
+		if err := rb.AddTagString(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagBytes converts the tag string into bytes, using the interner if set.
+func (rb *RowBuilder) tagBytes(s string) []byte {
+	if rb.tagInterner == nil {
+		return []byte(s)
+	}
+	return rb.tagInterner.Intern(s)
+}
+
+// AddSortedTags appends the key-value pairs which are sorted by key without duplicated key,
+// sorting and dedup are skipped when building if no other tags are appended.
+// Return error if tags are invalid or not sorted, no tag is appended.
+func (rb *RowBuilder) AddSortedTags(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("tag keys length: %d not equals values length: %d", len(keys), len(values))
+	}
+	if err := rb.checkSortedTags(keys, values); err != nil {
+		if rb.buildMode != BuildModeLenient {
+			return err
+		}
+		// auto-correct the tags, which are sorted and deduped when building
+		for idx := range keys {
+			_ = rb.AddTag(keys[idx], values[idx])
+		}
+		return nil
+	}
+	// only all tags come from sorted tags can skip sorting
+	rb.tagsSorted = rb.rowKVs.kvCount == 0
+	for idx := range keys {
+		rb.appendTag(keys[idx], values[idx])
+	}
+	return nil
+}
+
+// checkSortedTags checks the tags are valid, sorted by key and not duplicated.
+func (rb *RowBuilder) checkSortedTags(keys, values [][]byte) error {
+	for idx := range keys {
+		if len(keys[idx]) == 0 || len(values[idx]) == 0 {
+			return fmt.Errorf("tag[%s: %s] is empty", string(keys[idx]), string(values[idx]))
+		}
+		if idx > 0 && bytes.Compare(keys[idx-1], keys[idx]) >= 0 {
+			return fmt.Errorf("tag keys are not sorted or duplicated: %s, %s", string(keys[idx-1]), string(keys[idx]))
+		}
+		if rb.limits != nil {
+			if err := rb.limits.checkTag(keys[idx], values[idx]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendTag copies the key-value pair into row kvs.
+func (rb *RowBuilder) appendTag(key, value []byte) {
 	rb.rowKVs.kvCount++
 
 	if rb.rowKVs.kvCount > len(rb.rowKVs.kvs) {
@@ -135,10 +309,9 @@ func (rb *RowBuilder) AddTag(key, value []byte) error {
 	}
 	kvIdx := rb.rowKVs.kvCount - 1
 	// copy key
-	rb.rowKVs.kvs[kvIdx].key = append(rb.rowKVs.kvs[kvIdx].key[:0], key...)
+	rb.rowKVs.kvs[kvIdx].key = rb.copyBytes(rb.rowKVs.kvs[kvIdx].key, key)
 	// copy value
-	rb.rowKVs.kvs[kvIdx].value = append(rb.rowKVs.kvs[kvIdx].value[:0], value...)
-	return nil
+	rb.rowKVs.kvs[kvIdx].value = rb.copyBytes(rb.rowKVs.kvs[kvIdx].value, value)
 }
 
 // AddSimpleField appends a simple field
@@ -147,15 +320,38 @@ func (rb *RowBuilder) AddSimpleField(fieldName []byte, fieldType flatMetricsV1.S
 	if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
 		return fmt.Errorf("flat field type is unspecified")
 	}
-	if math.IsInf(fieldValue, 0) {
-		return fmt.Errorf("fieldValue is Inf :%f", fieldValue)
+	if len(fieldName) == 0 {
+		return fmt.Errorf("fieldName is empty")
 	}
-	if math.IsNaN(fieldValue) {
+	if math.IsInf(fieldValue, 0) || math.IsNaN(fieldValue) {
+		if rb.buildMode == BuildModeLenient {
+			// drop the field
+			return nil
+		}
+		if math.IsInf(fieldValue, 0) {
+			return fmt.Errorf("fieldValue is Inf :%f", fieldValue)
+		}
 		return fmt.Errorf("fieldValue is NaN :%f", fieldValue)
 	}
+	rb.appendSimpleField(fieldName, fieldType, fieldValue, false)
+	return nil
+}
+
+// AddAbsentSimpleField appends a simple field without value in this interval(e.g. gauge stopped reporting),
+// which is distinct from 0, so that downstream doesn't render it as zero.
+func (rb *RowBuilder) AddAbsentSimpleField(fieldName []byte, fieldType flatMetricsV1.SimpleFieldType) error {
+	if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+		return fmt.Errorf("flat field type is unspecified")
+	}
 	if len(fieldName) == 0 {
 		return fmt.Errorf("fieldName is empty")
 	}
+	rb.appendSimpleField(fieldName, fieldType, 0, true)
+	return nil
+}
+
+// appendSimpleField copies the simple field into row simple fields.
+func (rb *RowBuilder) appendSimpleField(fieldName []byte, fieldType flatMetricsV1.SimpleFieldType, fieldValue float64, absent bool) {
 	if ShouldSanitizeFieldName(fieldName) {
 		fieldName = SanitizeFieldName(fieldName)
 	}
@@ -168,13 +364,59 @@ func (rb *RowBuilder) AddSimpleField(fieldName []byte, fieldType flatMetricsV1.S
 	}
 	sfIdx := rb.simpleFieldCount - 1
 	// copy fieldName
-	rb.simpleFields[sfIdx].name = append(rb.simpleFields[sfIdx].name[:0], fieldName...)
+	rb.simpleFields[sfIdx].name = rb.copyBytes(rb.simpleFields[sfIdx].name, fieldName)
 	// copy field type, field value
 	rb.simpleFields[sfIdx].fType = fieldType
 	rb.simpleFields[sfIdx].value = fieldValue
+	rb.simpleFields[sfIdx].absent = absent
+}
+
+// AddInt64Field appends an int64 field without precision loss, the row is built as v2 row(PayloadVersionV2).
+func (rb *RowBuilder) AddInt64Field(fieldName []byte, fieldType flatMetricsV1.SimpleFieldType, fieldValue int64) error {
+	if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+		return fmt.Errorf("flat field type is unspecified")
+	}
+	if len(fieldName) == 0 {
+		return fmt.Errorf("fieldName is empty")
+	}
+	field := rb.appendTypedField(fieldName, fieldType, flatMetricsV2.ValueTypeInt64)
+	field.intValue = fieldValue
 	return nil
 }
 
+// AddStringField appends a string field(e.g. version, state), the row is built as v2 row(PayloadVersionV2).
+func (rb *RowBuilder) AddStringField(fieldName, fieldValue []byte) error {
+	if len(fieldName) == 0 {
+		return fmt.Errorf("fieldName is empty")
+	}
+	field := rb.appendTypedField(fieldName, flatMetricsV1.SimpleFieldTypeLast, flatMetricsV2.ValueTypeString)
+	field.stringValue = rb.copyBytes(field.stringValue, fieldValue)
+	return nil
+}
+
+// appendTypedField copies the typed field into row typed fields, returns the field for setting value.
+func (rb *RowBuilder) appendTypedField(fieldName []byte, fieldType flatMetricsV1.SimpleFieldType,
+	valueType flatMetricsV2.ValueType,
+) *rowTypedField {
+	if ShouldSanitizeFieldName(fieldName) {
+		fieldName = SanitizeFieldName(fieldName)
+	}
+	rb.typedFieldCount++
+	if rb.typedFieldCount > len(rb.typedFields) {
+		rb.typedFields = append(rb.typedFields, rowTypedField{})
+	}
+	field := &rb.typedFields[rb.typedFieldCount-1]
+	field.name = rb.copyBytes(field.name, fieldName)
+	field.fType = fieldType
+	field.valueType = valueType
+	field.intValue = 0
+	field.stringValue = field.stringValue[:0]
+	return field
+}
+
+// IsV2 returns if the row has v2 only parts(int64/string fields), which is built as v2 row.
+func (rb *RowBuilder) IsV2() bool { return rb.typedFieldCount > 0 }
+
 // AddExemplar appends a exemplar
 // Return false if exemplar is invalid
 func (rb *RowBuilder) AddExemplar(name, traceID, spanID []byte, duration int64) error {
@@ -195,9 +437,9 @@ func (rb *RowBuilder) AddExemplar(name, traceID, spanID []byte, duration int64)
 	}
 	exemplarIdx := rb.exemplarFieldCount - 1
 	// copy exemplar name/trace/span
-	rb.exemplarFields[exemplarIdx].name = append(rb.exemplarFields[exemplarIdx].name[:0], name...)
-	rb.exemplarFields[exemplarIdx].traceID = append(rb.exemplarFields[exemplarIdx].traceID[:0], traceID...)
-	rb.exemplarFields[exemplarIdx].spanID = append(rb.exemplarFields[exemplarIdx].spanID[:0], spanID...)
+	rb.exemplarFields[exemplarIdx].name = rb.copyBytes(rb.exemplarFields[exemplarIdx].name, name)
+	rb.exemplarFields[exemplarIdx].traceID = rb.copyBytes(rb.exemplarFields[exemplarIdx].traceID, traceID)
+	rb.exemplarFields[exemplarIdx].spanID = rb.copyBytes(rb.exemplarFields[exemplarIdx].spanID, spanID)
 	// copy duration
 	rb.exemplarFields[exemplarIdx].duration = duration
 	return nil
@@ -256,7 +498,61 @@ func (rb *RowBuilder) AddCompoundFieldMMSC(min, max, sum, count float64) error {
 	return nil
 }
 
+// AddSummaryField appends a summary field with pre-computed quantiles(e.g. p50/p95/p99),
+// quantiles must be in [0, 1] and strictly increasing, values are the quantile values in same order.
+func (rb *RowBuilder) AddSummaryField(fieldName []byte, count, sum float64, quantiles, values []float64) error {
+	if len(fieldName) == 0 {
+		return fmt.Errorf("fieldName is empty")
+	}
+	if len(quantiles) != len(values) {
+		return fmt.Errorf("quantiles's length: %d != values's length: %d", len(quantiles), len(values))
+	}
+	if len(quantiles) == 0 {
+		return fmt.Errorf("summary quantiles are empty")
+	}
+	if !(count >= 0 && sum >= 0) || math.IsInf(count, 0) || math.IsInf(sum, 0) {
+		return fmt.Errorf("summary count: %f, sum: %f should >= 0", count, sum)
+	}
+	for idx, q := range quantiles {
+		if !(q >= 0 && q <= 1) {
+			return fmt.Errorf("summary quantile: %f is not in [0, 1]", q)
+		}
+		if idx > 0 && q <= quantiles[idx-1] {
+			return fmt.Errorf("summary quantile is not increasing")
+		}
+	}
+	for _, v := range values {
+		if math.IsInf(v, 0) {
+			return fmt.Errorf("summary value contains Inf: %f", v)
+		}
+		if math.IsNaN(v) {
+			return fmt.Errorf("summary value contains NaN: %f", v)
+		}
+	}
+	if ShouldSanitizeFieldName(fieldName) {
+		fieldName = SanitizeFieldName(fieldName)
+	}
+
+	rb.summaryFieldCount++
+	if rb.summaryFieldCount > len(rb.summaryFields) {
+		rb.summaryFields = append(rb.summaryFields, rowSummaryField{})
+	}
+	sf := &rb.summaryFields[rb.summaryFieldCount-1]
+	sf.name = rb.copyBytes(sf.name, fieldName)
+	sf.count = count
+	sf.sum = sum
+	sf.quantiles = append(sf.quantiles[:0], quantiles...)
+	sf.values = append(sf.values[:0], values...)
+	return nil
+}
+
 func (rb *RowBuilder) AddMetricName(metricName []byte) {
+	if rb.sanitizer != nil {
+		var err error
+		rb.metricName, err = rb.sanitizer.Sanitize(SanitizeTargetMetricName, rb.metricName[:0], metricName)
+		rb.setSanitizeErr(SanitizeTargetMetricName, err)
+		return
+	}
 	if ShouldSanitizeNamespaceOrMetricName(metricName) {
 		metricName = SanitizeNamespaceOrMetricName(metricName)
 	}
@@ -264,21 +560,34 @@ func (rb *RowBuilder) AddMetricName(metricName []byte) {
 }
 
 func (rb *RowBuilder) AddNameSpace(namespace []byte) {
+	if rb.sanitizer != nil {
+		var err error
+		rb.nameSpace, err = rb.sanitizer.Sanitize(SanitizeTargetNamespace, rb.nameSpace[:0], namespace)
+		rb.setSanitizeErr(SanitizeTargetNamespace, err)
+		return
+	}
 	if ShouldSanitizeNamespaceOrMetricName(namespace) {
 		namespace = SanitizeNamespaceOrMetricName(namespace)
 	}
 	rb.nameSpace = append(rb.nameSpace[:0], namespace...)
 }
 
+// setSanitizeErr records the sanitize error of namespace/metric name, which is returned when building.
+func (rb *RowBuilder) setSanitizeErr(target SanitizeTarget, err error) {
+	rb.sanitizeErrs[target] = err
+}
+
 func (rb *RowBuilder) Reset() {
 	// reset flat builder context
 	rb.flatBuilder.Reset()
 	rb.metricName = rb.metricName[:0]
 	rb.nameSpace = rb.nameSpace[:0]
 	rb.timestamp = 0
+	rb.sanitizeErrs = [SanitizeTargetMetricName + 1]error{}
 
 	// reset kvs context
 	rb.rowKVs.kvCount = 0
+	rb.tagsSorted = false
 
 	// reset simple fields context
 	rb.simpleFieldCount = 0
@@ -293,6 +602,11 @@ func (rb *RowBuilder) Reset() {
 	rb.compoundFieldSum = 0
 	rb.compoundFieldCount = 0
 
+	// reset summary fields context
+	rb.summaryFieldCount = 0
+	// reset typed fields context
+	rb.typedFieldCount = 0
+
 	rb.keys = rb.keys[:0]
 	rb.values = rb.values[:0]
 	rb.kvs = rb.kvs[:0]
@@ -302,6 +616,9 @@ func (rb *RowBuilder) Reset() {
 	rb.exemplarTraces = rb.exemplarTraces[:0]
 	rb.exemplarSpans = rb.exemplarSpans[:0]
 	rb.exemplars = rb.exemplars[:0]
+	rb.quantiles = rb.quantiles[:0]
+	rb.summaries = rb.summaries[:0]
+	rb.typedOffsets = rb.typedOffsets[:0]
 }
 
 var (
@@ -310,6 +627,9 @@ var (
 )
 
 func (rb *RowBuilder) _xxHashOfKVs() uint64 {
+	if rb.hashStrategy != nil {
+		return rb.hashStrategy.HashTags(&rb.rowKVs)
+	}
 	if rb.rowKVs.kvCount == 0 {
 		return emptyStringHash
 	}
@@ -326,6 +646,9 @@ func (rb *RowBuilder) _xxHashOfKVs() uint64 {
 }
 
 func (rb *RowBuilder) _xxHashOfName() uint64 {
+	if rb.hashStrategy != nil {
+		return rb.hashStrategy.HashName(rb.nameSpace, rb.metricName)
+	}
 	rb.hashBuf.Reset()
 
 	if len(rb.nameSpace) > 0 {
@@ -337,7 +660,7 @@ func (rb *RowBuilder) _xxHashOfName() uint64 {
 
 // dedupTags removes duplicated tags
 func (rb *RowBuilder) dedupTagsThenXXHash() uint64 {
-	if rb.rowKVs.kvCount < 2 {
+	if rb.rowKVs.kvCount < 2 || rb.tagsSorted {
 		return rb._xxHashOfKVs()
 	}
 	if !sort.IsSorted(rb.rowKVs) {
@@ -371,16 +694,48 @@ func (rb *RowBuilder) dedupTagsThenXXHash() uint64 {
 }
 
 func (rb *RowBuilder) Build() ([]byte, error) {
+	for _, err := range rb.sanitizeErrs {
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(rb.metricName) == 0 {
 		return nil, fmt.Errorf("metric-name is empty")
 	}
-	if rb.simpleFieldCount == 0 && len(rb.compoundFieldValues) == 0 {
-		return nil, fmt.Errorf("simple field and compound field are both empty")
+	if rb.simpleFieldCount == 0 && len(rb.compoundFieldValues) == 0 && rb.summaryFieldCount == 0 && rb.typedFieldCount == 0 {
+		return nil, fmt.Errorf("simple field, compound field and summary field are all empty")
+	}
+	switch {
+	case rb.buildMode == BuildModeStrict:
+		if err := rb.checkDuplicates(); err != nil {
+			return nil, err
+		}
+	case rb.buildMode == BuildModeLenient:
+		rb.truncateMetricName()
+		rb.mergeSimpleFields()
+	case rb.fieldMerge:
+		rb.mergeSimpleFields()
+	}
+	var originalTags flatbuffers.UOffsetT
+	if rb.tagOrder && rb.rowKVs.kvCount > 0 {
+		originalTags = rb.buildOriginalTags()
+	}
+	if len(rb.globalTags) > 0 {
+		rb.applyGlobalTags()
 	}
 	hash := rb.dedupTagsThenXXHash()
+	if rb.limits != nil {
+		fields := rb.simpleFieldCount + rb.summaryFieldCount + rb.typedFieldCount
+		if len(rb.compoundFieldValues) > 0 {
+			fields++
+		}
+		if err := rb.limits.checkRow(rb.metricName, rb.rowKVs.kvCount, fields); err != nil {
+			return nil, err
+		}
+	}
 	for i := 0; i < rb.rowKVs.kvCount; i++ {
-		rb.keys = append(rb.keys, rb.flatBuilder.CreateByteString(rb.rowKVs.kvs[i].key))
-		rb.values = append(rb.values, rb.flatBuilder.CreateByteString(rb.rowKVs.kvs[i].value))
+		rb.keys = append(rb.keys, rb.createByteString(rb.rowKVs.kvs[i].key))
+		rb.values = append(rb.values, rb.createByteString(rb.rowKVs.kvs[i].value))
 	}
 	// building key values vector
 	for i := 0; i < len(rb.keys); i++ {
@@ -392,13 +747,14 @@ func (rb *RowBuilder) Build() ([]byte, error) {
 	// building field names
 	for i := 0; i < rb.simpleFieldCount; i++ {
 		// field name offsets
-		rb.fieldNames = append(rb.fieldNames, rb.flatBuilder.CreateByteString(rb.simpleFields[i].name))
+		rb.fieldNames = append(rb.fieldNames, rb.createByteString(rb.simpleFields[i].name))
 	}
 	for i := 0; i < rb.simpleFieldCount; i++ {
 		flatMetricsV1.SimpleFieldStart(rb.flatBuilder)
 		flatMetricsV1.SimpleFieldAddName(rb.flatBuilder, rb.fieldNames[i]) // write field name offset
 		flatMetricsV1.SimpleFieldAddType(rb.flatBuilder, rb.simpleFields[i].fType)
 		flatMetricsV1.SimpleFieldAddValue(rb.flatBuilder, rb.simpleFields[i].value)
+		flatMetricsV1.SimpleFieldAddAbsent(rb.flatBuilder, rb.simpleFields[i].absent)
 		rb.fields = append(rb.fields, flatMetricsV1.SimpleFieldEnd(rb.flatBuilder))
 	}
 
@@ -437,6 +793,17 @@ func (rb *RowBuilder) Build() ([]byte, error) {
 	}
 	exemplars := rb.flatBuilder.EndVector(rb.exemplarFieldCount)
 
+	// serialize summary fields
+	var summaries flatbuffers.UOffsetT
+	if rb.summaryFieldCount > 0 {
+		summaries = rb.buildSummaryFields()
+	}
+	// serialize typed fields of v2
+	var typedFields flatbuffers.UOffsetT
+	if rb.typedFieldCount > 0 {
+		typedFields = rb.buildTypedFields()
+	}
+
 	var (
 		compoundFieldBounds flatbuffers.UOffsetT
 		compoundFieldValues flatbuffers.UOffsetT
@@ -478,7 +845,12 @@ Serialize:
 		namespace = rb.flatBuilder.CreateByteString(rb.nameSpace)
 	}
 
-	flatMetricsV1.MetricStart(rb.flatBuilder)
+	if typedFields != 0 {
+		// v2 metric is a superset of v1 metric, parts of v1 are added by v1 functions
+		flatMetricsV2.MetricStart(rb.flatBuilder)
+	} else {
+		flatMetricsV1.MetricStart(rb.flatBuilder)
+	}
 	flatMetricsV1.MetricAddNamespace(rb.flatBuilder, namespace)
 	flatMetricsV1.MetricAddName(rb.flatBuilder, metricName)
 	if rb.timestamp == 0 {
@@ -493,13 +865,172 @@ Serialize:
 	if compoundField != 0 {
 		flatMetricsV1.MetricAddCompoundField(rb.flatBuilder, compoundField)
 	}
+	if summaries != 0 {
+		flatMetricsV1.MetricAddSummaryFields(rb.flatBuilder, summaries)
+	}
+	if typedFields != 0 {
+		flatMetricsV2.MetricAddFields(rb.flatBuilder, typedFields)
+	}
+	if originalTags != 0 {
+		// same slot in v1/v2 metric
+		flatMetricsV1.MetricAddOriginalTags(rb.flatBuilder, originalTags)
+	}
 	end := flatMetricsV1.MetricEnd(rb.flatBuilder)
 	// size prefix encoding
 	rb.flatBuilder.FinishSizePrefixed(end)
 
-	return rb.flatBuilder.FinishedBytes(), nil
+	data := rb.flatBuilder.FinishedBytes()
+	if rb.limits != nil {
+		if err := rb.limits.checkRowSize(rb.metricName, len(data)-flatbuffers.SizeUint32); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// buildSummaryFields writes all summary fields into flat builder, returns the offset of summary fields vector.
+func (rb *RowBuilder) buildSummaryFields() flatbuffers.UOffsetT {
+	for i := 0; i < rb.summaryFieldCount; i++ {
+		sf := &rb.summaryFields[i]
+		name := rb.createByteString(sf.name)
+		rb.quantiles = rb.quantiles[:0]
+		for j := range sf.quantiles {
+			flatMetricsV1.QuantileValueStart(rb.flatBuilder)
+			flatMetricsV1.QuantileValueAddQuantile(rb.flatBuilder, sf.quantiles[j])
+			flatMetricsV1.QuantileValueAddValue(rb.flatBuilder, sf.values[j])
+			rb.quantiles = append(rb.quantiles, flatMetricsV1.QuantileValueEnd(rb.flatBuilder))
+		}
+		flatMetricsV1.SummaryFieldStartQuantilesVector(rb.flatBuilder, len(rb.quantiles))
+		for j := len(rb.quantiles) - 1; j >= 0; j-- {
+			rb.flatBuilder.PrependUOffsetT(rb.quantiles[j])
+		}
+		quantiles := rb.flatBuilder.EndVector(len(rb.quantiles))
+
+		flatMetricsV1.SummaryFieldStart(rb.flatBuilder)
+		flatMetricsV1.SummaryFieldAddName(rb.flatBuilder, name)
+		flatMetricsV1.SummaryFieldAddCount(rb.flatBuilder, sf.count)
+		flatMetricsV1.SummaryFieldAddSum(rb.flatBuilder, sf.sum)
+		flatMetricsV1.SummaryFieldAddQuantiles(rb.flatBuilder, quantiles)
+		rb.summaries = append(rb.summaries, flatMetricsV1.SummaryFieldEnd(rb.flatBuilder))
+	}
+	flatMetricsV1.MetricStartSummaryFieldsVector(rb.flatBuilder, rb.summaryFieldCount)
+	for i := rb.summaryFieldCount - 1; i >= 0; i-- {
+		rb.flatBuilder.PrependUOffsetT(rb.summaries[i])
+	}
+	return rb.flatBuilder.EndVector(rb.summaryFieldCount)
+}
+
+// buildTypedFields writes all typed fields into flat builder, returns the offset of v2 fields vector.
+func (rb *RowBuilder) buildTypedFields() flatbuffers.UOffsetT {
+	for i := 0; i < rb.typedFieldCount; i++ {
+		tf := &rb.typedFields[i]
+		name := rb.createByteString(tf.name)
+		var stringValue flatbuffers.UOffsetT
+		if tf.valueType == flatMetricsV2.ValueTypeString {
+			stringValue = rb.flatBuilder.CreateByteString(tf.stringValue)
+		}
+		flatMetricsV2.FieldStart(rb.flatBuilder)
+		flatMetricsV2.FieldAddName(rb.flatBuilder, name)
+		flatMetricsV2.FieldAddType(rb.flatBuilder, tf.fType)
+		flatMetricsV2.FieldAddValueType(rb.flatBuilder, tf.valueType)
+		if stringValue != 0 {
+			flatMetricsV2.FieldAddStringValue(rb.flatBuilder, stringValue)
+		} else {
+			flatMetricsV2.FieldAddIntValue(rb.flatBuilder, tf.intValue)
+		}
+		rb.typedOffsets = append(rb.typedOffsets, flatMetricsV2.FieldEnd(rb.flatBuilder))
+	}
+	flatMetricsV2.MetricStartFieldsVector(rb.flatBuilder, rb.typedFieldCount)
+	for i := rb.typedFieldCount - 1; i >= 0; i-- {
+		rb.flatBuilder.PrependUOffsetT(rb.typedOffsets[i])
+	}
+	return rb.flatBuilder.EndVector(rb.typedFieldCount)
+}
+
+// size estimation of flat metric, includes vtable, table, alignment padding of each part.
+const (
+	metricEstimatedSize        = 4 + 4 + 24 + 64 // size prefix + root offset + vtable + table
+	keyValueEstimatedSize      = 8 + 12 + 4      // vtable + table + offset in vector
+	simpleFieldEstimatedSize   = 12 + 24 + 4     // vtable + table + offset in vector
+	exemplarEstimatedSize      = 12 + 32 + 4     // vtable + table + offset in vector
+	compoundFieldEstimatedSize = 16 + 48 + 2*8   // vtable + table + 2 vectors' length/padding
+	summaryFieldEstimatedSize  = 12 + 32 + 8 + 4 // vtable + table + quantiles vector's length/padding + offset in vector
+	quantileEstimatedSize      = 8 + 24 + 4      // vtable + table + offset in vector
+	typedFieldEstimatedSize    = 16 + 24 + 4     // vtable + table + offset in vector
+	typedFieldsEstimatedSize   = 8 + 8           // v2 metric's vtable slot + table offset + vector's length/padding
+	vectorEstimatedSize        = 8               // length + padding
+)
+
+// EstimatedSize returns the estimated byte size of the flat metric built by Build, it's an upper bound
+// without string dedup and vtable dedup, which can be used to cut off the batch before building.
+func (rb *RowBuilder) EstimatedSize() int {
+	size := metricEstimatedSize + estimatedStringSize(len(rb.nameSpace)) + estimatedStringSize(len(rb.metricName))
+	// tags
+	size += vectorEstimatedSize
+	for i := 0; i < rb.rowKVs.kvCount; i++ {
+		size += keyValueEstimatedSize +
+			estimatedStringSize(len(rb.rowKVs.kvs[i].key)) + estimatedStringSize(len(rb.rowKVs.kvs[i].value))
+	}
+	// simple fields
+	size += vectorEstimatedSize
+	for i := 0; i < rb.simpleFieldCount; i++ {
+		size += simpleFieldEstimatedSize + estimatedStringSize(len(rb.simpleFields[i].name))
+	}
+	// exemplars
+	size += vectorEstimatedSize
+	for i := 0; i < rb.exemplarFieldCount; i++ {
+		exemplar := &rb.exemplarFields[i]
+		size += exemplarEstimatedSize + estimatedStringSize(len(exemplar.name)) +
+			estimatedStringSize(len(exemplar.traceID)) + estimatedStringSize(len(exemplar.spanID))
+	}
+	// compound field
+	if len(rb.compoundFieldValues) > 0 {
+		size += compoundFieldEstimatedSize + 8*(len(rb.compoundFieldValues)+len(rb.compoundFieldExplicitValues))
+	}
+	// summary fields
+	if rb.summaryFieldCount > 0 {
+		size += vectorEstimatedSize
+	}
+	for i := 0; i < rb.summaryFieldCount; i++ {
+		sf := &rb.summaryFields[i]
+		size += summaryFieldEstimatedSize + estimatedStringSize(len(sf.name)) + quantileEstimatedSize*len(sf.quantiles)
+	}
+	// typed fields of v2
+	if rb.typedFieldCount > 0 {
+		size += typedFieldsEstimatedSize
+	}
+	for i := 0; i < rb.typedFieldCount; i++ {
+		tf := &rb.typedFields[i]
+		size += typedFieldEstimatedSize + estimatedStringSize(len(tf.name))
+		if tf.valueType == flatMetricsV2.ValueTypeString {
+			size += estimatedStringSize(len(tf.stringValue))
+		}
+	}
+	return size
+}
+
+// estimatedStringSize returns the byte size of flat string, includes length, null terminator and padding.
+func estimatedStringSize(length int) int {
+	return (4 + length + 1 + 3) &^ 3
+}
+
+// createByteString writes bytes as string into flat builder. If shared strings enabled(batch builder),
+// the same strings(tag key/value, field name) in one row are written once, the bytes are converted into string
+// once per batch by the shared string table, so that rows with same strings don't allocate.
+func (rb *RowBuilder) createByteString(s []byte) flatbuffers.UOffsetT {
+	if rb.sharedStrings == nil || len(s) > maxSharedStringLen {
+		return rb.flatBuilder.CreateByteString(s)
+	}
+	str, ok := rb.sharedStrings[string(s)] // no allocation for map lookup
+	if !ok {
+		str = string(s)
+		rb.sharedStrings[str] = str
+	}
+	return rb.flatBuilder.CreateSharedString(str)
 }
 
 func (rb *RowBuilder) SimpleFieldsLen() int { return rb.simpleFieldCount }
 
 func (rb *RowBuilder) ExemplarsLen() int { return rb.exemplarFieldCount }
+
+func (rb *RowBuilder) SummaryFieldsLen() int { return rb.summaryFieldCount }
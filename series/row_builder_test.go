@@ -18,6 +18,7 @@
 package series
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"testing"
@@ -208,6 +209,57 @@ func Test_dedupTagsThenXXHash_One(t *testing.T) {
 	assert.Equal(t, "ccc=g", rb.hashBuf.String())
 }
 
+func Test_RowBuilder_AddTags(t *testing.T) {
+	rb := CreateRowBuilder()
+	assert.NoError(t, rb.AddTags(map[string]string{"ccc": "a", "a": "b"}))
+	assert.NoError(t, rb.AddTag([]byte("a"), []byte("c")))
+	assert.False(t, rb.tagsSorted)
+	_ = rb.dedupTagsThenXXHash()
+	assert.Equal(t, "a=c,ccc=a", rb.hashBuf.String())
+
+	assert.Error(t, rb.AddTags(map[string]string{"a": ""}))
+}
+
+func Test_RowBuilder_AddSortedTags(t *testing.T) {
+	rb := CreateRowBuilder()
+	// invalid sorted tags
+	assert.Error(t, rb.AddSortedTags([][]byte{[]byte("a")}, nil))
+	assert.Error(t, rb.AddSortedTags([][]byte{[]byte("a")}, [][]byte{{}}))
+	assert.Error(t, rb.AddSortedTags([][]byte{[]byte("b"), []byte("a")}, [][]byte{[]byte("1"), []byte("2")}))
+	assert.Error(t, rb.AddSortedTags([][]byte{[]byte("a"), []byte("a")}, [][]byte{[]byte("1"), []byte("2")}))
+	assert.Zero(t, rb.rowKVs.kvCount)
+
+	assert.NoError(t, rb.AddSortedTags(
+		[][]byte{[]byte("a"), []byte("b"), []byte("c")},
+		[][]byte{[]byte("1"), []byte("2"), []byte("3")}))
+	assert.True(t, rb.tagsSorted)
+	sortedHash := rb.dedupTagsThenXXHash()
+	assert.Equal(t, "a=1,b=2,c=3", rb.hashBuf.String())
+
+	// same hash as unsorted tags
+	rb.Reset()
+	assert.False(t, rb.tagsSorted)
+	_ = rb.AddTag([]byte("c"), []byte("3"))
+	_ = rb.AddTag([]byte("a"), []byte("1"))
+	_ = rb.AddTag([]byte("b"), []byte("2"))
+	assert.Equal(t, sortedHash, rb.dedupTagsThenXXHash())
+
+	// mixed with other tags, need sorting
+	rb.Reset()
+	_ = rb.AddTag([]byte("c"), []byte("4"))
+	assert.NoError(t, rb.AddSortedTags([][]byte{[]byte("a"), []byte("b")}, [][]byte{[]byte("1"), []byte("2")}))
+	assert.False(t, rb.tagsSorted)
+	_ = rb.dedupTagsThenXXHash()
+	assert.Equal(t, "a=1,b=2,c=4", rb.hashBuf.String())
+
+	rb.Reset()
+	assert.NoError(t, rb.AddSortedTags([][]byte{[]byte("b")}, [][]byte{[]byte("2")}))
+	_ = rb.AddTag([]byte("a"), []byte("1"))
+	assert.False(t, rb.tagsSorted)
+	_ = rb.dedupTagsThenXXHash()
+	assert.Equal(t, "a=1,b=2", rb.hashBuf.String())
+}
+
 func buildFlatMetric(builder *flatbuffers.Builder) {
 	builder.Reset()
 
@@ -300,3 +352,144 @@ func buildFlatMetric(builder *flatbuffers.Builder) {
 	end := flatMetricsV1.MetricEnd(builder)
 	builder.Finish(end)
 }
+
+func Benchmark_RowBuilder_AddSortedTags(b *testing.B) {
+	var keys, values [][]byte
+	for i := 0; i < 10; i++ {
+		keys = append(keys, []byte("key"+strconv.Itoa(i)))
+		values = append(values, []byte("value"+strconv.Itoa(i)))
+	}
+	rb := CreateRowBuilder()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.Reset()
+		rb.AddMetricName([]byte("cpu"))
+		_ = rb.AddSortedTags(keys, values)
+		_ = rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1)
+		_, _ = rb.Build()
+	}
+}
+
+func Test_RowBuilder_AddSummaryField(t *testing.T) {
+	rb := CreateRowBuilder()
+	rb.AddMetricName([]byte("rpc"))
+	// invalid summary
+	assert.Error(t, rb.AddSummaryField(nil, 1, 1, []float64{0.5}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{0.5}, nil))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, nil, nil))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), -1, 1, []float64{0.5}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, math.NaN(), []float64{0.5}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), math.Inf(1), 1, []float64{0.5}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{1.5}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{math.NaN()}, []float64{1}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{0.9, 0.5}, []float64{1, 2}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{0.5}, []float64{math.Inf(1)}))
+	assert.Error(t, rb.AddSummaryField([]byte("f"), 1, 1, []float64{0.5}, []float64{math.NaN()}))
+	assert.Zero(t, rb.SummaryFieldsLen())
+
+	// summary field only
+	assert.NoError(t, rb.AddSummaryField([]byte("latency"), 10, 20, []float64{0.5, 0.95, 0.99}, []float64{1, 4, 5}))
+	assert.NoError(t, rb.AddSummaryField([]byte("c"), 0, 0, []float64{0}, []float64{0}))
+	assert.Equal(t, 2, rb.SummaryFieldsLen())
+	data, err := rb.Build()
+	assert.NoError(t, err)
+
+	m := flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	assert.Equal(t, 2, m.SummaryFieldsLength())
+	var (
+		sf flatMetricsV1.SummaryField
+		qv flatMetricsV1.QuantileValue
+	)
+	assert.True(t, m.SummaryFields(&sf, 0))
+	assert.Equal(t, "latency", string(sf.Name()))
+	assert.Equal(t, float64(10), sf.Count())
+	assert.Equal(t, float64(20), sf.Sum())
+	assert.Equal(t, 3, sf.QuantilesLength())
+	assert.True(t, sf.Quantiles(&qv, 1))
+	assert.Equal(t, 0.95, qv.Quantile())
+	assert.Equal(t, float64(4), qv.Value())
+
+	// reset summary fields
+	rb.Reset()
+	assert.Zero(t, rb.SummaryFieldsLen())
+	rb.AddMetricName([]byte("rpc"))
+	_, err = rb.Build()
+	assert.Error(t, err)
+	assert.NoError(t, rb.AddSummaryField([]byte("c"), 1, 1, []float64{0.5}, []float64{1}))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	m = flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	assert.Equal(t, 1, m.SummaryFieldsLength())
+	assert.Zero(t, m.SimpleFieldsLength())
+}
+
+func Test_RowBuilder_AddAbsentSimpleField(t *testing.T) {
+	rb, _ := NewRowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.Error(t, rb.AddAbsentSimpleField(nil, flatMetricsV1.SimpleFieldTypeLast))
+	assert.Error(t, rb.AddAbsentSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeUnSpecified))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, rb.AddSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeLast, 0))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+
+	m := flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	var f flatMetricsV1.SimpleField
+	assert.True(t, m.SimpleFields(&f, 0))
+	assert.Equal(t, "idle", string(f.Name()))
+	assert.True(t, f.Absent())
+	assert.True(t, m.SimpleFields(&f, 1))
+	assert.False(t, f.Absent())
+	assert.Zero(t, f.Value())
+
+	// absent flag is reset
+	rb.Reset()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	m = flatMetricsV1.GetSizePrefixedRootAsMetric(data, 0)
+	assert.True(t, m.SimpleFields(&f, 0))
+	assert.False(t, f.Absent())
+}
+
+func Test_RowBuilder_EstimatedSize(t *testing.T) {
+	cases := []func(rb *RowBuilder){
+		func(rb *RowBuilder) {
+			assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+		},
+		func(rb *RowBuilder) {
+			for i := 0; i < 20; i++ {
+				assert.NoError(t, rb.AddTag([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+				assert.NoError(t, rb.AddSimpleField([]byte(fmt.Sprintf("f-%d", i)), flatMetricsV1.SimpleFieldTypeLast, 1))
+			}
+		},
+		func(rb *RowBuilder) {
+			assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+			assert.NoError(t, rb.AddCompoundFieldMMSC(1, 1, 1, 1))
+			assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2, 3}, []float64{1, 2, math.Inf(1)}))
+		},
+		func(rb *RowBuilder) {
+			for i := 0; i < 5; i++ {
+				assert.NoError(t, rb.AddSummaryField([]byte(fmt.Sprintf("s-%d", i)), 1, 1,
+					[]float64{0.5, 0.9, 0.99}, []float64{1, 2, 3}))
+			}
+		},
+	}
+	for _, fn := range cases {
+		rb := CreateRowBuilder()
+		rb.AddNameSpace([]byte("ns"))
+		rb.AddMetricName([]byte("cpu"))
+		rb.AddTimestamp(1)
+		fn(rb)
+		size := rb.EstimatedSize()
+		data, err := rb.Build()
+		assert.NoError(t, err)
+		// upper bound, but not too large
+		assert.GreaterOrEqual(t, size, len(data))
+		assert.LessOrEqual(t, size, len(data)*3/2)
+	}
+	assert.Equal(t, 8, estimatedStringSize(0))
+	assert.Equal(t, 8, estimatedStringSize(3))
+	assert.Equal(t, 12, estimatedStringSize(4))
+}
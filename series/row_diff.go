@@ -0,0 +1,208 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RowsEqual returns if the rows of two flat metric buffers are semantically equal,
+// ignoring the order of rows/tags/fields and the flatbuffers layout.
+func RowsEqual(a, b []byte) bool {
+	return len(DiffRows(a, b)) == 0
+}
+
+// DiffRows compares the rows of two flat metric buffers semantically(see RowsEqual),
+// returns the human-readable differences, nil if equal. It's used by test suites,
+// so that the assertions don't break whenever the encoding order changes.
+func DiffRows(a, b []byte) []string {
+	rowsA, err := decodeRows(a)
+	if err != nil {
+		return []string{fmt.Sprintf("decode a failure: %v", err)}
+	}
+	rowsB, err := decodeRows(b)
+	if err != nil {
+		return []string{fmt.Sprintf("decode b failure: %v", err)}
+	}
+	// group rows by series(namespace/name/tags/timestamp)
+	var keys []string
+	groupA := make(map[string][]*Row)
+	groupB := make(map[string][]*Row)
+	group := func(rows []*Row, groups map[string][]*Row) {
+		for _, row := range rows {
+			key := rowKey(row)
+			if _, ok := groupA[key]; !ok {
+				if _, ok = groupB[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			groups[key] = append(groups[key], row)
+		}
+	}
+	group(rowsA, groupA)
+	group(rowsB, groupB)
+
+	var diffs []string
+	for _, key := range keys {
+		ra, rb := groupA[key], groupB[key]
+		if len(ra) == 1 && len(rb) == 1 {
+			for _, diff := range diffRow(ra[0], rb[0]) {
+				diffs = append(diffs, key+": "+diff)
+			}
+			continue
+		}
+		// duplicated series or missing series, compare as multiset
+		diffs = append(diffs, diffStrings(rowStrings(ra), rowStrings(rb), "row")...)
+	}
+	return diffs
+}
+
+// decodeRows decodes and normalizes all rows of the buffer.
+func decodeRows(payload []byte) ([]*Row, error) {
+	var rows []*Row
+	itr := NewRowIterator(payload)
+	for itr.Next() {
+		rows = append(rows, normalizeRow(itr.Row()))
+	}
+	if err := itr.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// normalizeRow sorts the tags/fields/exemplars of the row, buckets and quantiles keep their order.
+func normalizeRow(row *Row) *Row {
+	sort.SliceStable(row.Tags, func(i, j int) bool {
+		return row.Tags[i].Key < row.Tags[j].Key
+	})
+	sort.SliceStable(row.SimpleFields, func(i, j int) bool {
+		fi, fj := row.SimpleFields[i], row.SimpleFields[j]
+		if fi.Name != fj.Name {
+			return fi.Name < fj.Name
+		}
+		return fi.Type < fj.Type
+	})
+	sort.SliceStable(row.SummaryFields, func(i, j int) bool {
+		return row.SummaryFields[i].Name < row.SummaryFields[j].Name
+	})
+	sort.SliceStable(row.Exemplars, func(i, j int) bool {
+		return exemplarString(row.Exemplars[i]) < exemplarString(row.Exemplars[j])
+	})
+	return row
+}
+
+// rowKey returns the series identity of the normalized row.
+func rowKey(row *Row) string {
+	key := Row{Namespace: row.Namespace, Name: row.Name, Timestamp: row.Timestamp, Tags: row.Tags}
+	return key.String()
+}
+
+// diffRow returns the differences of fields between two rows of same series.
+func diffRow(a, b *Row) []string {
+	var diffs []string
+	// simple fields
+	var fieldsA, fieldsB []string
+	for _, f := range a.SimpleFields {
+		fieldsA = append(fieldsA, simpleFieldString(f))
+	}
+	for _, f := range b.SimpleFields {
+		fieldsB = append(fieldsB, simpleFieldString(f))
+	}
+	diffs = append(diffs, diffStrings(fieldsA, fieldsB, "simple field")...)
+	// compound field
+	compoundA, compoundB := compoundFieldString(a.CompoundField), compoundFieldString(b.CompoundField)
+	if compoundA != compoundB {
+		diffs = append(diffs, fmt.Sprintf("compound field: %s != %s", compoundA, compoundB))
+	}
+	// summary fields
+	var summariesA, summariesB []string
+	for _, s := range a.SummaryFields {
+		summariesA = append(summariesA, strings.TrimSpace((&Row{SummaryFields: []SummaryField{s}}).fieldsString()))
+	}
+	for _, s := range b.SummaryFields {
+		summariesB = append(summariesB, strings.TrimSpace((&Row{SummaryFields: []SummaryField{s}}).fieldsString()))
+	}
+	diffs = append(diffs, diffStrings(summariesA, summariesB, "summary field")...)
+	// exemplars
+	var exemplarsA, exemplarsB []string
+	for _, e := range a.Exemplars {
+		exemplarsA = append(exemplarsA, exemplarString(e))
+	}
+	for _, e := range b.Exemplars {
+		exemplarsB = append(exemplarsB, exemplarString(e))
+	}
+	diffs = append(diffs, diffStrings(exemplarsA, exemplarsB, "exemplar")...)
+	return diffs
+}
+
+// diffStrings compares two multisets of string, returns the missing(only in a) and unexpected(only in b) items.
+func diffStrings(a, b []string, kind string) []string {
+	counts := make(map[string]int)
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+	}
+	var diffs []string
+	appendDiff := func(items []string, positive bool, state string) {
+		for _, s := range items {
+			c := counts[s]
+			if (positive && c > 0) || (!positive && c < 0) {
+				diffs = append(diffs, fmt.Sprintf("%s %s: %s", state, kind, s))
+				if positive {
+					counts[s]--
+				} else {
+					counts[s]++
+				}
+			}
+		}
+	}
+	appendDiff(a, true, "missing")
+	appendDiff(b, false, "unexpected")
+	return diffs
+}
+
+// rowStrings returns the string representations of rows.
+func rowStrings(rows []*Row) []string {
+	rs := make([]string, len(rows))
+	for i, row := range rows {
+		rs[i] = row.String()
+	}
+	return rs
+}
+
+// simpleFieldString returns the string representation of simple field.
+func simpleFieldString(f SimpleField) string {
+	return strings.TrimSpace((&Row{SimpleFields: []SimpleField{f}}).fieldsString())
+}
+
+// compoundFieldString returns the string representation of compound field, "none" if nil.
+func compoundFieldString(c *CompoundField) string {
+	if c == nil {
+		return "none"
+	}
+	return strings.TrimSpace((&Row{CompoundField: c}).fieldsString())
+}
+
+// exemplarString returns the string representation of exemplar.
+func exemplarString(e Exemplar) string {
+	return strings.TrimSpace((&Row{Exemplars: []Exemplar{e}}).fieldsString())
+}
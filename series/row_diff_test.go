@@ -0,0 +1,123 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func buildDiffPayload(t *testing.T, rows ...func(rb *RowBuilder)) []byte {
+	bb := NewBatchBuilder()
+	for _, fn := range rows {
+		rb := bb.RowBuilder()
+		rb.AddNameSpace([]byte("ns"))
+		rb.AddMetricName([]byte("cpu"))
+		rb.AddTimestamp(100)
+		fn(rb)
+		assert.NoError(t, bb.Commit())
+	}
+	return append([]byte(nil), bb.Payload()...)
+}
+
+func TestRowsEqual(t *testing.T) {
+	row1 := func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h1")))
+		assert.NoError(t, rb.AddTag([]byte("az"), []byte("a")))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+		assert.NoError(t, rb.AddSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+		assert.NoError(t, rb.AddExemplar([]byte("e1"), []byte("t1"), []byte("s1"), 1))
+		assert.NoError(t, rb.AddExemplar([]byte("e2"), []byte("t2"), []byte("s2"), 2))
+	}
+	row1Reordered := func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("az"), []byte("a")))
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h1")))
+		assert.NoError(t, rb.AddExemplar([]byte("e2"), []byte("t2"), []byte("s2"), 2))
+		assert.NoError(t, rb.AddSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeDeltaSum, 2))
+		assert.NoError(t, rb.AddExemplar([]byte("e1"), []byte("t1"), []byte("s1"), 1))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	}
+	row2 := func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h2")))
+		assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+		assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+		assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 20, []float64{0.5}, []float64{1}))
+		assert.NoError(t, rb.AddSummaryField([]byte("db"), 1, 2, []float64{0.99}, []float64{3}))
+	}
+	row2Reordered := func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h2")))
+		assert.NoError(t, rb.AddSummaryField([]byte("db"), 1, 2, []float64{0.99}, []float64{3}))
+		assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 20, []float64{0.5}, []float64{1}))
+		assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+		assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+	}
+	a := buildDiffPayload(t, row1, row2)
+	b := buildDiffPayload(t, row2Reordered, row1Reordered)
+	assert.NotEqual(t, a, b)
+	assert.True(t, RowsEqual(a, b))
+	assert.Nil(t, DiffRows(a, b))
+	assert.True(t, RowsEqual(nil, nil))
+
+	assert.False(t, RowsEqual(a, buildDiffPayload(t, row1)))
+	assert.False(t, RowsEqual(a, buildDiffPayload(t, row1, row2, row2)))
+}
+
+func TestDiffRows(t *testing.T) {
+	a := buildDiffPayload(t, func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h1")))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+		assert.NoError(t, rb.AddSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeLast, 2))
+		assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 20, []float64{0.5}, []float64{1}))
+		assert.NoError(t, rb.AddExemplar([]byte("e1"), []byte("t1"), []byte("s1"), 1))
+	}, func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h2")))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	})
+	b := buildDiffPayload(t, func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h1")))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 3))
+		assert.NoError(t, rb.AddAbsentSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeLast))
+		assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+		assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+		assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 21, []float64{0.5}, []float64{1}))
+	}, func(rb *RowBuilder) {
+		assert.NoError(t, rb.AddTag([]byte("host"), []byte("h3")))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	})
+	assert.Equal(t, []string{
+		"ns:cpu{host=h1} 100: missing simple field: idle(Last)=1",
+		"ns:cpu{host=h1} 100: missing simple field: usage(Last)=2",
+		"ns:cpu{host=h1} 100: unexpected simple field: idle(Last)=3",
+		"ns:cpu{host=h1} 100: unexpected simple field: usage(Last)=absent",
+		"ns:cpu{host=h1} 100: compound field: none != histogram{min=1,max=2,sum=3,count=4,buckets=[1:1,+Inf:2]}",
+		"ns:cpu{host=h1} 100: missing summary field: rpc{sum=20,count=10,quantiles=[0.5:1]}",
+		"ns:cpu{host=h1} 100: unexpected summary field: rpc{sum=21,count=10,quantiles=[0.5:1]}",
+		"ns:cpu{host=h1} 100: missing exemplar: exemplar{name=e1,trace=t1,span=s1,duration=1}",
+		"missing row: ns:cpu{host=h2} 100 idle(Last)=1",
+		"unexpected row: ns:cpu{host=h3} 100 idle(Last)=1",
+	}, DiffRows(a, b))
+
+	// corrupted payload
+	assert.Equal(t, []string{"decode a failure: corrupted batch payload, size prefix is truncated at: 0"}, DiffRows([]byte{1, 2, 3}, b))
+	assert.Len(t, DiffRows(a, []byte{1, 2, 3}), 1)
+	assert.False(t, RowsEqual(a, []byte{1, 2, 3}))
+}
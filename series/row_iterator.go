@@ -0,0 +1,205 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/proto/gen/v2/flatMetricsV2"
+)
+
+// RowIterator walks a concatenated flat metric buffer without copying,
+// each row is validated before it is exposed by the accessors.
+// All returned byte slices reference the underlying buffer, copy them if they are kept after Next.
+type RowIterator struct {
+	batch BatchIterator
+
+	metric        *flatMetricsV1.Metric
+	kv            flatMetricsV1.KeyValue
+	simpleField   flatMetricsV1.SimpleField
+	compoundField flatMetricsV1.CompoundField
+	exemplar      flatMetricsV1.Exemplar
+	summaryField  flatMetricsV1.SummaryField
+	quantile      flatMetricsV1.QuantileValue
+	hasCompound   bool
+	kvIterator    KeyValueIterator
+}
+
+// NewRowIterator creates a row iterator for the concatenated flat metric buffer.
+func NewRowIterator(data []byte) *RowIterator {
+	return &RowIterator{batch: BatchIterator{payload: data}}
+}
+
+// Next moves to next row, returns false if no more row or the buffer is corrupted(check Err).
+func (itr *RowIterator) Next() bool {
+	if !itr.batch.HasNext() {
+		return false
+	}
+	itr.metric = itr.batch.Metric()
+	if err := itr.validate(); err != nil {
+		itr.batch.err = err
+		return false
+	}
+	return true
+}
+
+// Err returns the error when iterating the buffer.
+func (itr *RowIterator) Err() error { return itr.batch.Err() }
+
+// Metric returns the underlying flat metric of current row.
+func (itr *RowIterator) Metric() *flatMetricsV1.Metric { return itr.metric }
+
+// Namespace returns the namespace of current row.
+func (itr *RowIterator) Namespace() []byte { return itr.metric.Namespace() }
+
+// Name returns the metric name of current row.
+func (itr *RowIterator) Name() []byte { return itr.metric.Name() }
+
+// Timestamp returns the timestamp(in milliseconds) of current row.
+func (itr *RowIterator) Timestamp() int64 { return itr.metric.Timestamp() }
+
+// NameHash returns the hash of namespace and metric name.
+func (itr *RowIterator) NameHash() uint64 { return itr.metric.NameHash() }
+
+// TagsHash returns the hash of sorted tags.
+func (itr *RowIterator) TagsHash() uint64 { return itr.metric.KvsHash() }
+
+// TagsLen returns the number of tags of current row.
+func (itr *RowIterator) TagsLen() int { return itr.metric.KeyValuesLength() }
+
+// Tag returns the tag key/value at index.
+func (itr *RowIterator) Tag(idx int) (key, value []byte) {
+	itr.metric.KeyValues(&itr.kv, idx)
+	return itr.kv.Key(), itr.kv.Value()
+}
+
+// SimpleFieldsLen returns the number of simple fields of current row.
+func (itr *RowIterator) SimpleFieldsLen() int { return itr.metric.SimpleFieldsLength() }
+
+// SimpleField returns the simple field at index.
+func (itr *RowIterator) SimpleField(idx int) (name []byte, fieldType flatMetricsV1.SimpleFieldType, value float64) {
+	itr.metric.SimpleFields(&itr.simpleField, idx)
+	return itr.simpleField.Name(), itr.simpleField.Type(), itr.simpleField.Value()
+}
+
+// SimpleFieldIsAbsent returns if the simple field at index has no value in this interval,
+// the value of absent field should be ignored instead of treated as 0.
+func (itr *RowIterator) SimpleFieldIsAbsent(idx int) bool {
+	itr.metric.SimpleFields(&itr.simpleField, idx)
+	return itr.simpleField.Absent()
+}
+
+// HasCompoundField returns if current row has compound field.
+func (itr *RowIterator) HasCompoundField() bool { return itr.hasCompound }
+
+// CompoundFieldMMSC returns min/max/sum/count of the compound field.
+func (itr *RowIterator) CompoundFieldMMSC() (min, max, sum, count float64) {
+	return itr.compoundField.Min(), itr.compoundField.Max(), itr.compoundField.Sum(), itr.compoundField.Count()
+}
+
+// CompoundFieldBucketsLen returns the number of buckets of the compound field.
+func (itr *RowIterator) CompoundFieldBucketsLen() int { return itr.compoundField.ValuesLength() }
+
+// CompoundFieldBucket returns the explicit upper bound and value of the bucket at index.
+func (itr *RowIterator) CompoundFieldBucket(idx int) (bound, value float64) {
+	return itr.compoundField.ExplicitBounds(idx), itr.compoundField.Values(idx)
+}
+
+// ExemplarsLen returns the number of exemplars of current row.
+func (itr *RowIterator) ExemplarsLen() int { return itr.metric.ExemplarsLength() }
+
+// Exemplar returns the exemplar at index.
+func (itr *RowIterator) Exemplar(idx int) (name, traceID, spanID []byte, duration int64) {
+	itr.metric.Exemplars(&itr.exemplar, idx)
+	return itr.exemplar.Name(), itr.exemplar.TraceId(), itr.exemplar.SpanId(), itr.exemplar.Duration()
+}
+
+// SummaryFieldsLen returns the number of summary fields of current row.
+func (itr *RowIterator) SummaryFieldsLen() int { return itr.metric.SummaryFieldsLength() }
+
+// SummaryField returns the name/count/sum and the number of quantiles of the summary field at index,
+// quantiles are read by SummaryFieldQuantile after it.
+func (itr *RowIterator) SummaryField(idx int) (name []byte, count, sum float64, quantiles int) {
+	itr.metric.SummaryFields(&itr.summaryField, idx)
+	return itr.summaryField.Name(), itr.summaryField.Count(), itr.summaryField.Sum(), itr.summaryField.QuantilesLength()
+}
+
+// SummaryFieldQuantile returns the quantile and its value at index of the summary field selected by SummaryField.
+func (itr *RowIterator) SummaryFieldQuantile(idx int) (quantile, value float64) {
+	itr.summaryField.Quantiles(&itr.quantile, idx)
+	return itr.quantile.Quantile(), itr.quantile.Value()
+}
+
+// typedFieldsLen returns the number of int64/string fields if the metric is v2 row.
+func typedFieldsLen(metric *flatMetricsV1.Metric) int {
+	var metricV2 flatMetricsV2.Metric
+	table := metric.Table()
+	metricV2.Init(table.Bytes, table.Pos)
+	return metricV2.FieldsLength()
+}
+
+// validate walks all parts of current row, returns error if row is corrupted or invalid.
+func (itr *RowIterator) validate() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("corrupted flat metric: %v", r)
+		}
+	}()
+	if len(itr.Name()) == 0 {
+		return fmt.Errorf("metric-name is empty")
+	}
+	_ = itr.Namespace()
+	for i := 0; i < itr.TagsLen(); i++ {
+		if key, value := itr.Tag(i); len(key) == 0 || len(value) == 0 {
+			return fmt.Errorf("tag[%s: %s] is empty", string(key), string(value))
+		}
+	}
+	for i := 0; i < itr.SimpleFieldsLen(); i++ {
+		if name, _, _ := itr.SimpleField(i); len(name) == 0 {
+			return fmt.Errorf("fieldName is empty")
+		}
+		_ = itr.simpleField.Absent()
+	}
+	for i := 0; i < itr.ExemplarsLen(); i++ {
+		_, _, _, _ = itr.Exemplar(i)
+	}
+	itr.hasCompound = itr.metric.CompoundField(&itr.compoundField) != nil
+	if itr.hasCompound {
+		if itr.compoundField.ValuesLength() != itr.compoundField.ExplicitBoundsLength() {
+			return fmt.Errorf("values's length: %d != explicit-bounds's length: %d",
+				itr.compoundField.ValuesLength(), itr.compoundField.ExplicitBoundsLength())
+		}
+		for i := 0; i < itr.CompoundFieldBucketsLen(); i++ {
+			_, _ = itr.CompoundFieldBucket(i)
+		}
+	}
+	for i := 0; i < itr.SummaryFieldsLen(); i++ {
+		name, _, _, quantiles := itr.SummaryField(i)
+		if len(name) == 0 {
+			return fmt.Errorf("fieldName is empty")
+		}
+		for j := 0; j < quantiles; j++ {
+			_, _ = itr.SummaryFieldQuantile(j)
+		}
+	}
+	if itr.SimpleFieldsLen() == 0 && !itr.hasCompound && itr.SummaryFieldsLen() == 0 && typedFieldsLen(itr.metric) == 0 {
+		return fmt.Errorf("simple field, compound field and summary field are all empty")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestRowIterator(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+	assert.NoError(t, bb.Commit())
+	rb.AddMetricName([]byte("latency"))
+	assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+	assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+	assert.NoError(t, bb.Commit())
+	rb.AddMetricName([]byte("rpc"))
+	assert.NoError(t, rb.AddSummaryField([]byte("latency"), 10, 20, []float64{0.5, 0.99}, []float64{1, 5}))
+	assert.NoError(t, bb.Commit())
+
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns", string(itr.Namespace()))
+	assert.Equal(t, "cpu", string(itr.Name()))
+	assert.Equal(t, int64(100), itr.Timestamp())
+	assert.NotZero(t, itr.NameHash())
+	assert.NotZero(t, itr.TagsHash())
+	assert.Equal(t, 1, itr.TagsLen())
+	key, value := itr.Tag(0)
+	assert.Equal(t, "host", string(key))
+	assert.Equal(t, "host1", string(value))
+	assert.Equal(t, 1, itr.SimpleFieldsLen())
+	name, fieldType, fieldValue := itr.SimpleField(0)
+	assert.Equal(t, "idle", string(name))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+	assert.Equal(t, float64(1), fieldValue)
+	assert.False(t, itr.SimpleFieldIsAbsent(0))
+	assert.Equal(t, 1, itr.ExemplarsLen())
+	exemplarName, traceID, spanID, duration := itr.Exemplar(0)
+	assert.Equal(t, "e", string(exemplarName))
+	assert.Equal(t, "trace", string(traceID))
+	assert.Equal(t, "span", string(spanID))
+	assert.Equal(t, int64(10), duration)
+	assert.False(t, itr.HasCompoundField())
+	assert.NotNil(t, itr.Metric())
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "latency", string(itr.Name()))
+	assert.Zero(t, itr.SimpleFieldsLen())
+	assert.True(t, itr.HasCompoundField())
+	minValue, maxValue, sum, count := itr.CompoundFieldMMSC()
+	assert.Equal(t, []float64{1, 2, 3, 4}, []float64{minValue, maxValue, sum, count})
+	assert.Equal(t, 2, itr.CompoundFieldBucketsLen())
+	bound, bucket := itr.CompoundFieldBucket(1)
+	assert.True(t, math.IsInf(bound, 1))
+	assert.Equal(t, float64(2), bucket)
+	assert.Zero(t, itr.SummaryFieldsLen())
+
+	assert.True(t, itr.Next())
+	assert.Equal(t, "rpc", string(itr.Name()))
+	assert.False(t, itr.HasCompoundField())
+	assert.Equal(t, 1, itr.SummaryFieldsLen())
+	summaryName, count, sum, quantiles := itr.SummaryField(0)
+	assert.Equal(t, "latency", string(summaryName))
+	assert.Equal(t, []float64{10, 20}, []float64{count, sum})
+	assert.Equal(t, 2, quantiles)
+	quantile, quantileValue := itr.SummaryFieldQuantile(1)
+	assert.Equal(t, []float64{0.99, 5}, []float64{quantile, quantileValue})
+
+	assert.False(t, itr.Next())
+	assert.NoError(t, itr.Err())
+}
+
+func TestRowIterator_Invalid(t *testing.T) {
+	// corrupted framing
+	itr := NewRowIterator([]byte{1, 2})
+	assert.False(t, itr.Next())
+	assert.Error(t, itr.Err())
+
+	// corrupted body
+	rb := CreateRowBuilder()
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	corrupted := append([]byte{}, data...)
+	for i := 8; i < len(corrupted); i++ {
+		corrupted[i] = 0xff
+	}
+	itr = NewRowIterator(corrupted)
+	assert.False(t, itr.Next())
+	assert.Error(t, itr.Err())
+
+	// metric without fields
+	builder := CreateRowBuilder().flatBuilder
+	name := builder.CreateString("cpu")
+	flatMetricsV1.MetricStart(builder)
+	flatMetricsV1.MetricAddName(builder, name)
+	builder.FinishSizePrefixed(flatMetricsV1.MetricEnd(builder))
+	itr = NewRowIterator(builder.FinishedBytes())
+	assert.False(t, itr.Next())
+	assert.Error(t, itr.Err())
+
+	// summary field without name
+	builder.Reset()
+	name = builder.CreateString("cpu")
+	flatMetricsV1.SummaryFieldStart(builder)
+	summary := flatMetricsV1.SummaryFieldEnd(builder)
+	flatMetricsV1.MetricStartSummaryFieldsVector(builder, 1)
+	builder.PrependUOffsetT(summary)
+	summaries := builder.EndVector(1)
+	flatMetricsV1.MetricStart(builder)
+	flatMetricsV1.MetricAddName(builder, name)
+	flatMetricsV1.MetricAddSummaryFields(builder, summaries)
+	builder.FinishSizePrefixed(flatMetricsV1.MetricEnd(builder))
+	itr = NewRowIterator(builder.FinishedBytes())
+	assert.False(t, itr.Next())
+	assert.Error(t, itr.Err())
+
+	// metric without name
+	builder.Reset()
+	flatMetricsV1.MetricStart(builder)
+	builder.FinishSizePrefixed(flatMetricsV1.MetricEnd(builder))
+	itr = NewRowIterator(builder.FinishedBytes())
+	assert.False(t, itr.Next())
+	assert.Error(t, itr.Err())
+}
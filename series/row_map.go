@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// FromMap fills the row with metric name, tags, simple fields and timestamp of the generic maps,
+// which is the convenience entry point for ingestion handlers receiving dynamic(e.g. json) documents.
+// The type of field is looked up in fieldTypes, Last is used if not found.
+// Fields are added in the order of name, so that the built row is deterministic.
+// Return error if any tag or field is invalid, the row should be reset.
+func (rb *RowBuilder) FromMap(
+	name string,
+	tags map[string]string,
+	fields map[string]float64,
+	fieldTypes map[string]flatMetricsV1.SimpleFieldType,
+	ts int64,
+) error {
+	if name == "" {
+		return fmt.Errorf("metric-name is empty")
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("fields of metric: %s are empty", name)
+	}
+	rb.AddMetricName([]byte(name))
+	rb.AddTimestamp(ts)
+	if err := rb.AddTags(tags); err != nil {
+		return err
+	}
+	fieldNames := make([]string, 0, len(fields))
+	for fieldName := range fields {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		fieldType, ok := fieldTypes[fieldName]
+		if !ok || fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+			fieldType = flatMetricsV1.SimpleFieldTypeLast
+		}
+		if err := rb.AddSimpleField([]byte(fieldName), fieldType, fields[fieldName]); err != nil {
+			return fmt.Errorf("invalid field: %s of metric: %s, %w", fieldName, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestRowBuilder_FromMap(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	assert.NoError(t, rb.FromMap("cpu",
+		map[string]string{"host": "h1", "az": "a"},
+		map[string]float64{"usage": 1, "idle": 2, "count": 3},
+		map[string]flatMetricsV1.SimpleFieldType{
+			"count": flatMetricsV1.SimpleFieldTypeDeltaSum,
+			"idle":  flatMetricsV1.SimpleFieldTypeUnSpecified,
+			"other": flatMetricsV1.SimpleFieldTypeMax,
+		},
+		100))
+	assert.NoError(t, bb.Commit())
+	// nil tags/types
+	assert.NoError(t, rb.FromMap("mem", nil, map[string]float64{"used": 1}, nil, 100))
+	assert.NoError(t, bb.Commit())
+
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "cpu{az=a,host=h1} 100 count(DeltaSum)=3 idle(Last)=2 usage(Last)=1", itr.Row().String())
+	assert.True(t, itr.Next())
+	assert.Equal(t, "mem{} 100 used(Last)=1", itr.Row().String())
+	assert.False(t, itr.Next())
+}
+
+func TestRowBuilder_FromMap_Invalid(t *testing.T) {
+	rb := CreateRowBuilder()
+	assert.Error(t, rb.FromMap("", nil, map[string]float64{"f": 1}, nil, 100))
+	assert.Error(t, rb.FromMap("cpu", nil, nil, nil, 100))
+	rb.Reset()
+	assert.Error(t, rb.FromMap("cpu", map[string]string{"": "v"}, map[string]float64{"f": 1}, nil, 100))
+	rb.Reset()
+	err := rb.FromMap("cpu", nil, map[string]float64{"f": math.NaN()}, nil, 100)
+	assert.ErrorContains(t, err, "invalid field: f of metric: cpu")
+	rb.Reset()
+	assert.Error(t, rb.FromMap("cpu", nil, map[string]float64{"": 1}, nil, 100))
+}
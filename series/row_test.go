@@ -0,0 +1,75 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestRow(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1.5))
+	assert.NoError(t, rb.AddAbsentSimpleField([]byte("usage"), flatMetricsV1.SimpleFieldTypeLast))
+	assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+	assert.NoError(t, bb.Commit())
+	rb.AddMetricName([]byte("latency"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+	assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+	assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 20, []float64{0.5, 0.99}, []float64{1, 5}))
+	assert.NoError(t, bb.Commit())
+
+	itr := NewRowIterator(bb.Payload())
+	assert.True(t, itr.Next())
+	row := itr.Row()
+	assert.Equal(t, "ns:cpu{host=host1} 100 idle(Last)=1.5 usage(Last)=absent exemplar{name=e,trace=trace,span=span,duration=10}", row.String())
+	data, err := json.Marshal(row)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"namespace":"ns","name":"cpu","timestamp":100,
+		"tags":[{"key":"host","value":"host1"}],
+		"simpleFields":[{"name":"idle","type":"Last","value":1.5},{"name":"usage","type":"Last","value":0,"absent":true}],
+		"exemplars":[{"name":"e","traceId":"trace","spanId":"span","duration":10}]}`, string(data))
+
+	assert.True(t, itr.Next())
+	row = itr.Row()
+	assert.Equal(t, "latency{} 100 histogram{min=1,max=2,sum=3,count=4,buckets=[1:1,+Inf:2]}"+
+		" rpc{sum=20,count=10,quantiles=[0.5:1,0.99:5]}", row.String())
+	data, err = row.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"namespace":"","name":"latency","timestamp":100,
+		"compoundField":{"min":1,"max":2,"sum":3,"count":4,"buckets":[{"bound":1,"value":1},{"bound":"+Inf","value":2}]},
+		"summaryFields":[{"name":"rpc","count":10,"sum":20,"quantiles":[{"quantile":0.5,"value":1},{"quantile":0.99,"value":5}]}]}`,
+		string(data))
+	assert.False(t, itr.Next())
+
+	// NaN
+	data, err = json.Marshal(jsonFloat64(math.NaN()))
+	assert.NoError(t, err)
+	assert.Equal(t, `"NaN"`, string(data))
+}
@@ -0,0 +1,160 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidChar represents the name contains invalid char and is rejected by sanitizer.
+var ErrInvalidChar = errors.New("invalid char")
+
+// SanitizeTarget represents which part of row is sanitized.
+type SanitizeTarget uint8
+
+const (
+	SanitizeTargetNamespace SanitizeTarget = iota + 1
+	SanitizeTargetMetricName
+	SanitizeTargetTagKey
+	SanitizeTargetTagValue
+)
+
+// String returns the string value of sanitize target.
+func (t SanitizeTarget) String() string {
+	switch t {
+	case SanitizeTargetNamespace:
+		return "namespace"
+	case SanitizeTargetMetricName:
+		return "metric name"
+	case SanitizeTargetTagKey:
+		return "tag key"
+	case SanitizeTargetTagValue:
+		return "tag value"
+	default:
+		return "unknown"
+	}
+}
+
+// Sanitizer sanitizes the namespace/metric name/tags of row.
+type Sanitizer interface {
+	// Sanitize appends the sanitized src into dst, returns error if src is rejected.
+	Sanitize(target SanitizeTarget, dst, src []byte) ([]byte, error)
+}
+
+// SanitizerOptions represents the options of sanitizer.
+type SanitizerOptions struct {
+	// EscapeChars are the ascii chars which are invalid, e.g. "|".
+	EscapeChars string
+	// Replacement replaces the invalid chars, default '_'.
+	Replacement byte
+	// AllowUTF8 allows non-ascii utf8 chars, invalid utf8 sequence is always invalid.
+	AllowUTF8 bool
+	// Reject rejects the row with invalid chars instead of rewriting.
+	Reject bool
+	// Targets are parts of row to be sanitized, default namespace and metric name.
+	Targets []SanitizeTarget
+}
+
+// DefaultSanitizer replaces '|' of namespace and metric name with '_', utf8 chars are allowed.
+var DefaultSanitizer = NewSanitizer(SanitizerOptions{EscapeChars: "|", AllowUTF8: true})
+
+// charSanitizer implements Sanitizer interface based on the options.
+type charSanitizer struct {
+	escape      [utf8.RuneSelf]bool
+	replacement byte
+	allowUTF8   bool
+	reject      bool
+	targets     [SanitizeTargetTagValue + 1]bool
+}
+
+// NewSanitizer creates a sanitizer with options.
+func NewSanitizer(options SanitizerOptions) Sanitizer {
+	s := &charSanitizer{
+		replacement: options.Replacement,
+		allowUTF8:   options.AllowUTF8,
+		reject:      options.Reject,
+	}
+	if s.replacement == 0 {
+		s.replacement = '_'
+	}
+	for _, c := range []byte(options.EscapeChars) {
+		if c < utf8.RuneSelf {
+			s.escape[c] = true
+		}
+	}
+	targets := options.Targets
+	if len(targets) == 0 {
+		targets = []SanitizeTarget{SanitizeTargetNamespace, SanitizeTargetMetricName}
+	}
+	for _, target := range targets {
+		if target <= SanitizeTargetTagValue {
+			s.targets[target] = true
+		}
+	}
+	return s
+}
+
+// Sanitize appends the sanitized src into dst, returns error if src is rejected.
+func (s *charSanitizer) Sanitize(target SanitizeTarget, dst, src []byte) ([]byte, error) {
+	if target > SanitizeTargetTagValue || !s.targets[target] || s.isValid(src) {
+		return append(dst, src...), nil
+	}
+	if s.reject {
+		return dst, fmt.Errorf("%w: %s[%s]", ErrInvalidChar, target, strings.ToValidUTF8(string(src), "?"))
+	}
+	for len(src) > 0 {
+		c := src[0]
+		if c < utf8.RuneSelf {
+			if s.escape[c] {
+				c = s.replacement
+			}
+			dst = append(dst, c)
+			src = src[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(src)
+		if r == utf8.RuneError || !s.allowUTF8 {
+			dst = append(dst, s.replacement)
+		} else {
+			dst = append(dst, src[:size]...)
+		}
+		src = src[size:]
+	}
+	return dst, nil
+}
+
+// isValid checks if the name has no invalid chars(fast path).
+func (s *charSanitizer) isValid(src []byte) bool {
+	hasUTF8 := false
+	for _, c := range src {
+		if c >= utf8.RuneSelf {
+			if !s.allowUTF8 {
+				return false
+			}
+			hasUTF8 = true
+			continue
+		}
+		if s.escape[c] {
+			return false
+		}
+	}
+	return !hasUTF8 || utf8.Valid(src)
+}
@@ -0,0 +1,120 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestSanitizeTarget_String(t *testing.T) {
+	assert.Equal(t, "namespace", SanitizeTargetNamespace.String())
+	assert.Equal(t, "metric name", SanitizeTargetMetricName.String())
+	assert.Equal(t, "tag key", SanitizeTargetTagKey.String())
+	assert.Equal(t, "tag value", SanitizeTargetTagValue.String())
+	assert.Equal(t, "unknown", SanitizeTarget(0).String())
+}
+
+func TestDefaultSanitizer(t *testing.T) {
+	sanitize := func(s Sanitizer, target SanitizeTarget, src string) string {
+		dst, err := s.Sanitize(target, nil, []byte(src))
+		assert.NoError(t, err)
+		return string(dst)
+	}
+	assert.Equal(t, "a_b", sanitize(DefaultSanitizer, SanitizeTargetMetricName, "a|b"))
+	assert.Equal(t, "a_b", sanitize(DefaultSanitizer, SanitizeTargetNamespace, "a|b"))
+	assert.Equal(t, "cpu.使用率", sanitize(DefaultSanitizer, SanitizeTargetMetricName, "cpu.使用率"))
+	assert.Equal(t, "cpu_使用率", sanitize(DefaultSanitizer, SanitizeTargetMetricName, "cpu|使用率"))
+	assert.Equal(t, "a_b_", sanitize(DefaultSanitizer, SanitizeTargetMetricName, "a\xffb|"))
+	// tags not sanitized by default
+	assert.Equal(t, "a|b", sanitize(DefaultSanitizer, SanitizeTargetTagKey, "a|b"))
+	assert.Equal(t, "a|b", sanitize(DefaultSanitizer, SanitizeTarget(10), "a|b"))
+}
+
+func TestNewSanitizer(t *testing.T) {
+	s := NewSanitizer(SanitizerOptions{
+		EscapeChars: "|, 中",
+		Replacement: '-',
+		Targets:     []SanitizeTarget{SanitizeTargetTagKey, SanitizeTargetTagValue, SanitizeTarget(10)},
+	})
+	dst, err := s.Sanitize(SanitizeTargetTagKey, []byte("x"), []byte("a b,c|使用率"))
+	assert.NoError(t, err)
+	assert.Equal(t, "xa-b-c----", string(dst))
+	dst, err = s.Sanitize(SanitizeTargetMetricName, nil, []byte("a b"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a b", string(dst))
+
+	s = NewSanitizer(SanitizerOptions{EscapeChars: "|", Reject: true, AllowUTF8: true})
+	_, err = s.Sanitize(SanitizeTargetMetricName, nil, []byte("使用率|"))
+	assert.ErrorIs(t, err, ErrInvalidChar)
+	assert.Equal(t, "invalid char: metric name[使用率|]", err.Error())
+	_, err = s.Sanitize(SanitizeTargetNamespace, nil, []byte("a\xff"))
+	assert.ErrorIs(t, err, ErrInvalidChar)
+	dst, err = s.Sanitize(SanitizeTargetNamespace, nil, []byte("使用率"))
+	assert.NoError(t, err)
+	assert.Equal(t, "使用率", string(dst))
+}
+
+func TestRowBuilder_Sanitizer(t *testing.T) {
+	rb := CreateRowBuilder()
+	rb.SetSanitizer(NewSanitizer(SanitizerOptions{
+		EscapeChars: "|",
+		Targets:     []SanitizeTarget{SanitizeTargetNamespace, SanitizeTargetMetricName, SanitizeTargetTagKey, SanitizeTargetTagValue},
+	}))
+	rb.AddNameSpace([]byte("ns|1"))
+	rb.AddMetricName([]byte("cpu|使用率"))
+	assert.NoError(t, rb.AddTag([]byte("host|name"), []byte("a|b")))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	itr := NewRowIterator(data)
+	assert.True(t, itr.Next())
+	assert.Equal(t, "ns_1", string(itr.Namespace()))
+	assert.Equal(t, "cpu____", string(itr.Name()))
+	key, value := itr.Tag(0)
+	assert.Equal(t, "host_name", string(key))
+	assert.Equal(t, "a_b", string(value))
+
+	// reject invalid rows
+	bb := NewBatchBuilder()
+	bb.SetSanitizer(NewSanitizer(SanitizerOptions{
+		EscapeChars: "|",
+		Reject:      true,
+		Targets:     []SanitizeTarget{SanitizeTargetNamespace, SanitizeTargetMetricName, SanitizeTargetTagKey, SanitizeTargetTagValue},
+	}))
+	rb = bb.RowBuilder()
+	assert.ErrorIs(t, rb.AddTag([]byte("a|"), []byte("b")), ErrInvalidChar)
+	assert.ErrorIs(t, rb.AddTag([]byte("a"), []byte("b|")), ErrInvalidChar)
+	rb.AddNameSpace([]byte("ns|"))
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.ErrorIs(t, bb.Commit(), ErrInvalidChar)
+	// error reset
+	rb.AddNameSpace([]byte("ns|"))
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu|"))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.ErrorIs(t, bb.Commit(), ErrInvalidChar)
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("f"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, bb.Commit())
+	assert.Equal(t, 1, bb.Rows())
+}
@@ -0,0 +1,131 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lindb/common/pkg/logger"
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// FieldTypeConflict represents a field which is reported with different field types.
+type FieldTypeConflict struct {
+	Namespace  string
+	MetricName string
+	FieldName  string
+	// Registered is the field type recorded firstly.
+	Registered flatMetricsV1.SimpleFieldType
+	// Conflicted is the field type which conflicts with the registered one.
+	Conflicted flatMetricsV1.SimpleFieldType
+}
+
+// Error returns the conflict message.
+func (c *FieldTypeConflict) Error() string {
+	return fmt.Sprintf("field type conflict, namespace: %s, metric: %s, field: %s, registered: %s, conflicted: %s",
+		c.Namespace, c.MetricName, c.FieldName, c.Registered, c.Conflicted)
+}
+
+// SchemaRegistry records field name=>field type for each metric in memory,
+// and flags type conflicts which are caused by client misconfiguration.
+type SchemaRegistry struct {
+	metrics map[string]map[string]flatMetricsV1.SimpleFieldType // namespace|metric => field name => field type
+	logger  logger.Logger
+
+	lock sync.RWMutex
+}
+
+// NewSchemaRegistry creates an in-memory schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		metrics: make(map[string]map[string]flatMetricsV1.SimpleFieldType),
+		logger:  logger.GetLogger("Series", "SchemaRegistry"),
+	}
+}
+
+// Check registers the field type if not exist, returns conflict if field type is different from the registered one.
+func (r *SchemaRegistry) Check(namespace, metricName, fieldName string, fieldType flatMetricsV1.SimpleFieldType) *FieldTypeConflict {
+	key := JoinNamespaceMetric(namespace, metricName)
+	r.lock.RLock()
+	registered, ok := r.metrics[key][fieldName]
+	r.lock.RUnlock()
+	if !ok {
+		r.lock.Lock()
+		fields, exist := r.metrics[key]
+		if !exist {
+			fields = make(map[string]flatMetricsV1.SimpleFieldType)
+			r.metrics[key] = fields
+		}
+		// double check, maybe registered by other goroutine
+		registered, ok = fields[fieldName]
+		if !ok {
+			fields[fieldName] = fieldType
+			registered = fieldType
+		}
+		r.lock.Unlock()
+	}
+	if registered == fieldType {
+		return nil
+	}
+	conflict := &FieldTypeConflict{
+		Namespace:  namespace,
+		MetricName: metricName,
+		FieldName:  fieldName,
+		Registered: registered,
+		Conflicted: fieldType,
+	}
+	r.logger.Warn("field type conflict",
+		logger.String("namespace", namespace),
+		logger.String("metric", metricName),
+		logger.String("field", fieldName),
+		logger.String("registered", registered.String()),
+		logger.String("conflicted", fieldType.String()),
+	)
+	return conflict
+}
+
+// CheckRow checks all simple fields of the row which is building, returns all type conflicts.
+func (r *SchemaRegistry) CheckRow(rb *RowBuilder) (conflicts []*FieldTypeConflict) {
+	namespace := string(rb.nameSpace)
+	metricName := string(rb.metricName)
+	for i := 0; i < rb.simpleFieldCount; i++ {
+		field := &rb.simpleFields[i]
+		if conflict := r.Check(namespace, metricName, string(field.name), field.fType); conflict != nil {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// FieldType returns the registered field type of the metric field.
+func (r *SchemaRegistry) FieldType(namespace, metricName, fieldName string) (flatMetricsV1.SimpleFieldType, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	fieldType, ok := r.metrics[JoinNamespaceMetric(namespace, metricName)][fieldName]
+	return fieldType, ok
+}
+
+// Reset removes all registered schemas.
+func (r *SchemaRegistry) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.metrics = make(map[string]map[string]flatMetricsV1.SimpleFieldType)
+}
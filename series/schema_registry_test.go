@@ -0,0 +1,67 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := NewSchemaRegistry()
+	assert.Nil(t, registry.Check("ns", "cpu", "idle", flatMetricsV1.SimpleFieldTypeLast))
+	assert.Nil(t, registry.Check("ns", "cpu", "idle", flatMetricsV1.SimpleFieldTypeLast))
+	// same field name of other metric
+	assert.Nil(t, registry.Check("ns", "mem", "idle", flatMetricsV1.SimpleFieldTypeDeltaSum))
+
+	conflict := registry.Check("ns", "cpu", "idle", flatMetricsV1.SimpleFieldTypeDeltaSum)
+	assert.NotNil(t, conflict)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, conflict.Registered)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, conflict.Conflicted)
+	assert.NotEmpty(t, conflict.Error())
+
+	fieldType, ok := registry.FieldType("ns", "cpu", "idle")
+	assert.True(t, ok)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, fieldType)
+
+	registry.Reset()
+	_, ok = registry.FieldType("ns", "cpu", "idle")
+	assert.False(t, ok)
+}
+
+func TestSchemaRegistry_CheckRow(t *testing.T) {
+	registry := NewSchemaRegistry()
+	rb := CreateRowBuilder()
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("f2"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.Empty(t, registry.CheckRow(rb))
+
+	rb.Reset()
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	assert.NoError(t, rb.AddSimpleField([]byte("f2"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	conflicts := registry.CheckRow(rb)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "f1", conflicts[0].FieldName)
+}
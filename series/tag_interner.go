@@ -0,0 +1,149 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// tagInternerShards is the number of lock stripes, must be power of 2.
+	tagInternerShards = 32
+	// defaultTagInternerMaxLen is the max length of interned string,
+	// long values(e.g. trace id) are unlikely to be shared.
+	defaultTagInternerMaxLen = 128
+)
+
+// DefaultTagInterner is the tag interner shared by all row builders which enable interning.
+var DefaultTagInterner = NewTagInterner(64*1024, defaultTagInternerMaxLen)
+
+// TagInternerStats represents the statistics of tag interner.
+type TagInternerStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// tagInternerShard is a lock stripe of tag interner.
+type tagInternerShard struct {
+	items map[string][]byte
+	lock  sync.RWMutex
+}
+
+// TagInterner is a thread-safe, size-bounded and lock-striped cache of common tag keys/values(host, region etc.),
+// which is shared across row builders to avoid converting same string into bytes repeatedly.
+// The returned bytes are shared, they MUST NOT be modified.
+type TagInterner struct {
+	shards          [tagInternerShards]tagInternerShard
+	maxShardEntries int
+	maxLen          int
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// NewTagInterner creates a tag interner which keeps at most maxEntries strings(with length <= maxLen).
+func NewTagInterner(maxEntries, maxLen int) *TagInterner {
+	maxShardEntries := maxEntries / tagInternerShards
+	if maxShardEntries <= 0 {
+		maxShardEntries = 1
+	}
+	interner := &TagInterner{
+		maxShardEntries: maxShardEntries,
+		maxLen:          maxLen,
+	}
+	for idx := range interner.shards {
+		interner.shards[idx].items = make(map[string][]byte)
+	}
+	return interner
+}
+
+// Intern returns the shared bytes of the string.
+func (in *TagInterner) Intern(s string) []byte {
+	if len(s) > in.maxLen {
+		return []byte(s)
+	}
+	shard := &in.shards[xxhash.Sum64String(s)&(tagInternerShards-1)]
+	shard.lock.RLock()
+	b, ok := shard.items[s]
+	shard.lock.RUnlock()
+	if ok {
+		in.hits.Add(1)
+		return b
+	}
+	return in.store(shard, s)
+}
+
+// InternBytes returns the shared bytes which equal to b.
+func (in *TagInterner) InternBytes(b []byte) []byte {
+	if len(b) > in.maxLen {
+		return b
+	}
+	shard := &in.shards[xxhash.Sum64(b)&(tagInternerShards-1)]
+	shard.lock.RLock()
+	rs, ok := shard.items[string(b)] // no allocation for map lookup
+	shard.lock.RUnlock()
+	if ok {
+		in.hits.Add(1)
+		return rs
+	}
+	return in.store(shard, string(b))
+}
+
+// store puts the string into shard, evicts an arbitrary entry if shard is full.
+func (in *TagInterner) store(shard *tagInternerShard, s string) []byte {
+	in.misses.Add(1)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	if b, ok := shard.items[s]; ok {
+		// stored by other goroutine
+		return b
+	}
+	if len(shard.items) >= in.maxShardEntries {
+		for key := range shard.items {
+			delete(shard.items, key)
+			in.evictions.Add(1)
+			break
+		}
+	}
+	b := []byte(s)
+	shard.items[s] = b
+	return b
+}
+
+// Stats returns the statistics of tag interner.
+func (in *TagInterner) Stats() TagInternerStats {
+	stats := TagInternerStats{
+		Hits:      in.hits.Load(),
+		Misses:    in.misses.Load(),
+		Evictions: in.evictions.Load(),
+	}
+	for idx := range in.shards {
+		shard := &in.shards[idx]
+		shard.lock.RLock()
+		stats.Entries += len(shard.items)
+		shard.lock.RUnlock()
+	}
+	return stats
+}
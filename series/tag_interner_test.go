@@ -0,0 +1,101 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestTagInterner(t *testing.T) {
+	interner := NewTagInterner(1024, 8)
+	b1 := interner.Intern("host")
+	b2 := interner.Intern("host")
+	b3 := interner.InternBytes([]byte("host"))
+	assert.Equal(t, "host", string(b1))
+	assert.Same(t, &b1[0], &b2[0])
+	assert.Same(t, &b1[0], &b3[0])
+	b4 := interner.InternBytes([]byte("region"))
+	assert.Equal(t, "region", string(b4))
+	assert.Same(t, &b4[0], &interner.Intern("region")[0])
+	// too long, not interned
+	assert.Equal(t, "long-value", string(interner.Intern("long-value")))
+	assert.Equal(t, "long-value", string(interner.InternBytes([]byte("long-value"))))
+	assert.Equal(t, TagInternerStats{Hits: 3, Misses: 2, Entries: 2}, interner.Stats())
+}
+
+func TestTagInterner_Bounded(t *testing.T) {
+	interner := NewTagInterner(0, 16)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, strconv.Itoa(i), string(interner.Intern(strconv.Itoa(i))))
+	}
+	stats := interner.Stats()
+	assert.LessOrEqual(t, stats.Entries, tagInternerShards)
+	assert.Equal(t, uint64(1000-stats.Entries), stats.Evictions)
+}
+
+func TestTagInterner_Concurrent(t *testing.T) {
+	interner := NewTagInterner(128, 16)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				s := "host-" + strconv.Itoa(j%200)
+				assert.Equal(t, s, string(interner.Intern(s)))
+				assert.Equal(t, s, string(interner.InternBytes([]byte(s))))
+			}
+		}()
+	}
+	wg.Wait()
+	stats := interner.Stats()
+	assert.Equal(t, uint64(16000), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Entries, 128)
+}
+
+func TestRowBuilder_TagInterner(t *testing.T) {
+	build := func(interner *TagInterner) []byte {
+		bb := NewBatchBuilder()
+		bb.SetTagInterner(interner)
+		rb := bb.RowBuilder()
+		rb.AddMetricName([]byte("cpu"))
+		assert.NoError(t, rb.AddTagString("host", "host1"))
+		assert.NoError(t, rb.AddTags(map[string]string{"region": "sh"}))
+		assert.Error(t, rb.AddTagString("", "empty"))
+		assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+		assert.NoError(t, bb.Commit())
+		return bb.Payload()
+	}
+	interner := NewTagInterner(1024, 16)
+	assert.Equal(t, build(nil), build(interner))
+	assert.Equal(t, build(interner), build(interner))
+	assert.Equal(t, 6, interner.Stats().Entries)
+
+	itr := NewRowIterator(build(interner))
+	assert.True(t, itr.Next())
+	assert.Equal(t, 2, itr.TagsLen())
+	key, value := itr.Tag(1)
+	assert.Equal(t, "region", string(key))
+	assert.Equal(t, "sh", string(value))
+}
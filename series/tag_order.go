@@ -0,0 +1,64 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// WithTagOrder records the original tag insertion order(including duplicated tags) into each row for debugging,
+// which is surfaced by RowIterator.OriginalTag and the decoded Row, so that operators can trace which agent sent
+// out-of-order or duplicated tags during ingestion incidents. Global tags are not recorded.
+// The series identity is not changed, but the row size grows, so it should only be enabled when debugging.
+func WithTagOrder() RowBuilderOption {
+	return func(rb *RowBuilder) {
+		rb.tagOrder = true
+	}
+}
+
+// buildOriginalTags writes the tags in insertion order into flat builder, returns the offset of tags vector,
+// must be invoked before tags are sorted and deduplicated.
+func (rb *RowBuilder) buildOriginalTags() flatbuffers.UOffsetT {
+	count := rb.rowKVs.kvCount
+	for i := 0; i < count; i++ {
+		key := rb.createByteString(rb.rowKVs.kvs[i].key)
+		value := rb.createByteString(rb.rowKVs.kvs[i].value)
+		flatMetricsV1.KeyValueStart(rb.flatBuilder)
+		flatMetricsV1.KeyValueAddKey(rb.flatBuilder, key)
+		flatMetricsV1.KeyValueAddValue(rb.flatBuilder, value)
+		rb.kvs = append(rb.kvs, flatMetricsV1.KeyValueEnd(rb.flatBuilder))
+	}
+	flatMetricsV1.MetricStartOriginalTagsVector(rb.flatBuilder, count)
+	for i := count - 1; i >= 0; i-- {
+		rb.flatBuilder.PrependUOffsetT(rb.kvs[i])
+	}
+	// reuse the offsets buffer for sorted tags
+	rb.kvs = rb.kvs[:0]
+	return rb.flatBuilder.EndVector(count)
+}
+
+// OriginalTagsLen returns the number of tags in original insertion order, 0 if not recorded(see WithTagOrder).
+func (itr *RowIterator) OriginalTagsLen() int { return itr.metric.OriginalTagsLength() }
+
+// OriginalTag returns the tag key/value at index of original insertion order.
+func (itr *RowIterator) OriginalTag(idx int) (key, value []byte) {
+	itr.metric.OriginalTags(&itr.kv, idx)
+	return itr.kv.Key(), itr.kv.Value()
+}
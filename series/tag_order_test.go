@@ -0,0 +1,87 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func buildTagOrderRow(t *testing.T, rb *RowBuilder, v2 bool) {
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("ip"), []byte("1.1.1.1")))
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("a")))
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("b")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	if v2 {
+		assert.NoError(t, rb.AddStringField([]byte("state"), []byte("running")))
+	}
+}
+
+func TestWithTagOrder(t *testing.T) {
+	for _, v2 := range []bool{false, true} {
+		bb := NewBatchBuilder(WithTagOrder(), WithGlobalTags(map[string]string{"pod": "broker-0"}))
+		buildTagOrderRow(t, bb.RowBuilder(), v2)
+		assert.NoError(t, bb.Commit())
+		expect := NewBatchBuilder(WithGlobalTags(map[string]string{"pod": "broker-0"}))
+		buildTagOrderRow(t, expect.RowBuilder(), v2)
+		assert.NoError(t, expect.Commit())
+
+		itr := NewRowIterator(expect.Payload())
+		assert.True(t, itr.Next())
+		hash := itr.TagsHash()
+		assert.Zero(t, itr.OriginalTagsLen())
+		assert.Empty(t, itr.Row().OriginalTags)
+
+		itr = NewRowIterator(bb.Payload())
+		assert.True(t, itr.Next())
+		// series identity isn't changed
+		assert.Equal(t, hash, itr.TagsHash())
+		assert.Equal(t, 3, itr.OriginalTagsLen())
+		key, value := itr.OriginalTag(2)
+		assert.Equal(t, "host", string(key))
+		assert.Equal(t, "b", string(value))
+
+		row := itr.Row()
+		assert.Equal(t, []Tag{{Key: "host", Value: "b"}, {Key: "ip", Value: "1.1.1.1"}, {Key: "pod", Value: "broker-0"}}, row.Tags)
+		assert.Equal(t, []Tag{{Key: "ip", Value: "1.1.1.1"}, {Key: "host", Value: "a"}, {Key: "host", Value: "b"}}, row.OriginalTags)
+		assert.Contains(t, row.String(), "ns:cpu{host=b,ip=1.1.1.1,pod=broker-0} 100 idle(Last)=1")
+		assert.Contains(t, row.String(), " original_tags{ip=1.1.1.1,host=a,host=b}")
+		data, err := json.Marshal(row)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"originalTags":[{"key":"ip","value":"1.1.1.1"}`)
+	}
+}
+
+func TestWithTagOrder_NoTags(t *testing.T) {
+	rb := CreateRowBuilder(WithTagOrder())
+	rb.AddMetricName([]byte("cpu"))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	itr := NewRowIterator(data)
+	assert.True(t, itr.Next())
+	assert.Zero(t, itr.OriginalTagsLen())
+	assert.NotContains(t, itr.Row().String(), "original_tags")
+}
@@ -0,0 +1,481 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	"github.com/lindb/common/proto/gen/v2/flatMetricsV2"
+)
+
+// vtable slots of tables, same as generated code.
+const (
+	metricNamespaceSlot     = 0
+	metricNameSlot          = 1
+	metricTimestampSlot     = 2
+	metricNameHashSlot      = 3
+	metricKeyValuesSlot     = 4
+	metricKvsHashSlot       = 5
+	metricSimpleFieldsSlot  = 6
+	metricCompoundFieldSlot = 7
+	metricExemplarsSlot     = 8
+	metricSummaryFieldsSlot = 9
+	metricFieldsSlot        = 10 // v2 only
+
+	keyValueKeySlot   = 0
+	keyValueValueSlot = 1
+
+	simpleFieldNameSlot   = 0
+	simpleFieldTypeSlot   = 1
+	simpleFieldValueSlot  = 2
+	simpleFieldAbsentSlot = 3
+
+	compoundFieldMinSlot    = 0
+	compoundFieldMaxSlot    = 1
+	compoundFieldSumSlot    = 2
+	compoundFieldCountSlot  = 3
+	compoundFieldBoundsSlot = 4
+	compoundFieldValuesSlot = 5
+
+	exemplarNameSlot     = 0
+	exemplarSpanIDSlot   = 1
+	exemplarTraceIDSlot  = 2
+	exemplarDurationSlot = 3
+
+	summaryFieldNameSlot      = 0
+	summaryFieldCountSlot     = 1
+	summaryFieldSumSlot       = 2
+	summaryFieldQuantilesSlot = 3
+
+	quantileValueQuantileSlot = 0
+	quantileValueValueSlot    = 1
+
+	fieldNameSlot        = 0
+	fieldTypeSlot        = 1
+	fieldValueTypeSlot   = 2
+	fieldIntValueSlot    = 3
+	fieldStringValueSlot = 4
+)
+
+// Verify validates the batch payload(size prefixed v1/v2 rows without version marker) from untrusted input defensively,
+// all flatbuffers offsets/vector bounds are checked before accessing them, so it never panics.
+// It also checks the invariants of metric:
+//  1. names/tags are valid utf-8 and not empty;
+//  2. field values are not NaN/Inf(except +Inf bound of last histogram bucket);
+//  3. histogram bounds/quantiles are strictly increasing.
+func Verify(payload []byte) error {
+	return walkRows(payload, func(pos, end int) error {
+		if err := verifyRow(payload[pos:end]); err != nil {
+			return fmt.Errorf("invalid metric at: %d, %w", pos, err)
+		}
+		return nil
+	})
+}
+
+// verifyRow verifies the flat metric with size prefix.
+func verifyRow(buf []byte) error {
+	v := &verifier{buf: buf}
+	root, err := v.offset(flatbuffers.SizeUint32)
+	if err != nil {
+		return err
+	}
+	metric, err := v.table(root)
+	if err != nil {
+		return err
+	}
+	namespace, err := metric.str(metricNamespaceSlot)
+	if err != nil {
+		return fmt.Errorf("namespace %w", err)
+	}
+	if !utf8.Valid(namespace) {
+		return fmt.Errorf("namespace is not valid utf-8")
+	}
+	if err = metric.nonEmptyStr(metricNameSlot, "metric-name"); err != nil {
+		return err
+	}
+	for _, slot := range []int{metricTimestampSlot, metricNameHashSlot, metricKvsHashSlot} {
+		if _, err = metric.scalar(slot, flatbuffers.SizeInt64); err != nil {
+			return err
+		}
+	}
+	if err = metric.tables(metricKeyValuesSlot, verifyKeyValue); err != nil {
+		return err
+	}
+	if err = metric.tables(metricSimpleFieldsSlot, verifySimpleField); err != nil {
+		return err
+	}
+	compoundPos, hasCompound, err := metric.offsetField(metricCompoundFieldSlot)
+	if err != nil {
+		return err
+	}
+	if hasCompound {
+		var compound *tableVerifier
+		if compound, err = v.table(compoundPos); err != nil {
+			return fmt.Errorf("compound field %w", err)
+		}
+		if err = verifyCompoundField(compound); err != nil {
+			return err
+		}
+	}
+	if err = metric.tables(metricExemplarsSlot, verifyExemplar); err != nil {
+		return err
+	}
+	if err = metric.tables(metricSummaryFieldsSlot, verifySummaryField); err != nil {
+		return err
+	}
+	if err = metric.tables(metricFieldsSlot, verifyTypedField); err != nil {
+		return err
+	}
+	simpleFields, _ := metric.tablesLen(metricSimpleFieldsSlot)
+	summaryFields, _ := metric.tablesLen(metricSummaryFieldsSlot)
+	typedFields, _ := metric.tablesLen(metricFieldsSlot)
+	if simpleFields == 0 && !hasCompound && summaryFields == 0 && typedFields == 0 {
+		return fmt.Errorf("simple field, compound field and summary field are all empty")
+	}
+	return nil
+}
+
+// verifyKeyValue verifies the tag.
+func verifyKeyValue(t *tableVerifier) error {
+	if err := t.nonEmptyStr(keyValueKeySlot, "tag key"); err != nil {
+		return err
+	}
+	return t.nonEmptyStr(keyValueValueSlot, "tag value")
+}
+
+// verifySimpleField verifies the simple field.
+func verifySimpleField(t *tableVerifier) error {
+	if err := t.nonEmptyStr(simpleFieldNameSlot, "fieldName"); err != nil {
+		return err
+	}
+	pos, err := t.scalar(simpleFieldTypeSlot, flatbuffers.SizeInt8)
+	if err != nil {
+		return err
+	}
+	if pos > 0 {
+		fieldType := flatMetricsV1.SimpleFieldType(int8(t.v.buf[pos]))
+		if _, ok := flatMetricsV1.EnumNamesSimpleFieldType[fieldType]; !ok {
+			return fmt.Errorf("unknown simple field type: %d", fieldType)
+		}
+	}
+	if _, err = t.scalar(simpleFieldAbsentSlot, flatbuffers.SizeBool); err != nil {
+		return err
+	}
+	return t.finite(simpleFieldValueSlot, "simple field value")
+}
+
+// verifyTypedField verifies the int64/string field of v2 row.
+func verifyTypedField(t *tableVerifier) error {
+	if err := t.nonEmptyStr(fieldNameSlot, "fieldName"); err != nil {
+		return err
+	}
+	pos, err := t.scalar(fieldTypeSlot, flatbuffers.SizeInt8)
+	if err != nil {
+		return err
+	}
+	if pos > 0 {
+		fieldType := flatMetricsV1.SimpleFieldType(int8(t.v.buf[pos]))
+		if _, ok := flatMetricsV1.EnumNamesSimpleFieldType[fieldType]; !ok {
+			return fmt.Errorf("unknown simple field type: %d", fieldType)
+		}
+	}
+	if pos, err = t.scalar(fieldValueTypeSlot, flatbuffers.SizeInt8); err != nil {
+		return err
+	}
+	valueType := flatMetricsV2.ValueTypeInt64
+	if pos > 0 {
+		valueType = flatMetricsV2.ValueType(int8(t.v.buf[pos]))
+	}
+	if _, ok := flatMetricsV2.EnumNamesValueType[valueType]; !ok {
+		return fmt.Errorf("unknown value type: %d", valueType)
+	}
+	if _, err = t.scalar(fieldIntValueSlot, flatbuffers.SizeInt64); err != nil {
+		return err
+	}
+	value, err := t.str(fieldStringValueSlot)
+	if err != nil {
+		return fmt.Errorf("string value %w", err)
+	}
+	if !utf8.Valid(value) {
+		return fmt.Errorf("string value is not valid utf-8")
+	}
+	return nil
+}
+
+// verifyCompoundField verifies the histogram field.
+func verifyCompoundField(t *tableVerifier) error {
+	for _, slot := range []int{compoundFieldMinSlot, compoundFieldMaxSlot, compoundFieldSumSlot, compoundFieldCountSlot} {
+		if err := t.finite(slot, "compound field value"); err != nil {
+			return err
+		}
+	}
+	boundsStart, bounds, err := t.vector(compoundFieldBoundsSlot, flatbuffers.SizeFloat64)
+	if err != nil {
+		return err
+	}
+	valuesStart, values, err := t.vector(compoundFieldValuesSlot, flatbuffers.SizeFloat64)
+	if err != nil {
+		return err
+	}
+	if bounds != values {
+		return fmt.Errorf("values's length: %d != explicit-bounds's length: %d", values, bounds)
+	}
+	prev := math.Inf(-1)
+	for i := 0; i < bounds; i++ {
+		bound := t.v.float64(boundsStart + i*flatbuffers.SizeFloat64)
+		if math.IsNaN(bound) || math.IsInf(bound, -1) || (math.IsInf(bound, 1) && i != bounds-1) {
+			return fmt.Errorf("explicit-bound: %v at: %d is invalid", bound, i)
+		}
+		if bound <= prev {
+			return fmt.Errorf("explicit-bounds are not strictly increasing at: %d", i)
+		}
+		prev = bound
+		if value := t.v.float64(valuesStart + i*flatbuffers.SizeFloat64); !isFinite(value) {
+			return fmt.Errorf("bucket value: %v at: %d is invalid", value, i)
+		}
+	}
+	return nil
+}
+
+// verifyExemplar verifies the exemplar.
+func verifyExemplar(t *tableVerifier) error {
+	for _, slot := range []int{exemplarNameSlot, exemplarSpanIDSlot, exemplarTraceIDSlot} {
+		s, err := t.str(slot)
+		if err != nil {
+			return fmt.Errorf("exemplar %w", err)
+		}
+		if !utf8.Valid(s) {
+			return fmt.Errorf("exemplar is not valid utf-8")
+		}
+	}
+	_, err := t.scalar(exemplarDurationSlot, flatbuffers.SizeInt64)
+	return err
+}
+
+// verifySummaryField verifies the summary field.
+func verifySummaryField(t *tableVerifier) error {
+	if err := t.nonEmptyStr(summaryFieldNameSlot, "fieldName"); err != nil {
+		return err
+	}
+	if err := t.finite(summaryFieldCountSlot, "summary count"); err != nil {
+		return err
+	}
+	if err := t.finite(summaryFieldSumSlot, "summary sum"); err != nil {
+		return err
+	}
+	prev := -1.0
+	return t.tables(summaryFieldQuantilesSlot, func(q *tableVerifier) error {
+		pos, err := q.scalar(quantileValueQuantileSlot, flatbuffers.SizeFloat64)
+		if err != nil {
+			return err
+		}
+		quantile := 0.0
+		if pos > 0 {
+			quantile = q.v.float64(pos)
+		}
+		if quantile < 0 || quantile > 1 || math.IsNaN(quantile) {
+			return fmt.Errorf("quantile: %v is not in [0, 1]", quantile)
+		}
+		if quantile <= prev {
+			return fmt.Errorf("quantiles are not strictly increasing")
+		}
+		prev = quantile
+		return q.finite(quantileValueValueSlot, "quantile value")
+	})
+}
+
+// verifier checks flatbuffers offsets against the buffer.
+type verifier struct {
+	buf []byte
+}
+
+// float64 reads float64 at pos which has been checked.
+func (v *verifier) float64(pos int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(v.buf[pos:]))
+}
+
+// inBounds returns if [pos, pos+size) is in the buffer.
+func (v *verifier) inBounds(pos, size int) bool {
+	return pos >= 0 && size >= 0 && pos <= len(v.buf) && size <= len(v.buf)-pos
+}
+
+// offset returns the position referenced by the uoffset at pos.
+func (v *verifier) offset(pos int) (int, error) {
+	if !v.inBounds(pos, flatbuffers.SizeUOffsetT) {
+		return 0, fmt.Errorf("offset at: %d is out of range", pos)
+	}
+	target := uint64(pos) + uint64(binary.LittleEndian.Uint32(v.buf[pos:]))
+	if target >= uint64(len(v.buf)) {
+		return 0, fmt.Errorf("offset at: %d points out of range", pos)
+	}
+	return int(target), nil
+}
+
+// table checks the table at pos and its vtable.
+func (v *verifier) table(pos int) (*tableVerifier, error) {
+	if !v.inBounds(pos, flatbuffers.SizeSOffsetT) {
+		return nil, fmt.Errorf("table at: %d is out of range", pos)
+	}
+	vtable := int64(pos) - int64(int32(binary.LittleEndian.Uint32(v.buf[pos:])))
+	if vtable < 0 || !v.inBounds(int(vtable), 2*flatbuffers.SizeVOffsetT) {
+		return nil, fmt.Errorf("vtable of table at: %d is out of range", pos)
+	}
+	vtableSize := int(binary.LittleEndian.Uint16(v.buf[vtable:]))
+	tableSize := int(binary.LittleEndian.Uint16(v.buf[vtable+flatbuffers.SizeVOffsetT:]))
+	if vtableSize < 2*flatbuffers.SizeVOffsetT || vtableSize%flatbuffers.SizeVOffsetT != 0 || !v.inBounds(int(vtable), vtableSize) {
+		return nil, fmt.Errorf("vtable size: %d of table at: %d is invalid", vtableSize, pos)
+	}
+	if tableSize < flatbuffers.SizeSOffsetT || !v.inBounds(pos, tableSize) {
+		return nil, fmt.Errorf("table size: %d of table at: %d is invalid", tableSize, pos)
+	}
+	return &tableVerifier{v: v, pos: pos, vtable: int(vtable), vtableSize: vtableSize, tableSize: tableSize}, nil
+}
+
+// tableVerifier checks the fields of a table.
+type tableVerifier struct {
+	v          *verifier
+	pos        int
+	vtable     int
+	vtableSize int
+	tableSize  int
+}
+
+// scalar returns the position of the field with given size, returns 0 if field not set.
+func (t *tableVerifier) scalar(slot, size int) (int, error) {
+	vo := (slot + 2) * flatbuffers.SizeVOffsetT
+	if vo >= t.vtableSize {
+		return 0, nil
+	}
+	off := int(binary.LittleEndian.Uint16(t.v.buf[t.vtable+vo:]))
+	if off == 0 {
+		return 0, nil
+	}
+	if off < flatbuffers.SizeSOffsetT || off+size > t.tableSize {
+		return 0, fmt.Errorf("field: %d of table at: %d is out of range", slot, t.pos)
+	}
+	return t.pos + off, nil
+}
+
+// finite checks the float64 field is not NaN/Inf.
+func (t *tableVerifier) finite(slot int, name string) error {
+	pos, err := t.scalar(slot, flatbuffers.SizeFloat64)
+	if err != nil {
+		return err
+	}
+	if pos > 0 {
+		if value := t.v.float64(pos); !isFinite(value) {
+			return fmt.Errorf("%s: %v is invalid", name, value)
+		}
+	}
+	return nil
+}
+
+// offsetField returns the position referenced by the offset field.
+func (t *tableVerifier) offsetField(slot int) (target int, ok bool, err error) {
+	pos, err := t.scalar(slot, flatbuffers.SizeUOffsetT)
+	if err != nil || pos == 0 {
+		return 0, false, err
+	}
+	target, err = t.v.offset(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	return target, true, nil
+}
+
+// vector returns the start position and length of the vector field.
+func (t *tableVerifier) vector(slot, elemSize int) (start, n int, err error) {
+	pos, ok, err := t.offsetField(slot)
+	if err != nil || !ok {
+		return 0, 0, err
+	}
+	if !t.v.inBounds(pos, flatbuffers.SizeUOffsetT) {
+		return 0, 0, fmt.Errorf("vector length at: %d is out of range", pos)
+	}
+	length := uint64(binary.LittleEndian.Uint32(t.v.buf[pos:]))
+	start = pos + flatbuffers.SizeUOffsetT
+	if length*uint64(elemSize) > uint64(len(t.v.buf)-start) {
+		return 0, 0, fmt.Errorf("vector at: %d with length: %d is out of range", pos, length)
+	}
+	return start, int(length), nil
+}
+
+// str returns the string field, returns nil if field not set.
+func (t *tableVerifier) str(slot int) ([]byte, error) {
+	start, n, err := t.vector(slot, 1)
+	if err != nil {
+		return nil, err
+	}
+	return t.v.buf[start : start+n], nil
+}
+
+// nonEmptyStr checks the string field is valid utf-8 and not empty.
+func (t *tableVerifier) nonEmptyStr(slot int, name string) error {
+	s, err := t.str(slot)
+	if err != nil {
+		return fmt.Errorf("%s %w", name, err)
+	}
+	if len(s) == 0 {
+		return fmt.Errorf("%s is empty", name)
+	}
+	if !utf8.Valid(s) {
+		return fmt.Errorf("%s is not valid utf-8", name)
+	}
+	return nil
+}
+
+// tablesLen returns the length of the vector of tables.
+func (t *tableVerifier) tablesLen(slot int) (int, error) {
+	_, n, err := t.vector(slot, flatbuffers.SizeUOffsetT)
+	return n, err
+}
+
+// tables verifies each table of the vector of tables.
+func (t *tableVerifier) tables(slot int, fn func(t *tableVerifier) error) error {
+	start, n, err := t.vector(slot, flatbuffers.SizeUOffsetT)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		var (
+			pos   int
+			table *tableVerifier
+		)
+		if pos, err = t.v.offset(start + i*flatbuffers.SizeUOffsetT); err != nil {
+			return err
+		}
+		if table, err = t.v.table(pos); err != nil {
+			return err
+		}
+		if err = fn(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFinite returns if the value is not NaN/Inf.
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,244 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// rawMetric builds flat metric without the validation of RowBuilder.
+type rawMetric struct {
+	name       string
+	field      string
+	fieldType  flatMetricsV1.SimpleFieldType
+	value      float64
+	bounds     []float64
+	values     []float64
+	quantiles  []float64
+	noSimple   bool
+	tagValue   string
+	exemplarID string
+}
+
+func (m rawMetric) build() []byte {
+	b := flatbuffers.NewBuilder(256)
+	name := b.CreateString(m.name)
+	var kvs flatbuffers.UOffsetT
+	if m.tagValue != "" {
+		key, value := b.CreateString("host"), b.CreateString(m.tagValue)
+		flatMetricsV1.KeyValueStart(b)
+		flatMetricsV1.KeyValueAddKey(b, key)
+		flatMetricsV1.KeyValueAddValue(b, value)
+		kv := flatMetricsV1.KeyValueEnd(b)
+		flatMetricsV1.MetricStartKeyValuesVector(b, 1)
+		b.PrependUOffsetT(kv)
+		kvs = b.EndVector(1)
+	}
+	var simpleFields flatbuffers.UOffsetT
+	if !m.noSimple {
+		fieldName := b.CreateString(m.field)
+		flatMetricsV1.SimpleFieldStart(b)
+		flatMetricsV1.SimpleFieldAddName(b, fieldName)
+		flatMetricsV1.SimpleFieldAddType(b, m.fieldType)
+		flatMetricsV1.SimpleFieldAddValue(b, m.value)
+		field := flatMetricsV1.SimpleFieldEnd(b)
+		flatMetricsV1.MetricStartSimpleFieldsVector(b, 1)
+		b.PrependUOffsetT(field)
+		simpleFields = b.EndVector(1)
+	}
+	var compound flatbuffers.UOffsetT
+	if m.bounds != nil {
+		flatMetricsV1.CompoundFieldStartExplicitBoundsVector(b, len(m.bounds))
+		for i := len(m.bounds) - 1; i >= 0; i-- {
+			b.PrependFloat64(m.bounds[i])
+		}
+		bounds := b.EndVector(len(m.bounds))
+		flatMetricsV1.CompoundFieldStartValuesVector(b, len(m.values))
+		for i := len(m.values) - 1; i >= 0; i-- {
+			b.PrependFloat64(m.values[i])
+		}
+		values := b.EndVector(len(m.values))
+		flatMetricsV1.CompoundFieldStart(b)
+		flatMetricsV1.CompoundFieldAddExplicitBounds(b, bounds)
+		flatMetricsV1.CompoundFieldAddValues(b, values)
+		compound = flatMetricsV1.CompoundFieldEnd(b)
+	}
+	var summaryFields flatbuffers.UOffsetT
+	if m.quantiles != nil {
+		offsets := make([]flatbuffers.UOffsetT, len(m.quantiles))
+		for i, q := range m.quantiles {
+			flatMetricsV1.QuantileValueStart(b)
+			flatMetricsV1.QuantileValueAddQuantile(b, q)
+			flatMetricsV1.QuantileValueAddValue(b, 1)
+			offsets[i] = flatMetricsV1.QuantileValueEnd(b)
+		}
+		flatMetricsV1.SummaryFieldStartQuantilesVector(b, len(offsets))
+		for i := len(offsets) - 1; i >= 0; i-- {
+			b.PrependUOffsetT(offsets[i])
+		}
+		quantiles := b.EndVector(len(offsets))
+		summaryName := b.CreateString("rpc")
+		flatMetricsV1.SummaryFieldStart(b)
+		flatMetricsV1.SummaryFieldAddName(b, summaryName)
+		flatMetricsV1.SummaryFieldAddQuantiles(b, quantiles)
+		summary := flatMetricsV1.SummaryFieldEnd(b)
+		flatMetricsV1.MetricStartSummaryFieldsVector(b, 1)
+		b.PrependUOffsetT(summary)
+		summaryFields = b.EndVector(1)
+	}
+	var exemplars flatbuffers.UOffsetT
+	if m.exemplarID != "" {
+		traceID := b.CreateString(m.exemplarID)
+		flatMetricsV1.ExemplarStart(b)
+		flatMetricsV1.ExemplarAddTraceId(b, traceID)
+		exemplar := flatMetricsV1.ExemplarEnd(b)
+		flatMetricsV1.MetricStartExemplarsVector(b, 1)
+		b.PrependUOffsetT(exemplar)
+		exemplars = b.EndVector(1)
+	}
+	flatMetricsV1.MetricStart(b)
+	flatMetricsV1.MetricAddName(b, name)
+	if kvs != 0 {
+		flatMetricsV1.MetricAddKeyValues(b, kvs)
+	}
+	if simpleFields != 0 {
+		flatMetricsV1.MetricAddSimpleFields(b, simpleFields)
+	}
+	if compound != 0 {
+		flatMetricsV1.MetricAddCompoundField(b, compound)
+	}
+	if summaryFields != 0 {
+		flatMetricsV1.MetricAddSummaryFields(b, summaryFields)
+	}
+	if exemplars != 0 {
+		flatMetricsV1.MetricAddExemplars(b, exemplars)
+	}
+	b.FinishSizePrefixed(flatMetricsV1.MetricEnd(b))
+	return b.FinishedBytes()
+}
+
+func TestVerify(t *testing.T) {
+	bb := NewBatchBuilder()
+	rb := bb.RowBuilder()
+	rb.AddNameSpace([]byte("ns"))
+	rb.AddMetricName([]byte("cpu"))
+	rb.AddTimestamp(100)
+	assert.NoError(t, rb.AddTag([]byte("host"), []byte("host1")))
+	assert.NoError(t, rb.AddSimpleField([]byte("idle"), flatMetricsV1.SimpleFieldTypeLast, 1))
+	assert.NoError(t, rb.AddExemplar([]byte("e"), []byte("trace"), []byte("span"), 10))
+	assert.NoError(t, bb.Commit())
+	rb.AddMetricName([]byte("latency"))
+	assert.NoError(t, rb.AddCompoundFieldMMSC(1, 2, 3, 4))
+	assert.NoError(t, rb.AddCompoundFieldData([]float64{1, 2}, []float64{1, math.Inf(1)}))
+	assert.NoError(t, rb.AddSummaryField([]byte("rpc"), 10, 20, []float64{0.5, 0.99}, []float64{1, 5}))
+	assert.NoError(t, bb.Commit())
+	payload := bb.Payload()
+
+	assert.NoError(t, Verify(nil))
+	assert.NoError(t, Verify(payload))
+	assert.NoError(t, Verify(rawMetric{name: "cpu", field: "f", fieldType: flatMetricsV1.SimpleFieldTypeLast}.build()))
+	// truncated framing
+	assert.Error(t, Verify(payload[:len(payload)-1]))
+	assert.Error(t, Verify(payload[:2]))
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	valid := rawMetric{name: "cpu", field: "f", fieldType: flatMetricsV1.SimpleFieldTypeLast, value: 1}
+	cases := []struct {
+		name   string
+		update func(m *rawMetric)
+		err    string
+	}{
+		{name: "empty metric name", update: func(m *rawMetric) { m.name = "" }, err: "metric-name is empty"},
+		{name: "invalid utf-8 name", update: func(m *rawMetric) { m.name = "cpu\xff" }, err: "metric-name is not valid utf-8"},
+		{name: "invalid utf-8 tag", update: func(m *rawMetric) { m.tagValue = "\xfe" }, err: "tag value is not valid utf-8"},
+		{name: "invalid utf-8 exemplar", update: func(m *rawMetric) { m.exemplarID = "\xfe" }, err: "exemplar is not valid utf-8"},
+		{name: "empty field name", update: func(m *rawMetric) { m.field = "" }, err: "fieldName is empty"},
+		{name: "unknown field type", update: func(m *rawMetric) { m.fieldType = 100 }, err: "unknown simple field type: 100"},
+		{name: "NaN value", update: func(m *rawMetric) { m.value = math.NaN() }, err: "simple field value: NaN is invalid"},
+		{name: "Inf value", update: func(m *rawMetric) { m.value = math.Inf(-1) }, err: "simple field value: -Inf is invalid"},
+		{name: "no fields", update: func(m *rawMetric) { m.noSimple = true }, err: "are all empty"},
+		{
+			name:   "bounds/values length mismatch",
+			update: func(m *rawMetric) { m.bounds, m.values = []float64{1, 2}, []float64{1} },
+			err:    "values's length: 1 != explicit-bounds's length: 2",
+		},
+		{
+			name:   "bounds not increasing",
+			update: func(m *rawMetric) { m.bounds, m.values = []float64{2, 1}, []float64{1, 1} },
+			err:    "explicit-bounds are not strictly increasing at: 1",
+		},
+		{
+			name:   "Inf bound not last",
+			update: func(m *rawMetric) { m.bounds, m.values = []float64{math.Inf(1), 1}, []float64{1, 1} },
+			err:    "explicit-bound: +Inf at: 0 is invalid",
+		},
+		{
+			name:   "NaN bucket value",
+			update: func(m *rawMetric) { m.bounds, m.values = []float64{1, math.Inf(1)}, []float64{1, math.NaN()} },
+			err:    "bucket value: NaN at: 1 is invalid",
+		},
+		{name: "quantile out of range", update: func(m *rawMetric) { m.quantiles = []float64{0.5, 1.5} }, err: "quantile: 1.5 is not in [0, 1]"},
+		{name: "quantile not increasing", update: func(m *rawMetric) { m.quantiles = []float64{0.9, 0.5} }, err: "quantiles are not strictly increasing"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := valid
+			tt.update(&m)
+			assert.ErrorContains(t, Verify(m.build()), tt.err)
+		})
+	}
+}
+
+func TestVerify_Corrupted(t *testing.T) {
+	payload := rawMetric{
+		name: "cpu", field: "f", fieldType: flatMetricsV1.SimpleFieldTypeLast, tagValue: "host1", exemplarID: "trace",
+		bounds: []float64{1, math.Inf(1)}, values: []float64{1, 2}, quantiles: []float64{0.5, 0.9},
+	}.build()
+	assert.NoError(t, Verify(payload))
+
+	// flip bytes randomly, Verify never panics, and rows passed verification can be iterated safely.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		data := append([]byte{}, payload...)
+		for j := 0; j < 1+r.Intn(4); j++ {
+			data[flatbuffers.SizeUint32+r.Intn(len(data)-flatbuffers.SizeUint32)] = byte(r.Intn(256))
+		}
+		assert.NotPanics(t, func() {
+			if Verify(data) == nil {
+				itr := NewRowIterator(data)
+				for itr.Next() {
+					_ = itr.Row()
+				}
+			}
+		})
+	}
+	// offsets point out of range
+	data := append([]byte{}, payload...)
+	data[flatbuffers.SizeUint32] = 0xff
+	data[flatbuffers.SizeUint32+1] = 0xff
+	assert.Error(t, Verify(data))
+}